@@ -55,6 +55,9 @@ type Routes struct {
 
 	PublicFile *mux.Router // 'files/{file_id:[A-Za-z0-9]+}/public'
 
+	Uploads *mux.Router // 'api/v4/uploads'
+	Upload  *mux.Router // 'api/v4/uploads/{upload_id:[A-Za-z0-9]+}'
+
 	Commands        *mux.Router // 'api/v4/commands'
 	Command         *mux.Router // 'api/v4/commands/{command_id:[A-Za-z0-9]+}'
 	CommandsForTeam *mux.Router // 'api/v4/teams/{team_id:[A-Za-z0-9]+}/commands'
@@ -93,6 +96,22 @@ type Routes struct {
 	ReactionByNameForPostForUser *mux.Router // 'api/v4/users/{user_id:[A-Za-z0-9]+}/posts/{post_id:[A-Za-z0-9]+}/reactions/{emoji_name:[A-Za-z0-9_-+]+}'
 
 	Webrtc *mux.Router // 'api/v4/webrtc'
+
+	Jobs *mux.Router // 'api/v4/jobs'
+
+	Dialogs *mux.Router // 'api/v4/actions/dialogs'
+
+	Plugins *mux.Router // 'api/v4/plugins'
+	Plugin  *mux.Router // 'api/v4/plugins/{plugin_id:[a-z0-9_.-]+}'
+
+	Bots *mux.Router // 'api/v4/bots'
+	Bot  *mux.Router // 'api/v4/bots/{bot_user_id:[A-Za-z0-9]+}'
+
+	Roles *mux.Router // 'api/v4/roles'
+	Role  *mux.Router // 'api/v4/roles/{role_id:[a-z0-9_]+}'
+
+	Schemes *mux.Router // 'api/v4/schemes'
+	Scheme  *mux.Router // 'api/v4/schemes/{scheme_id:[A-Za-z0-9]+}'
 }
 
 var BaseRoutes *Routes
@@ -141,6 +160,9 @@ func InitApi(full bool) {
 	BaseRoutes.File = BaseRoutes.Files.PathPrefix("/{file_id:[A-Za-z0-9]+}").Subrouter()
 	BaseRoutes.PublicFile = BaseRoutes.Root.PathPrefix("/files/{file_id:[A-Za-z0-9]+}/public").Subrouter()
 
+	BaseRoutes.Uploads = BaseRoutes.ApiRoot.PathPrefix("/uploads").Subrouter()
+	BaseRoutes.Upload = BaseRoutes.Uploads.PathPrefix("/{upload_id:[A-Za-z0-9]+}").Subrouter()
+
 	BaseRoutes.Commands = BaseRoutes.ApiRoot.PathPrefix("/commands").Subrouter()
 	BaseRoutes.Command = BaseRoutes.Commands.PathPrefix("/{command_id:[A-Za-z0-9]+}").Subrouter()
 	BaseRoutes.CommandsForTeam = BaseRoutes.Team.PathPrefix("/commands").Subrouter()
@@ -174,11 +196,28 @@ func InitApi(full bool) {
 
 	BaseRoutes.Webrtc = BaseRoutes.ApiRoot.PathPrefix("/webrtc").Subrouter()
 
+	BaseRoutes.Jobs = BaseRoutes.ApiRoot.PathPrefix("/jobs").Subrouter()
+
+	BaseRoutes.Dialogs = BaseRoutes.ApiRoot.PathPrefix("/actions/dialogs").Subrouter()
+
+	BaseRoutes.Plugins = BaseRoutes.ApiRoot.PathPrefix("/plugins").Subrouter()
+	BaseRoutes.Plugin = BaseRoutes.Plugins.PathPrefix("/{plugin_id:[a-z0-9_.-]+}").Subrouter()
+
+	BaseRoutes.Bots = BaseRoutes.ApiRoot.PathPrefix("/bots").Subrouter()
+	BaseRoutes.Bot = BaseRoutes.Bots.PathPrefix("/{bot_user_id:[A-Za-z0-9]+}").Subrouter()
+
+	BaseRoutes.Roles = BaseRoutes.ApiRoot.PathPrefix("/roles").Subrouter()
+	BaseRoutes.Role = BaseRoutes.Roles.PathPrefix("/{role_id:[a-z0-9_]+}").Subrouter()
+
+	BaseRoutes.Schemes = BaseRoutes.ApiRoot.PathPrefix("/schemes").Subrouter()
+	BaseRoutes.Scheme = BaseRoutes.Schemes.PathPrefix("/{scheme_id:[A-Za-z0-9]+}").Subrouter()
+
 	InitUser()
 	InitTeam()
 	InitChannel()
 	InitPost()
 	InitFile()
+	InitUpload()
 	InitSystem()
 	InitWebhook()
 	InitPreference()
@@ -194,6 +233,12 @@ func InitApi(full bool) {
 	InitOAuth()
 	InitReaction()
 	InitWebrtc()
+	InitJob()
+	InitDialog()
+	InitPlugin()
+	InitBot()
+	InitRole()
+	InitScheme()
 
 	app.Srv.Router.Handle("/api/v4/{anything:.*}", http.HandlerFunc(Handle404))
 
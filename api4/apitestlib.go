@@ -22,8 +22,6 @@ import (
 	"github.com/primefour/servers/store"
 	"github.com/primefour/servers/utils"
 	"github.com/primefour/servers/wsapi"
-
-	s3 "github.com/minio/minio-go"
 )
 
 type TestHelper struct {
@@ -627,46 +625,19 @@ func readTestFile(name string) ([]byte, error) {
 }
 
 func cleanupTestFile(info *model.FileInfo) error {
-	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
-		endpoint := utils.Cfg.FileSettings.AmazonS3Endpoint
-		accessKey := utils.Cfg.FileSettings.AmazonS3AccessKeyId
-		secretKey := utils.Cfg.FileSettings.AmazonS3SecretAccessKey
-		secure := *utils.Cfg.FileSettings.AmazonS3SSL
-		s3Clnt, err := s3.New(endpoint, accessKey, secretKey, secure)
-		if err != nil {
-			return err
-		}
-		bucket := utils.Cfg.FileSettings.AmazonS3Bucket
-		if err := s3Clnt.RemoveObject(bucket, info.Path); err != nil {
-			return err
-		}
-
-		if info.ThumbnailPath != "" {
-			if err := s3Clnt.RemoveObject(bucket, info.ThumbnailPath); err != nil {
-				return err
-			}
-		}
+	if err := app.RemoveFile(info.Path); err != nil {
+		return err
+	}
 
-		if info.PreviewPath != "" {
-			if err := s3Clnt.RemoveObject(bucket, info.PreviewPath); err != nil {
-				return err
-			}
-		}
-	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
-		if err := os.Remove(utils.Cfg.FileSettings.Directory + info.Path); err != nil {
+	if info.ThumbnailPath != "" {
+		if err := app.RemoveFile(info.ThumbnailPath); err != nil {
 			return err
 		}
+	}
 
-		if info.ThumbnailPath != "" {
-			if err := os.Remove(utils.Cfg.FileSettings.Directory + info.ThumbnailPath); err != nil {
-				return err
-			}
-		}
-
-		if info.PreviewPath != "" {
-			if err := os.Remove(utils.Cfg.FileSettings.Directory + info.PreviewPath); err != nil {
-				return err
-			}
+	if info.PreviewPath != "" {
+		if err := app.RemoveFile(info.PreviewPath); err != nil {
+			return err
 		}
 	}
 
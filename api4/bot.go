@@ -0,0 +1,215 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"net/http"
+	"strconv"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func InitBot() {
+	l4g.Debug(utils.T("api.bot.init.debug"))
+
+	BaseRoutes.Bots.Handle("", ApiSessionRequired(createBot)).Methods("POST")
+	BaseRoutes.Bots.Handle("", ApiSessionRequired(getBots)).Methods("GET")
+	BaseRoutes.Bot.Handle("", ApiSessionRequired(getBot)).Methods("GET")
+	BaseRoutes.Bot.Handle("/patch", ApiSessionRequired(patchBot)).Methods("PUT")
+	BaseRoutes.Bot.Handle("/enable", ApiSessionRequired(enableBot)).Methods("POST")
+	BaseRoutes.Bot.Handle("/disable", ApiSessionRequired(disableBot)).Methods("POST")
+	BaseRoutes.Bot.Handle("/assign/{user_id:[A-Za-z0-9]+}", ApiSessionRequired(assignBot)).Methods("POST")
+}
+
+// sessionHasPermissionToBot checks the permission that's needed to act on a bot, which depends on whether the session's
+// user owns it.
+func sessionHasPermissionToBot(c *Context, bot *model.Bot, ownPermission *model.Permission, othersPermission *model.Permission) bool {
+	permission := othersPermission
+	if bot.OwnerId == c.Session.UserId {
+		permission = ownPermission
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, permission) {
+		c.SetPermissionError(permission)
+		return false
+	}
+
+	return true
+}
+
+func createBot(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.BotFromJson(r.Body)
+	if props == nil {
+		c.SetInvalidParam("bot")
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_CREATE_BOT) {
+		c.SetPermissionError(model.PERMISSION_CREATE_BOT)
+		return
+	}
+
+	bot := &model.Bot{
+		Username:    props.Username,
+		DisplayName: props.DisplayName,
+		Description: props.Description,
+		OwnerId:     c.Session.UserId,
+	}
+
+	rbot, err := app.CreateBot(bot)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("bot_user_id=" + rbot.UserId)
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(rbot.ToJson()))
+}
+
+func getBots(c *Context, w http.ResponseWriter, r *http.Request) {
+	includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+
+	ownerId := ""
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_READ_OTHERS_BOTS) {
+		if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_READ_BOTS) {
+			c.SetPermissionError(model.PERMISSION_READ_BOTS)
+			return
+		}
+
+		// Users who can only read their own bots just get those
+		ownerId = c.Session.UserId
+	}
+
+	bots, err := app.GetBotsPage(ownerId, includeDeleted, c.Params.Page, c.Params.PerPage)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.BotListToJson(bots)))
+}
+
+func getBot(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireBotUserId()
+	if c.Err != nil {
+		return
+	}
+
+	includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+
+	bot, err := app.GetBot(c.Params.BotUserId, includeDeleted)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if !sessionHasPermissionToBot(c, bot, model.PERMISSION_READ_BOTS, model.PERMISSION_READ_OTHERS_BOTS) {
+		return
+	}
+
+	w.Write([]byte(bot.ToJson()))
+}
+
+func patchBot(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireBotUserId()
+	if c.Err != nil {
+		return
+	}
+
+	patch := model.BotPatchFromJson(r.Body)
+	if patch == nil {
+		c.SetInvalidParam("bot")
+		return
+	}
+
+	bot, err := app.GetBot(c.Params.BotUserId, true)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if !sessionHasPermissionToBot(c, bot, model.PERMISSION_MANAGE_BOTS, model.PERMISSION_MANAGE_OTHERS_BOTS) {
+		return
+	}
+
+	rbot, err := app.PatchBot(c.Params.BotUserId, patch)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("bot_user_id=" + rbot.UserId)
+	w.Write([]byte(rbot.ToJson()))
+}
+
+func enableBot(c *Context, w http.ResponseWriter, r *http.Request) {
+	updateBotActive(c, w, true)
+}
+
+func disableBot(c *Context, w http.ResponseWriter, r *http.Request) {
+	updateBotActive(c, w, false)
+}
+
+func updateBotActive(c *Context, w http.ResponseWriter, active bool) {
+	c.RequireBotUserId()
+	if c.Err != nil {
+		return
+	}
+
+	bot, err := app.GetBot(c.Params.BotUserId, true)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if !sessionHasPermissionToBot(c, bot, model.PERMISSION_MANAGE_BOTS, model.PERMISSION_MANAGE_OTHERS_BOTS) {
+		return
+	}
+
+	rbot, err := app.UpdateBotActive(c.Params.BotUserId, active)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("bot_user_id=" + rbot.UserId + " active=" + strconv.FormatBool(active))
+	w.Write([]byte(rbot.ToJson()))
+}
+
+func assignBot(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireBotUserId()
+	c.RequireUserId()
+	if c.Err != nil {
+		return
+	}
+
+	bot, err := app.GetBot(c.Params.BotUserId, true)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if !sessionHasPermissionToBot(c, bot, model.PERMISSION_MANAGE_BOTS, model.PERMISSION_MANAGE_OTHERS_BOTS) {
+		return
+	}
+
+	// Only users who can manage other users' bots can hand a bot over to someone else
+	if c.Params.UserId != c.Session.UserId && !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_OTHERS_BOTS) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_OTHERS_BOTS)
+		return
+	}
+
+	rbot, err := app.UpdateBotOwner(c.Params.BotUserId, c.Params.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("bot_user_id=" + rbot.UserId + " owner_id=" + rbot.OwnerId)
+	w.Write([]byte(rbot.ToJson()))
+}
@@ -0,0 +1,377 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"testing"
+
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func enableBotAccountCreation() func() {
+	enable := *utils.Cfg.ServiceSettings.EnableBotAccountCreation
+	*utils.Cfg.ServiceSettings.EnableBotAccountCreation = true
+
+	return func() {
+		*utils.Cfg.ServiceSettings.EnableBotAccountCreation = enable
+	}
+}
+
+func (me *TestHelper) CreateBotWithClient(client *model.Client4) *model.Bot {
+	bot := &model.Bot{
+		Username:    GenerateTestUsername(),
+		DisplayName: "a bot",
+		Description: "bot",
+	}
+
+	utils.DisableDebugLogForTest()
+	rbot, _ := client.CreateBot(bot)
+	utils.EnableDebugLogForTest()
+	return rbot
+}
+
+func TestCreateBot(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	bot := &model.Bot{
+		Username:    GenerateTestUsername(),
+		DisplayName: "a bot",
+		Description: "bot",
+	}
+
+	_, resp := Client.CreateBot(bot)
+	CheckNotImplementedStatus(t, resp)
+
+	defer enableBotAccountCreation()()
+
+	rbot, resp := Client.CreateBot(bot)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+
+	if rbot.Username != bot.Username || rbot.DisplayName != bot.DisplayName || rbot.Description != bot.Description {
+		t.Fatal("should have created the bot", rbot.ToJson())
+	}
+
+	if rbot.OwnerId != th.BasicUser.Id {
+		t.Fatal("should be owned by the user who created it")
+	}
+
+	user, resp := Client.GetUser(rbot.UserId, "")
+	CheckNoError(t, resp)
+
+	if !user.IsBot || user.Username != bot.Username {
+		t.Fatal("should have created a bot user")
+	}
+
+	_, resp = Client.CreateBot(bot)
+	CheckBadRequestStatus(t, resp)
+
+	bot.Username = "not valid"
+	_, resp = Client.CreateBot(bot)
+	CheckBadRequestStatus(t, resp)
+
+	// The owner can't be chosen by the client
+	bot.Username = GenerateTestUsername()
+	bot.OwnerId = th.SystemAdminUser.Id
+	rbot, resp = Client.CreateBot(bot)
+	CheckNoError(t, resp)
+
+	if rbot.OwnerId != th.BasicUser.Id {
+		t.Fatal("should be owned by the user who created it")
+	}
+
+	Client.Logout()
+	_, resp = Client.CreateBot(bot)
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestCreateUserCantBeBot(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+
+	user := &model.User{Email: GenerateTestEmail(), Username: GenerateTestUsername(), Password: "password", IsBot: true}
+
+	ruser, resp := Client.CreateUser(user)
+	CheckNoError(t, resp)
+
+	if ruser.IsBot {
+		t.Fatal("shouldn't be able to create a bot as a user")
+	}
+}
+
+func TestGetBots(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	defer enableBotAccountCreation()()
+
+	bot1 := th.CreateBotWithClient(Client)
+	bot2 := th.CreateBotWithClient(th.SystemAdminClient)
+
+	bots, resp := Client.GetBots(0, 100, "")
+	CheckNoError(t, resp)
+
+	for _, bot := range bots {
+		if bot.OwnerId != th.BasicUser.Id {
+			t.Fatal("should only have returned the bots of the user")
+		}
+	}
+
+	if len(bots) != 1 || bots[0].UserId != bot1.UserId || bots[0].Username != bot1.Username {
+		t.Fatal("should have returned the bot of the user")
+	}
+
+	bots, resp = th.SystemAdminClient.GetBots(0, 100, "")
+	CheckNoError(t, resp)
+
+	found := 0
+	for _, bot := range bots {
+		if bot.UserId == bot1.UserId || bot.UserId == bot2.UserId {
+			found++
+		}
+	}
+
+	if found != 2 {
+		t.Fatal("should have returned the bots of every user")
+	}
+
+	_, resp = Client.DisableBot(bot1.UserId)
+	CheckNoError(t, resp)
+
+	bots, resp = Client.GetBots(0, 100, "")
+	CheckNoError(t, resp)
+
+	if len(bots) != 0 {
+		t.Fatal("shouldn't have returned the disabled bot")
+	}
+
+	bots, resp = Client.GetBotsIncludeDeleted(0, 100, "")
+	CheckNoError(t, resp)
+
+	if len(bots) != 1 || bots[0].DeleteAt == 0 {
+		t.Fatal("should have returned the disabled bot")
+	}
+}
+
+func TestGetBot(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	defer enableBotAccountCreation()()
+
+	bot := th.CreateBotWithClient(Client)
+
+	rbot, resp := Client.GetBot(bot.UserId, "")
+	CheckNoError(t, resp)
+
+	if rbot.UserId != bot.UserId || rbot.Username != bot.Username || rbot.OwnerId != th.BasicUser.Id {
+		t.Fatal("should have returned the bot")
+	}
+
+	_, resp = th.SystemAdminClient.GetBot(bot.UserId, "")
+	CheckNoError(t, resp)
+
+	_, resp = Client.GetBot(th.BasicUser2.Id, "")
+	CheckNotFoundStatus(t, resp)
+
+	_, resp = Client.GetBot("junk", "")
+	CheckNotFoundStatus(t, resp)
+
+	th.LoginBasic2()
+	_, resp = Client.GetBot(bot.UserId, "")
+	CheckForbiddenStatus(t, resp)
+
+	th.LoginBasic()
+	_, resp = Client.DisableBot(bot.UserId)
+	CheckNoError(t, resp)
+
+	_, resp = Client.GetBot(bot.UserId, "")
+	CheckNotFoundStatus(t, resp)
+
+	_, resp = Client.GetBotIncludeDeleted(bot.UserId, "")
+	CheckNoError(t, resp)
+}
+
+func TestPatchBot(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	defer enableBotAccountCreation()()
+
+	bot := th.CreateBotWithClient(Client)
+
+	username := GenerateTestUsername()
+	description := "a different bot"
+	patch := &model.BotPatch{Username: &username, Description: &description}
+
+	rbot, resp := Client.PatchBot(bot.UserId, patch)
+	CheckNoError(t, resp)
+
+	if rbot.Username != username || rbot.Description != description || rbot.DisplayName != bot.DisplayName {
+		t.Fatal("should have patched the bot", rbot.ToJson())
+	}
+
+	user, resp := Client.GetUser(bot.UserId, "")
+	CheckNoError(t, resp)
+
+	if user.Username != username {
+		t.Fatal("should have changed the username of the bot user")
+	}
+
+	invalid := "not valid"
+	_, resp = Client.PatchBot(bot.UserId, &model.BotPatch{Username: &invalid})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.PatchBot(bot.UserId, patch)
+	CheckNoError(t, resp)
+
+	th.LoginBasic2()
+	_, resp = Client.PatchBot(bot.UserId, patch)
+	CheckForbiddenStatus(t, resp)
+}
+
+func TestDisableEnableBot(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	defer enableBotAccountCreation()()
+
+	bot := th.CreateBotWithClient(Client)
+
+	th.LoginBasic2()
+	_, resp := Client.DisableBot(bot.UserId)
+	CheckForbiddenStatus(t, resp)
+
+	th.LoginBasic()
+	rbot, resp := Client.DisableBot(bot.UserId)
+	CheckNoError(t, resp)
+
+	if rbot.DeleteAt == 0 {
+		t.Fatal("should have disabled the bot")
+	}
+
+	user, resp := th.SystemAdminClient.GetUser(bot.UserId, "")
+	CheckNoError(t, resp)
+
+	if user.DeleteAt == 0 {
+		t.Fatal("should have deactivated the bot user")
+	}
+
+	rbot, resp = Client.EnableBot(bot.UserId)
+	CheckNoError(t, resp)
+
+	if rbot.DeleteAt != 0 {
+		t.Fatal("should have enabled the bot")
+	}
+
+	_, resp = th.SystemAdminClient.DisableBot(bot.UserId)
+	CheckNoError(t, resp)
+}
+
+func TestAssignBot(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	defer enableBotAccountCreation()()
+
+	bot := th.CreateBotWithClient(Client)
+
+	_, resp := Client.AssignBot(bot.UserId, th.BasicUser2.Id)
+	CheckForbiddenStatus(t, resp)
+
+	rbot, resp := th.SystemAdminClient.AssignBot(bot.UserId, th.BasicUser2.Id)
+	CheckNoError(t, resp)
+
+	if rbot.OwnerId != th.BasicUser2.Id {
+		t.Fatal("should have changed the owner")
+	}
+
+	_, resp = Client.GetBot(bot.UserId, "")
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.AssignBot(bot.UserId, bot.UserId)
+	CheckBadRequestStatus(t, resp)
+}
+
+func TestBotDisabledWithOwner(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	defer enableBotAccountCreation()()
+
+	bot := th.CreateBotWithClient(Client)
+
+	_, resp := th.SystemAdminClient.UpdateUserActive(th.BasicUser.Id, false)
+	CheckNoError(t, resp)
+
+	rbot, resp := th.SystemAdminClient.GetBotIncludeDeleted(bot.UserId, "")
+	CheckNoError(t, resp)
+
+	if rbot.DeleteAt == 0 {
+		t.Fatal("should have disabled the bot along with its owner")
+	}
+}
+
+func TestBotUserAccessTokens(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	defer enableBotAccountCreation()()
+
+	enableUserAccessTokens := *utils.Cfg.ServiceSettings.EnableUserAccessTokens
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableUserAccessTokens = enableUserAccessTokens
+	}()
+	*utils.Cfg.ServiceSettings.EnableUserAccessTokens = true
+
+	app.UpdateUserRoles(th.BasicUser.Id, model.ROLE_SYSTEM_USER.Id+" "+model.ROLE_SYSTEM_USER_ACCESS_TOKEN.Id)
+	app.UpdateUserRoles(th.BasicUser2.Id, model.ROLE_SYSTEM_USER.Id+" "+model.ROLE_SYSTEM_USER_ACCESS_TOKEN.Id)
+	th.LoginBasic()
+
+	bot := th.CreateBotWithClient(Client)
+
+	token, resp := Client.CreateUserAccessToken(bot.UserId, "bot token")
+	CheckNoError(t, resp)
+
+	tokens, resp := Client.GetUserAccessTokensForUser(bot.UserId, 0, 100)
+	CheckNoError(t, resp)
+
+	if len(tokens) != 1 || tokens[0].Id != token.Id {
+		t.Fatal("should have returned the token of the bot")
+	}
+
+	BotClient := th.CreateClient()
+	BotClient.AuthToken = token.Token
+	BotClient.AuthType = model.HEADER_BEARER
+
+	me, resp := BotClient.GetMe("")
+	CheckNoError(t, resp)
+
+	if me.Id != bot.UserId || !me.IsBot {
+		t.Fatal("should be logged in as the bot")
+	}
+
+	th.LoginBasic2()
+	_, resp = Client.CreateUserAccessToken(bot.UserId, "bot token")
+	CheckForbiddenStatus(t, resp)
+
+	th.LoginBasic()
+	_, resp = Client.DisableBot(bot.UserId)
+	CheckNoError(t, resp)
+
+	_, resp = BotClient.GetMe("")
+	CheckUnauthorizedStatus(t, resp)
+}
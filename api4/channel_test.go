@@ -1469,6 +1469,52 @@ func TestGetPinnedPosts(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestGetPinnedPostsWithoutActionIntegrations(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+
+	post := &model.Post{
+		ChannelId: th.BasicChannel.Id,
+		Message:   "with actions",
+		IsPinned:  true,
+		Props: model.StringInterface{
+			"attachments": []*model.SlackAttachment{
+				{
+					Actions: []*model.PostAction{
+						{
+							Name: "button",
+							Integration: &model.PostActionIntegration{
+								URL:     "http://localhost:8065/action",
+								Context: map[string]interface{}{"secret": "value"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	rpost, resp := Client.CreatePost(post)
+	CheckNoError(t, resp)
+
+	th.LoginBasic2()
+
+	posts, resp := Client.GetPinnedPosts(th.BasicChannel.Id, "")
+	CheckNoError(t, resp)
+
+	pinned, ok := posts.Posts[rpost.Id]
+	if !ok {
+		t.Fatal("missing pinned post")
+	}
+
+	if attachments := pinned.Attachments(); len(attachments) != 1 || len(attachments[0].Actions) != 1 {
+		t.Fatal("should have returned the actions")
+	} else if attachments[0].Actions[0].Integration != nil {
+		t.Fatal("shouldn't have returned the integration to a channel member")
+	}
+}
+
 func TestUpdateChannelRoles(t *testing.T) {
 	th := Setup().InitBasic().InitSystemAdmin()
 	defer TearDown()
@@ -1991,3 +2037,166 @@ func TestRemoveChannelMember(t *testing.T) {
 	_, resp = th.SystemAdminClient.RemoveUserFromChannel(privateChannel.Id, user2.Id)
 	CheckNoError(t, resp)
 }
+
+func TestArchivedChannel(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	channel := th.CreatePublicChannel()
+	post := th.CreateMessagePostWithClient(Client, channel, "archivedterm"+model.NewId())
+
+	_, resp := Client.DeleteChannel(channel.Id)
+	CheckNoError(t, resp)
+
+	// Members can still read archived channels
+	_, resp = Client.GetChannel(channel.Id, "")
+	CheckNoError(t, resp)
+
+	posts, resp := Client.GetPostsForChannel(channel.Id, 0, 60, "")
+	CheckNoError(t, resp)
+	if _, ok := posts.Posts[post.Id]; !ok {
+		t.Fatal("should have returned the post")
+	}
+
+	posts, resp = Client.SearchPosts(th.BasicTeam.Id, post.Message, false)
+	CheckNoError(t, resp)
+	if _, ok := posts.Posts[post.Id]; !ok {
+		t.Fatal("search should have returned the post")
+	}
+
+	// But they're read-only
+	_, resp = Client.CreatePost(&model.Post{ChannelId: channel.Id, Message: "hello"})
+	CheckForbiddenStatus(t, resp)
+
+	message := "edited"
+	_, resp = Client.PatchPost(post.Id, &model.PostPatch{Message: &message})
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.SaveReaction(&model.Reaction{UserId: th.BasicUser.Id, PostId: post.Id, EmojiName: "smile"})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.PinPost(post.Id)
+	CheckBadRequestStatus(t, resp)
+
+	channels, resp := Client.GetArchivedChannelsForTeamForUser(th.BasicTeam.Id, th.BasicUser.Id)
+	CheckNoError(t, resp)
+	found := false
+	for _, c := range *channels {
+		if c.Id == channel.Id {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("should have listed the archived channel")
+	}
+
+	_, resp = Client.GetArchivedChannelsForTeamForUser(th.BasicTeam.Id, th.BasicUser2.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetArchivedChannelsForTeamForUser(th.BasicTeam.Id, th.BasicUser.Id)
+	CheckNoError(t, resp)
+
+	// Users who couldn't have archived the channel can't unarchive it
+	outsider := th.CreateUser()
+	outsiderClient := th.CreateClient()
+	outsiderClient.Login(outsider.Email, outsider.Password)
+	_, resp = outsiderClient.RestoreChannel(channel.Id)
+	CheckForbiddenStatus(t, resp)
+
+	rchannel, resp := Client.RestoreChannel(channel.Id)
+	CheckNoError(t, resp)
+	if rchannel.DeleteAt != 0 {
+		t.Fatal("should have unarchived the channel")
+	}
+
+	_, resp = Client.RestoreChannel(channel.Id)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.CreatePost(&model.Post{ChannelId: channel.Id, Message: "hello"})
+	CheckNoError(t, resp)
+
+	posts, resp = Client.GetPostsForChannel(channel.Id, 0, 60, "")
+	CheckNoError(t, resp)
+	restored := false
+	for _, p := range posts.Posts {
+		if p.Type == model.POST_CHANNEL_RESTORED {
+			restored = true
+		}
+	}
+	if !restored {
+		t.Fatal("should have posted a system message")
+	}
+
+	channels, resp = Client.GetArchivedChannelsForTeamForUser(th.BasicTeam.Id, th.BasicUser.Id)
+	CheckNoError(t, resp)
+	for _, c := range *channels {
+		if c.Id == channel.Id {
+			t.Fatal("shouldn't have listed the unarchived channel")
+		}
+	}
+}
+
+func TestGuestChannelPermissions(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	defer enableGuestAccounts()()
+
+	otherChannel, err := app.CreateChannel(&model.Channel{DisplayName: "Other", Name: GenerateTestChannelName(), Type: model.CHANNEL_OPEN, TeamId: th.BasicTeam.Id}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outsider := th.CreateUser()
+	LinkUserToTeam(outsider, th.BasicTeam)
+
+	if _, err := app.DemoteUserToGuest(th.BasicUser); err != nil {
+		t.Fatal(err)
+	}
+
+	_, resp := Client.GetChannel(th.BasicChannel.Id, "")
+	CheckNoError(t, resp)
+
+	_, resp = Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "hello"})
+	CheckNoError(t, resp)
+
+	_, resp = Client.GetChannel(otherChannel.Id, "")
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.GetPublicChannelsForTeam(th.BasicTeam.Id, 0, 100, "")
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.AddChannelMember(otherChannel.Id, th.BasicUser.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.CreateChannel(&model.Channel{DisplayName: "Guest", Name: GenerateTestChannelName(), Type: model.CHANNEL_OPEN, TeamId: th.BasicTeam.Id})
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.GetUsersInTeam(th.BasicTeam.Id, 0, 100, "")
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.GetUsersInChannel(th.BasicChannel.Id, 0, 100, "")
+	CheckNoError(t, resp)
+
+	_, resp = Client.CreateDirectChannel(th.BasicUser.Id, th.BasicUser2.Id)
+	CheckNoError(t, resp)
+
+	_, resp = Client.CreateDirectChannel(th.BasicUser.Id, outsider.Id)
+	CheckForbiddenStatus(t, resp)
+
+	// Other users can't start a direct message with a guest that they don't share a channel with either
+	_, resp = th.SystemAdminClient.CreateDirectChannel(th.SystemAdminUser.Id, th.BasicUser.Id)
+	CheckForbiddenStatus(t, resp)
+
+	if _, err := app.PromoteGuestToUser(th.BasicUser); err != nil {
+		t.Fatal(err)
+	}
+
+	_, resp = Client.GetPublicChannelsForTeam(th.BasicTeam.Id, 0, 100, "")
+	CheckNoError(t, resp)
+
+	_, resp = Client.CreateDirectChannel(th.BasicUser.Id, outsider.Id)
+	CheckNoError(t, resp)
+}
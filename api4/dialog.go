@@ -0,0 +1,69 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func InitDialog() {
+	l4g.Debug(utils.T("api.dialog.init.debug"))
+
+	// The trigger id authenticates the integration opening a dialog, so no session is needed
+	BaseRoutes.Dialogs.Handle("/open", ApiHandler(openDialog)).Methods("POST")
+	BaseRoutes.Dialogs.Handle("/submit", ApiSessionRequired(submitDialog)).Methods("POST")
+}
+
+func openDialog(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := model.OpenDialogRequestFromJson(r.Body)
+	if request == nil {
+		c.SetInvalidParam("dialog")
+		return
+	}
+
+	if err := app.OpenInteractiveDialog(request); err != nil {
+		c.Err = err
+		return
+	}
+
+	ReturnStatusOK(w)
+}
+
+func submitDialog(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := model.SubmitDialogRequestFromJson(r.Body)
+	if request == nil {
+		c.SetInvalidParam("dialog")
+		return
+	}
+
+	request.UserId = c.Session.UserId
+
+	if len(request.ChannelId) != 26 {
+		c.SetInvalidParam("channel_id")
+		return
+	}
+
+	if !app.SessionHasPermissionToChannel(c.Session, request.ChannelId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	if request.TeamId != "" && !app.SessionHasPermissionToTeam(c.Session, request.TeamId, model.PERMISSION_VIEW_TEAM) {
+		c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+		return
+	}
+
+	response, err := app.SubmitInteractiveDialog(request)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(response.ToJson()))
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func TestOpenDialog(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+
+	_, triggerId, err := app.GenerateTriggerId(th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := model.OpenDialogRequest{
+		TriggerId: triggerId,
+		URL:       "http://localhost:8065",
+		Dialog: model.Dialog{
+			CallbackId: "callbackid",
+			Title:      "Some Title",
+			Elements: []model.DialogElement{
+				{DisplayName: "Element Name", Name: "element_name", Type: model.DIALOG_ELEMENT_TYPE_TEXT},
+			},
+			SubmitLabel: "Submit",
+		},
+	}
+
+	pass, resp := Client.OpenInteractiveDialog(request)
+	CheckNoError(t, resp)
+
+	if !pass {
+		t.Fatal("should have passed")
+	}
+
+	// No session is needed since the trigger id authenticates the request
+	Client.Logout()
+	_, resp = Client.OpenInteractiveDialog(request)
+	CheckNoError(t, resp)
+
+	request.TriggerId = "junk"
+	_, resp = Client.OpenInteractiveDialog(request)
+	CheckBadRequestStatus(t, resp)
+
+	_, request.TriggerId = model.GenerateTriggerId(th.BasicUser.Id, model.NewId())
+	_, resp = Client.OpenInteractiveDialog(request)
+	CheckUnauthorizedStatus(t, resp)
+
+	request.TriggerId = triggerId
+	request.URL = ""
+	_, resp = Client.OpenInteractiveDialog(request)
+	CheckBadRequestStatus(t, resp)
+
+	request.URL = "http://localhost:8065"
+	request.Dialog.Elements[0].Type = "junk"
+	_, resp = Client.OpenInteractiveDialog(request)
+	CheckBadRequestStatus(t, resp)
+}
+
+func TestSubmitDialog(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+
+	allowed := *utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections
+	defer func() {
+		*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = allowed
+	}()
+	*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = "127.0.0.1"
+
+	var received *model.SubmitDialogRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = model.SubmitDialogRequestFromJson(r.Body)
+
+		response := &model.SubmitDialogResponse{}
+		if received.Submission["name"] == "taken" {
+			response.Errors = map[string]string{"name": "This name is taken."}
+		}
+
+		w.Write([]byte(response.ToJson()))
+	}))
+	defer ts.Close()
+
+	key, err := app.GetInteractiveDialogKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opened := &model.OpenedDialog{
+		UserId: th.BasicUser.Id,
+		URL:    ts.URL,
+		Dialog: model.Dialog{
+			CallbackId: "callbackid",
+			Title:      "Some Title",
+			Elements: []model.DialogElement{
+				{DisplayName: "Name", Name: "name", Type: model.DIALOG_ELEMENT_TYPE_TEXT, MaxLength: 10},
+			},
+			State: "somestate",
+		},
+		CreateAt: model.GetMillis(),
+	}
+
+	request := model.SubmitDialogRequest{
+		DialogId:   model.EncodeDialogId(opened, key),
+		CallbackId: "othercallbackid",
+		State:      "otherstate",
+		UserId:     model.NewId(),
+		ChannelId:  th.BasicChannel.Id,
+		TeamId:     th.BasicTeam.Id,
+		Submission: map[string]interface{}{"name": "value"},
+	}
+
+	submitResp, resp := Client.SubmitInteractiveDialog(request)
+	CheckNoError(t, resp)
+
+	if submitResp == nil || len(submitResp.Errors) != 0 {
+		t.Fatal("shouldn't have returned errors")
+	}
+
+	if received == nil || received.Type != model.DIALOG_SUBMISSION_TYPE || received.UserId != th.BasicUser.Id || received.DialogId != "" {
+		t.Fatal("should have sent the submission to the integration")
+	}
+
+	if received.CallbackId != "callbackid" || received.State != "somestate" {
+		t.Fatal("should have sent the callback id and state of the dialog")
+	}
+
+	request.Submission["name"] = "taken"
+	submitResp, resp = Client.SubmitInteractiveDialog(request)
+	CheckNoError(t, resp)
+
+	if submitResp == nil || submitResp.Errors["name"] == "" {
+		t.Fatal("should have returned the errors from the integration")
+	}
+
+	received = nil
+	request.Submission["name"] = "a value that is too long"
+	submitResp, resp = Client.SubmitInteractiveDialog(request)
+	CheckNoError(t, resp)
+
+	if submitResp == nil || submitResp.Errors["name"] == "" || received != nil {
+		t.Fatal("should have rejected the submission without sending it to the integration")
+	}
+
+	request.Submission["name"] = "value"
+	request.DialogId = "junk"
+	_, resp = Client.SubmitInteractiveDialog(request)
+	CheckBadRequestStatus(t, resp)
+
+	request.DialogId = model.EncodeDialogId(opened, model.NewId())
+	_, resp = Client.SubmitInteractiveDialog(request)
+	CheckUnauthorizedStatus(t, resp)
+
+	other := *opened
+	other.UserId = th.BasicUser2.Id
+	request.DialogId = model.EncodeDialogId(&other, key)
+	_, resp = Client.SubmitInteractiveDialog(request)
+	CheckForbiddenStatus(t, resp)
+
+	*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = ""
+	request.DialogId = model.EncodeDialogId(opened, key)
+	_, resp = Client.SubmitInteractiveDialog(request)
+	CheckBadRequestStatus(t, resp)
+
+	request.ChannelId = model.NewId()
+	_, resp = Client.SubmitInteractiveDialog(request)
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.SubmitInteractiveDialog(request)
+	CheckUnauthorizedStatus(t, resp)
+}
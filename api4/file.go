@@ -4,9 +4,11 @@
 package api4
 
 import (
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/primefour/servers/app"
@@ -16,6 +18,9 @@ import (
 
 const (
 	FILE_TEAM_ID = "noteam"
+
+	// Uploaded files beyond this size are buffered on disk instead of in memory while they're being parsed
+	MAX_UPLOAD_MEMORY = 10 * 1024 * 1024
 )
 
 func InitFile() {
@@ -43,12 +48,15 @@ func uploadFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := r.ParseMultipartForm(*utils.Cfg.FileSettings.MaxFileSize); err != nil {
+	r.Body = http.MaxBytesReader(w, r.Body, *utils.Cfg.FileSettings.MaxFileSize)
+
+	if err := r.ParseMultipartForm(MAX_UPLOAD_MEMORY); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	m := r.MultipartForm
+	defer m.RemoveAll()
 
 	props := m.Value
 	if len(props["channel_id"]) == 0 {
@@ -98,17 +106,26 @@ func getFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data, err := app.ReadFile(info.Path)
+	fileReader, err := app.FileReader(info.Path)
 	if err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
 		return
 	}
+	defer fileReader.Close()
 
 	contentTypeToCheck := []string{"image/jpeg", "image/png", "image/bmp", "image/gif",
 		"video/avi", "video/mpeg", "audio/mpeg3", "audio/wav"}
 
-	contentType := http.DetectContentType(data)
+	// Only the first 512 bytes are needed to detect the content type
+	sniffed := make([]byte, 512)
+	n, _ := io.ReadFull(fileReader, sniffed)
+	if _, seekErr := fileReader.Seek(0, io.SeekStart); seekErr != nil {
+		c.Err = model.NewAppError("getFile", "api.file.get_file.seek.app_error", nil, seekErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	contentType := http.DetectContentType(sniffed[:n])
 	foundContentType := false
 	for _, contentTypeFromList := range contentTypeToCheck {
 		if contentType == contentTypeFromList && toDownload == false {
@@ -120,7 +137,7 @@ func getFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		toDownload = true
 	}
 
-	err = writeFileResponse(info.Name, info.MimeType, data, toDownload, w, r)
+	err = writeFileResponse(info.Name, info.MimeType, info.UpdateAt, fileReader, toDownload, w, r)
 	if err != nil {
 		c.Err = err
 		return
@@ -155,12 +172,16 @@ func getFileThumbnail(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if data, err := app.ReadFile(info.ThumbnailPath); err != nil {
+	if fileReader, err := app.FileReader(info.ThumbnailPath); err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
-	} else if err := writeFileResponse(info.Name, info.MimeType, data, toDownload, w, r); err != nil {
-		c.Err = err
-		return
+	} else {
+		defer fileReader.Close()
+
+		if err := writeFileResponse(info.Name, info.MimeType, info.UpdateAt, fileReader, toDownload, w, r); err != nil {
+			c.Err = err
+			return
+		}
 	}
 }
 
@@ -227,12 +248,16 @@ func getFilePreview(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if data, err := app.ReadFile(info.PreviewPath); err != nil {
+	if fileReader, err := app.FileReader(info.PreviewPath); err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
-	} else if err := writeFileResponse(info.Name, info.MimeType, data, toDownload, w, r); err != nil {
-		c.Err = err
-		return
+	} else {
+		defer fileReader.Close()
+
+		if err := writeFileResponse(info.Name, info.MimeType, info.UpdateAt, fileReader, toDownload, w, r); err != nil {
+			c.Err = err
+			return
+		}
 	}
 }
 
@@ -289,23 +314,28 @@ func getPublicFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if data, err := app.ReadFile(info.Path); err != nil {
+	if fileReader, err := app.FileReader(info.Path); err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
-	} else if err := writeFileResponse(info.Name, info.MimeType, data, true, w, r); err != nil {
-		c.Err = err
-		return
+	} else {
+		defer fileReader.Close()
+
+		if err := writeFileResponse(info.Name, info.MimeType, info.UpdateAt, fileReader, true, w, r); err != nil {
+			c.Err = err
+			return
+		}
 	}
 }
 
-func writeFileResponse(filename string, contentType string, bytes []byte, toDownload bool, w http.ResponseWriter, r *http.Request) *model.AppError {
+// writeFileResponse streams fileReader to the client. Range and conditional requests are handled by
+// http.ServeContent, so large files can be downloaded in pieces and resumed.
+func writeFileResponse(filename string, contentType string, lastModified int64, fileReader io.ReadSeeker, toDownload bool, w http.ResponseWriter, r *http.Request) *model.AppError {
 	w.Header().Set("Cache-Control", "max-age=2592000, public")
-	w.Header().Set("Content-Length", strconv.Itoa(len(bytes)))
 
 	if contentType != "" {
 		w.Header().Set("Content-Type", contentType)
 	} else {
-		w.Header().Del("Content-Type") // Content-Type will be set automatically by the http writer
+		w.Header().Del("Content-Type") // Content-Type will be detected from the file name or contents
 	}
 
 	if toDownload {
@@ -318,7 +348,7 @@ func writeFileResponse(filename string, contentType string, bytes []byte, toDown
 	w.Header().Set("X-Frame-Options", "DENY")
 	w.Header().Set("Content-Security-Policy", "Frame-ancestors 'none'")
 
-	w.Write(bytes)
+	http.ServeContent(w, r, filename, time.Unix(0, lastModified*int64(time.Millisecond)), fileReader)
 
 	return nil
 }
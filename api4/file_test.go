@@ -4,7 +4,9 @@
 package api4
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"strings"
 	"testing"
@@ -162,6 +164,50 @@ func TestGetFile(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestGetFileRange(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+	channel := th.BasicChannel
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	sent, err := readTestFile("test.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileResp, resp := Client.UploadFile(sent, channel.Id, "test.png")
+	CheckNoError(t, resp)
+	fileId := fileResp.FileInfos[0].Id
+
+	rq, _ := http.NewRequest(http.MethodGet, Client.ApiUrl+Client.GetFileRoute(fileId), nil)
+	rq.Header.Set(model.HEADER_AUTH, Client.AuthType+" "+Client.AuthToken)
+	rq.Header.Set("Range", "bytes=10-19")
+
+	rp, err := Client.HttpClient.Do(rq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Body.Close()
+
+	if rp.StatusCode != http.StatusPartialContent {
+		t.Fatal("should have returned partial content", rp.StatusCode)
+	}
+
+	if data, err := ioutil.ReadAll(rp.Body); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(data, sent[10:20]) {
+		t.Fatal("received range didn't match the sent file")
+	}
+
+	if rp.Header.Get("Content-Range") != fmt.Sprintf("bytes 10-19/%v", len(sent)) {
+		t.Fatal("wrong Content-Range header", rp.Header.Get("Content-Range"))
+	}
+}
+
 func TestGetFileThumbnail(t *testing.T) {
 	th := Setup().InitBasic().InitSystemAdmin()
 	defer TearDown()
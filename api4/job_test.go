@@ -0,0 +1,169 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"testing"
+
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/store"
+)
+
+func TestCreateJob(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+
+	job := &model.Job{
+		Type: model.JOB_TYPE_LDAP_SYNC,
+		Data: map[string]string{
+			"thing": "stuff",
+		},
+	}
+
+	received, resp := th.SystemAdminClient.CreateJob(job)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+	defer app.Srv.Store.Job().Delete(received.Id)
+
+	if received.Status != model.JOB_STATUS_PENDING || received.Data["thing"] != "stuff" {
+		t.Fatal("should have created a pending job")
+	}
+
+	job.Type = "junk"
+	_, resp = th.SystemAdminClient.CreateJob(job)
+	CheckBadRequestStatus(t, resp)
+
+	job.Type = model.JOB_TYPE_LDAP_SYNC
+	_, resp = th.Client.CreateJob(job)
+	CheckForbiddenStatus(t, resp)
+}
+
+func TestGetJob(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+
+	job := &model.Job{
+		Id:       model.NewId(),
+		Type:     model.JOB_TYPE_COMPLIANCE_EXPORT,
+		CreateAt: model.GetMillis(),
+		Status:   model.JOB_STATUS_PENDING,
+	}
+	store.Must(app.Srv.Store.Job().Save(job))
+	defer app.Srv.Store.Job().Delete(job.Id)
+
+	received, resp := th.SystemAdminClient.GetJob(job.Id)
+	CheckNoError(t, resp)
+
+	if received.Id != job.Id || received.Status != model.JOB_STATUS_PENDING {
+		t.Fatal("incorrect job received")
+	}
+
+	_, resp = th.SystemAdminClient.GetJob("1234")
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.Client.GetJob(job.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetJob(model.NewId())
+	CheckNotFoundStatus(t, resp)
+}
+
+func TestGetJobs(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+
+	jobType := model.JOB_TYPE_BULK_IMPORT
+
+	jobs := []*model.Job{
+		{
+			Id:       model.NewId(),
+			Type:     jobType,
+			CreateAt: model.GetMillis() + 1000,
+			Status:   model.JOB_STATUS_SUCCESS,
+		},
+		{
+			Id:       model.NewId(),
+			Type:     jobType,
+			CreateAt: model.GetMillis() + 2000,
+			Status:   model.JOB_STATUS_SUCCESS,
+		},
+	}
+
+	for _, job := range jobs {
+		store.Must(app.Srv.Store.Job().Save(job))
+		defer app.Srv.Store.Job().Delete(job.Id)
+	}
+
+	received, resp := th.SystemAdminClient.GetJobs(0, 1)
+	CheckNoError(t, resp)
+
+	if len(received) != 1 || received[0].Id != jobs[1].Id {
+		t.Fatal("should've received newest job")
+	}
+
+	received, resp = th.SystemAdminClient.GetJobsByType(jobType, 0, 2)
+	CheckNoError(t, resp)
+
+	if len(received) != 2 || received[0].Id != jobs[1].Id || received[1].Id != jobs[0].Id {
+		t.Fatal("should've received both jobs, newest first")
+	}
+
+	_, resp = th.Client.GetJobs(0, 60)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.Client.GetJobsByType(jobType, 0, 60)
+	CheckForbiddenStatus(t, resp)
+}
+
+func TestCancelJob(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+
+	jobs := []*model.Job{
+		{
+			Id:       model.NewId(),
+			Type:     model.JOB_TYPE_LDAP_SYNC,
+			CreateAt: model.GetMillis(),
+			Status:   model.JOB_STATUS_PENDING,
+		},
+		{
+			Id:       model.NewId(),
+			Type:     model.JOB_TYPE_LDAP_SYNC,
+			CreateAt: model.GetMillis(),
+			Status:   model.JOB_STATUS_IN_PROGRESS,
+		},
+		{
+			Id:       model.NewId(),
+			Type:     model.JOB_TYPE_LDAP_SYNC,
+			CreateAt: model.GetMillis(),
+			Status:   model.JOB_STATUS_SUCCESS,
+		},
+	}
+
+	for _, job := range jobs {
+		store.Must(app.Srv.Store.Job().Save(job))
+		defer app.Srv.Store.Job().Delete(job.Id)
+	}
+
+	_, resp := th.Client.CancelJob(jobs[0].Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.CancelJob(jobs[0].Id)
+	CheckNoError(t, resp)
+
+	if received, _ := th.SystemAdminClient.GetJob(jobs[0].Id); received.Status != model.JOB_STATUS_CANCELED {
+		t.Fatal("pending job should have been canceled")
+	}
+
+	_, resp = th.SystemAdminClient.CancelJob(jobs[1].Id)
+	CheckNoError(t, resp)
+
+	if received, _ := th.SystemAdminClient.GetJob(jobs[1].Id); received.Status != model.JOB_STATUS_CANCEL_REQUESTED {
+		t.Fatal("running job should have been asked to cancel")
+	}
+
+	_, resp = th.SystemAdminClient.CancelJob(jobs[2].Id)
+	CheckBadRequestStatus(t, resp)
+}
@@ -21,12 +21,21 @@ type ApiParams struct {
 	TeamId         string
 	ChannelId      string
 	PostId         string
+	ActionId       string
 	FileId         string
+	UploadId       string
 	CommandId      string
 	HookId         string
 	ReportId       string
 	EmojiId        string
 	AppId          string
+	JobId          string
+	JobType        string
+	TokenId        string
+	PluginId       string
+	BotUserId      string
+	RoleId         string
+	SchemeId       string
 	Email          string
 	Username       string
 	TeamName       string
@@ -60,10 +69,18 @@ func ApiParamsFromRequest(r *http.Request) *ApiParams {
 		params.PostId = val
 	}
 
+	if val, ok := props["action_id"]; ok {
+		params.ActionId = val
+	}
+
 	if val, ok := props["file_id"]; ok {
 		params.FileId = val
 	}
 
+	if val, ok := props["upload_id"]; ok {
+		params.UploadId = val
+	}
+
 	if val, ok := props["command_id"]; ok {
 		params.CommandId = val
 	}
@@ -84,6 +101,34 @@ func ApiParamsFromRequest(r *http.Request) *ApiParams {
 		params.AppId = val
 	}
 
+	if val, ok := props["job_id"]; ok {
+		params.JobId = val
+	}
+
+	if val, ok := props["job_type"]; ok {
+		params.JobType = val
+	}
+
+	if val, ok := props["token_id"]; ok {
+		params.TokenId = val
+	}
+
+	if val, ok := props["plugin_id"]; ok {
+		params.PluginId = val
+	}
+
+	if val, ok := props["bot_user_id"]; ok {
+		params.BotUserId = val
+	}
+
+	if val, ok := props["role_id"]; ok {
+		params.RoleId = val
+	}
+
+	if val, ok := props["scheme_id"]; ok {
+		params.SchemeId = val
+	}
+
 	if val, ok := props["email"]; ok {
 		params.Email = val
 	}
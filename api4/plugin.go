@@ -0,0 +1,80 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func InitPlugin() {
+	l4g.Debug(utils.T("api.plugin.init.debug"))
+
+	BaseRoutes.Plugins.Handle("", ApiSessionRequired(getPlugins)).Methods("GET")
+	BaseRoutes.Plugin.Handle("/enable", ApiSessionRequired(enablePlugin)).Methods("POST")
+	BaseRoutes.Plugin.Handle("/disable", ApiSessionRequired(disablePlugin)).Methods("POST")
+}
+
+func getPlugins(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	response, err := app.GetPlugins()
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(response.ToJson()))
+}
+
+func enablePlugin(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequirePluginId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	c.LogAudit("attempt")
+
+	if err := app.EnablePlugin(c.Params.PluginId); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("plugin_id=" + c.Params.PluginId)
+	ReturnStatusOK(w)
+}
+
+func disablePlugin(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequirePluginId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	c.LogAudit("attempt")
+
+	if err := app.DisablePlugin(c.Params.PluginId); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("plugin_id=" + c.Params.PluginId)
+	ReturnStatusOK(w)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func TestPlugins(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	dir, err := ioutil.TempDir("", "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifest := &model.Manifest{Id: "testplugin", Name: "Test Plugin"}
+	if err := os.Mkdir(filepath.Join(dir, manifest.Id), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, manifest.Id, model.PLUGIN_MANIFEST_FILENAME), []byte(manifest.ToJson()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	originalConfig := model.ConfigFromJson(strings.NewReader(utils.Cfg.ToJson()))
+	defer func() {
+		app.SaveConfig(originalConfig)
+		app.ShutDownPlugins()
+	}()
+
+	*utils.Cfg.PluginSettings.Enable = true
+	*utils.Cfg.PluginSettings.Directory = dir
+	utils.Cfg.PluginSettings.PluginStates = map[string]*model.PluginState{}
+	app.InitPlugins()
+
+	plugins, resp := th.SystemAdminClient.GetPlugins()
+	CheckNoError(t, resp)
+
+	if len(plugins.Active) != 0 || len(plugins.Inactive) != 1 || plugins.Inactive[0].Id != manifest.Id {
+		t.Fatal("should have listed the plugin as inactive")
+	}
+
+	_, resp = Client.GetPlugins()
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.EnablePlugin(manifest.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.EnablePlugin("missing")
+	CheckNotFoundStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.EnablePlugin("Invalid Id")
+	CheckNotFoundStatus(t, resp)
+
+	ok, resp := th.SystemAdminClient.EnablePlugin(manifest.Id)
+	CheckNoError(t, resp)
+
+	if !ok {
+		t.Fatal("should have returned true")
+	}
+
+	plugins, resp = th.SystemAdminClient.GetPlugins()
+	CheckNoError(t, resp)
+
+	if len(plugins.Active) != 1 || len(plugins.Inactive) != 0 {
+		t.Fatal("should have activated the plugin")
+	}
+
+	if state := utils.Cfg.PluginSettings.PluginStates[manifest.Id]; state == nil || !state.Enable {
+		t.Fatal("should have saved the plugin state in the config")
+	}
+
+	_, resp = Client.DisablePlugin(manifest.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.DisablePlugin(manifest.Id)
+	CheckNoError(t, resp)
+
+	plugins, resp = th.SystemAdminClient.GetPlugins()
+	CheckNoError(t, resp)
+
+	if len(plugins.Active) != 0 || len(plugins.Inactive) != 1 {
+		t.Fatal("should have deactivated the plugin")
+	}
+
+	*utils.Cfg.PluginSettings.Enable = false
+
+	_, resp = th.SystemAdminClient.GetPlugins()
+	CheckNotImplementedStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.EnablePlugin(manifest.Id)
+	CheckNotImplementedStatus(t, resp)
+}
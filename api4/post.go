@@ -29,6 +29,7 @@ func InitPost() {
 	BaseRoutes.Post.Handle("/patch", ApiSessionRequired(patchPost)).Methods("PUT")
 	BaseRoutes.Post.Handle("/pin", ApiSessionRequired(pinPost)).Methods("POST")
 	BaseRoutes.Post.Handle("/unpin", ApiSessionRequired(unpinPost)).Methods("POST")
+	BaseRoutes.Post.Handle("/actions/{action_id:[A-Za-z0-9]+}", ApiSessionRequired(doPostAction)).Methods("POST")
 }
 
 func createPost(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -55,6 +56,8 @@ func createPost(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rp.StripActionIntegrations()
+
 	w.WriteHeader(http.StatusCreated)
 	w.Write([]byte(rp.ToJson()))
 }
@@ -125,7 +128,7 @@ func getPostsForChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 	if len(etag) > 0 {
 		w.Header().Set(model.HEADER_ETAG_SERVER, etag)
 	}
-	w.Write([]byte(list.ToJson()))
+	w.Write([]byte(list.WithoutActionIntegrations().ToJson()))
 }
 
 func getFlaggedPostsForUser(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -158,7 +161,7 @@ func getFlaggedPostsForUser(c *Context, w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	w.Write([]byte(posts.ToJson()))
+	w.Write([]byte(posts.WithoutActionIntegrations().ToJson()))
 }
 
 func getPost(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -178,6 +181,8 @@ func getPost(c *Context, w http.ResponseWriter, r *http.Request) {
 	} else if HandleEtag(post.Etag(), "Get Post", w, r) {
 		return
 	} else {
+		post.StripActionIntegrations()
+
 		w.Header().Set(model.HEADER_ETAG_SERVER, post.Etag())
 		w.Write([]byte(post.ToJson()))
 	}
@@ -220,7 +225,7 @@ func getPostThread(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	} else {
 		w.Header().Set(model.HEADER_ETAG_SERVER, list.Etag())
-		w.Write([]byte(list.ToJson()))
+		w.Write([]byte(list.WithoutActionIntegrations().ToJson()))
 	}
 }
 
@@ -255,7 +260,7 @@ func searchPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
-	w.Write([]byte(posts.ToJson()))
+	w.Write([]byte(posts.WithoutActionIntegrations().ToJson()))
 }
 
 func updatePost(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -285,6 +290,7 @@ func updatePost(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rpost.StripActionIntegrations()
 	w.Write([]byte(rpost.ToJson()))
 }
 
@@ -317,6 +323,7 @@ func patchPost(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	patchedPost.StripActionIntegrations()
 	w.Write([]byte(patchedPost.ToJson()))
 }
 
@@ -374,3 +381,33 @@ func getFileInfosForPost(c *Context, w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(model.FileInfosToJson(infos)))
 	}
 }
+
+func doPostAction(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequirePostId().RequireActionId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionToChannelByPost(c.Session, c.Params.PostId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	selectedOption := ""
+	if r.ContentLength != 0 {
+		actionRequest := model.DoPostActionRequestFromJson(r.Body)
+		if actionRequest == nil {
+			c.SetInvalidParam("post_action")
+			return
+		}
+
+		selectedOption = actionRequest.SelectedOption
+	}
+
+	if err := app.DoPostAction(c.Params.PostId, c.Params.ActionId, c.Session.UserId, selectedOption); err != nil {
+		c.Err = err
+		return
+	}
+
+	ReturnStatusOK(w)
+}
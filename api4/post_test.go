@@ -5,6 +5,7 @@ package api4
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strconv"
 	"testing"
@@ -1111,3 +1112,122 @@ func TestGetFileInfosForPost(t *testing.T) {
 	_, resp = th.SystemAdminClient.GetFileInfosForPost(th.BasicPost.Id, "")
 	CheckNoError(t, resp)
 }
+
+func TestDoPostAction(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	allowed := *utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections
+	defer func() {
+		*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = allowed
+	}()
+	*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = "127.0.0.1"
+
+	var request *model.PostActionIntegrationRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = model.PostActionIntegrationRequestFromJson(r.Body)
+
+		response := &model.PostActionIntegrationResponse{EphemeralText: "clicked"}
+		if request.Context["update"] == true {
+			response.Update = &model.Post{Message: "updated"}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response.ToJson()))
+	}))
+	defer ts.Close()
+
+	post := &model.Post{
+		ChannelId: th.BasicChannel.Id,
+		Message:   "with actions",
+		Props: model.StringInterface{
+			"attachments": []*model.SlackAttachment{
+				{
+					Text: "attachment",
+					Actions: []*model.PostAction{
+						{
+							Name: "button",
+							Integration: &model.PostActionIntegration{
+								URL:     ts.URL,
+								Context: map[string]interface{}{"update": true},
+							},
+						},
+						{
+							Name:    "menu",
+							Type:    model.POST_ACTION_TYPE_SELECT,
+							Options: []*model.PostActionOptions{{Text: "One", Value: "1"}},
+							Integration: &model.PostActionIntegration{
+								URL: ts.URL,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	rpost, resp := Client.CreatePost(post)
+	CheckNoError(t, resp)
+
+	attachments := rpost.Attachments()
+	if len(attachments) != 1 || len(attachments[0].Actions) != 2 {
+		t.Fatal("should have returned the actions")
+	}
+
+	button := attachments[0].Actions[0]
+	menu := attachments[0].Actions[1]
+	if button.Id == "" || menu.Id == "" {
+		t.Fatal("should have generated ids for the actions")
+	}
+
+	if button.Integration != nil || menu.Integration != nil {
+		t.Fatal("shouldn't have returned the integrations")
+	}
+
+	_, resp = Client.DoPostAction(rpost.Id, menu.Id, "1")
+	CheckNoError(t, resp)
+
+	if request == nil || request.Type != model.POST_ACTION_TYPE_SELECT || request.Context["selected_option"] != "1" {
+		t.Fatal("should have sent the selected option")
+	}
+
+	_, resp = Client.DoPostAction(rpost.Id, menu.Id, "2")
+	CheckBadRequestStatus(t, resp)
+
+	*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = ""
+	_, resp = Client.DoPostAction(rpost.Id, button.Id, "")
+	CheckBadRequestStatus(t, resp)
+	*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = "127.0.0.1"
+
+	pass, resp := Client.DoPostAction(rpost.Id, button.Id, "")
+	CheckNoError(t, resp)
+
+	if !pass {
+		t.Fatal("should have passed")
+	}
+
+	if request.UserId != th.BasicUser.Id || request.PostId != rpost.Id || request.ChannelId != th.BasicChannel.Id || request.TeamId != th.BasicTeam.Id {
+		t.Fatal("should have sent the action to the integration")
+	}
+
+	if updated, err := app.GetSinglePost(rpost.Id); err != nil {
+		t.Fatal(err)
+	} else if updated.Message != "updated" || updated.Props["from_webhook"] != "true" {
+		t.Fatal("should have updated the post")
+	}
+
+	_, resp = Client.DoPostAction(rpost.Id, "junk", "")
+	CheckNotFoundStatus(t, resp)
+
+	_, resp = Client.DoPostAction("junk", button.Id, "")
+	CheckBadRequestStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.DoPostAction(rpost.Id, button.Id, "")
+	CheckUnauthorizedStatus(t, resp)
+
+	th.LoginBasic2()
+	_, resp = Client.DoPostAction(model.NewId(), button.Id, "")
+	CheckForbiddenStatus(t, resp)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func InitRole() {
+	l4g.Debug(utils.T("api.role.init.debug"))
+
+	BaseRoutes.Roles.Handle("", ApiSessionRequired(getAllRoles)).Methods("GET")
+	BaseRoutes.Role.Handle("", ApiSessionRequired(getRole)).Methods("GET")
+	BaseRoutes.Role.Handle("/patch", ApiSessionRequired(patchRole)).Methods("PUT")
+	BaseRoutes.Role.Handle("/reset", ApiSessionRequired(resetRole)).Methods("POST")
+}
+
+func getAllRoles(c *Context, w http.ResponseWriter, r *http.Request) {
+	roles, err := app.GetAllRoles()
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.RoleListToJson(roles)))
+}
+
+func getRole(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireRoleId()
+	if c.Err != nil {
+		return
+	}
+
+	role, err := app.GetRole(c.Params.RoleId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(role.ToJson()))
+}
+
+func patchRole(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireRoleId()
+	if c.Err != nil {
+		return
+	}
+
+	patch := model.RolePatchFromJson(r.Body)
+	if patch == nil {
+		c.SetInvalidParam("role")
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	role, err := app.PatchRole(c.Params.RoleId, patch)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("role_id=" + role.Id)
+	w.Write([]byte(role.ToJson()))
+}
+
+func resetRole(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireRoleId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	role, err := app.ResetRole(c.Params.RoleId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("role_id=" + role.Id)
+	w.Write([]byte(role.ToJson()))
+}
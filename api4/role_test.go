@@ -0,0 +1,99 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"testing"
+
+	"github.com/primefour/servers/model"
+)
+
+func TestGetRole(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+
+	role, resp := Client.GetRole(model.ROLE_SYSTEM_USER.Id, "")
+	CheckNoError(t, resp)
+
+	if role.Id != model.ROLE_SYSTEM_USER.Id || !role.HasPermission(model.PERMISSION_CREATE_DIRECT_CHANNEL.Id) {
+		t.Fatal("should have returned the system user role", role.ToJson())
+	}
+
+	_, resp = Client.GetRole("junk_role", "")
+	CheckNotFoundStatus(t, resp)
+
+	Client.Logout()
+
+	_, resp = Client.GetRole(model.ROLE_SYSTEM_USER.Id, "")
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestGetAllRoles(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+
+	roles, resp := Client.GetAllRoles()
+	CheckNoError(t, resp)
+
+	found := false
+	for _, role := range roles {
+		if role.Id == model.ROLE_SYSTEM_ADMIN.Id {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("should have returned the built-in roles")
+	}
+}
+
+func TestPatchRole(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	defer th.SystemAdminClient.ResetRole(model.ROLE_CHANNEL_USER.Id)
+
+	permissions := []string{}
+	for _, permissionId := range model.ROLE_CHANNEL_USER.Permissions {
+		if permissionId != model.PERMISSION_CREATE_POST.Id {
+			permissions = append(permissions, permissionId)
+		}
+	}
+	patch := &model.RolePatch{Permissions: &permissions}
+
+	_, resp := Client.PatchRole(model.ROLE_CHANNEL_USER.Id, patch)
+	CheckForbiddenStatus(t, resp)
+
+	role, resp := th.SystemAdminClient.PatchRole(model.ROLE_CHANNEL_USER.Id, patch)
+	CheckNoError(t, resp)
+
+	if role.HasPermission(model.PERMISSION_CREATE_POST.Id) {
+		t.Fatal("should have removed the permission")
+	}
+
+	_, resp = Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "no permission"})
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.PatchRole(model.ROLE_CHANNEL_USER.Id, &model.RolePatch{Permissions: &[]string{"junk"}})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.PatchRole(model.ROLE_SYSTEM_ADMIN.Id, &model.RolePatch{Permissions: &[]string{}})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.ResetRole(model.ROLE_CHANNEL_USER.Id)
+	CheckForbiddenStatus(t, resp)
+
+	role, resp = th.SystemAdminClient.ResetRole(model.ROLE_CHANNEL_USER.Id)
+	CheckNoError(t, resp)
+
+	if !role.HasPermission(model.PERMISSION_CREATE_POST.Id) {
+		t.Fatal("should have restored the permission")
+	}
+
+	_, resp = Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "permission"})
+	CheckNoError(t, resp)
+}
@@ -6,6 +6,8 @@ package api4
 import (
 	"mime/multipart"
 	"net/http"
+	"net/url"
+	"strings"
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/primefour/servers/app"
@@ -27,6 +29,9 @@ func InitSaml() {
 	BaseRoutes.SAML.Handle("/certificate/idp", ApiSessionRequired(removeSamlIdpCertificate)).Methods("DELETE")
 
 	BaseRoutes.SAML.Handle("/certificate/status", ApiSessionRequired(getSamlCertificateStatus)).Methods("GET")
+
+	BaseRoutes.Root.Handle("/login/sso/saml", ApiHandler(loginWithSaml)).Methods("GET")
+	BaseRoutes.Root.Handle("/login/sso/saml", ApiHandlerTrustRequester(completeSaml)).Methods("POST")
 }
 
 func getSamlMetadata(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -41,6 +46,78 @@ func getSamlMetadata(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(metadata))
 }
 
+func loginWithSaml(c *Context, w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	teamId, err := app.GetTeamIdFromQuery(query)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	props := map[string]string{}
+	if len(teamId) != 0 {
+		props["team_id"] = teamId
+	}
+
+	if action := query.Get("action"); len(action) != 0 {
+		props["action"] = action
+		if action == model.OAUTH_ACTION_EMAIL_TO_SSO {
+			props["email"] = query.Get("email")
+		}
+	}
+
+	if redirectTo := query.Get("redirect_to"); len(redirectTo) != 0 {
+		props["redirect_to"] = redirectTo
+	}
+
+	if loginUrl, err := app.GetSamlLoginUrl(props); err != nil {
+		c.Err = err
+		return
+	} else {
+		http.Redirect(w, r, loginUrl, http.StatusFound)
+	}
+}
+
+// completeSaml is the assertion consumer service that the identity provider posts its Response to.
+func completeSaml(c *Context, w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		c.Err = model.NewAppError("completeSaml", "api.user.saml.missing_response.app_error", nil, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, props, err := app.CompleteSaml(r.FormValue("SAMLResponse"), r.FormValue("RelayState"))
+	if err != nil {
+		c.LogAudit("fail")
+		c.Err = err
+		return
+	}
+
+	if props["action"] == model.OAUTH_ACTION_EMAIL_TO_SSO {
+		c.LogAuditWithUserId(user.Id, "switched to saml")
+		http.Redirect(w, r, c.GetSiteURLHeader()+"/login?extra=signin_change&email="+url.QueryEscape(user.Email), http.StatusFound)
+		return
+	}
+
+	session, err := app.DoLogin(w, r, user, "")
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.Session = *session
+	c.LogAuditWithUserId(user.Id, "success")
+
+	redirectUrl := c.GetSiteURLHeader()
+
+	// Only redirect within this site so the relay state can't be used to send users elsewhere
+	if redirectTo := props["redirect_to"]; strings.HasPrefix(redirectTo, "/") && !strings.HasPrefix(redirectTo, "//") {
+		redirectUrl += redirectTo
+	}
+
+	http.Redirect(w, r, redirectUrl, http.StatusFound)
+}
+
 func parseSamlCertificateRequest(r *http.Request) (*multipart.FileHeader, *model.AppError) {
 	err := r.ParseMultipartForm(*utils.Cfg.FileSettings.MaxFileSize)
 	if err != nil {
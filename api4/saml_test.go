@@ -4,6 +4,8 @@
 package api4
 
 import (
+	"net/http"
+	"net/url"
 	"testing"
 )
 
@@ -17,3 +19,29 @@ func TestGetSamlMetadata(t *testing.T) {
 
 	// Rest is tested by enterprise tests
 }
+
+func TestSamlLogin(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+
+	if rp, err := Client.HttpClient.Get(Client.Url + "/login/sso/saml"); err != nil {
+		t.Fatal(err)
+	} else {
+		rp.Body.Close()
+		if rp.StatusCode != http.StatusNotImplemented {
+			t.Fatal("should not be implemented without a SAML provider", rp.StatusCode)
+		}
+	}
+
+	if rp, err := Client.HttpClient.PostForm(Client.Url+"/login/sso/saml", url.Values{"SAMLResponse": {"response"}}); err != nil {
+		t.Fatal(err)
+	} else {
+		rp.Body.Close()
+		if rp.StatusCode != http.StatusNotImplemented {
+			t.Fatal("should not be implemented without a SAML provider", rp.StatusCode)
+		}
+	}
+
+	// Rest is tested by the saml package
+}
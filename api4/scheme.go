@@ -0,0 +1,133 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func InitScheme() {
+	l4g.Debug(utils.T("api.scheme.init.debug"))
+
+	BaseRoutes.Schemes.Handle("", ApiSessionRequired(createScheme)).Methods("POST")
+	BaseRoutes.Schemes.Handle("", ApiSessionRequired(getSchemes)).Methods("GET")
+	BaseRoutes.Scheme.Handle("", ApiSessionRequired(getScheme)).Methods("GET")
+	BaseRoutes.Scheme.Handle("", ApiSessionRequired(deleteScheme)).Methods("DELETE")
+	BaseRoutes.Scheme.Handle("/patch", ApiSessionRequired(patchScheme)).Methods("PUT")
+}
+
+func createScheme(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.SchemeFromJson(r.Body)
+	if props == nil {
+		c.SetInvalidParam("scheme")
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	scheme := &model.Scheme{
+		Name:        props.Name,
+		Description: props.Description,
+	}
+
+	rscheme, err := app.CreateScheme(scheme)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("scheme_id=" + rscheme.Id)
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(rscheme.ToJson()))
+}
+
+func getSchemes(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	schemes, err := app.GetSchemesPage(c.Params.Page, c.Params.PerPage)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.SchemeListToJson(schemes)))
+}
+
+func getScheme(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireSchemeId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	scheme, err := app.GetScheme(c.Params.SchemeId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(scheme.ToJson()))
+}
+
+func patchScheme(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireSchemeId()
+	if c.Err != nil {
+		return
+	}
+
+	patch := model.SchemePatchFromJson(r.Body)
+	if patch == nil {
+		c.SetInvalidParam("scheme")
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	scheme, err := app.PatchScheme(c.Params.SchemeId, patch)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("scheme_id=" + scheme.Id)
+	w.Write([]byte(scheme.ToJson()))
+}
+
+func deleteScheme(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireSchemeId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	if _, err := app.DeleteScheme(c.Params.SchemeId); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("scheme_id=" + c.Params.SchemeId)
+	ReturnStatusOK(w)
+}
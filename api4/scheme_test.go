@@ -0,0 +1,144 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"testing"
+
+	"github.com/primefour/servers/model"
+)
+
+func TestCreateScheme(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	scheme := &model.Scheme{Name: "Some Scheme"}
+
+	_, resp := Client.CreateScheme(scheme)
+	CheckForbiddenStatus(t, resp)
+
+	rscheme, resp := th.SystemAdminClient.CreateScheme(scheme)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+	defer th.SystemAdminClient.DeleteScheme(rscheme.Id)
+
+	if rscheme.Name != scheme.Name {
+		t.Fatal("should have created the scheme", rscheme.ToJson())
+	}
+
+	role, resp := th.SystemAdminClient.GetRole(rscheme.DefaultChannelUserRole, "")
+	CheckNoError(t, resp)
+
+	if !role.SchemeManaged || !role.HasPermission(model.PERMISSION_CREATE_POST.Id) {
+		t.Fatal("should have copied the channel user role", role.ToJson())
+	}
+
+	_, resp = th.SystemAdminClient.CreateScheme(&model.Scheme{})
+	CheckBadRequestStatus(t, resp)
+}
+
+func TestGetPatchDeleteScheme(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	scheme, resp := th.SystemAdminClient.CreateScheme(&model.Scheme{Name: "Some Scheme"})
+	CheckNoError(t, resp)
+
+	_, resp = Client.GetScheme(scheme.Id, "")
+	CheckForbiddenStatus(t, resp)
+
+	rscheme, resp := th.SystemAdminClient.GetScheme(scheme.Id, "")
+	CheckNoError(t, resp)
+
+	if rscheme.Id != scheme.Id {
+		t.Fatal("should have returned the scheme")
+	}
+
+	schemes, resp := th.SystemAdminClient.GetSchemes(0, 1000, "")
+	CheckNoError(t, resp)
+
+	found := false
+	for _, s := range schemes {
+		if s.Id == scheme.Id {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("should have listed the scheme")
+	}
+
+	name := "Other Scheme"
+	_, resp = Client.PatchScheme(scheme.Id, &model.SchemePatch{Name: &name})
+	CheckForbiddenStatus(t, resp)
+
+	rscheme, resp = th.SystemAdminClient.PatchScheme(scheme.Id, &model.SchemePatch{Name: &name})
+	CheckNoError(t, resp)
+
+	if rscheme.Name != name {
+		t.Fatal("should have patched the scheme")
+	}
+
+	_, resp = Client.DeleteScheme(scheme.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.DeleteScheme(scheme.Id)
+	CheckNoError(t, resp)
+
+	_, resp = th.SystemAdminClient.GetScheme(scheme.Id, "")
+	CheckNotFoundStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetRole(scheme.DefaultChannelUserRole, "")
+	CheckNotFoundStatus(t, resp)
+}
+
+func TestUpdateTeamScheme(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	scheme, resp := th.SystemAdminClient.CreateScheme(&model.Scheme{Name: "Some Scheme"})
+	CheckNoError(t, resp)
+	defer th.SystemAdminClient.DeleteScheme(scheme.Id)
+
+	_, resp = th.SystemAdminClient.PatchRole(scheme.DefaultChannelUserRole, &model.RolePatch{Permissions: &[]string{model.PERMISSION_READ_CHANNEL.Id}})
+	CheckNoError(t, resp)
+
+	_, resp = Client.UpdateTeamScheme(th.BasicTeam.Id, scheme.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.UpdateTeamScheme(th.BasicTeam.Id, model.NewId())
+	CheckNotFoundStatus(t, resp)
+
+	team, resp := th.SystemAdminClient.UpdateTeamScheme(th.BasicTeam.Id, scheme.Id)
+	CheckNoError(t, resp)
+
+	if team.SchemeId != scheme.Id {
+		t.Fatal("the team should use the scheme")
+	}
+
+	// The scheme's channel user role can't post
+	_, resp = Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "no permission"})
+	CheckForbiddenStatus(t, resp)
+
+	// Regular team updates leave the scheme alone
+	team, resp = th.SystemAdminClient.PatchTeam(th.BasicTeam.Id, &model.TeamPatch{})
+	CheckNoError(t, resp)
+
+	if team.SchemeId != scheme.Id {
+		t.Fatal("the team should still use the scheme")
+	}
+
+	team, resp = th.SystemAdminClient.UpdateTeamScheme(th.BasicTeam.Id, "")
+	CheckNoError(t, resp)
+
+	if team.SchemeId != "" {
+		t.Fatal("the team should use the built-in roles")
+	}
+
+	_, resp = Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "permission"})
+	CheckNoError(t, resp)
+}
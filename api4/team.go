@@ -32,6 +32,7 @@ func InitTeam() {
 	BaseRoutes.Team.Handle("", ApiSessionRequired(updateTeam)).Methods("PUT")
 	BaseRoutes.Team.Handle("", ApiSessionRequired(softDeleteTeam)).Methods("DELETE")
 	BaseRoutes.Team.Handle("/patch", ApiSessionRequired(patchTeam)).Methods("PUT")
+	BaseRoutes.Team.Handle("/scheme", ApiSessionRequired(updateTeamScheme)).Methods("PUT")
 	BaseRoutes.Team.Handle("/stats", ApiSessionRequired(getTeamStats)).Methods("GET")
 	BaseRoutes.TeamMembers.Handle("", ApiSessionRequired(getTeamMembers)).Methods("GET")
 	BaseRoutes.TeamMembers.Handle("/ids", ApiSessionRequired(getTeamMembersByIds)).Methods("POST")
@@ -49,6 +50,7 @@ func InitTeam() {
 
 	BaseRoutes.Team.Handle("/import", ApiSessionRequired(importTeam)).Methods("POST")
 	BaseRoutes.Team.Handle("/invite/email", ApiSessionRequired(inviteUsersToTeam)).Methods("POST")
+	BaseRoutes.Team.Handle("/invite-guests/email", ApiSessionRequired(inviteGuestsToTeam)).Methods("POST")
 }
 
 func createTeam(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -172,6 +174,35 @@ func patchTeam(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(patchedTeam.ToJson()))
 }
 
+func updateTeamScheme(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	props := model.MapFromJson(r.Body)
+
+	schemeId, ok := props["scheme_id"]
+	if !ok || (len(schemeId) != 0 && len(schemeId) != 26) {
+		c.SetInvalidParam("scheme_id")
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	team, err := app.SetTeamScheme(c.Params.TeamId, schemeId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("scheme_id=" + schemeId)
+	w.Write([]byte(team.ToJson()))
+}
+
 func softDeleteTeam(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.RequireTeamId()
 	if c.Err != nil {
@@ -265,6 +296,11 @@ func getTeamMembers(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_VIEW_MEMBERS) {
+		c.SetPermissionError(model.PERMISSION_VIEW_MEMBERS)
+		return
+	}
+
 	if members, err := app.GetTeamMembers(c.Params.TeamId, c.Params.Page, c.Params.PerPage); err != nil {
 		c.Err = err
 		return
@@ -681,3 +717,57 @@ func inviteUsersToTeam(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	ReturnStatusOK(w)
 }
+
+func inviteGuestsToTeam(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	invite := model.GuestsInviteFromJson(r.Body)
+	if invite == nil {
+		c.SetInvalidParam("guests_invite")
+		return
+	}
+
+	if len(invite.Emails) == 0 {
+		c.SetInvalidParam("emails")
+		return
+	}
+
+	if !app.SessionHasPermissionToTeam(c.Session, c.Params.TeamId, model.PERMISSION_INVITE_USER) {
+		c.SetPermissionError(model.PERMISSION_INVITE_USER)
+		return
+	}
+
+	if !app.SessionHasPermissionToTeam(c.Session, c.Params.TeamId, model.PERMISSION_ADD_USER_TO_TEAM) {
+		c.SetPermissionError(model.PERMISSION_INVITE_USER)
+		return
+	}
+
+	channels, err := app.GetGuestInviteChannels(c.Params.TeamId, invite.Channels)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	// The guests are added to the channels when they sign up, so the inviter needs to be able to add members to them
+	for _, channel := range channels {
+		permission := model.PERMISSION_MANAGE_PUBLIC_CHANNEL_MEMBERS
+		if channel.Type == model.CHANNEL_PRIVATE {
+			permission = model.PERMISSION_MANAGE_PRIVATE_CHANNEL_MEMBERS
+		}
+
+		if !app.SessionHasPermissionToChannel(c.Session, channel.Id, permission) {
+			c.SetPermissionError(permission)
+			return
+		}
+	}
+
+	if err := app.InviteGuestsToChannels(c.Params.TeamId, invite.Channels, invite.Emails, c.Session.UserId); err != nil {
+		c.Err = err
+		return
+	}
+
+	ReturnStatusOK(w)
+}
@@ -1373,6 +1373,15 @@ func TestImportTeam(t *testing.T) {
 	})
 }
 
+func enableGuestAccounts() func() {
+	enable := *utils.Cfg.GuestAccountsSettings.Enable
+	*utils.Cfg.GuestAccountsSettings.Enable = true
+
+	return func() {
+		*utils.Cfg.GuestAccountsSettings.Enable = enable
+	}
+}
+
 func TestInviteUsersToTeam(t *testing.T) {
 	th := Setup().InitBasic().InitSystemAdmin()
 	defer TearDown()
@@ -1420,3 +1429,53 @@ func TestInviteUsersToTeam(t *testing.T) {
 		}
 	}
 }
+
+func TestInviteGuestsToTeam(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	emailList := []string{GenerateTestEmail()}
+	channelIds := []string{th.BasicChannel.Id}
+
+	_, resp := th.SystemAdminClient.InviteGuestsToTeam(th.BasicTeam.Id, emailList, channelIds)
+	CheckNotImplementedStatus(t, resp)
+
+	defer enableGuestAccounts()()
+
+	ok, resp := th.SystemAdminClient.InviteGuestsToTeam(th.BasicTeam.Id, emailList, channelIds)
+	CheckNoError(t, resp)
+	if !ok {
+		t.Fatal("should return true")
+	}
+
+	_, resp = th.SystemAdminClient.InviteGuestsToTeam(th.BasicTeam.Id, emailList, []string{})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.InviteGuestsToTeam(th.BasicTeam.Id, []string{}, channelIds)
+	CheckBadRequestStatus(t, resp)
+
+	otherTeam := th.CreateTeamWithClient(th.SystemAdminClient)
+	_, resp = th.SystemAdminClient.InviteGuestsToTeam(otherTeam.Id, emailList, channelIds)
+	CheckBadRequestStatus(t, resp)
+
+	allowedDomains := *utils.Cfg.GuestAccountsSettings.AllowedDomains
+	defer func() {
+		*utils.Cfg.GuestAccountsSettings.AllowedDomains = allowedDomains
+	}()
+	*utils.Cfg.GuestAccountsSettings.AllowedDomains = "example.com"
+
+	_, resp = th.SystemAdminClient.InviteGuestsToTeam(th.BasicTeam.Id, []string{"guest@another.com"}, channelIds)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.InviteGuestsToTeam(th.BasicTeam.Id, []string{"guest@example.com"}, channelIds)
+	CheckNoError(t, resp)
+
+	// Members who can't add people to a private channel can't invite guests to it either
+	privateChannel := th.CreateChannelWithClient(th.SystemAdminClient, model.CHANNEL_PRIVATE)
+	_, resp = Client.InviteGuestsToTeam(th.BasicTeam.Id, []string{"guest@example.com"}, []string{privateChannel.Id})
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.InviteGuestsToTeam(th.BasicTeam.Id, []string{"guest@example.com"}, channelIds)
+	CheckNoError(t, resp)
+}
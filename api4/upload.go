@@ -0,0 +1,139 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"net/http"
+	"strconv"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func InitUpload() {
+	l4g.Debug(utils.T("api.upload.init.debug"))
+
+	BaseRoutes.Uploads.Handle("", ApiSessionRequired(createUploadSession)).Methods("POST")
+	BaseRoutes.Upload.Handle("", ApiSessionRequired(getUploadSession)).Methods("GET")
+	BaseRoutes.Upload.Handle("", ApiSessionRequired(uploadData)).Methods("PATCH")
+	BaseRoutes.Upload.Handle("", ApiSessionRequired(cancelUploadSession)).Methods("DELETE")
+	BaseRoutes.Upload.Handle("/finish", ApiSessionRequired(finishUploadSession)).Methods("POST")
+}
+
+func createUploadSession(c *Context, w http.ResponseWriter, r *http.Request) {
+	us := model.UploadSessionFromJson(r.Body)
+	if us == nil {
+		c.SetInvalidParam("upload_session")
+		return
+	}
+
+	if len(us.ChannelId) != 26 {
+		c.SetInvalidParam("channel_id")
+		return
+	}
+
+	if !app.SessionHasPermissionToChannel(c.Session, us.ChannelId, model.PERMISSION_UPLOAD_FILE) {
+		c.SetPermissionError(model.PERMISSION_UPLOAD_FILE)
+		return
+	}
+
+	us.UserId = c.Session.UserId
+
+	rus, err := app.CreateUploadSession(us)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(rus.ToJson()))
+}
+
+// getUploadSessionForRequest loads the upload session from the URL and makes sure that it belongs to the current user.
+func getUploadSessionForRequest(c *Context) *model.UploadSession {
+	c.RequireUploadId()
+	if c.Err != nil {
+		return nil
+	}
+
+	us, err := app.GetUploadSession(c.Params.UploadId)
+	if err != nil {
+		c.Err = err
+		return nil
+	}
+
+	if us.UserId != c.Session.UserId {
+		c.SetPermissionError(model.PERMISSION_UPLOAD_FILE)
+		return nil
+	}
+
+	return us
+}
+
+func getUploadSession(c *Context, w http.ResponseWriter, r *http.Request) {
+	us := getUploadSessionForRequest(c)
+	if c.Err != nil {
+		return
+	}
+
+	w.Write([]byte(us.ToJson()))
+}
+
+func uploadData(c *Context, w http.ResponseWriter, r *http.Request) {
+	us := getUploadSessionForRequest(c)
+	if c.Err != nil {
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.SetInvalidUrlParam("offset")
+		return
+	}
+
+	rus, appErr := app.UploadData(us, offset, r.Body)
+	if appErr != nil {
+		c.Err = appErr
+		return
+	}
+
+	w.Write([]byte(rus.ToJson()))
+}
+
+func finishUploadSession(c *Context, w http.ResponseWriter, r *http.Request) {
+	us := getUploadSessionForRequest(c)
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionToChannel(c.Session, us.ChannelId, model.PERMISSION_UPLOAD_FILE) {
+		c.SetPermissionError(model.PERMISSION_UPLOAD_FILE)
+		return
+	}
+
+	info, err := app.FinishUploadSession(us, FILE_TEAM_ID)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(info.ToJson()))
+}
+
+func cancelUploadSession(c *Context, w http.ResponseWriter, r *http.Request) {
+	us := getUploadSessionForRequest(c)
+	if c.Err != nil {
+		return
+	}
+
+	if err := app.RemoveUploadSession(us); err != nil {
+		c.Err = err
+		return
+	}
+
+	ReturnStatusOK(w)
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func TestUploadSession(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+	channel := th.BasicChannel
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	data, err := readTestFile("test.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	us, resp := Client.CreateUploadSession(&model.UploadSession{ChannelId: channel.Id, Filename: "test.png", FileSize: int64(len(data))})
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+
+	if us.UserId != th.BasicUser.Id {
+		t.Fatal("upload session should belong to the user")
+	} else if us.FileOffset != 0 {
+		t.Fatal("upload session shouldn't have received any data")
+	}
+
+	half := int64(len(data) / 2)
+
+	us, resp = Client.UploadData(us.Id, 0, data[:half])
+	CheckNoError(t, resp)
+
+	if us.FileOffset != half {
+		t.Fatal("upload session should have received the first half of the file")
+	}
+
+	_, resp = Client.FinishUploadSession(us.Id)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.UploadData(us.Id, 0, data[:half])
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetUploadSession(us.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.UploadData(us.Id, half, data[half:])
+	CheckForbiddenStatus(t, resp)
+
+	us, resp = Client.GetUploadSession(us.Id)
+	CheckNoError(t, resp)
+
+	if us.FileOffset != half {
+		t.Fatal("upload session should report how much has been received")
+	}
+
+	us, resp = Client.UploadData(us.Id, half, data[half:])
+	CheckNoError(t, resp)
+
+	if !us.IsComplete() {
+		t.Fatal("upload session should have received the whole file")
+	}
+
+	info, resp := Client.FinishUploadSession(us.Id)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+
+	if info.CreatorId != th.BasicUser.Id {
+		t.Fatal("file should be assigned to user")
+	} else if info.Size != int64(len(data)) {
+		t.Fatal("file should be the size of the uploaded data")
+	}
+
+	received, resp := Client.GetFile(info.Id)
+	CheckNoError(t, resp)
+
+	if !bytes.Equal(received, data) {
+		t.Fatal("received file didn't match the uploaded data")
+	}
+
+	if result := <-app.Srv.Store.FileInfo().Get(info.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if err := cleanupTestFile(result.Data.(*model.FileInfo)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, resp = Client.GetUploadSession(us.Id)
+	CheckNotFoundStatus(t, resp)
+}
+
+func TestUploadSessionRejectsBadData(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+	channel := th.BasicChannel
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	_, resp := Client.CreateUploadSession(&model.UploadSession{ChannelId: model.NewId(), Filename: "test.txt", FileSize: 10})
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.CreateUploadSession(&model.UploadSession{ChannelId: channel.Id, Filename: "test.txt", FileSize: *utils.Cfg.FileSettings.MaxFileSize + 1})
+	CheckPayLoadTooLargeStatus(t, resp)
+
+	us, resp := Client.CreateUploadSession(&model.UploadSession{ChannelId: channel.Id, Filename: "test.txt", FileSize: 10})
+	CheckNoError(t, resp)
+
+	_, resp = Client.UploadData(us.Id, 0, []byte("this is more than ten bytes"))
+	CheckPayLoadTooLargeStatus(t, resp)
+
+	_, resp = Client.UploadData(us.Id, 0, []byte{})
+	CheckBadRequestStatus(t, resp)
+
+	rq, _ := http.NewRequest(http.MethodPatch, Client.ApiUrl+Client.GetUploadRoute(us.Id)+"?offset=junk", bytes.NewReader([]byte("data")))
+	rq.Header.Set(model.HEADER_AUTH, Client.AuthType+" "+Client.AuthToken)
+	if rp, err := Client.HttpClient.Do(rq); err != nil {
+		t.Fatal(err)
+	} else {
+		ioutil.ReadAll(rp.Body)
+		rp.Body.Close()
+		if rp.StatusCode != http.StatusBadRequest {
+			t.Fatal("should have rejected an invalid offset", rp.StatusCode)
+		}
+	}
+
+	enableFileAttachments := *utils.Cfg.FileSettings.EnableFileAttachments
+	defer func() {
+		*utils.Cfg.FileSettings.EnableFileAttachments = enableFileAttachments
+	}()
+	*utils.Cfg.FileSettings.EnableFileAttachments = false
+
+	_, resp = Client.UploadData(us.Id, 0, []byte("ten bytes!"))
+	CheckNotImplementedStatus(t, resp)
+
+	*utils.Cfg.FileSettings.EnableFileAttachments = true
+
+	ok, resp := Client.CancelUploadSession(us.Id)
+	CheckNoError(t, resp)
+
+	if !ok {
+		t.Fatal("should have cancelled the upload session")
+	}
+
+	_, resp = Client.GetUploadSession(us.Id)
+	CheckNotFoundStatus(t, resp)
+}
@@ -4,7 +4,14 @@
 package api4
 
 import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/primefour/servers/model"
 	"github.com/primefour/servers/utils"
@@ -657,6 +664,10 @@ func TestRegenOutgoingHookToken(t *testing.T) {
 		t.Fatal("regen didn't work properly")
 	}
 
+	if regenHookToken.SigningSecret == rhook.SigningSecret || len(regenHookToken.SigningSecret) != model.OUTGOING_WEBHOOK_SIGNING_SECRET_LENGTH {
+		t.Fatal("should have regenerated the signing secret")
+	}
+
 	_, resp = Client.RegenOutgoingHookToken(rhook.Id)
 	CheckForbiddenStatus(t, resp)
 
@@ -893,3 +904,116 @@ func TestDeleteOutgoingHook(t *testing.T) {
 		CheckForbiddenStatus(t, resp)
 	})
 }
+
+func TestGetOutgoingHookDeliveries(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	enableOutgoingHooks := utils.Cfg.ServiceSettings.EnableOutgoingWebhooks
+	enableAdminOnlyHooks := utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableOutgoingWebhooks = enableOutgoingHooks
+		utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = enableAdminOnlyHooks
+		utils.SetDefaultRolesBasedOnConfig()
+	}()
+	utils.Cfg.ServiceSettings.EnableOutgoingWebhooks = true
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = true
+	utils.SetDefaultRolesBasedOnConfig()
+
+	var mutex sync.Mutex
+	requests := 0
+	signatures := []string{}
+	timestamps := []string{}
+	bodies := [][]byte{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		requests++
+		signatures = append(signatures, r.Header.Get(model.HEADER_OUTGOING_WEBHOOK_SIGNATURE))
+		timestamps = append(timestamps, r.Header.Get(model.HEADER_OUTGOING_WEBHOOK_TIMESTAMP))
+		bodies = append(bodies, body)
+
+		// Fail the first attempt so that the delivery is retried
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("try again later"))
+			return
+		}
+
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	hook := &model.OutgoingWebhook{ChannelId: th.BasicChannel.Id, TeamId: th.BasicChannel.TeamId, CallbackURLs: []string{ts.URL}}
+	rhook, resp := th.SystemAdminClient.CreateOutgoingWebhook(hook)
+	CheckNoError(t, resp)
+
+	post := th.CreatePost()
+
+	var deliveries []*model.OutgoingWebhookDelivery
+	for i := 0; ; i++ {
+		deliveries, resp = th.SystemAdminClient.GetOutgoingWebhookDeliveries(rhook.Id, 0, 10)
+		CheckNoError(t, resp)
+
+		if len(deliveries) == 2 && !deliveries[0].IsPending() {
+			break
+		} else if i == 100 {
+			t.Fatal("should have retried the delivery", len(deliveries))
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if deliveries[0].Attempt != 2 || deliveries[0].StatusCode != http.StatusOK || deliveries[0].PostId != post.Id || deliveries[0].CallbackURL != ts.URL {
+		t.Fatal("should have logged the successful attempt first", deliveries[0].ToJson())
+	}
+
+	if deliveries[1].Attempt != 1 || deliveries[1].StatusCode != http.StatusServiceUnavailable || deliveries[1].ResponseExcerpt != "try again later" {
+		t.Fatal("should have logged the failed attempt", deliveries[1].ToJson())
+	}
+
+	if len(rhook.SigningSecret) != model.OUTGOING_WEBHOOK_SIGNING_SECRET_LENGTH {
+		t.Fatal("should have returned the signing secret to the creator of the hook")
+	}
+
+	mutex.Lock()
+	for i, signature := range signatures {
+		timestamp, err := strconv.ParseInt(timestamps[i], 10, 64)
+		if err != nil || timestamp < post.CreateAt/1000 {
+			t.Fatal("should have sent the time of the request", timestamps[i])
+		}
+
+		if signature != rhook.Sign(timestamp, bodies[i]) {
+			t.Fatal("should have signed the request with the signing secret of the hook")
+		}
+
+		if strings.Contains(string(bodies[i]), rhook.SigningSecret) {
+			t.Fatal("shouldn't have sent the signing secret")
+		}
+	}
+	mutex.Unlock()
+
+	deliveries, resp = th.SystemAdminClient.GetOutgoingWebhookDeliveries(rhook.Id, 1, 1)
+	CheckNoError(t, resp)
+	if len(deliveries) != 1 || deliveries[0].Attempt != 1 {
+		t.Fatal("should page the deliveries")
+	}
+
+	_, resp = th.SystemAdminClient.GetOutgoingWebhookDeliveries("junk", 0, 10)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetOutgoingWebhookDeliveries(model.NewId(), 0, 10)
+	CheckInternalErrorStatus(t, resp)
+
+	_, resp = Client.GetOutgoingWebhookDeliveries(rhook.Id, 0, 10)
+	CheckForbiddenStatus(t, resp)
+
+	utils.Cfg.ServiceSettings.EnableOutgoingWebhooks = false
+	_, resp = th.SystemAdminClient.GetOutgoingWebhookDeliveries(rhook.Id, 0, 10)
+	CheckNotImplementedStatus(t, resp)
+}
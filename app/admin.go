@@ -97,6 +97,7 @@ func InvalidateAllCachesSkipSend() {
 	l4g.Info(utils.T("api.context.invalidate_all_caches"))
 	sessionCache.Purge()
 	ClearStatusCache()
+	InvalidateCacheForRolesSkipClusterSend()
 	store.ClearChannelCaches()
 	store.ClearUserCaches()
 	store.ClearPostCaches()
@@ -118,9 +119,28 @@ func ReloadConfig() {
 
 	// start/restart email batching job if necessary
 	InitEmailBatching()
+
+	SyncPluginsActiveState()
 }
 
 func SaveConfig(cfg *model.Config) *model.AppError {
+	oldCfg := utils.Cfg
+
+	if err := SaveConfigSkipClusterSend(cfg); err != nil {
+		return err
+	}
+
+	if einterfaces.GetClusterInterface() != nil {
+		err := einterfaces.GetClusterInterface().ConfigChanged(oldCfg, utils.Cfg, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func SaveConfigSkipClusterSend(cfg *model.Config) *model.AppError {
 	cfg.SetDefaults()
 	utils.Desanitize(cfg)
 
@@ -132,10 +152,6 @@ func SaveConfig(cfg *model.Config) *model.AppError {
 		return err
 	}
 
-	if *utils.Cfg.ClusterSettings.Enable {
-		return model.NewLocAppError("saveConfig", "ent.cluster.save_config.error", nil, "")
-	}
-
 	utils.DisableConfigWatch()
 	utils.SaveConfig(utils.CfgFileName, cfg)
 	utils.LoadConfig(utils.CfgFileName)
@@ -149,18 +165,11 @@ func SaveConfig(cfg *model.Config) *model.AppError {
 		}
 	}
 
-	// oldCfg := utils.Cfg
-	// Future feature is to sync the configuration files
-	// if einterfaces.GetClusterInterface() != nil {
-	// 	err := einterfaces.GetClusterInterface().ConfigChanged(cfg, oldCfg, true)
-	// 	if err != nil {
-	// 		return err
-	// 	}
-	// }
-
 	// start/restart email batching job if necessary
 	InitEmailBatching()
 
+	SyncPluginsActiveState()
+
 	return nil
 }
 
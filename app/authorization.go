@@ -21,7 +21,7 @@ func SessionHasPermissionToTeam(session model.Session, teamId string, permission
 
 	teamMember := session.GetTeamByTeamId(teamId)
 	if teamMember != nil {
-		if CheckIfRolesGrantPermission(teamMember.GetRoles(), permission.Id) {
+		if CheckIfRolesGrantPermission(getMemberRoles(session.IsGuest(), teamId, teamMember.GetRoles()), permission.Id) {
 			return true
 		}
 	}
@@ -36,18 +36,28 @@ func SessionHasPermissionToChannel(session model.Session, channelId string, perm
 
 	cmc := Srv.Store.Channel().GetAllChannelMembersForUser(session.UserId, true)
 
+	channel, err := GetChannel(channelId)
+
+	teamId := ""
+	if err == nil {
+		if !isPermissionGrantedInChannel(channel, permission) {
+			return false
+		}
+
+		teamId = channel.TeamId
+	}
+
 	var channelRoles []string
 	if cmcresult := <-cmc; cmcresult.Err == nil {
 		ids := cmcresult.Data.(map[string]string)
 		if roles, ok := ids[channelId]; ok {
-			channelRoles = strings.Fields(roles)
+			channelRoles = getMemberRoles(session.IsGuest(), teamId, strings.Fields(roles))
 			if CheckIfRolesGrantPermission(channelRoles, permission.Id) {
 				return true
 			}
 		}
 	}
 
-	channel, err := GetChannel(channelId)
 	if err == nil {
 		return SessionHasPermissionToTeam(session, channel.TeamId, permission)
 	}
@@ -56,17 +66,28 @@ func SessionHasPermissionToChannel(session model.Session, channelId string, perm
 }
 
 func SessionHasPermissionToChannelByPost(session model.Session, postId string, permission *model.Permission) bool {
+	var channel *model.Channel
+	teamId := ""
+	if result := <-Srv.Store.Channel().GetForPost(postId); result.Err == nil {
+		channel = result.Data.(*model.Channel)
+		teamId = channel.TeamId
+
+		if !isPermissionGrantedInChannel(channel, permission) {
+			return false
+		}
+	}
+
 	var channelMember *model.ChannelMember
 	if result := <-Srv.Store.Channel().GetMemberForPost(postId, session.UserId); result.Err == nil {
 		channelMember = result.Data.(*model.ChannelMember)
 
-		if CheckIfRolesGrantPermission(channelMember.GetRoles(), permission.Id) {
+		channelRoles := getMemberRoles(session.IsGuest(), teamId, channelMember.GetRoles())
+		if CheckIfRolesGrantPermission(channelRoles, permission.Id) {
 			return true
 		}
 	}
 
-	if result := <-Srv.Store.Channel().GetForPost(postId); result.Err == nil {
-		channel := result.Data.(*model.Channel)
+	if channel != nil {
 		return SessionHasPermissionToTeam(session, channel.TeamId, permission)
 	}
 
@@ -89,6 +110,24 @@ func SessionHasPermissionToUser(session model.Session, userId string) bool {
 	return false
 }
 
+// SessionHasPermissionToUserOrBot is like SessionHasPermissionToUser, but also lets the owner of a bot act on it, as
+// well as anyone who can manage other users' bots.
+func SessionHasPermissionToUserOrBot(session model.Session, userId string) bool {
+	if SessionHasPermissionToUser(session, userId) {
+		return true
+	}
+
+	if result := <-Srv.Store.Bot().Get(userId, true); result.Err == nil {
+		if bot := result.Data.(*model.Bot); bot.OwnerId == session.UserId {
+			return SessionHasPermissionTo(session, model.PERMISSION_MANAGE_BOTS)
+		}
+
+		return SessionHasPermissionTo(session, model.PERMISSION_MANAGE_OTHERS_BOTS)
+	}
+
+	return false
+}
+
 func SessionHasPermissionToPost(session model.Session, postId string, permission *model.Permission) bool {
 	post, err := GetSinglePost(postId)
 	if err != nil {
@@ -123,7 +162,7 @@ func HasPermissionToTeam(askingUserId string, teamId string, permission *model.P
 		return false
 	}
 
-	roles := teamMember.GetRoles()
+	roles := getMemberRoles(isUserGuest(askingUserId), teamId, teamMember.GetRoles())
 
 	if CheckIfRolesGrantPermission(roles, permission.Id) {
 		return true
@@ -137,17 +176,26 @@ func HasPermissionToChannel(askingUserId string, channelId string, permission *m
 		return false
 	}
 
+	channel, channelErr := GetChannel(channelId)
+
+	teamId := ""
+	if channelErr == nil {
+		if !isPermissionGrantedInChannel(channel, permission) {
+			return false
+		}
+
+		teamId = channel.TeamId
+	}
+
 	channelMember, err := GetChannelMember(channelId, askingUserId)
 	if err == nil {
-		roles := channelMember.GetRoles()
+		roles := getMemberRoles(isUserGuest(askingUserId), teamId, channelMember.GetRoles())
 		if CheckIfRolesGrantPermission(roles, permission.Id) {
 			return true
 		}
 	}
 
-	var channel *model.Channel
-	channel, err = GetChannel(channelId)
-	if err == nil {
+	if channelErr == nil {
 		return HasPermissionToTeam(askingUserId, channel.TeamId, permission)
 	}
 
@@ -155,17 +203,28 @@ func HasPermissionToChannel(askingUserId string, channelId string, permission *m
 }
 
 func HasPermissionToChannelByPost(askingUserId string, postId string, permission *model.Permission) bool {
+	var channel *model.Channel
+	teamId := ""
+	if result := <-Srv.Store.Channel().GetForPost(postId); result.Err == nil {
+		channel = result.Data.(*model.Channel)
+		teamId = channel.TeamId
+
+		if !isPermissionGrantedInChannel(channel, permission) {
+			return false
+		}
+	}
+
 	var channelMember *model.ChannelMember
 	if result := <-Srv.Store.Channel().GetMemberForPost(postId, askingUserId); result.Err == nil {
 		channelMember = result.Data.(*model.ChannelMember)
 
-		if CheckIfRolesGrantPermission(channelMember.GetRoles(), permission.Id) {
+		channelRoles := getMemberRoles(isUserGuest(askingUserId), teamId, channelMember.GetRoles())
+		if CheckIfRolesGrantPermission(channelRoles, permission.Id) {
 			return true
 		}
 	}
 
-	if result := <-Srv.Store.Channel().GetForPost(postId); result.Err == nil {
-		channel := result.Data.(*model.Channel)
+	if channel != nil {
 		return HasPermissionToTeam(askingUserId, channel.TeamId, permission)
 	}
 
@@ -184,18 +243,56 @@ func HasPermissionToUser(askingUserId string, userId string) bool {
 	return false
 }
 
+// getMemberRoles returns the roles that the permissions of a team or channel member are checked against. Guests get the
+// guest roles in place of their team and channel roles, while the roles of the team's scheme take the place of the
+// built-in ones for everyone else.
+func getMemberRoles(isGuest bool, teamId string, roles []string) []string {
+	if isGuest {
+		guestRoles := make([]string, len(roles))
+		for i, roleId := range roles {
+			guestRoles[i] = model.GuestRoleFor(roleId)
+		}
+
+		return guestRoles
+	}
+
+	return applyTeamScheme(teamId, roles)
+}
+
+// isPermissionGrantedInChannel returns false for the permissions that nobody has in a channel no matter their roles.
+// Archived channels are read-only, so they can only be read or unarchived by those who could archive them.
+func isPermissionGrantedInChannel(channel *model.Channel, permission *model.Permission) bool {
+	if channel.DeleteAt == 0 {
+		return true
+	}
+
+	switch permission.Id {
+	case model.PERMISSION_READ_CHANNEL.Id, model.PERMISSION_DELETE_PUBLIC_CHANNEL.Id, model.PERMISSION_DELETE_PRIVATE_CHANNEL.Id:
+		return true
+	}
+
+	return false
+}
+
+func isUserGuest(userId string) bool {
+	user, err := GetUser(userId)
+	if err != nil {
+		// Err on the side of granting less
+		return true
+	}
+
+	return user.IsGuest()
+}
+
+// CheckIfRolesGrantPermission returns true if any of the given roles has the permission. Roles are read through the
+// role cache, so changes that are saved to them take effect without a restart.
 func CheckIfRolesGrantPermission(roles []string, permissionId string) bool {
 	for _, roleId := range roles {
-		if role, ok := model.BuiltInRoles[roleId]; !ok {
-			l4g.Debug("Bad role in system " + roleId)
+		if role, err := GetRole(roleId); err != nil {
+			l4g.Debug("Bad role in system " + roleId + ", " + err.Error())
 			return false
-		} else {
-			permissions := role.Permissions
-			for _, permission := range permissions {
-				if permission == permissionId {
-					return true
-				}
-			}
+		} else if role.HasPermission(permissionId) {
+			return true
 		}
 	}
 
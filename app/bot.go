@@ -0,0 +1,227 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	DISABLE_USER_BOTS_PAGE_SIZE = 100
+)
+
+// CreateBot creates a bot along with the user that it posts as. The bot's owner must be set by the caller.
+func CreateBot(bot *model.Bot) (*model.Bot, *model.AppError) {
+	if !*utils.Cfg.ServiceSettings.EnableBotAccountCreation {
+		return nil, model.NewAppError("CreateBot", "app.bot.create.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	if err := bot.IsValidUserFields(); err != nil {
+		return nil, err
+	}
+
+	if result := <-Srv.Store.User().Get(bot.OwnerId); result.Err != nil {
+		return nil, result.Err
+	} else if result.Data.(*model.User).IsBot {
+		return nil, model.NewAppError("CreateBot", "app.bot.owned_by_bot.app_error", nil, "owner_id="+bot.OwnerId, http.StatusBadRequest)
+	}
+
+	user := bot.ToUser()
+	user.Roles = model.ROLE_SYSTEM_USER.Id
+	user.Locale = *utils.Cfg.LocalizationSettings.DefaultClientLocale
+
+	ruser, err := createUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	bot.UserId = ruser.Id
+
+	if result := <-Srv.Store.Bot().Save(bot); result.Err != nil {
+		<-Srv.Store.User().PermanentDelete(ruser.Id)
+		return nil, result.Err
+	}
+
+	bot.SetUserFields(ruser)
+
+	// This message goes to everyone, so the teamId, channelId and userId are irrelevant
+	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_NEW_USER, "", "", "", nil)
+	message.Add("user_id", ruser.Id)
+	go Publish(message)
+
+	return bot, nil
+}
+
+func GetBot(botUserId string, includeDeleted bool) (*model.Bot, *model.AppError) {
+	var bot *model.Bot
+	if result := <-Srv.Store.Bot().Get(botUserId, includeDeleted); result.Err != nil {
+		return nil, result.Err
+	} else {
+		bot = result.Data.(*model.Bot)
+	}
+
+	user, err := GetUser(botUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	bot.SetUserFields(user)
+
+	return bot, nil
+}
+
+// GetBotsPage returns a page of bots. If ownerId is set, only the bots owned by that user are returned.
+func GetBotsPage(ownerId string, includeDeleted bool, page int, perPage int) ([]*model.Bot, *model.AppError) {
+	var bots []*model.Bot
+	if result := <-Srv.Store.Bot().GetAll(ownerId, includeDeleted, page*perPage, perPage); result.Err != nil {
+		return nil, result.Err
+	} else {
+		bots = result.Data.([]*model.Bot)
+	}
+
+	if len(bots) == 0 {
+		return bots, nil
+	}
+
+	userIds := make([]string, len(bots))
+	for i, bot := range bots {
+		userIds[i] = bot.UserId
+	}
+
+	if result := <-Srv.Store.User().GetProfileByIds(userIds, true); result.Err != nil {
+		return nil, result.Err
+	} else {
+		users := make(map[string]*model.User)
+		for _, user := range result.Data.([]*model.User) {
+			users[user.Id] = user
+		}
+
+		for _, bot := range bots {
+			if user, ok := users[bot.UserId]; ok {
+				bot.SetUserFields(user)
+			}
+		}
+	}
+
+	return bots, nil
+}
+
+// PatchBot changes a bot's description along with the username and display name that are kept on its user.
+func PatchBot(botUserId string, patch *model.BotPatch) (*model.Bot, *model.AppError) {
+	bot, err := GetBot(botUserId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	bot.Patch(patch)
+
+	if err := bot.IsValidUserFields(); err != nil {
+		return nil, err
+	}
+
+	user, err := GetUser(botUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Username = bot.Username
+	user.FirstName = bot.DisplayName
+
+	ruser, err := UpdateUser(user, false)
+	if err != nil {
+		return nil, err
+	}
+
+	sendUpdatedUserEvent(*ruser, false)
+
+	if result := <-Srv.Store.Bot().Update(bot); result.Err != nil {
+		return nil, result.Err
+	}
+
+	bot.SetUserFields(ruser)
+
+	return bot, nil
+}
+
+// UpdateBotActive enables or disables a bot. Disabling a bot deactivates its user, which revokes the sessions of its
+// personal access tokens.
+func UpdateBotActive(botUserId string, active bool) (*model.Bot, *model.AppError) {
+	bot, err := GetBot(botUserId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := GetUser(botUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	ruser, err := UpdateActive(user, active)
+	if err != nil {
+		return nil, err
+	}
+
+	bot.DeleteAt = ruser.DeleteAt
+
+	if result := <-Srv.Store.Bot().Update(bot); result.Err != nil {
+		return nil, result.Err
+	}
+
+	return bot, nil
+}
+
+// UpdateBotOwner hands a bot over to another user.
+func UpdateBotOwner(botUserId string, ownerId string) (*model.Bot, *model.AppError) {
+	bot, err := GetBot(botUserId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := GetUser(ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	if owner.IsBot {
+		return nil, model.NewAppError("UpdateBotOwner", "app.bot.owned_by_bot.app_error", nil, "owner_id="+ownerId, http.StatusBadRequest)
+	}
+
+	bot.OwnerId = ownerId
+
+	if result := <-Srv.Store.Bot().Update(bot); result.Err != nil {
+		return nil, result.Err
+	}
+
+	return bot, nil
+}
+
+// disableUserBots disables the bots owned by a user, which is done when the user is deactivated so that nobody is
+// left posting through them.
+func disableUserBots(userId string) {
+	for {
+		var bots []*model.Bot
+		if result := <-Srv.Store.Bot().GetAll(userId, false, 0, DISABLE_USER_BOTS_PAGE_SIZE); result.Err != nil {
+			l4g.Error(utils.T("app.bot.disable_user_bots.error"), userId, result.Err.Error())
+			return
+		} else {
+			bots = result.Data.([]*model.Bot)
+		}
+
+		for _, bot := range bots {
+			if _, err := UpdateBotActive(bot.UserId, false); err != nil {
+				l4g.Error(utils.T("app.bot.disable_user_bots.error"), userId, err.Error())
+				return
+			}
+		}
+
+		if len(bots) < DISABLE_USER_BOTS_PAGE_SIZE {
+			return
+		}
+	}
+}
@@ -54,6 +54,8 @@ func JoinDefaultChannels(teamId string, user *model.User, channelRole string, us
 
 		if cmResult := <-Srv.Store.Channel().SaveMember(cm); cmResult.Err != nil {
 			err = cmResult.Err
+		} else {
+			go pluginsUserHasJoinedChannel(cm)
 		}
 
 		if requestor == nil {
@@ -79,6 +81,8 @@ func JoinDefaultChannels(teamId string, user *model.User, channelRole string, us
 
 		if cmResult := <-Srv.Store.Channel().SaveMember(cm); cmResult.Err != nil {
 			err = cmResult.Err
+		} else {
+			go pluginsUserHasJoinedChannel(cm)
 		}
 
 		if requestor == nil {
@@ -163,12 +167,23 @@ func CreateDirectChannel(userId string, otherUserId string) (*model.Channel, *mo
 	uc1 := Srv.Store.User().Get(userId)
 	uc2 := Srv.Store.User().Get(otherUserId)
 
+	var user, otherUser *model.User
 	if result := <-uc1; result.Err != nil {
 		return nil, model.NewAppError("CreateDirectChannel", "api.channel.create_direct_channel.invalid_user.app_error", nil, userId, http.StatusBadRequest)
+	} else {
+		user = result.Data.(*model.User)
 	}
 
 	if result := <-uc2; result.Err != nil {
 		return nil, model.NewAppError("CreateDirectChannel", "api.channel.create_direct_channel.invalid_user.app_error", nil, otherUserId, http.StatusBadRequest)
+	} else {
+		otherUser = result.Data.(*model.User)
+	}
+
+	if user.IsGuest() || otherUser.IsGuest() {
+		if err := checkGuestCanMessage(user.Id, otherUser.Id); err != nil {
+			return nil, err
+		}
 	}
 
 	if result := <-Srv.Store.Channel().CreateDirectChannel(userId, otherUserId); result.Err != nil {
@@ -193,6 +208,22 @@ func CreateDirectChannel(userId string, otherUserId string) (*model.Channel, *mo
 	}
 }
 
+// checkGuestCanMessage returns an error unless two users share a channel, since guests may only message the people in
+// the channels they were added to.
+func checkGuestCanMessage(userId string, otherUserId string) *model.AppError {
+	if userId == otherUserId {
+		return nil
+	}
+
+	if result := <-Srv.Store.Channel().UsersShareChannel(userId, otherUserId); result.Err != nil {
+		return result.Err
+	} else if !result.Data.(bool) {
+		return model.NewAppError("checkGuestCanMessage", "api.channel.create_direct_channel.guest.app_error", nil, "user_id="+userId+", other_user_id="+otherUserId, http.StatusForbidden)
+	}
+
+	return nil
+}
+
 func WaitForChannelMembership(channelId string, userId string) {
 	if len(utils.Cfg.SqlSettings.DataSourceReplicas) > 0 {
 		now := model.GetMillis()
@@ -234,6 +265,18 @@ func CreateGroupChannel(userIds []string, creatorId string) (*model.Channel, *mo
 		return nil, model.NewAppError("CreateGroupChannel", "api.channel.create_group.bad_user.app_error", nil, "user_ids="+model.ArrayToJson(userIds), http.StatusBadRequest)
 	}
 
+	for _, user := range users {
+		if !user.IsGuest() {
+			continue
+		}
+
+		for _, otherUser := range users {
+			if err := checkGuestCanMessage(user.Id, otherUser.Id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	group := &model.Channel{
 		Name:        model.GetGroupNameFromUserIds(userIds),
 		DisplayName: model.GetGroupDisplayNameFromUsers(users, true),
@@ -439,6 +482,71 @@ func DeleteChannel(channel *model.Channel, userId string) *model.AppError {
 	return nil
 }
 
+// RestoreChannel unarchives a channel that was deleted. A system message is posted to the channel by the user who
+// restored it, if there is one. The webhooks that were removed when the channel was archived aren't brought back.
+func RestoreChannel(channel *model.Channel, userId string) (*model.Channel, *model.AppError) {
+	if channel.DeleteAt == 0 {
+		return nil, model.NewAppError("RestoreChannel", "api.channel.restore_channel.restored.app_error", nil, "channel_id="+channel.Id, http.StatusBadRequest)
+	}
+
+	if result := <-Srv.Store.Channel().Restore(channel.Id, model.GetMillis()); result.Err != nil {
+		return nil, result.Err
+	}
+	InvalidateCacheForChannel(channel)
+
+	channel.DeleteAt = 0
+
+	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_CHANNEL_RESTORED, channel.TeamId, "", "", nil)
+	message.Add("channel_id", channel.Id)
+	Publish(message)
+
+	if userId != "" {
+		if err := postChannelRestoredMessage(userId, channel); err != nil {
+			l4g.Error(err.Error())
+		}
+	}
+
+	return channel, nil
+}
+
+func postChannelRestoredMessage(userId string, channel *model.Channel) *model.AppError {
+	var user *model.User
+	if result := <-Srv.Store.User().Get(userId); result.Err != nil {
+		return result.Err
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	T := utils.GetUserTranslations(user.Locale)
+
+	post := &model.Post{
+		ChannelId: channel.Id,
+		Message:   fmt.Sprintf(T("api.channel.restore_channel.unarchived"), user.Username),
+		Type:      model.POST_CHANNEL_RESTORED,
+		UserId:    userId,
+		Props: model.StringInterface{
+			"username": user.Username,
+		},
+	}
+
+	if _, err := CreatePost(post, channel.TeamId, false); err != nil {
+		return model.NewAppError("postChannelRestoredMessage", "api.channel.restore_channel.post.error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// checkChannelIsNotArchived returns an error if a channel has been archived, since archived channels are read-only.
+func checkChannelIsNotArchived(where string, channelId string) *model.AppError {
+	if result := <-Srv.Store.Channel().Get(channelId, true); result.Err != nil {
+		return result.Err
+	} else if result.Data.(*model.Channel).DeleteAt != 0 {
+		return model.NewAppError(where, "app.channel.archived.app_error", nil, "channel_id="+channelId, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
 func addUserToChannel(user *model.User, channel *model.Channel) (*model.ChannelMember, *model.AppError) {
 	if channel.DeleteAt > 0 {
 		return nil, model.NewLocAppError("AddUserToChannel", "api.channel.add_user_to_channel.deleted.app_error", nil, "")
@@ -485,6 +593,8 @@ func addUserToChannel(user *model.User, channel *model.Channel) (*model.ChannelM
 	InvalidateCacheForUser(user.Id)
 	InvalidateCacheForChannelMembers(channel.Id)
 
+	go pluginsUserHasJoinedChannel(newMember)
+
 	return newMember, nil
 }
 
@@ -503,6 +613,8 @@ func AddUserToChannel(user *model.User, channel *model.Channel) (*model.ChannelM
 	return newMember, nil
 }
 
+// AddChannelMember adds a user to a channel on behalf of another one. An empty userRequestorId means that the server
+// itself added the user, such as for a plugin.
 func AddChannelMember(userId string, channel *model.Channel, userRequestorId string) (*model.ChannelMember, *model.AppError) {
 	var user *model.User
 	var err *model.AppError
@@ -512,8 +624,10 @@ func AddChannelMember(userId string, channel *model.Channel, userRequestorId str
 	}
 
 	var userRequestor *model.User
-	if userRequestor, err = GetUser(userRequestorId); err != nil {
-		return nil, err
+	if userRequestorId != "" {
+		if userRequestor, err = GetUser(userRequestorId); err != nil {
+			return nil, err
+		}
 	}
 
 	cm, err := AddUserToChannel(user, channel)
@@ -521,13 +635,15 @@ func AddChannelMember(userId string, channel *model.Channel, userRequestorId str
 		return nil, err
 	}
 
-	if userId == userRequestorId {
+	if userRequestor == nil || userId == userRequestorId {
 		postJoinChannelMessage(user, channel)
 	} else {
 		go PostAddToChannelMessage(userRequestor, user, channel)
 	}
 
-	UpdateChannelLastViewedAt([]string{channel.Id}, userRequestor.Id)
+	if userRequestor != nil {
+		UpdateChannelLastViewedAt([]string{channel.Id}, userRequestor.Id)
+	}
 
 	return cm, nil
 }
@@ -724,6 +840,15 @@ func GetChannelsForUser(teamId string, userId string) (*model.ChannelList, *mode
 	}
 }
 
+// GetArchivedChannelsForUser returns the archived channels on a team that a user is a member of.
+func GetArchivedChannelsForUser(teamId string, userId string) (*model.ChannelList, *model.AppError) {
+	if result := <-Srv.Store.Channel().GetDeletedForUser(teamId, userId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.ChannelList), nil
+	}
+}
+
 func GetChannelsUserNotIn(teamId string, userId string, offset int, limit int) (*model.ChannelList, *model.AppError) {
 	if result := <-Srv.Store.Channel().GetMoreChannels(teamId, userId, offset, limit); result.Err != nil {
 		return nil, result.Err
@@ -74,6 +74,13 @@ func ListAutocompleteCommands(teamId string, T goi18n.TranslateFunc) ([]*model.C
 		}
 	}
 
+	for _, cmd := range getPluginCommandsForTeam(teamId) {
+		if cmd.AutoComplete && !seen[cmd.Trigger] {
+			seen[cmd.Trigger] = true
+			commands = append(commands, cmd)
+		}
+	}
+
 	if *utils.Cfg.ServiceSettings.EnableCommands {
 		if result := <-Srv.Store.Command().GetByTeam(teamId); result.Err != nil {
 			return nil, result.Err
@@ -116,6 +123,13 @@ func ListAllCommands(teamId string, T goi18n.TranslateFunc) ([]*model.Command, *
 		}
 	}
 
+	for _, cmd := range getPluginCommandsForTeam(teamId) {
+		if !seen[cmd.Trigger] {
+			seen[cmd.Trigger] = true
+			commands = append(commands, cmd)
+		}
+	}
+
 	if *utils.Cfg.ServiceSettings.EnableCommands {
 		if result := <-Srv.Store.Command().GetByTeam(teamId); result.Err != nil {
 			return nil, result.Err
@@ -141,9 +155,17 @@ func ExecuteCommand(args *model.CommandArgs) (*model.CommandResponse, *model.App
 	message := strings.Join(parts[1:], " ")
 	provider := GetCommandProvider(trigger)
 
+	if _, triggerId, err := GenerateTriggerId(args.UserId); err != nil {
+		return nil, err
+	} else {
+		args.TriggerId = triggerId
+	}
+
 	if provider != nil {
 		response := provider.DoCommand(args, message)
 		return HandleCommandResponse(provider.GetCommand(args.T), args, response, true)
+	} else if response, err, found := executePluginCommand(args, trigger); found {
+		return response, err
 	} else {
 		if !*utils.Cfg.ServiceSettings.EnableCommands {
 			return nil, model.NewAppError("ExecuteCommand", "api.command.disabled.app_error", nil, "", http.StatusNotImplemented)
@@ -198,6 +220,7 @@ func ExecuteCommand(args *model.CommandArgs) (*model.CommandResponse, *model.App
 					p.Set("command", "/"+trigger)
 					p.Set("text", message)
 					p.Set("response_url", "not supported yet")
+					p.Set("trigger_id", args.TriggerId)
 
 					method := "POST"
 					if cmd.Method == model.COMMAND_METHOD_GET {
@@ -5,14 +5,17 @@ package app
 
 import (
 	"io/ioutil"
+	"net/http"
+	"time"
 
 	"github.com/primefour/servers/einterfaces"
+	"github.com/primefour/servers/jobs"
 	"github.com/primefour/servers/model"
 	"github.com/primefour/servers/utils"
 )
 
 func GetComplianceReports(page, perPage int) (model.Compliances, *model.AppError) {
-	if !*utils.Cfg.ComplianceSettings.Enable || !utils.IsLicensed || !*utils.License.Features.Compliance {
+	if !*utils.Cfg.ComplianceSettings.Enable {
 		return nil, model.NewLocAppError("GetComplianceReports", "ent.compliance.licence_disable.app_error", nil, "")
 	}
 
@@ -24,7 +27,7 @@ func GetComplianceReports(page, perPage int) (model.Compliances, *model.AppError
 }
 
 func SaveComplianceReport(job *model.Compliance) (*model.Compliance, *model.AppError) {
-	if !*utils.Cfg.ComplianceSettings.Enable || !utils.IsLicensed || !*utils.License.Features.Compliance || einterfaces.GetComplianceInterface() == nil {
+	if !*utils.Cfg.ComplianceSettings.Enable || einterfaces.GetComplianceInterface() == nil {
 		return nil, model.NewLocAppError("saveComplianceReport", "ent.compliance.licence_disable.app_error", nil, "")
 	}
 
@@ -34,14 +37,17 @@ func SaveComplianceReport(job *model.Compliance) (*model.Compliance, *model.AppE
 		return nil, result.Err
 	} else {
 		job = result.Data.(*model.Compliance)
-		go einterfaces.GetComplianceInterface().RunComplianceJob(job)
+	}
+
+	if _, err := jobs.CreateJob(model.JOB_TYPE_COMPLIANCE_EXPORT, map[string]string{"compliance_id": job.Id}); err != nil {
+		return nil, err
 	}
 
 	return job, nil
 }
 
 func GetComplianceReport(reportId string) (*model.Compliance, *model.AppError) {
-	if !*utils.Cfg.ComplianceSettings.Enable || !utils.IsLicensed || !*utils.License.Features.Compliance || einterfaces.GetComplianceInterface() == nil {
+	if !*utils.Cfg.ComplianceSettings.Enable || einterfaces.GetComplianceInterface() == nil {
 		return nil, model.NewLocAppError("downloadComplianceReport", "ent.compliance.licence_disable.app_error", nil, "")
 	}
 
@@ -60,3 +66,60 @@ func GetComplianceFile(job *model.Compliance) ([]byte, *model.AppError) {
 		return f, nil
 	}
 }
+
+func isComplianceEnabled() bool {
+	return *utils.Cfg.ComplianceSettings.Enable
+}
+
+func isDailyComplianceEnabled() bool {
+	return isComplianceEnabled() && *utils.Cfg.ComplianceSettings.EnableDaily
+}
+
+// ComplianceExportJob writes the compliance report named by compliance_id in the job's data. Jobs without a report,
+// which are the ones created by the daily scheduler, create a daily report covering the previous day.
+func ComplianceExportJob(job *model.Job, cancel <-chan interface{}) *model.AppError {
+	complianceI := einterfaces.GetComplianceInterface()
+	if !isComplianceEnabled() || complianceI == nil {
+		return model.NewAppError("ComplianceExportJob", "ent.compliance.licence_disable.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	var compliance *model.Compliance
+	if complianceId, ok := job.Data["compliance_id"]; ok {
+		if result := <-Srv.Store.Compliance().Get(complianceId); result.Err != nil {
+			return result.Err
+		} else {
+			compliance = result.Data.(*model.Compliance)
+		}
+	} else {
+		var err *model.AppError
+		if compliance, err = createDailyComplianceReport(time.Now()); err != nil {
+			return err
+		}
+
+		if job.Data == nil {
+			job.Data = make(model.StringMap)
+		}
+		job.Data["compliance_id"] = compliance.Id
+	}
+
+	return complianceI.RunComplianceJob(compliance)
+}
+
+func createDailyComplianceReport(now time.Time) (*model.Compliance, *model.AppError) {
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := end.AddDate(0, 0, -1)
+
+	compliance := &model.Compliance{
+		Desc:    start.Format("2006-01-02"),
+		Type:    model.COMPLIANCE_TYPE_DAILY,
+		UserId:  "system",
+		StartAt: start.UnixNano() / int64(time.Millisecond),
+		EndAt:   end.UnixNano()/int64(time.Millisecond) - 1,
+	}
+
+	if result := <-Srv.Store.Compliance().Save(compliance); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.Compliance), nil
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	DATA_RETENTION_BATCH_SIZE = 1000
+	DATA_RETENTION_DAY        = 24 * 60 * 60 * 1000
+)
+
+// dataRetentionScope is a set of channels along with the times before which posts and files in them are deleted. An
+// end time of 0 means that nothing of that kind is deleted. A scope with a teamId covers that team's channels, and the
+// scope without one covers every other channel, including direct and group channels.
+type dataRetentionScope struct {
+	teamId          string
+	excludedTeamIds []string
+	messageEndTime  int64
+	fileEndTime     int64
+}
+
+func isDataRetentionEnabled() bool {
+	return *utils.Cfg.DataRetentionSettings.EnableMessageDeletion || *utils.Cfg.DataRetentionSettings.EnableFileDeletion
+}
+
+// getDataRetentionScopes turns the data retention settings into one scope for each team with its own policy and one
+// for everything else.
+func getDataRetentionScopes(settings *model.DataRetentionSettings, now int64) []dataRetentionScope {
+	endTime := func(enabled bool, days int, teamDays *int) int64 {
+		if !enabled {
+			return 0
+		}
+
+		if teamDays != nil {
+			days = *teamDays
+		}
+
+		return now - int64(days)*DATA_RETENTION_DAY
+	}
+
+	scopes := []dataRetentionScope{}
+	teamIds := []string{}
+
+	for _, policy := range settings.TeamPolicies {
+		scopes = append(scopes, dataRetentionScope{
+			teamId:         policy.TeamId,
+			messageEndTime: endTime(*settings.EnableMessageDeletion, *settings.MessageRetentionDays, policy.MessageRetentionDays),
+			fileEndTime:    endTime(*settings.EnableFileDeletion, *settings.FileRetentionDays, policy.FileRetentionDays),
+		})
+
+		teamIds = append(teamIds, policy.TeamId)
+	}
+
+	scopes = append(scopes, dataRetentionScope{
+		excludedTeamIds: teamIds,
+		messageEndTime:  endTime(*settings.EnableMessageDeletion, *settings.MessageRetentionDays, nil),
+		fileEndTime:     endTime(*settings.EnableFileDeletion, *settings.FileRetentionDays, nil),
+	})
+
+	return scopes
+}
+
+// DataRetentionJob permanently deletes the posts and files that are older than the data retention settings allow. The
+// number of posts and files that were deleted is stored in the job's data.
+func DataRetentionJob(job *model.Job, cancel <-chan interface{}) *model.AppError {
+	if !isDataRetentionEnabled() {
+		return model.NewAppError("DataRetentionJob", "app.data_retention.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	var deletedPosts, deletedFiles int64
+	defer func() {
+		if job.Data == nil {
+			job.Data = make(model.StringMap)
+		}
+		job.Data["deleted_posts"] = strconv.FormatInt(deletedPosts, 10)
+		job.Data["deleted_files"] = strconv.FormatInt(deletedFiles, 10)
+
+		if deletedPosts > 0 || deletedFiles > 0 {
+			InvalidateAllCaches()
+		}
+	}()
+
+	for _, scope := range getDataRetentionScopes(&utils.Cfg.DataRetentionSettings, model.GetMillis()) {
+		if scope.messageEndTime != 0 {
+			posts, files, err := purgeOldPosts(scope, cancel)
+			deletedPosts += posts
+			deletedFiles += files
+			if err != nil {
+				return err
+			}
+		}
+
+		if scope.fileEndTime != 0 {
+			files, err := purgeOldFiles(scope, cancel)
+			deletedFiles += files
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// purgeOldPosts deletes the posts in a scope in batches along with their reactions and files. It returns the number
+// of posts and files that were deleted.
+func purgeOldPosts(scope dataRetentionScope, cancel <-chan interface{}) (int64, int64, *model.AppError) {
+	var deletedPosts, deletedFiles int64
+
+	for {
+		select {
+		case <-cancel:
+			return deletedPosts, deletedFiles, nil
+		default:
+		}
+
+		result := <-Srv.Store.Post().GetIdsForDataRetention(scope.messageEndTime, scope.teamId, scope.excludedTeamIds, DATA_RETENTION_BATCH_SIZE)
+		if result.Err != nil {
+			return deletedPosts, deletedFiles, result.Err
+		}
+
+		postIds := result.Data.([]string)
+		if len(postIds) == 0 {
+			return deletedPosts, deletedFiles, nil
+		}
+
+		fresult := <-Srv.Store.FileInfo().GetForPostIds(postIds)
+		if fresult.Err != nil {
+			return deletedPosts, deletedFiles, fresult.Err
+		}
+
+		files, err := removeFileInfos(fresult.Data.([]*model.FileInfo))
+		deletedFiles += files
+		if err != nil {
+			return deletedPosts, deletedFiles, err
+		}
+
+		if rresult := <-Srv.Store.Reaction().PermanentDeleteByPostIds(postIds); rresult.Err != nil {
+			return deletedPosts, deletedFiles, rresult.Err
+		}
+
+		presult := <-Srv.Store.Post().PermanentDeleteByIds(postIds)
+		if presult.Err != nil {
+			return deletedPosts, deletedFiles, presult.Err
+		}
+
+		deleted := presult.Data.(int64)
+		deletedPosts += deleted
+
+		deletePostsFromIndex(postIds)
+
+		if deleted == 0 {
+			// Nothing could be deleted, so the next batch would be the same as this one
+			return deletedPosts, deletedFiles, nil
+		}
+	}
+}
+
+// purgeOldFiles deletes the files in a scope in batches. It returns the number of files that were deleted.
+func purgeOldFiles(scope dataRetentionScope, cancel <-chan interface{}) (int64, *model.AppError) {
+	var deletedFiles int64
+
+	for {
+		select {
+		case <-cancel:
+			return deletedFiles, nil
+		default:
+		}
+
+		result := <-Srv.Store.FileInfo().GetForDataRetention(scope.fileEndTime, scope.teamId, scope.excludedTeamIds, DATA_RETENTION_BATCH_SIZE)
+		if result.Err != nil {
+			return deletedFiles, result.Err
+		}
+
+		infos := result.Data.([]*model.FileInfo)
+		if len(infos) == 0 {
+			return deletedFiles, nil
+		}
+
+		deleted, err := removeFileInfos(infos)
+		deletedFiles += deleted
+		if err != nil {
+			return deletedFiles, err
+		} else if deleted == 0 {
+			return deletedFiles, nil
+		}
+	}
+}
+
+// removeFileInfos removes the stored objects for the given files and then deletes their file infos. Objects that
+// can't be removed are logged and skipped so that a missing file doesn't stop the rest from being deleted.
+func removeFileInfos(infos []*model.FileInfo) (int64, *model.AppError) {
+	if len(infos) == 0 {
+		return 0, nil
+	}
+
+	fileIds := make([]string, 0, len(infos))
+	for _, info := range infos {
+		for _, path := range []string{info.Path, info.ThumbnailPath, info.PreviewPath} {
+			if path == "" {
+				continue
+			}
+
+			if err := RemoveFile(path); err != nil {
+				l4g.Warn(utils.T("app.data_retention.remove_file.warn"), path, err.Error())
+			}
+		}
+
+		fileIds = append(fileIds, info.Id)
+
+		if info.PostId != "" {
+			Srv.Store.FileInfo().InvalidateFileInfosForPostCache(info.PostId)
+		}
+	}
+
+	if result := <-Srv.Store.FileInfo().PermanentDeleteByIds(fileIds); result.Err != nil {
+		return 0, result.Err
+	} else {
+		return result.Data.(int64), nil
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func TestGetDataRetentionScopes(t *testing.T) {
+	settings := model.DataRetentionSettings{}
+	settings.EnableMessageDeletion = new(bool)
+	settings.EnableFileDeletion = new(bool)
+	settings.MessageRetentionDays = new(int)
+	settings.FileRetentionDays = new(int)
+
+	*settings.EnableMessageDeletion = true
+	*settings.MessageRetentionDays = 10
+	*settings.FileRetentionDays = 20
+
+	now := int64(100 * DATA_RETENTION_DAY)
+
+	scopes := getDataRetentionScopes(&settings, now)
+	if len(scopes) != 1 {
+		t.Fatal("should only have the default scope")
+	} else if scopes[0].teamId != "" || len(scopes[0].excludedTeamIds) != 0 {
+		t.Fatal("default scope should cover every channel")
+	} else if scopes[0].messageEndTime != 90*DATA_RETENTION_DAY {
+		t.Fatal("default scope should use the default message retention")
+	} else if scopes[0].fileEndTime != 0 {
+		t.Fatal("files shouldn't be deleted when file deletion is disabled")
+	}
+
+	teamDays := 5
+	teamId := model.NewId()
+	settings.TeamPolicies = []model.DataRetentionTeamPolicy{
+		{TeamId: teamId, MessageRetentionDays: &teamDays},
+	}
+	*settings.EnableFileDeletion = true
+
+	scopes = getDataRetentionScopes(&settings, now)
+	if len(scopes) != 2 {
+		t.Fatal("should have a scope for the team and a default scope")
+	}
+
+	if scopes[0].teamId != teamId {
+		t.Fatal("first scope should be for the team")
+	} else if scopes[0].messageEndTime != 95*DATA_RETENTION_DAY {
+		t.Fatal("team scope should use the team's message retention")
+	} else if scopes[0].fileEndTime != 80*DATA_RETENTION_DAY {
+		t.Fatal("team scope should fall back to the default file retention")
+	}
+
+	if scopes[1].teamId != "" || len(scopes[1].excludedTeamIds) != 1 || scopes[1].excludedTeamIds[0] != teamId {
+		t.Fatal("default scope should exclude the team with its own policy")
+	}
+}
+
+func TestDataRetentionJob(t *testing.T) {
+	th := Setup().InitBasic()
+
+	enableMessageDeletion := *utils.Cfg.DataRetentionSettings.EnableMessageDeletion
+	messageRetentionDays := *utils.Cfg.DataRetentionSettings.MessageRetentionDays
+	teamPolicies := utils.Cfg.DataRetentionSettings.TeamPolicies
+	defer func() {
+		*utils.Cfg.DataRetentionSettings.EnableMessageDeletion = enableMessageDeletion
+		*utils.Cfg.DataRetentionSettings.MessageRetentionDays = messageRetentionDays
+		utils.Cfg.DataRetentionSettings.TeamPolicies = teamPolicies
+	}()
+
+	// Only delete posts on this team so that the test doesn't delete other tests' posts
+	*utils.Cfg.DataRetentionSettings.EnableMessageDeletion = true
+	*utils.Cfg.DataRetentionSettings.MessageRetentionDays = 100000
+
+	days := 1
+	utils.Cfg.DataRetentionSettings.TeamPolicies = []model.DataRetentionTeamPolicy{
+		{TeamId: th.BasicTeam.Id, MessageRetentionDays: &days},
+	}
+
+	oldPost, err := CreatePost(&model.Post{
+		UserId:    th.BasicUser.Id,
+		ChannelId: th.BasicChannel.Id,
+		Message:   "old message",
+		CreateAt:  model.GetMillis() - 2*DATA_RETENTION_DAY,
+	}, th.BasicTeam.Id, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := SaveReactionForPost(&model.Reaction{UserId: th.BasicUser.Id, PostId: oldPost.Id, EmojiName: "smile"}); err != nil {
+		t.Fatal(err)
+	}
+
+	newPost, err := CreatePost(&model.Post{
+		UserId:    th.BasicUser.Id,
+		ChannelId: th.BasicChannel.Id,
+		Message:   "new message",
+	}, th.BasicTeam.Id, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := &model.Job{Id: model.NewId(), Type: model.JOB_TYPE_DATA_RETENTION}
+	if err := DataRetentionJob(job, make(chan interface{})); err != nil {
+		t.Fatal(err)
+	}
+
+	if job.Data["deleted_posts"] == "0" {
+		t.Fatal("should have recorded the number of deleted posts")
+	}
+
+	if result := <-Srv.Store.Post().Get(oldPost.Id); result.Err == nil {
+		t.Fatal("old post should have been deleted")
+	}
+
+	if result := <-Srv.Store.Reaction().GetForPost(oldPost.Id, false); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]*model.Reaction)) != 0 {
+		t.Fatal("reactions to the old post should have been deleted")
+	}
+
+	if result := <-Srv.Store.Post().Get(newPost.Id); result.Err != nil {
+		t.Fatal("new post shouldn't have been deleted")
+	}
+}
@@ -288,6 +288,7 @@ func trackConfig() {
 		"enable_gitlab":    utils.Cfg.GitLabSettings.Enable,
 		"enable_google":    utils.Cfg.GoogleSettings.Enable,
 		"enable_office365": utils.Cfg.Office365Settings.Enable,
+		"enable_openid":    utils.Cfg.OpenIdSettings.Enable,
 	})
 
 	SendDiagnostic(TRACK_CONFIG_SUPPORT, map[string]interface{}{
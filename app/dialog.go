@@ -0,0 +1,172 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+var (
+	interactiveDialogKey      string
+	interactiveDialogKeyMutex sync.Mutex
+)
+
+// GetInteractiveDialogKey returns the key that signs trigger ids and the dialogs sent to clients. It's created the
+// first time it's needed and kept in the database so that every server in a cluster signs with the same key.
+func GetInteractiveDialogKey() (string, *model.AppError) {
+	interactiveDialogKeyMutex.Lock()
+	defer interactiveDialogKeyMutex.Unlock()
+
+	if interactiveDialogKey != "" {
+		return interactiveDialogKey, nil
+	}
+
+	if result := <-Srv.Store.System().GetByName(model.SYSTEM_INTERACTIVE_DIALOG_KEY); result.Err == nil {
+		interactiveDialogKey = result.Data.(*model.System).Value
+		return interactiveDialogKey, nil
+	}
+
+	system := &model.System{Name: model.SYSTEM_INTERACTIVE_DIALOG_KEY, Value: model.NewRandomString(64)}
+	if result := <-Srv.Store.System().Save(system); result.Err != nil {
+		// Another server may have created the key first
+		if result := <-Srv.Store.System().GetByName(model.SYSTEM_INTERACTIVE_DIALOG_KEY); result.Err != nil {
+			return "", result.Err
+		} else {
+			system = result.Data.(*model.System)
+		}
+	}
+
+	interactiveDialogKey = system.Value
+	return interactiveDialogKey, nil
+}
+
+// GenerateTriggerId creates a trigger id that lets an integration open a dialog for the user.
+func GenerateTriggerId(userId string) (string, string, *model.AppError) {
+	key, err := GetInteractiveDialogKey()
+	if err != nil {
+		return "", "", err
+	}
+
+	clientTriggerId, triggerId := model.GenerateTriggerId(userId, key)
+	return clientTriggerId, triggerId, nil
+}
+
+// OpenInteractiveDialog sends a dialog to the client of the user that the trigger id of the request was created for.
+// The URL that the dialog is submitted to is kept out of what's sent and signed into the dialog id instead.
+func OpenInteractiveDialog(request *model.OpenDialogRequest) *model.AppError {
+	if err := request.IsValid(); err != nil {
+		return err
+	}
+
+	key, err := GetInteractiveDialogKey()
+	if err != nil {
+		return err
+	}
+
+	clientTriggerId, userId, err := model.DecodeAndVerifyTriggerId(request.TriggerId, key)
+	if err != nil {
+		return err
+	}
+
+	dialogId := model.EncodeDialogId(&model.OpenedDialog{
+		UserId:   userId,
+		URL:      request.URL,
+		Dialog:   request.Dialog,
+		CreateAt: model.GetMillis(),
+	}, key)
+
+	request.TriggerId = clientTriggerId
+	request.URL = ""
+
+	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_OPEN_DIALOG, "", "", userId, nil)
+	message.Add("dialog", request.ToJson())
+	message.Add("dialog_id", dialogId)
+	Publish(message)
+
+	return nil
+}
+
+// SubmitInteractiveDialog posts what a user entered in a dialog to the integration that opened it and returns the
+// errors for each element, if any. What's entered is checked against the elements of the dialog first, and only sent
+// to the integration if it's accepted.
+func SubmitInteractiveDialog(request *model.SubmitDialogRequest) (*model.SubmitDialogResponse, *model.AppError) {
+	key, err := GetInteractiveDialogKey()
+	if err != nil {
+		return nil, err
+	}
+
+	opened, err := model.DecodeAndVerifyDialogId(request.DialogId, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if opened.UserId != request.UserId {
+		return nil, model.NewAppError("SubmitInteractiveDialog", "api.dialog.submit.user.app_error", nil, "", http.StatusForbidden)
+	}
+
+	request.DialogId = ""
+	request.Type = model.DIALOG_SUBMISSION_TYPE
+	request.CallbackId = opened.Dialog.CallbackId
+	request.State = opened.Dialog.State
+
+	if request.Cancelled {
+		if !opened.Dialog.NotifyOnCancel {
+			return &model.SubmitDialogResponse{}, nil
+		}
+
+		request.Submission = nil
+	} else if errors := opened.Dialog.ValidateSubmission(request.Submission); len(errors) > 0 {
+		user, err := GetUser(request.UserId)
+		if err != nil {
+			return nil, err
+		}
+
+		T := utils.GetUserTranslations(user.Locale)
+
+		response := &model.SubmitDialogResponse{Errors: map[string]string{}}
+		for name, err := range errors {
+			response.Errors[name] = err.SystemMessage(T)
+		}
+
+		return response, nil
+	}
+
+	req, _ := http.NewRequest("POST", opened.URL, strings.NewReader(request.ToJson()))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, httpErr := utils.NewUntrustedHTTPClient().Do(req)
+	if httpErr != nil {
+		return nil, model.NewAppError("SubmitInteractiveDialog", "api.dialog.submit.integration.app_error", nil, "err="+httpErr.Error(), http.StatusBadRequest)
+	}
+	defer CloseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, model.NewAppError("SubmitInteractiveDialog", "api.dialog.submit.integration.app_error", nil, "status="+strconv.Itoa(resp.StatusCode), http.StatusBadRequest)
+	}
+
+	body, httpErr := ioutil.ReadAll(resp.Body)
+	if httpErr != nil {
+		return nil, model.NewAppError("SubmitInteractiveDialog", "api.dialog.submit.integration.app_error", nil, "err="+httpErr.Error(), http.StatusBadRequest)
+	}
+
+	// An integration that accepts the submission doesn't have to reply with anything
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return &model.SubmitDialogResponse{}, nil
+	}
+
+	response := model.SubmitDialogResponseFromJson(strings.NewReader(string(body)))
+	if response == nil {
+		return nil, model.NewAppError("SubmitInteractiveDialog", "api.dialog.submit.integration.app_error", nil, "err=invalid response", http.StatusBadRequest)
+	}
+
+	return response, nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/url"
+	"strings"
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/primefour/servers/model"
@@ -269,3 +270,52 @@ func SendInviteEmails(team *model.Team, senderName string, invites []string, sit
 		}
 	}
 }
+
+// SendGuestInviteEmails sends invitations to join a team as a guest. The signup link carries the channels that the
+// guests are added to once they've created their accounts.
+func SendGuestInviteEmails(team *model.Team, channels []*model.Channel, senderName string, invites []string, siteURL string) {
+	channelIds := make([]string, len(channels))
+	channelNames := make([]string, len(channels))
+	for i, channel := range channels {
+		channelIds[i] = channel.Id
+		channelNames[i] = channel.DisplayName
+	}
+
+	for _, invite := range invites {
+		if len(invite) > 0 {
+			subject := utils.T("api.templates.invite_subject",
+				map[string]interface{}{"SenderName": senderName,
+					"TeamDisplayName": team.DisplayName,
+					"SiteName":        utils.ClientCfg["SiteName"]})
+
+			bodyPage := utils.NewHTMLTemplate("invite_body", model.DEFAULT_LOCALE)
+			bodyPage.Props["SiteURL"] = siteURL
+			bodyPage.Props["Title"] = utils.T("api.templates.invite_body.title")
+			bodyPage.Html["Info"] = template.HTML(utils.T("api.templates.guest_invite_body.info",
+				map[string]interface{}{"SenderName": senderName, "TeamDisplayName": team.DisplayName, "ChannelNames": strings.Join(channelNames, ", ")}))
+			bodyPage.Props["Button"] = utils.T("api.templates.invite_body.button")
+			bodyPage.Html["ExtraInfo"] = template.HTML(utils.T("api.templates.invite_body.extra_info",
+				map[string]interface{}{"TeamDisplayName": team.DisplayName, "TeamURL": siteURL + "/" + team.Name}))
+
+			props := make(map[string]string)
+			props["email"] = invite
+			props["id"] = team.Id
+			props["display_name"] = team.DisplayName
+			props["name"] = team.Name
+			props["guest"] = "true"
+			props["channels"] = strings.Join(channelIds, ",")
+			props["time"] = fmt.Sprintf("%v", model.GetMillis())
+			data := model.MapToJson(props)
+			hash := utils.HashSha256(fmt.Sprintf("%v:%v", data, utils.Cfg.EmailSettings.InviteSalt))
+			bodyPage.Props["Link"] = fmt.Sprintf("%s/signup_user_complete/?d=%s&h=%s", siteURL, url.QueryEscape(data), url.QueryEscape(hash))
+
+			if !utils.Cfg.EmailSettings.SendEmailNotifications {
+				l4g.Info(utils.T("api.team.invite_members.sending.info"), invite, bodyPage.Props["Link"])
+			}
+
+			if err := utils.SendMail(invite, subject, bodyPage.Render()); err != nil {
+				l4g.Error(utils.T("api.team.invite_members.send.error"), err)
+			}
+		}
+	}
+}
@@ -80,13 +80,17 @@ func UploadEmojiImage(id string, imageData *multipart.FileHeader) *model.AppErro
 	buf := bytes.NewBuffer(nil)
 	io.Copy(buf, file)
 
+	return uploadEmojiImageData(id, imageData.Filename, buf.Bytes())
+}
+
+// uploadEmojiImageData stores the image for an emoji, resizing it first if it's larger than the maximum dimensions.
+func uploadEmojiImageData(id string, filename string, data []byte) *model.AppError {
 	// make sure the file is an image and is within the required dimensions
-	if config, _, err := image.DecodeConfig(bytes.NewReader(buf.Bytes())); err != nil {
+	if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
 		return model.NewAppError("uploadEmojiImage", "api.emoji.upload.image.app_error", nil, "", http.StatusBadRequest)
 	} else if config.Width > MaxEmojiWidth || config.Height > MaxEmojiHeight {
-		data := buf.Bytes()
 		newbuf := bytes.NewBuffer(nil)
-		if info, err := model.GetInfoForBytes(imageData.Filename, data); err != nil {
+		if info, err := model.GetInfoForBytes(filename, data); err != nil {
 			return err
 		} else if info.MimeType == "image/gif" {
 			if gif_data, err := gif.DecodeAll(bytes.NewReader(data)); err != nil {
@@ -96,7 +100,7 @@ func UploadEmojiImage(id string, imageData *multipart.FileHeader) *model.AppErro
 				if err := gif.EncodeAll(newbuf, resized_gif); err != nil {
 					return model.NewAppError("uploadEmojiImage", "api.emoji.upload.large_image.gif_encode_error", nil, "", http.StatusBadRequest)
 				}
-				if err := WriteFile(newbuf.Bytes(), getEmojiImagePath(id)); err != nil {
+				if _, err := WriteFile(newbuf, getEmojiImagePath(id)); err != nil {
 					return err
 				}
 			}
@@ -108,13 +112,13 @@ func UploadEmojiImage(id string, imageData *multipart.FileHeader) *model.AppErro
 				if err := png.Encode(newbuf, resized_image); err != nil {
 					return model.NewAppError("uploadEmojiImage", "api.emoji.upload.large_image.encode_error", nil, "", http.StatusBadRequest)
 				}
-				if err := WriteFile(newbuf.Bytes(), getEmojiImagePath(id)); err != nil {
+				if _, err := WriteFile(newbuf, getEmojiImagePath(id)); err != nil {
 					return err
 				}
 			}
 		}
 	} else {
-		if err := WriteFile(buf.Bytes(), getEmojiImagePath(id)); err != nil {
+		if _, err := WriteFile(bytes.NewReader(data), getEmojiImagePath(id)); err != nil {
 			return err
 		}
 	}
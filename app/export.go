@@ -0,0 +1,422 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/primefour/servers/model"
+)
+
+const (
+	EXPORT_BATCH_SIZE = 1000
+)
+
+//
+// -- Bulk Export Functions --
+// These functions read data directly from the database and write it out one JSON object per line in the same format
+// that is accepted by BulkImport. Replies and reactions are exported along with the posts that they belong to, but
+// direct messages, file attachments and custom emoji aren't exported yet.
+//
+
+func BulkExport(writer io.Writer) *model.AppError {
+	if err := exportVersion(writer); err != nil {
+		return err
+	}
+
+	teams, err := exportAllTeams(writer)
+	if err != nil {
+		return err
+	}
+
+	channels, err := exportAllChannels(writer, teams)
+	if err != nil {
+		return err
+	}
+
+	if err := exportAllUsers(writer, teams, channels); err != nil {
+		return err
+	}
+
+	if err := exportAllPosts(writer); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func exportWriteLine(writer io.Writer, line *LineImportData) *model.AppError {
+	b, err := json.Marshal(line)
+	if err != nil {
+		return model.NewAppError("BulkExport", "app.export.export_write_line.json_marshall.error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if _, err := writer.Write(append(b, '\n')); err != nil {
+		return model.NewAppError("BulkExport", "app.export.export_write_line.io_writer.error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func exportVersion(writer io.Writer) *model.AppError {
+	version := 1
+	return exportWriteLine(writer, &LineImportData{
+		Type:    "version",
+		Version: &version,
+	})
+}
+
+// exportAllTeams writes every team that hasn't been deleted and returns them keyed by id.
+func exportAllTeams(writer io.Writer) (map[string]*model.Team, *model.AppError) {
+	result := <-Srv.Store.Team().GetAll()
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	teams := make(map[string]*model.Team)
+	for _, team := range result.Data.([]*model.Team) {
+		if team.DeleteAt != 0 {
+			continue
+		}
+
+		if err := exportWriteLine(writer, ImportLineFromTeam(team)); err != nil {
+			return nil, err
+		}
+
+		teams[team.Id] = team
+	}
+
+	return teams, nil
+}
+
+// exportAllChannels writes every public and private channel that hasn't been deleted from the given teams and returns
+// them keyed by id.
+func exportAllChannels(writer io.Writer, teams map[string]*model.Team) (map[string]*model.Channel, *model.AppError) {
+	channels := make(map[string]*model.Channel)
+
+	for _, team := range teams {
+		result := <-Srv.Store.Channel().GetAll(team.Id)
+		if result.Err != nil {
+			return nil, result.Err
+		}
+
+		for _, channel := range result.Data.([]*model.Channel) {
+			if channel.DeleteAt != 0 || (channel.Type != model.CHANNEL_OPEN && channel.Type != model.CHANNEL_PRIVATE) {
+				continue
+			}
+
+			if err := exportWriteLine(writer, ImportLineFromChannel(team, channel)); err != nil {
+				return nil, err
+			}
+
+			channels[channel.Id] = channel
+		}
+	}
+
+	return channels, nil
+}
+
+func exportAllUsers(writer io.Writer, teams map[string]*model.Team, channels map[string]*model.Channel) *model.AppError {
+	afterId := ""
+
+	for {
+		result := <-Srv.Store.User().GetAllAfter(EXPORT_BATCH_SIZE, afterId)
+		if result.Err != nil {
+			return result.Err
+		}
+
+		users := result.Data.([]*model.User)
+		if len(users) == 0 {
+			return nil
+		}
+
+		for _, user := range users {
+			line := ImportLineFromUser(user)
+
+			if err := exportUserPreferences(line.User, user.Id); err != nil {
+				return err
+			}
+
+			if err := exportUserTeams(line.User, user.Id, teams, channels); err != nil {
+				return err
+			}
+
+			if err := exportWriteLine(writer, line); err != nil {
+				return err
+			}
+		}
+
+		afterId = users[len(users)-1].Id
+	}
+}
+
+func exportUserPreferences(data *UserImportData, userId string) *model.AppError {
+	if result := <-Srv.Store.Preference().Get(userId, model.PREFERENCE_CATEGORY_THEME, ""); result.Err == nil {
+		theme := result.Data.(model.Preference).Value
+		data.Theme = &theme
+	}
+
+	result := <-Srv.Store.Preference().GetCategory(userId, model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS)
+	if result.Err != nil {
+		return result.Err
+	}
+
+	for _, preference := range result.Data.(model.Preferences) {
+		value := preference.Value
+
+		switch preference.Name {
+		case "selected_font":
+			data.SelectedFont = &value
+		case "use_military_time":
+			data.UseMilitaryTime = &value
+		case "name_format":
+			data.NameFormat = &value
+		case "collapse_previews":
+			data.CollapsePreviews = &value
+		case "message_display":
+			data.MessageDisplay = &value
+		case "channel_display_mode":
+			data.ChannelDisplayMode = &value
+		}
+	}
+
+	return nil
+}
+
+func exportUserTeams(data *UserImportData, userId string, teams map[string]*model.Team, channels map[string]*model.Channel) *model.AppError {
+	result := <-Srv.Store.Team().GetTeamsForUser(userId)
+	if result.Err != nil {
+		return result.Err
+	}
+
+	userTeams := []UserTeamImportData{}
+	for _, member := range result.Data.([]*model.TeamMember) {
+		team, ok := teams[member.TeamId]
+		if !ok || member.DeleteAt != 0 {
+			continue
+		}
+
+		teamData := UserTeamImportData{
+			Name:  &team.Name,
+			Roles: &member.Roles,
+		}
+
+		cresult := <-Srv.Store.Channel().GetMembersForUser(team.Id, userId)
+		if cresult.Err != nil {
+			return cresult.Err
+		}
+
+		userChannels := []UserChannelImportData{}
+		for _, channelMember := range *cresult.Data.(*model.ChannelMembers) {
+			if channel, ok := channels[channelMember.ChannelId]; ok && channel.TeamId == team.Id {
+				userChannels = append(userChannels, ImportDataFromChannelMember(channel, channelMember))
+			}
+		}
+		teamData.Channels = &userChannels
+
+		userTeams = append(userTeams, teamData)
+	}
+
+	data.Teams = &userTeams
+
+	return nil
+}
+
+func exportAllPosts(writer io.Writer) *model.AppError {
+	afterId := ""
+	usernames := make(map[string]string)
+
+	for {
+		result := <-Srv.Store.Post().GetPostsForExport(afterId, EXPORT_BATCH_SIZE)
+		if result.Err != nil {
+			return result.Err
+		}
+
+		posts := result.Data.([]*model.PostForExport)
+		if len(posts) == 0 {
+			return nil
+		}
+
+		for _, post := range posts {
+			line := ImportLineFromPost(post)
+
+			if reactions, err := exportReactions(&post.Post, usernames); err != nil {
+				return err
+			} else {
+				line.Post.Reactions = reactions
+			}
+
+			if replies, err := exportReplies(post.Id, usernames); err != nil {
+				return err
+			} else {
+				line.Post.Replies = replies
+			}
+
+			if err := exportWriteLine(writer, line); err != nil {
+				return err
+			}
+		}
+
+		afterId = posts[len(posts)-1].Id
+	}
+}
+
+func exportReplies(rootId string, usernames map[string]string) (*[]ReplyImportData, *model.AppError) {
+	result := <-Srv.Store.Post().GetRepliesForExport(rootId)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	replies := []ReplyImportData{}
+	for _, reply := range result.Data.([]*model.PostForExport) {
+		data := ImportDataFromReply(reply)
+
+		if reactions, err := exportReactions(&reply.Post, usernames); err != nil {
+			return nil, err
+		} else {
+			data.Reactions = reactions
+		}
+
+		replies = append(replies, data)
+	}
+
+	return &replies, nil
+}
+
+// exportReactions returns the reactions to a post. The usernames of the users that reacted are looked up as needed and
+// cached in usernames, which maps user ids to usernames.
+func exportReactions(post *model.Post, usernames map[string]string) (*[]ReactionImportData, *model.AppError) {
+	if !post.HasReactions {
+		return nil, nil
+	}
+
+	result := <-Srv.Store.Reaction().GetForPost(post.Id, false)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	reactions := []ReactionImportData{}
+	for _, reaction := range result.Data.([]*model.Reaction) {
+		username, ok := usernames[reaction.UserId]
+		if !ok {
+			uresult := <-Srv.Store.User().Get(reaction.UserId)
+			if uresult.Err != nil {
+				return nil, uresult.Err
+			}
+
+			username = uresult.Data.(*model.User).Username
+			usernames[reaction.UserId] = username
+		}
+
+		reactions = append(reactions, ImportDataFromReaction(username, reaction))
+	}
+
+	return &reactions, nil
+}
+
+func ImportLineFromTeam(team *model.Team) *LineImportData {
+	return &LineImportData{
+		Type: "team",
+		Team: &TeamImportData{
+			Name:            &team.Name,
+			DisplayName:     &team.DisplayName,
+			Type:            &team.Type,
+			Description:     &team.Description,
+			AllowOpenInvite: &team.AllowOpenInvite,
+		},
+	}
+}
+
+func ImportLineFromChannel(team *model.Team, channel *model.Channel) *LineImportData {
+	return &LineImportData{
+		Type: "channel",
+		Channel: &ChannelImportData{
+			Team:        &team.Name,
+			Name:        &channel.Name,
+			DisplayName: &channel.DisplayName,
+			Type:        &channel.Type,
+			Header:      &channel.Header,
+			Purpose:     &channel.Purpose,
+		},
+	}
+}
+
+// ImportLineFromUser converts a user to an import line. Passwords are stored as hashes and can't be exported, so users
+// that don't sign in with another authentication service will be given a new password when they are imported.
+func ImportLineFromUser(user *model.User) *LineImportData {
+	data := &UserImportData{
+		Username:  &user.Username,
+		Email:     &user.Email,
+		Nickname:  &user.Nickname,
+		FirstName: &user.FirstName,
+		LastName:  &user.LastName,
+		Position:  &user.Position,
+		Roles:     &user.Roles,
+		Locale:    &user.Locale,
+	}
+
+	if user.AuthService != "" {
+		data.AuthService = &user.AuthService
+		data.AuthData = user.AuthData
+	}
+
+	return &LineImportData{
+		Type: "user",
+		User: data,
+	}
+}
+
+func ImportDataFromChannelMember(channel *model.Channel, member model.ChannelMember) UserChannelImportData {
+	data := UserChannelImportData{
+		Name:  &channel.Name,
+		Roles: &member.Roles,
+	}
+
+	desktop, hasDesktop := member.NotifyProps[model.DESKTOP_NOTIFY_PROP]
+	markUnread, hasMarkUnread := member.NotifyProps[model.MARK_UNREAD_NOTIFY_PROP]
+	if hasDesktop || hasMarkUnread {
+		data.NotifyProps = &UserChannelNotifyPropsImportData{}
+
+		if hasDesktop {
+			data.NotifyProps.Desktop = &desktop
+		}
+
+		if hasMarkUnread {
+			data.NotifyProps.MarkUnread = &markUnread
+		}
+	}
+
+	return data
+}
+
+func ImportLineFromPost(post *model.PostForExport) *LineImportData {
+	return &LineImportData{
+		Type: "post",
+		Post: &PostImportData{
+			Team:     &post.TeamName,
+			Channel:  &post.ChannelName,
+			User:     &post.Username,
+			Message:  &post.Message,
+			CreateAt: &post.CreateAt,
+		},
+	}
+}
+
+func ImportDataFromReply(reply *model.PostForExport) ReplyImportData {
+	return ReplyImportData{
+		User:     &reply.Username,
+		Message:  &reply.Message,
+		CreateAt: &reply.CreateAt,
+	}
+}
+
+func ImportDataFromReaction(username string, reaction *model.Reaction) ReactionImportData {
+	return ReactionImportData{
+		User:      &username,
+		EmojiName: &reaction.EmojiName,
+		CreateAt:  &reaction.CreateAt,
+	}
+}
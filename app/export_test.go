@@ -0,0 +1,149 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/primefour/servers/model"
+)
+
+func TestExportImportLineFromUser(t *testing.T) {
+	user := &model.User{
+		Username: "username",
+		Email:    "username@example.com",
+		Password: "passwordhash",
+		Roles:    model.ROLE_SYSTEM_USER.Id,
+		Locale:   "en",
+	}
+
+	line := ImportLineFromUser(user)
+	if line.Type != "user" || *line.User.Username != user.Username || *line.User.Email != user.Email {
+		t.Fatal("should have converted the user")
+	} else if line.User.Password != nil {
+		t.Fatal("shouldn't have exported the password")
+	} else if line.User.AuthService != nil || line.User.AuthData != nil {
+		t.Fatal("shouldn't have exported an auth service")
+	}
+
+	if err := validateUserImportData(line.User); err != nil {
+		t.Fatal(err)
+	}
+
+	authData := "someone"
+	user.AuthService = model.USER_AUTH_SERVICE_GITLAB
+	user.AuthData = &authData
+
+	line = ImportLineFromUser(user)
+	if *line.User.AuthService != user.AuthService || *line.User.AuthData != authData {
+		t.Fatal("should have exported the auth service")
+	}
+
+	if err := validateUserImportData(line.User); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImportDataFromChannelMember(t *testing.T) {
+	channel := &model.Channel{Name: "channel"}
+	member := model.ChannelMember{
+		Roles: model.ROLE_CHANNEL_USER.Id,
+		NotifyProps: model.StringMap{
+			model.DESKTOP_NOTIFY_PROP:     model.CHANNEL_NOTIFY_MENTION,
+			model.MARK_UNREAD_NOTIFY_PROP: model.CHANNEL_MARK_UNREAD_ALL,
+		},
+	}
+
+	data := ImportDataFromChannelMember(channel, member)
+	if *data.Name != channel.Name || *data.Roles != member.Roles {
+		t.Fatal("should have converted the channel member")
+	} else if data.NotifyProps == nil || *data.NotifyProps.Desktop != model.CHANNEL_NOTIFY_MENTION || *data.NotifyProps.MarkUnread != model.CHANNEL_MARK_UNREAD_ALL {
+		t.Fatal("should have converted the notify props")
+	}
+
+	member.NotifyProps = model.StringMap{}
+
+	if data := ImportDataFromChannelMember(channel, member); data.NotifyProps != nil {
+		t.Fatal("shouldn't have exported missing notify props")
+	}
+}
+
+func TestExportBulkExport(t *testing.T) {
+	th := Setup().InitBasic()
+
+	var buf bytes.Buffer
+	if err := BulkExport(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	exported := buf.Bytes()
+
+	foundTeam := false
+	foundChannel := false
+	foundUser := false
+	foundPost := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(exported))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var line LineImportData
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+
+		if lineNumber == 1 {
+			if line.Type != "version" || *line.Version != 1 {
+				t.Fatal("first line should be the version")
+			}
+			continue
+		}
+
+		switch line.Type {
+		case "team":
+			if *line.Team.Name == th.BasicTeam.Name {
+				foundTeam = true
+			}
+		case "channel":
+			if *line.Channel.Team == th.BasicTeam.Name && *line.Channel.Name == th.BasicChannel.Name {
+				foundChannel = true
+			}
+		case "user":
+			if *line.User.Username != th.BasicUser.Username {
+				continue
+			}
+
+			for _, team := range *line.User.Teams {
+				if *team.Name != th.BasicTeam.Name {
+					continue
+				}
+
+				for _, channel := range *team.Channels {
+					if *channel.Name == th.BasicChannel.Name {
+						foundUser = true
+					}
+				}
+			}
+		case "post":
+			if *line.Post.Channel == th.BasicChannel.Name && *line.Post.Message == th.BasicPost.Message && *line.Post.CreateAt == th.BasicPost.CreateAt {
+				foundPost = true
+			}
+		}
+	}
+
+	if !foundTeam {
+		t.Fatal("should have exported the team")
+	} else if !foundChannel {
+		t.Fatal("should have exported the channel")
+	} else if !foundUser {
+		t.Fatal("should have exported the user along with their team and channel memberships")
+	} else if !foundPost {
+		t.Fatal("should have exported the post")
+	}
+
+	if err, line := BulkImport(bytes.NewReader(exported), true, 2); err != nil {
+		t.Fatalf("exported data should be valid for import: %v, line %v", err.Error(), line)
+	}
+}
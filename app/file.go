@@ -18,15 +18,12 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/url"
-	"os"
-	"path"
 	"path/filepath"
 	"strings"
 	"sync"
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/disintegration/imaging"
-	s3 "github.com/minio/minio-go"
 	"github.com/primefour/servers/model"
 	"github.com/primefour/servers/utils"
 	"github.com/rwcarlsen/goexif/exif"
@@ -56,138 +53,129 @@ const (
 	MaxImageSize = 6048 * 4032 // 24 megapixels, roughly 36MB as a raw image
 )
 
-func ReadFile(path string) ([]byte, *model.AppError) {
-	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
-		endpoint := utils.Cfg.FileSettings.AmazonS3Endpoint
-		accessKey := utils.Cfg.FileSettings.AmazonS3AccessKeyId
-		secretKey := utils.Cfg.FileSettings.AmazonS3SecretAccessKey
-		secure := *utils.Cfg.FileSettings.AmazonS3SSL
-		s3Clnt, err := s3.New(endpoint, accessKey, secretKey, secure)
-		if err != nil {
-			return nil, model.NewLocAppError("ReadFile", "api.file.read_file.s3.app_error", nil, err.Error())
-		}
-		bucket := utils.Cfg.FileSettings.AmazonS3Bucket
-		minioObject, err := s3Clnt.GetObject(bucket, path)
-		defer minioObject.Close()
-		if err != nil {
-			return nil, model.NewLocAppError("ReadFile", "api.file.read_file.s3.app_error", nil, err.Error())
-		}
-		if f, err := ioutil.ReadAll(minioObject); err != nil {
-			return nil, model.NewLocAppError("ReadFile", "api.file.read_file.s3.app_error", nil, err.Error())
-		} else {
-			return f, nil
-		}
-	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
-		if f, err := ioutil.ReadFile(utils.Cfg.FileSettings.Directory + path); err != nil {
-			return nil, model.NewLocAppError("ReadFile", "api.file.read_file.reading_local.app_error", nil, err.Error())
-		} else {
-			return f, nil
-		}
-	} else {
-		return nil, model.NewAppError("ReadFile", "api.file.read_file.configured.app_error", nil, "", http.StatusNotImplemented)
+var fileBackendLock sync.Mutex
+var fileBackend utils.FileBackend
+var fileBackendSettings string
+var overrideFileBackend utils.FileBackend
+
+// FileBackend returns the storage backend described by the current FileSettings. The backend is created once
+// and reused until the settings that it was created from change.
+func FileBackend() (utils.FileBackend, *model.AppError) {
+	fileBackendLock.Lock()
+	defer fileBackendLock.Unlock()
+
+	if overrideFileBackend != nil {
+		return overrideFileBackend, nil
+	}
+
+	settings := &utils.Cfg.FileSettings
+	key := fileBackendKey(settings)
+	if fileBackend != nil && key == fileBackendSettings {
+		return fileBackend, nil
 	}
+
+	backend, err := utils.NewFileBackend(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	fileBackend = backend
+	fileBackendSettings = key
+	return fileBackend, nil
 }
 
-func MoveFile(oldPath, newPath string) *model.AppError {
-	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
-		endpoint := utils.Cfg.FileSettings.AmazonS3Endpoint
-		accessKey := utils.Cfg.FileSettings.AmazonS3AccessKeyId
-		secretKey := utils.Cfg.FileSettings.AmazonS3SecretAccessKey
-		secure := *utils.Cfg.FileSettings.AmazonS3SSL
-		s3Clnt, err := s3.New(endpoint, accessKey, secretKey, secure)
-		if err != nil {
-			return model.NewLocAppError("moveFile", "api.file.write_file.s3.app_error", nil, err.Error())
-		}
-		bucket := utils.Cfg.FileSettings.AmazonS3Bucket
+// SetFileBackend replaces the configured storage backend, or restores it when passed nil. It's intended for tests.
+func SetFileBackend(backend utils.FileBackend) {
+	fileBackendLock.Lock()
+	defer fileBackendLock.Unlock()
 
-		var copyConds = s3.NewCopyConditions()
-		if err = s3Clnt.CopyObject(bucket, newPath, "/"+path.Join(bucket, oldPath), copyConds); err != nil {
-			return model.NewLocAppError("moveFile", "api.file.move_file.delete_from_s3.app_error", nil, err.Error())
-		}
-		if err = s3Clnt.RemoveObject(bucket, oldPath); err != nil {
-			return model.NewLocAppError("moveFile", "api.file.move_file.delete_from_s3.app_error", nil, err.Error())
-		}
-	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
-		if err := os.MkdirAll(filepath.Dir(utils.Cfg.FileSettings.Directory+newPath), 0774); err != nil {
-			return model.NewLocAppError("moveFile", "api.file.move_file.rename.app_error", nil, err.Error())
-		}
+	overrideFileBackend = backend
+}
 
-		if err := os.Rename(utils.Cfg.FileSettings.Directory+oldPath, utils.Cfg.FileSettings.Directory+newPath); err != nil {
-			return model.NewLocAppError("moveFile", "api.file.move_file.rename.app_error", nil, err.Error())
-		}
-	} else {
-		return model.NewLocAppError("moveFile", "api.file.move_file.configured.app_error", nil, "")
+func fileBackendKey(settings *model.FileSettings) string {
+	secure := settings.AmazonS3SSL == nil || *settings.AmazonS3SSL
+	return strings.Join([]string{
+		settings.DriverName,
+		settings.Directory,
+		settings.AmazonS3AccessKeyId,
+		settings.AmazonS3SecretAccessKey,
+		settings.AmazonS3Bucket,
+		settings.AmazonS3Region,
+		settings.AmazonS3Endpoint,
+		fmt.Sprint(secure),
+	}, "\x00")
+}
+
+func TestFileConnection() *model.AppError {
+	backend, err := FileBackend()
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return backend.TestConnection()
 }
 
-func WriteFile(f []byte, path string) *model.AppError {
-	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
-		endpoint := utils.Cfg.FileSettings.AmazonS3Endpoint
-		accessKey := utils.Cfg.FileSettings.AmazonS3AccessKeyId
-		secretKey := utils.Cfg.FileSettings.AmazonS3SecretAccessKey
-		secure := *utils.Cfg.FileSettings.AmazonS3SSL
-		s3Clnt, err := s3.New(endpoint, accessKey, secretKey, secure)
-		if err != nil {
-			return model.NewLocAppError("WriteFile", "api.file.write_file.s3.app_error", nil, err.Error())
-		}
-		bucket := utils.Cfg.FileSettings.AmazonS3Bucket
-		ext := filepath.Ext(path)
+func ReadFile(path string) ([]byte, *model.AppError) {
+	backend, err := FileBackend()
+	if err != nil {
+		return nil, err
+	}
 
-		if model.IsFileExtImage(ext) {
-			_, err = s3Clnt.PutObject(bucket, path, bytes.NewReader(f), model.GetImageMimeType(ext))
-		} else {
-			_, err = s3Clnt.PutObject(bucket, path, bytes.NewReader(f), "binary/octet-stream")
-		}
-		if err != nil {
-			return model.NewLocAppError("WriteFile", "api.file.write_file.s3.app_error", nil, err.Error())
-		}
-	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
-		if err := writeFileLocally(f, utils.Cfg.FileSettings.Directory+path); err != nil {
-			return err
-		}
-	} else {
-		return model.NewLocAppError("WriteFile", "api.file.write_file.configured.app_error", nil, "")
+	return backend.ReadFile(path)
+}
+
+func MoveFile(oldPath, newPath string) *model.AppError {
+	backend, err := FileBackend()
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return backend.MoveFile(oldPath, newPath)
 }
 
-func writeFileLocally(f []byte, path string) *model.AppError {
-	if err := os.MkdirAll(filepath.Dir(path), 0774); err != nil {
-		directory, _ := filepath.Abs(filepath.Dir(path))
-		return model.NewLocAppError("WriteFile", "api.file.write_file_locally.create_dir.app_error", nil, "directory="+directory+", err="+err.Error())
+func FileReader(path string) (utils.ReadCloseSeeker, *model.AppError) {
+	backend, err := FileBackend()
+	if err != nil {
+		return nil, err
 	}
 
-	if err := ioutil.WriteFile(path, f, 0644); err != nil {
-		return model.NewLocAppError("WriteFile", "api.file.write_file_locally.writing.app_error", nil, err.Error())
+	return backend.Reader(path)
+}
+
+func WriteFile(fr io.Reader, path string) (int64, *model.AppError) {
+	backend, err := FileBackend()
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	return backend.WriteFile(fr, path)
 }
 
-func openFileWriteStream(path string) (io.Writer, *model.AppError) {
-	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
-		return nil, model.NewLocAppError("openFileWriteStream", "api.file.open_file_write_stream.s3.app_error", nil, "")
-	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
-		if err := os.MkdirAll(filepath.Dir(utils.Cfg.FileSettings.Directory+path), 0774); err != nil {
-			return nil, model.NewLocAppError("openFileWriteStream", "api.file.open_file_write_stream.creating_dir.app_error", nil, err.Error())
-		}
+func RemoveFile(path string) *model.AppError {
+	backend, err := FileBackend()
+	if err != nil {
+		return err
+	}
 
-		if fileHandle, err := os.Create(utils.Cfg.FileSettings.Directory + path); err != nil {
-			return nil, model.NewLocAppError("openFileWriteStream", "api.file.open_file_write_stream.local_server.app_error", nil, err.Error())
-		} else {
-			fileHandle.Chmod(0644)
-			return fileHandle, nil
-		}
+	return backend.RemoveFile(path)
+}
+
+func ListDirectory(path string) ([]string, *model.AppError) {
+	backend, err := FileBackend()
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, model.NewLocAppError("openFileWriteStream", "api.file.open_file_write_stream.configured.app_error", nil, "")
+	return backend.ListDirectory(path)
 }
 
-func closeFileWriteStream(file io.Writer) {
-	file.(*os.File).Close()
+func RemoveDirectory(path string) *model.AppError {
+	backend, err := FileBackend()
+	if err != nil {
+		return err
+	}
+
+	return backend.RemoveDirectory(path)
 }
 
 func GetInfoForFilename(post *model.Post, teamId string, filename string) *model.FileInfo {
@@ -391,22 +379,12 @@ func UploadFiles(teamId string, channelId string, userId string, fileHeaders []*
 	imageDataList := [][]byte{}
 
 	for i, fileHeader := range fileHeaders {
-		file, fileErr := fileHeader.Open()
-		defer file.Close()
-		if fileErr != nil {
-			return nil, model.NewAppError("UploadFiles", "api.file.upload_file.bad_parse.app_error", nil, fileErr.Error(), http.StatusBadRequest)
-		}
-
-		buf := bytes.NewBuffer(nil)
-		io.Copy(buf, file)
-		data := buf.Bytes()
-
-		info, err := DoUploadFile(teamId, channelId, userId, fileHeader.Filename, data)
+		info, data, err := uploadMultipartFile(teamId, channelId, userId, fileHeader)
 		if err != nil {
 			return nil, err
 		}
 
-		if info.PreviewPath != "" || info.ThumbnailPath != "" {
+		if data != nil {
 			previewPathList = append(previewPathList, info.PreviewPath)
 			thumbnailPathList = append(thumbnailPathList, info.ThumbnailPath)
 			imageDataList = append(imageDataList, data)
@@ -424,10 +402,67 @@ func UploadFiles(teamId string, channelId string, userId string, fileHeaders []*
 	return resStruct, nil
 }
 
+// uploadMultipartFile stores one file of a multipart upload. The file's contents are also returned if it's an image,
+// since only images get a preview and thumbnail. The file is closed before returning so that uploading many files
+// doesn't keep all of them open at once.
+func uploadMultipartFile(teamId string, channelId string, userId string, fileHeader *multipart.FileHeader) (*model.FileInfo, []byte, *model.AppError) {
+	file, fileErr := fileHeader.Open()
+	if fileErr != nil {
+		return nil, nil, model.NewAppError("UploadFiles", "api.file.upload_file.bad_parse.app_error", nil, fileErr.Error(), http.StatusBadRequest)
+	}
+	defer file.Close()
+
+	info, err := DoUploadFileStream(teamId, channelId, userId, fileHeader.Filename, file, fileHeader.Size)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info.PreviewPath == "" && info.ThumbnailPath == "" {
+		return info, nil, nil
+	}
+
+	data, readErr := ioutil.ReadAll(file)
+	if readErr != nil {
+		return nil, nil, model.NewAppError("UploadFiles", "api.file.upload_file.bad_parse.app_error", nil, readErr.Error(), http.StatusBadRequest)
+	}
+
+	return info, data, nil
+}
+
 func DoUploadFile(teamId string, channelId string, userId string, rawFilename string, data []byte) (*model.FileInfo, *model.AppError) {
+	return DoUploadFileStream(teamId, channelId, userId, rawFilename, bytes.NewReader(data), int64(len(data)))
+}
+
+// DoUploadFileStream copies file into the storage backend without holding it in memory and saves a FileInfo
+// for it. On success, file is left positioned at its start so that the caller can read it again.
+func DoUploadFileStream(teamId string, channelId string, userId string, rawFilename string, file io.ReadSeeker, size int64) (*model.FileInfo, *model.AppError) {
+	info, err := newUploadedFileInfo(teamId, channelId, userId, rawFilename, file, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if written, err := WriteFile(file, info.Path); err != nil {
+		return nil, err
+	} else {
+		info.Size = written
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, model.NewAppError("DoUploadFileStream", "api.file.upload_file.bad_parse.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	if result := <-Srv.Store.FileInfo().Save(info); result.Err != nil {
+		return nil, result.Err
+	}
+
+	return info, nil
+}
+
+// newUploadedFileInfo builds the FileInfo, including the storage paths, for a file that's about to be uploaded.
+func newUploadedFileInfo(teamId string, channelId string, userId string, rawFilename string, file io.ReadSeeker, size int64) (*model.FileInfo, *model.AppError) {
 	filename := filepath.Base(rawFilename)
 
-	info, err := model.GetInfoForBytes(filename, data)
+	info, err := model.GetInfoForReader(filename, size, file)
 	if err != nil {
 		err.StatusCode = http.StatusBadRequest
 		return nil, err
@@ -452,14 +487,6 @@ func DoUploadFile(teamId string, channelId string, userId string, rawFilename st
 		info.ThumbnailPath = pathPrefix + nameWithoutExtension + "_thumb.jpg"
 	}
 
-	if err := WriteFile(data, info.Path); err != nil {
-		return nil, err
-	}
-
-	if result := <-Srv.Store.FileInfo().Save(info); result.Err != nil {
-		return nil, result.Err
-	}
-
 	return info, nil
 }
 
@@ -555,7 +582,7 @@ func generateThumbnailImage(img image.Image, thumbnailPath string, width int, he
 		return
 	}
 
-	if err := WriteFile(buf.Bytes(), thumbnailPath); err != nil {
+	if _, err := WriteFile(buf, thumbnailPath); err != nil {
 		l4g.Error(utils.T("api.file.handle_images_forget.upload_thumb.error"), thumbnailPath, err)
 		return
 	}
@@ -576,7 +603,7 @@ func generatePreviewImage(img image.Image, previewPath string, width int) {
 		return
 	}
 
-	if err := WriteFile(buf.Bytes(), previewPath); err != nil {
+	if _, err := WriteFile(buf, previewPath); err != nil {
 		l4g.Error(utils.T("api.file.handle_images_forget.upload_preview.error"), previewPath, err)
 		return
 	}
@@ -4,9 +4,11 @@
 package app
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
 )
 
 func TestGeneratePublicLinkHash(t *testing.T) {
@@ -31,3 +33,32 @@ func TestGeneratePublicLinkHash(t *testing.T) {
 		t.Fatal("hashes for the same file with different salts should not be equal")
 	}
 }
+
+func TestFileBackendOverride(t *testing.T) {
+	backend := utils.NewMemoryFileBackend()
+	SetFileBackend(backend)
+	defer SetFileBackend(nil)
+
+	path := "tests/" + model.NewId() + "/file.txt"
+	if _, err := WriteFile(strings.NewReader("data"), path); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := backend.ReadFile(path); err != nil {
+		t.Fatal(err)
+	} else if string(data) != "data" {
+		t.Fatal("should have written to the override backend")
+	}
+
+	if err := MoveFile(path, path+".moved"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RemoveFile(path + ".moved"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadFile(path + ".moved"); err == nil {
+		t.Fatal("file should have been removed")
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func checkGuestAccountsEnabled(where string) *model.AppError {
+	if !*utils.Cfg.GuestAccountsSettings.Enable {
+		return model.NewAppError(where, "app.guest.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	return nil
+}
+
+func checkGuestDomain(where string, email string) *model.AppError {
+	if !CheckUserDomain(&model.User{Email: email}, *utils.Cfg.GuestAccountsSettings.AllowedDomains) {
+		return model.NewAppError(where, "app.guest.accepted_domain.app_error", nil, "email="+email, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// CreateGuest creates a user with the system_guest role. The user's email address must belong to one of the domains
+// that guests are allowed to sign up from.
+func CreateGuest(user *model.User) (*model.User, *model.AppError) {
+	if err := checkGuestAccountsEnabled("CreateGuest"); err != nil {
+		return nil, err
+	}
+
+	if err := checkGuestDomain("CreateGuest", user.Email); err != nil {
+		return nil, err
+	}
+
+	user.Roles = model.ROLE_SYSTEM_GUEST.Id
+	user.IsBot = false
+
+	if _, ok := utils.GetSupportedLocales()[user.Locale]; !ok {
+		user.Locale = *utils.Cfg.LocalizationSettings.DefaultClientLocale
+	}
+
+	ruser, err := createUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	// This message goes to everyone, so the teamId, channelId and userId are irrelevant
+	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_NEW_USER, "", "", "", nil)
+	message.Add("user_id", ruser.Id)
+	go Publish(message)
+
+	return ruser, nil
+}
+
+// GetGuestInviteChannels returns the channels that guests are invited to, which must be public or private channels on
+// the given team.
+func GetGuestInviteChannels(teamId string, channelIds []string) ([]*model.Channel, *model.AppError) {
+	if len(channelIds) == 0 {
+		return nil, model.NewAppError("GetGuestInviteChannels", "app.guest.invite.no_channels.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	channels := make([]*model.Channel, 0, len(channelIds))
+	for _, channelId := range channelIds {
+		channel, err := GetChannel(channelId)
+		if err != nil {
+			return nil, err
+		}
+
+		if channel.TeamId != teamId || (channel.Type != model.CHANNEL_OPEN && channel.Type != model.CHANNEL_PRIVATE) {
+			return nil, model.NewAppError("GetGuestInviteChannels", "app.guest.invite.invalid_channel.app_error", nil, "channel_id="+channelId, http.StatusBadRequest)
+		}
+
+		channels = append(channels, channel)
+	}
+
+	return channels, nil
+}
+
+// InviteGuestsToChannels emails invitations to join a team as guests who are only added to the given channels.
+func InviteGuestsToChannels(teamId string, channelIds []string, emailList []string, senderId string) *model.AppError {
+	if err := checkGuestAccountsEnabled("InviteGuestsToChannels"); err != nil {
+		return err
+	}
+
+	if len(emailList) == 0 {
+		return model.NewAppError("InviteGuestsToChannels", "api.team.invite_members.no_one.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	for _, email := range emailList {
+		if err := checkGuestDomain("InviteGuestsToChannels", email); err != nil {
+			return err
+		}
+	}
+
+	tchan := Srv.Store.Team().Get(teamId)
+	uchan := Srv.Store.User().Get(senderId)
+
+	var team *model.Team
+	if result := <-tchan; result.Err != nil {
+		return result.Err
+	} else {
+		team = result.Data.(*model.Team)
+	}
+
+	var user *model.User
+	if result := <-uchan; result.Err != nil {
+		return result.Err
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	channels, err := GetGuestInviteChannels(teamId, channelIds)
+	if err != nil {
+		return err
+	}
+
+	SendGuestInviteEmails(team, channels, user.GetDisplayName(), emailList, utils.GetSiteURL())
+
+	return nil
+}
+
+// addGuestToChannels adds a guest who signed up through an invitation to the channels that they were invited to.
+// Channels that have since been deleted or moved are skipped.
+func addGuestToChannels(user *model.User, teamId string, channelIds []string) {
+	for _, channelId := range channelIds {
+		channel, err := GetChannel(channelId)
+		if err != nil || channel.TeamId != teamId || channel.DeleteAt != 0 {
+			l4g.Warn(utils.T("app.guest.add_to_channel.warn"), user.Id, channelId)
+			continue
+		}
+
+		if _, err := AddUserToChannel(user, channel); err != nil {
+			l4g.Warn(utils.T("app.guest.add_to_channel.warn"), user.Id, channelId)
+			continue
+		}
+
+		if err := postJoinChannelMessage(user, channel); err != nil {
+			l4g.Error(err.Error())
+		}
+	}
+}
+
+// DemoteUserToGuest makes a user into a guest. The user keeps their team and channel memberships, but their roles in
+// them are treated as the guest roles from then on.
+func DemoteUserToGuest(user *model.User) (*model.User, *model.AppError) {
+	if err := checkGuestAccountsEnabled("DemoteUserToGuest"); err != nil {
+		return nil, err
+	}
+
+	if user.IsGuest() {
+		return user, nil
+	}
+
+	if user.IsBot {
+		return nil, model.NewAppError("DemoteUserToGuest", "app.guest.demote.bot.app_error", nil, "user_id="+user.Id, http.StatusBadRequest)
+	}
+
+	if user.IsInRole(model.ROLE_SYSTEM_ADMIN.Id) {
+		return nil, model.NewAppError("DemoteUserToGuest", "app.guest.demote.system_admin.app_error", nil, "user_id="+user.Id, http.StatusBadRequest)
+	}
+
+	ruser, err := UpdateUserRoles(user.Id, model.ROLE_SYSTEM_GUEST.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	InvalidateCacheForUser(user.Id)
+	sendUpdatedUserEvent(*ruser, false)
+
+	return ruser, nil
+}
+
+// PromoteGuestToUser makes a guest into a regular user.
+func PromoteGuestToUser(user *model.User) (*model.User, *model.AppError) {
+	if !user.IsGuest() {
+		return user, nil
+	}
+
+	ruser, err := UpdateUserRoles(user.Id, model.ROLE_SYSTEM_USER.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	InvalidateCacheForUser(user.Id)
+	sendUpdatedUserEvent(*ruser, false)
+
+	return ruser, nil
+}
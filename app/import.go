@@ -7,9 +7,14 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"unicode/utf8"
@@ -19,15 +24,23 @@ import (
 	"github.com/primefour/servers/utils"
 )
 
+const (
+	// Files attached to direct and group messages don't belong to a team
+	DIRECT_POST_FILE_TEAM_ID = "noteam"
+)
+
 // Import Data Models
 
 type LineImportData struct {
-	Type    string             `json:"type"`
-	Team    *TeamImportData    `json:"team"`
-	Channel *ChannelImportData `json:"channel"`
-	User    *UserImportData    `json:"user"`
-	Post    *PostImportData    `json:"post"`
-	Version *int               `json:"version"`
+	Type          string                   `json:"type"`
+	Team          *TeamImportData          `json:"team,omitempty"`
+	Channel       *ChannelImportData       `json:"channel,omitempty"`
+	User          *UserImportData          `json:"user,omitempty"`
+	Post          *PostImportData          `json:"post,omitempty"`
+	DirectChannel *DirectChannelImportData `json:"direct_channel,omitempty"`
+	DirectPost    *DirectPostImportData    `json:"direct_post,omitempty"`
+	Emoji         *EmojiImportData         `json:"emoji,omitempty"`
+	Version       *int                     `json:"version,omitempty"`
 }
 
 type TeamImportData struct {
@@ -95,6 +108,55 @@ type PostImportData struct {
 
 	Message  *string `json:"message"`
 	CreateAt *int64  `json:"create_at"`
+
+	Reactions   *[]ReactionImportData   `json:"reactions"`
+	Replies     *[]ReplyImportData      `json:"replies"`
+	Attachments *[]AttachmentImportData `json:"attachments"`
+}
+
+type ReplyImportData struct {
+	User *string `json:"user"`
+
+	Message  *string `json:"message"`
+	CreateAt *int64  `json:"create_at"`
+
+	Reactions   *[]ReactionImportData   `json:"reactions"`
+	Attachments *[]AttachmentImportData `json:"attachments"`
+}
+
+type ReactionImportData struct {
+	User      *string `json:"user"`
+	EmojiName *string `json:"emoji_name"`
+	CreateAt  *int64  `json:"create_at"`
+}
+
+// AttachmentImportData refers to a file on the machine running the import. Relative paths are resolved against the
+// working directory of the import process.
+type AttachmentImportData struct {
+	Path *string `json:"path"`
+}
+
+type DirectChannelImportData struct {
+	Members *[]string `json:"members"`
+	Header  *string   `json:"header"`
+}
+
+type DirectPostImportData struct {
+	ChannelMembers *[]string `json:"channel_members"`
+	User           *string   `json:"user"`
+
+	Message  *string `json:"message"`
+	CreateAt *int64  `json:"create_at"`
+
+	Reactions   *[]ReactionImportData   `json:"reactions"`
+	Replies     *[]ReplyImportData      `json:"replies"`
+	Attachments *[]AttachmentImportData `json:"attachments"`
+}
+
+type EmojiImportData struct {
+	Name    *string `json:"name"`
+	Image   *string `json:"image"`
+	Creator *string `json:"creator"`
 }
 
 type LineImportWorkerData struct {
@@ -199,6 +261,63 @@ func BulkImport(fileReader io.Reader, dryRun bool, workers int) (*model.AppError
 	return nil, 0
 }
 
+// BulkImportJob runs BulkImport on a file that has already been uploaded. The job's data holds the id of the file in
+// file_id, along with the optional dry_run and workers settings.
+func BulkImportJob(job *model.Job, cancel <-chan interface{}) *model.AppError {
+	fileId, ok := job.Data["file_id"]
+	if !ok {
+		return model.NewAppError("BulkImportJob", "app.import.bulk_import_job.file_id.error", nil, "id="+job.Id, http.StatusBadRequest)
+	}
+
+	dryRun := job.Data["dry_run"] == "true"
+
+	workers := 2
+	if value, ok := job.Data["workers"]; ok {
+		if parsed, err := strconv.Atoi(value); err != nil || parsed < 1 {
+			return model.NewAppError("BulkImportJob", "app.import.bulk_import_job.workers.error", nil, "id="+job.Id, http.StatusBadRequest)
+		} else {
+			workers = parsed
+		}
+	}
+
+	result := <-Srv.Store.FileInfo().Get(fileId)
+	if result.Err != nil {
+		return result.Err
+	}
+
+	reader, err := FileReader(result.Data.(*model.FileInfo).Path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err, lineNumber := BulkImport(&cancelableReader{reader, cancel}, dryRun, workers); err != nil {
+		if lineNumber != 0 {
+			job.Data["line_number"] = strconv.Itoa(lineNumber)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// cancelableReader stops reading once cancel has been closed so that a long import can be interrupted.
+type cancelableReader struct {
+	io.Reader
+	cancel <-chan interface{}
+}
+
+func (r *cancelableReader) Read(p []byte) (int, error) {
+	select {
+	case <-r.cancel:
+		return 0, errImportCanceled
+	default:
+		return r.Reader.Read(p)
+	}
+}
+
+var errImportCanceled = errors.New("import canceled")
+
 func processImportDataFileVersionLine(line LineImportData) (int, *model.AppError) {
 	if line.Type != "version" || line.Version == nil {
 		return -1, model.NewAppError("BulkImport", "app.import.process_import_data_file_version_line.invalid_version.error", nil, "", http.StatusBadRequest)
@@ -233,6 +352,24 @@ func ImportLine(line LineImportData, dryRun bool) *model.AppError {
 		} else {
 			return ImportPost(line.Post, dryRun)
 		}
+	case line.Type == "direct_channel":
+		if line.DirectChannel == nil {
+			return model.NewAppError("BulkImport", "app.import.import_line.null_direct_channel.error", nil, "", http.StatusBadRequest)
+		} else {
+			return ImportDirectChannel(line.DirectChannel, dryRun)
+		}
+	case line.Type == "direct_post":
+		if line.DirectPost == nil {
+			return model.NewAppError("BulkImport", "app.import.import_line.null_direct_post.error", nil, "", http.StatusBadRequest)
+		} else {
+			return ImportDirectPost(line.DirectPost, dryRun)
+		}
+	case line.Type == "emoji":
+		if line.Emoji == nil {
+			return model.NewAppError("BulkImport", "app.import.import_line.null_emoji.error", nil, "", http.StatusBadRequest)
+		} else {
+			return ImportEmoji(line.Emoji, dryRun)
+		}
 	default:
 		return model.NewLocAppError("BulkImport", "app.import.import_line.unknown_line_type.error", map[string]interface{}{"Type": line.Type}, "")
 	}
@@ -825,17 +962,32 @@ func ImportPost(data *PostImportData, dryRun bool) *model.AppError {
 		user = result.Data.(*model.User)
 	}
 
+	post, err := importPostContent(team.Id, channel.Id, user, "", *data.Message, *data.CreateAt, data.Attachments)
+	if err != nil {
+		return err
+	}
+
+	if err := importReactions(post, data.Reactions); err != nil {
+		return err
+	}
+
+	return importReplies(team.Id, post, data.Replies)
+}
+
+// importPostContent saves a post or, if rootId is set, a reply along with its attachments. A post that was already
+// imported with the same message and time is updated instead of being duplicated.
+func importPostContent(fileTeamId string, channelId string, user *model.User, rootId string, message string, createAt int64, attachments *[]AttachmentImportData) (*model.Post, *model.AppError) {
 	// Check if this post already exists.
 	var posts []*model.Post
-	if result := <-Srv.Store.Post().GetPostsCreatedAt(channel.Id, *data.CreateAt); result.Err != nil {
-		return result.Err
+	if result := <-Srv.Store.Post().GetPostsCreatedAt(channelId, createAt); result.Err != nil {
+		return nil, result.Err
 	} else {
 		posts = result.Data.([]*model.Post)
 	}
 
 	var post *model.Post
 	for _, p := range posts {
-		if p.Message == *data.Message {
+		if p.ChannelId == channelId && p.RootId == rootId && p.Message == message {
 			post = p
 			break
 		}
@@ -845,19 +997,125 @@ func ImportPost(data *PostImportData, dryRun bool) *model.AppError {
 		post = &model.Post{}
 	}
 
-	post.ChannelId = channel.Id
-	post.Message = *data.Message
+	post.ChannelId = channelId
+	post.Message = message
 	post.UserId = user.Id
-	post.CreateAt = *data.CreateAt
+	post.CreateAt = createAt
+	post.RootId = rootId
+	post.ParentId = rootId
 
 	post.Hashtags, _ = model.ParseHashtags(post.Message)
 
+	fileIds, err := importAttachments(fileTeamId, post, attachments)
+	if err != nil {
+		return nil, err
+	}
+	post.FileIds = append(post.FileIds, fileIds...)
+
 	if post.Id == "" {
 		if result := <-Srv.Store.Post().Save(post); result.Err != nil {
-			return result.Err
+			return nil, result.Err
 		}
 	} else {
 		if result := <-Srv.Store.Post().Overwrite(post); result.Err != nil {
+			return nil, result.Err
+		}
+	}
+
+	for _, fileId := range fileIds {
+		if result := <-Srv.Store.FileInfo().AttachToPost(fileId, post.Id); result.Err != nil {
+			return nil, result.Err
+		}
+	}
+
+	return post, nil
+}
+
+// importAttachments uploads the attachments that the post doesn't already have and returns the ids of their file infos.
+func importAttachments(fileTeamId string, post *model.Post, data *[]AttachmentImportData) ([]string, *model.AppError) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var existing []*model.FileInfo
+	if post.Id != "" {
+		if result := <-Srv.Store.FileInfo().GetForPost(post.Id, true, false); result.Err != nil {
+			return nil, result.Err
+		} else {
+			existing = result.Data.([]*model.FileInfo)
+		}
+	}
+
+	fileIds := []string{}
+	for _, adata := range *data {
+		if info, err := importAttachment(fileTeamId, post, *adata.Path, existing); err != nil {
+			return nil, err
+		} else if info != nil {
+			fileIds = append(fileIds, info.Id)
+		}
+	}
+
+	return fileIds, nil
+}
+
+// importAttachment uploads the file at path for a post. Nothing is uploaded and no file info is returned if the post
+// already has a file with the same name and size.
+func importAttachment(fileTeamId string, post *model.Post, path string, existing []*model.FileInfo) (*model.FileInfo, *model.AppError) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, model.NewAppError("BulkImport", "app.import.import_attachment.open.error", map[string]interface{}{"Path": path}, err.Error(), http.StatusBadRequest)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, model.NewAppError("BulkImport", "app.import.import_attachment.open.error", map[string]interface{}{"Path": path}, err.Error(), http.StatusBadRequest)
+	}
+
+	name := filepath.Base(path)
+	for _, info := range existing {
+		if info.Name == name && info.Size == stat.Size() {
+			return nil, nil
+		}
+	}
+
+	info, appErr := DoUploadFileStream(fileTeamId, post.ChannelId, post.UserId, name, file, stat.Size())
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if info.PreviewPath != "" || info.ThumbnailPath != "" {
+		if data, err := ioutil.ReadAll(file); err != nil {
+			return nil, model.NewAppError("BulkImport", "app.import.import_attachment.open.error", map[string]interface{}{"Path": path}, err.Error(), http.StatusBadRequest)
+		} else {
+			HandleImages([]string{info.PreviewPath}, []string{info.ThumbnailPath}, [][]byte{data})
+		}
+	}
+
+	return info, nil
+}
+
+func importReactions(post *model.Post, data *[]ReactionImportData) *model.AppError {
+	if data == nil {
+		return nil
+	}
+
+	for _, rdata := range *data {
+		var user *model.User
+		if result := <-Srv.Store.User().GetByUsername(*rdata.User); result.Err != nil {
+			return model.NewAppError("BulkImport", "app.import.import_reaction.user_not_found.error", map[string]interface{}{"Username": *rdata.User}, "", http.StatusBadRequest)
+		} else {
+			user = result.Data.(*model.User)
+		}
+
+		reaction := &model.Reaction{
+			UserId:    user.Id,
+			PostId:    post.Id,
+			EmojiName: *rdata.EmojiName,
+			CreateAt:  *rdata.CreateAt,
+		}
+
+		if result := <-Srv.Store.Reaction().Save(reaction); result.Err != nil {
 			return result.Err
 		}
 	}
@@ -865,6 +1123,32 @@ func ImportPost(data *PostImportData, dryRun bool) *model.AppError {
 	return nil
 }
 
+func importReplies(fileTeamId string, post *model.Post, data *[]ReplyImportData) *model.AppError {
+	if data == nil {
+		return nil
+	}
+
+	for _, rdata := range *data {
+		var user *model.User
+		if result := <-Srv.Store.User().GetByUsername(*rdata.User); result.Err != nil {
+			return model.NewAppError("BulkImport", "app.import.import_reply.user_not_found.error", map[string]interface{}{"Username": *rdata.User}, "", http.StatusBadRequest)
+		} else {
+			user = result.Data.(*model.User)
+		}
+
+		reply, err := importPostContent(fileTeamId, post.ChannelId, user, post.Id, *rdata.Message, *rdata.CreateAt, rdata.Attachments)
+		if err != nil {
+			return err
+		}
+
+		if err := importReactions(reply, rdata.Reactions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func validatePostImportData(data *PostImportData) *model.AppError {
 	if data.Team == nil {
 		return model.NewAppError("BulkImport", "app.import.validate_post_import_data.team_missing.error", nil, "", http.StatusBadRequest)
@@ -890,6 +1174,309 @@ func validatePostImportData(data *PostImportData) *model.AppError {
 		return model.NewAppError("BulkImport", "app.import.validate_post_import_data.create_at_zero.error", nil, "", http.StatusBadRequest)
 	}
 
+	if err := validateReactionsImportData(data.Reactions); err != nil {
+		return err
+	}
+
+	if err := validateRepliesImportData(data.Replies); err != nil {
+		return err
+	}
+
+	return validateAttachmentsImportData(data.Attachments)
+}
+
+func validateRepliesImportData(data *[]ReplyImportData) *model.AppError {
+	if data == nil {
+		return nil
+	}
+
+	for _, rdata := range *data {
+		if rdata.User == nil {
+			return model.NewAppError("BulkImport", "app.import.validate_reply_import_data.user_missing.error", nil, "", http.StatusBadRequest)
+		}
+
+		if rdata.Message == nil {
+			return model.NewAppError("BulkImport", "app.import.validate_reply_import_data.message_missing.error", nil, "", http.StatusBadRequest)
+		} else if utf8.RuneCountInString(*rdata.Message) > model.POST_MESSAGE_MAX_RUNES {
+			return model.NewAppError("BulkImport", "app.import.validate_reply_import_data.message_length.error", nil, "", http.StatusBadRequest)
+		}
+
+		if rdata.CreateAt == nil {
+			return model.NewAppError("BulkImport", "app.import.validate_reply_import_data.create_at_missing.error", nil, "", http.StatusBadRequest)
+		} else if *rdata.CreateAt == 0 {
+			return model.NewAppError("BulkImport", "app.import.validate_reply_import_data.create_at_zero.error", nil, "", http.StatusBadRequest)
+		}
+
+		if err := validateReactionsImportData(rdata.Reactions); err != nil {
+			return err
+		}
+
+		if err := validateAttachmentsImportData(rdata.Attachments); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateReactionsImportData(data *[]ReactionImportData) *model.AppError {
+	if data == nil {
+		return nil
+	}
+
+	for _, rdata := range *data {
+		if rdata.User == nil {
+			return model.NewAppError("BulkImport", "app.import.validate_reaction_import_data.user_missing.error", nil, "", http.StatusBadRequest)
+		}
+
+		if rdata.EmojiName == nil {
+			return model.NewAppError("BulkImport", "app.import.validate_reaction_import_data.emoji_name_missing.error", nil, "", http.StatusBadRequest)
+		} else if !model.IsValidReactionEmojiName(*rdata.EmojiName) {
+			return model.NewAppError("BulkImport", "app.import.validate_reaction_import_data.emoji_name_invalid.error", nil, "", http.StatusBadRequest)
+		}
+
+		if rdata.CreateAt == nil {
+			return model.NewAppError("BulkImport", "app.import.validate_reaction_import_data.create_at_missing.error", nil, "", http.StatusBadRequest)
+		} else if *rdata.CreateAt == 0 {
+			return model.NewAppError("BulkImport", "app.import.validate_reaction_import_data.create_at_zero.error", nil, "", http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func validateAttachmentsImportData(data *[]AttachmentImportData) *model.AppError {
+	if data == nil {
+		return nil
+	}
+
+	for _, adata := range *data {
+		if adata.Path == nil || len(*adata.Path) == 0 {
+			return model.NewAppError("BulkImport", "app.import.validate_attachment_import_data.path_missing.error", nil, "", http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func ImportDirectChannel(data *DirectChannelImportData, dryRun bool) *model.AppError {
+	if err := validateDirectChannelImportData(data); err != nil {
+		return err
+	}
+
+	// If this is a Dry Run, do not continue any further.
+	if dryRun {
+		return nil
+	}
+
+	channel, err := getOrCreateDirectChannel(*data.Members)
+	if err != nil {
+		return err
+	}
+
+	if data.Header != nil && channel.Header != *data.Header {
+		channel.Header = *data.Header
+		if _, err := UpdateChannel(channel); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getOrCreateDirectChannel returns the direct channel between two users, or the group channel between more of them,
+// creating it first if it doesn't exist yet.
+func getOrCreateDirectChannel(usernames []string) (*model.Channel, *model.AppError) {
+	userIds := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		if result := <-Srv.Store.User().GetByUsername(username); result.Err != nil {
+			return nil, model.NewAppError("BulkImport", "app.import.get_or_create_direct_channel.user_not_found.error", map[string]interface{}{"Username": username}, "", http.StatusBadRequest)
+		} else {
+			userIds = append(userIds, result.Data.(*model.User).Id)
+		}
+	}
+
+	if len(userIds) == 2 {
+		return CreateDirectChannel(userIds[0], userIds[1])
+	} else {
+		return CreateGroupChannel(userIds, userIds[0])
+	}
+}
+
+func validateDirectChannelImportData(data *DirectChannelImportData) *model.AppError {
+	if err := validateDirectChannelMembers(data.Members); err != nil {
+		return err
+	}
+
+	if data.Header != nil && utf8.RuneCountInString(*data.Header) > model.CHANNEL_HEADER_MAX_RUNES {
+		return model.NewAppError("BulkImport", "app.import.validate_direct_channel_import_data.header_length.error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func validateDirectChannelMembers(members *[]string) *model.AppError {
+	if members == nil {
+		return model.NewAppError("BulkImport", "app.import.validate_direct_channel_import_data.members_missing.error", nil, "", http.StatusBadRequest)
+	} else if len(*members) != 2 && (len(*members) < model.CHANNEL_GROUP_MIN_USERS || len(*members) > model.CHANNEL_GROUP_MAX_USERS) {
+		return model.NewAppError("BulkImport", "app.import.validate_direct_channel_import_data.members_count.error", map[string]interface{}{"Max": model.CHANNEL_GROUP_MAX_USERS}, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func ImportDirectPost(data *DirectPostImportData, dryRun bool) *model.AppError {
+	if err := validateDirectPostImportData(data); err != nil {
+		return err
+	}
+
+	// If this is a Dry Run, do not continue any further.
+	if dryRun {
+		return nil
+	}
+
+	channel, err := getOrCreateDirectChannel(*data.ChannelMembers)
+	if err != nil {
+		return err
+	}
+
+	var user *model.User
+	if result := <-Srv.Store.User().GetByUsername(*data.User); result.Err != nil {
+		return model.NewAppError("BulkImport", "app.import.import_post.user_not_found.error", map[string]interface{}{"Username": *data.User}, "", http.StatusBadRequest)
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	post, err := importPostContent(DIRECT_POST_FILE_TEAM_ID, channel.Id, user, "", *data.Message, *data.CreateAt, data.Attachments)
+	if err != nil {
+		return err
+	}
+
+	if err := importReactions(post, data.Reactions); err != nil {
+		return err
+	}
+
+	return importReplies(DIRECT_POST_FILE_TEAM_ID, post, data.Replies)
+}
+
+func validateDirectPostImportData(data *DirectPostImportData) *model.AppError {
+	if err := validateDirectChannelMembers(data.ChannelMembers); err != nil {
+		return err
+	}
+
+	if data.User == nil {
+		return model.NewAppError("BulkImport", "app.import.validate_direct_post_import_data.user_missing.error", nil, "", http.StatusBadRequest)
+	}
+
+	isMember := false
+	for _, member := range *data.ChannelMembers {
+		if member == *data.User {
+			isMember = true
+			break
+		}
+	}
+
+	if !isMember {
+		return model.NewAppError("BulkImport", "app.import.validate_direct_post_import_data.user_not_member.error", nil, "", http.StatusBadRequest)
+	}
+
+	if data.Message == nil {
+		return model.NewAppError("BulkImport", "app.import.validate_direct_post_import_data.message_missing.error", nil, "", http.StatusBadRequest)
+	} else if utf8.RuneCountInString(*data.Message) > model.POST_MESSAGE_MAX_RUNES {
+		return model.NewAppError("BulkImport", "app.import.validate_direct_post_import_data.message_length.error", nil, "", http.StatusBadRequest)
+	}
+
+	if data.CreateAt == nil {
+		return model.NewAppError("BulkImport", "app.import.validate_direct_post_import_data.create_at_missing.error", nil, "", http.StatusBadRequest)
+	} else if *data.CreateAt == 0 {
+		return model.NewAppError("BulkImport", "app.import.validate_direct_post_import_data.create_at_zero.error", nil, "", http.StatusBadRequest)
+	}
+
+	if err := validateReactionsImportData(data.Reactions); err != nil {
+		return err
+	}
+
+	if err := validateRepliesImportData(data.Replies); err != nil {
+		return err
+	}
+
+	return validateAttachmentsImportData(data.Attachments)
+}
+
+func ImportEmoji(data *EmojiImportData, dryRun bool) *model.AppError {
+	if err := validateEmojiImportData(data); err != nil {
+		return err
+	}
+
+	// If this is a Dry Run, do not continue any further.
+	if dryRun {
+		return nil
+	}
+
+	var creator *model.User
+	if result := <-Srv.Store.User().GetByUsername(*data.Creator); result.Err != nil {
+		return model.NewAppError("BulkImport", "app.import.import_emoji.creator_not_found.error", map[string]interface{}{"Username": *data.Creator}, "", http.StatusBadRequest)
+	} else {
+		creator = result.Data.(*model.User)
+	}
+
+	var emoji *model.Emoji
+	isNew := false
+	if result := <-Srv.Store.Emoji().GetByName(*data.Name); result.Err == nil && result.Data != nil {
+		emoji = result.Data.(*model.Emoji)
+	} else {
+		isNew = true
+		emoji = &model.Emoji{
+			Name:      *data.Name,
+			CreatorId: creator.Id,
+		}
+		emoji.PreSave()
+		if err := emoji.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Open(*data.Image)
+	if err != nil {
+		return model.NewAppError("BulkImport", "app.import.import_emoji.open.error", map[string]interface{}{"Path": *data.Image}, err.Error(), http.StatusBadRequest)
+	}
+	defer file.Close()
+
+	imageData, err := ioutil.ReadAll(io.LimitReader(file, MaxEmojiFileSize+1))
+	if err != nil {
+		return model.NewAppError("BulkImport", "app.import.import_emoji.open.error", map[string]interface{}{"Path": *data.Image}, err.Error(), http.StatusBadRequest)
+	} else if len(imageData) > MaxEmojiFileSize {
+		return model.NewAppError("BulkImport", "app.import.import_emoji.too_large.error", map[string]interface{}{"Path": *data.Image}, "", http.StatusBadRequest)
+	}
+
+	if err := uploadEmojiImageData(emoji.Id, filepath.Base(*data.Image), imageData); err != nil {
+		return err
+	}
+
+	if isNew {
+		if result := <-Srv.Store.Emoji().Save(emoji); result.Err != nil {
+			return result.Err
+		}
+	}
+
+	return nil
+}
+
+func validateEmojiImportData(data *EmojiImportData) *model.AppError {
+	if data.Name == nil {
+		return model.NewAppError("BulkImport", "app.import.validate_emoji_import_data.name_missing.error", nil, "", http.StatusBadRequest)
+	} else if len(*data.Name) == 0 || len(*data.Name) > model.EMOJI_NAME_MAX_LENGTH || !model.IsValidAlphaNumHyphenUnderscore(*data.Name, false) {
+		return model.NewAppError("BulkImport", "app.import.validate_emoji_import_data.name_invalid.error", nil, "", http.StatusBadRequest)
+	}
+
+	if data.Image == nil || len(*data.Image) == 0 {
+		return model.NewAppError("BulkImport", "app.import.validate_emoji_import_data.image_missing.error", nil, "", http.StatusBadRequest)
+	}
+
+	if data.Creator == nil {
+		return model.NewAppError("BulkImport", "app.import.validate_emoji_import_data.creator_missing.error", nil, "", http.StatusBadRequest)
+	}
+
 	return nil
 }
 
@@ -4,11 +4,17 @@
 package app
 
 import (
-	"github.com/primefour/servers/model"
-	"github.com/primefour/servers/utils"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"runtime/debug"
 	"strings"
 	"testing"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
 )
 
 func ptrStr(s string) *string {
@@ -571,6 +577,248 @@ func TestImportValidatePostImportData(t *testing.T) {
 	}
 }
 
+func TestImportValidateReplyImportData(t *testing.T) {
+
+	// Test with minimum required valid properties.
+	data := []ReplyImportData{{
+		User:     ptrStr("username"),
+		Message:  ptrStr("message"),
+		CreateAt: ptrInt64(model.GetMillis()),
+	}}
+	if err := validateRepliesImportData(&data); err != nil {
+		t.Fatal("Validation failed but should have been valid.")
+	}
+
+	// Test with missing required properties.
+	data[0].User = nil
+	if err := validateRepliesImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing user.")
+	}
+
+	data[0].User = ptrStr("username")
+	data[0].Message = nil
+	if err := validateRepliesImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing message.")
+	}
+
+	data[0].Message = ptrStr(strings.Repeat("1234567890", 500))
+	if err := validateRepliesImportData(&data); err == nil {
+		t.Fatal("Should have failed due to too long message.")
+	}
+
+	data[0].Message = ptrStr("message")
+	data[0].CreateAt = nil
+	if err := validateRepliesImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing create-at.")
+	}
+
+	data[0].CreateAt = ptrInt64(0)
+	if err := validateRepliesImportData(&data); err == nil {
+		t.Fatal("Should have failed due to 0 create-at value.")
+	}
+
+	// Test with invalid nested data.
+	data[0].CreateAt = ptrInt64(model.GetMillis())
+	data[0].Reactions = &[]ReactionImportData{{User: ptrStr("username")}}
+	if err := validateRepliesImportData(&data); err == nil {
+		t.Fatal("Should have failed due to an invalid reaction.")
+	}
+
+	data[0].Reactions = nil
+	data[0].Attachments = &[]AttachmentImportData{{}}
+	if err := validateRepliesImportData(&data); err == nil {
+		t.Fatal("Should have failed due to an invalid attachment.")
+	}
+}
+
+func TestImportValidateReactionImportData(t *testing.T) {
+
+	// Test with minimum required valid properties.
+	data := []ReactionImportData{{
+		User:      ptrStr("username"),
+		EmojiName: ptrStr("+1"),
+		CreateAt:  ptrInt64(model.GetMillis()),
+	}}
+	if err := validateReactionsImportData(&data); err != nil {
+		t.Fatal("Validation failed but should have been valid.")
+	}
+
+	// Test with missing required properties.
+	data[0].User = nil
+	if err := validateReactionsImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing user.")
+	}
+
+	data[0].User = ptrStr("username")
+	data[0].EmojiName = nil
+	if err := validateReactionsImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing emoji name.")
+	}
+
+	data[0].EmojiName = ptrStr("not an emoji")
+	if err := validateReactionsImportData(&data); err == nil {
+		t.Fatal("Should have failed due to invalid emoji name.")
+	}
+
+	data[0].EmojiName = ptrStr("smile")
+	data[0].CreateAt = nil
+	if err := validateReactionsImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing create-at.")
+	}
+
+	data[0].CreateAt = ptrInt64(0)
+	if err := validateReactionsImportData(&data); err == nil {
+		t.Fatal("Should have failed due to 0 create-at value.")
+	}
+}
+
+func TestImportValidateAttachmentImportData(t *testing.T) {
+
+	data := []AttachmentImportData{{Path: ptrStr("file.txt")}}
+	if err := validateAttachmentsImportData(&data); err != nil {
+		t.Fatal("Validation failed but should have been valid.")
+	}
+
+	data[0].Path = ptrStr("")
+	if err := validateAttachmentsImportData(&data); err == nil {
+		t.Fatal("Should have failed due to empty path.")
+	}
+
+	data[0].Path = nil
+	if err := validateAttachmentsImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing path.")
+	}
+}
+
+func TestImportValidateDirectChannelImportData(t *testing.T) {
+
+	// Test with minimum required valid properties.
+	data := DirectChannelImportData{
+		Members: &[]string{"user1", "user2"},
+	}
+	if err := validateDirectChannelImportData(&data); err != nil {
+		t.Fatal("Validation failed but should have been valid.")
+	}
+
+	// Test with a group channel.
+	data.Members = &[]string{"user1", "user2", "user3"}
+	if err := validateDirectChannelImportData(&data); err != nil {
+		t.Fatal("Validation failed but should have been valid.")
+	}
+
+	// Test with the wrong number of members.
+	data.Members = &[]string{"user1"}
+	if err := validateDirectChannelImportData(&data); err == nil {
+		t.Fatal("Should have failed due to too few members.")
+	}
+
+	data.Members = &[]string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}
+	if err := validateDirectChannelImportData(&data); err == nil {
+		t.Fatal("Should have failed due to too many members.")
+	}
+
+	data.Members = nil
+	if err := validateDirectChannelImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing members.")
+	}
+
+	// Test with all optional parameters.
+	data.Members = &[]string{"user1", "user2"}
+	data.Header = ptrStr("header")
+	if err := validateDirectChannelImportData(&data); err != nil {
+		t.Fatal("Validation failed but should have been valid.")
+	}
+
+	data.Header = ptrStr(strings.Repeat("1234567890", 110))
+	if err := validateDirectChannelImportData(&data); err == nil {
+		t.Fatal("Should have failed due to too long header.")
+	}
+}
+
+func TestImportValidateDirectPostImportData(t *testing.T) {
+
+	// Test with minimum required valid properties.
+	data := DirectPostImportData{
+		ChannelMembers: &[]string{"user1", "user2"},
+		User:           ptrStr("user1"),
+		Message:        ptrStr("message"),
+		CreateAt:       ptrInt64(model.GetMillis()),
+	}
+	if err := validateDirectPostImportData(&data); err != nil {
+		t.Fatal("Validation failed but should have been valid.")
+	}
+
+	// Test with missing required properties.
+	data.ChannelMembers = nil
+	if err := validateDirectPostImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing channel members.")
+	}
+
+	data.ChannelMembers = &[]string{"user1", "user2"}
+	data.User = nil
+	if err := validateDirectPostImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing user.")
+	}
+
+	data.User = ptrStr("user3")
+	if err := validateDirectPostImportData(&data); err == nil {
+		t.Fatal("Should have failed due to the user not being a channel member.")
+	}
+
+	data.User = ptrStr("user2")
+	data.Message = nil
+	if err := validateDirectPostImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing message.")
+	}
+
+	data.Message = ptrStr("message")
+	data.CreateAt = ptrInt64(0)
+	if err := validateDirectPostImportData(&data); err == nil {
+		t.Fatal("Should have failed due to 0 create-at value.")
+	}
+
+	// Test with invalid nested data.
+	data.CreateAt = ptrInt64(model.GetMillis())
+	data.Replies = &[]ReplyImportData{{User: ptrStr("user1")}}
+	if err := validateDirectPostImportData(&data); err == nil {
+		t.Fatal("Should have failed due to an invalid reply.")
+	}
+}
+
+func TestImportValidateEmojiImportData(t *testing.T) {
+
+	data := EmojiImportData{
+		Name:    ptrStr("emoji"),
+		Image:   ptrStr("emoji.png"),
+		Creator: ptrStr("username"),
+	}
+	if err := validateEmojiImportData(&data); err != nil {
+		t.Fatal("Validation failed but should have been valid.")
+	}
+
+	data.Name = ptrStr("not valid")
+	if err := validateEmojiImportData(&data); err == nil {
+		t.Fatal("Should have failed due to invalid name.")
+	}
+
+	data.Name = nil
+	if err := validateEmojiImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing name.")
+	}
+
+	data.Name = ptrStr("emoji")
+	data.Image = ptrStr("")
+	if err := validateEmojiImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing image.")
+	}
+
+	data.Image = ptrStr("emoji.png")
+	data.Creator = nil
+	if err := validateEmojiImportData(&data); err == nil {
+		t.Fatal("Should have failed due to missing creator.")
+	}
+}
+
 func TestImportImportTeam(t *testing.T) {
 	_ = Setup()
 
@@ -1719,6 +1967,268 @@ func TestImportImportPost(t *testing.T) {
 	}
 }
 
+func TestImportImportPostWithRepliesReactionsAndAttachments(t *testing.T) {
+	_ = Setup()
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	teamName := model.NewId()
+	ImportTeam(&TeamImportData{
+		Name:        &teamName,
+		DisplayName: ptrStr("Display Name"),
+		Type:        ptrStr("O"),
+	}, false)
+	team, err := GetTeamByName(teamName)
+	if err != nil {
+		t.Fatalf("Failed to get team from database.")
+	}
+
+	channelName := model.NewId()
+	ImportChannel(&ChannelImportData{
+		Team:        &teamName,
+		Name:        &channelName,
+		DisplayName: ptrStr("Display Name"),
+		Type:        ptrStr("O"),
+	}, false)
+	channel, err := GetChannelByName(channelName, team.Id)
+	if err != nil {
+		t.Fatalf("Failed to get channel from database.")
+	}
+
+	username := model.NewId()
+	ImportUser(&UserImportData{
+		Username: &username,
+		Email:    ptrStr(model.NewId() + "@example.com"),
+	}, false)
+
+	dir, ioErr := ioutil.TempDir("", "import")
+	if ioErr != nil {
+		t.Fatal(ioErr)
+	}
+	defer os.RemoveAll(dir)
+
+	attachmentPath := filepath.Join(dir, "attachment.txt")
+	if ioErr := ioutil.WriteFile(attachmentPath, []byte("attached"), 0600); ioErr != nil {
+		t.Fatal(ioErr)
+	}
+
+	postTime := model.GetMillis()
+	replyTime := postTime + 1
+	data := &PostImportData{
+		Team:     &teamName,
+		Channel:  &channelName,
+		User:     &username,
+		Message:  ptrStr("Hello"),
+		CreateAt: &postTime,
+		Reactions: &[]ReactionImportData{{
+			User:      &username,
+			EmojiName: ptrStr("smile"),
+			CreateAt:  ptrInt64(postTime + 2),
+		}},
+		Replies: &[]ReplyImportData{{
+			User:     &username,
+			Message:  ptrStr("Reply"),
+			CreateAt: &replyTime,
+		}},
+		Attachments: &[]AttachmentImportData{{Path: &attachmentPath}},
+	}
+
+	// Importing the same post twice shouldn't duplicate any of it.
+	for i := 0; i < 2; i++ {
+		if err := ImportPost(data, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var post *model.Post
+	if result := <-Srv.Store.Post().GetPostsCreatedAt(channel.Id, postTime); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		for _, p := range result.Data.([]*model.Post) {
+			if p.ChannelId == channel.Id {
+				if post != nil {
+					t.Fatal("post should only have been imported once")
+				}
+				post = p
+			}
+		}
+	}
+
+	if post == nil {
+		t.Fatal("post should have been imported")
+	} else if len(post.FileIds) != 1 {
+		t.Fatal("post should have one attachment")
+	}
+
+	if infos, err := GetFileInfosForPost(post.Id, true); err != nil {
+		t.Fatal(err)
+	} else if len(infos) != 1 || infos[0].Name != "attachment.txt" {
+		t.Fatal("attachment should have been uploaded")
+	}
+
+	if reactions, err := GetReactionsForPost(post.Id); err != nil {
+		t.Fatal(err)
+	} else if len(reactions) != 1 || reactions[0].EmojiName != "smile" {
+		t.Fatal("reaction should have been imported")
+	}
+
+	if result := <-Srv.Store.Post().GetPostsCreatedAt(channel.Id, replyTime); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		replies := 0
+		for _, p := range result.Data.([]*model.Post) {
+			if p.ChannelId == channel.Id {
+				replies++
+				if p.RootId != post.Id {
+					t.Fatal("reply should belong to the post")
+				}
+			}
+		}
+
+		if replies != 1 {
+			t.Fatal("reply should have been imported once")
+		}
+	}
+}
+
+func TestImportImportDirectChannelAndPost(t *testing.T) {
+	_ = Setup()
+
+	usernames := []string{model.NewId(), model.NewId(), model.NewId()}
+	for _, username := range usernames {
+		ImportUser(&UserImportData{
+			Username: ptrStr(username),
+			Email:    ptrStr(model.NewId() + "@example.com"),
+		}, false)
+	}
+
+	// Dry run shouldn't create anything.
+	data := &DirectChannelImportData{
+		Members: &[]string{usernames[0], usernames[1]},
+		Header:  ptrStr("Direct header"),
+	}
+	if err := ImportDirectChannel(data, true); err != nil {
+		t.Fatal(err)
+	}
+
+	user1, _ := GetUserByUsername(usernames[0])
+	user2, _ := GetUserByUsername(usernames[1])
+	if _, err := GetChannelByName(model.GetDMNameFromIds(user1.Id, user2.Id), ""); err == nil {
+		t.Fatal("dry run shouldn't have created the channel")
+	}
+
+	if err := ImportDirectChannel(data, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if channel, err := GetChannelByName(model.GetDMNameFromIds(user1.Id, user2.Id), ""); err != nil {
+		t.Fatal(err)
+	} else if channel.Header != "Direct header" {
+		t.Fatal("should have set the header")
+	}
+
+	// Posting to a group channel that doesn't exist yet creates it.
+	postTime := model.GetMillis()
+	post := &DirectPostImportData{
+		ChannelMembers: &usernames,
+		User:           ptrStr(usernames[2]),
+		Message:        ptrStr("Group message"),
+		CreateAt:       &postTime,
+	}
+	if err := ImportDirectPost(post, false); err != nil {
+		t.Fatal(err)
+	}
+
+	user3, _ := GetUserByUsername(usernames[2])
+	group, err := GetChannelByName(model.GetGroupNameFromUserIds([]string{user1.Id, user2.Id, user3.Id}), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-Srv.Store.Post().GetPostsCreatedAt(group.Id, postTime); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		found := false
+		for _, p := range result.Data.([]*model.Post) {
+			if p.ChannelId == group.Id && p.UserId == user3.Id && p.Message == "Group message" {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("should have imported the group message")
+		}
+	}
+}
+
+func TestImportImportEmoji(t *testing.T) {
+	_ = Setup()
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	username := model.NewId()
+	ImportUser(&UserImportData{
+		Username: &username,
+		Email:    ptrStr(model.NewId() + "@example.com"),
+	}, false)
+
+	dir, ioErr := ioutil.TempDir("", "import")
+	if ioErr != nil {
+		t.Fatal(ioErr)
+	}
+	defer os.RemoveAll(dir)
+
+	imagePath := filepath.Join(dir, "emoji.png")
+	if file, ioErr := os.Create(imagePath); ioErr != nil {
+		t.Fatal(ioErr)
+	} else {
+		png.Encode(file, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+		file.Close()
+	}
+
+	name := "a" + strings.ToLower(model.NewId()[:10])
+	data := &EmojiImportData{
+		Name:    &name,
+		Image:   &imagePath,
+		Creator: &username,
+	}
+
+	if err := ImportEmoji(data, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-Srv.Store.Emoji().GetByName(name); result.Err == nil {
+		t.Fatal("dry run shouldn't have created the emoji")
+	}
+
+	// Importing the same emoji twice should update it instead of failing.
+	for i := 0; i < 2; i++ {
+		if err := ImportEmoji(data, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if result := <-Srv.Store.Emoji().GetByName(name); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		emoji := result.Data.(*model.Emoji)
+		defer DeleteEmoji(emoji)
+
+		if _, _, err := GetEmojiImage(emoji.Id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data.Image = ptrStr(filepath.Join(dir, "missing.png"))
+	if err := ImportEmoji(data, false); err == nil {
+		t.Fatal("should have failed to import a missing image")
+	}
+}
+
 func TestImportImportLine(t *testing.T) {
 	_ = Setup()
 
@@ -1754,6 +2264,24 @@ func TestImportImportLine(t *testing.T) {
 	if err := ImportLine(line, false); err == nil {
 		t.Fatalf("Expected an error when importing a line with type post with a nil post.")
 	}
+
+	// Try import line with direct_channel type but nil direct_channel.
+	line.Type = "direct_channel"
+	if err := ImportLine(line, false); err == nil {
+		t.Fatalf("Expected an error when importing a line with type direct_channel with a nil direct_channel.")
+	}
+
+	// Try import line with direct_post type but nil direct_post.
+	line.Type = "direct_post"
+	if err := ImportLine(line, false); err == nil {
+		t.Fatalf("Expected an error when importing a line with type direct_post with a nil direct_post.")
+	}
+
+	// Try import line with emoji type but nil emoji.
+	line.Type = "emoji"
+	if err := ImportLine(line, false); err == nil {
+		t.Fatalf("Expected an error when importing a line with type emoji with a nil emoji.")
+	}
 }
 
 func TestImportBulkImport(t *testing.T) {
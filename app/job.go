@@ -0,0 +1,68 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"time"
+
+	"github.com/primefour/servers/jobs"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+// InitJobs registers the workers and schedulers for every job type. They are only started by jobs.StartWorkers and
+// jobs.StartSchedulers.
+func InitJobs() {
+	jobs.RegisterWorker(model.JOB_TYPE_BULK_IMPORT, jobs.NewSimpleWorker("BulkImport", BulkImportJob))
+	jobs.RegisterWorker(model.JOB_TYPE_LDAP_SYNC, jobs.NewSimpleWorker("LdapSync", LdapSyncJob))
+	jobs.RegisterWorker(model.JOB_TYPE_COMPLIANCE_EXPORT, jobs.NewSimpleWorker("ComplianceExport", ComplianceExportJob))
+	jobs.RegisterWorker(model.JOB_TYPE_DATA_RETENTION, jobs.NewSimpleWorker("DataRetention", DataRetentionJob))
+	jobs.RegisterWorker(model.JOB_TYPE_SEARCH_INDEXING, jobs.NewSimpleWorker("SearchIndexing", SearchIndexingJob))
+
+	jobs.RegisterScheduler(model.JOB_TYPE_LDAP_SYNC, jobs.NewPeriodicScheduler("LdapSync", model.JOB_TYPE_LDAP_SYNC, isLdapSyncEnabled, func() time.Duration {
+		if *utils.Cfg.LdapSettings.SyncIntervalMinutes < 1 {
+			return time.Minute
+		}
+
+		return time.Duration(*utils.Cfg.LdapSettings.SyncIntervalMinutes) * time.Minute
+	}))
+	jobs.RegisterScheduler(model.JOB_TYPE_COMPLIANCE_EXPORT, jobs.NewPeriodicScheduler("ComplianceExport", model.JOB_TYPE_COMPLIANCE_EXPORT, isDailyComplianceEnabled, func() time.Duration {
+		return 24 * time.Hour
+	}))
+	jobs.RegisterScheduler(model.JOB_TYPE_DATA_RETENTION, jobs.NewPeriodicScheduler("DataRetention", model.JOB_TYPE_DATA_RETENTION, isDataRetentionEnabled, func() time.Duration {
+		return 24 * time.Hour
+	}))
+}
+
+func GetJob(id string) (*model.Job, *model.AppError) {
+	if result := <-Srv.Store.Job().Get(id); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.Job), nil
+	}
+}
+
+func GetJobsPage(page int, perPage int) ([]*model.Job, *model.AppError) {
+	if result := <-Srv.Store.Job().GetAllPage(page*perPage, perPage); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.Job), nil
+	}
+}
+
+func GetJobsByTypePage(jobType string, page int, perPage int) ([]*model.Job, *model.AppError) {
+	if result := <-Srv.Store.Job().GetAllByTypePage(jobType, page*perPage, perPage); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.Job), nil
+	}
+}
+
+func CreateJob(job *model.Job) (*model.Job, *model.AppError) {
+	return jobs.CreateJob(job.Type, job.Data)
+}
+
+func CancelJob(jobId string) *model.AppError {
+	return jobs.RequestCancellation(jobId)
+}
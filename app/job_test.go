@@ -0,0 +1,80 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/store"
+)
+
+func TestGetJob(t *testing.T) {
+	Setup()
+
+	job := &model.Job{
+		Id:       model.NewId(),
+		Type:     model.JOB_TYPE_BULK_IMPORT,
+		CreateAt: model.GetMillis(),
+		Status:   model.JOB_STATUS_PENDING,
+	}
+	if result := <-Srv.Store.Job().Save(job); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	defer Srv.Store.Job().Delete(job.Id)
+
+	if received, err := GetJob(job.Id); err != nil {
+		t.Fatal(err)
+	} else if received.Id != job.Id || received.Status != job.Status {
+		t.Fatal("incorrect job received")
+	}
+}
+
+func TestGetJobsByType(t *testing.T) {
+	Setup()
+
+	jobType := model.JOB_TYPE_COMPLIANCE_EXPORT
+
+	jobs := []*model.Job{
+		{
+			Id:       model.NewId(),
+			Type:     jobType,
+			CreateAt: model.GetMillis() + 1000,
+			Status:   model.JOB_STATUS_SUCCESS,
+		},
+		{
+			Id:       model.NewId(),
+			Type:     jobType,
+			CreateAt: model.GetMillis() + 2000,
+			Status:   model.JOB_STATUS_SUCCESS,
+		},
+	}
+
+	for _, job := range jobs {
+		store.Must(Srv.Store.Job().Save(job))
+		defer Srv.Store.Job().Delete(job.Id)
+	}
+
+	if received, err := GetJobsByTypePage(jobType, 0, 2); err != nil {
+		t.Fatal(err)
+	} else if len(received) != 2 || received[0].Id != jobs[1].Id || received[1].Id != jobs[0].Id {
+		t.Fatal("should've received both jobs, newest first")
+	}
+}
+
+func TestCreateDailyComplianceReport(t *testing.T) {
+	Setup()
+
+	report, err := createDailyComplianceReport(time.Date(2017, time.June, 15, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Type != model.COMPLIANCE_TYPE_DAILY || report.Desc != "2017-06-14" {
+		t.Fatal("should have created a daily report")
+	} else if report.EndAt-report.StartAt != 24*60*60*1000-1 {
+		t.Fatal("daily report should cover a single day")
+	}
+}
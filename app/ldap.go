@@ -8,24 +8,39 @@ import (
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/primefour/servers/einterfaces"
+	"github.com/primefour/servers/jobs"
 	"github.com/primefour/servers/model"
 	"github.com/primefour/servers/utils"
 )
 
+// SyncLdap queues a job to synchronize users with the LDAP server.
 func SyncLdap() {
-	go func() {
-		if utils.IsLicensed && *utils.License.Features.LDAP && *utils.Cfg.LdapSettings.Enable {
-			if ldapI := einterfaces.GetLdapInterface(); ldapI != nil {
-				ldapI.SyncNow()
-			} else {
-				l4g.Error("%v", model.NewLocAppError("SyncLdap", "ent.ldap.disabled.app_error", nil, "").Error())
-			}
+	if isLdapSyncEnabled() {
+		if _, err := jobs.CreateJob(model.JOB_TYPE_LDAP_SYNC, nil); err != nil {
+			l4g.Error(utils.T("ent.ldap.sync_worker.create_job.error"), err.Error())
 		}
-	}()
+	}
+}
+
+func isLdapSyncEnabled() bool {
+	return *utils.Cfg.LdapSettings.Enable
+}
+
+func LdapSyncJob(job *model.Job, cancel <-chan interface{}) *model.AppError {
+	if !isLdapSyncEnabled() {
+		return model.NewAppError("LdapSyncJob", "ent.ldap.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	ldapI := einterfaces.GetLdapInterface()
+	if ldapI == nil {
+		return model.NewAppError("LdapSyncJob", "ent.ldap.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	return ldapI.Syncronize()
 }
 
 func TestLdap() *model.AppError {
-	if ldapI := einterfaces.GetLdapInterface(); ldapI != nil && utils.IsLicensed && *utils.License.Features.LDAP && *utils.Cfg.LdapSettings.Enable {
+	if ldapI := einterfaces.GetLdapInterface(); ldapI != nil && *utils.Cfg.LdapSettings.Enable {
 		if err := ldapI.RunTest(); err != nil {
 			err.StatusCode = 500
 			return err
@@ -21,6 +21,10 @@ import (
 	"github.com/primefour/servers/utils"
 )
 
+const (
+	TOKEN_TYPE_OPENID_NONCE = "openid_nonce"
+)
+
 func CreateOAuthApp(app *model.OAuthApp) (*model.OAuthApp, *model.AppError) {
 	if !utils.Cfg.ServiceSettings.EnableOAuthServiceProvider {
 		return nil, model.NewAppError("CreateOAuthApp", "api.oauth.register_oauth_app.turn_off.app_error", nil, "", http.StatusNotImplemented)
@@ -522,15 +526,34 @@ func CompleteSwitchWithOAuth(service string, userData io.ReadCloser, email strin
 
 func GetAuthorizationCode(service string, props map[string]string, loginHint string) (string, *model.AppError) {
 	sso := utils.Cfg.GetSSOService(service)
-	if sso != nil && !sso.Enable {
+	if sso == nil || !sso.Enable {
 		return "", model.NewAppError("GetAuthorizationCode", "api.user.get_authorization_code.unsupported.app_error", nil, "service="+service, http.StatusNotImplemented)
 	}
 
+	sso, err := getOAuthEndpoints(service, sso)
+	if err != nil {
+		return "", err
+	}
+
 	clientId := sso.Id
 	endpoint := sso.AuthEndpoint
 	scope := sso.Scope
 
 	props["hash"] = utils.HashSha256(clientId)
+
+	// OpenID Connect providers echo a nonce in the id token so that a token can only complete the login it was
+	// requested for
+	nonce := ""
+	if _, ok := einterfaces.GetOauthProvider(service).(einterfaces.OpenIdProvider); ok {
+		token := model.NewToken(TOKEN_TYPE_OPENID_NONCE, "")
+		if result := <-Srv.Store.Token().Save(token); result.Err != nil {
+			return "", result.Err
+		}
+
+		nonce = token.Token
+		props["nonce"] = nonce
+	}
+
 	state := b64.StdEncoding.EncodeToString([]byte(model.MapToJson(props)))
 
 	redirectUri := utils.GetSiteURL() + "/signup/" + service + "/complete"
@@ -545,9 +568,23 @@ func GetAuthorizationCode(service string, props map[string]string, loginHint str
 		authUrl += "&login_hint=" + utils.UrlEncode(loginHint)
 	}
 
+	if len(nonce) > 0 {
+		authUrl += "&nonce=" + url.QueryEscape(nonce)
+	}
+
 	return authUrl, nil
 }
 
+// getOAuthEndpoints returns the settings for an SSO service with the endpoints filled in for providers that discover
+// them.
+func getOAuthEndpoints(service string, sso *model.SSOSettings) (*model.SSOSettings, *model.AppError) {
+	if provider, ok := einterfaces.GetOauthProvider(service).(einterfaces.OpenIdProvider); ok {
+		return provider.GetEndpoints(sso)
+	}
+
+	return sso, nil
+}
+
 func AuthorizeOAuthUser(service, code, state, redirectUri string) (io.ReadCloser, string, map[string]string, *model.AppError) {
 	sso := utils.Cfg.GetSSOService(service)
 	if sso == nil || !sso.Enable {
@@ -569,6 +606,11 @@ func AuthorizeOAuthUser(service, code, state, redirectUri string) (io.ReadCloser
 
 	teamId := stateProps["team_id"]
 
+	sso, endpointsErr := getOAuthEndpoints(service, sso)
+	if endpointsErr != nil {
+		return nil, "", nil, endpointsErr
+	}
+
 	p := url.Values{}
 	p.Set("client_id", sso.Id)
 	p.Set("client_secret", sso.Secret)
@@ -608,6 +650,19 @@ func AuthorizeOAuthUser(service, code, state, redirectUri string) (io.ReadCloser
 		return nil, "", nil, model.NewLocAppError("AuthorizeOAuthUser", "api.user.authorize_oauth_user.missing.app_error", nil, "")
 	}
 
+	// OpenID Connect providers identify the user with the id token rather than the user API response alone
+	if provider, ok := einterfaces.GetOauthProvider(service).(einterfaces.OpenIdProvider); ok {
+		if err := useOpenIdNonce(stateProps["nonce"]); err != nil {
+			return nil, "", nil, err
+		}
+
+		if data, err := provider.GetUserData(sso, ar, stateProps["nonce"]); err != nil {
+			return nil, "", nil, err
+		} else {
+			return ioutil.NopCloser(bytes.NewReader(data)), teamId, stateProps, nil
+		}
+	}
+
 	p = url.Values{}
 	p.Set("access_token", ar.AccessToken)
 	req, _ = http.NewRequest("GET", sso.UserApiEndpoint, strings.NewReader(""))
@@ -625,6 +680,21 @@ func AuthorizeOAuthUser(service, code, state, redirectUri string) (io.ReadCloser
 
 }
 
+// useOpenIdNonce deletes the token for a nonce sent with an OpenID Connect authorization request so that it can only be
+// used once.
+func useOpenIdNonce(nonce string) *model.AppError {
+	result := <-Srv.Store.Token().GetByToken(nonce)
+	if len(nonce) == 0 || result.Err != nil || result.Data.(*model.Token).Type != TOKEN_TYPE_OPENID_NONCE {
+		return model.NewAppError("AuthorizeOAuthUser", "api.user.authorize_oauth_user.invalid_nonce.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if result := <-Srv.Store.Token().Delete(nonce); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
 func SwitchEmailToOAuth(email, password, code, service string) (string, *model.AppError) {
 	var user *model.User
 	var err *model.AppError
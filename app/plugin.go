@@ -0,0 +1,512 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/plugin/rpcplugin"
+	"github.com/primefour/servers/utils"
+)
+
+type pluginBundle struct {
+	dir      string
+	manifest *model.Manifest
+}
+
+type activePlugin struct {
+	manifest   *model.Manifest
+	supervisor *rpcplugin.Supervisor // nil for plugins without a backend
+	restarts   int                   // how many times the plugin was started again after its process exited
+}
+
+const (
+	PLUGIN_MAX_RESTARTS = 3
+)
+
+type pluginCommand struct {
+	pluginId string
+	command  *model.Command
+}
+
+var (
+	// pluginsSyncLock makes sure that plugins are activated and deactivated by one goroutine at a time. Hooks are
+	// called without holding pluginsLock since plugins call back into the server while they run.
+	pluginsSyncLock    sync.Mutex
+	pluginsInitialized bool
+
+	pluginsLock    sync.RWMutex
+	activePlugins  = make(map[string]*activePlugin)
+	pluginCommands = []*pluginCommand{}
+)
+
+// pluginLogWriter logs what a plugin writes to its stdout and stderr.
+type pluginLogWriter struct {
+	pluginId string
+}
+
+func (w *pluginLogWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		l4g.Info(utils.T("api.plugin.output.info"), w.pluginId, line)
+	}
+
+	return len(p), nil
+}
+
+func getPluginDirectory() string {
+	dir, _ := filepath.Abs(*utils.Cfg.PluginSettings.Directory)
+	return dir
+}
+
+// findPluginBundles returns the plugins found in the plugin directory by their id. Each plugin is in a directory of
+// its own with a manifest at its root.
+func findPluginBundles() (map[string]*pluginBundle, *model.AppError) {
+	bundles := make(map[string]*pluginBundle)
+
+	dir := getPluginDirectory()
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return bundles, nil
+	} else if err != nil {
+		return nil, model.NewAppError("findPluginBundles", "api.plugin.read_directory.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		bundleDir := filepath.Join(dir, entry.Name())
+
+		file, err := os.Open(filepath.Join(bundleDir, model.PLUGIN_MANIFEST_FILENAME))
+		if err != nil {
+			continue
+		}
+
+		manifest := model.ManifestFromJson(file)
+		file.Close()
+
+		if manifest == nil {
+			l4g.Warn(utils.T("api.plugin.manifest.warn"), bundleDir)
+			continue
+		} else if err := manifest.IsValid(); err != nil {
+			l4g.Warn(utils.T("api.plugin.manifest.warn"), bundleDir+", err="+err.Error())
+			continue
+		}
+
+		bundles[manifest.Id] = &pluginBundle{dir: bundleDir, manifest: manifest}
+	}
+
+	return bundles, nil
+}
+
+func isPluginEnabled(id string) bool {
+	state := utils.Cfg.PluginSettings.PluginStates[id]
+	return state != nil && state.Enable
+}
+
+// InitPlugins activates the plugins that are enabled in the config. From then on, SyncPluginsActiveState keeps the
+// active plugins in line with the config whenever it changes.
+func InitPlugins() {
+	pluginsSyncLock.Lock()
+	pluginsInitialized = true
+	pluginsSyncLock.Unlock()
+
+	SyncPluginsActiveState()
+}
+
+// ShutDownPlugins deactivates every active plugin.
+func ShutDownPlugins() {
+	pluginsSyncLock.Lock()
+	defer pluginsSyncLock.Unlock()
+
+	for _, id := range getActivePluginIds() {
+		deactivatePlugin(id)
+	}
+
+	pluginsInitialized = false
+}
+
+// SyncPluginsActiveState activates the plugins that have been enabled and deactivates the ones that have been
+// disabled or removed. Plugins that stay active are told that the config has changed.
+func SyncPluginsActiveState() {
+	pluginsSyncLock.Lock()
+	defer pluginsSyncLock.Unlock()
+
+	if !pluginsInitialized {
+		return
+	}
+
+	bundles := make(map[string]*pluginBundle)
+	if *utils.Cfg.PluginSettings.Enable {
+		var err *model.AppError
+		if bundles, err = findPluginBundles(); err != nil {
+			l4g.Error(err.Error())
+			return
+		}
+	}
+
+	for _, id := range getActivePluginIds() {
+		if bundles[id] == nil || !isPluginEnabled(id) {
+			deactivatePlugin(id)
+		} else if plugin := getActivePlugin(id); plugin.supervisor != nil {
+			if err := plugin.supervisor.Hooks().OnConfigurationChange(); err != nil {
+				l4g.Error(utils.T("api.plugin.configuration_change.error"), id, err.Error())
+			}
+		}
+	}
+
+	for id, bundle := range bundles {
+		if isPluginEnabled(id) && getActivePlugin(id) == nil {
+			activatePlugin(bundle, 0)
+		}
+	}
+}
+
+func activatePlugin(bundle *pluginBundle, restarts int) {
+	id := bundle.manifest.Id
+	plugin := &activePlugin{manifest: bundle.manifest, restarts: restarts}
+
+	if bundle.manifest.Backend != nil {
+		cmd := exec.Command(filepath.Join(bundle.dir, bundle.manifest.Backend.Executable))
+		cmd.Dir = bundle.dir
+		cmd.Stdout = &pluginLogWriter{pluginId: id}
+		cmd.Stderr = cmd.Stdout
+
+		api := &PluginAPI{id: id}
+
+		supervisor, err := rpcplugin.StartSupervisor(cmd, api)
+		if err != nil {
+			l4g.Error(utils.T("api.plugin.activate.error"), id, err.Error())
+			return
+		}
+
+		if err := supervisor.Hooks().OnActivate(api); err != nil {
+			l4g.Error(utils.T("api.plugin.activate.error"), id, err.Error())
+			unregisterPluginCommands(id)
+			supervisor.Stop()
+			return
+		}
+
+		plugin.supervisor = supervisor
+	}
+
+	pluginsLock.Lock()
+	activePlugins[id] = plugin
+	pluginsLock.Unlock()
+
+	if plugin.supervisor != nil {
+		go watchPlugin(id, plugin)
+	}
+
+	l4g.Info(utils.T("api.plugin.activated.info"), id)
+}
+
+// watchPlugin waits for the process of a plugin to exit. If the plugin is still active by then, it crashed or was
+// killed for not answering a hook, so it's deactivated and started again, up to PLUGIN_MAX_RESTARTS times.
+func watchPlugin(id string, plugin *activePlugin) {
+	<-plugin.supervisor.Exited()
+
+	pluginsSyncLock.Lock()
+	defer pluginsSyncLock.Unlock()
+
+	// A plugin that was deactivated on purpose was stopped by then
+	if getActivePlugin(id) != plugin {
+		return
+	}
+
+	l4g.Error(utils.T("api.plugin.exited.error"), id)
+
+	pluginsLock.Lock()
+	delete(activePlugins, id)
+	pluginsLock.Unlock()
+
+	unregisterPluginCommands(id)
+	plugin.supervisor.Stop()
+
+	if plugin.restarts >= PLUGIN_MAX_RESTARTS {
+		l4g.Error(utils.T("api.plugin.restart_limit.error"), id, PLUGIN_MAX_RESTARTS)
+		return
+	}
+
+	if !pluginsInitialized || !*utils.Cfg.PluginSettings.Enable || !isPluginEnabled(id) {
+		return
+	}
+
+	if bundles, err := findPluginBundles(); err != nil {
+		l4g.Error(err.Error())
+	} else if bundle := bundles[id]; bundle != nil {
+		activatePlugin(bundle, plugin.restarts+1)
+	}
+}
+
+func deactivatePlugin(id string) {
+	pluginsLock.Lock()
+	plugin := activePlugins[id]
+	delete(activePlugins, id)
+	pluginsLock.Unlock()
+
+	unregisterPluginCommands(id)
+
+	if plugin != nil && plugin.supervisor != nil {
+		if err := plugin.supervisor.Hooks().OnDeactivate(); err != nil {
+			l4g.Error(utils.T("api.plugin.deactivate.error"), id, err.Error())
+		}
+
+		plugin.supervisor.Stop()
+	}
+
+	l4g.Info(utils.T("api.plugin.deactivated.info"), id)
+}
+
+func getActivePlugin(id string) *activePlugin {
+	pluginsLock.RLock()
+	defer pluginsLock.RUnlock()
+
+	return activePlugins[id]
+}
+
+func getActivePluginIds() []string {
+	pluginsLock.RLock()
+	defer pluginsLock.RUnlock()
+
+	ids := make([]string, 0, len(activePlugins))
+	for id := range activePlugins {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// getPluginHooks returns the hooks of the active plugins that implement the given hook.
+func getPluginHooks(hook string) []*rpcplugin.RemoteHooks {
+	pluginsLock.RLock()
+	defer pluginsLock.RUnlock()
+
+	hooks := []*rpcplugin.RemoteHooks{}
+	for _, plugin := range activePlugins {
+		if plugin.supervisor != nil && plugin.supervisor.Hooks().Implements(hook) {
+			hooks = append(hooks, plugin.supervisor.Hooks())
+		}
+	}
+
+	return hooks
+}
+
+func GetPlugins() (*model.PluginsResponse, *model.AppError) {
+	if !*utils.Cfg.PluginSettings.Enable {
+		return nil, model.NewAppError("GetPlugins", "api.plugin.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	bundles, err := findPluginBundles()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(bundles))
+	for id := range bundles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	response := &model.PluginsResponse{Active: []*model.Manifest{}, Inactive: []*model.Manifest{}}
+	for _, id := range ids {
+		if getActivePlugin(id) != nil {
+			response.Active = append(response.Active, bundles[id].manifest)
+		} else {
+			response.Inactive = append(response.Inactive, bundles[id].manifest)
+		}
+	}
+
+	return response, nil
+}
+
+// EnablePlugin enables a plugin in the config, which activates it on every server.
+func EnablePlugin(id string) *model.AppError {
+	if err := setPluginState(id, true); err != nil {
+		return err
+	}
+
+	if getActivePlugin(id) == nil {
+		return model.NewAppError("EnablePlugin", "api.plugin.activate.app_error", nil, "id="+id, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// DisablePlugin disables a plugin in the config, which deactivates it on every server.
+func DisablePlugin(id string) *model.AppError {
+	return setPluginState(id, false)
+}
+
+func setPluginState(id string, enable bool) *model.AppError {
+	if !*utils.Cfg.PluginSettings.Enable {
+		return model.NewAppError("setPluginState", "api.plugin.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	bundles, err := findPluginBundles()
+	if err != nil {
+		return err
+	}
+
+	if bundles[id] == nil {
+		return model.NewAppError("setPluginState", "api.plugin.not_found.app_error", nil, "id="+id, http.StatusNotFound)
+	}
+
+	cfg := model.ConfigFromJson(strings.NewReader(utils.Cfg.ToJson()))
+	cfg.PluginSettings.PluginStates[id] = &model.PluginState{Enable: enable}
+
+	return SaveConfig(cfg)
+}
+
+// pluginsMessageWillBePosted runs a post through the MessageWillBePosted hooks of the active plugins, any of which
+// can change it or reject it.
+func pluginsMessageWillBePosted(post *model.Post) (*model.Post, *model.AppError) {
+	for _, hooks := range getPluginHooks("MessageWillBePosted") {
+		var reason string
+		if post, reason = hooks.MessageWillBePosted(post); reason != "" {
+			return nil, model.NewAppError("createPost", "api.post.create_post.rejected_by_plugin.app_error", map[string]interface{}{"Reason": reason}, "", http.StatusBadRequest)
+		}
+	}
+
+	return post, nil
+}
+
+func pluginsMessageHasBeenPosted(post *model.Post) {
+	for _, hooks := range getPluginHooks("MessageHasBeenPosted") {
+		hooks.MessageHasBeenPosted(post)
+	}
+}
+
+func pluginsUserHasJoinedChannel(channelMember *model.ChannelMember) {
+	for _, hooks := range getPluginHooks("UserHasJoinedChannel") {
+		hooks.UserHasJoinedChannel(channelMember)
+	}
+}
+
+func registerPluginCommand(pluginId string, command *model.Command) *model.AppError {
+	trigger := strings.ToLower(command.Trigger)
+	if len(trigger) < model.MIN_TRIGGER_LENGTH || len(trigger) > model.MAX_TRIGGER_LENGTH || strings.HasPrefix(trigger, "/") || strings.Contains(trigger, " ") {
+		return model.NewAppError("registerPluginCommand", "model.command.is_valid.trigger.app_error", nil, "trigger="+command.Trigger, http.StatusBadRequest)
+	}
+
+	if GetCommandProvider(trigger) != nil {
+		return model.NewAppError("registerPluginCommand", "api.plugin.command.duplicate.app_error", map[string]interface{}{"Trigger": trigger}, "", http.StatusBadRequest)
+	}
+
+	cmd := *command
+	cmd.Trigger = trigger
+
+	pluginsLock.Lock()
+	defer pluginsLock.Unlock()
+
+	for i, pc := range pluginCommands {
+		if pc.command.Trigger == trigger && pc.command.TeamId == cmd.TeamId {
+			if pc.pluginId != pluginId {
+				return model.NewAppError("registerPluginCommand", "api.plugin.command.duplicate.app_error", map[string]interface{}{"Trigger": trigger}, "", http.StatusBadRequest)
+			}
+
+			pluginCommands[i] = &pluginCommand{pluginId: pluginId, command: &cmd}
+			return nil
+		}
+	}
+
+	pluginCommands = append(pluginCommands, &pluginCommand{pluginId: pluginId, command: &cmd})
+	return nil
+}
+
+func unregisterPluginCommand(pluginId, teamId, trigger string) {
+	trigger = strings.ToLower(trigger)
+
+	pluginsLock.Lock()
+	defer pluginsLock.Unlock()
+
+	remaining := []*pluginCommand{}
+	for _, pc := range pluginCommands {
+		if pc.pluginId != pluginId || pc.command.TeamId != teamId || pc.command.Trigger != trigger {
+			remaining = append(remaining, pc)
+		}
+	}
+	pluginCommands = remaining
+}
+
+func unregisterPluginCommands(pluginId string) {
+	pluginsLock.Lock()
+	defer pluginsLock.Unlock()
+
+	remaining := []*pluginCommand{}
+	for _, pc := range pluginCommands {
+		if pc.pluginId != pluginId {
+			remaining = append(remaining, pc)
+		}
+	}
+	pluginCommands = remaining
+}
+
+// getPluginCommand returns the plugin command for a trigger in a team, preferring a command registered for that
+// team over one registered for every team.
+func getPluginCommand(teamId, trigger string) *pluginCommand {
+	pluginsLock.RLock()
+	defer pluginsLock.RUnlock()
+
+	var found *pluginCommand
+	for _, pc := range pluginCommands {
+		if pc.command.Trigger == trigger {
+			if pc.command.TeamId == teamId {
+				return pc
+			} else if pc.command.TeamId == "" {
+				found = pc
+			}
+		}
+	}
+
+	return found
+}
+
+func getPluginCommandsForTeam(teamId string) []*model.Command {
+	pluginsLock.RLock()
+	defer pluginsLock.RUnlock()
+
+	commands := []*model.Command{}
+	for _, pc := range pluginCommands {
+		if pc.command.TeamId == "" || pc.command.TeamId == teamId {
+			cmd := *pc.command
+			commands = append(commands, &cmd)
+		}
+	}
+
+	return commands
+}
+
+// executePluginCommand runs a command registered by a plugin. It returns false if no plugin registered the trigger.
+func executePluginCommand(args *model.CommandArgs, trigger string) (*model.CommandResponse, *model.AppError, bool) {
+	pc := getPluginCommand(args.TeamId, trigger)
+	if pc == nil {
+		return nil, nil, false
+	}
+
+	plugin := getActivePlugin(pc.pluginId)
+	if plugin == nil || plugin.supervisor == nil {
+		return nil, nil, false
+	}
+
+	response, err := plugin.supervisor.Hooks().ExecuteCommand(args)
+	if err != nil {
+		return nil, err, true
+	} else if response == nil {
+		return nil, model.NewAppError("command", "api.command.execute_command.failed_empty.app_error", map[string]interface{}{"Trigger": trigger}, "", http.StatusInternalServerError), true
+	}
+
+	response, err = HandleCommandResponse(pc.command, args, response, true)
+	return response, err, true
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"encoding/json"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/plugin"
+	"github.com/primefour/servers/utils"
+)
+
+// PluginAPI is the API given to a plugin, which calls into the app as the plugin.
+type PluginAPI struct {
+	id string
+}
+
+var _ plugin.API = (*PluginAPI)(nil)
+
+func (api *PluginAPI) LoadPluginConfiguration(dest interface{}) error {
+	if b, err := json.Marshal(utils.Cfg.PluginSettings.Plugins[api.id]); err != nil {
+		return err
+	} else {
+		return json.Unmarshal(b, dest)
+	}
+}
+
+func (api *PluginAPI) RegisterCommand(command *model.Command) error {
+	if err := registerPluginCommand(api.id, command); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (api *PluginAPI) UnregisterCommand(teamId, trigger string) error {
+	unregisterPluginCommand(api.id, teamId, trigger)
+	return nil
+}
+
+func (api *PluginAPI) GetTeam(teamId string) (*model.Team, *model.AppError) {
+	return GetTeam(teamId)
+}
+
+func (api *PluginAPI) GetTeamByName(name string) (*model.Team, *model.AppError) {
+	return GetTeamByName(name)
+}
+
+func (api *PluginAPI) GetUser(userId string) (*model.User, *model.AppError) {
+	return GetUser(userId)
+}
+
+func (api *PluginAPI) GetUserByUsername(name string) (*model.User, *model.AppError) {
+	return GetUserByUsername(name)
+}
+
+func (api *PluginAPI) GetChannel(channelId string) (*model.Channel, *model.AppError) {
+	return GetChannel(channelId)
+}
+
+func (api *PluginAPI) GetChannelByName(name, teamId string) (*model.Channel, *model.AppError) {
+	return GetChannelByName(name, teamId)
+}
+
+func (api *PluginAPI) GetChannelMember(channelId, userId string) (*model.ChannelMember, *model.AppError) {
+	return GetChannelMember(channelId, userId)
+}
+
+func (api *PluginAPI) AddChannelMember(channelId, userId string) (*model.ChannelMember, *model.AppError) {
+	channel, err := GetChannel(channelId)
+	if err != nil {
+		return nil, err
+	}
+
+	// The user is added by the plugin rather than by themselves, so it's the server that's the requestor
+	return AddChannelMember(userId, channel, "")
+}
+
+func (api *PluginAPI) GetPost(postId string) (*model.Post, *model.AppError) {
+	return GetSinglePost(postId)
+}
+
+func (api *PluginAPI) CreatePost(post *model.Post) (*model.Post, *model.AppError) {
+	channel, err := GetChannel(post.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreatePost(post, channel.TeamId, true)
+}
+
+func (api *PluginAPI) UpdatePost(post *model.Post) (*model.Post, *model.AppError) {
+	return UpdatePost(post, false)
+}
+
+func (api *PluginAPI) DeletePost(postId string) *model.AppError {
+	_, err := DeletePost(postId)
+	return err
+}
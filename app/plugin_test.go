@@ -0,0 +1,272 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const testPluginSource = `
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/plugin"
+	"github.com/primefour/servers/plugin/rpcplugin"
+)
+
+type configuration struct {
+	Word string
+}
+
+type hooks struct {
+	api    plugin.API
+	config configuration
+}
+
+func (h *hooks) OnActivate(api plugin.API) error {
+	h.api = api
+	if err := api.LoadPluginConfiguration(&h.config); err != nil {
+		return err
+	}
+	return api.RegisterCommand(&model.Command{Trigger: "testplugin", AutoComplete: true})
+}
+
+func (h *hooks) OnConfigurationChange() error {
+	return h.api.LoadPluginConfiguration(&h.config)
+}
+
+func (h *hooks) MessageWillBePosted(post *model.Post) (*model.Post, string) {
+	if post.Message == "crash" {
+		os.Exit(1)
+	}
+	if strings.Contains(post.Message, h.config.Word) {
+		return nil, "no " + h.config.Word
+	}
+	post.Message = post.Message + "!"
+	return post, ""
+}
+
+func main() {
+	rpcplugin.Main(&hooks{})
+}
+`
+
+func setupTestPlugins(t *testing.T) (string, func()) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	dir, err := ioutil.TempDir("", "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := utils.Cfg.PluginSettings
+	enable := true
+	utils.Cfg.PluginSettings = model.PluginSettings{
+		Enable:       &enable,
+		Directory:    &dir,
+		Plugins:      map[string]interface{}{},
+		PluginStates: map[string]*model.PluginState{},
+	}
+
+	InitPlugins()
+
+	return dir, func() {
+		ShutDownPlugins()
+		utils.Cfg.PluginSettings = settings
+		os.RemoveAll(dir)
+	}
+}
+
+func writeTestPlugin(t *testing.T, dir string, manifest *model.Manifest) string {
+	pluginDir := filepath.Join(dir, manifest.Id)
+	if err := os.MkdirAll(pluginDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(pluginDir, model.PLUGIN_MANIFEST_FILENAME), []byte(manifest.ToJson()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return pluginDir
+}
+
+func TestPluginActiveState(t *testing.T) {
+	dir, teardown := setupTestPlugins(t)
+	defer teardown()
+
+	writeTestPlugin(t, dir, &model.Manifest{Id: "webapp"})
+	writeTestPlugin(t, dir, &model.Manifest{Id: "Invalid Id"})
+
+	if response, err := GetPlugins(); err != nil {
+		t.Fatal(err)
+	} else if len(response.Active) != 0 || len(response.Inactive) != 1 || response.Inactive[0].Id != "webapp" {
+		t.Fatal("should have found the valid plugin", response.ToJson())
+	}
+
+	utils.Cfg.PluginSettings.PluginStates["webapp"] = &model.PluginState{Enable: true}
+	SyncPluginsActiveState()
+
+	if response, err := GetPlugins(); err != nil {
+		t.Fatal(err)
+	} else if len(response.Active) != 1 || len(response.Inactive) != 0 {
+		t.Fatal("should have activated the plugin", response.ToJson())
+	}
+
+	*utils.Cfg.PluginSettings.Enable = false
+	SyncPluginsActiveState()
+
+	if getActivePlugin("webapp") != nil {
+		t.Fatal("should have deactivated the plugin when plugins are disabled")
+	}
+
+	if _, err := GetPlugins(); err == nil {
+		t.Fatal("should fail when plugins are disabled")
+	}
+}
+
+func TestPluginHooks(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is needed to build the test plugin")
+	}
+
+	dir, teardown := setupTestPlugins(t)
+	defer teardown()
+
+	pluginDir := writeTestPlugin(t, dir, &model.Manifest{Id: "testplugin", Backend: &model.ManifestBackend{Executable: "plugin.exe"}})
+
+	source := filepath.Join(pluginDir, "main.go")
+	if err := ioutil.WriteFile(source, []byte(testPluginSource), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if output, err := exec.Command("go", "build", "-o", filepath.Join(pluginDir, "plugin.exe"), source).CombinedOutput(); err != nil {
+		t.Fatal("failed to build the test plugin", string(output))
+	}
+
+	utils.Cfg.PluginSettings.Plugins["testplugin"] = map[string]interface{}{"word": "cabbage"}
+	utils.Cfg.PluginSettings.PluginStates["testplugin"] = &model.PluginState{Enable: true}
+	SyncPluginsActiveState()
+
+	if getActivePlugin("testplugin") == nil {
+		t.Fatal("should have activated the plugin")
+	}
+
+	if getPluginCommand(model.NewId(), "testplugin") == nil {
+		t.Fatal("should have registered the command of the plugin")
+	}
+
+	if post, err := pluginsMessageWillBePosted(&model.Post{Message: "hello"}); err != nil {
+		t.Fatal(err)
+	} else if post.Message != "hello!" {
+		t.Fatal("should have changed the post", post.Message)
+	}
+
+	if _, err := pluginsMessageWillBePosted(&model.Post{Message: "cabbage"}); err == nil || err.Id != "api.post.create_post.rejected_by_plugin.app_error" {
+		t.Fatal("should have rejected the post")
+	}
+
+	utils.Cfg.PluginSettings.Plugins["testplugin"] = map[string]interface{}{"word": "carrot"}
+	SyncPluginsActiveState()
+
+	if _, err := pluginsMessageWillBePosted(&model.Post{Message: "carrot"}); err == nil {
+		t.Fatal("should have reloaded the configuration of the plugin")
+	}
+
+	crashed := getActivePlugin("testplugin")
+	if post, err := pluginsMessageWillBePosted(&model.Post{Message: "crash"}); err != nil || post.Message != "crash" {
+		t.Fatal("should have let the post through when the plugin crashed")
+	}
+
+	<-crashed.supervisor.Exited()
+	for i := 0; ; i++ {
+		if plugin := getActivePlugin("testplugin"); plugin != nil && plugin != crashed {
+			if plugin.restarts != 1 {
+				t.Fatal("should have counted the restart")
+			}
+			break
+		} else if i == 100 {
+			t.Fatal("should have started the plugin again after it crashed")
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if getPluginCommand(model.NewId(), "testplugin") == nil {
+		t.Fatal("should have registered the command of the restarted plugin")
+	}
+
+	utils.Cfg.PluginSettings.PluginStates["testplugin"].Enable = false
+	SyncPluginsActiveState()
+
+	if getActivePlugin("testplugin") != nil {
+		t.Fatal("should have deactivated the plugin")
+	}
+
+	if getPluginCommand(model.NewId(), "testplugin") != nil {
+		t.Fatal("should have unregistered the command of the plugin")
+	}
+}
+
+func TestPluginCommands(t *testing.T) {
+	_, teardown := setupTestPlugins(t)
+	defer teardown()
+
+	teamId := model.NewId()
+
+	if err := registerPluginCommand("plugin1", &model.Command{Trigger: "Something", AutoComplete: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := registerPluginCommand("plugin2", &model.Command{Trigger: "something", TeamId: teamId}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := registerPluginCommand("plugin2", &model.Command{Trigger: "something"}); err == nil {
+		t.Fatal("shouldn't register a trigger used by another plugin")
+	}
+
+	if err := registerPluginCommand("plugin2", &model.Command{Trigger: "echo"}); err == nil {
+		t.Fatal("shouldn't register a trigger used by a built in command")
+	}
+
+	if err := registerPluginCommand("plugin2", &model.Command{Trigger: "two words"}); err == nil {
+		t.Fatal("shouldn't register an invalid trigger")
+	}
+
+	if pc := getPluginCommand(teamId, "something"); pc == nil || pc.pluginId != "plugin2" {
+		t.Fatal("should prefer the command registered for the team")
+	}
+
+	if pc := getPluginCommand(model.NewId(), "something"); pc == nil || pc.pluginId != "plugin1" {
+		t.Fatal("should fall back to the command registered for every team")
+	}
+
+	if commands := getPluginCommandsForTeam(model.NewId()); len(commands) != 1 || commands[0].Trigger != "something" {
+		t.Fatal("should list the commands registered for every team")
+	}
+
+	unregisterPluginCommand("plugin2", teamId, "Something")
+	if pc := getPluginCommand(teamId, "something"); pc == nil || pc.pluginId != "plugin1" {
+		t.Fatal("should have unregistered the command")
+	}
+
+	unregisterPluginCommands("plugin1")
+	if getPluginCommand(teamId, "something") != nil {
+		t.Fatal("should have unregistered the commands of the plugin")
+	}
+}
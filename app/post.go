@@ -9,6 +9,8 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	l4g "github.com/alecthomas/log4go"
@@ -116,7 +118,13 @@ func CreatePost(post *model.Post, teamId string, triggerWebhooks bool) (*model.P
 		}
 	}
 
+	post, err := pluginsMessageWillBePosted(post)
+	if err != nil {
+		return nil, err
+	}
+
 	post.Hashtags, _ = model.ParseHashtags(post.Message)
+	post.GenerateActionIds()
 
 	var rpost *model.Post
 	if result := <-Srv.Store.Post().Save(post); result.Err != nil {
@@ -125,6 +133,8 @@ func CreatePost(post *model.Post, teamId string, triggerWebhooks bool) (*model.P
 		rpost = result.Data.(*model.Post)
 	}
 
+	indexPost(rpost, teamId)
+
 	if einterfaces.GetMetricsInterface() != nil {
 		einterfaces.GetMetricsInterface().IncrementPostCreate()
 	}
@@ -148,6 +158,8 @@ func CreatePost(post *model.Post, teamId string, triggerWebhooks bool) (*model.P
 		return nil, err
 	}
 
+	go pluginsMessageHasBeenPosted(rpost)
+
 	return rpost, nil
 }
 
@@ -239,8 +251,11 @@ func SendEphemeralPost(teamId, userId string, post *model.Post) *model.Post {
 		post.Props = model.StringInterface{}
 	}
 
+	clientPost := *post
+	clientPost.StripActionIntegrations()
+
 	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_EPHEMERAL_MESSAGE, "", post.ChannelId, userId, nil)
-	message.Add("post", post.ToJson())
+	message.Add("post", clientPost.ToJson())
 
 	go Publish(message)
 
@@ -281,6 +296,10 @@ func UpdatePost(post *model.Post, safeUpdate bool) (*model.Post, *model.AppError
 			return nil, err
 		}
 
+		if err := checkChannelIsNotArchived("UpdatePost", oldPost.ChannelId); err != nil {
+			return nil, err
+		}
+
 		if utils.IsLicensed {
 			if *utils.Cfg.ServiceSettings.AllowEditPost == model.ALLOW_EDIT_POST_TIME_LIMIT && model.GetMillis() > oldPost.CreateAt+int64(*utils.Cfg.ServiceSettings.PostEditTimeLimit*1000) {
 				err := model.NewAppError("UpdatePost", "api.post.update_post.permissions_time_limit.app_error", map[string]interface{}{"timeLimit": *utils.Cfg.ServiceSettings.PostEditTimeLimit}, "", http.StatusBadRequest)
@@ -301,13 +320,21 @@ func UpdatePost(post *model.Post, safeUpdate bool) (*model.Post, *model.AppError
 		newPost.HasReactions = post.HasReactions
 		newPost.FileIds = post.FileIds
 		newPost.Props = post.Props
+		newPost.CopyActionIntegrations(oldPost)
+		newPost.GenerateActionIds()
 	}
 
+	return saveUpdatedPost(newPost, oldPost)
+}
+
+func saveUpdatedPost(newPost *model.Post, oldPost *model.Post) (*model.Post, *model.AppError) {
 	if result := <-Srv.Store.Post().Update(newPost, oldPost); result.Err != nil {
 		return nil, result.Err
 	} else {
 		rpost := result.Data.(*model.Post)
 
+		indexPost(rpost, "")
+
 		sendUpdatedPostEvent(rpost)
 
 		InvalidateCacheForChannelPosts(rpost.ChannelId)
@@ -316,6 +343,33 @@ func UpdatePost(post *model.Post, safeUpdate bool) (*model.Post, *model.AppError
 	}
 }
 
+// updatePostFromAction replaces a post with the update that its integration sent in reply to an action. It isn't an
+// edit by a user, so the edit settings and time limit don't apply to it.
+func updatePostFromAction(oldPost *model.Post, update *model.Post) (*model.Post, *model.AppError) {
+	if oldPost.DeleteAt != 0 {
+		return nil, model.NewAppError("updatePostFromAction", "api.post.update_post.permissions_details.app_error", map[string]interface{}{"PostId": oldPost.Id}, "", http.StatusBadRequest)
+	}
+
+	newPost := &model.Post{}
+	*newPost = *oldPost
+
+	newPost.Message = update.Message
+	newPost.EditAt = model.GetMillis()
+	newPost.Hashtags, _ = model.ParseHashtags(update.Message)
+	newPost.Props = update.Props
+
+	if attachments := newPost.Attachments(); attachments != nil {
+		parseSlackAttachment(newPost, attachments)
+		newPost.Type = oldPost.Type
+	}
+	newPost.AddProp("from_webhook", "true")
+
+	newPost.CopyActionIntegrations(oldPost)
+	newPost.GenerateActionIds()
+
+	return saveUpdatedPost(newPost, oldPost)
+}
+
 func PatchPost(postId string, patch *model.PostPatch) (*model.Post, *model.AppError) {
 	post, err := GetSinglePost(postId)
 	if err != nil {
@@ -336,8 +390,11 @@ func PatchPost(postId string, patch *model.PostPatch) (*model.Post, *model.AppEr
 }
 
 func sendUpdatedPostEvent(post *model.Post) {
+	clientPost := *post
+	clientPost.StripActionIntegrations()
+
 	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POST_EDITED, "", post.ChannelId, "", nil)
-	message.Add("post", post.ToJson())
+	message.Add("post", clientPost.ToJson())
 
 	go Publish(message)
 }
@@ -477,12 +534,16 @@ func DeletePost(postId string) (*model.Post, *model.AppError) {
 			return nil, result.Err
 		}
 
+		clientPost := *post
+		clientPost.StripActionIntegrations()
+
 		message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POST_DELETED, "", post.ChannelId, "", nil)
-		message.Add("post", post.ToJson())
+		message.Add("post", clientPost.ToJson())
 
 		go Publish(message)
 		go DeletePostFiles(post)
 		go DeleteFlaggedPosts(post.Id)
+		deletePostsFromIndex([]string{post.Id})
 
 		InvalidateCacheForChannelPosts(post.ChannelId)
 
@@ -509,10 +570,18 @@ func DeletePostFiles(post *model.Post) {
 
 func SearchPostsInTeam(terms string, userId string, teamId string, isOrSearch bool) (*model.PostList, *model.AppError) {
 	paramsList := model.ParseSearchParams(terms)
+	for _, params := range paramsList {
+		params.OrTerms = isOrSearch
+		params.InChannels = getSearchChannelNames(userId, params.InChannels)
+	}
+
+	if searchEngine := einterfaces.GetSearchEngineInterface(); searchEngine != nil && searchEngine.IsSearchEnabled() {
+		return searchPostsInTeamWithSearchEngine(searchEngine, paramsList, userId, teamId)
+	}
+
 	channels := []store.StoreChannel{}
 
 	for _, params := range paramsList {
-		params.OrTerms = isOrSearch
 		// don't allow users to search for everything
 		if params.Terms != "*" {
 			channels = append(channels, Srv.Store.Post().Search(teamId, userId, params))
@@ -532,6 +601,24 @@ func SearchPostsInTeam(terms string, userId string, teamId string, isOrSearch bo
 	return posts, nil
 }
 
+// getSearchChannelNames replaces the @usernames in the channels that a search is limited to with the names of the
+// user's direct channels with those users.
+func getSearchChannelNames(userId string, inChannels []string) []string {
+	names := make([]string, 0, len(inChannels))
+
+	for _, name := range inChannels {
+		if strings.HasPrefix(name, "@") {
+			if result := <-Srv.Store.User().GetByUsername(strings.TrimPrefix(name, "@")); result.Err == nil {
+				name = model.GetDMNameFromIds(userId, result.Data.(*model.User).Id)
+			}
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
 func GetFileInfosForPost(postId string, readFromMaster bool) ([]*model.FileInfo, *model.AppError) {
 	pchan := Srv.Store.Post().GetSingle(postId)
 	fchan := Srv.Store.FileInfo().GetForPost(postId, readFromMaster, true)
@@ -578,3 +665,105 @@ func GetOpenGraphMetadata(url string) *opengraph.OpenGraph {
 
 	return og
 }
+
+// DoPostAction sends the context of an action on a post to its integration when a user clicks a button or picks an
+// option from a select menu. The integration can reply with an update for the post and with text that's shown only to
+// the user who did the action.
+func DoPostAction(postId string, actionId string, userId string, selectedOption string) *model.AppError {
+	var post *model.Post
+	if result := <-Srv.Store.Post().GetSingle(postId); result.Err != nil {
+		return result.Err
+	} else {
+		post = result.Data.(*model.Post)
+	}
+
+	action := post.GetAction(actionId)
+	if action == nil || action.Integration == nil || action.Integration.URL == "" {
+		return model.NewAppError("DoPostAction", "api.post.do_action.action_id.app_error", nil, "action_id="+actionId, http.StatusNotFound)
+	}
+
+	var channel *model.Channel
+	if result := <-Srv.Store.Channel().Get(post.ChannelId, true); result.Err != nil {
+		return result.Err
+	} else {
+		channel = result.Data.(*model.Channel)
+	}
+
+	if channel.DeleteAt != 0 {
+		return model.NewAppError("DoPostAction", "app.channel.archived.app_error", nil, "channel_id="+channel.Id, http.StatusBadRequest)
+	}
+
+	request := &model.PostActionIntegrationRequest{
+		UserId:     userId,
+		ChannelId:  post.ChannelId,
+		TeamId:     channel.TeamId,
+		PostId:     postId,
+		Type:       action.Type,
+		DataSource: action.DataSource,
+		Context:    action.Integration.Context,
+	}
+
+	if _, triggerId, err := GenerateTriggerId(userId); err != nil {
+		return err
+	} else {
+		request.TriggerId = triggerId
+	}
+
+	if action.Type == model.POST_ACTION_TYPE_SELECT {
+		if !action.IsValidOption(selectedOption) {
+			return model.NewAppError("DoPostAction", "api.post.do_action.selected_option.app_error", nil, "action_id="+actionId, http.StatusBadRequest)
+		}
+
+		request.Context = make(map[string]interface{}, len(action.Integration.Context)+1)
+		for key, value := range action.Integration.Context {
+			request.Context[key] = value
+		}
+		request.Context["selected_option"] = selectedOption
+	}
+
+	req, err := http.NewRequest("POST", action.Integration.URL, strings.NewReader(request.ToJson()))
+	if err != nil {
+		return model.NewAppError("DoPostAction", "api.post.do_action.action_integration.app_error", nil, "err="+err.Error(), http.StatusBadRequest)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := utils.NewUntrustedHTTPClient().Do(req)
+	if err != nil {
+		return model.NewAppError("DoPostAction", "api.post.do_action.action_integration.app_error", nil, "err="+err.Error(), http.StatusBadRequest)
+	}
+	defer CloseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return model.NewAppError("DoPostAction", "api.post.do_action.action_integration.app_error", nil, "status="+strconv.Itoa(resp.StatusCode), http.StatusBadRequest)
+	}
+
+	response := model.PostActionIntegrationResponseFromJson(resp.Body)
+	if response == nil {
+		return model.NewAppError("DoPostAction", "api.post.do_action.action_integration.app_error", nil, "err=invalid response", http.StatusBadRequest)
+	}
+
+	if response.Update != nil {
+		if _, err := updatePostFromAction(post, response.Update); err != nil {
+			return err
+		}
+	}
+
+	if response.EphemeralText != "" {
+		ephemeralPost := &model.Post{
+			Message:   parseSlackLinksToMarkdown(response.EphemeralText),
+			ChannelId: post.ChannelId,
+			RootId:    post.RootId,
+			UserId:    userId,
+		}
+
+		if ephemeralPost.RootId == "" {
+			ephemeralPost.RootId = post.Id
+		}
+
+		ephemeralPost.AddProp("from_webhook", "true")
+		SendEphemeralPost(channel.TeamId, userId, ephemeralPost)
+	}
+
+	return nil
+}
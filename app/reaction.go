@@ -13,6 +13,10 @@ func SaveReactionForPost(reaction *model.Reaction) (*model.Reaction, *model.AppE
 		return nil, err
 	}
 
+	if err := checkChannelIsNotArchived("SaveReactionForPost", post.ChannelId); err != nil {
+		return nil, err
+	}
+
 	if result := <-Srv.Store.Reaction().Save(reaction); result.Err != nil {
 		return nil, result.Err
 	} else {
@@ -40,6 +44,10 @@ func DeleteReactionForPost(reaction *model.Reaction) *model.AppError {
 		return err
 	}
 
+	if err := checkChannelIsNotArchived("DeleteReactionForPost", post.ChannelId); err != nil {
+		return err
+	}
+
 	if result := <-Srv.Store.Reaction().Delete(reaction); result.Err != nil {
 		return result.Err
 	} else {
@@ -59,9 +67,12 @@ func sendReactionEvent(event string, reaction *model.Reaction, post *model.Post)
 
 	// The post is always modified since the UpdateAt always changes
 	InvalidateCacheForChannelPosts(post.ChannelId)
-	post.HasReactions = true
-	post.UpdateAt = model.GetMillis()
+	clientPost := *post
+	clientPost.HasReactions = true
+	clientPost.UpdateAt = model.GetMillis()
+	clientPost.StripActionIntegrations()
+
 	umessage := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POST_EDITED, "", post.ChannelId, "", nil)
-	umessage.Add("post", post.ToJson())
+	umessage.Add("post", clientPost.ToJson())
 	Publish(umessage)
 }
@@ -0,0 +1,311 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/primefour/servers/einterfaces"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	OLD_CHANNEL_GUEST_ROLE_ID        = "guest"
+	CHANNEL_GUEST_ROLE_MIGRATION_KEY = "channel_guest_role_migration"
+)
+
+// roleCache holds the roles that are saved in the database by id. Roles that aren't saved are cached as nil so that
+// checking a built-in role that was never changed doesn't hit the database.
+var roleCache *utils.Cache = utils.NewLru(model.ROLE_CACHE_SIZE)
+
+// teamSchemeCache holds the scheme that each team uses by team id, or nil if the team uses the built-in roles.
+var teamSchemeCache *utils.Cache = utils.NewLru(model.TEAM_SCHEME_CACHE_SIZE)
+
+func InvalidateCacheForRoles() {
+	InvalidateCacheForRolesSkipClusterSend()
+
+	if cluster := einterfaces.GetClusterInterface(); cluster != nil {
+		cluster.InvalidateCacheForRoles()
+	}
+}
+
+func InvalidateCacheForRolesSkipClusterSend() {
+	roleCache.Purge()
+	teamSchemeCache.Purge()
+}
+
+// getSavedRole returns the role that's saved in the database with the given id, or nil if there isn't one.
+func getSavedRole(roleId string) (*model.Role, *model.AppError) {
+	if cacheItem, ok := roleCache.Get(roleId); ok {
+		return cacheItem.(*model.Role), nil
+	}
+
+	var role *model.Role
+	if result := <-Srv.Store.Role().Get(roleId); result.Err != nil {
+		if result.Err.StatusCode != http.StatusNotFound {
+			return nil, result.Err
+		}
+	} else {
+		role = result.Data.(*model.Role)
+	}
+
+	roleCache.AddWithExpiresInSecs(roleId, role, model.ROLE_CACHE_SEC)
+
+	return role, nil
+}
+
+// GetRole returns the role with the given id. A role that's saved in the database takes the place of the built-in role
+// with the same id, whose permissions otherwise depend on the config.
+func GetRole(roleId string) (*model.Role, *model.AppError) {
+	role, err := getSavedRole(roleId)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != nil {
+		return role, nil
+	}
+
+	if role, ok := model.BuiltInRoles[roleId]; ok {
+		return role, nil
+	}
+
+	return nil, model.NewAppError("GetRole", "app.role.get.missing.app_error", nil, "id="+roleId, http.StatusNotFound)
+}
+
+// GetAllRoles returns the built-in roles, with any changes that were saved to them, followed by the roles that are
+// managed by schemes.
+func GetAllRoles() ([]*model.Role, *model.AppError) {
+	var savedRoles []*model.Role
+	if result := <-Srv.Store.Role().GetAll(); result.Err != nil {
+		return nil, result.Err
+	} else {
+		savedRoles = result.Data.([]*model.Role)
+	}
+
+	rolesById := make(map[string]*model.Role)
+	for id, role := range model.BuiltInRoles {
+		rolesById[id] = role
+	}
+
+	for _, role := range savedRoles {
+		rolesById[role.Id] = role
+	}
+
+	roles := make([]*model.Role, 0, len(rolesById))
+	for _, role := range rolesById {
+		roles = append(roles, role)
+	}
+
+	sort.Slice(roles, func(i, j int) bool {
+		if roles[i].SchemeManaged != roles[j].SchemeManaged {
+			return !roles[i].SchemeManaged
+		}
+
+		return roles[i].Id < roles[j].Id
+	})
+
+	return roles, nil
+}
+
+// PatchRole changes the permissions of a role. Changing a built-in role saves a copy of it to the database, which is
+// used from then on instead of the permissions that are set by the config.
+func PatchRole(roleId string, patch *model.RolePatch) (*model.Role, *model.AppError) {
+	role, err := GetRole(roleId)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := getSavedRole(roleId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Roles may be shared through the cache, so the patch is applied to a copy
+	patched := *role
+	patched.Permissions = append(model.StringArray{}, role.Permissions...)
+	patched.Patch(patch)
+
+	// Don't let the system admins lock themselves out of the system console
+	if patched.Id == model.ROLE_SYSTEM_ADMIN.Id && !patched.HasPermission(model.PERMISSION_MANAGE_SYSTEM.Id) {
+		return nil, model.NewAppError("PatchRole", "app.role.patch.system_admin.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if saved == nil {
+		if result := <-Srv.Store.Role().Save(&patched); result.Err != nil {
+			return nil, result.Err
+		}
+	} else {
+		if result := <-Srv.Store.Role().Update(&patched); result.Err != nil {
+			return nil, result.Err
+		}
+	}
+
+	InvalidateCacheForRoles()
+
+	return &patched, nil
+}
+
+// ResetRole removes the changes that were saved to a built-in role so that its permissions are set by the config
+// again.
+func ResetRole(roleId string) (*model.Role, *model.AppError) {
+	role, ok := model.BuiltInRoles[roleId]
+	if !ok {
+		return nil, model.NewAppError("ResetRole", "app.role.reset.not_built_in.app_error", nil, "id="+roleId, http.StatusBadRequest)
+	}
+
+	if result := <-Srv.Store.Role().PermanentDelete(roleId); result.Err != nil {
+		return nil, result.Err
+	}
+
+	InvalidateCacheForRoles()
+
+	return role, nil
+}
+
+// rolePermissionMigration gives permissions to the roles that are saved in the database in the place of built-in roles,
+// as well as to the roles that schemes copied from them. A saved role keeps the permissions it was saved with, so a
+// permission that's later added to a built-in role only reaches the saved roles through a migration. Each migration is
+// run once and is recorded in the Systems table under its key.
+type rolePermissionMigration struct {
+	key         string
+	roleIds     []string
+	permissions []string
+}
+
+// rolePermissionMigrations lists the permissions that were added to the built-in roles since roles could be saved,
+// oldest first.
+var rolePermissionMigrations = []*rolePermissionMigration{
+	{
+		key:         "view_members_permission_migration",
+		roleIds:     []string{model.ROLE_SYSTEM_USER.Id, model.ROLE_SYSTEM_ADMIN.Id},
+		permissions: []string{model.PERMISSION_VIEW_MEMBERS.Id},
+	},
+}
+
+// MigrateRolePermissions runs the role permission migrations that haven't been run yet.
+func MigrateRolePermissions() *model.AppError {
+	for _, migration := range rolePermissionMigrations {
+		if err := runRolePermissionMigration(migration); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runRolePermissionMigration(migration *rolePermissionMigration) *model.AppError {
+	if result := <-Srv.Store.System().GetByName(migration.key); result.Err == nil {
+		return nil
+	}
+
+	roleIds, err := getSavedCopiesOfRoles(migration.roleIds)
+	if err != nil {
+		return err
+	}
+
+	for _, roleId := range roleIds {
+		var role *model.Role
+		if result := <-Srv.Store.Role().Get(roleId); result.Err != nil {
+			if result.Err.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return result.Err
+		} else {
+			role = result.Data.(*model.Role)
+		}
+
+		changed := false
+		for _, permissionId := range migration.permissions {
+			if !role.HasPermission(permissionId) {
+				role.Permissions = append(role.Permissions, permissionId)
+				changed = true
+			}
+		}
+
+		if changed {
+			if result := <-Srv.Store.Role().Update(role); result.Err != nil {
+				return result.Err
+			}
+		}
+	}
+
+	// Running a migration twice does no harm, so it doesn't matter if another server records it first
+	if result := <-Srv.Store.System().SaveOrUpdate(&model.System{Name: migration.key, Value: "true"}); result.Err != nil {
+		return result.Err
+	}
+
+	InvalidateCacheForRoles()
+
+	return nil
+}
+
+// MigrateChannelGuestRole gives the channel members that have the guest channel role by its old id of "guest" its new
+// id. It's run once and recorded in the Systems table.
+func MigrateChannelGuestRole() *model.AppError {
+	if result := <-Srv.Store.System().GetByName(CHANNEL_GUEST_ROLE_MIGRATION_KEY); result.Err == nil {
+		return nil
+	}
+
+	if result := <-Srv.Store.Channel().RenameMemberRole(OLD_CHANNEL_GUEST_ROLE_ID, model.ROLE_CHANNEL_GUEST.Id); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-Srv.Store.System().SaveOrUpdate(&model.System{Name: CHANNEL_GUEST_ROLE_MIGRATION_KEY, Value: "true"}); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// getSavedCopiesOfRoles returns the ids of the roles that may be saved in the place of the given built-in roles,
+// which are the built-in roles themselves and the roles of every scheme that take their place.
+func getSavedCopiesOfRoles(builtInRoleIds []string) ([]string, *model.AppError) {
+	roleIds := append([]string{}, builtInRoleIds...)
+
+	for page := 0; ; page++ {
+		var schemes []*model.Scheme
+		if result := <-Srv.Store.Scheme().GetAllPage(page*100, 100); result.Err != nil {
+			return nil, result.Err
+		} else {
+			schemes = result.Data.([]*model.Scheme)
+		}
+
+		for _, scheme := range schemes {
+			for _, roleId := range builtInRoleIds {
+				if schemeRoleId := scheme.RoleFor(roleId); schemeRoleId != roleId {
+					roleIds = append(roleIds, schemeRoleId)
+				}
+			}
+		}
+
+		if len(schemes) < 100 {
+			return roleIds, nil
+		}
+	}
+}
+
+// getTeamScheme returns the scheme that a team uses, or nil if the team uses the built-in roles.
+func getTeamScheme(teamId string) (*model.Scheme, *model.AppError) {
+	if cacheItem, ok := teamSchemeCache.Get(teamId); ok {
+		return cacheItem.(*model.Scheme), nil
+	}
+
+	var scheme *model.Scheme
+	if result := <-Srv.Store.Team().Get(teamId); result.Err != nil {
+		return nil, result.Err
+	} else if schemeId := result.Data.(*model.Team).SchemeId; schemeId != "" {
+		if result := <-Srv.Store.Scheme().Get(schemeId); result.Err != nil {
+			return nil, result.Err
+		} else {
+			scheme = result.Data.(*model.Scheme)
+		}
+	}
+
+	teamSchemeCache.AddWithExpiresInSecs(teamId, scheme, model.TEAM_SCHEME_CACHE_SEC)
+
+	return scheme, nil
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/primefour/servers/model"
+)
+
+func TestMigrateRolePermissions(t *testing.T) {
+	Setup()
+
+	migrations := rolePermissionMigrations
+	defer func() {
+		rolePermissionMigrations = migrations
+	}()
+
+	migration := &rolePermissionMigration{
+		key:         "test_migration_" + model.NewId(),
+		roleIds:     []string{model.ROLE_CHANNEL_USER.Id},
+		permissions: []string{model.PERMISSION_MANAGE_PUBLIC_CHANNEL_MEMBERS.Id},
+	}
+	rolePermissionMigrations = []*rolePermissionMigration{migration}
+
+	defer ResetRole(model.ROLE_CHANNEL_USER.Id)
+	if _, err := PatchRole(model.ROLE_CHANNEL_USER.Id, &model.RolePatch{Permissions: &[]string{model.PERMISSION_CREATE_POST.Id}}); err != nil {
+		t.Fatal(err)
+	}
+
+	scheme, err := CreateScheme(&model.Scheme{Name: "scheme" + model.NewId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DeleteScheme(scheme.Id)
+
+	teamUserRole, err := GetRole(scheme.DefaultTeamUserRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MigrateRolePermissions(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, roleId := range []string{model.ROLE_CHANNEL_USER.Id, scheme.DefaultChannelUserRole} {
+		if role, err := GetRole(roleId); err != nil {
+			t.Fatal(err)
+		} else if !role.HasPermission(model.PERMISSION_MANAGE_PUBLIC_CHANNEL_MEMBERS.Id) || !role.HasPermission(model.PERMISSION_CREATE_POST.Id) {
+			t.Fatal("should have added the permission to the saved role", roleId, role.Permissions)
+		}
+	}
+
+	if role, err := GetRole(scheme.DefaultTeamUserRole); err != nil {
+		t.Fatal(err)
+	} else if len(role.Permissions) != len(teamUserRole.Permissions) {
+		t.Fatal("shouldn't have changed other roles")
+	}
+
+	if _, err := PatchRole(model.ROLE_CHANNEL_USER.Id, &model.RolePatch{Permissions: &[]string{model.PERMISSION_CREATE_POST.Id}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MigrateRolePermissions(); err != nil {
+		t.Fatal(err)
+	}
+
+	if role, err := GetRole(model.ROLE_CHANNEL_USER.Id); err != nil {
+		t.Fatal(err)
+	} else if role.HasPermission(model.PERMISSION_MANAGE_PUBLIC_CHANNEL_MEMBERS.Id) {
+		t.Fatal("should only run a migration once")
+	}
+}
@@ -4,11 +4,14 @@
 package app
 
 import (
+	b64 "encoding/base64"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"os"
+	"strings"
 
+	l4g "github.com/alecthomas/log4go"
 	"github.com/primefour/servers/einterfaces"
 	"github.com/primefour/servers/model"
 	"github.com/primefour/servers/utils"
@@ -29,6 +32,72 @@ func GetSamlMetadata() (string, *model.AppError) {
 	}
 }
 
+// GetSamlLoginUrl returns the identity provider URL that a user is sent to in order to sign in. The props are passed
+// through the identity provider as the relay state and handed back to CompleteSaml.
+func GetSamlLoginUrl(props map[string]string) (string, *model.AppError) {
+	samlInterface := einterfaces.GetSamlInterface()
+	if samlInterface == nil || !*utils.Cfg.SamlSettings.Enable {
+		return "", model.NewAppError("GetSamlLoginUrl", "api.user.saml.not_available.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	relayState := b64.StdEncoding.EncodeToString([]byte(model.MapToJson(props)))
+
+	if request, err := samlInterface.BuildRequest(relayState); err != nil {
+		return "", err
+	} else {
+		return request.URL, nil
+	}
+}
+
+// CompleteSaml logs in the user identified by a Response from the identity provider and returns it along with the
+// props that were passed to GetSamlLoginUrl.
+func CompleteSaml(encodedXML string, relayState string) (*model.User, map[string]string, *model.AppError) {
+	samlInterface := einterfaces.GetSamlInterface()
+	if samlInterface == nil || !*utils.Cfg.SamlSettings.Enable {
+		return nil, nil, model.NewAppError("CompleteSaml", "api.user.saml.not_available.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	if len(encodedXML) == 0 {
+		return nil, nil, model.NewAppError("CompleteSaml", "api.user.saml.missing_response.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	props := map[string]string{}
+	if len(relayState) > 0 {
+		if data, err := b64.StdEncoding.DecodeString(relayState); err != nil {
+			return nil, nil, model.NewAppError("CompleteSaml", "api.user.saml.invalid_relay_state.app_error", nil, err.Error(), http.StatusBadRequest)
+		} else {
+			props = model.MapFromJson(strings.NewReader(string(data)))
+		}
+	}
+
+	user, err := samlInterface.DoLogin(encodedXML, props)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkUserNotDisabled(user); err != nil {
+		return nil, nil, err
+	}
+
+	if props["action"] == model.OAUTH_ACTION_EMAIL_TO_SSO {
+		if err := RevokeAllSessions(user.Id); err != nil {
+			return nil, nil, err
+		}
+
+		go func() {
+			if err := SendSignInChangeEmail(user.Email, strings.Title(model.USER_AUTH_SERVICE_SAML)+" SSO", user.Locale, utils.GetSiteURL()); err != nil {
+				l4g.Error(err.Error())
+			}
+		}()
+	} else if teamId := props["team_id"]; len(teamId) > 0 {
+		if err := AddUserToTeamByTeamId(teamId, user); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return user, props, nil
+}
+
 func WriteSamlFile(fileData *multipart.FileHeader) *model.AppError {
 	filename := filepath.Base(fileData.Filename)
 
@@ -0,0 +1,165 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	l4g "github.com/alecthomas/log4go"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+// CreateScheme creates a scheme along with its team and channel roles, which start out with the permissions that the
+// built-in roles have at the time.
+func CreateScheme(scheme *model.Scheme) (*model.Scheme, *model.AppError) {
+	var roles []*model.Role
+	for _, builtInRoleId := range []string{model.ROLE_TEAM_ADMIN.Id, model.ROLE_TEAM_USER.Id, model.ROLE_CHANNEL_ADMIN.Id, model.ROLE_CHANNEL_USER.Id} {
+		builtInRole, err := GetRole(builtInRoleId)
+		if err != nil {
+			deleteSchemeRoles(roles)
+			return nil, err
+		}
+
+		role := &model.Role{
+			Id:            model.NewId(),
+			Name:          builtInRole.Name,
+			Description:   builtInRole.Description,
+			Permissions:   append(model.StringArray{}, builtInRole.Permissions...),
+			SchemeManaged: true,
+		}
+
+		if result := <-Srv.Store.Role().Save(role); result.Err != nil {
+			deleteSchemeRoles(roles)
+			return nil, result.Err
+		}
+
+		roles = append(roles, role)
+	}
+
+	scheme.DefaultTeamAdminRole = roles[0].Id
+	scheme.DefaultTeamUserRole = roles[1].Id
+	scheme.DefaultChannelAdminRole = roles[2].Id
+	scheme.DefaultChannelUserRole = roles[3].Id
+
+	if result := <-Srv.Store.Scheme().Save(scheme); result.Err != nil {
+		deleteSchemeRoles(roles)
+		return nil, result.Err
+	}
+
+	return scheme, nil
+}
+
+func deleteSchemeRoles(roles []*model.Role) {
+	for _, role := range roles {
+		if result := <-Srv.Store.Role().PermanentDelete(role.Id); result.Err != nil {
+			l4g.Error(utils.T("app.scheme.delete_roles.error"), role.Id, result.Err.Error())
+		}
+	}
+}
+
+func GetScheme(schemeId string) (*model.Scheme, *model.AppError) {
+	if result := <-Srv.Store.Scheme().Get(schemeId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.Scheme), nil
+	}
+}
+
+func GetSchemesPage(page int, perPage int) ([]*model.Scheme, *model.AppError) {
+	if result := <-Srv.Store.Scheme().GetAllPage(page*perPage, perPage); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.Scheme), nil
+	}
+}
+
+func PatchScheme(schemeId string, patch *model.SchemePatch) (*model.Scheme, *model.AppError) {
+	scheme, err := GetScheme(schemeId)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Patch(patch)
+
+	if result := <-Srv.Store.Scheme().Update(scheme); result.Err != nil {
+		return nil, result.Err
+	}
+
+	return scheme, nil
+}
+
+// DeleteScheme deletes a scheme and its roles. The teams that used it go back to the built-in roles.
+func DeleteScheme(schemeId string) (*model.Scheme, *model.AppError) {
+	scheme, err := GetScheme(schemeId)
+	if err != nil {
+		return nil, err
+	}
+
+	if result := <-Srv.Store.Scheme().Delete(schemeId, model.GetMillis()); result.Err != nil {
+		return nil, result.Err
+	}
+
+	InvalidateCacheForRoles()
+
+	deleteSchemeRoles([]*model.Role{
+		{Id: scheme.DefaultTeamAdminRole},
+		{Id: scheme.DefaultTeamUserRole},
+		{Id: scheme.DefaultChannelAdminRole},
+		{Id: scheme.DefaultChannelUserRole},
+	})
+
+	return scheme, nil
+}
+
+// SetTeamScheme sets the scheme whose roles take the place of the built-in team and channel roles on a team. An empty
+// schemeId moves the team back to the built-in roles.
+func SetTeamScheme(teamId string, schemeId string) (*model.Team, *model.AppError) {
+	team, err := GetTeam(teamId)
+	if err != nil {
+		return nil, err
+	}
+
+	if schemeId != "" {
+		if _, err := GetScheme(schemeId); err != nil {
+			return nil, err
+		}
+	}
+
+	if result := <-Srv.Store.Team().UpdateSchemeId(teamId, schemeId); result.Err != nil {
+		return nil, result.Err
+	}
+
+	InvalidateCacheForRoles()
+
+	team.SchemeId = schemeId
+	team.Sanitize()
+
+	sendUpdatedTeamEvent(team)
+
+	return team, nil
+}
+
+// applyTeamScheme replaces the built-in team and channel roles with the roles of the team's scheme, if it has one.
+func applyTeamScheme(teamId string, roles []string) []string {
+	if teamId == "" {
+		return roles
+	}
+
+	scheme, err := getTeamScheme(teamId)
+	if err != nil {
+		l4g.Error(utils.T("app.scheme.get_team_scheme.error"), teamId, err.Error())
+		return roles
+	}
+
+	if scheme == nil {
+		return roles
+	}
+
+	schemeRoles := make([]string, len(roles))
+	for i, roleId := range roles {
+		schemeRoles[i] = scheme.RoleFor(roleId)
+	}
+
+	return schemeRoles
+}
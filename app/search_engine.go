@@ -0,0 +1,228 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/einterfaces"
+	"github.com/primefour/servers/jobs"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	SEARCH_ENGINE_RESULT_LIMIT = 100
+)
+
+func StartSearchEngine() {
+	if searchEngine := einterfaces.GetSearchEngineInterface(); searchEngine != nil {
+		if err := searchEngine.Start(); err != nil {
+			l4g.Error(utils.T("app.search_engine.start.error"), err.Error())
+		}
+	}
+}
+
+func StopSearchEngine() {
+	if searchEngine := einterfaces.GetSearchEngineInterface(); searchEngine != nil {
+		if err := searchEngine.Stop(); err != nil {
+			l4g.Error(utils.T("app.search_engine.stop.error"), err.Error())
+		}
+	}
+}
+
+func getIndexingSearchEngine() einterfaces.SearchEngineInterface {
+	if searchEngine := einterfaces.GetSearchEngineInterface(); searchEngine != nil && searchEngine.IsIndexingEnabled() {
+		return searchEngine
+	}
+
+	return nil
+}
+
+// indexPost adds a post to the search index in the background. If teamId is empty, it's looked up from the post's
+// channel.
+func indexPost(post *model.Post, teamId string) {
+	searchEngine := getIndexingSearchEngine()
+	if searchEngine == nil {
+		return
+	}
+
+	go func() {
+		if teamId == "" {
+			if result := <-Srv.Store.Channel().Get(post.ChannelId, true); result.Err != nil {
+				l4g.Error(utils.T("app.search_engine.index_post.error"), post.Id, result.Err.Error())
+				return
+			} else {
+				teamId = result.Data.(*model.Channel).TeamId
+			}
+		}
+
+		if err := searchEngine.IndexPost(post, teamId); err != nil {
+			l4g.Error(utils.T("app.search_engine.index_post.error"), post.Id, err.Error())
+		}
+	}()
+}
+
+// deletePostsFromIndex removes posts from the search index in the background.
+func deletePostsFromIndex(postIds []string) {
+	searchEngine := getIndexingSearchEngine()
+	if searchEngine == nil {
+		return
+	}
+
+	go func() {
+		for _, postId := range postIds {
+			if err := searchEngine.DeletePost(postId); err != nil {
+				l4g.Error(utils.T("app.search_engine.delete_post.error"), postId, err.Error())
+			}
+		}
+	}()
+}
+
+// searchPostsInTeamWithSearchEngine is SearchPostsInTeam for when the search engine is used instead of the database.
+// The engine only returns the ids of matching posts, so the posts themselves are loaded from the database.
+func searchPostsInTeamWithSearchEngine(searchEngine einterfaces.SearchEngineInterface, paramsList []*model.SearchParams, userId string, teamId string) (*model.PostList, *model.AppError) {
+	var channels []*model.Channel
+	if result := <-Srv.Store.Channel().GetChannels(teamId, userId); result.Err != nil {
+		if result.Err.Id != "store.sql_channel.get_channels.not_found.app_error" {
+			return nil, result.Err
+		}
+	} else {
+		channels = *result.Data.(*model.ChannelList)
+	}
+
+	// Archived channels are still searched by their members
+	if result := <-Srv.Store.Channel().GetDeletedForUser(teamId, userId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		channels = append(channels, *result.Data.(*model.ChannelList)...)
+	}
+
+	posts := model.NewPostList()
+
+	for _, params := range paramsList {
+		// don't allow users to search for everything
+		if params.Terms == "*" {
+			continue
+		}
+
+		inChannels := make(map[string]bool)
+		for _, name := range params.InChannels {
+			inChannels[name] = true
+		}
+
+		channelIds := []string{}
+		for _, channel := range channels {
+			if params.InDirectChannels && channel.Type != model.CHANNEL_DIRECT && channel.Type != model.CHANNEL_GROUP {
+				continue
+			}
+
+			if len(inChannels) == 0 || inChannels[channel.Name] {
+				channelIds = append(channelIds, channel.Id)
+			}
+		}
+
+		userIds := []string{}
+		if len(params.FromUsers) > 0 {
+			if result := <-Srv.Store.User().GetProfilesByUsernames(params.FromUsers, teamId); result.Err != nil {
+				return nil, result.Err
+			} else {
+				for _, user := range result.Data.([]*model.User) {
+					userIds = append(userIds, user.Id)
+				}
+			}
+
+			if len(userIds) == 0 {
+				continue
+			}
+		}
+
+		postIds, err := searchEngine.SearchPosts(channelIds, userIds, params, SEARCH_ENGINE_RESULT_LIMIT)
+		if err != nil {
+			return nil, err
+		}
+
+		if result := <-Srv.Store.Post().GetPostsByIds(postIds); result.Err != nil {
+			return nil, result.Err
+		} else {
+			list := model.NewPostList()
+			for _, post := range result.Data.([]*model.Post) {
+				// The index may not have caught up with posts that were just deleted
+				if post.DeleteAt != 0 {
+					continue
+				}
+
+				list.AddPost(post)
+				list.AddOrder(post.Id)
+			}
+
+			posts.Extend(list)
+		}
+	}
+
+	return posts, nil
+}
+
+// SearchIndexingJob rebuilds the search index from every post in the database. The number of posts that were indexed
+// is stored in the job's data. It returns an error if the job is canceled before every post has been indexed.
+func SearchIndexingJob(job *model.Job, cancel <-chan interface{}) *model.AppError {
+	searchEngine := getIndexingSearchEngine()
+	if searchEngine == nil {
+		return model.NewAppError("SearchIndexingJob", "app.search_engine.indexing_disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	var total int64
+	if result := <-Srv.Store.Post().AnalyticsPostCount("", false, false); result.Err != nil {
+		return result.Err
+	} else {
+		total = result.Data.(int64)
+	}
+
+	if err := searchEngine.PurgeIndexes(); err != nil {
+		return err
+	}
+
+	var indexed int64
+	defer func() {
+		if job.Data == nil {
+			job.Data = make(model.StringMap)
+		}
+		job.Data["indexed_posts"] = strconv.FormatInt(indexed, 10)
+	}()
+
+	afterId := ""
+	for {
+		select {
+		case <-cancel:
+			return model.NewAppError("SearchIndexingJob", "app.search_engine.indexing_canceled.app_error", nil, "id="+job.Id, http.StatusInternalServerError)
+		default:
+		}
+
+		var posts []*model.PostForIndexing
+		if result := <-Srv.Store.Post().GetPostsForIndexing(afterId, *utils.Cfg.BleveSettings.BulkIndexingBatchSize); result.Err != nil {
+			return result.Err
+		} else {
+			posts = result.Data.([]*model.PostForIndexing)
+		}
+
+		if len(posts) == 0 {
+			return nil
+		}
+
+		if err := searchEngine.IndexPosts(posts); err != nil {
+			return err
+		}
+
+		indexed += int64(len(posts))
+		afterId = posts[len(posts)-1].Id
+
+		if total > 0 && indexed < total {
+			if err := jobs.SetJobProgress(job, indexed*100/total); err != nil {
+				l4g.Warn(utils.T("app.search_engine.indexing_progress.warn"), job.Id, err.Error())
+			}
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	l4g "github.com/alecthomas/log4go"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/primefour/servers/jobs"
 	"github.com/primefour/servers/model"
 	"github.com/primefour/servers/store"
 	"github.com/primefour/servers/utils"
@@ -76,6 +77,7 @@ func NewServer() {
 
 func InitStores() {
 	Srv.Store = store.NewSqlStore()
+	jobs.Srv.Store = Srv.Store
 }
 
 type VaryBy struct{}
@@ -200,6 +202,8 @@ func StartServer() {
 			time.Sleep(time.Second)
 		}
 	}()
+
+	StartOutgoingWebhookDeliveries()
 }
 
 func StopServer() {
@@ -207,6 +211,7 @@ func StopServer() {
 	l4g.Info(utils.T("api.server.stop_server.stopping.info"))
 
 	Srv.GracefulServer.Stop(TIME_TO_WAIT_FOR_CONNECTIONS_TO_CLOSE_ON_SERVER_SHUTDOWN)
+	StopOutgoingWebhookDeliveries()
 	Srv.Store.Close()
 	HubStop()
 
@@ -44,12 +44,21 @@ func GetSession(token string) (*model.Session, *model.AppError) {
 
 	if session == nil {
 		if sessionResult := <-Srv.Store.Session().Get(token); sessionResult.Err != nil {
+			if *utils.Cfg.ServiceSettings.EnableUserAccessTokens {
+				// The token may be a personal access token that hasn't been used since its session was removed
+				if session, err := createSessionForUserAccessToken(token); err == nil {
+					return session, nil
+				}
+			}
+
 			return nil, model.NewLocAppError("GetSession", "api.context.invalid_token.error", map[string]interface{}{"Token": token, "Error": sessionResult.Err.DetailedError}, "")
 		} else {
 			session = sessionResult.Data.(*model.Session)
 
 			if session == nil || session.IsExpired() || session.Token != token {
 				return nil, model.NewLocAppError("GetSession", "api.context.invalid_token.error", map[string]interface{}{"Token": token, "Error": ""}, "")
+			} else if isDisabledUserAccessTokenSession(session) {
+				return nil, model.NewLocAppError("GetSession", "api.context.invalid_token.error", map[string]interface{}{"Token": token, "Error": ""}, "user access tokens are disabled")
 			} else {
 				AddSessionToCache(session)
 				return session, nil
@@ -61,6 +70,10 @@ func GetSession(token string) (*model.Session, *model.AppError) {
 		return nil, model.NewLocAppError("GetSession", "api.context.invalid_token.error", map[string]interface{}{"Token": token}, "")
 	}
 
+	if isDisabledUserAccessTokenSession(session) {
+		return nil, model.NewLocAppError("GetSession", "api.context.invalid_token.error", map[string]interface{}{"Token": token}, "user access tokens are disabled")
+	}
+
 	return session, nil
 }
 
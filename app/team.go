@@ -306,9 +306,11 @@ func JoinUserToTeam(team *model.Team, user *model.User, userRequestorId string)
 		channelRole = model.ROLE_CHANNEL_USER.Id + " " + model.ROLE_CHANNEL_ADMIN.Id
 	}
 
-	// Soft error if there is an issue joining the default channels
-	if err := JoinDefaultChannels(team.Id, user, channelRole, userRequestorId); err != nil {
-		l4g.Error(utils.T("api.user.create_user.joining.error"), user.Id, team.Id, err)
+	// Soft error if there is an issue joining the default channels. Guests only join the channels they're added to.
+	if !user.IsGuest() {
+		if err := JoinDefaultChannels(team.Id, user, channelRole, userRequestorId); err != nil {
+			l4g.Error(utils.T("api.user.create_user.joining.error"), user.Id, team.Id, err)
+		}
 	}
 
 	ClearSessionCacheForUser(user.Id)
@@ -0,0 +1,256 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	UPLOAD_SESSION_CHUNKS_DIR = "chunks"
+	UPLOAD_SESSION_FILE       = "file"
+)
+
+func checkFileAttachmentsEnabled(where string) *model.AppError {
+	if !*utils.Cfg.FileSettings.EnableFileAttachments {
+		return model.NewAppError(where, "api.file.attachments.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	return nil
+}
+
+func CreateUploadSession(us *model.UploadSession) (*model.UploadSession, *model.AppError) {
+	if err := checkFileAttachmentsEnabled("CreateUploadSession"); err != nil {
+		return nil, err
+	}
+
+	if len(utils.Cfg.FileSettings.DriverName) == 0 {
+		return nil, model.NewAppError("CreateUploadSession", "api.file.upload_file.storage.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	if us.FileSize > *utils.Cfg.FileSettings.MaxFileSize {
+		return nil, model.NewAppError("CreateUploadSession", "api.file.upload_file.too_large.app_error", nil, "", http.StatusRequestEntityTooLarge)
+	}
+
+	us.Id = model.NewId()
+	us.CreateAt = 0
+	us.UpdateAt = 0
+	us.Finishing = false
+	us.Path = "uploads/" + us.Id
+	us.FileOffset = 0
+
+	if result := <-Srv.Store.UploadSession().Save(us); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.UploadSession), nil
+	}
+}
+
+func GetUploadSession(uploadId string) (*model.UploadSession, *model.AppError) {
+	if result := <-Srv.Store.UploadSession().Get(uploadId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.UploadSession), nil
+	}
+}
+
+// UploadData stores the next chunk of an upload session. Chunks must be sent in order, so offset has to match the
+// amount of data that the session has already received.
+func UploadData(us *model.UploadSession, offset int64, data io.Reader) (*model.UploadSession, *model.AppError) {
+	if err := checkFileAttachmentsEnabled("UploadData"); err != nil {
+		return nil, err
+	}
+
+	if offset != us.FileOffset {
+		return nil, model.NewAppError("UploadData", "api.upload.upload_data.offset.app_error", map[string]interface{}{"Offset": us.FileOffset}, "offset="+strconv.FormatInt(offset, 10), http.StatusBadRequest)
+	}
+
+	remaining := us.FileSize - us.FileOffset
+	if remaining == 0 {
+		return nil, model.NewAppError("UploadData", "api.upload.upload_data.complete.app_error", nil, "id="+us.Id, http.StatusBadRequest)
+	}
+
+	chunkPath := path.Join(us.Path, UPLOAD_SESSION_CHUNKS_DIR, fmt.Sprintf("%020d_%s", offset, model.NewId()))
+
+	// Read one byte more than is needed so that we can tell if the client sent too much
+	written, err := WriteFile(io.LimitReader(data, remaining+1), chunkPath)
+	if err != nil {
+		RemoveFile(chunkPath)
+		return nil, err
+	}
+
+	if written == 0 {
+		RemoveFile(chunkPath)
+		return nil, model.NewAppError("UploadData", "api.upload.upload_data.empty.app_error", nil, "id="+us.Id, http.StatusBadRequest)
+	} else if written > remaining {
+		RemoveFile(chunkPath)
+		return nil, model.NewAppError("UploadData", "api.upload.upload_data.too_large.app_error", nil, "id="+us.Id, http.StatusRequestEntityTooLarge)
+	}
+
+	if result := <-Srv.Store.UploadSession().UpdateOffset(us.Id, offset, offset+written); result.Err != nil {
+		// Another request stored this part of the file first
+		RemoveFile(chunkPath)
+		return nil, result.Err
+	}
+
+	us.FileOffset = offset + written
+	return us, nil
+}
+
+// FinishUploadSession assembles the chunks of a complete upload session into a file and saves a FileInfo for it. The
+// session is marked as finishing first so that concurrent requests can't both save the same file.
+func FinishUploadSession(us *model.UploadSession, teamId string) (*model.FileInfo, *model.AppError) {
+	if err := checkFileAttachmentsEnabled("FinishUploadSession"); err != nil {
+		return nil, err
+	}
+
+	if !us.IsComplete() {
+		return nil, model.NewAppError("FinishUploadSession", "api.upload.finish_upload_session.incomplete.app_error", map[string]interface{}{"Offset": us.FileOffset}, "id="+us.Id, http.StatusBadRequest)
+	}
+
+	if result := <-Srv.Store.UploadSession().SetFinishing(us.Id, true); result.Err != nil {
+		return nil, result.Err
+	}
+
+	info, err := assembleUploadSession(us, teamId)
+	if err != nil {
+		// Let the client try again
+		if result := <-Srv.Store.UploadSession().SetFinishing(us.Id, false); result.Err != nil {
+			l4g.Warn(utils.T("api.upload.finish_upload_session.release.warn"), us.Id, result.Err.Error())
+		}
+
+		return nil, err
+	}
+
+	if err := RemoveUploadSession(us); err != nil {
+		l4g.Warn(utils.T("api.upload.finish_upload_session.cleanup.warn"), us.Id, err.Error())
+	}
+
+	return info, nil
+}
+
+func assembleUploadSession(us *model.UploadSession, teamId string) (*model.FileInfo, *model.AppError) {
+	chunks, err := ListDirectory(path.Join(us.Path, UPLOAD_SESSION_CHUNKS_DIR))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(chunks)
+
+	stagingPath := path.Join(us.Path, UPLOAD_SESSION_FILE)
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(copyUploadChunks(pw, chunks))
+	}()
+
+	written, err := WriteFile(pr, stagingPath)
+	pr.Close()
+	if err != nil {
+		return nil, err
+	} else if written != us.FileSize {
+		return nil, model.NewAppError("FinishUploadSession", "api.upload.finish_upload_session.size.app_error", nil, fmt.Sprintf("id=%v, expected=%v, actual=%v", us.Id, us.FileSize, written), http.StatusInternalServerError)
+	}
+
+	reader, err := FileReader(stagingPath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	info, err := newUploadedFileInfo(teamId, us.ChannelId, us.UserId, us.Filename, reader, written)
+	if err != nil {
+		return nil, err
+	}
+
+	// Images are small enough to be read into memory and need to be read before the staged file is moved
+	var imageData []byte
+	if info.PreviewPath != "" || info.ThumbnailPath != "" {
+		var readErr error
+		if imageData, readErr = ioutil.ReadAll(reader); readErr != nil {
+			return nil, model.NewAppError("FinishUploadSession", "api.upload.finish_upload_session.read.app_error", nil, readErr.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	if err := MoveFile(stagingPath, info.Path); err != nil {
+		return nil, err
+	}
+
+	if result := <-Srv.Store.FileInfo().Save(info); result.Err != nil {
+		return nil, result.Err
+	}
+
+	if imageData != nil {
+		HandleImages([]string{info.PreviewPath}, []string{info.ThumbnailPath}, [][]byte{imageData})
+	}
+
+	return info, nil
+}
+
+// copyUploadChunks writes the chunks, which are sorted by offset, to w and makes sure that there are no gaps
+// or overlaps between them.
+func copyUploadChunks(w io.Writer, chunks []string) error {
+	var copied int64
+
+	for _, chunk := range chunks {
+		offset, parseErr := strconv.ParseInt(strings.SplitN(path.Base(chunk), "_", 2)[0], 10, 64)
+		if parseErr != nil || offset != copied {
+			return model.NewAppError("copyUploadChunks", "api.upload.finish_upload_session.chunks.app_error", nil, "chunk="+chunk, http.StatusInternalServerError)
+		}
+
+		reader, err := FileReader(chunk)
+		if err != nil {
+			return err
+		}
+
+		n, copyErr := io.Copy(w, reader)
+		reader.Close()
+		if copyErr != nil {
+			return copyErr
+		}
+
+		copied += n
+	}
+
+	return nil
+}
+
+// RemoveUploadSession deletes an upload session along with any data that has been uploaded for it.
+func RemoveUploadSession(us *model.UploadSession) *model.AppError {
+	if err := RemoveDirectory(us.Path); err != nil {
+		return err
+	}
+
+	if result := <-Srv.Store.UploadSession().Delete(us.Id); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+func CleanupUploadSessions() {
+	l4g.Debug(utils.T("api.upload.cleanup_upload_sessions.debug"))
+
+	result := <-Srv.Store.UploadSession().GetStale(model.GetMillis() - model.UPLOAD_SESSION_EXPIRY_TIME)
+	if result.Err != nil {
+		l4g.Error(utils.T("api.upload.cleanup_upload_sessions.error"), result.Err.Error())
+		return
+	}
+
+	for _, us := range result.Data.([]*model.UploadSession) {
+		if err := RemoveUploadSession(us); err != nil {
+			l4g.Error(utils.T("api.upload.cleanup_upload_sessions.error"), err.Error())
+		}
+	}
+}
@@ -64,9 +64,17 @@ func CreateUserWithHash(user *model.User, hash string, data string) (*model.User
 	user.Email = props["email"]
 	user.EmailVerified = true
 
+	// Guests were invited to specific channels, so they only join those instead of the team's default channels
+	isGuest := props["guest"] == "true"
+
 	var ruser *model.User
 	var err *model.AppError
-	if ruser, err = CreateUser(user); err != nil {
+	if isGuest {
+		ruser, err = CreateGuest(user)
+	} else {
+		ruser, err = CreateUser(user)
+	}
+	if err != nil {
 		return nil, err
 	}
 
@@ -74,7 +82,11 @@ func CreateUserWithHash(user *model.User, hash string, data string) (*model.User
 		return nil, err
 	}
 
-	AddDirectChannels(team.Id, ruser)
+	if isGuest {
+		addGuestToChannels(ruser, team.Id, strings.Split(props["channels"], ","))
+	} else {
+		AddDirectChannels(team.Id, ruser)
+	}
 
 	return ruser, nil
 }
@@ -182,6 +194,9 @@ func CreateUser(user *model.User) (*model.User, *model.AppError) {
 
 	user.Roles = model.ROLE_SYSTEM_USER.Id
 
+	// Bots are only created through CreateBot, which also records their owner
+	user.IsBot = false
+
 	// Below is a special case where the first user in the entire
 	// system is granted the system_admin role
 	if result := <-Srv.Store.User().GetTotalUsersCount(); result.Err != nil {
@@ -212,7 +227,7 @@ func CreateUser(user *model.User) (*model.User, *model.AppError) {
 func createUser(user *model.User) (*model.User, *model.AppError) {
 	user.MakeNonNil()
 
-	if err := utils.IsPasswordValid(user.Password); user.AuthService == "" && err != nil {
+	if err := utils.IsPasswordValid(user.Password); user.AuthService == "" && !user.IsBot && err != nil {
 		return nil, err
 	}
 
@@ -377,7 +392,7 @@ func GetUserByAuth(authData *string, authService string) (*model.User, *model.Ap
 }
 
 func GetUserForLogin(loginId string, onlyLdap bool) (*model.User, *model.AppError) {
-	ldapAvailable := *utils.Cfg.LdapSettings.Enable && einterfaces.GetLdapInterface() != nil && utils.IsLicensed && *utils.License.Features.LDAP
+	ldapAvailable := *utils.Cfg.LdapSettings.Enable && einterfaces.GetLdapInterface() != nil
 
 	if result := <-Srv.Store.User().GetForLogin(
 		loginId,
@@ -673,6 +688,22 @@ func DeactivateMfa(userId string) *model.AppError {
 	return nil
 }
 
+// GenerateMfaRecoveryCodes replaces a user's MFA recovery codes with new ones and returns them. They can't be
+// retrieved again afterwards.
+func GenerateMfaRecoveryCodes(userId string) ([]string, *model.AppError) {
+	mfaInterface := einterfaces.GetMfaInterface()
+	if mfaInterface == nil {
+		return nil, model.NewAppError("GenerateMfaRecoveryCodes", "api.user.update_mfa.not_available.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	user, err := GetUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return mfaInterface.GenerateRecoveryCodes(user)
+}
+
 func CreateProfileImage(username string, userId string) ([]byte, *model.AppError) {
 	colors := []color.NRGBA{
 		{197, 8, 126, 255},
@@ -768,7 +799,7 @@ func GetProfileImage(user *model.User) ([]byte, bool, *model.AppError) {
 			}
 
 			if user.LastPictureUpdate == 0 {
-				if err := WriteFile(img, path); err != nil {
+				if _, err := WriteFile(bytes.NewReader(img), path); err != nil {
 					return nil, false, err
 				}
 			}
@@ -815,7 +846,7 @@ func SetProfileImage(userId string, imageData *multipart.FileHeader) *model.AppE
 
 	path := "users/" + userId + "/profile.png"
 
-	if err := WriteFile(buf.Bytes(), path); err != nil {
+	if _, err := WriteFile(buf, path); err != nil {
 		return model.NewLocAppError("SetProfileImage", "api.user.upload_profile_user.upload_profile.app_error", nil, "")
 	}
 
@@ -915,6 +946,10 @@ func UpdateActive(user *model.User, active bool) (*model.User, *model.AppError)
 
 		if !active {
 			SetStatusOffline(ruser.Id, false)
+
+			if !ruser.IsBot {
+				disableUserBots(ruser.Id)
+			}
 		}
 
 		return ruser, nil
@@ -1218,6 +1253,18 @@ func PermanentDeleteUser(user *model.User) *model.AppError {
 		return result.Err
 	}
 
+	if result := <-Srv.Store.UserAccessToken().DeleteAllForUser(user.Id); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-Srv.Store.Mfa().DeleteAllForUser(user.Id); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-Srv.Store.Bot().PermanentDelete(user.Id); result.Err != nil {
+		return result.Err
+	}
+
 	if result := <-Srv.Store.OAuth().PermanentDeleteAuthDataByUser(user.Id); result.Err != nil {
 		return result.Err
 	}
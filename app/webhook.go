@@ -4,12 +4,15 @@
 package app
 
 import (
+	"bytes"
 	"crypto/tls"
-	"io"
 	"io/ioutil"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 	"unicode/utf8"
 
 	l4g "github.com/alecthomas/log4go"
@@ -22,6 +25,23 @@ import (
 const (
 	TRIGGERWORDS_FULL       = 0
 	TRIGGERWORDS_STARTSWITH = 1
+
+	OUTGOING_WEBHOOK_DELIVERY_WORKERS    = 8
+	OUTGOING_WEBHOOK_DELIVERY_BATCH_SIZE = 100
+)
+
+var (
+	outgoingWebhookTimeout      = time.Duration(30 * time.Second)
+	outgoingWebhookRetryDelay   = time.Duration(1 * time.Second) // doubled after every failed attempt
+	outgoingWebhookPollInterval = time.Duration(1 * time.Second)
+
+	outgoingWebhookDeliveryWake    = make(chan bool, 1)
+	outgoingWebhookDeliveryStop    chan struct{}
+	outgoingWebhookDeliveryStopped chan struct{}
+
+	// Only used by the goroutine that makes the delivery attempts
+	outgoingWebhookTransport         *http.Transport
+	outgoingWebhookTransportInsecure bool
 )
 
 func handleWebhookEvents(post *model.Post, team *model.Team, channel *model.Channel, user *model.User) *model.AppError {
@@ -63,62 +83,323 @@ func handleWebhookEvents(post *model.Post, team *model.Team, channel *model.Chan
 		}
 	}
 
+	now := model.GetMillis()
 	for _, hook := range relevantHooks {
-		go func(hook *model.OutgoingWebhook) {
-			payload := &model.OutgoingWebhookPayload{
-				Token:       hook.Token,
-				TeamId:      hook.TeamId,
-				TeamDomain:  team.Name,
-				ChannelId:   post.ChannelId,
-				ChannelName: channel.Name,
-				Timestamp:   post.CreateAt,
-				UserId:      post.UserId,
-				UserName:    user.Username,
-				PostId:      post.Id,
-				Text:        post.Message,
-				TriggerWord: firstWord,
+		for _, url := range hook.CallbackURLs {
+			delivery := &model.OutgoingWebhookDelivery{
+				HookId:        hook.Id,
+				PostId:        post.Id,
+				CallbackURL:   url,
+				Attempt:       1,
+				NextAttemptAt: now,
 			}
-			var body io.Reader
-			var contentType string
-			if hook.ContentType == "application/json" {
-				body = strings.NewReader(payload.ToJSON())
-				contentType = "application/json"
-			} else {
-				body = strings.NewReader(payload.ToFormValues())
-				contentType = "application/x-www-form-urlencoded"
+
+			if result := <-Srv.Store.Webhook().SaveOutgoingDelivery(delivery); result.Err != nil {
+				l4g.Error(utils.T("api.webhook.outgoing_delivery.save.error"), hook.Id, result.Err.Error())
 			}
-			tr := &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections},
+		}
+	}
+
+	wakeOutgoingWebhookDeliveries()
+
+	return nil
+}
+
+// StartOutgoingWebhookDeliveries starts making the attempts at delivering posts to outgoing webhooks that are waiting in
+// the delivery log, including the ones left over from when the server last stopped. Every server in a cluster makes
+// them, but an attempt is claimed by a single server before it's made.
+func StartOutgoingWebhookDeliveries() {
+	outgoingWebhookDeliveryStop = make(chan struct{})
+	outgoingWebhookDeliveryStopped = make(chan struct{})
+
+	go func() {
+		defer close(outgoingWebhookDeliveryStopped)
+
+		ticker := time.NewTicker(outgoingWebhookPollInterval)
+		defer ticker.Stop()
+
+		for {
+			// Keep going for as long as full batches are found so that a backlog is cleared without waiting
+			for processPendingOutgoingWebhookDeliveries() == OUTGOING_WEBHOOK_DELIVERY_BATCH_SIZE {
 			}
-			client := &http.Client{Transport: tr}
-
-			for _, url := range hook.CallbackURLs {
-				go func(url string) {
-					req, _ := http.NewRequest("POST", url, body)
-					req.Header.Set("Content-Type", contentType)
-					req.Header.Set("Accept", "application/json")
-					if resp, err := client.Do(req); err != nil {
-						l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.event_post.error"), err.Error())
-					} else {
-						defer func() {
-							ioutil.ReadAll(resp.Body)
-							resp.Body.Close()
-						}()
-						respProps := model.MapFromJson(resp.Body)
-
-						if text, ok := respProps["text"]; ok {
-							if _, err := CreateWebhookPost(hook.CreatorId, hook.TeamId, post.ChannelId, text, respProps["username"], respProps["icon_url"], post.Props, post.Type); err != nil {
-								l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.create_post.error"), err)
-							}
-						}
-					}
-				}(url)
+
+			select {
+			case <-outgoingWebhookDeliveryStop:
+				return
+			case <-ticker.C:
+			case <-outgoingWebhookDeliveryWake:
 			}
+		}
+	}()
+}
 
-		}(hook)
+// StopOutgoingWebhookDeliveries stops making delivery attempts once the ones that are under way are done.
+func StopOutgoingWebhookDeliveries() {
+	if outgoingWebhookDeliveryStop == nil {
+		return
 	}
 
-	return nil
+	close(outgoingWebhookDeliveryStop)
+	<-outgoingWebhookDeliveryStopped
+
+	outgoingWebhookDeliveryStop = nil
+	outgoingWebhookDeliveryStopped = nil
+
+	if outgoingWebhookTransport != nil {
+		outgoingWebhookTransport.CloseIdleConnections()
+		outgoingWebhookTransport = nil
+	}
+}
+
+func wakeOutgoingWebhookDeliveries() {
+	select {
+	case outgoingWebhookDeliveryWake <- true:
+	default:
+	}
+}
+
+// processPendingOutgoingWebhookDeliveries makes a batch of the delivery attempts that are due, no more than
+// OUTGOING_WEBHOOK_DELIVERY_WORKERS at a time, and returns the size of the batch.
+func processPendingOutgoingWebhookDeliveries() int {
+	if !utils.Cfg.ServiceSettings.EnableOutgoingWebhooks {
+		return 0
+	}
+
+	var deliveries []*model.OutgoingWebhookDelivery
+	if result := <-Srv.Store.Webhook().GetPendingOutgoingDeliveries(model.GetMillis(), OUTGOING_WEBHOOK_DELIVERY_BATCH_SIZE); result.Err != nil {
+		l4g.Error(utils.T("api.webhook.outgoing_delivery.get_pending.error"), result.Err.Error())
+		return 0
+	} else {
+		deliveries = result.Data.([]*model.OutgoingWebhookDelivery)
+	}
+
+	client := &http.Client{Transport: getOutgoingWebhookTransport(), Timeout: outgoingWebhookTimeout}
+
+	workers := make(chan bool, OUTGOING_WEBHOOK_DELIVERY_WORKERS)
+	var wg sync.WaitGroup
+
+	hookIds := map[string]bool{}
+	for _, delivery := range deliveries {
+		hookIds[delivery.HookId] = true
+
+		workers <- true
+		wg.Add(1)
+
+		go func(delivery *model.OutgoingWebhookDelivery) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+
+			// Another server gets to make the attempt if this one stops before recording it
+			until := model.GetMillis() + int64(2*outgoingWebhookTimeout/time.Millisecond)
+			if result := <-Srv.Store.Webhook().ClaimOutgoingDelivery(delivery, until); result.Err != nil {
+				l4g.Error(utils.T("api.webhook.outgoing_delivery.save.error"), delivery.HookId, result.Err.Error())
+			} else if result.Data.(bool) {
+				deliverOutgoingWebhook(client, delivery)
+			}
+		}(delivery)
+	}
+
+	wg.Wait()
+
+	for hookId := range hookIds {
+		if result := <-Srv.Store.Webhook().PruneOutgoingDeliveries(hookId, model.OUTGOING_WEBHOOK_DELIVERY_LOG_MAX_PER_HOOK); result.Err != nil {
+			l4g.Error(utils.T("api.webhook.outgoing_delivery.save.error"), hookId, result.Err.Error())
+		}
+	}
+
+	return len(deliveries)
+}
+
+// getOutgoingWebhookTransport returns the transport that the delivery attempts share so that their connections to the
+// callback URLs are reused. It's replaced when the setting that allows insecure connections changes.
+func getOutgoingWebhookTransport() *http.Transport {
+	insecure := *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections
+
+	if outgoingWebhookTransport == nil || outgoingWebhookTransportInsecure != insecure {
+		if outgoingWebhookTransport != nil {
+			outgoingWebhookTransport.CloseIdleConnections()
+		}
+
+		outgoingWebhookTransport = &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			IdleConnTimeout: outgoingWebhookTimeout,
+		}
+		outgoingWebhookTransportInsecure = insecure
+	}
+
+	return outgoingWebhookTransport
+}
+
+// deliverOutgoingWebhook makes a claimed attempt at delivering a post to a callback URL of a hook and records what
+// happened in the delivery log. An attempt that fails in a way that may not happen again is followed by another one
+// after an exponential backoff. Once the callback URL accepts the post, its response is posted to the channel.
+func deliverOutgoingWebhook(client *http.Client, delivery *model.OutgoingWebhookDelivery) {
+	hook, post, body, contentType, err := prepareOutgoingWebhookDelivery(delivery)
+
+	var respBody []byte
+	if err != nil {
+		delivery.Error = err.Error()
+	} else {
+		respBody = doOutgoingWebhookRequest(client, hook, delivery, body, contentType)
+	}
+
+	delivery.NextAttemptAt = 0
+	if result := <-Srv.Store.Webhook().UpdateOutgoingDelivery(delivery); result.Err != nil {
+		l4g.Error(utils.T("api.webhook.outgoing_delivery.save.error"), delivery.HookId, result.Err.Error())
+	}
+
+	if delivery.IsSuccess() {
+		respProps := model.MapFromJson(bytes.NewReader(respBody))
+
+		if text, ok := respProps["text"]; ok {
+			if _, err := CreateWebhookPost(hook.CreatorId, hook.TeamId, post.ChannelId, text, respProps["username"], respProps["icon_url"], post.Props, post.Type); err != nil {
+				l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.create_post.error"), err)
+			}
+		}
+
+		return
+	}
+
+	if err != nil || !delivery.ShouldRetry() || delivery.Attempt == model.OUTGOING_WEBHOOK_DELIVERY_MAX_ATTEMPTS {
+		l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.event_post.error"), "hook_id="+delivery.HookId+", url="+delivery.CallbackURL+", attempts="+strconv.Itoa(delivery.Attempt)+", status_code="+strconv.Itoa(delivery.StatusCode)+", err="+delivery.Error)
+		return
+	}
+
+	next := &model.OutgoingWebhookDelivery{
+		HookId:        delivery.HookId,
+		PostId:        delivery.PostId,
+		CallbackURL:   delivery.CallbackURL,
+		Attempt:       delivery.Attempt + 1,
+		NextAttemptAt: model.GetMillis() + int64((outgoingWebhookRetryDelay<<uint(delivery.Attempt-1))/time.Millisecond),
+	}
+
+	if result := <-Srv.Store.Webhook().SaveOutgoingDelivery(next); result.Err != nil {
+		l4g.Error(utils.T("api.webhook.outgoing_delivery.save.error"), delivery.HookId, result.Err.Error())
+	}
+}
+
+// prepareOutgoingWebhookDelivery builds the request body of a delivery from the post as it is now, so that nothing is
+// sent for a post or a callback URL that was deleted since the delivery was scheduled.
+func prepareOutgoingWebhookDelivery(delivery *model.OutgoingWebhookDelivery) (*model.OutgoingWebhook, *model.Post, string, string, *model.AppError) {
+	hchan := Srv.Store.Webhook().GetOutgoing(delivery.HookId)
+	pchan := Srv.Store.Post().GetSingle(delivery.PostId)
+
+	var hook *model.OutgoingWebhook
+	if result := <-hchan; result.Err != nil {
+		return nil, nil, "", "", result.Err
+	} else {
+		hook = result.Data.(*model.OutgoingWebhook)
+	}
+
+	var post *model.Post
+	if result := <-pchan; result.Err != nil {
+		return nil, nil, "", "", result.Err
+	} else {
+		post = result.Data.(*model.Post)
+	}
+
+	if len(utils.StringArrayIntersection(hook.CallbackURLs, []string{delivery.CallbackURL})) == 0 {
+		return nil, nil, "", "", model.NewAppError("prepareOutgoingWebhookDelivery", "api.webhook.outgoing_delivery.callback_url.app_error", nil, "hook_id="+hook.Id, http.StatusBadRequest)
+	}
+
+	// Webhooks created before signing secrets were added get one the first time they are used
+	if len(hook.SigningSecret) == 0 {
+		hook.SigningSecret = model.NewRandomString(model.OUTGOING_WEBHOOK_SIGNING_SECRET_LENGTH)
+
+		if result := <-Srv.Store.Webhook().UpdateOutgoing(hook); result.Err != nil {
+			return nil, nil, "", "", result.Err
+		}
+	}
+
+	cchan := Srv.Store.Channel().Get(post.ChannelId, true)
+	uchan := Srv.Store.User().Get(post.UserId)
+
+	var channel *model.Channel
+	if result := <-cchan; result.Err != nil {
+		return nil, nil, "", "", result.Err
+	} else {
+		channel = result.Data.(*model.Channel)
+	}
+
+	tchan := Srv.Store.Team().Get(channel.TeamId)
+
+	var user *model.User
+	if result := <-uchan; result.Err != nil {
+		return nil, nil, "", "", result.Err
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	var team *model.Team
+	if result := <-tchan; result.Err != nil {
+		return nil, nil, "", "", result.Err
+	} else {
+		team = result.Data.(*model.Team)
+	}
+
+	var triggerWord string
+	if splitWords := strings.Fields(post.Message); len(splitWords) != 0 {
+		triggerWord = splitWords[0]
+	}
+
+	payload := &model.OutgoingWebhookPayload{
+		Token:       hook.Token,
+		TeamId:      hook.TeamId,
+		TeamDomain:  team.Name,
+		ChannelId:   post.ChannelId,
+		ChannelName: channel.Name,
+		Timestamp:   post.CreateAt,
+		UserId:      post.UserId,
+		UserName:    user.Username,
+		PostId:      post.Id,
+		Text:        post.Message,
+		TriggerWord: triggerWord,
+	}
+
+	if hook.ContentType == "application/json" {
+		return hook, post, payload.ToJSON(), "application/json", nil
+	} else {
+		return hook, post, payload.ToFormValues(), "application/x-www-form-urlencoded", nil
+	}
+}
+
+// doOutgoingWebhookRequest makes a single attempt at posting body to the callback URL of the delivery and fills in the
+// delivery with what happened.
+func doOutgoingWebhookRequest(client *http.Client, hook *model.OutgoingWebhook, delivery *model.OutgoingWebhookDelivery, body, contentType string) []byte {
+	req, err := http.NewRequest("POST", delivery.CallbackURL, strings.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return nil
+	}
+	timestamp := model.GetMillis() / 1000
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(model.HEADER_OUTGOING_WEBHOOK_TIMESTAMP, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(model.HEADER_OUTGOING_WEBHOOK_SIGNATURE, hook.Sign(timestamp, []byte(body)))
+
+	start := time.Now()
+	defer func() {
+		delivery.Latency = int64(time.Since(start) / time.Millisecond)
+	}()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		delivery.Error = err.Error()
+		return nil
+	}
+	defer CloseBody(resp)
+
+	delivery.StatusCode = resp.StatusCode
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	delivery.ResponseExcerpt = string(respBody)
+
+	return respBody
 }
 
 func CreateWebhookPost(userId, teamId, channelId, text, overrideUsername, overrideIconUrl string, props model.StringInterface, postType string) (*model.Post, *model.AppError) {
@@ -337,6 +618,7 @@ func UpdateOutgoingWebhook(oldHook, updatedHook *model.OutgoingWebhook) (*model.
 	}
 
 	updatedHook.CreatorId = oldHook.CreatorId
+	updatedHook.SigningSecret = oldHook.SigningSecret
 	updatedHook.CreateAt = oldHook.CreateAt
 	updatedHook.DeleteAt = oldHook.DeleteAt
 	updatedHook.TeamId = oldHook.TeamId
@@ -406,15 +688,32 @@ func DeleteOutgoingWebhook(hookId string) *model.AppError {
 		return result.Err
 	}
 
+	if result := <-Srv.Store.Webhook().PermanentDeleteOutgoingDeliveriesByHook(hookId); result.Err != nil {
+		return result.Err
+	}
+
 	return nil
 }
 
+func GetOutgoingWebhookDeliveriesPage(hookId string, page, perPage int) ([]*model.OutgoingWebhookDelivery, *model.AppError) {
+	if !utils.Cfg.ServiceSettings.EnableOutgoingWebhooks {
+		return nil, model.NewAppError("GetOutgoingWebhookDeliveriesPage", "api.outgoing_webhook.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	if result := <-Srv.Store.Webhook().GetOutgoingDeliveries(hookId, page*perPage, perPage); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.OutgoingWebhookDelivery), nil
+	}
+}
+
 func RegenOutgoingWebhookToken(hook *model.OutgoingWebhook) (*model.OutgoingWebhook, *model.AppError) {
 	if !utils.Cfg.ServiceSettings.EnableOutgoingWebhooks {
 		return nil, model.NewAppError("RegenOutgoingWebhookToken", "api.outgoing_webhook.disabled.app_error", nil, "", http.StatusNotImplemented)
 	}
 
 	hook.Token = model.NewId()
+	hook.SigningSecret = model.NewRandomString(model.OUTGOING_WEBHOOK_SIGNING_SECRET_LENGTH)
 
 	if result := <-Srv.Store.Webhook().UpdateOutgoing(hook); result.Err != nil {
 		return nil, result.Err
@@ -0,0 +1,37 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/primefour/servers/utils"
+)
+
+func TestOutgoingWebhookDeliveries(t *testing.T) {
+	Setup()
+
+	StopOutgoingWebhookDeliveries()
+	defer StartOutgoingWebhookDeliveries()
+
+	// Stopping again shouldn't panic
+	StopOutgoingWebhookDeliveries()
+
+	insecure := *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections = insecure
+	}()
+
+	transport := getOutgoingWebhookTransport()
+	if getOutgoingWebhookTransport() != transport {
+		t.Fatal("should have reused the transport")
+	}
+
+	*utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections = !insecure
+	if getOutgoingWebhookTransport() == transport {
+		t.Fatal("should have replaced the transport when the setting changed")
+	} else if getOutgoingWebhookTransport().TLSClientConfig.InsecureSkipVerify != !insecure {
+		t.Fatal("should have used the setting")
+	}
+}
@@ -0,0 +1,499 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package bleveengine
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/document"
+	"github.com/blevesearch/bleve/index/scorch"
+	"github.com/blevesearch/bleve/mapping"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/primefour/servers/einterfaces"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	POST_INDEX_NAME = "posts.bleve"
+
+	// MESSAGE_ANALYZER splits messages into lowercase words. Unlike the standard analyzer, it keeps stop words so that
+	// searching for a phrase like "to be or not to be" still works.
+	MESSAGE_ANALYZER = "message"
+)
+
+// BleveEngine is a SearchEngineInterface that keeps a full text index of posts on disk in the directory set by
+// BleveSettings.IndexDir. Posts are indexed one writer at a time so that the UpdateAt of the indexed version can be
+// checked before it's replaced.
+type BleveEngine struct {
+	mutex      sync.RWMutex
+	writeMutex sync.Mutex
+	postIndex  bleve.Index
+}
+
+// blevePost is the document that's stored in the index for each post. Only the fields that are needed to filter and
+// sort the results are indexed, and the posts themselves are loaded from the database.
+type blevePost struct {
+	TeamId    string
+	ChannelId string
+	UserId    string
+	CreateAt  int64
+	UpdateAt  int64
+	Message   string
+	Hashtags  []string
+	IsPinned  bool
+	HasFile   bool
+	HasLink   bool
+}
+
+func init() {
+	einterfaces.RegisterSearchEngineInterface(&BleveEngine{})
+}
+
+func getPostIndexMapping() mapping.IndexMapping {
+	keywordMapping := bleve.NewTextFieldMapping()
+	keywordMapping.Analyzer = keyword.Name
+
+	messageMapping := bleve.NewTextFieldMapping()
+	messageMapping.Analyzer = MESSAGE_ANALYZER
+
+	postMapping := bleve.NewDocumentStaticMapping()
+	postMapping.AddFieldMappingsAt("TeamId", keywordMapping)
+	postMapping.AddFieldMappingsAt("ChannelId", keywordMapping)
+	postMapping.AddFieldMappingsAt("UserId", keywordMapping)
+	postMapping.AddFieldMappingsAt("CreateAt", bleve.NewNumericFieldMapping())
+	postMapping.AddFieldMappingsAt("UpdateAt", bleve.NewNumericFieldMapping())
+	postMapping.AddFieldMappingsAt("Message", messageMapping)
+	postMapping.AddFieldMappingsAt("Hashtags", keywordMapping)
+	postMapping.AddFieldMappingsAt("IsPinned", bleve.NewBooleanFieldMapping())
+	postMapping.AddFieldMappingsAt("HasFile", bleve.NewBooleanFieldMapping())
+	postMapping.AddFieldMappingsAt("HasLink", bleve.NewBooleanFieldMapping())
+
+	indexMapping := bleve.NewIndexMapping()
+	if err := indexMapping.AddCustomAnalyzer(MESSAGE_ANALYZER, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name},
+	}); err != nil {
+		l4g.Error(utils.T("bleveengine.mapping.analyzer.error"), err.Error())
+	}
+	indexMapping.DefaultMapping = postMapping
+
+	return indexMapping
+}
+
+func createOrOpenIndex(path string, indexMapping mapping.IndexMapping) (bleve.Index, error) {
+	if index, err := bleve.Open(path); err == nil {
+		return index, nil
+	}
+
+	return bleve.NewUsing(path, indexMapping, scorch.Name, scorch.Name, nil)
+}
+
+// Start opens the index, creating it if it doesn't exist yet. It does nothing if indexing is disabled.
+func (b *BleveEngine) Start() *model.AppError {
+	if !*utils.Cfg.BleveSettings.EnableIndexing {
+		return nil
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.postIndex != nil {
+		return nil
+	}
+
+	l4g.Info(utils.T("bleveengine.start.info"), *utils.Cfg.BleveSettings.IndexDir)
+
+	if err := os.MkdirAll(*utils.Cfg.BleveSettings.IndexDir, 0700); err != nil {
+		return model.NewAppError("BleveEngine.Start", "bleveengine.start.create_dir.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	index, err := createOrOpenIndex(b.getPostIndexPath(), getPostIndexMapping())
+	if err != nil {
+		return model.NewAppError("BleveEngine.Start", "bleveengine.start.open_index.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	b.postIndex = index
+
+	return nil
+}
+
+func (b *BleveEngine) Stop() *model.AppError {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.postIndex == nil {
+		return nil
+	}
+
+	err := b.postIndex.Close()
+	b.postIndex = nil
+
+	if err != nil {
+		return model.NewAppError("BleveEngine.Stop", "bleveengine.stop.close_index.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (b *BleveEngine) getPostIndexPath() string {
+	return filepath.Join(*utils.Cfg.BleveSettings.IndexDir, POST_INDEX_NAME)
+}
+
+func (b *BleveEngine) IsIndexingEnabled() bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return *utils.Cfg.BleveSettings.EnableIndexing && b.postIndex != nil
+}
+
+func (b *BleveEngine) IsSearchEnabled() bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return *utils.Cfg.BleveSettings.EnableSearching && b.postIndex != nil
+}
+
+func newBlevePost(post *model.Post, teamId string) *blevePost {
+	hashtags := []string{}
+	for _, hashtag := range strings.Fields(post.Hashtags) {
+		hashtags = append(hashtags, strings.ToLower(hashtag))
+	}
+
+	return &blevePost{
+		TeamId:    teamId,
+		ChannelId: post.ChannelId,
+		UserId:    post.UserId,
+		CreateAt:  post.CreateAt,
+		UpdateAt:  post.UpdateAt,
+		Message:   post.Message,
+		Hashtags:  hashtags,
+		IsPinned:  post.IsPinned,
+		HasFile:   len(post.FileIds) > 0 || len(post.Filenames) > 0,
+		HasLink:   strings.Contains(post.Message, "http://") || strings.Contains(post.Message, "https://"),
+	}
+}
+
+// isNewerVersionIndexed returns true if the index already has a version of the post that was updated after updateAt.
+// The caller must hold writeMutex.
+func (b *BleveEngine) isNewerVersionIndexed(postId string, updateAt int64) bool {
+	doc, err := b.postIndex.Document(postId)
+	if err != nil || doc == nil {
+		return false
+	}
+
+	for _, field := range doc.Fields {
+		if numericField, ok := field.(*document.NumericField); ok && numericField.Name() == "UpdateAt" {
+			indexedUpdateAt, err := numericField.Number()
+			return err == nil && int64(indexedUpdateAt) > updateAt
+		}
+	}
+
+	return false
+}
+
+// IndexPost adds a post to the index, replacing the version that's already there unless that version is newer.
+func (b *BleveEngine) IndexPost(post *model.Post, teamId string) *model.AppError {
+	if post.IsSystemMessage() {
+		return nil
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if b.postIndex == nil {
+		return nil
+	}
+
+	b.writeMutex.Lock()
+	defer b.writeMutex.Unlock()
+
+	if b.isNewerVersionIndexed(post.Id, post.UpdateAt) {
+		return nil
+	}
+
+	if err := b.postIndex.Index(post.Id, newBlevePost(post, teamId)); err != nil {
+		return model.NewAppError("BleveEngine.IndexPost", "bleveengine.index_post.app_error", nil, "post_id="+post.Id+", "+err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// IndexPosts adds a batch of posts to the index at once, which is much faster than indexing them one by one. Posts
+// that have been indexed with a newer version in the meantime are skipped.
+func (b *BleveEngine) IndexPosts(posts []*model.PostForIndexing) *model.AppError {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if b.postIndex == nil {
+		return nil
+	}
+
+	b.writeMutex.Lock()
+	defer b.writeMutex.Unlock()
+
+	batch := b.postIndex.NewBatch()
+	for _, post := range posts {
+		if post.IsSystemMessage() || b.isNewerVersionIndexed(post.Id, post.UpdateAt) {
+			continue
+		}
+
+		if err := batch.Index(post.Id, newBlevePost(&post.Post, post.TeamId)); err != nil {
+			return model.NewAppError("BleveEngine.IndexPosts", "bleveengine.index_posts.app_error", nil, "post_id="+post.Id+", "+err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	if err := b.postIndex.Batch(batch); err != nil {
+		return model.NewAppError("BleveEngine.IndexPosts", "bleveengine.index_posts.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (b *BleveEngine) DeletePost(postId string) *model.AppError {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if b.postIndex == nil {
+		return nil
+	}
+
+	if err := b.postIndex.Delete(postId); err != nil {
+		return model.NewAppError("BleveEngine.DeletePost", "bleveengine.delete_post.app_error", nil, "post_id="+postId+", "+err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// SearchPosts returns the ids of the newest posts in the given channels that match the search params, up to limit.
+// If userIds isn't empty, only posts made by those users are returned.
+func (b *BleveEngine) SearchPosts(channelIds []string, userIds []string, params *model.SearchParams, limit int) ([]string, *model.AppError) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if b.postIndex == nil {
+		return nil, model.NewAppError("BleveEngine.SearchPosts", "bleveengine.search_posts.not_started.app_error", nil, "", http.StatusInternalServerError)
+	}
+
+	if len(channelIds) == 0 {
+		return []string{}, nil
+	}
+
+	searchQuery := bleve.NewBooleanQuery()
+	searchQuery.AddMust(newTermsQuery("ChannelId", channelIds))
+
+	if len(userIds) > 0 {
+		searchQuery.AddMust(newTermsQuery("UserId", userIds))
+	}
+
+	if termsQuery := newSearchTermsQuery(params.Terms, params.IsHashtag, params.OrTerms); termsQuery != nil {
+		searchQuery.AddMust(termsQuery)
+	} else if !params.IsFiltered() {
+		// Don't return every post when there's nothing to search for
+		return []string{}, nil
+	}
+
+	if excludedQuery := newSearchTermsQuery(params.ExcludedTerms, params.IsHashtag, true); excludedQuery != nil {
+		searchQuery.AddMustNot(excludedQuery)
+	}
+
+	for _, modifierQuery := range newSearchModifierQueries(params) {
+		searchQuery.AddMust(modifierQuery)
+	}
+
+	request := bleve.NewSearchRequestOptions(searchQuery, limit, 0, false)
+	request.SortBy([]string{"-CreateAt"})
+
+	result, err := b.postIndex.Search(request)
+	if err != nil {
+		return nil, model.NewAppError("BleveEngine.SearchPosts", "bleveengine.search_posts.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	postIds := make([]string, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		postIds = append(postIds, hit.ID)
+	}
+
+	return postIds, nil
+}
+
+// newTermsQuery returns a query that matches documents where field is exactly one of the given values.
+func newTermsQuery(field string, values []string) query.Query {
+	queries := make([]query.Query, 0, len(values))
+	for _, value := range values {
+		termQuery := bleve.NewTermQuery(value)
+		termQuery.SetField(field)
+		queries = append(queries, termQuery)
+	}
+
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+// newSearchModifierQueries returns the queries for the date, has: and is: modifiers of a search.
+func newSearchModifierQueries(params *model.SearchParams) []query.Query {
+	queries := []query.Query{}
+
+	newDateQuery := func(start int64, end int64) query.Query {
+		var min, max *float64
+		if start != 0 {
+			value := float64(start)
+			min = &value
+		}
+		if end != 0 {
+			value := float64(end)
+			max = &value
+		}
+
+		dateQuery := bleve.NewNumericRangeQuery(min, max)
+		dateQuery.SetField("CreateAt")
+		return dateQuery
+	}
+
+	if start, end := params.GetOnDateMillis(); start != 0 {
+		queries = append(queries, newDateQuery(start, end))
+	}
+
+	if before := params.GetBeforeDateMillis(); before != 0 {
+		queries = append(queries, newDateQuery(0, before))
+	}
+
+	if after := params.GetAfterDateMillis(); after != 0 {
+		queries = append(queries, newDateQuery(after, 0))
+	}
+
+	newFlagQuery := func(field string) query.Query {
+		flagQuery := bleve.NewBoolFieldQuery(true)
+		flagQuery.SetField(field)
+		return flagQuery
+	}
+
+	if params.HasFile {
+		queries = append(queries, newFlagQuery("HasFile"))
+	}
+
+	if params.HasLink {
+		queries = append(queries, newFlagQuery("HasLink"))
+	}
+
+	if params.IsPinned {
+		queries = append(queries, newFlagQuery("IsPinned"))
+	}
+
+	return queries
+}
+
+// newSearchTermsQuery turns the terms of a search into a query. Quoted terms are matched as phrases and terms ending
+// in * are matched as prefixes. It returns nil if there are no terms to search for.
+func newSearchTermsQuery(terms string, isHashtag bool, orTerms bool) query.Query {
+	queries := []query.Query{}
+
+	for _, term := range splitSearchTerms(terms) {
+		var termQuery query.Query
+
+		if isHashtag {
+			hashtagQuery := bleve.NewTermQuery(strings.ToLower(term))
+			hashtagQuery.SetField("Hashtags")
+			termQuery = hashtagQuery
+		} else if strings.HasPrefix(term, "\"") && strings.HasSuffix(term, "\"") && len(term) > 1 {
+			phraseQuery := bleve.NewMatchPhraseQuery(strings.Trim(term, "\""))
+			phraseQuery.SetField("Message")
+			termQuery = phraseQuery
+		} else if strings.HasSuffix(term, "*") {
+			prefix := strings.ToLower(strings.TrimRight(term, "*"))
+			if prefix == "" {
+				continue
+			}
+
+			prefixQuery := bleve.NewPrefixQuery(prefix)
+			prefixQuery.SetField("Message")
+			termQuery = prefixQuery
+		} else {
+			matchQuery := bleve.NewMatchQuery(term)
+			matchQuery.SetField("Message")
+			matchQuery.SetOperator(query.MatchQueryOperatorAnd)
+			termQuery = matchQuery
+		}
+
+		queries = append(queries, termQuery)
+	}
+
+	if len(queries) == 0 {
+		return nil
+	} else if orTerms {
+		return bleve.NewDisjunctionQuery(queries...)
+	} else {
+		return bleve.NewConjunctionQuery(queries...)
+	}
+}
+
+// splitSearchTerms splits search terms on whitespace while keeping quoted phrases together, quotes included.
+func splitSearchTerms(terms string) []string {
+	words := []string{}
+
+	inQuotes := false
+	current := ""
+	for _, char := range terms {
+		if char == '"' {
+			if inQuotes {
+				words = append(words, current+"\"")
+				current = ""
+			} else {
+				if strings.TrimSpace(current) != "" {
+					words = append(words, strings.Fields(current)...)
+				}
+				current = "\""
+			}
+
+			inQuotes = !inQuotes
+		} else {
+			current += string(char)
+		}
+	}
+
+	if inQuotes {
+		// Treat an unterminated quote as a set of regular words
+		current = strings.TrimPrefix(current, "\"")
+	}
+
+	return append(words, strings.Fields(current)...)
+}
+
+// PurgeIndexes deletes everything from the index so that it can be rebuilt from scratch.
+func (b *BleveEngine) PurgeIndexes() *model.AppError {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.postIndex == nil {
+		return nil
+	}
+
+	if err := b.postIndex.Close(); err != nil {
+		return model.NewAppError("BleveEngine.PurgeIndexes", "bleveengine.purge_indexes.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	b.postIndex = nil
+
+	if err := os.RemoveAll(b.getPostIndexPath()); err != nil {
+		return model.NewAppError("BleveEngine.PurgeIndexes", "bleveengine.purge_indexes.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	index, err := createOrOpenIndex(b.getPostIndexPath(), getPostIndexMapping())
+	if err != nil {
+		return model.NewAppError("BleveEngine.PurgeIndexes", "bleveengine.start.open_index.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	b.postIndex = index
+
+	return nil
+}
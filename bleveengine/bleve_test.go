@@ -0,0 +1,296 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package bleveengine
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func setupEngine(t *testing.T) (*BleveEngine, func()) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	dir, err := ioutil.TempDir("", "bleveengine")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	indexDir := *utils.Cfg.BleveSettings.IndexDir
+	enableIndexing := *utils.Cfg.BleveSettings.EnableIndexing
+	enableSearching := *utils.Cfg.BleveSettings.EnableSearching
+
+	*utils.Cfg.BleveSettings.IndexDir = dir
+	*utils.Cfg.BleveSettings.EnableIndexing = true
+	*utils.Cfg.BleveSettings.EnableSearching = true
+
+	engine := &BleveEngine{}
+	if err := engine.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	return engine, func() {
+		engine.Stop()
+		os.RemoveAll(dir)
+
+		*utils.Cfg.BleveSettings.IndexDir = indexDir
+		*utils.Cfg.BleveSettings.EnableIndexing = enableIndexing
+		*utils.Cfg.BleveSettings.EnableSearching = enableSearching
+	}
+}
+
+func indexTestPost(t *testing.T, engine *BleveEngine, channelId string, userId string, createAt int64, message string) *model.Post {
+	post := &model.Post{
+		Id:        model.NewId(),
+		ChannelId: channelId,
+		UserId:    userId,
+		CreateAt:  createAt,
+		Message:   message,
+	}
+	post.Hashtags, _ = model.ParseHashtags(message)
+
+	if err := engine.IndexPost(post, model.NewId()); err != nil {
+		t.Fatal(err)
+	}
+
+	return post
+}
+
+func checkSearch(t *testing.T, engine *BleveEngine, channelIds []string, userIds []string, params *model.SearchParams, expected ...*model.Post) {
+	postIds, err := engine.SearchPosts(channelIds, userIds, params, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedIds := []string{}
+	for _, post := range expected {
+		expectedIds = append(expectedIds, post.Id)
+	}
+
+	if !reflect.DeepEqual(postIds, expectedIds) {
+		t.Fatalf("search for %v returned %v, expected %v", params.ToJson(), postIds, expectedIds)
+	}
+}
+
+func TestBleveEngineSearchPosts(t *testing.T) {
+	engine, teardown := setupEngine(t)
+	defer teardown()
+
+	channelId := model.NewId()
+	otherChannelId := model.NewId()
+	userId := model.NewId()
+	otherUserId := model.NewId()
+
+	p1 := indexTestPost(t, engine, channelId, userId, 1000, "the quick brown fox")
+	p2 := indexTestPost(t, engine, channelId, otherUserId, 2000, "a brown dog jumps over the fox #animals")
+	p3 := indexTestPost(t, engine, otherChannelId, userId, 3000, "quick thinking")
+
+	channelIds := []string{channelId, otherChannelId}
+
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "fox"}, p2, p1)
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "FOX BROWN"}, p2, p1)
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "quick fox"}, p1)
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "quick dog", OrTerms: true}, p3, p2, p1)
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "qui*"}, p3, p1)
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "\"brown fox\""}, p1)
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "\"the fox\""}, p2)
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "#Animals", IsHashtag: true}, p2)
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "animals", IsHashtag: true})
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "cat"})
+
+	checkSearch(t, engine, []string{channelId}, nil, &model.SearchParams{Terms: "quick"}, p1)
+	checkSearch(t, engine, channelIds, []string{userId}, &model.SearchParams{Terms: "brown"}, p1)
+	checkSearch(t, engine, []string{otherChannelId}, nil, &model.SearchParams{InChannels: []string{"other"}}, p3)
+	checkSearch(t, engine, []string{}, nil, &model.SearchParams{Terms: "fox"})
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{})
+}
+
+func TestBleveEngineSearchPostsModifiers(t *testing.T) {
+	engine, teardown := setupEngine(t)
+	defer teardown()
+
+	channelIds := []string{model.NewId()}
+
+	june14 := int64(1497441600000) // 2017-06-14 12:00 UTC
+	june15 := june14 + 24*60*60*1000
+	june16 := june15 + 24*60*60*1000
+
+	p1 := indexTestPost(t, engine, channelIds[0], model.NewId(), june14, "zebra apple")
+	p2 := indexTestPost(t, engine, channelIds[0], model.NewId(), june15, "zebra banana https://example.com")
+	p3 := indexTestPost(t, engine, channelIds[0], model.NewId(), june16, "zebra cherry")
+
+	p2.IsPinned = true
+	if err := engine.IndexPost(p2, model.NewId()); err != nil {
+		t.Fatal(err)
+	}
+
+	p3.FileIds = []string{model.NewId()}
+	if err := engine.IndexPost(p3, model.NewId()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, testCase := range []struct {
+		Params   *model.SearchParams
+		Expected []*model.Post
+	}{
+		{&model.SearchParams{Terms: "zebra"}, []*model.Post{p3, p2, p1}},
+		{&model.SearchParams{Terms: "zebra", ExcludedTerms: "banana"}, []*model.Post{p3, p1}},
+		{&model.SearchParams{Terms: "zebra", ExcludedTerms: "banana cherry"}, []*model.Post{p1}},
+		{&model.SearchParams{Terms: "zebra", ExcludedTerms: "\"zebra cherry\""}, []*model.Post{p2, p1}},
+		{&model.SearchParams{Terms: "zebra", OnDate: "2017-06-15"}, []*model.Post{p2}},
+		{&model.SearchParams{Terms: "zebra", BeforeDate: "2017-06-15"}, []*model.Post{p1}},
+		{&model.SearchParams{Terms: "zebra", AfterDate: "2017-06-15"}, []*model.Post{p3}},
+		{&model.SearchParams{Terms: "zebra", AfterDate: "2017-06-13", BeforeDate: "2017-06-16"}, []*model.Post{p2, p1}},
+		{&model.SearchParams{Terms: "zebra", HasFile: true}, []*model.Post{p3}},
+		{&model.SearchParams{Terms: "zebra", HasLink: true}, []*model.Post{p2}},
+		{&model.SearchParams{Terms: "zebra", IsPinned: true}, []*model.Post{p2}},
+		{&model.SearchParams{IsPinned: true}, []*model.Post{p2}},
+		{&model.SearchParams{ExcludedTerms: "zebra"}, []*model.Post{}},
+	} {
+		checkSearch(t, engine, channelIds, nil, testCase.Params, testCase.Expected...)
+	}
+}
+
+func TestBleveEngineUpdateAndDeletePost(t *testing.T) {
+	engine, teardown := setupEngine(t)
+	defer teardown()
+
+	channelIds := []string{model.NewId()}
+
+	post := indexTestPost(t, engine, channelIds[0], model.NewId(), 1000, "original message")
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "original"}, post)
+
+	post.Message = "edited message"
+	if err := engine.IndexPost(post, model.NewId()); err != nil {
+		t.Fatal(err)
+	}
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "original"})
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "edited"}, post)
+
+	if err := engine.DeletePost(post.Id); err != nil {
+		t.Fatal(err)
+	}
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "edited"})
+}
+
+func TestBleveEngineIndexPostOutOfOrder(t *testing.T) {
+	engine, teardown := setupEngine(t)
+	defer teardown()
+
+	channelIds := []string{model.NewId()}
+	teamId := model.NewId()
+
+	post := &model.Post{
+		Id:        model.NewId(),
+		ChannelId: channelIds[0],
+		UserId:    model.NewId(),
+		CreateAt:  1000,
+		UpdateAt:  3000,
+		Message:   "newer message",
+	}
+	if err := engine.IndexPost(post, teamId); err != nil {
+		t.Fatal(err)
+	}
+
+	older := *post
+	older.UpdateAt = 2000
+	older.Message = "older message"
+	if err := engine.IndexPost(&older, teamId); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := engine.IndexPosts([]*model.PostForIndexing{{Post: older, TeamId: teamId}}); err != nil {
+		t.Fatal(err)
+	}
+
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "older"})
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "newer"}, post)
+}
+
+func TestBleveEngineIndexPostsAndPurge(t *testing.T) {
+	engine, teardown := setupEngine(t)
+	defer teardown()
+
+	channelIds := []string{model.NewId()}
+
+	posts := []*model.PostForIndexing{}
+	for i := 0; i < 3; i++ {
+		posts = append(posts, &model.PostForIndexing{
+			Post: model.Post{
+				Id:        model.NewId(),
+				ChannelId: channelIds[0],
+				UserId:    model.NewId(),
+				CreateAt:  int64(i),
+				Message:   "batch message",
+			},
+			TeamId: model.NewId(),
+		})
+	}
+
+	posts = append(posts, &model.PostForIndexing{
+		Post: model.Post{
+			Id:        model.NewId(),
+			ChannelId: channelIds[0],
+			UserId:    model.NewId(),
+			Message:   "joined the batch message channel",
+			Type:      model.POST_JOIN_CHANNEL,
+		},
+	})
+
+	if err := engine.IndexPosts(posts); err != nil {
+		t.Fatal(err)
+	}
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "batch"}, &posts[2].Post, &posts[1].Post, &posts[0].Post)
+
+	if err := engine.PurgeIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	checkSearch(t, engine, channelIds, nil, &model.SearchParams{Terms: "batch"})
+
+	if !engine.IsSearchEnabled() {
+		t.Fatal("should still be able to search after purging the index")
+	}
+}
+
+func TestBleveEngineStartDisabled(t *testing.T) {
+	engine, teardown := setupEngine(t)
+	defer teardown()
+
+	engine.Stop()
+	*utils.Cfg.BleveSettings.EnableIndexing = false
+
+	if err := engine.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if engine.IsIndexingEnabled() || engine.IsSearchEnabled() {
+		t.Fatal("engine shouldn't be enabled")
+	}
+
+	if _, err := engine.SearchPosts([]string{model.NewId()}, nil, &model.SearchParams{Terms: "test"}, 100); err == nil {
+		t.Fatal("shouldn't be able to search without an index")
+	}
+}
+
+func TestSplitSearchTerms(t *testing.T) {
+	for terms, expected := range map[string][]string{
+		"":                           {},
+		"one two":                    {"one", "two"},
+		"  one   two ":               {"one", "two"},
+		"\"one two\" three":          {"\"one two\"", "three"},
+		"one \"two three\"":          {"one", "\"two three\""},
+		"one \"two three\" \"four\"": {"one", "\"two three\"", "\"four\""},
+		"one \"two three":            {"one", "two", "three"},
+	} {
+		if actual := splitSearchTerms(terms); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("splitSearchTerms(%q) returned %q, expected %q", terms, actual, expected)
+		}
+	}
+}
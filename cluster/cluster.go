@@ -0,0 +1,504 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package cluster implements high availability between servers that share a database. Each server listens on the
+// inter node address from the cluster settings and relays websocket events, cache invalidations, statuses and config
+// changes to the other servers over HTTPS. Requests between servers are signed with the cluster secret, which every
+// server has in its own config and which is never sent to the other servers.
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/einterfaces"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	CLUSTER_PING_INTERVAL           = 5 * time.Second
+	CLUSTER_REQUEST_TIMEOUT         = 5 * time.Second
+	CLUSTER_SERVER_SHUTDOWN_TIMEOUT = 5 * time.Second
+	CLUSTER_MAX_CLOCK_SKEW          = 5 * time.Minute
+	CLUSTER_SEND_RETRIES            = 3
+	CLUSTER_SEND_QUEUE_SIZE         = 4096
+
+	CLUSTER_ROUTE_PING    = "/cluster/ping"
+	CLUSTER_ROUTE_MESSAGE = "/cluster/message"
+	CLUSTER_ROUTE_STATS   = "/cluster/stats"
+	CLUSTER_ROUTE_LOGS    = "/cluster/logs"
+
+	HEADER_CLUSTER_TIMESTAMP = "X-Cluster-Timestamp"
+	HEADER_CLUSTER_SIGNATURE = "X-Cluster-Signature"
+)
+
+type InterClusterImpl struct {
+	id       string
+	hostname string
+	handlers map[string]func(msg *model.ClusterMessage) *model.AppError
+
+	lifecycleMutex sync.Mutex
+
+	mutex     sync.RWMutex
+	transport *http.Transport
+	server    *http.Server
+	address   string
+	nodes     []*clusterNode
+	stop      chan struct{}
+}
+
+// clusterNode is another server from the inter node URLs. A URL that turns out to point back at this server is
+// marked as self and skipped from then on.
+type clusterNode struct {
+	url   string
+	info  *model.ClusterInfo
+	queue chan *model.ClusterMessage
+
+	mutex sync.RWMutex
+	self  bool
+}
+
+func init() {
+	einterfaces.RegisterClusterInterface(NewInterClusterImpl())
+}
+
+func NewInterClusterImpl() *InterClusterImpl {
+	hostname, _ := os.Hostname()
+
+	c := &InterClusterImpl{
+		id:       model.NewId(),
+		hostname: hostname,
+	}
+	c.handlers = c.defaultHandlers()
+
+	return c
+}
+
+func (c *InterClusterImpl) StartInterNodeCommunication() {
+	c.lifecycleMutex.Lock()
+	defer c.lifecycleMutex.Unlock()
+
+	c.stopInterNodeCommunication()
+
+	settings := utils.Cfg.ClusterSettings
+	if !*settings.Enable {
+		return
+	}
+
+	if len(*settings.Secret) < model.CLUSTER_SETTINGS_SECRET_MINIMUM_LENGTH {
+		l4g.Error(utils.T("ent.cluster.secret.error"), model.CLUSTER_SETTINGS_SECRET_MINIMUM_LENGTH)
+		return
+	}
+
+	tlsConfig, err := loadTLSConfig(*settings.TLSCertFile, *settings.TLSKeyFile)
+	if err != nil {
+		l4g.Error(utils.T("ent.cluster.tls.error"), err.Error())
+		return
+	}
+
+	listener, err := net.Listen("tcp", *settings.InterNodeListenAddress)
+	if err != nil {
+		l4g.Error(utils.T("ent.cluster.listen.error"), *settings.InterNodeListenAddress, err.Error())
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(CLUSTER_ROUTE_PING, c.handlePing)
+	mux.HandleFunc(CLUSTER_ROUTE_MESSAGE, c.handleMessage)
+	mux.HandleFunc(CLUSTER_ROUTE_STATS, c.handleStats)
+	mux.HandleFunc(CLUSTER_ROUTE_LOGS, c.handleLogs)
+
+	server := &http.Server{Handler: mux, TLSConfig: tlsConfig}
+	stop := make(chan struct{})
+
+	nodes := make([]*clusterNode, 0, len(settings.InterNodeUrls))
+	seen := map[string]bool{}
+	for _, url := range settings.InterNodeUrls {
+		url = strings.TrimRight(strings.TrimSpace(url), "/")
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+
+		if !strings.HasPrefix(url, "https://") {
+			l4g.Error(utils.T("ent.cluster.insecure_url.error"), url)
+			continue
+		}
+
+		nodes = append(nodes, &clusterNode{
+			url:   url,
+			info:  &model.ClusterInfo{InterNodeUrl: url},
+			queue: make(chan *model.ClusterMessage, CLUSTER_SEND_QUEUE_SIZE),
+		})
+	}
+
+	c.mutex.Lock()
+	c.transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: tlsConfig.RootCAs, MinVersion: tls.VersionTLS12}}
+	c.server = server
+	c.address = listener.Addr().String()
+	c.nodes = nodes
+	c.stop = stop
+	c.mutex.Unlock()
+
+	go func() {
+		if err := server.ServeTLS(listener, "", ""); err != nil && err != http.ErrServerClosed {
+			l4g.Error(utils.T("ent.cluster.listen.error"), listener.Addr().String(), err.Error())
+		}
+	}()
+
+	for _, node := range nodes {
+		go c.sendLoop(node, stop)
+	}
+
+	go c.pingLoop(stop)
+
+	l4g.Info(utils.T("ent.cluster.starting.info"), c.address, c.hostname, c.id)
+}
+
+func (c *InterClusterImpl) StopInterNodeCommunication() {
+	c.lifecycleMutex.Lock()
+	defer c.lifecycleMutex.Unlock()
+
+	c.stopInterNodeCommunication()
+}
+
+func (c *InterClusterImpl) stopInterNodeCommunication() {
+	c.mutex.Lock()
+	server := c.server
+	address := c.address
+	stop := c.stop
+	transport := c.transport
+	c.transport = nil
+	c.server = nil
+	c.nodes = nil
+	c.stop = nil
+	c.mutex.Unlock()
+
+	if server == nil {
+		return
+	}
+
+	close(stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), CLUSTER_SERVER_SHUTDOWN_TIMEOUT)
+	defer cancel()
+
+	server.Shutdown(ctx)
+	transport.CloseIdleConnections()
+
+	l4g.Info(utils.T("ent.cluster.stopping.info"), address, c.hostname, c.id)
+}
+
+// loadTLSConfig loads the certificate that this server presents to the other servers. The other servers are trusted
+// if their certificates are signed by a system certificate authority or by the certificate in the cert file, which
+// lets the servers share a self signed certificate.
+func loadTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pem, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+	rootCAs.AppendCertsFromPEM(pem)
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      rootCAs,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// getClient returns a client for requests to the other servers that trusts the certificates of the cluster and gives
+// up after the timeout.
+func (c *InterClusterImpl) getClient(timeout time.Duration) *http.Client {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	client := &http.Client{Timeout: timeout}
+	if c.transport != nil {
+		client.Transport = c.transport
+	}
+
+	return client
+}
+
+func (c *InterClusterImpl) GetClusterId() string {
+	return c.id
+}
+
+// GetClusterInfos returns the info of this server followed by the info of every other server from the inter node
+// URLs, including the ones that have never answered a ping.
+func (c *InterClusterImpl) GetClusterInfos() []*model.ClusterInfo {
+	self := c.selfInfo()
+	infos := []*model.ClusterInfo{self}
+
+	for _, node := range c.getNodes() {
+		if node.isSelf() {
+			self.InterNodeUrl = node.url
+			continue
+		}
+
+		infos = append(infos, node.info.Copy())
+	}
+
+	return infos
+}
+
+func (c *InterClusterImpl) selfInfo() *model.ClusterInfo {
+	return &model.ClusterInfo{
+		Id:                 c.id,
+		Version:            model.CurrentVersion,
+		ConfigHash:         utils.CfgHash,
+		Hostname:           c.hostname,
+		LastSuccessfulPing: model.GetMillis(),
+		Alive:              1,
+	}
+}
+
+func (c *InterClusterImpl) getNodes() []*clusterNode {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.nodes
+}
+
+// getLiveNodes returns the other servers that answered the last ping.
+func (c *InterClusterImpl) getLiveNodes() []*clusterNode {
+	nodes := []*clusterNode{}
+
+	for _, node := range c.getNodes() {
+		if !node.isSelf() && node.info.IsAlive() {
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes
+}
+
+func (n *clusterNode) isSelf() bool {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+
+	return n.self
+}
+
+func (n *clusterNode) setSelf() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.self = true
+}
+
+func (c *InterClusterImpl) pingLoop(stop chan struct{}) {
+	c.pingNodes()
+
+	ticker := time.NewTicker(CLUSTER_PING_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.pingNodes()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (c *InterClusterImpl) pingNodes() {
+	var wg sync.WaitGroup
+
+	for _, node := range c.getNodes() {
+		if node.isSelf() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(node *clusterNode) {
+			defer wg.Done()
+			c.pingNode(node)
+		}(node)
+	}
+
+	wg.Wait()
+}
+
+// pingNode asks another server for its info, which also tells whether the server is alive and whether its URL
+// points back at this server.
+func (c *InterClusterImpl) pingNode(node *clusterNode) {
+	var info *model.ClusterInfo
+
+	body, err := c.doRequest(node.url, http.MethodGet, CLUSTER_ROUTE_PING, nil, *utils.Cfg.ClusterSettings.Secret)
+	if err == nil {
+		info = model.ClusterInfoFromJson(bytes.NewReader(body))
+	}
+
+	if info == nil {
+		if node.info.IsAlive() {
+			l4g.Info(utils.T("ent.cluster.ping_failed.info"), node.info.Hostname, node.url, node.info.Id)
+		}
+
+		node.info.SetAlive(false)
+		return
+	}
+
+	if info.Id == c.id {
+		node.setSelf()
+		l4g.Info(utils.T("ent.cluster.ping_success.info"), info.Hostname, node.url, info.Id, true)
+		return
+	}
+
+	wasAlive := node.info.IsAlive()
+
+	node.info.Mutex.Lock()
+	versionChanged := node.info.Version != info.Version
+	configChanged := node.info.ConfigHash != info.ConfigHash
+	node.info.Id = info.Id
+	node.info.Version = info.Version
+	node.info.ConfigHash = info.ConfigHash
+	node.info.Hostname = info.Hostname
+	node.info.LastSuccessfulPing = model.GetMillis()
+	node.info.Mutex.Unlock()
+
+	node.info.SetAlive(true)
+
+	if !wasAlive {
+		l4g.Info(utils.T("ent.cluster.ping_success.info"), info.Hostname, node.url, info.Id, false)
+	}
+
+	if versionChanged && info.Version != model.CurrentVersion {
+		l4g.Warn(utils.T("ent.cluster.incompatible.warn"), node.url)
+	}
+
+	if configChanged && info.ConfigHash != utils.CfgHash {
+		l4g.Warn(utils.T("ent.cluster.incompatible_config.warn"), node.url)
+	}
+}
+
+// doRequest sends a signed request to another server and returns the body of a successful response.
+func (c *InterClusterImpl) doRequest(url, method, uri string, body []byte, key string) ([]byte, *model.AppError) {
+	return c.doRequestWithClient(c.getClient(CLUSTER_REQUEST_TIMEOUT), url, method, uri, body, key)
+}
+
+func (c *InterClusterImpl) doRequestWithClient(client *http.Client, url, method, uri string, body []byte, key string) ([]byte, *model.AppError) {
+	start := time.Now()
+	if metrics := einterfaces.GetMetricsInterface(); metrics != nil {
+		metrics.IncrementClusterRequest()
+		defer func() {
+			metrics.ObserveClusterRequestDuration(time.Since(start).Seconds())
+		}()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, url+uri, reader)
+	if err != nil {
+		return nil, model.NewAppError("doRequest", "ent.cluster.request.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	timestamp := strconv.FormatInt(model.GetMillis(), 10)
+	req.Header.Set(model.HEADER_CLUSTER_ID, c.id)
+	req.Header.Set(HEADER_CLUSTER_TIMESTAMP, timestamp)
+	req.Header.Set(HEADER_CLUSTER_SIGNATURE, sign(key, method, uri, timestamp, body))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, model.NewAppError("doRequest", "ent.cluster.request.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, model.NewAppError("doRequest", "ent.cluster.request.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, model.NewAppError("doRequest", "ent.cluster.request.app_error", nil, "status="+strconv.Itoa(resp.StatusCode)+" "+string(data), resp.StatusCode)
+	}
+
+	return data, nil
+}
+
+// sign computes the signature of a request between servers. It covers the method, the request URI, the time the
+// request was sent and the body so that a request can't be altered or replayed much later.
+func sign(key, method, uri, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(method + "\n" + uri + "\n" + timestamp + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyRequest checks the signature of a request from another server and returns its body.
+func verifyRequest(r *http.Request) ([]byte, *model.AppError) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, model.NewAppError("verifyRequest", "ent.cluster.invalid_message.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	timestamp := r.Header.Get(HEADER_CLUSTER_TIMESTAMP)
+	millis, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, model.NewAppError("verifyRequest", "ent.cluster.invalid_signature.app_error", nil, "", http.StatusUnauthorized)
+	}
+
+	if skew := time.Duration(model.GetMillis()-millis) * time.Millisecond; skew > CLUSTER_MAX_CLOCK_SKEW || skew < -CLUSTER_MAX_CLOCK_SKEW {
+		return nil, model.NewAppError("verifyRequest", "ent.cluster.invalid_signature.app_error", nil, "timestamp="+timestamp, http.StatusUnauthorized)
+	}
+
+	key := *utils.Cfg.ClusterSettings.Secret
+	if len(key) < model.CLUSTER_SETTINGS_SECRET_MINIMUM_LENGTH {
+		return nil, model.NewAppError("verifyRequest", "ent.cluster.invalid_signature.app_error", nil, "", http.StatusUnauthorized)
+	}
+
+	expected := sign(key, r.Method, r.URL.RequestURI(), timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get(HEADER_CLUSTER_SIGNATURE))) {
+		return nil, model.NewAppError("verifyRequest", "ent.cluster.invalid_signature.app_error", nil, "", http.StatusUnauthorized)
+	}
+
+	return body, nil
+}
+
+func writeError(w http.ResponseWriter, err *model.AppError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.StatusCode)
+	w.Write([]byte(err.ToJson()))
+}
+
+func (c *InterClusterImpl) handlePing(w http.ResponseWriter, r *http.Request) {
+	if _, err := verifyRequest(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Write([]byte(c.selfInfo().ToJson()))
+}
@@ -0,0 +1,361 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package cluster
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+type testNode struct {
+	*InterClusterImpl
+	url      string
+	messages chan *model.ClusterMessage
+}
+
+func newBool(b bool) *bool       { return &b }
+func newString(s string) *string { return &s }
+
+// writeTestCertificate writes a self signed certificate for localhost that the servers of a test cluster share.
+func writeTestCertificate(t *testing.T, dir string) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, "cluster.crt")
+	keyFile := filepath.Join(dir, "cluster.key")
+
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certFile, keyFile
+}
+
+// setupCluster starts count servers on localhost that all have every server in their inter node URLs, like servers
+// sharing a config would. The servers record the messages they receive instead of handling them.
+func setupCluster(t *testing.T, count int) ([]*testNode, func()) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	dir, err := ioutil.TempDir("", "cluster")
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := writeTestCertificate(t, dir)
+
+	settings := utils.Cfg.ClusterSettings
+	secret := model.NewId() + model.NewId()
+
+	addresses := []string{}
+	urls := []string{}
+	for i := 0; i < count; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addresses = append(addresses, listener.Addr().String())
+		urls = append(urls, "https://"+listener.Addr().String())
+		listener.Close()
+	}
+
+	nodes := []*testNode{}
+	for i := 0; i < count; i++ {
+		node := &testNode{
+			InterClusterImpl: NewInterClusterImpl(),
+			url:              urls[i],
+			messages:         make(chan *model.ClusterMessage, 100),
+		}
+
+		for event := range node.handlers {
+			node.handlers[event] = func(msg *model.ClusterMessage) *model.AppError {
+				node.messages <- msg
+				return nil
+			}
+		}
+
+		utils.Cfg.ClusterSettings = model.ClusterSettings{
+			Enable:                 newBool(true),
+			InterNodeListenAddress: newString(addresses[i]),
+			InterNodeUrls:          urls,
+			Secret:                 newString(secret),
+			TLSCertFile:            newString(certFile),
+			TLSKeyFile:             newString(keyFile),
+		}
+		node.StartInterNodeCommunication()
+
+		nodes = append(nodes, node)
+	}
+
+	teardown := func() {
+		for _, node := range nodes {
+			node.StopInterNodeCommunication()
+		}
+
+		utils.Cfg.ClusterSettings = settings
+		os.RemoveAll(dir)
+	}
+
+	for _, node := range nodes {
+		for i := 0; len(node.getLiveNodes()) != count-1; i++ {
+			if i == 50 {
+				teardown()
+				t.Fatal("servers should have found each other")
+			}
+
+			time.Sleep(100 * time.Millisecond)
+			node.pingNodes()
+		}
+	}
+
+	return nodes, teardown
+}
+
+func receiveMessage(t *testing.T, node *testNode) *model.ClusterMessage {
+	select {
+	case msg := <-node.messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("should have received a message on " + node.url)
+		return nil
+	}
+}
+
+func TestClusterInfos(t *testing.T) {
+	nodes, teardown := setupCluster(t, 3)
+	defer teardown()
+
+	for _, node := range nodes {
+		infos := node.GetClusterInfos()
+		if len(infos) != 3 {
+			t.Fatal("should have the info of every server", len(infos))
+		}
+
+		if infos[0].Id != node.GetClusterId() || infos[0].InterNodeUrl != node.url || !infos[0].IsAlive() {
+			t.Fatal("should start with the info of this server", infos[0].ToJson())
+		}
+
+		for _, info := range infos[1:] {
+			if info.Id == "" || info.Id == node.GetClusterId() || !info.IsAlive() {
+				t.Fatal("should have the info of the other servers", info.ToJson())
+			}
+
+			if info.Version != model.CurrentVersion || info.ConfigHash != utils.CfgHash || info.LastSuccessfulPing == 0 {
+				t.Fatal("should have filled in the info from the ping", info.ToJson())
+			}
+		}
+	}
+}
+
+func TestClusterMessages(t *testing.T) {
+	nodes, teardown := setupCluster(t, 3)
+	defer teardown()
+
+	nodes[0].InvalidateCacheForChannelByName("teamid", "town-square")
+	nodes[0].Publish(model.NewWebSocketEvent(model.WEBSOCKET_EVENT_TYPING, "", "channelid", "", nil))
+	nodes[0].UpdateStatus(&model.Status{UserId: "userid", Status: model.STATUS_AWAY})
+
+	for _, node := range nodes[1:] {
+		msg := receiveMessage(t, node)
+		if msg.Event != model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_BY_NAME || msg.Data != "town-square" || msg.Props["team_id"] != "teamid" {
+			t.Fatal("should have received the invalidation", msg.ToJson())
+		}
+
+		msg = receiveMessage(t, node)
+		if event := model.WebSocketEventFromJson(strings.NewReader(msg.Data)); msg.Event != model.CLUSTER_EVENT_PUBLISH || event == nil || event.Broadcast.ChannelId != "channelid" {
+			t.Fatal("should have received the websocket event", msg.ToJson())
+		}
+
+		msg = receiveMessage(t, node)
+		if status := model.StatusFromJson(strings.NewReader(msg.Data)); msg.Event != model.CLUSTER_EVENT_UPDATE_STATUS || status == nil || status.Status != model.STATUS_AWAY {
+			t.Fatal("should have received the status", msg.ToJson())
+		}
+	}
+
+	if len(nodes[0].messages) != 0 {
+		t.Fatal("shouldn't have sent the messages to itself")
+	}
+}
+
+func TestClusterConfigChanged(t *testing.T) {
+	nodes, teardown := setupCluster(t, 2)
+	defer teardown()
+
+	previousConfig := utils.Cfg
+	newConfig := model.ConfigFromJson(strings.NewReader(previousConfig.ToJson()))
+	newConfig.TeamSettings.SiteName = "cluster"
+	newConfig.SqlSettings.AtRestEncryptKey = model.NewId()
+
+	if err := nodes[0].ConfigChanged(previousConfig, newConfig, true); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := receiveMessage(t, nodes[1])
+	cfg := model.ConfigFromJson(strings.NewReader(msg.Data))
+	if msg.Event != model.CLUSTER_EVENT_CONFIG_CHANGED || cfg == nil || cfg.TeamSettings.SiteName != "cluster" {
+		t.Fatal("should have received the new config", msg.Event)
+	}
+
+	if cfg.SqlSettings.AtRestEncryptKey != model.FAKE_SETTING || cfg.SqlSettings.DataSource != model.FAKE_SETTING || *cfg.ClusterSettings.Secret != model.FAKE_SETTING {
+		t.Fatal("shouldn't have sent the secrets")
+	}
+
+	if newConfig.SqlSettings.AtRestEncryptKey == model.FAKE_SETTING || *previousConfig.ClusterSettings.Secret == model.FAKE_SETTING {
+		t.Fatal("shouldn't have sanitized the config of this server")
+	}
+}
+
+func TestClusterNodeDown(t *testing.T) {
+	nodes, teardown := setupCluster(t, 3)
+	defer teardown()
+
+	nodes[2].StopInterNodeCommunication()
+	nodes[0].pingNodes()
+
+	if len(nodes[0].getLiveNodes()) != 1 {
+		t.Fatal("should have noticed that the server is down")
+	}
+
+	for _, info := range nodes[0].GetClusterInfos() {
+		if info.Id == nodes[2].GetClusterId() && info.IsAlive() {
+			t.Fatal("should report the server as down")
+		}
+	}
+
+	nodes[0].InvalidateCacheForUser("userid")
+
+	if msg := receiveMessage(t, nodes[1]); msg.Event != model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_USER {
+		t.Fatal("should still send to the servers that are up")
+	}
+}
+
+func TestClusterRequestSignature(t *testing.T) {
+	nodes, teardown := setupCluster(t, 2)
+	defer teardown()
+
+	body := []byte((&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_USER, Data: "userid"}).ToJson())
+
+	post := func(id, key string, timestamp int64, body []byte) int {
+		req, _ := http.NewRequest(http.MethodPost, nodes[1].url+CLUSTER_ROUTE_MESSAGE, bytes.NewReader(body))
+		if key != "" {
+			req.Header.Set(model.HEADER_CLUSTER_ID, id)
+			req.Header.Set(HEADER_CLUSTER_TIMESTAMP, strconv.FormatInt(timestamp, 10))
+			req.Header.Set(HEADER_CLUSTER_SIGNATURE, sign(key, http.MethodPost, CLUSTER_ROUTE_MESSAGE, strconv.FormatInt(timestamp, 10), body))
+		}
+
+		resp, err := nodes[0].getClient(CLUSTER_REQUEST_TIMEOUT).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	key := *utils.Cfg.ClusterSettings.Secret
+	now := model.GetMillis()
+
+	if resp, err := http.Get(strings.Replace(nodes[1].url, "https://", "http://", 1) + CLUSTER_ROUTE_PING); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Fatal("should only accept requests over TLS")
+		}
+	}
+
+	if _, err := http.Get(nodes[1].url + CLUSTER_ROUTE_PING); err == nil {
+		t.Fatal("shouldn't trust the certificate of the cluster outside of it")
+	}
+
+	if code := post(nodes[0].GetClusterId(), "", now, body); code != http.StatusUnauthorized {
+		t.Fatal("should reject an unsigned request", code)
+	}
+
+	if code := post(nodes[0].GetClusterId(), model.NewId(), now, body); code != http.StatusUnauthorized {
+		t.Fatal("should reject a request signed with another key", code)
+	}
+
+	if code := post(nodes[0].GetClusterId(), key, now-int64(time.Hour/time.Millisecond), body); code != http.StatusUnauthorized {
+		t.Fatal("should reject an old request", code)
+	}
+
+	if code := post(nodes[1].GetClusterId(), key, now, body); code != http.StatusBadRequest {
+		t.Fatal("should reject a request from itself", code)
+	}
+
+	if code := post(nodes[0].GetClusterId(), key, now, []byte(`{"event":"junk"}`)); code != http.StatusBadRequest {
+		t.Fatal("should reject an unknown event", code)
+	}
+
+	if code := post(nodes[0].GetClusterId(), key, now, body); code != http.StatusOK {
+		t.Fatal("should accept a signed request", code)
+	}
+
+	if msg := receiveMessage(t, nodes[1]); msg.Data != "userid" {
+		t.Fatal("should have handled the message")
+	}
+}
+
+func TestDefaultHandlers(t *testing.T) {
+	c := NewInterClusterImpl()
+
+	userId := model.NewId()
+	status := &model.Status{UserId: userId, Status: model.STATUS_ONLINE}
+
+	if err := c.handlers[model.CLUSTER_EVENT_UPDATE_STATUS](&model.ClusterMessage{Event: model.CLUSTER_EVENT_UPDATE_STATUS, Data: status.ToJson()}); err != nil {
+		t.Fatal(err)
+	}
+
+	if cached := app.GetStatusFromCache(userId); cached == nil || cached.Status != model.STATUS_ONLINE {
+		t.Fatal("should have cached the status")
+	}
+
+	if err := c.handlers[model.CLUSTER_EVENT_UPDATE_STATUS](&model.ClusterMessage{Event: model.CLUSTER_EVENT_UPDATE_STATUS, Data: "junk"}); err == nil {
+		t.Fatal("should have failed to decode the status")
+	}
+
+	if err := c.handlers[model.CLUSTER_EVENT_PUBLISH](&model.ClusterMessage{Event: model.CLUSTER_EVENT_PUBLISH, Data: "junk"}); err == nil {
+		t.Fatal("should have failed to decode the event")
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package cluster
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func (c *InterClusterImpl) ClearSessionCacheForUser(userId string) {
+	c.send(&model.ClusterMessage{Event: model.CLUSTER_EVENT_CLEAR_SESSION_CACHE_FOR_USER, Data: userId})
+}
+
+func (c *InterClusterImpl) InvalidateCacheForUser(userId string) {
+	c.send(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_USER, Data: userId})
+}
+
+func (c *InterClusterImpl) InvalidateCacheForChannel(channelId string) {
+	c.send(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL, Data: channelId})
+}
+
+func (c *InterClusterImpl) InvalidateCacheForChannelByName(teamId, name string) {
+	c.send(&model.ClusterMessage{
+		Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_BY_NAME,
+		Data:  name,
+		Props: map[string]string{"team_id": teamId},
+	})
+}
+
+func (c *InterClusterImpl) InvalidateCacheForChannelMembers(channelId string) {
+	c.send(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_MEMBERS, Data: channelId})
+}
+
+func (c *InterClusterImpl) InvalidateCacheForChannelMembersNotifyProps(channelId string) {
+	c.send(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_MEMBERS_NOTIFY_PROPS, Data: channelId})
+}
+
+func (c *InterClusterImpl) InvalidateCacheForChannelPosts(channelId string) {
+	c.send(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_POSTS, Data: channelId})
+}
+
+func (c *InterClusterImpl) InvalidateCacheForWebhook(webhookId string) {
+	c.send(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_WEBHOOK, Data: webhookId})
+}
+
+func (c *InterClusterImpl) InvalidateCacheForReactions(postId string) {
+	c.send(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_REACTIONS, Data: postId})
+}
+
+func (c *InterClusterImpl) InvalidateCacheForRoles() {
+	c.send(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_ROLES})
+}
+
+func (c *InterClusterImpl) Publish(event *model.WebSocketEvent) {
+	c.send(&model.ClusterMessage{Event: model.CLUSTER_EVENT_PUBLISH, Data: event.ToJson()})
+}
+
+func (c *InterClusterImpl) UpdateStatus(status *model.Status) {
+	c.send(&model.ClusterMessage{Event: model.CLUSTER_EVENT_UPDATE_STATUS, Data: status.ToJson()})
+}
+
+func (c *InterClusterImpl) InvalidateAllCaches() *model.AppError {
+	c.send(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_ALL_CACHES})
+	return nil
+}
+
+// ConfigChanged sends the new config to the other servers when sendToOtherServer is set, waiting until each of them
+// has saved it, and restarts the inter node communication of this server if the cluster settings have changed. The
+// config is sanitized before it's sent so that secrets such as the data source and the cluster secret never leave this
+// server, and each server keeps its own values for them. The message is signed with the previous cluster secret since
+// the other servers don't receive a new one.
+func (c *InterClusterImpl) ConfigChanged(previousConfig *model.Config, newConfig *model.Config, sendToOtherServer bool) *model.AppError {
+	var result *model.AppError
+
+	if sendToOtherServer {
+		sanitized := model.ConfigFromJson(strings.NewReader(newConfig.ToJson()))
+		sanitized.Sanitize()
+
+		msg := &model.ClusterMessage{Event: model.CLUSTER_EVENT_CONFIG_CHANGED, Data: sanitized.ToJson()}
+
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+
+		for _, node := range c.getLiveNodes() {
+			wg.Add(1)
+			go func(node *clusterNode) {
+				defer wg.Done()
+
+				if err := c.sendToNode(node, msg, *previousConfig.ClusterSettings.Secret); err != nil {
+					mutex.Lock()
+					result = err
+					mutex.Unlock()
+				}
+			}(node)
+		}
+
+		wg.Wait()
+	}
+
+	if !reflect.DeepEqual(previousConfig.ClusterSettings, newConfig.ClusterSettings) {
+		l4g.Info(utils.T("ent.cluster.config_changed.info"), c.id)
+		go c.StartInterNodeCommunication()
+	}
+
+	return result
+}
+
+func (c *InterClusterImpl) GetClusterStats() ([]*model.ClusterStats, *model.AppError) {
+	stats := []*model.ClusterStats{}
+
+	for _, node := range c.getLiveNodes() {
+		body, err := c.doRequest(node.url, http.MethodGet, CLUSTER_ROUTE_STATS, nil, *utils.Cfg.ClusterSettings.Secret)
+		if err != nil {
+			l4g.Error(utils.T("ent.cluster.stats.error"), node.url, err.Error())
+			continue
+		}
+
+		if stat := model.ClusterStatsFromJson(bytes.NewReader(body)); stat != nil {
+			stats = append(stats, stat)
+		}
+	}
+
+	return stats, nil
+}
+
+func (c *InterClusterImpl) GetLogs(page, perPage int) ([]string, *model.AppError) {
+	lines := []string{}
+
+	client := c.getClient(time.Duration(*utils.Cfg.ServiceSettings.ClusterLogTimeoutMilliseconds) * time.Millisecond)
+	uri := CLUSTER_ROUTE_LOGS + "?page=" + strconv.Itoa(page) + "&per_page=" + strconv.Itoa(perPage)
+
+	for _, node := range c.getLiveNodes() {
+		body, err := c.doRequestWithClient(client, node.url, http.MethodGet, uri, nil, *utils.Cfg.ClusterSettings.Secret)
+		if err != nil {
+			l4g.Error(utils.T("ent.cluster.logs.error"), node.url, err.Error())
+			continue
+		}
+
+		lines = append(lines, model.ArrayFromJson(bytes.NewReader(body))...)
+	}
+
+	return lines, nil
+}
+
+// send queues a message for every other server that's alive. Each server has its own queue so a slow server doesn't
+// hold up the others while the order of the messages sent to a server is kept.
+func (c *InterClusterImpl) send(msg *model.ClusterMessage) {
+	for _, node := range c.getLiveNodes() {
+		select {
+		case node.queue <- msg:
+		default:
+			l4g.Error(utils.T("ent.cluster.queue_full.error"), node.url, msg.Event)
+		}
+	}
+}
+
+func (c *InterClusterImpl) sendLoop(node *clusterNode, stop chan struct{}) {
+	for {
+		select {
+		case msg := <-node.queue:
+			c.sendToNode(node, msg, *utils.Cfg.ClusterSettings.Secret)
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (c *InterClusterImpl) sendToNode(node *clusterNode, msg *model.ClusterMessage, key string) *model.AppError {
+	body := []byte(msg.ToJson())
+
+	var err *model.AppError
+	for retry := 1; retry <= CLUSTER_SEND_RETRIES; retry++ {
+		if _, err = c.doRequest(node.url, http.MethodPost, CLUSTER_ROUTE_MESSAGE, body, key); err == nil {
+			return nil
+		}
+
+		if retry < CLUSTER_SEND_RETRIES {
+			l4g.Debug(utils.T("ent.cluster.debug_fail.debug"), node.url, err.Error(), msg.Event, retry)
+			time.Sleep(time.Duration(retry*100) * time.Millisecond)
+		}
+	}
+
+	l4g.Error(utils.T("ent.cluster.final_fail.error"), node.url, err.Error(), msg.Event, CLUSTER_SEND_RETRIES)
+	return err
+}
+
+func (c *InterClusterImpl) defaultHandlers() map[string]func(msg *model.ClusterMessage) *model.AppError {
+	invalidate := func(f func(id string)) func(msg *model.ClusterMessage) *model.AppError {
+		return func(msg *model.ClusterMessage) *model.AppError {
+			f(msg.Data)
+			return nil
+		}
+	}
+
+	return map[string]func(msg *model.ClusterMessage) *model.AppError{
+		model.CLUSTER_EVENT_CLEAR_SESSION_CACHE_FOR_USER:                      invalidate(app.ClearSessionCacheForUserSkipClusterSend),
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_USER:                         invalidate(app.InvalidateCacheForUserSkipClusterSend),
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL:                      invalidate(app.InvalidateCacheForChannelSkipClusterSend),
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_MEMBERS:              invalidate(app.InvalidateCacheForChannelMembersSkipClusterSend),
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_MEMBERS_NOTIFY_PROPS: invalidate(app.InvalidateCacheForChannelMembersNotifyPropsSkipClusterSend),
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_POSTS:                invalidate(app.InvalidateCacheForChannelPostsSkipClusterSend),
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_WEBHOOK:                      invalidate(app.InvalidateCacheForWebhookSkipClusterSend),
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_REACTIONS:                    invalidate(app.InvalidateCacheForReactionsSkipClusterSend),
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_BY_NAME: func(msg *model.ClusterMessage) *model.AppError {
+			app.InvalidateCacheForChannelByNameSkipClusterSend(msg.Props["team_id"], msg.Data)
+			return nil
+		},
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_ROLES: func(msg *model.ClusterMessage) *model.AppError {
+			app.InvalidateCacheForRolesSkipClusterSend()
+			return nil
+		},
+		model.CLUSTER_EVENT_INVALIDATE_ALL_CACHES: func(msg *model.ClusterMessage) *model.AppError {
+			app.InvalidateAllCachesSkipSend()
+			return nil
+		},
+		model.CLUSTER_EVENT_PUBLISH: func(msg *model.ClusterMessage) *model.AppError {
+			event := model.WebSocketEventFromJson(strings.NewReader(msg.Data))
+			if event == nil {
+				return model.NewAppError("handlePublish", "ent.cluster.invalid_message.app_error", nil, "event="+msg.Event, http.StatusBadRequest)
+			}
+
+			app.PublishSkipClusterSend(event)
+			return nil
+		},
+		model.CLUSTER_EVENT_UPDATE_STATUS: func(msg *model.ClusterMessage) *model.AppError {
+			status := model.StatusFromJson(strings.NewReader(msg.Data))
+			if status == nil {
+				return model.NewAppError("handleUpdateStatus", "ent.cluster.invalid_message.app_error", nil, "event="+msg.Event, http.StatusBadRequest)
+			}
+
+			app.AddStatusCacheSkipClusterSend(status)
+			return nil
+		},
+		model.CLUSTER_EVENT_CONFIG_CHANGED: c.handleConfigChanged,
+	}
+}
+
+// handleConfigChanged saves the config sent by another server unless it matches the config of this server already.
+// The sanitized secrets are filled in from the config of this server.
+func (c *InterClusterImpl) handleConfigChanged(msg *model.ClusterMessage) *model.AppError {
+	cfg := model.ConfigFromJson(strings.NewReader(msg.Data))
+	if cfg == nil {
+		return model.NewAppError("handleConfigChanged", "ent.cluster.invalid_message.app_error", nil, "event="+msg.Event, http.StatusBadRequest)
+	}
+
+	cfg.SetDefaults()
+	utils.Desanitize(cfg)
+
+	if reflect.DeepEqual(cfg, utils.Cfg) {
+		return nil
+	}
+
+	previousConfig := utils.Cfg
+	if err := app.SaveConfigSkipClusterSend(cfg); err != nil {
+		return err
+	}
+
+	return c.ConfigChanged(previousConfig, utils.Cfg, false)
+}
+
+func (c *InterClusterImpl) handleMessage(w http.ResponseWriter, r *http.Request) {
+	body, err := verifyRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if r.Header.Get(model.HEADER_CLUSTER_ID) == c.id {
+		writeError(w, model.NewAppError("handleMessage", "ent.cluster.self_message.app_error", nil, "", http.StatusBadRequest))
+		return
+	}
+
+	msg := model.ClusterMessageFromJson(bytes.NewReader(body))
+	if msg == nil {
+		writeError(w, model.NewAppError("handleMessage", "ent.cluster.invalid_message.app_error", nil, "", http.StatusBadRequest))
+		return
+	}
+
+	handler, ok := c.handlers[msg.Event]
+	if !ok {
+		writeError(w, model.NewAppError("handleMessage", "ent.cluster.unknown_event.app_error", nil, "event="+msg.Event, http.StatusBadRequest))
+		return
+	}
+
+	if err := handler(msg); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Write([]byte(model.MapToJson(map[string]string{model.STATUS: model.STATUS_OK})))
+}
+
+func (c *InterClusterImpl) handleStats(w http.ResponseWriter, r *http.Request) {
+	if _, err := verifyRequest(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	stats := &model.ClusterStats{
+		Id:                        c.id,
+		TotalWebsocketConnections: app.TotalWebsocketConnections(),
+	}
+
+	if app.Srv != nil && app.Srv.Store != nil {
+		stats.TotalMasterDbConnections = app.Srv.Store.TotalMasterDbConnections()
+		stats.TotalReadDbConnections = app.Srv.Store.TotalReadDbConnections()
+	}
+
+	w.Write([]byte(stats.ToJson()))
+}
+
+func (c *InterClusterImpl) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if _, err := verifyRequest(r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+
+	lines, err := app.GetLogsSkipSend(page, perPage)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Write([]byte(model.ArrayToJson(lines)))
+}
@@ -278,8 +278,8 @@ func restoreChannelsCmdF(cmd *cobra.Command, args []string) error {
 			CommandPrintErrorln("Unable to find channel '" + args[i] + "'")
 			continue
 		}
-		if result := <-app.Srv.Store.Channel().SetDeleteAt(channel.Id, 0, model.GetMillis()); result.Err != nil {
-			CommandPrintErrorln("Unable to restore channel '" + args[i] + "'")
+		if _, err := app.RestoreChannel(channel, ""); err != nil {
+			CommandPrintErrorln("Unable to restore channel '" + args[i] + "'. Error: " + err.Error())
 		}
 	}
 
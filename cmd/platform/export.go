@@ -0,0 +1,55 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/primefour/servers/app"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export data.",
+}
+
+var bulkExportCmd = &cobra.Command{
+	Use:     "bulk [file]",
+	Short:   "Export bulk data.",
+	Long:    "Export data to a file in the Mattermost Bulk Import format.",
+	Example: "  export bulk bulk_data.json",
+	RunE:    bulkExportCmdF,
+}
+
+func init() {
+	exportCmd.AddCommand(
+		bulkExportCmd,
+	)
+}
+
+func bulkExportCmdF(cmd *cobra.Command, args []string) error {
+	initDBCommandContextCobra(cmd)
+
+	if len(args) != 1 {
+		return errors.New("Incorrect number of arguments.")
+	}
+
+	fileWriter, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+	defer fileWriter.Close()
+
+	CommandPrettyPrintln("Running Bulk Export. This may take a long time.")
+
+	if err := app.BulkExport(fileWriter); err != nil {
+		return err
+	}
+
+	CommandPrettyPrintln("Finished Bulk Export.")
+	CommandPrettyPrintln("Passwords can't be exported, so imported users that don't use another sign-in method will need to reset theirs.")
+
+	return nil
+}
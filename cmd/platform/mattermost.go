@@ -12,12 +12,18 @@ import (
 	"github.com/spf13/cobra"
 
 	// Plugins
+	_ "github.com/primefour/servers/bleveengine"
+	_ "github.com/primefour/servers/cluster"
+	_ "github.com/primefour/servers/compliance"
+	_ "github.com/primefour/servers/ldap"
+	_ "github.com/primefour/servers/metrics"
+	_ "github.com/primefour/servers/mfa"
 	_ "github.com/primefour/servers/model/gitlab"
+	_ "github.com/primefour/servers/model/openid"
+	_ "github.com/primefour/servers/saml"
 
 	// Enterprise Deps
-	_ "github.com/dgryski/dgoogauth"
 	_ "github.com/go-ldap/ldap"
-	_ "github.com/mattermost/rsc/qr"
 )
 
 //ENTERPRISE_IMPORTS
@@ -34,7 +40,7 @@ func init() {
 
 	resetCmd.Flags().Bool("confirm", false, "Confirm you really want to delete everything and a DB backup has been performed.")
 
-	rootCmd.AddCommand(serverCmd, versionCmd, userCmd, teamCmd, licenseCmd, importCmd, resetCmd, channelCmd, rolesCmd, testCmd, ldapCmd, configCmd)
+	rootCmd.AddCommand(serverCmd, versionCmd, userCmd, teamCmd, licenseCmd, importCmd, exportCmd, resetCmd, channelCmd, rolesCmd, testCmd, ldapCmd, configCmd)
 }
 
 var rootCmd = &cobra.Command{
@@ -13,6 +13,7 @@ import (
 	"github.com/primefour/servers/api4"
 	"github.com/primefour/servers/app"
 	"github.com/primefour/servers/einterfaces"
+	"github.com/primefour/servers/jobs"
 	"github.com/primefour/servers/manualtesting"
 	"github.com/primefour/servers/model"
 	"github.com/primefour/servers/utils"
@@ -85,6 +86,14 @@ func runServer(configFileLocation string) {
 
 	app.ReloadConfig()
 
+	if err := app.MigrateRolePermissions(); err != nil {
+		l4g.Error(utils.T("api.role.migrate_permissions.error"), err.Error())
+	}
+
+	if err := app.MigrateChannelGuestRole(); err != nil {
+		l4g.Error(utils.T("api.role.migrate_channel_guest_role.error"), err.Error())
+	}
+
 	resetStatuses()
 
 	app.StartServer()
@@ -100,10 +109,15 @@ func runServer(configFileLocation string) {
 	go runDiagnosticsJob()
 
 	go runTokenCleanupJob()
+	go runUploadSessionCleanupJob()
 
-	if complianceI := einterfaces.GetComplianceInterface(); complianceI != nil {
-		complianceI.StartComplianceDailyJob()
-	}
+	app.StartSearchEngine()
+
+	app.InitJobs()
+	jobs.StartWorkers()
+	jobs.StartSchedulers()
+
+	app.InitPlugins()
 
 	if einterfaces.GetClusterInterface() != nil {
 		einterfaces.GetClusterInterface().StartInterNodeCommunication()
@@ -127,6 +141,13 @@ func runServer(configFileLocation string) {
 		einterfaces.GetMetricsInterface().StopServer()
 	}
 
+	app.ShutDownPlugins()
+
+	jobs.StopSchedulers()
+	jobs.StopWorkers()
+
+	app.StopSearchEngine()
+
 	app.StopServer()
 }
 
@@ -145,6 +166,11 @@ func runTokenCleanupJob() {
 	model.CreateRecurringTask("Token Cleanup", doTokenCleanup, time.Hour*1)
 }
 
+func runUploadSessionCleanupJob() {
+	app.CleanupUploadSessions()
+	model.CreateRecurringTask("Upload Session Cleanup", app.CleanupUploadSessions, time.Hour*1)
+}
+
 func resetStatuses() {
 	if result := <-app.Srv.Store.Status().ResetAll(); result.Err != nil {
 		l4g.Error(utils.T("mattermost.reset_status.error"), result.Err.Error())
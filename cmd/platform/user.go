@@ -127,6 +127,23 @@ var searchUserCmd = &cobra.Command{
 	RunE:    searchUserCmdF,
 }
 
+var userDemoteCmd = &cobra.Command{
+	Use:   "demote [users]",
+	Short: "Demote users to guests",
+	Long: `Demote users to guests. Guests can only see the channels they're members of and can only send direct messages to the people in those channels.
+Guest accounts must be enabled.`,
+	Example: "  user demote user1@mail.com user2",
+	RunE:    userDemoteCmdF,
+}
+
+var userPromoteCmd = &cobra.Command{
+	Use:     "promote [users]",
+	Short:   "Promote guests to users",
+	Long:    "Promote guests to regular users.",
+	Example: "  user promote guest1@mail.com guest2",
+	RunE:    userPromoteCmdF,
+}
+
 func init() {
 	userCreateCmd.Flags().String("username", "", "Username")
 	userCreateCmd.Flags().String("email", "", "Email")
@@ -153,6 +170,8 @@ func init() {
 		migrateAuthCmd,
 		verifyUserCmd,
 		searchUserCmd,
+		userDemoteCmd,
+		userPromoteCmd,
 	)
 }
 
@@ -451,6 +470,48 @@ func verifyUserCmdF(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func userDemoteCmdF(cmd *cobra.Command, args []string) error {
+	initDBCommandContextCobra(cmd)
+	if len(args) < 1 {
+		return errors.New("Enter at least one user.")
+	}
+
+	users := getUsersFromUserArgs(args)
+
+	for i, user := range users {
+		if user == nil {
+			CommandPrintErrorln("Unable to find user '" + args[i] + "'")
+			continue
+		}
+		if _, err := app.DemoteUserToGuest(user); err != nil {
+			CommandPrintErrorln("Unable to demote '" + args[i] + "'. Error: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+func userPromoteCmdF(cmd *cobra.Command, args []string) error {
+	initDBCommandContextCobra(cmd)
+	if len(args) < 1 {
+		return errors.New("Enter at least one user.")
+	}
+
+	users := getUsersFromUserArgs(args)
+
+	for i, user := range users {
+		if user == nil {
+			CommandPrintErrorln("Unable to find user '" + args[i] + "'")
+			continue
+		}
+		if _, err := app.PromoteGuestToUser(user); err != nil {
+			CommandPrintErrorln("Unable to promote '" + args[i] + "'. Error: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
 func searchUserCmdF(cmd *cobra.Command, args []string) error {
 	initDBCommandContextCobra(cmd)
 	if len(args) < 1 {
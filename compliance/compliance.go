@@ -0,0 +1,188 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package compliance exports the posts matched by a compliance report to a zip file in the compliance directory, where
+// it can be downloaded through the compliance API.
+package compliance
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/einterfaces"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	// The most rows ComplianceStore.ComplianceExport returns for a single report
+	COMPLIANCE_EXPORT_LIMIT = 30000
+
+	COMPLIANCE_EXPORT_POSTS_FILE = "posts.csv"
+	COMPLIANCE_EXPORT_META_FILE  = "meta.json"
+)
+
+type ComplianceExporter struct{}
+
+// ComplianceExportMeta describes an export and is written alongside the exported posts.
+type ComplianceExportMeta struct {
+	Id         string `json:"id"`
+	JobName    string `json:"job_name"`
+	Type       string `json:"type"`
+	Desc       string `json:"desc"`
+	UserId     string `json:"user_id"`
+	StartAt    int64  `json:"start_at"`
+	EndAt      int64  `json:"end_at"`
+	Keywords   string `json:"keywords"`
+	Emails     string `json:"emails"`
+	Count      int    `json:"count"`
+	Truncated  bool   `json:"truncated"`
+	ExportedAt int64  `json:"exported_at"`
+}
+
+func init() {
+	einterfaces.RegisterComplianceInterface(&ComplianceExporter{})
+}
+
+// RunComplianceJob exports the posts matching the report's date range, keywords and emails, and records the outcome on
+// the report.
+func (me *ComplianceExporter) RunComplianceJob(job *model.Compliance) *model.AppError {
+	filePath := getComplianceFilePath(job)
+	logParams := map[string]interface{}{"JobName": job.JobName(), "FilePath": filePath}
+
+	l4g.Info(utils.T("ent.compliance.run_started.info", logParams))
+
+	job.Status = model.COMPLIANCE_STATUS_RUNNING
+	if result := <-app.Srv.Store.Compliance().Update(job); result.Err != nil {
+		return result.Err
+	}
+
+	var posts []*model.CompliancePost
+	if result := <-app.Srv.Store.Compliance().ComplianceExport(job); result.Err != nil {
+		return failComplianceJob(job, logParams, result.Err)
+	} else {
+		posts = result.Data.([]*model.CompliancePost)
+	}
+
+	if len(posts) >= COMPLIANCE_EXPORT_LIMIT {
+		l4g.Warn(utils.T("ent.compliance.run_limit.warning", logParams))
+	}
+
+	if err := writeComplianceFile(filePath, job, posts); err != nil {
+		return failComplianceJob(job, logParams, err)
+	}
+
+	job.Status = model.COMPLIANCE_STATUS_FINISHED
+	job.Count = len(posts)
+	if result := <-app.Srv.Store.Compliance().Update(job); result.Err != nil {
+		return result.Err
+	}
+
+	logParams["Count"] = job.Count
+	l4g.Info(utils.T("ent.compliance.run_finished.info", logParams))
+
+	return nil
+}
+
+func getComplianceFilePath(job *model.Compliance) string {
+	return *utils.Cfg.ComplianceSettings.Directory + "compliance/" + job.JobName() + ".zip"
+}
+
+func failComplianceJob(job *model.Compliance, logParams map[string]interface{}, err *model.AppError) *model.AppError {
+	l4g.Error(utils.T("ent.compliance.run_failed.error", logParams))
+
+	job.Status = model.COMPLIANCE_STATUS_FAILED
+	if result := <-app.Srv.Store.Compliance().Update(job); result.Err != nil {
+		l4g.Error(result.Err.Error())
+	}
+
+	return err
+}
+
+// writeComplianceFile writes the export to a temporary file first so that a partial zip is never served as a finished
+// report.
+func writeComplianceFile(filePath string, job *model.Compliance, posts []*model.CompliancePost) *model.AppError {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0774); err != nil {
+		return model.NewAppError("writeComplianceFile", "compliance.write_file.create_directory.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	tmpPath := filePath + ".tmp"
+
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return model.NewAppError("writeComplianceFile", "compliance.write_file.create_file.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if err := writeComplianceZip(f, job, posts, model.GetMillis()); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return model.NewAppError("writeComplianceFile", "compliance.write_file.write_zip.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return model.NewAppError("writeComplianceFile", "compliance.write_file.write_zip.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return model.NewAppError("writeComplianceFile", "compliance.write_file.rename.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func writeComplianceZip(w io.Writer, job *model.Compliance, posts []*model.CompliancePost, exportedAt int64) error {
+	zipWriter := zip.NewWriter(w)
+
+	if postsFile, err := zipWriter.Create(COMPLIANCE_EXPORT_POSTS_FILE); err != nil {
+		return err
+	} else {
+		csvWriter := csv.NewWriter(postsFile)
+
+		if err := csvWriter.Write(model.CompliancePostHeader()); err != nil {
+			return err
+		}
+
+		for _, post := range posts {
+			if err := csvWriter.Write(post.Row()); err != nil {
+				return err
+			}
+		}
+
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return err
+		}
+	}
+
+	meta := &ComplianceExportMeta{
+		Id:         job.Id,
+		JobName:    job.JobName(),
+		Type:       job.Type,
+		Desc:       job.Desc,
+		UserId:     job.UserId,
+		StartAt:    job.StartAt,
+		EndAt:      job.EndAt,
+		Keywords:   job.Keywords,
+		Emails:     job.Emails,
+		Count:      len(posts),
+		Truncated:  len(posts) >= COMPLIANCE_EXPORT_LIMIT,
+		ExportedAt: exportedAt,
+	}
+
+	if metaFile, err := zipWriter.Create(COMPLIANCE_EXPORT_META_FILE); err != nil {
+		return err
+	} else if err := json.NewEncoder(metaFile).Encode(meta); err != nil {
+		return err
+	}
+
+	return zipWriter.Close()
+}
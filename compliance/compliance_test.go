@@ -0,0 +1,174 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package compliance
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/primefour/servers/model"
+)
+
+func newTestCompliance() *model.Compliance {
+	job := &model.Compliance{
+		Desc:     "2017-08-01",
+		Type:     model.COMPLIANCE_TYPE_DAILY,
+		UserId:   "system",
+		StartAt:  1501545600000,
+		EndAt:    1501631999999,
+		Keywords: "secret",
+		Emails:   "test@example.com",
+	}
+	job.PreSave()
+
+	return job
+}
+
+func newTestCompliancePost(message string) *model.CompliancePost {
+	return &model.CompliancePost{
+		TeamName:     "team",
+		ChannelName:  "town-square",
+		UserUsername: "user",
+		UserEmail:    "test@example.com",
+		PostId:       model.NewId(),
+		PostCreateAt: 1501545600001,
+		PostUpdateAt: 1501545600001,
+		PostMessage:  message,
+	}
+}
+
+func readZip(t *testing.T, data []byte) map[string][]byte {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string][]byte)
+	for _, file := range reader.File {
+		f, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if files[file.Name], err = ioutil.ReadAll(f); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+
+	return files
+}
+
+func TestWriteComplianceZip(t *testing.T) {
+	job := newTestCompliance()
+	posts := []*model.CompliancePost{
+		newTestCompliancePost("a secret message"),
+		newTestCompliancePost("another \"secret\",\nover two lines"),
+	}
+
+	var buf bytes.Buffer
+	if err := writeComplianceZip(&buf, job, posts, 1501632000000); err != nil {
+		t.Fatal(err)
+	}
+
+	files := readZip(t, buf.Bytes())
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %v", len(files))
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(files[COMPLIANCE_EXPORT_POSTS_FILE])).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 3 {
+		t.Fatalf("expected a header and 2 rows, got %v", len(rows))
+	}
+
+	header := model.CompliancePostHeader()
+	for i, column := range rows[0] {
+		if column != header[i] {
+			t.Fatalf("bad header column %v: %v", i, column)
+		}
+	}
+
+	for i, post := range posts {
+		if rows[i+1][7] != post.PostId || rows[i+1][14] != post.PostMessage {
+			t.Fatalf("bad row %v: %v", i, rows[i+1])
+		}
+	}
+
+	var meta ComplianceExportMeta
+	if err := json.Unmarshal(files[COMPLIANCE_EXPORT_META_FILE], &meta); err != nil {
+		t.Fatal(err)
+	}
+
+	if meta.Id != job.Id || meta.JobName != job.JobName() || meta.Keywords != "secret" || meta.Emails != "test@example.com" {
+		t.Fatal("bad meta", meta)
+	}
+
+	if meta.StartAt != job.StartAt || meta.EndAt != job.EndAt || meta.ExportedAt != 1501632000000 {
+		t.Fatal("bad meta times", meta)
+	}
+
+	if meta.Count != 2 || meta.Truncated {
+		t.Fatal("bad meta count", meta)
+	}
+}
+
+func TestWriteComplianceZipEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeComplianceZip(&buf, newTestCompliance(), nil, model.GetMillis()); err != nil {
+		t.Fatal(err)
+	}
+
+	files := readZip(t, buf.Bytes())
+
+	if rows, err := csv.NewReader(bytes.NewReader(files[COMPLIANCE_EXPORT_POSTS_FILE])).ReadAll(); err != nil {
+		t.Fatal(err)
+	} else if len(rows) != 1 {
+		t.Fatal("expected only the header")
+	}
+
+	var meta ComplianceExportMeta
+	if err := json.Unmarshal(files[COMPLIANCE_EXPORT_META_FILE], &meta); err != nil {
+		t.Fatal(err)
+	} else if meta.Count != 0 {
+		t.Fatal("bad meta count", meta)
+	}
+}
+
+func TestWriteComplianceFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compliance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	job := newTestCompliance()
+	filePath := filepath.Join(dir, "compliance", job.JobName()+".zip")
+
+	if err := writeComplianceFile(filePath, job, []*model.CompliancePost{newTestCompliancePost("message")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filePath + ".tmp"); !os.IsNotExist(err) {
+		t.Fatal("the temporary file should have been removed")
+	}
+
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if files := readZip(t, data); len(files) != 2 {
+		t.Fatal("expected both files in the zip")
+	}
+}
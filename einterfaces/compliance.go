@@ -8,7 +8,6 @@ import (
 )
 
 type ComplianceInterface interface {
-	StartComplianceDailyJob()
 	RunComplianceJob(job *model.Compliance) *model.AppError
 }
 
@@ -14,8 +14,6 @@ type LdapInterface interface {
 	SwitchToLdap(userId, ldapId, ldapPassword string) *model.AppError
 	ValidateFilter(filter string) *model.AppError
 	Syncronize() *model.AppError
-	StartLdapSyncJob()
-	SyncNow()
 	RunTest() *model.AppError
 	GetAllLdapUsers() ([]*model.User, *model.AppError)
 }
@@ -14,6 +14,15 @@ type OauthProvider interface {
 	GetAuthDataFromJson(data io.Reader) string
 }
 
+// OpenIdProvider is an OauthProvider that looks up its endpoints with OpenID Connect discovery and identifies users
+// by the id token returned alongside the access token. The id token must carry the nonce sent with the authorization
+// request.
+type OpenIdProvider interface {
+	OauthProvider
+	GetEndpoints(settings *model.SSOSettings) (*model.SSOSettings, *model.AppError)
+	GetUserData(settings *model.SSOSettings, accessResponse *model.AccessResponse, nonce string) ([]byte, *model.AppError)
+}
+
 var oauthProviders = make(map[string]OauthProvider)
 
 func RegisterOauthProvider(name string, newProvider OauthProvider) {
@@ -0,0 +1,32 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package einterfaces
+
+import (
+	"github.com/primefour/servers/model"
+)
+
+type SearchEngineInterface interface {
+	Start() *model.AppError
+	Stop() *model.AppError
+
+	IsIndexingEnabled() bool
+	IsSearchEnabled() bool
+
+	IndexPost(post *model.Post, teamId string) *model.AppError
+	IndexPosts(posts []*model.PostForIndexing) *model.AppError
+	DeletePost(postId string) *model.AppError
+	SearchPosts(channelIds []string, userIds []string, params *model.SearchParams, limit int) ([]string, *model.AppError)
+	PurgeIndexes() *model.AppError
+}
+
+var theSearchEngineInterface SearchEngineInterface
+
+func RegisterSearchEngineInterface(newInterface SearchEngineInterface) {
+	theSearchEngineInterface = newInterface
+}
+
+func GetSearchEngineInterface() SearchEngineInterface {
+	return theSearchEngineInterface
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/store"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	CANCEL_WATCHER_POLLING_INTERVAL = 5000
+	INTERRUPTED_JOB_TIMEOUT         = 1000 * 60 * 5 // 5 minutes
+)
+
+type JobServer struct {
+	Store store.Store
+
+	workers           map[string]model.Worker
+	schedulers        map[string]model.Scheduler
+	watcher           *Watcher
+	schedulersRunning bool
+}
+
+var Srv = JobServer{
+	workers:    make(map[string]model.Worker),
+	schedulers: make(map[string]model.Scheduler),
+}
+
+func CreateJob(jobType string, jobData map[string]string) (*model.Job, *model.AppError) {
+	job := model.Job{
+		Id:       model.NewId(),
+		Type:     jobType,
+		CreateAt: model.GetMillis(),
+		Status:   model.JOB_STATUS_PENDING,
+		Data:     jobData,
+	}
+
+	if result := <-Srv.Store.Job().Save(&job); result.Err != nil {
+		return nil, result.Err
+	}
+
+	PollNow()
+
+	return &job, nil
+}
+
+// ClaimJob moves a pending job to in progress. It returns false if another worker has already claimed the job or if
+// it was canceled before it could start.
+func ClaimJob(job *model.Job) (bool, *model.AppError) {
+	if result := <-Srv.Store.Job().UpdateStatusOptimistically(job.Id, model.JOB_STATUS_PENDING, model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+		return false, result.Err
+	} else if !result.Data.(bool) {
+		return false, nil
+	}
+
+	job.Status = model.JOB_STATUS_IN_PROGRESS
+	return true, nil
+}
+
+func SetJobProgress(job *model.Job, progress int64) *model.AppError {
+	job.Status = model.JOB_STATUS_IN_PROGRESS
+	job.Progress = progress
+
+	if result := <-Srv.Store.Job().UpdateOptimistically(job, model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+func SetJobSuccess(job *model.Job) *model.AppError {
+	job.Status = model.JOB_STATUS_SUCCESS
+	job.Progress = 100
+
+	if result := <-Srv.Store.Job().UpdateOptimistically(job, model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+		return result.Err
+	} else if !result.Data.(bool) {
+		// Cancellation was requested too late to stop the job, so it finished anyway
+		if result := <-Srv.Store.Job().UpdateOptimistically(job, model.JOB_STATUS_CANCEL_REQUESTED); result.Err != nil {
+			return result.Err
+		}
+	}
+
+	return nil
+}
+
+// SetJobError marks a job as failed and stores the error in its data so that it can be seen through the API.
+func SetJobError(job *model.Job, jobError *model.AppError) *model.AppError {
+	job.Status = model.JOB_STATUS_ERROR
+	if jobError != nil {
+		setJobDataError(job, jobError.Error())
+	}
+
+	if result := <-Srv.Store.Job().UpdateOptimistically(job, model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+		return result.Err
+	} else if !result.Data.(bool) {
+		if result := <-Srv.Store.Job().UpdateOptimistically(job, model.JOB_STATUS_CANCEL_REQUESTED); result.Err != nil {
+			return result.Err
+		} else if !result.Data.(bool) {
+			return model.NewAppError("Jobs.SetJobError", "jobs.set_job_error.update.error", nil, "id="+job.Id, http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+// setJobDataError stores message as the error in a job's data. The message is shortened if needed so that the data
+// still fits in the database.
+func setJobDataError(job *model.Job, message string) {
+	if job.Data == nil {
+		job.Data = make(model.StringMap)
+	}
+
+	job.Data["error"] = message
+	for excess := len(model.MapToJson(job.Data)) - model.JOB_DATA_MAX_LENGTH; excess > 0 && message != ""; excess = len(model.MapToJson(job.Data)) - model.JOB_DATA_MAX_LENGTH {
+		if excess >= len(message) {
+			message = ""
+		} else {
+			message = message[:len(message)-excess]
+			for !utf8.ValidString(message) {
+				message = message[:len(message)-1]
+			}
+		}
+
+		job.Data["error"] = message
+	}
+}
+
+func SetJobCanceled(job *model.Job) *model.AppError {
+	job.Status = model.JOB_STATUS_CANCELED
+
+	if result := <-Srv.Store.Job().UpdateStatus(job.Id, model.JOB_STATUS_CANCELED); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// RequestCancellation cancels a pending job immediately. A job that's already in progress is asked to stop, and its
+// worker will mark it as canceled once it does.
+func RequestCancellation(jobId string) *model.AppError {
+	if result := <-Srv.Store.Job().UpdateStatusOptimistically(jobId, model.JOB_STATUS_PENDING, model.JOB_STATUS_CANCELED); result.Err != nil {
+		return result.Err
+	} else if result.Data.(bool) {
+		return nil
+	}
+
+	if result := <-Srv.Store.Job().UpdateStatusOptimistically(jobId, model.JOB_STATUS_IN_PROGRESS, model.JOB_STATUS_CANCEL_REQUESTED); result.Err != nil {
+		return result.Err
+	} else if result.Data.(bool) {
+		return nil
+	}
+
+	return model.NewAppError("Jobs.RequestCancellation", "jobs.request_cancellation.status.error", nil, "id="+jobId, http.StatusBadRequest)
+}
+
+// RecoverInterruptedJobs finishes jobs that were left in progress when a server stopped without completing them so
+// that they don't stay in progress forever. When running in a cluster, jobs that are still reporting activity are left
+// alone since another server may be running them.
+func RecoverInterruptedJobs() {
+	staleBefore := model.GetMillis()
+	if *utils.Cfg.ClusterSettings.Enable {
+		staleBefore -= INTERRUPTED_JOB_TIMEOUT
+	}
+
+	for _, status := range []string{model.JOB_STATUS_IN_PROGRESS, model.JOB_STATUS_CANCEL_REQUESTED} {
+		result := <-Srv.Store.Job().GetAllByStatus(status)
+		if result.Err != nil {
+			l4g.Error(utils.T("jobs.recover_interrupted_jobs.error"), result.Err.Error())
+			continue
+		}
+
+		for _, job := range result.Data.([]*model.Job) {
+			if job.LastActivityAt >= staleBefore {
+				continue
+			}
+
+			l4g.Warn(utils.T("jobs.recover_interrupted_jobs.warn"), job.Id, job.Type)
+
+			var err *model.AppError
+			if status == model.JOB_STATUS_CANCEL_REQUESTED {
+				err = SetJobCanceled(job)
+			} else {
+				err = SetJobError(job, model.NewAppError("RecoverInterruptedJobs", "jobs.recover_interrupted_jobs.interrupted.app_error", nil, "id="+job.Id, http.StatusInternalServerError))
+			}
+
+			if err != nil {
+				l4g.Error(utils.T("jobs.recover_interrupted_jobs.error"), err.Error())
+			}
+		}
+	}
+}
+
+// CancellationWatcher closes cancelChan once cancellation of the job has been requested. It polls the database until
+// then or until ctx is done, and updates the job's LastActivityAt each time to show that the job is still running.
+func CancellationWatcher(ctx context.Context, jobId string, cancelChan chan interface{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(CANCEL_WATCHER_POLLING_INTERVAL * time.Millisecond):
+			if result := <-Srv.Store.Job().Get(jobId); result.Err != nil {
+				l4g.Error(utils.T("jobs.cancellation_watcher.get.error"), jobId, result.Err.Error())
+			} else if result.Data.(*model.Job).Status == model.JOB_STATUS_CANCEL_REQUESTED {
+				close(cancelChan)
+				return
+			} else if result := <-Srv.Store.Job().UpdateStatusOptimistically(jobId, model.JOB_STATUS_IN_PROGRESS, model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+				l4g.Error(utils.T("jobs.cancellation_watcher.update_activity.error"), jobId, result.Err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/store"
+	"github.com/primefour/servers/utils"
+)
+
+func Setup() {
+	if Srv.Store == nil {
+		utils.TranslationsPreInit()
+		utils.LoadConfig("config.json")
+		utils.InitTranslations(utils.Cfg.LocalizationSettings)
+		Srv.Store = store.NewSqlStore()
+		Srv.Store.MarkSystemRanUnitTests()
+	}
+}
+
+func getJob(t *testing.T, id string) *model.Job {
+	return store.Must(Srv.Store.Job().Get(id)).(*model.Job)
+}
+
+func TestSimpleWorkerSuccess(t *testing.T) {
+	Setup()
+
+	job, err := CreateJob(model.JOB_TYPE_BULK_IMPORT, map[string]string{"key": "value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Srv.Store.Job().Delete(job.Id)
+
+	ran := false
+	worker := NewSimpleWorker("Test", func(job *model.Job, cancel <-chan interface{}) *model.AppError {
+		ran = job.Data["key"] == "value"
+		return nil
+	})
+
+	worker.DoJob(job)
+
+	if !ran {
+		t.Fatal("should have run the handler with the job's data")
+	}
+
+	if received := getJob(t, job.Id); received.Status != model.JOB_STATUS_SUCCESS || received.Progress != 100 || received.StartAt == 0 {
+		t.Fatal("job should have succeeded")
+	}
+
+	// A finished job can't be claimed and run again
+	ran = false
+	worker.DoJob(job)
+
+	if ran {
+		t.Fatal("shouldn't have run the job twice")
+	}
+}
+
+func TestSimpleWorkerError(t *testing.T) {
+	Setup()
+
+	job, err := CreateJob(model.JOB_TYPE_BULK_IMPORT, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Srv.Store.Job().Delete(job.Id)
+
+	worker := NewSimpleWorker("Test", func(job *model.Job, cancel <-chan interface{}) *model.AppError {
+		return model.NewAppError("Test", "test.error", nil, "", http.StatusInternalServerError)
+	})
+
+	worker.DoJob(job)
+
+	if received := getJob(t, job.Id); received.Status != model.JOB_STATUS_ERROR || received.Data["error"] == "" {
+		t.Fatal("job should have failed with an error")
+	}
+}
+
+func TestRequestCancellation(t *testing.T) {
+	Setup()
+
+	job, err := CreateJob(model.JOB_TYPE_LDAP_SYNC, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Srv.Store.Job().Delete(job.Id)
+
+	if err := RequestCancellation(job.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if received := getJob(t, job.Id); received.Status != model.JOB_STATUS_CANCELED {
+		t.Fatal("pending job should have been canceled immediately")
+	}
+
+	if err := RequestCancellation(job.Id); err == nil {
+		t.Fatal("shouldn't be able to cancel a job that has finished")
+	}
+}
+
+func TestSetJobDataErrorTruncatesLongErrors(t *testing.T) {
+	job := &model.Job{Data: map[string]string{"key": "value"}}
+
+	setJobDataError(job, "short error")
+	if job.Data["error"] != "short error" || job.Data["key"] != "value" {
+		t.Fatal("should have stored a short error as it is")
+	}
+
+	setJobDataError(job, strings.Repeat("é", model.JOB_DATA_MAX_LENGTH))
+	if length := len(model.MapToJson(job.Data)); length > model.JOB_DATA_MAX_LENGTH {
+		t.Fatal("job data should have fit in the database", length)
+	} else if job.Data["error"] == "" || !utf8.ValidString(job.Data["error"]) {
+		t.Fatal("should have kept as much of the error as fits", job.Data["error"])
+	}
+}
+
+func TestRecoverInterruptedJobs(t *testing.T) {
+	Setup()
+
+	job, err := CreateJob(model.JOB_TYPE_BULK_IMPORT, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Srv.Store.Job().Delete(job.Id)
+
+	if claimed, err := ClaimJob(job); err != nil || !claimed {
+		t.Fatal("should have claimed the job", err)
+	}
+
+	enableCluster := *utils.Cfg.ClusterSettings.Enable
+	defer func() {
+		*utils.Cfg.ClusterSettings.Enable = enableCluster
+	}()
+
+	*utils.Cfg.ClusterSettings.Enable = true
+	RecoverInterruptedJobs()
+
+	if received := getJob(t, job.Id); received.Status != model.JOB_STATUS_IN_PROGRESS {
+		t.Fatal("shouldn't have recovered a job that another server may be running")
+	}
+
+	*utils.Cfg.ClusterSettings.Enable = false
+	RecoverInterruptedJobs()
+
+	if received := getJob(t, job.Id); received.Status != model.JOB_STATUS_ERROR || received.Data["error"] == "" {
+		t.Fatal("should have marked the interrupted job as failed")
+	}
+}
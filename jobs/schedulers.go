@@ -0,0 +1,135 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import (
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+func RegisterScheduler(jobType string, scheduler model.Scheduler) {
+	Srv.schedulers[jobType] = scheduler
+}
+
+func StartSchedulers() {
+	if !*utils.Cfg.JobSettings.RunScheduler {
+		return
+	}
+
+	l4g.Info(utils.T("jobs.schedulers.starting.info"))
+
+	for _, scheduler := range Srv.schedulers {
+		go scheduler.Run()
+	}
+
+	Srv.schedulersRunning = true
+}
+
+func StopSchedulers() {
+	if !Srv.schedulersRunning {
+		return
+	}
+
+	for _, scheduler := range Srv.schedulers {
+		scheduler.Stop()
+	}
+
+	Srv.schedulersRunning = false
+
+	l4g.Info(utils.T("jobs.schedulers.stopped.info"))
+}
+
+// PeriodicScheduler is a Scheduler that creates a job every time the interval returned by interval passes. Both
+// enabled and interval are checked again before every run so that they follow changes to the config. The first run is
+// timed from the last job of its type that succeeded so that restarting the server doesn't keep postponing it.
+type PeriodicScheduler struct {
+	name     string
+	jobType  string
+	enabled  func() bool
+	interval func() time.Duration
+	stop     chan bool
+	stopped  chan bool
+}
+
+func NewPeriodicScheduler(name string, jobType string, enabled func() bool, interval func() time.Duration) *PeriodicScheduler {
+	return &PeriodicScheduler{
+		name:     name,
+		jobType:  jobType,
+		enabled:  enabled,
+		interval: interval,
+		stop:     make(chan bool, 1),
+		stopped:  make(chan bool, 1),
+	}
+}
+
+func (scheduler *PeriodicScheduler) Run() {
+	l4g.Debug(utils.T("jobs.scheduler.started.debug"), scheduler.name)
+
+	defer func() {
+		l4g.Debug(utils.T("jobs.scheduler.finished.debug"), scheduler.name)
+		scheduler.stopped <- true
+	}()
+
+	wait := scheduler.timeUntilFirstRun()
+
+	for {
+		select {
+		case <-scheduler.stop:
+			return
+		case <-time.After(wait):
+			if scheduler.enabled() {
+				scheduler.scheduleJob()
+			}
+		}
+
+		wait = scheduler.interval()
+	}
+}
+
+func (scheduler *PeriodicScheduler) timeUntilFirstRun() time.Duration {
+	interval := scheduler.interval()
+
+	result := <-Srv.Store.Job().GetNewestJobByStatusAndType(model.JOB_STATUS_SUCCESS, scheduler.jobType)
+	if result.Err != nil {
+		l4g.Error(utils.T("jobs.scheduler.schedule_job.error"), scheduler.name, result.Err.Error())
+		return interval
+	} else if result.Data.(*model.Job) == nil {
+		return interval
+	}
+
+	return nextRunDelay(result.Data.(*model.Job).CreateAt, interval, model.GetMillis())
+}
+
+// nextRunDelay returns how long to wait from now until interval has passed since lastRun, both of which are in
+// milliseconds since the epoch. It returns 0 if that time has already passed.
+func nextRunDelay(lastRun int64, interval time.Duration, now int64) time.Duration {
+	delay := time.Duration(lastRun-now)*time.Millisecond + interval
+	if delay < 0 {
+		return 0
+	}
+
+	return delay
+}
+
+func (scheduler *PeriodicScheduler) Stop() {
+	scheduler.stop <- true
+	<-scheduler.stopped
+}
+
+func (scheduler *PeriodicScheduler) scheduleJob() {
+	// Don't pile up jobs if the previous one hasn't been picked up yet
+	if result := <-Srv.Store.Job().GetCountByStatusAndType(model.JOB_STATUS_PENDING, scheduler.jobType); result.Err != nil {
+		l4g.Error(utils.T("jobs.scheduler.schedule_job.error"), scheduler.name, result.Err.Error())
+		return
+	} else if result.Data.(int64) > 0 {
+		return
+	}
+
+	if _, err := CreateJob(scheduler.jobType, nil); err != nil {
+		l4g.Error(utils.T("jobs.scheduler.schedule_job.error"), scheduler.name, err.Error())
+	}
+}
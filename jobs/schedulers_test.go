@@ -0,0 +1,21 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRunDelay(t *testing.T) {
+	now := int64(10 * 60 * 60 * 1000)
+
+	if delay := nextRunDelay(now-int64(time.Hour/time.Millisecond), 3*time.Hour, now); delay != 2*time.Hour {
+		t.Fatal("should wait for the rest of the interval", delay)
+	}
+
+	if delay := nextRunDelay(now-int64(5*time.Hour/time.Millisecond), 3*time.Hour, now); delay != 0 {
+		t.Fatal("should run immediately when the interval has already passed", delay)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import (
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	WATCHER_POLLING_INTERVAL = 15000
+)
+
+// Watcher polls the database for pending jobs and hands each one to the worker registered for its type.
+type Watcher struct {
+	stop    chan bool
+	stopped chan bool
+	poll    chan bool
+}
+
+func NewWatcher() *Watcher {
+	return &Watcher{
+		stop:    make(chan bool, 1),
+		stopped: make(chan bool, 1),
+		poll:    make(chan bool, 1),
+	}
+}
+
+func (watcher *Watcher) Start() {
+	l4g.Debug(utils.T("jobs.watcher.started.debug"))
+
+	defer func() {
+		l4g.Debug(utils.T("jobs.watcher.finished.debug"))
+		watcher.stopped <- true
+	}()
+
+	for {
+		select {
+		case <-watcher.stop:
+			return
+		case <-watcher.poll:
+			watcher.PollAndNotify()
+		case <-time.After(WATCHER_POLLING_INTERVAL * time.Millisecond):
+			watcher.PollAndNotify()
+		}
+	}
+}
+
+func (watcher *Watcher) Stop() {
+	watcher.stop <- true
+	<-watcher.stopped
+}
+
+// PollNow makes the watcher check for pending jobs without waiting for the polling interval to pass.
+func (watcher *Watcher) PollNow() {
+	select {
+	case watcher.poll <- true:
+	default:
+	}
+}
+
+func (watcher *Watcher) PollAndNotify() {
+	result := <-Srv.Store.Job().GetAllByStatus(model.JOB_STATUS_PENDING)
+	if result.Err != nil {
+		l4g.Error(utils.T("jobs.watcher.poll.error"), result.Err.Error())
+		return
+	}
+
+	for _, job := range result.Data.([]*model.Job) {
+		worker, ok := Srv.workers[job.Type]
+		if !ok {
+			continue
+		}
+
+		// Workers that are busy will receive the job on a later poll
+		select {
+		case worker.JobChannel() <- *job:
+		default:
+		}
+	}
+}
+
+// PollNow asks the running watcher, if there is one, to check for pending jobs immediately.
+func PollNow() {
+	if Srv.watcher != nil {
+		Srv.watcher.PollNow()
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import (
+	"context"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+// JobHandler does the work for a single job. cancel is closed if cancellation of the job is requested while it's
+// running, and long running handlers should check it regularly and return early when it is.
+type JobHandler func(job *model.Job, cancel <-chan interface{}) *model.AppError
+
+func RegisterWorker(jobType string, worker model.Worker) {
+	Srv.workers[jobType] = worker
+}
+
+func GetWorker(jobType string) model.Worker {
+	return Srv.workers[jobType]
+}
+
+func StartWorkers() {
+	if !*utils.Cfg.JobSettings.RunJobs {
+		return
+	}
+
+	l4g.Info(utils.T("jobs.workers.starting.info"))
+
+	RecoverInterruptedJobs()
+
+	for _, worker := range Srv.workers {
+		go worker.Run()
+	}
+
+	Srv.watcher = NewWatcher()
+	go Srv.watcher.Start()
+}
+
+func StopWorkers() {
+	if Srv.watcher == nil {
+		return
+	}
+
+	Srv.watcher.Stop()
+	Srv.watcher = nil
+
+	for _, worker := range Srv.workers {
+		worker.Stop()
+	}
+
+	l4g.Info(utils.T("jobs.workers.stopped.info"))
+}
+
+// SimpleWorker is a Worker that runs each job it receives through a JobHandler and records the outcome.
+type SimpleWorker struct {
+	name    string
+	handler JobHandler
+	stop    chan bool
+	stopped chan bool
+	jobs    chan model.Job
+}
+
+func NewSimpleWorker(name string, handler JobHandler) *SimpleWorker {
+	return &SimpleWorker{
+		name:    name,
+		handler: handler,
+		stop:    make(chan bool, 1),
+		stopped: make(chan bool, 1),
+		jobs:    make(chan model.Job),
+	}
+}
+
+func (worker *SimpleWorker) Run() {
+	l4g.Debug(utils.T("jobs.worker.started.debug"), worker.name)
+
+	defer func() {
+		l4g.Debug(utils.T("jobs.worker.finished.debug"), worker.name)
+		worker.stopped <- true
+	}()
+
+	for {
+		select {
+		case <-worker.stop:
+			return
+		case job := <-worker.jobs:
+			worker.DoJob(&job)
+		}
+	}
+}
+
+func (worker *SimpleWorker) Stop() {
+	worker.stop <- true
+	<-worker.stopped
+}
+
+func (worker *SimpleWorker) JobChannel() chan<- model.Job {
+	return worker.jobs
+}
+
+func (worker *SimpleWorker) DoJob(job *model.Job) {
+	if claimed, err := ClaimJob(job); err != nil {
+		l4g.Error(utils.T("jobs.worker.claim_job.error"), worker.name, job.Id, err.Error())
+		return
+	} else if !claimed {
+		return
+	}
+
+	l4g.Info(utils.T("jobs.worker.do_job.info"), worker.name, job.Id)
+
+	cancelCtx, cancelCancelWatcher := context.WithCancel(context.Background())
+	cancelChan := make(chan interface{})
+	go CancellationWatcher(cancelCtx, job.Id, cancelChan)
+	defer cancelCancelWatcher()
+
+	jobErr := worker.handler(job, cancelChan)
+
+	select {
+	case <-cancelChan:
+		l4g.Info(utils.T("jobs.worker.do_job.canceled.info"), worker.name, job.Id)
+		if err := SetJobCanceled(job); err != nil {
+			l4g.Error(utils.T("jobs.worker.set_job_status.error"), worker.name, job.Id, err.Error())
+		}
+		return
+	default:
+	}
+
+	if jobErr != nil {
+		l4g.Error(utils.T("jobs.worker.do_job.error"), worker.name, job.Id, jobErr.Error())
+		if err := SetJobError(job, jobErr); err != nil {
+			l4g.Error(utils.T("jobs.worker.set_job_status.error"), worker.name, job.Id, err.Error())
+		}
+		return
+	}
+
+	l4g.Info(utils.T("jobs.worker.do_job.success.info"), worker.name, job.Id)
+	if err := SetJobSuccess(job); err != nil {
+		l4g.Error(utils.T("jobs.worker.set_job_status.error"), worker.name, job.Id, err.Error())
+	}
+}
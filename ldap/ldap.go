@@ -0,0 +1,435 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package ldap authenticates and synchronizes users against an AD/LDAP server configured in the LDAP settings.
+package ldap
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	goldap "github.com/go-ldap/ldap"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/einterfaces"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+type LdapProvider struct{}
+
+func init() {
+	einterfaces.RegisterLdapInterface(&LdapProvider{})
+}
+
+func checkLdapEnabled(where string) *model.AppError {
+	if !*utils.Cfg.LdapSettings.Enable {
+		return model.NewAppError(where, "ent.ldap.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	return nil
+}
+
+// connect opens a connection to the LDAP server using the configured connection security. The connection is not
+// bound to any user.
+func connect() (*goldap.Conn, *model.AppError) {
+	settings := utils.Cfg.LdapSettings
+	address := fmt.Sprintf("%v:%v", *settings.LdapServer, *settings.LdapPort)
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: *settings.SkipCertificateVerification,
+		ServerName:         *settings.LdapServer,
+	}
+
+	var conn *goldap.Conn
+	var err error
+	if *settings.ConnectionSecurity == model.CONN_SECURITY_TLS {
+		conn, err = goldap.DialTLS("tcp", address, tlsConfig)
+	} else {
+		conn, err = goldap.Dial("tcp", address)
+	}
+
+	if err != nil {
+		return nil, model.NewAppError("connect", "ent.ldap.do_login.unable_to_connect.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if *settings.ConnectionSecurity == model.CONN_SECURITY_STARTTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, model.NewAppError("connect", "ent.ldap.do_login.unable_to_connect.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	if *settings.QueryTimeout > 0 {
+		conn.SetTimeout(time.Duration(*settings.QueryTimeout) * time.Second)
+	}
+
+	return conn, nil
+}
+
+// connectAndBind opens a connection to the LDAP server that is bound as the configured bind user.
+func connectAndBind() (*goldap.Conn, *model.AppError) {
+	conn, err := connect()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(*utils.Cfg.LdapSettings.BindUsername, *utils.Cfg.LdapSettings.BindPassword); err != nil {
+		conn.Close()
+		return nil, model.NewAppError("connectAndBind", "ent.ldap.do_login.bind_admin_user.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return conn, nil
+}
+
+// checkEntryPassword binds as the given entry on a new connection to verify its password. Empty passwords are rejected
+// since most servers treat a bind without a password as an anonymous bind.
+func checkEntryPassword(entry *goldap.Entry, password string) *model.AppError {
+	if password == "" {
+		return model.NewAppError("checkEntryPassword", "ent.ldap.do_login.invalid_password.app_error", nil, "", http.StatusUnauthorized)
+	}
+
+	conn, err := connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		return model.NewAppError("checkEntryPassword", "ent.ldap.do_login.invalid_password.app_error", nil, err.Error(), http.StatusUnauthorized)
+	}
+
+	return nil
+}
+
+// getUserFilter restricts the given filter to the users allowed by the configured user filter.
+func getUserFilter(filter string) string {
+	userFilter := strings.TrimSpace(*utils.Cfg.LdapSettings.UserFilter)
+	if userFilter == "" {
+		return filter
+	}
+
+	if !strings.HasPrefix(userFilter, "(") {
+		userFilter = "(" + userFilter + ")"
+	}
+
+	return "(&" + filter + userFilter + ")"
+}
+
+func getIdFilter(id string) string {
+	return "(" + *utils.Cfg.LdapSettings.IdAttribute + "=" + goldap.EscapeFilter(id) + ")"
+}
+
+func getAllUsersFilter() string {
+	return "(" + *utils.Cfg.LdapSettings.IdAttribute + "=*)"
+}
+
+// getUserAttributes returns the attributes needed to build a user from an entry.
+func getUserAttributes() []string {
+	settings := utils.Cfg.LdapSettings
+
+	attributes := []string{}
+	for _, attribute := range []string{
+		*settings.IdAttribute,
+		*settings.UsernameAttribute,
+		*settings.EmailAttribute,
+		*settings.FirstNameAttribute,
+		*settings.LastNameAttribute,
+		*settings.NicknameAttribute,
+		*settings.PositionAttribute,
+	} {
+		if attribute != "" {
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	return attributes
+}
+
+func searchEntries(conn *goldap.Conn, filter string) ([]*goldap.Entry, *model.AppError) {
+	request := goldap.NewSearchRequest(
+		*utils.Cfg.LdapSettings.BaseDN,
+		goldap.ScopeWholeSubtree,
+		goldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		filter,
+		getUserAttributes(),
+		nil,
+	)
+
+	var result *goldap.SearchResult
+	var err error
+	if *utils.Cfg.LdapSettings.MaxPageSize > 0 {
+		result, err = conn.SearchWithPaging(request, uint32(*utils.Cfg.LdapSettings.MaxPageSize))
+	} else {
+		result, err = conn.Search(request)
+	}
+
+	if err != nil {
+		return nil, model.NewAppError("searchEntries", "ent.ldap.do_login.search_ldap_server.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return result.Entries, nil
+}
+
+// findEntry returns the entry with the given id that passes the user filter.
+func findEntry(conn *goldap.Conn, id string) (*goldap.Entry, *model.AppError) {
+	entries, err := searchEntries(conn, getUserFilter(getIdFilter(id)))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) > 1 {
+		return nil, model.NewAppError("findEntry", "ent.ldap.do_login.matched_to_many_users.app_error", nil, "", http.StatusBadRequest)
+	} else if len(entries) == 1 {
+		return entries[0], nil
+	}
+
+	// Distinguish between users that don't exist and users that are excluded by the filter
+	if *utils.Cfg.LdapSettings.UserFilter != "" {
+		if unfiltered, err := searchEntries(conn, getIdFilter(id)); err == nil && len(unfiltered) > 0 {
+			return nil, model.NewAppError("findEntry", "ent.ldap.do_login.user_filtered.app_error", nil, "", http.StatusBadRequest)
+		}
+	}
+
+	return nil, model.NewAppError("findEntry", "ent.ldap.do_login.user_not_registered.app_error", nil, "", http.StatusBadRequest)
+}
+
+// entryToUser maps an entry to a user using the attribute settings. Attributes that aren't configured are left empty.
+func entryToUser(entry *goldap.Entry) *model.User {
+	settings := utils.Cfg.LdapSettings
+
+	getAttribute := func(attribute string) string {
+		if attribute == "" {
+			return ""
+		}
+
+		return strings.TrimSpace(entry.GetAttributeValue(attribute))
+	}
+
+	authData := getAttribute(*settings.IdAttribute)
+
+	user := &model.User{
+		AuthService:   model.USER_AUTH_SERVICE_LDAP,
+		AuthData:      &authData,
+		Username:      model.CleanUsername(getAttribute(*settings.UsernameAttribute)),
+		Email:         strings.ToLower(getAttribute(*settings.EmailAttribute)),
+		FirstName:     getAttribute(*settings.FirstNameAttribute),
+		LastName:      getAttribute(*settings.LastNameAttribute),
+		Nickname:      getAttribute(*settings.NicknameAttribute),
+		Position:      getAttribute(*settings.PositionAttribute),
+		EmailVerified: true,
+	}
+
+	return user
+}
+
+// updateUserAttributes copies the attributes mapped from LDAP onto an existing user and reports whether anything
+// changed. Optional attributes are only copied when they are configured.
+func updateUserAttributes(user *model.User, ldapUser *model.User) bool {
+	settings := utils.Cfg.LdapSettings
+	changed := false
+
+	update := func(field *string, value string, attribute string) {
+		if attribute != "" && *field != value {
+			*field = value
+			changed = true
+		}
+	}
+
+	update(&user.Username, ldapUser.Username, *settings.UsernameAttribute)
+	update(&user.Email, ldapUser.Email, *settings.EmailAttribute)
+	update(&user.FirstName, ldapUser.FirstName, *settings.FirstNameAttribute)
+	update(&user.LastName, ldapUser.LastName, *settings.LastNameAttribute)
+	update(&user.Nickname, ldapUser.Nickname, *settings.NicknameAttribute)
+	update(&user.Position, ldapUser.Position, *settings.PositionAttribute)
+
+	return changed
+}
+
+func saveUser(user *model.User) (*model.User, *model.AppError) {
+	if result := <-app.Srv.Store.User().Update(user, true); result.Err != nil {
+		return nil, result.Err
+	} else {
+		app.InvalidateCacheForUser(user.Id)
+		return result.Data.([2]*model.User)[0], nil
+	}
+}
+
+func (me *LdapProvider) DoLogin(id string, password string) (*model.User, *model.AppError) {
+	if err := checkLdapEnabled("DoLogin"); err != nil {
+		return nil, err
+	}
+
+	conn, err := connectAndBind()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := findEntry(conn, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkEntryPassword(entry, password); err != nil {
+		return nil, err
+	}
+
+	ldapUser := entryToUser(entry)
+
+	if result := <-app.Srv.Store.User().GetByAuth(ldapUser.AuthData, model.USER_AUTH_SERVICE_LDAP); result.Err != nil {
+		if user, err := app.CreateUser(ldapUser); err != nil {
+			return nil, model.NewAppError("DoLogin", "ent.ldap.do_login.unable_to_create_user.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			return user, nil
+		}
+	} else {
+		user := result.Data.(*model.User)
+
+		if updateUserAttributes(user, ldapUser) {
+			if updatedUser, err := saveUser(user); err != nil {
+				l4g.Error(utils.T("ent.ldap.do_login.update_user.error"), user.Id, err.Error())
+			} else {
+				user = updatedUser
+			}
+		}
+
+		return user, nil
+	}
+}
+
+func (me *LdapProvider) GetUser(id string) (*model.User, *model.AppError) {
+	if err := checkLdapEnabled("GetUser"); err != nil {
+		return nil, err
+	}
+
+	conn, err := connectAndBind()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if entry, err := findEntry(conn, id); err != nil {
+		return nil, err
+	} else {
+		return entryToUser(entry), nil
+	}
+}
+
+func (me *LdapProvider) CheckPassword(id string, password string) *model.AppError {
+	if err := checkLdapEnabled("CheckPassword"); err != nil {
+		return err
+	}
+
+	conn, err := connectAndBind()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if entry, err := findEntry(conn, id); err != nil {
+		return err
+	} else {
+		return checkEntryPassword(entry, password)
+	}
+}
+
+func (me *LdapProvider) SwitchToLdap(userId, ldapId, ldapPassword string) *model.AppError {
+	if err := checkLdapEnabled("SwitchToLdap"); err != nil {
+		return err
+	}
+
+	conn, err := connectAndBind()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	entry, err := findEntry(conn, ldapId)
+	if err != nil {
+		return err
+	}
+
+	if err := checkEntryPassword(entry, ldapPassword); err != nil {
+		return err
+	}
+
+	ldapUser := entryToUser(entry)
+
+	if result := <-app.Srv.Store.User().GetByAuth(ldapUser.AuthData, model.USER_AUTH_SERVICE_LDAP); result.Err == nil {
+		return model.NewAppError("SwitchToLdap", "ent.ldap.switch_to_ldap.already_used.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if result := <-app.Srv.Store.User().UpdateAuthData(userId, model.USER_AUTH_SERVICE_LDAP, ldapUser.AuthData, "", false); result.Err != nil {
+		return result.Err
+	}
+
+	app.InvalidateCacheForUser(userId)
+
+	return nil
+}
+
+func (me *LdapProvider) ValidateFilter(filter string) *model.AppError {
+	if _, err := goldap.CompileFilter(filter); err != nil {
+		return model.NewAppError("ValidateFilter", "ent.ldap.validate_filter.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// RunTest checks that the bind user can connect and search for users with the configured settings.
+func (me *LdapProvider) RunTest() *model.AppError {
+	if err := checkLdapEnabled("RunTest"); err != nil {
+		return err
+	}
+
+	if *utils.Cfg.LdapSettings.IdAttribute == "" {
+		return model.NewAppError("RunTest", "ent.ldap.run_test.id_attribute.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *utils.Cfg.LdapSettings.UserFilter != "" {
+		if err := me.ValidateFilter(getUserFilter(getAllUsersFilter())); err != nil {
+			return err
+		}
+	}
+
+	conn, err := connectAndBind()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = searchEntries(conn, getUserFilter(getAllUsersFilter()))
+	return err
+}
+
+func (me *LdapProvider) GetAllLdapUsers() ([]*model.User, *model.AppError) {
+	if err := checkLdapEnabled("GetAllLdapUsers"); err != nil {
+		return nil, err
+	}
+
+	conn, err := connectAndBind()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entries, err := searchEntries(conn, getUserFilter(getAllUsersFilter()))
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*model.User, 0, len(entries))
+	for _, entry := range entries {
+		if user := entryToUser(entry); *user.AuthData != "" {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
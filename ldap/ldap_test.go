@@ -0,0 +1,384 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package ldap
+
+import (
+	"testing"
+
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	testBaseDN       = "dc=example,dc=com"
+	testBindDN       = "cn=admin,dc=example,dc=com"
+	testBindPassword = "adminpassword"
+)
+
+func newString(s string) *string {
+	return &s
+}
+
+func newBool(b bool) *bool {
+	return &b
+}
+
+func newTestUserEntry(uid, password string) *testLdapEntry {
+	return &testLdapEntry{
+		DN:       "uid=" + uid + ",ou=users," + testBaseDN,
+		Password: password,
+		Attributes: map[string][]string{
+			"objectClass": {"inetOrgPerson"},
+			"uid":         {uid},
+			"mail":        {uid + "@Example.com"},
+			"givenName":   {"First " + uid},
+			"sn":          {"Last " + uid},
+			"title":       {"Engineer"},
+		},
+	}
+}
+
+// setupTestLdap starts a test server with a bind user and points the LDAP settings at it. The returned function stops
+// the server and restores the settings.
+func setupTestLdap(t *testing.T) (*testLdapServer, func()) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	server, err := newTestLdapServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.AddEntry(&testLdapEntry{
+		DN:       testBindDN,
+		Password: testBindPassword,
+		Attributes: map[string][]string{
+			"objectClass": {"organizationalRole"},
+			"cn":          {"admin"},
+		},
+	})
+
+	oldSettings := utils.Cfg.LdapSettings
+
+	port := server.Port()
+	queryTimeout := 5
+	maxPageSize := 0
+	utils.Cfg.LdapSettings = model.LdapSettings{
+		Enable:                      newBool(true),
+		LdapServer:                  newString("127.0.0.1"),
+		LdapPort:                    &port,
+		ConnectionSecurity:          newString(model.CONN_SECURITY_NONE),
+		BaseDN:                      newString(testBaseDN),
+		BindUsername:                newString(testBindDN),
+		BindPassword:                newString(testBindPassword),
+		UserFilter:                  newString("(objectClass=inetOrgPerson)"),
+		FirstNameAttribute:          newString("givenName"),
+		LastNameAttribute:           newString("sn"),
+		EmailAttribute:              newString("mail"),
+		UsernameAttribute:           newString("uid"),
+		NicknameAttribute:           newString(""),
+		IdAttribute:                 newString("uid"),
+		PositionAttribute:           newString("title"),
+		SyncIntervalMinutes:         oldSettings.SyncIntervalMinutes,
+		SkipCertificateVerification: newBool(false),
+		QueryTimeout:                &queryTimeout,
+		MaxPageSize:                 &maxPageSize,
+		LoginFieldName:              newString(""),
+	}
+
+	return server, func() {
+		server.Close()
+		utils.Cfg.LdapSettings = oldSettings
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	server, teardown := setupTestLdap(t)
+	defer teardown()
+
+	server.AddEntry(newTestUserEntry("jsmith", "password"))
+
+	filtered := newTestUserEntry("service", "password")
+	filtered.Attributes["objectClass"] = []string{"account"}
+	server.AddEntry(filtered)
+
+	provider := &LdapProvider{}
+
+	user, err := provider.GetUser("jsmith")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.AuthService != model.USER_AUTH_SERVICE_LDAP || user.AuthData == nil || *user.AuthData != "jsmith" {
+		t.Fatal("bad auth data", user.AuthService, user.AuthData)
+	}
+
+	if user.Username != "jsmith" || user.Email != "jsmith@example.com" {
+		t.Fatal("bad username or email", user.Username, user.Email)
+	}
+
+	if user.FirstName != "First jsmith" || user.LastName != "Last jsmith" || user.Position != "Engineer" || user.Nickname != "" {
+		t.Fatal("bad attributes", user.FirstName, user.LastName, user.Position, user.Nickname)
+	}
+
+	if _, err := provider.GetUser("missing"); err == nil || err.Id != "ent.ldap.do_login.user_not_registered.app_error" {
+		t.Fatal("should not have found the user", err)
+	}
+
+	if _, err := provider.GetUser("service"); err == nil || err.Id != "ent.ldap.do_login.user_filtered.app_error" {
+		t.Fatal("should have been excluded by the user filter", err)
+	}
+
+	if _, err := provider.GetUser("*"); err == nil {
+		t.Fatal("filter characters in the id should be escaped")
+	}
+
+	server.AddEntry(newTestUserEntry("duplicate", ""))
+	duplicate := newTestUserEntry("duplicate", "")
+	duplicate.DN = "uid=duplicate,ou=other," + testBaseDN
+	server.AddEntry(duplicate)
+
+	if _, err := provider.GetUser("duplicate"); err == nil || err.Id != "ent.ldap.do_login.matched_to_many_users.app_error" {
+		t.Fatal("should have matched multiple users", err)
+	}
+
+	*utils.Cfg.LdapSettings.Enable = false
+	if _, err := provider.GetUser("jsmith"); err == nil {
+		t.Fatal("should fail when disabled")
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	server, teardown := setupTestLdap(t)
+	defer teardown()
+
+	server.AddEntry(newTestUserEntry("jsmith", "password"))
+
+	provider := &LdapProvider{}
+
+	if err := provider.CheckPassword("jsmith", "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.CheckPassword("jsmith", "wrong"); err == nil || err.Id != "ent.ldap.do_login.invalid_password.app_error" {
+		t.Fatal("should have rejected the password", err)
+	}
+
+	if err := provider.CheckPassword("jsmith", ""); err == nil {
+		t.Fatal("should not allow an empty password")
+	}
+
+	if err := provider.CheckPassword("missing", "password"); err == nil {
+		t.Fatal("should fail for a missing user")
+	}
+
+	*utils.Cfg.LdapSettings.BindPassword = "wrong"
+	if err := provider.CheckPassword("jsmith", "password"); err == nil || err.Id != "ent.ldap.do_login.bind_admin_user.app_error" {
+		t.Fatal("should have failed to bind as the bind user", err)
+	}
+}
+
+func TestGetAllLdapUsers(t *testing.T) {
+	server, teardown := setupTestLdap(t)
+	defer teardown()
+
+	server.AddEntry(newTestUserEntry("user1", "password"))
+	server.AddEntry(newTestUserEntry("user2", "password"))
+
+	filtered := newTestUserEntry("service", "password")
+	filtered.Attributes["objectClass"] = []string{"account"}
+	server.AddEntry(filtered)
+
+	outside := newTestUserEntry("outside", "password")
+	outside.DN = "uid=outside,dc=other,dc=com"
+	server.AddEntry(outside)
+
+	provider := &LdapProvider{}
+
+	for _, maxPageSize := range []int{0, 1} {
+		*utils.Cfg.LdapSettings.MaxPageSize = maxPageSize
+
+		users, err := provider.GetAllLdapUsers()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(users) != 2 {
+			t.Fatalf("expected 2 users with a page size of %v, got %v", maxPageSize, len(users))
+		}
+
+		for _, user := range users {
+			if *user.AuthData != "user1" && *user.AuthData != "user2" {
+				t.Fatal("returned an unexpected user", *user.AuthData)
+			}
+		}
+	}
+
+	*utils.Cfg.LdapSettings.UserFilter = ""
+	if users, err := provider.GetAllLdapUsers(); err != nil {
+		t.Fatal(err)
+	} else if len(users) != 3 {
+		t.Fatal("expected the filtered user without a user filter", len(users))
+	}
+}
+
+func TestRunTest(t *testing.T) {
+	_, teardown := setupTestLdap(t)
+	defer teardown()
+
+	provider := &LdapProvider{}
+
+	if err := provider.RunTest(); err != nil {
+		t.Fatal(err)
+	}
+
+	*utils.Cfg.LdapSettings.UserFilter = "(objectClass=inetOrgPerson"
+	if err := provider.RunTest(); err == nil {
+		t.Fatal("should have rejected the user filter")
+	}
+	*utils.Cfg.LdapSettings.UserFilter = "objectClass=inetOrgPerson"
+	if err := provider.RunTest(); err != nil {
+		t.Fatal("should have accepted a filter without parentheses", err)
+	}
+
+	*utils.Cfg.LdapSettings.BindPassword = "wrong"
+	if err := provider.RunTest(); err == nil {
+		t.Fatal("should have failed to bind")
+	}
+
+	*utils.Cfg.LdapSettings.LdapPort = 1
+	if err := provider.RunTest(); err == nil || err.Id != "ent.ldap.do_login.unable_to_connect.app_error" {
+		t.Fatal("should have failed to connect", err)
+	}
+}
+
+func TestValidateFilter(t *testing.T) {
+	provider := &LdapProvider{}
+
+	for _, filter := range []string{"(objectClass=person)", "(&(objectClass=person)(!(memberOf=cn=disabled)))", "(uid=a*)"} {
+		if err := provider.ValidateFilter(filter); err != nil {
+			t.Fatal(filter, err)
+		}
+	}
+
+	for _, filter := range []string{"(objectClass=person", "objectClass=person)", "(&(a=b)"} {
+		if err := provider.ValidateFilter(filter); err == nil {
+			t.Fatal("should have rejected", filter)
+		}
+	}
+}
+
+func TestUpdateUserAttributes(t *testing.T) {
+	_, teardown := setupTestLdap(t)
+	defer teardown()
+
+	user := &model.User{Username: "old", Email: "old@example.com", FirstName: "Old", LastName: "Name", Nickname: "nick"}
+	ldapUser := &model.User{Username: "old", Email: "old@example.com", FirstName: "Old", LastName: "Name"}
+
+	if updateUserAttributes(user, ldapUser) {
+		t.Fatal("nothing should have changed since the nickname attribute isn't configured")
+	}
+
+	if user.Nickname != "nick" {
+		t.Fatal("should not have cleared the nickname")
+	}
+
+	ldapUser.Username = "new"
+	ldapUser.Email = "new@example.com"
+	ldapUser.Position = "Manager"
+
+	if !updateUserAttributes(user, ldapUser) {
+		t.Fatal("should have changed")
+	}
+
+	if user.Username != "new" || user.Email != "new@example.com" || user.Position != "Manager" {
+		t.Fatal("should have copied the attributes", user.Username, user.Email, user.Position)
+	}
+}
+
+func TestSyncronize(t *testing.T) {
+	server, teardown := setupTestLdap(t)
+	defer teardown()
+
+	th := app.Setup()
+
+	uid1 := "a" + model.NewId()[:20]
+	uid2 := "b" + model.NewId()[:20]
+	uid3 := "c" + model.NewId()[:20]
+
+	server.AddEntry(newTestUserEntry(uid1, "password"))
+	server.AddEntry(newTestUserEntry(uid2, "password"))
+
+	provider := &LdapProvider{}
+
+	user1, err := provider.DoLogin(uid1, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user2, err := provider.DoLogin(uid2, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.DoLogin(uid1, "wrong"); err == nil {
+		t.Fatal("should have rejected the password")
+	}
+
+	entry := newTestUserEntry(uid1, "password")
+	entry.Attributes["givenName"] = []string{"Changed"}
+	server.AddEntry(entry)
+	server.RemoveEntry(newTestUserEntry(uid2, "").DN)
+
+	if err := provider.Syncronize(); err != nil {
+		t.Fatal(err)
+	}
+
+	if user, err := app.GetUser(user1.Id); err != nil {
+		t.Fatal(err)
+	} else if user.FirstName != "Changed" || user.DeleteAt != 0 {
+		t.Fatal("should have updated the first name", user.FirstName, user.DeleteAt)
+	}
+
+	if user, err := app.GetUser(user2.Id); err != nil {
+		t.Fatal(err)
+	} else if user.DeleteAt == 0 {
+		t.Fatal("should have deactivated the removed user")
+	}
+
+	server.AddEntry(newTestUserEntry(uid2, "password"))
+
+	if err := provider.Syncronize(); err != nil {
+		t.Fatal(err)
+	}
+
+	if user, err := app.GetUser(user2.Id); err != nil {
+		t.Fatal(err)
+	} else if user.DeleteAt != 0 {
+		t.Fatal("should have reactivated the user")
+	}
+
+	emailUser := th.CreateUser()
+	server.AddEntry(newTestUserEntry(uid3, "password"))
+
+	if err := provider.SwitchToLdap(emailUser.Id, uid3, "wrong"); err == nil {
+		t.Fatal("should have rejected the password")
+	}
+
+	if err := provider.SwitchToLdap(emailUser.Id, uid3, "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.SwitchToLdap(th.CreateUser().Id, uid3, "password"); err == nil {
+		t.Fatal("should not allow two users to use the same LDAP account")
+	}
+
+	if user, err := app.GetUser(emailUser.Id); err != nil {
+		t.Fatal(err)
+	} else if user.AuthService != model.USER_AUTH_SERVICE_LDAP || *user.AuthData != uid3 {
+		t.Fatal("should have switched to LDAP", user.AuthService)
+	}
+}
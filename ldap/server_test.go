@@ -0,0 +1,283 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package ldap
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	goldap "github.com/go-ldap/ldap"
+	"gopkg.in/asn1-ber.v1"
+)
+
+// testLdapEntry is a directory entry served by testLdapServer. Entries with a password can be bound to.
+type testLdapEntry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+func (e *testLdapEntry) getValues(attribute string) []string {
+	for name, values := range e.Attributes {
+		if strings.EqualFold(name, attribute) {
+			return values
+		}
+	}
+
+	return nil
+}
+
+// testLdapServer is an in-process stand-in for an LDAP server that supports the simple binds and subtree searches used
+// by LdapProvider. Filters support and, or, not, equality, presence and substring matches, all case insensitive.
+type testLdapServer struct {
+	listener net.Listener
+
+	mutex   sync.Mutex
+	entries map[string]*testLdapEntry
+}
+
+func newTestLdapServer() (*testLdapServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	server := &testLdapServer{
+		listener: listener,
+		entries:  make(map[string]*testLdapEntry),
+	}
+
+	go server.serve()
+
+	return server, nil
+}
+
+func (s *testLdapServer) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *testLdapServer) Close() {
+	s.listener.Close()
+}
+
+func (s *testLdapServer) AddEntry(entry *testLdapEntry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries[strings.ToLower(entry.DN)] = entry
+}
+
+func (s *testLdapServer) RemoveEntry(dn string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.entries, strings.ToLower(dn))
+}
+
+func (s *testLdapServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *testLdapServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		messageId := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+
+		var responses []*ber.Packet
+		switch request.Tag {
+		case goldap.ApplicationBindRequest:
+			name := request.Children[1].Value.(string)
+			password := request.Children[2].Data.String()
+			responses = append(responses, newTestResult(messageId, goldap.ApplicationBindResponse, s.bind(name, password)))
+		case goldap.ApplicationSearchRequest:
+			responses = s.search(messageId, request)
+		case goldap.ApplicationUnbindRequest:
+			return
+		default:
+			responses = append(responses, newTestResult(messageId, goldap.ApplicationExtendedResponse, goldap.LDAPResultUnwillingToPerform))
+		}
+
+		for _, response := range responses {
+			if _, err := conn.Write(response.Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *testLdapServer) bind(name, password string) uint8 {
+	if name == "" && password == "" {
+		return goldap.LDAPResultSuccess
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if entry, ok := s.entries[strings.ToLower(name)]; !ok || entry.Password == "" || entry.Password != password {
+		return goldap.LDAPResultInvalidCredentials
+	}
+
+	return goldap.LDAPResultSuccess
+}
+
+func (s *testLdapServer) search(messageId int64, request *ber.Packet) []*ber.Packet {
+	baseDN := strings.ToLower(request.Children[0].Value.(string))
+	filter := request.Children[6]
+
+	var attributes []string
+	for _, attribute := range request.Children[7].Children {
+		attributes = append(attributes, attribute.Value.(string))
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var responses []*ber.Packet
+	for dn, entry := range s.entries {
+		if dn != baseDN && !strings.HasSuffix(dn, ","+baseDN) {
+			continue
+		}
+
+		if !matchesTestFilter(entry, filter) {
+			continue
+		}
+
+		responses = append(responses, newTestSearchEntry(messageId, entry, attributes))
+	}
+
+	return append(responses, newTestResult(messageId, goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess))
+}
+
+func matchesTestFilter(entry *testLdapEntry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case goldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matchesTestFilter(entry, child) {
+				return false
+			}
+		}
+		return true
+	case goldap.FilterOr:
+		for _, child := range filter.Children {
+			if matchesTestFilter(entry, child) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterNot:
+		return !matchesTestFilter(entry, filter.Children[0])
+	case goldap.FilterPresent:
+		return len(entry.getValues(filter.Data.String())) > 0
+	case goldap.FilterEqualityMatch:
+		for _, value := range entry.getValues(filter.Children[0].Value.(string)) {
+			if strings.EqualFold(value, filter.Children[1].Value.(string)) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterSubstrings:
+		for _, value := range entry.getValues(filter.Children[0].Value.(string)) {
+			if matchesTestSubstrings(strings.ToLower(value), filter.Children[1].Children) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return false
+}
+
+func matchesTestSubstrings(value string, parts []*ber.Packet) bool {
+	for _, part := range parts {
+		substring := strings.ToLower(part.Data.String())
+
+		switch part.Tag {
+		case goldap.FilterSubstringsInitial:
+			if !strings.HasPrefix(value, substring) {
+				return false
+			}
+			value = value[len(substring):]
+		case goldap.FilterSubstringsAny:
+			index := strings.Index(value, substring)
+			if index < 0 {
+				return false
+			}
+			value = value[index+len(substring):]
+		case goldap.FilterSubstringsFinal:
+			if !strings.HasSuffix(value, substring) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func newTestResponse(messageId int64, response *ber.Packet) *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageId, "MessageID"))
+	packet.AppendChild(response)
+
+	return packet
+}
+
+func newTestResult(messageId int64, tag ber.Tag, resultCode uint8) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, uint64(resultCode), "Result Code"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, goldap.LDAPResultCodeMap[resultCode], "Diagnostic Message"))
+
+	return newTestResponse(messageId, result)
+}
+
+func newTestSearchEntry(messageId int64, entry *testLdapEntry, attributes []string) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "DN"))
+
+	attributesPacket := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range entry.Attributes {
+		if len(attributes) > 0 && !containsFold(attributes, name) {
+			continue
+		}
+
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+
+		valuesPacket := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			valuesPacket.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+
+		attribute.AppendChild(valuesPacket)
+		attributesPacket.AppendChild(attribute)
+	}
+	result.AppendChild(attributesPacket)
+
+	return newTestResponse(messageId, result)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
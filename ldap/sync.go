@@ -0,0 +1,66 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package ldap
+
+import (
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+// Syncronize updates every LDAP user to match the LDAP server. Users that are no longer returned by the server,
+// including those now excluded by the user filter, are deactivated and users that reappear are reactivated.
+func (me *LdapProvider) Syncronize() *model.AppError {
+	ldapUsers, err := me.GetAllLdapUsers()
+	if err != nil {
+		return err
+	}
+
+	var users []*model.User
+	if result := <-app.Srv.Store.User().GetAllUsingAuthService(model.USER_AUTH_SERVICE_LDAP); result.Err != nil {
+		return model.NewAppError("Syncronize", "ent.ldap.syncronize.get_all.app_error", nil, result.Err.Error(), result.Err.StatusCode)
+	} else {
+		users = result.Data.([]*model.User)
+	}
+
+	ldapUsersByAuthData := make(map[string]*model.User, len(ldapUsers))
+	for _, ldapUser := range ldapUsers {
+		ldapUsersByAuthData[*ldapUser.AuthData] = ldapUser
+	}
+
+	for _, user := range users {
+		if user.AuthData == nil {
+			continue
+		}
+
+		ldapUser, ok := ldapUsersByAuthData[*user.AuthData]
+		if !ok {
+			if user.DeleteAt == 0 {
+				if _, err := app.UpdateActive(user, false); err != nil {
+					l4g.Error(utils.T("ent.ldap.syncronize.deactivate_user.error"), user.Id, err.Error())
+				}
+			}
+
+			continue
+		}
+
+		if updateUserAttributes(user, ldapUser) {
+			if _, err := saveUser(user); err != nil {
+				l4g.Error(utils.T("ent.ldap.do_login.update_user.error"), user.Id, err.Error())
+				continue
+			}
+		}
+
+		if user.DeleteAt != 0 {
+			if _, err := app.UpdateActive(user, true); err != nil {
+				l4g.Error(utils.T("ent.ldap.syncronize.activate_user.error"), user.Id, err.Error())
+			}
+		}
+	}
+
+	l4g.Info(utils.T("ent.ldap.syncdone.info"))
+
+	return nil
+}
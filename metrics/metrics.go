@@ -0,0 +1,335 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package metrics records server metrics with Prometheus and serves them in the Prometheus text format on the address
+// from the metrics settings.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/einterfaces"
+	"github.com/primefour/servers/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	METRICS_NAMESPACE = "mattermost"
+
+	METRICS_SUBSYSTEM_POST      = "post"
+	METRICS_SUBSYSTEM_HTTP      = "http"
+	METRICS_SUBSYSTEM_CLUSTER   = "cluster"
+	METRICS_SUBSYSTEM_LOGIN     = "login"
+	METRICS_SUBSYSTEM_CACHE     = "cache"
+	METRICS_SUBSYSTEM_WEBSOCKET = "websocket"
+	METRICS_SUBSYSTEM_DB        = "db"
+
+	METRICS_CACHE_SESSION = "Session"
+
+	METRICS_SERVER_SHUTDOWN_TIMEOUT = 5 * time.Second
+)
+
+type PrometheusMetrics struct {
+	registry *prometheus.Registry
+
+	postCreate         prometheus.Counter
+	webhookPost        prometheus.Counter
+	postSentEmail      prometheus.Counter
+	postSentPush       prometheus.Counter
+	postBroadcast      prometheus.Counter
+	postFileAttachment prometheus.Counter
+
+	httpRequest         prometheus.Counter
+	httpError           prometheus.Counter
+	httpRequestDuration prometheus.Histogram
+
+	clusterRequest         prometheus.Counter
+	clusterRequestDuration prometheus.Histogram
+
+	login     prometheus.Counter
+	loginFail prometheus.Counter
+
+	etagHit  *prometheus.CounterVec
+	etagMiss *prometheus.CounterVec
+
+	memCacheHit  *prometheus.CounterVec
+	memCacheMiss *prometheus.CounterVec
+
+	websocketEvent     *prometheus.CounterVec
+	websocketBroadcast *prometheus.CounterVec
+
+	serverLock sync.Mutex
+	server     *http.Server
+}
+
+func init() {
+	einterfaces.RegisterMetricsInterface(NewPrometheusMetrics())
+}
+
+// NewPrometheusMetrics creates the metrics along with a registry that holds them and the Go runtime and process
+// metrics.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	m := &PrometheusMetrics{
+		registry: prometheus.NewRegistry(),
+	}
+
+	newCounter := func(subsystem string, name string, help string) prometheus.Counter {
+		counter := prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: METRICS_NAMESPACE,
+			Subsystem: subsystem,
+			Name:      name,
+			Help:      help,
+		})
+		m.registry.MustRegister(counter)
+		return counter
+	}
+
+	newCounterVec := func(subsystem string, name string, help string, label string) *prometheus.CounterVec {
+		counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: METRICS_NAMESPACE,
+			Subsystem: subsystem,
+			Name:      name,
+			Help:      help,
+		}, []string{label})
+		m.registry.MustRegister(counter)
+		return counter
+	}
+
+	newHistogram := func(subsystem string, name string, help string) prometheus.Histogram {
+		histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: METRICS_NAMESPACE,
+			Subsystem: subsystem,
+			Name:      name,
+			Help:      help,
+		})
+		m.registry.MustRegister(histogram)
+		return histogram
+	}
+
+	newGauge := func(subsystem string, name string, help string, function func() float64) {
+		m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: METRICS_NAMESPACE,
+			Subsystem: subsystem,
+			Name:      name,
+			Help:      help,
+		}, function))
+	}
+
+	m.registry.MustRegister(prometheus.NewGoCollector())
+	m.registry.MustRegister(prometheus.NewProcessCollector(os.Getpid(), ""))
+
+	m.postCreate = newCounter(METRICS_SUBSYSTEM_POST, "total", "The total number of posts created.")
+	m.webhookPost = newCounter(METRICS_SUBSYSTEM_POST, "webhooks_total", "The total number of webhook posts created.")
+	m.postSentEmail = newCounter(METRICS_SUBSYSTEM_POST, "emails_sent_total", "The total number of emails sent because a post was created.")
+	m.postSentPush = newCounter(METRICS_SUBSYSTEM_POST, "pushes_sent_total", "The total number of mobile push notifications sent because a post was created.")
+	m.postBroadcast = newCounter(METRICS_SUBSYSTEM_POST, "broadcasts_total", "The total number of websocket broadcasts sent because a post was created.")
+	m.postFileAttachment = newCounter(METRICS_SUBSYSTEM_POST, "file_attachments_total", "The total number of files attached to posts.")
+
+	m.httpRequest = newCounter(METRICS_SUBSYSTEM_HTTP, "requests_total", "The total number of HTTP requests.")
+	m.httpError = newCounter(METRICS_SUBSYSTEM_HTTP, "errors_total", "The total number of HTTP requests that returned an error.")
+	m.httpRequestDuration = newHistogram(METRICS_SUBSYSTEM_HTTP, "request_duration_seconds", "The time taken to handle HTTP requests.")
+
+	m.clusterRequest = newCounter(METRICS_SUBSYSTEM_CLUSTER, "requests_total", "The total number of requests sent to other cluster nodes.")
+	m.clusterRequestDuration = newHistogram(METRICS_SUBSYSTEM_CLUSTER, "request_duration_seconds", "The time taken for requests to other cluster nodes.")
+
+	m.login = newCounter(METRICS_SUBSYSTEM_LOGIN, "logins_total", "The total number of successful logins.")
+	m.loginFail = newCounter(METRICS_SUBSYSTEM_LOGIN, "logins_fail_total", "The total number of failed logins.")
+
+	m.etagHit = newCounterVec(METRICS_SUBSYSTEM_HTTP, "etag_hit_total", "The total number of requests that matched the ETag sent by the client.", "route")
+	m.etagMiss = newCounterVec(METRICS_SUBSYSTEM_HTTP, "etag_miss_total", "The total number of requests that didn't match the ETag sent by the client.", "route")
+
+	m.memCacheHit = newCounterVec(METRICS_SUBSYSTEM_CACHE, "mem_hit_total", "The total number of in-memory cache hits.", "name")
+	m.memCacheMiss = newCounterVec(METRICS_SUBSYSTEM_CACHE, "mem_miss_total", "The total number of in-memory cache misses.", "name")
+
+	m.websocketEvent = newCounterVec(METRICS_SUBSYSTEM_WEBSOCKET, "events_total", "The total number of websocket events received.", "type")
+	m.websocketBroadcast = newCounterVec(METRICS_SUBSYSTEM_WEBSOCKET, "broadcasts_total", "The total number of websocket broadcasts sent.", "type")
+
+	newGauge(METRICS_SUBSYSTEM_HTTP, "websockets_total", "The number of open websocket connections to this server.", func() float64 {
+		return float64(app.TotalWebsocketConnections())
+	})
+	newGauge(METRICS_SUBSYSTEM_DB, "master_connections_total", "The number of open connections to the master database.", func() float64 {
+		if app.Srv == nil || app.Srv.Store == nil {
+			return 0
+		}
+		return float64(app.Srv.Store.TotalMasterDbConnections())
+	})
+	newGauge(METRICS_SUBSYSTEM_DB, "read_replica_connections_total", "The number of open connections to the read replica databases.", func() float64 {
+		if app.Srv == nil || app.Srv.Store == nil {
+			return 0
+		}
+		return float64(app.Srv.Store.TotalReadDbConnections())
+	})
+	newGauge(METRICS_SUBSYSTEM_DB, "search_replica_connections_total", "The number of open connections to the search replica databases.", func() float64 {
+		if app.Srv == nil || app.Srv.Store == nil {
+			return 0
+		}
+		return float64(app.Srv.Store.TotalSearchDbConnections())
+	})
+
+	return m
+}
+
+// Handler returns the handler that serves the metrics in the Prometheus text format.
+func (m *PrometheusMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// StartServer starts serving the metrics if they're enabled. A server that's already running is restarted so that
+// changes to the listen address are picked up.
+func (m *PrometheusMetrics) StartServer() {
+	m.StopServer()
+
+	if !*utils.Cfg.MetricsSettings.Enable {
+		return
+	}
+
+	runtime.SetBlockProfileRate(*utils.Cfg.MetricsSettings.BlockProfileRate)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	server := &http.Server{
+		Addr:    *utils.Cfg.MetricsSettings.ListenAddress,
+		Handler: mux,
+	}
+
+	m.serverLock.Lock()
+	m.server = server
+	m.serverLock.Unlock()
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			l4g.Error(utils.T("metrics.server.error"), err.Error())
+		}
+	}()
+
+	l4g.Info(utils.T("metrics.server.started.info"), server.Addr)
+}
+
+func (m *PrometheusMetrics) StopServer() {
+	m.serverLock.Lock()
+	server := m.server
+	m.server = nil
+	m.serverLock.Unlock()
+
+	if server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), METRICS_SERVER_SHUTDOWN_TIMEOUT)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		l4g.Warn(utils.T("metrics.server.stop.warn"), err.Error())
+	}
+
+	runtime.SetBlockProfileRate(0)
+
+	l4g.Info(utils.T("metrics.server.stopped.info"))
+}
+
+func (m *PrometheusMetrics) IncrementPostCreate() {
+	m.postCreate.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementWebhookPost() {
+	m.webhookPost.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementPostSentEmail() {
+	m.postSentEmail.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementPostSentPush() {
+	m.postSentPush.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementPostBroadcast() {
+	m.postBroadcast.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementPostFileAttachment(count int) {
+	m.postFileAttachment.Add(float64(count))
+}
+
+func (m *PrometheusMetrics) IncrementHttpRequest() {
+	m.httpRequest.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementHttpError() {
+	m.httpError.Inc()
+}
+
+func (m *PrometheusMetrics) ObserveHttpRequestDuration(elapsed float64) {
+	m.httpRequestDuration.Observe(elapsed)
+}
+
+func (m *PrometheusMetrics) IncrementClusterRequest() {
+	m.clusterRequest.Inc()
+}
+
+func (m *PrometheusMetrics) ObserveClusterRequestDuration(elapsed float64) {
+	m.clusterRequestDuration.Observe(elapsed)
+}
+
+func (m *PrometheusMetrics) IncrementLogin() {
+	m.login.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementLoginFail() {
+	m.loginFail.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementEtagHitCounter(route string) {
+	m.etagHit.WithLabelValues(route).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementEtagMissCounter(route string) {
+	m.etagMiss.WithLabelValues(route).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementMemCacheHitCounter(cacheName string) {
+	m.memCacheHit.WithLabelValues(cacheName).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementMemCacheMissCounter(cacheName string) {
+	m.memCacheMiss.WithLabelValues(cacheName).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementMemCacheHitCounterSession() {
+	m.memCacheHit.WithLabelValues(METRICS_CACHE_SESSION).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementMemCacheMissCounterSession() {
+	m.memCacheMiss.WithLabelValues(METRICS_CACHE_SESSION).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementWebsocketEvent(eventType string) {
+	m.websocketEvent.WithLabelValues(eventType).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementWebSocketBroadcast(eventType string) {
+	m.websocketBroadcast.WithLabelValues(eventType).Inc()
+}
+
+func (m *PrometheusMetrics) AddMemCacheHitCounter(cacheName string, amount float64) {
+	m.memCacheHit.WithLabelValues(cacheName).Add(amount)
+}
+
+func (m *PrometheusMetrics) AddMemCacheMissCounter(cacheName string, amount float64) {
+	m.memCacheMiss.WithLabelValues(cacheName).Add(amount)
+}
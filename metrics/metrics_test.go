@@ -0,0 +1,131 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package metrics
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/primefour/servers/utils"
+)
+
+func getMetricsText(t *testing.T, handler http.Handler) string {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatal("metrics request failed", recorder.Code)
+	}
+
+	return recorder.Body.String()
+}
+
+func checkMetric(t *testing.T, text string, line string) {
+	for _, l := range strings.Split(text, "\n") {
+		if l == line {
+			return
+		}
+	}
+
+	t.Fatalf("metrics should contain %q", line)
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	m := NewPrometheusMetrics()
+
+	m.IncrementPostCreate()
+	m.IncrementPostCreate()
+	m.IncrementPostFileAttachment(3)
+	m.IncrementHttpRequest()
+	m.ObserveHttpRequestDuration(0.2)
+	m.IncrementLoginFail()
+	m.IncrementEtagHitCounter("getPosts")
+	m.IncrementMemCacheHitCounter("Profile")
+	m.AddMemCacheMissCounter("Profile", 5)
+	m.IncrementMemCacheHitCounterSession()
+	m.IncrementWebsocketEvent("typing")
+
+	text := getMetricsText(t, m.Handler())
+
+	checkMetric(t, text, "mattermost_post_total 2")
+	checkMetric(t, text, "mattermost_post_file_attachments_total 3")
+	checkMetric(t, text, "mattermost_http_requests_total 1")
+	checkMetric(t, text, "mattermost_http_request_duration_seconds_count 1")
+	checkMetric(t, text, "mattermost_login_logins_fail_total 1")
+	checkMetric(t, text, `mattermost_http_etag_hit_total{route="getPosts"} 1`)
+	checkMetric(t, text, `mattermost_cache_mem_hit_total{name="Profile"} 1`)
+	checkMetric(t, text, `mattermost_cache_mem_miss_total{name="Profile"} 5`)
+	checkMetric(t, text, `mattermost_cache_mem_hit_total{name="Session"} 1`)
+	checkMetric(t, text, `mattermost_websocket_events_total{type="typing"} 1`)
+	checkMetric(t, text, "mattermost_http_websockets_total 0")
+	checkMetric(t, text, "mattermost_db_master_connections_total 0")
+
+	if !strings.Contains(text, "go_goroutines") {
+		t.Fatal("metrics should include the Go runtime metrics")
+	}
+}
+
+func TestPrometheusMetricsServer(t *testing.T) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	enable := *utils.Cfg.MetricsSettings.Enable
+	listenAddress := *utils.Cfg.MetricsSettings.ListenAddress
+	defer func() {
+		*utils.Cfg.MetricsSettings.Enable = enable
+		*utils.Cfg.MetricsSettings.ListenAddress = listenAddress
+	}()
+
+	// Find a free port to listen on
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	*utils.Cfg.MetricsSettings.Enable = true
+	*utils.Cfg.MetricsSettings.ListenAddress = address
+
+	m := NewPrometheusMetrics()
+	m.StartServer()
+	defer m.StopServer()
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = http.Get("http://" + address + "/metrics"); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "mattermost_post_total") {
+		t.Fatal("server should serve the metrics")
+	}
+
+	m.StopServer()
+
+	if _, err := http.Get("http://" + address + "/metrics"); err == nil {
+		t.Fatal("server should have stopped")
+	}
+
+	*utils.Cfg.MetricsSettings.Enable = false
+	m.StartServer()
+
+	if m.server != nil {
+		t.Fatal("server shouldn't start when metrics are disabled")
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package mfa provides multi-factor authentication using time-based one-time passwords (RFC 6238) that work with
+// authenticator apps such as Google Authenticator, along with one-time recovery codes.
+package mfa
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgryski/dgoogauth"
+	"github.com/mattermost/rsc/qr"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/einterfaces"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	MFA_SECRET_SIZE       = 20 // bytes, as recommended by RFC 4226
+	MFA_TIME_STEP_SECONDS = 30
+	MFA_TOKEN_LENGTH      = 6
+
+	// The number of time steps before and after the current one whose tokens are still accepted, so that a device
+	// whose clock is slightly off can still be used
+	MFA_WINDOW_SIZE = 1
+)
+
+type TotpMfa struct{}
+
+func init() {
+	einterfaces.RegisterMfaInterface(&TotpMfa{})
+}
+
+func checkMfaEnabled() *model.AppError {
+	if !*utils.Cfg.ServiceSettings.EnableMultifactorAuthentication {
+		return model.NewAppError("checkMfaEnabled", "mfa.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	return nil
+}
+
+// newSecret generates a random base32 encoded secret.
+func newSecret() (string, *model.AppError) {
+	b := make([]byte, MFA_SECRET_SIZE)
+	if _, err := rand.Read(b); err != nil {
+		return "", model.NewAppError("newSecret", "mfa.generate_secret.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return base32.StdEncoding.EncodeToString(b), nil
+}
+
+// newQRCode returns a QR code PNG that an authenticator app can scan to add an account with the given secret.
+func newQRCode(secret string, accountName string, issuer string) ([]byte, *model.AppError) {
+	config := &dgoogauth.OTPConfig{Secret: secret}
+
+	code, err := qr.Encode(config.ProvisionURIWithIssuer(accountName, issuer), qr.H)
+	if err != nil {
+		return nil, model.NewAppError("newQRCode", "mfa.generate_secret.qr_code.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return code.PNG(), nil
+}
+
+// getTimeStep returns the TOTP time step that the given time falls in.
+func getTimeStep(now time.Time) int64 {
+	return now.Unix() / MFA_TIME_STEP_SECONDS
+}
+
+// checkTotpToken checks a token against the tokens for the time steps around now. It returns the time step that the
+// token matched so that the caller can make sure it isn't used again.
+func checkTotpToken(secret string, token string, now time.Time) (int64, bool) {
+	if len(token) != MFA_TOKEN_LENGTH {
+		return 0, false
+	}
+
+	code, err := strconv.Atoi(token)
+	if err != nil || code < 0 {
+		return 0, false
+	}
+
+	current := getTimeStep(now)
+	for step := current - MFA_WINDOW_SIZE; step <= current+MFA_WINDOW_SIZE; step++ {
+		if dgoogauth.ComputeCode(secret, step) == code {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// isTotpToken returns true if the token looks like it came from an authenticator app rather than being a recovery code.
+func isTotpToken(token string) bool {
+	if len(token) != MFA_TOKEN_LENGTH {
+		return false
+	}
+
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// useTotpToken checks a token from an authenticator app and records its time step so that neither it nor an older
+// token that's still inside the window can be used afterwards.
+func useTotpToken(user *model.User, token string) (bool, *model.AppError) {
+	now := time.Now()
+
+	step, ok := checkTotpToken(user.MfaSecret, token, now)
+	if !ok {
+		return false, nil
+	}
+
+	if result := <-app.Srv.Store.Mfa().SaveUsedTimeStep(user.Id, step); result.Err != nil {
+		if result.Err.StatusCode == http.StatusConflict {
+			// This token or a newer one has already been used
+			return false, nil
+		}
+
+		return false, result.Err
+	}
+
+	return true, nil
+}
+
+func (m *TotpMfa) GenerateSecret(user *model.User) (string, []byte, *model.AppError) {
+	if err := checkMfaEnabled(); err != nil {
+		return "", nil, err
+	}
+
+	if user.MfaActive {
+		return "", nil, model.NewAppError("GenerateSecret", "mfa.generate_secret.already_active.app_error", nil, "user_id="+user.Id, http.StatusBadRequest)
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return "", nil, err
+	}
+
+	img, err := newQRCode(secret, user.Email, utils.Cfg.TeamSettings.SiteName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if result := <-app.Srv.Store.User().UpdateMfaSecret(user.Id, secret); result.Err != nil {
+		return "", nil, result.Err
+	}
+
+	app.InvalidateCacheForUser(user.Id)
+
+	return secret, img, nil
+}
+
+func (m *TotpMfa) Activate(user *model.User, token string) *model.AppError {
+	if err := checkMfaEnabled(); err != nil {
+		return err
+	}
+
+	if user.MfaSecret == "" {
+		return model.NewAppError("Activate", "mfa.activate.no_secret.app_error", nil, "user_id="+user.Id, http.StatusBadRequest)
+	}
+
+	if ok, err := useTotpToken(user, strings.TrimSpace(token)); err != nil {
+		return err
+	} else if !ok {
+		return model.NewAppError("Activate", "mfa.activate.bad_token.app_error", nil, "user_id="+user.Id, http.StatusUnauthorized)
+	}
+
+	if result := <-app.Srv.Store.User().UpdateMfaActive(user.Id, true); result.Err != nil {
+		return result.Err
+	}
+
+	app.InvalidateCacheForUser(user.Id)
+
+	return nil
+}
+
+func (m *TotpMfa) Deactivate(userId string) *model.AppError {
+	if result := <-app.Srv.Store.User().UpdateMfaActive(userId, false); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-app.Srv.Store.User().UpdateMfaSecret(userId, ""); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-app.Srv.Store.Mfa().DeleteAllForUser(userId); result.Err != nil {
+		return result.Err
+	}
+
+	app.InvalidateCacheForUser(userId)
+
+	return nil
+}
+
+// ValidateToken accepts either a token from the user's authenticator app or one of their unused recovery codes. Each
+// of them can only be used once.
+func (m *TotpMfa) ValidateToken(user *model.User, token string) (bool, *model.AppError) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return false, nil
+	}
+
+	if isTotpToken(token) {
+		return useTotpToken(user, token)
+	}
+
+	if result := <-app.Srv.Store.Mfa().UseRecoveryCode(user.Id, model.HashMfaRecoveryCode(token)); result.Err != nil {
+		return false, result.Err
+	} else {
+		return result.Data.(bool), nil
+	}
+}
+
+// GenerateRecoveryCodes replaces a user's recovery codes with new ones. The codes are only returned by this, since
+// just their hashes are stored.
+func (m *TotpMfa) GenerateRecoveryCodes(user *model.User) ([]string, *model.AppError) {
+	if err := checkMfaEnabled(); err != nil {
+		return nil, err
+	}
+
+	if !user.MfaActive {
+		return nil, model.NewAppError("GenerateRecoveryCodes", "mfa.generate_recovery_codes.not_active.app_error", nil, "user_id="+user.Id, http.StatusBadRequest)
+	}
+
+	codes := make([]string, 0, model.MFA_RECOVERY_CODE_COUNT)
+	recoveryCodes := make([]*model.MfaRecoveryCode, 0, model.MFA_RECOVERY_CODE_COUNT)
+	for i := 0; i < model.MFA_RECOVERY_CODE_COUNT; i++ {
+		code := model.NewMfaRecoveryCode()
+
+		codes = append(codes, code)
+		recoveryCodes = append(recoveryCodes, &model.MfaRecoveryCode{CodeHash: model.HashMfaRecoveryCode(code)})
+	}
+
+	if result := <-app.Srv.Store.Mfa().SaveRecoveryCodes(user.Id, recoveryCodes); result.Err != nil {
+		return nil, result.Err
+	}
+
+	return codes, nil
+}
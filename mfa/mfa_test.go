@@ -0,0 +1,120 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package mfa
+
+import (
+	"bytes"
+	"encoding/base32"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgryski/dgoogauth"
+)
+
+// The SHA1 secret from the test vectors in RFC 6238
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCheckTotpToken(t *testing.T) {
+	for _, testCase := range []struct {
+		Time  int64
+		Token string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	} {
+		now := time.Unix(testCase.Time, 0)
+
+		if step, ok := checkTotpToken(rfcSecret, testCase.Token, now); !ok {
+			t.Fatalf("token %v should be valid at %v", testCase.Token, testCase.Time)
+		} else if step != getTimeStep(now) {
+			t.Fatalf("token %v should have matched the current time step", testCase.Token)
+		}
+
+		if _, ok := checkTotpToken(rfcSecret, testCase.Token, now.Add(-10*time.Minute)); ok {
+			t.Fatalf("token %v shouldn't be valid 10 minutes early", testCase.Token)
+		}
+	}
+}
+
+func TestCheckTotpTokenClockSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := getTimeStep(now)
+
+	for offset := int64(-MFA_WINDOW_SIZE); offset <= MFA_WINDOW_SIZE; offset++ {
+		token := fmt.Sprintf("%06d", dgoogauth.ComputeCode(rfcSecret, current+offset))
+
+		if step, ok := checkTotpToken(rfcSecret, token, now); !ok {
+			t.Fatalf("token from %v time steps away should be valid", offset)
+		} else if step != current+offset {
+			t.Fatalf("token from %v time steps away matched the wrong time step", offset)
+		}
+	}
+
+	for _, offset := range []int64{-MFA_WINDOW_SIZE - 1, MFA_WINDOW_SIZE + 1} {
+		token := fmt.Sprintf("%06d", dgoogauth.ComputeCode(rfcSecret, current+offset))
+
+		if _, ok := checkTotpToken(rfcSecret, token, now); ok {
+			t.Fatalf("token from %v time steps away shouldn't be valid", offset)
+		}
+	}
+}
+
+func TestCheckTotpTokenInvalid(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	for _, token := range []string{"", "28708", "2870820", "abcdef", "-28708"} {
+		if _, ok := checkTotpToken(rfcSecret, token, now); ok {
+			t.Fatalf("token %q shouldn't be valid", token)
+		}
+	}
+
+	if _, ok := checkTotpToken("not base32!", "287082", now); ok {
+		t.Fatal("token shouldn't be valid with a bad secret")
+	}
+}
+
+func TestIsTotpToken(t *testing.T) {
+	for token, expected := range map[string]bool{
+		"123456":      true,
+		"000000":      true,
+		"12345":       false,
+		"1234567":     false,
+		"12345a":      false,
+		"abcde-fghij": false,
+	} {
+		if isTotpToken(token) != expected {
+			t.Fatalf("isTotpToken(%q) should be %v", token, expected)
+		}
+	}
+}
+
+func TestNewSecretAndQRCode(t *testing.T) {
+	secret, err := newSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key, err := base32.StdEncoding.DecodeString(secret); err != nil {
+		t.Fatal(err)
+	} else if len(key) != MFA_SECRET_SIZE {
+		t.Fatal("secret has the wrong size")
+	}
+
+	if other, _ := newSecret(); other == secret {
+		t.Fatal("secrets should be random")
+	}
+
+	img, err := newQRCode(secret, "test@example.com", "Mattermost")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(img, []byte("\x89PNG")) {
+		t.Fatal("QR code should be a PNG")
+	}
+}
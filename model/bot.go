@@ -0,0 +1,169 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	BOT_DISPLAY_NAME_MAX_RUNES = USER_FIRST_NAME_MAX_RUNES
+	BOT_DESCRIPTION_MAX_RUNES  = 1024
+)
+
+// Bot is a user account that's flagged as a bot and owned by another user. Integrations post as a bot through its
+// personal access tokens instead of overriding the username and icon of their posts. The username and display name are
+// kept on the bot's user and are only filled in when a bot is returned to a client.
+type Bot struct {
+	UserId      string `json:"user_id"`
+	Username    string `db:"-" json:"username"`
+	DisplayName string `db:"-" json:"display_name,omitempty"`
+	Description string `json:"description,omitempty"`
+	OwnerId     string `json:"owner_id"`
+	CreateAt    int64  `json:"create_at"`
+	UpdateAt    int64  `json:"update_at"`
+	DeleteAt    int64  `json:"delete_at"`
+}
+
+type BotPatch struct {
+	Username    *string `json:"username"`
+	DisplayName *string `json:"display_name"`
+	Description *string `json:"description"`
+}
+
+func (b *Bot) IsValid() *AppError {
+	if len(b.UserId) != 26 {
+		return NewAppError("Bot.IsValid", "model.bot.is_valid.user_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(b.Description) > BOT_DESCRIPTION_MAX_RUNES {
+		return NewAppError("Bot.IsValid", "model.bot.is_valid.description.app_error", nil, "user_id="+b.UserId, http.StatusBadRequest)
+	}
+
+	if len(b.OwnerId) != 26 {
+		return NewAppError("Bot.IsValid", "model.bot.is_valid.owner_id.app_error", nil, "user_id="+b.UserId, http.StatusBadRequest)
+	}
+
+	if b.CreateAt == 0 {
+		return NewAppError("Bot.IsValid", "model.bot.is_valid.create_at.app_error", nil, "user_id="+b.UserId, http.StatusBadRequest)
+	}
+
+	if b.UpdateAt == 0 {
+		return NewAppError("Bot.IsValid", "model.bot.is_valid.update_at.app_error", nil, "user_id="+b.UserId, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (b *Bot) PreSave() {
+	b.CreateAt = GetMillis()
+	b.UpdateAt = b.CreateAt
+}
+
+func (b *Bot) PreUpdate() {
+	b.UpdateAt = GetMillis()
+}
+
+func (b *Bot) Patch(patch *BotPatch) {
+	if patch.Username != nil {
+		b.Username = *patch.Username
+	}
+
+	if patch.DisplayName != nil {
+		b.DisplayName = *patch.DisplayName
+	}
+
+	if patch.Description != nil {
+		b.Description = *patch.Description
+	}
+}
+
+// IsValidUserFields checks the fields of a bot that are saved on its user, so that a bad username or display name can
+// be reported before the user is created.
+func (b *Bot) IsValidUserFields() *AppError {
+	if !IsValidUsername(b.Username) {
+		return NewAppError("Bot.IsValid", "model.bot.is_valid.username.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(b.DisplayName) > BOT_DISPLAY_NAME_MAX_RUNES {
+		return NewAppError("Bot.IsValid", "model.bot.is_valid.display_name.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// ToUser returns the user that's created for a new bot. Bots can't log in, so the user has no password and an email
+// address that can't receive mail.
+func (b *Bot) ToUser() *User {
+	return &User{
+		Username:  b.Username,
+		Email:     strings.ToLower(b.Username) + "@localhost",
+		FirstName: b.DisplayName,
+		IsBot:     true,
+	}
+}
+
+// SetUserFields copies the fields of a bot that are kept on its user.
+func (b *Bot) SetUserFields(user *User) {
+	b.Username = user.Username
+	b.DisplayName = user.FirstName
+}
+
+func (b *Bot) ToJson() string {
+	if b, err := json.Marshal(b); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func BotFromJson(data io.Reader) *Bot {
+	var b Bot
+
+	if err := json.NewDecoder(data).Decode(&b); err != nil {
+		return nil
+	} else {
+		return &b
+	}
+}
+
+func (p *BotPatch) ToJson() string {
+	if b, err := json.Marshal(p); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func BotPatchFromJson(data io.Reader) *BotPatch {
+	var p BotPatch
+
+	if err := json.NewDecoder(data).Decode(&p); err != nil {
+		return nil
+	} else {
+		return &p
+	}
+}
+
+func BotListToJson(b []*Bot) string {
+	if b, err := json.Marshal(b); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func BotListFromJson(data io.Reader) []*Bot {
+	var b []*Bot
+
+	if err := json.NewDecoder(data).Decode(&b); err != nil {
+		return nil
+	} else {
+		return b
+	}
+}
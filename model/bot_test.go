@@ -0,0 +1,119 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBotJson(t *testing.T) {
+	bot := Bot{
+		UserId:      NewId(),
+		Username:    "somebot",
+		DisplayName: "Some Bot",
+		Description: "does things",
+		OwnerId:     NewId(),
+	}
+
+	rbot := BotFromJson(strings.NewReader(bot.ToJson()))
+	if rbot.UserId != bot.UserId || rbot.Username != bot.Username || rbot.DisplayName != bot.DisplayName || rbot.OwnerId != bot.OwnerId {
+		t.Fatal("bots do not match")
+	}
+
+	rbots := BotListFromJson(strings.NewReader(BotListToJson([]*Bot{&bot})))
+	if len(rbots) != 1 || rbots[0].UserId != bot.UserId {
+		t.Fatal("bot lists do not match")
+	}
+
+	username := "otherbot"
+	rpatch := BotPatchFromJson(strings.NewReader((&BotPatch{Username: &username}).ToJson()))
+	if rpatch.Username == nil || *rpatch.Username != username || rpatch.Description != nil {
+		t.Fatal("patches do not match")
+	}
+}
+
+func TestBotIsValid(t *testing.T) {
+	bot := Bot{
+		UserId:  NewId(),
+		OwnerId: NewId(),
+	}
+	bot.PreSave()
+
+	if err := bot.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	bot.UserId = "junk"
+	if err := bot.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	bot.UserId = NewId()
+	bot.OwnerId = ""
+	if err := bot.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	bot.OwnerId = NewId()
+	bot.Description = strings.Repeat("a", BOT_DESCRIPTION_MAX_RUNES+1)
+	if err := bot.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	bot.Description = ""
+	bot.CreateAt = 0
+	if err := bot.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestBotIsValidUserFields(t *testing.T) {
+	bot := Bot{Username: "somebot", DisplayName: "Some Bot"}
+
+	if err := bot.IsValidUserFields(); err != nil {
+		t.Fatal(err)
+	}
+
+	bot.Username = "not valid"
+	if err := bot.IsValidUserFields(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	bot.Username = "somebot"
+	bot.DisplayName = strings.Repeat("a", BOT_DISPLAY_NAME_MAX_RUNES+1)
+	if err := bot.IsValidUserFields(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestBotPatch(t *testing.T) {
+	bot := Bot{Username: "somebot", DisplayName: "Some Bot", Description: "does things"}
+
+	description := "does other things"
+	bot.Patch(&BotPatch{Description: &description})
+
+	if bot.Description != description || bot.Username != "somebot" || bot.DisplayName != "Some Bot" {
+		t.Fatal("should only have patched the description")
+	}
+}
+
+func TestBotToUser(t *testing.T) {
+	bot := Bot{Username: "SomeBot", DisplayName: "Some Bot"}
+
+	user := bot.ToUser()
+	if !user.IsBot || user.Username != bot.Username || user.FirstName != bot.DisplayName || user.Email != "somebot@localhost" {
+		t.Fatal("should have made a bot user", user.ToJson())
+	}
+
+	if user.Password != "" {
+		t.Fatal("bots shouldn't have a password")
+	}
+
+	rbot := Bot{}
+	rbot.SetUserFields(user)
+	if rbot.Username != bot.Username || rbot.DisplayName != bot.DisplayName {
+		t.Fatal("should have copied the user fields")
+	}
+}
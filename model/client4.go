@@ -154,6 +154,14 @@ func (c *Client4) GetFileRoute(fileId string) string {
 	return fmt.Sprintf(c.GetFilesRoute()+"/%v", fileId)
 }
 
+func (c *Client4) GetUploadsRoute() string {
+	return fmt.Sprintf("/uploads")
+}
+
+func (c *Client4) GetUploadRoute(uploadId string) string {
+	return fmt.Sprintf(c.GetUploadsRoute()+"/%v", uploadId)
+}
+
 func (c *Client4) GetSystemRoute() string {
 	return fmt.Sprintf("/system")
 }
@@ -190,6 +198,50 @@ func (c *Client4) GetComplianceReportRoute(reportId string) string {
 	return fmt.Sprintf("/compliance/reports/%v", reportId)
 }
 
+func (c *Client4) GetJobsRoute() string {
+	return fmt.Sprintf("/jobs")
+}
+
+func (c *Client4) GetJobRoute(jobId string) string {
+	return fmt.Sprintf(c.GetJobsRoute()+"/%v", jobId)
+}
+
+func (c *Client4) GetPluginsRoute() string {
+	return fmt.Sprintf("/plugins")
+}
+
+func (c *Client4) GetPluginRoute(pluginId string) string {
+	return fmt.Sprintf(c.GetPluginsRoute()+"/%v", pluginId)
+}
+
+func (c *Client4) GetBotsRoute() string {
+	return fmt.Sprintf("/bots")
+}
+
+func (c *Client4) GetBotRoute(botUserId string) string {
+	return fmt.Sprintf(c.GetBotsRoute()+"/%v", botUserId)
+}
+
+func (c *Client4) GetRolesRoute() string {
+	return fmt.Sprintf("/roles")
+}
+
+func (c *Client4) GetRoleRoute(roleId string) string {
+	return fmt.Sprintf(c.GetRolesRoute()+"/%v", roleId)
+}
+
+func (c *Client4) GetSchemesRoute() string {
+	return fmt.Sprintf("/schemes")
+}
+
+func (c *Client4) GetSchemeRoute(schemeId string) string {
+	return fmt.Sprintf(c.GetSchemesRoute()+"/%v", schemeId)
+}
+
+func (c *Client4) GetDialogsRoute() string {
+	return fmt.Sprintf("/actions/dialogs")
+}
+
 func (c *Client4) GetOutgoingWebhooksRoute() string {
 	return fmt.Sprintf("/hooks/outgoing")
 }
@@ -693,6 +745,17 @@ func (c *Client4) GenerateMfaSecret(userId string) (*MfaSecret, *Response) {
 	}
 }
 
+// GenerateMfaRecoveryCodes will replace a user's MFA recovery codes with new ones and return
+// them. Each code can be used once in place of an MFA token.
+func (c *Client4) GenerateMfaRecoveryCodes(userId string) ([]string, *Response) {
+	if r, err := c.DoApiPost(c.GetUserRoute(userId)+"/mfa/recovery_codes", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return ArrayFromJson(r.Body), BuildResponse(r)
+	}
+}
+
 // UpdateUserPassword updates a user's password. Must be logged in as the user or be a system administrator.
 func (c *Client4) UpdateUserPassword(userId, currentPassword, newPassword string) (bool, *Response) {
 	requestBody := map[string]string{"current_password": currentPassword, "new_password": newPassword}
@@ -879,6 +942,59 @@ func (c *Client4) SetProfileImage(userId string, data []byte) (bool, *Response)
 	}
 }
 
+// CreateUserAccessToken will generate a user access token that can be used in place
+// of a session token to access the REST API. Must have the 'create_user_access_token'
+// permission and if generating for another user, must have the 'edit_other_users'
+// permission. A non-blank description is required.
+func (c *Client4) CreateUserAccessToken(userId, description string) (*UserAccessToken, *Response) {
+	requestBody := map[string]string{"description": description}
+	if r, err := c.DoApiPost(c.GetUserRoute(userId)+"/tokens", MapToJson(requestBody)); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return UserAccessTokenFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetUserAccessTokensForUser will get a paged list of user access tokens for a user.
+// Does not include the actual authentication tokens. Must have the 'read_user_access_token'
+// permission and if getting for another user, must have the 'edit_other_users' permission.
+func (c *Client4) GetUserAccessTokensForUser(userId string, page, perPage int) ([]*UserAccessToken, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
+	if r, err := c.DoApiGet(c.GetUserRoute(userId)+"/tokens"+query, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return UserAccessTokenListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetUserAccessToken will get a user access token's id, description and the user_id
+// of the user it is for. The actual token will not be returned. Must have the
+// 'read_user_access_token' permission and if getting for another user, must have the
+// 'edit_other_users' permission.
+func (c *Client4) GetUserAccessToken(tokenId string) (*UserAccessToken, *Response) {
+	if r, err := c.DoApiGet(c.GetUsersRoute()+"/tokens/"+tokenId, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return UserAccessTokenFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// RevokeUserAccessToken will revoke a user access token by id. Must have the
+// 'revoke_user_access_token' permission and if revoking for another user, must have the
+// 'edit_other_users' permission.
+func (c *Client4) RevokeUserAccessToken(tokenId string) (bool, *Response) {
+	requestBody := map[string]string{"token_id": tokenId}
+	if r, err := c.DoApiPost(c.GetUsersRoute()+"/tokens/revoke", MapToJson(requestBody)); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
 // Team Section
 
 // CreateTeam creates a team in the system based on the provided team struct.
@@ -994,6 +1110,17 @@ func (c *Client4) PatchTeam(teamId string, patch *TeamPatch) (*Team, *Response)
 	}
 }
 
+// UpdateTeamScheme sets the scheme that a team uses. An empty schemeId moves the team back to the built-in roles.
+func (c *Client4) UpdateTeamScheme(teamId string, schemeId string) (*Team, *Response) {
+	data := map[string]string{"scheme_id": schemeId}
+	if r, err := c.DoApiPut(c.GetTeamRoute(teamId)+"/scheme", MapToJson(data)); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return TeamFromJson(r.Body), BuildResponse(r)
+	}
+}
+
 // SoftDeleteTeam deletes the team softly (archive only, not permanent delete).
 func (c *Client4) SoftDeleteTeam(teamId string) (bool, *Response) {
 	if r, err := c.DoApiDelete(c.GetTeamRoute(teamId)); err != nil {
@@ -1147,6 +1274,17 @@ func (c *Client4) InviteUsersToTeam(teamId string, userEmails []string) (bool, *
 	}
 }
 
+// InviteGuestsToTeam will send an email invitation to join a team as a guest who is only added to the given channels.
+func (c *Client4) InviteGuestsToTeam(teamId string, userEmails []string, channelIds []string) (bool, *Response) {
+	invite := &GuestsInvite{Emails: userEmails, Channels: channelIds}
+	if r, err := c.DoApiPost(c.GetTeamRoute(teamId)+"/invite-guests/email", invite.ToJson()); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
 // Channel Section
 
 // CreateChannel creates a channel based on the provided channel struct.
@@ -1262,6 +1400,16 @@ func (c *Client4) GetChannelsForTeamForUser(teamId, userId, etag string) (*Chann
 	}
 }
 
+// GetArchivedChannelsForTeamForUser returns a list of the archived channels on a team that a user is a member of.
+func (c *Client4) GetArchivedChannelsForTeamForUser(teamId, userId string) (*ChannelList, *Response) {
+	if r, err := c.DoApiGet(c.GetUserRoute(userId)+c.GetTeamRoute(teamId)+"/channels/archived", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return ChannelListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
 // SearchChannels returns the channels on a team matching the provided search term.
 func (c *Client4) SearchChannels(teamId string, search *ChannelSearch) (*ChannelList, *Response) {
 	if r, err := c.DoApiPost(c.GetChannelsForTeamRoute(teamId)+"/search", search.ToJson()); err != nil {
@@ -1282,6 +1430,16 @@ func (c *Client4) DeleteChannel(channelId string) (bool, *Response) {
 	}
 }
 
+// RestoreChannel unarchives a channel based on the provided channel id string.
+func (c *Client4) RestoreChannel(channelId string) (*Channel, *Response) {
+	if r, err := c.DoApiPost(c.GetChannelRoute(channelId)+"/restore", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return ChannelFromJson(r.Body), BuildResponse(r)
+	}
+}
+
 // GetChannelByName returns a channel based on the provided channel name and team id strings.
 func (c *Client4) GetChannelByName(channelName, teamId string, etag string) (*Channel, *Response) {
 	if r, err := c.DoApiGet(c.GetChannelByNameRoute(channelName, teamId), etag); err != nil {
@@ -1450,6 +1608,17 @@ func (c *Client4) PinPost(postId string) (bool, *Response) {
 	}
 }
 
+// DoPostAction performs a post action, sending the selected option when the action is a select menu.
+func (c *Client4) DoPostAction(postId, actionId, selectedOption string) (bool, *Response) {
+	request := &DoPostActionRequest{SelectedOption: selectedOption}
+	if r, err := c.DoApiPost(c.GetPostRoute(postId)+"/actions/"+actionId, request.ToJson()); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
 // UnpinPost unpin a post based on provided post id string.
 func (c *Client4) UnpinPost(postId string) (bool, *Response) {
 	if r, err := c.DoApiPost(c.GetPostRoute(postId)+"/unpin", ""); err != nil {
@@ -1674,6 +1843,60 @@ func (c *Client4) GetFileInfosForPost(postId string, etag string) ([]*FileInfo,
 	}
 }
 
+// Upload Section
+
+// CreateUploadSession starts a resumable upload of a file into a channel.
+func (c *Client4) CreateUploadSession(us *UploadSession) (*UploadSession, *Response) {
+	if r, err := c.DoApiPost(c.GetUploadsRoute(), us.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return UploadSessionFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetUploadSession gets an upload session, which includes how much of the file has been received so far.
+func (c *Client4) GetUploadSession(uploadId string) (*UploadSession, *Response) {
+	if r, err := c.DoApiGet(c.GetUploadRoute(uploadId), ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return UploadSessionFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// UploadData sends the next chunk of a file for an upload session. The offset must match the amount of data that
+// the server has already received.
+func (c *Client4) UploadData(uploadId string, offset int64, data []byte) (*UploadSession, *Response) {
+	url := c.ApiUrl + c.GetUploadRoute(uploadId) + fmt.Sprintf("?offset=%v", offset)
+	if r, err := c.DoApiRequest(http.MethodPatch, url, string(data), ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return UploadSessionFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// FinishUploadSession completes an upload session once all of the data has been sent and returns the uploaded file's info.
+func (c *Client4) FinishUploadSession(uploadId string) (*FileInfo, *Response) {
+	if r, err := c.DoApiPost(c.GetUploadRoute(uploadId)+"/finish", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return FileInfoFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// CancelUploadSession deletes an upload session along with any data that has been sent for it.
+func (c *Client4) CancelUploadSession(uploadId string) (bool, *Response) {
+	if r, err := c.DoApiDelete(c.GetUploadRoute(uploadId)); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
 // General Section
 
 // GetPing will ping the server and to see if it is up and running.
@@ -1905,6 +2128,18 @@ func (c *Client4) RegenOutgoingHookToken(hookId string) (*OutgoingWebhook, *Resp
 	}
 }
 
+// GetOutgoingWebhookDeliveries returns a page of the delivery log of an outgoing webhook, starting with the most
+// recent attempts. Page counting starts at 0.
+func (c *Client4) GetOutgoingWebhookDeliveries(hookId string, page int, perPage int) ([]*OutgoingWebhookDelivery, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
+	if r, err := c.DoApiGet(c.GetOutgoingWebhookRoute(hookId)+"/deliveries"+query, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return OutgoingWebhookDeliveryListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
 // DeleteOutgoingWebhook delete the outgoing webhook on the system requested by Hook Id.
 func (c *Client4) DeleteOutgoingWebhook(hookId string) (bool, *Response) {
 	if r, err := c.DoApiDelete(c.GetOutgoingWebhookRoute(hookId)); err != nil {
@@ -2564,3 +2799,302 @@ func (c *Client4) DeleteReaction(reaction *Reaction) (bool, *Response) {
 		return CheckStatusOK(r), BuildResponse(r)
 	}
 }
+
+// Jobs Section
+
+// GetJob gets a single job.
+func (c *Client4) GetJob(id string) (*Job, *Response) {
+	if r, err := c.DoApiGet(c.GetJobRoute(id), ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return JobFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetJobs gets a page of jobs of all types, newest first.
+func (c *Client4) GetJobs(page int, perPage int) ([]*Job, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
+	if r, err := c.DoApiGet(c.GetJobsRoute()+query, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return JobsFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetJobsByType gets a page of jobs of the given type, newest first.
+func (c *Client4) GetJobsByType(jobType string, page int, perPage int) ([]*Job, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
+	if r, err := c.DoApiGet(c.GetJobsRoute()+fmt.Sprintf("/type/%v", jobType)+query, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return JobsFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// CreateJob creates a job of the type and with the data given in job. It will be run in the background.
+func (c *Client4) CreateJob(job *Job) (*Job, *Response) {
+	if r, err := c.DoApiPost(c.GetJobsRoute(), job.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return JobFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// CancelJob requests that a job be canceled.
+func (c *Client4) CancelJob(jobId string) (bool, *Response) {
+	if r, err := c.DoApiPost(c.GetJobRoute(jobId)+"/cancel", ""); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
+// Dialogs Section
+
+// OpenInteractiveDialog sends a dialog to the client of the user that the trigger id was created for.
+func (c *Client4) OpenInteractiveDialog(request OpenDialogRequest) (bool, *Response) {
+	if r, err := c.DoApiPost(c.GetDialogsRoute()+"/open", request.ToJson()); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
+// SubmitInteractiveDialog submits a dialog to the integration that opened it and returns the errors that the
+// integration found, if any.
+func (c *Client4) SubmitInteractiveDialog(request SubmitDialogRequest) (*SubmitDialogResponse, *Response) {
+	if r, err := c.DoApiPost(c.GetDialogsRoute()+"/submit", request.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return SubmitDialogResponseFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// Plugins Section
+
+// GetPlugins returns the plugins installed on the server, split between the active and the inactive ones.
+func (c *Client4) GetPlugins() (*PluginsResponse, *Response) {
+	if r, err := c.DoApiGet(c.GetPluginsRoute(), ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return PluginsResponseFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// EnablePlugin enables and activates a plugin.
+func (c *Client4) EnablePlugin(id string) (bool, *Response) {
+	if r, err := c.DoApiPost(c.GetPluginRoute(id)+"/enable", ""); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
+// DisablePlugin disables and deactivates a plugin.
+func (c *Client4) DisablePlugin(id string) (bool, *Response) {
+	if r, err := c.DoApiPost(c.GetPluginRoute(id)+"/disable", ""); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
+// Bots Section
+
+// CreateBot creates a bot that's owned by the current user.
+func (c *Client4) CreateBot(bot *Bot) (*Bot, *Response) {
+	if r, err := c.DoApiPost(c.GetBotsRoute(), bot.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return BotFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// PatchBot changes the username, display name or description of a bot.
+func (c *Client4) PatchBot(botUserId string, patch *BotPatch) (*Bot, *Response) {
+	if r, err := c.DoApiPut(c.GetBotRoute(botUserId)+"/patch", patch.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return BotFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetBot returns a bot, which must not have been disabled.
+func (c *Client4) GetBot(botUserId string, etag string) (*Bot, *Response) {
+	if r, err := c.DoApiGet(c.GetBotRoute(botUserId), etag); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return BotFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetBotIncludeDeleted returns a bot, even if it has been disabled.
+func (c *Client4) GetBotIncludeDeleted(botUserId string, etag string) (*Bot, *Response) {
+	if r, err := c.DoApiGet(c.GetBotRoute(botUserId)+"?include_deleted=true", etag); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return BotFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetBots returns a page of the bots that haven't been disabled. Users who can't read other users' bots only get
+// their own.
+func (c *Client4) GetBots(page int, perPage int, etag string) ([]*Bot, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
+	if r, err := c.DoApiGet(c.GetBotsRoute()+query, etag); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return BotListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetBotsIncludeDeleted returns a page of bots, including the ones that have been disabled.
+func (c *Client4) GetBotsIncludeDeleted(page int, perPage int, etag string) ([]*Bot, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v&include_deleted=true", page, perPage)
+	if r, err := c.DoApiGet(c.GetBotsRoute()+query, etag); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return BotListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// DisableBot disables a bot, which deactivates its user.
+func (c *Client4) DisableBot(botUserId string) (*Bot, *Response) {
+	if r, err := c.DoApiPost(c.GetBotRoute(botUserId)+"/disable", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return BotFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// EnableBot enables a bot that was disabled.
+func (c *Client4) EnableBot(botUserId string) (*Bot, *Response) {
+	if r, err := c.DoApiPost(c.GetBotRoute(botUserId)+"/enable", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return BotFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// AssignBot hands a bot over to another user.
+func (c *Client4) AssignBot(botUserId string, newOwnerId string) (*Bot, *Response) {
+	if r, err := c.DoApiPost(c.GetBotRoute(botUserId)+"/assign/"+newOwnerId, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return BotFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// Roles Section
+
+// GetAllRoles returns the built-in roles, with any changes that were saved to them, and the roles of schemes.
+func (c *Client4) GetAllRoles() ([]*Role, *Response) {
+	if r, err := c.DoApiGet(c.GetRolesRoute(), ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return RoleListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetRole returns a role by its id, such as "system_user".
+func (c *Client4) GetRole(roleId string, etag string) (*Role, *Response) {
+	if r, err := c.DoApiGet(c.GetRoleRoute(roleId), etag); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return RoleFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// PatchRole changes the permissions of a role.
+func (c *Client4) PatchRole(roleId string, patch *RolePatch) (*Role, *Response) {
+	if r, err := c.DoApiPut(c.GetRoleRoute(roleId)+"/patch", patch.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return RoleFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// ResetRole undoes the changes that were made to a built-in role.
+func (c *Client4) ResetRole(roleId string) (*Role, *Response) {
+	if r, err := c.DoApiPost(c.GetRoleRoute(roleId)+"/reset", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return RoleFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// Schemes Section
+
+// CreateScheme creates a scheme along with its team and channel roles.
+func (c *Client4) CreateScheme(scheme *Scheme) (*Scheme, *Response) {
+	if r, err := c.DoApiPost(c.GetSchemesRoute(), scheme.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return SchemeFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetScheme returns a scheme by its id.
+func (c *Client4) GetScheme(schemeId string, etag string) (*Scheme, *Response) {
+	if r, err := c.DoApiGet(c.GetSchemeRoute(schemeId), etag); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return SchemeFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetSchemes returns a page of schemes.
+func (c *Client4) GetSchemes(page int, perPage int, etag string) ([]*Scheme, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
+	if r, err := c.DoApiGet(c.GetSchemesRoute()+query, etag); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return SchemeListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// PatchScheme changes the name or description of a scheme.
+func (c *Client4) PatchScheme(schemeId string, patch *SchemePatch) (*Scheme, *Response) {
+	if r, err := c.DoApiPut(c.GetSchemeRoute(schemeId)+"/patch", patch.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return SchemeFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// DeleteScheme deletes a scheme and moves the teams that used it back to the built-in roles.
+func (c *Client4) DeleteScheme(schemeId string) (bool, *Response) {
+	if r, err := c.DoApiDelete(c.GetSchemeRoute(schemeId)); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterMessageJson(t *testing.T) {
+	m := ClusterMessage{
+		Event: CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_BY_NAME,
+		Data:  NewId(),
+		Props: map[string]string{"name": "town-square"},
+	}
+	json := m.ToJson()
+	result := ClusterMessageFromJson(strings.NewReader(json))
+
+	if result == nil {
+		t.Fatal("should have decoded the message")
+	}
+
+	if m.Event != result.Event || m.Data != result.Data || result.Props["name"] != "town-square" {
+		t.Fatal("messages do not match")
+	}
+
+	if ClusterMessageFromJson(strings.NewReader("junk")) != nil {
+		t.Fatal("should have failed to decode junk")
+	}
+}
@@ -17,6 +17,7 @@ type CommandArgs struct {
 	RootId    string               `json:"root_id"`
 	ParentId  string               `json:"parent_id"`
 	Command   string               `json:"command"`
+	TriggerId string               `json:"trigger_id"`
 	SiteURL   string               `json:"-"`
 	T         goi18n.TranslateFunc `json:"-"`
 	Session   Session              `json:"-"`
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/url"
+	"strings"
 )
 
 const (
@@ -27,6 +28,7 @@ const (
 	SERVICE_GITLAB    = "gitlab"
 	SERVICE_GOOGLE    = "google"
 	SERVICE_OFFICE365 = "office365"
+	SERVICE_OPENID    = "openid"
 
 	WEBSERVER_MODE_REGULAR  = "regular"
 	WEBSERVER_MODE_GZIP     = "gzip"
@@ -107,6 +109,16 @@ const (
 	WEBRTC_SETTINGS_DEFAULT_TURN_URI = ""
 
 	ANALYTICS_SETTINGS_DEFAULT_MAX_USERS_FOR_STATISTICS = 2500
+
+	CLUSTER_SETTINGS_SECRET_MINIMUM_LENGTH = 32
+
+	DATA_RETENTION_SETTINGS_DEFAULT_MESSAGE_RETENTION_DAYS = 365
+	DATA_RETENTION_SETTINGS_DEFAULT_FILE_RETENTION_DAYS    = 365
+
+	BLEVE_SETTINGS_DEFAULT_INDEX_DIR                = ""
+	BLEVE_SETTINGS_DEFAULT_BULK_INDEXING_BATCH_SIZE = 1000
+
+	PLUGIN_SETTINGS_DEFAULT_DIRECTORY = "./plugins"
 )
 
 type ServiceSettings struct {
@@ -135,6 +147,7 @@ type ServiceSettings struct {
 	EnableDeveloper                          *bool
 	EnableSecurityFixAlert                   *bool
 	EnableInsecureOutgoingConnections        *bool
+	AllowedUntrustedInternalConnections      *string
 	EnableMultifactorAuthentication          *bool
 	EnforceMultifactorAuthentication         *bool
 	AllowCorsFrom                            *string
@@ -155,12 +168,17 @@ type ServiceSettings struct {
 	EnableUserTypingMessages                 *bool
 	EnableUserStatuses                       *bool
 	ClusterLogTimeoutMilliseconds            *int
+	EnableUserAccessTokens                   *bool
+	EnableBotAccountCreation                 *bool
 }
 
 type ClusterSettings struct {
 	Enable                 *bool
 	InterNodeListenAddress *string
 	InterNodeUrls          []string
+	Secret                 *string
+	TLSCertFile            *string
+	TLSKeyFile             *string
 }
 
 type MetricsSettings struct {
@@ -173,14 +191,58 @@ type AnalyticsSettings struct {
 	MaxUsersForStatistics *int
 }
 
+type DataRetentionTeamPolicy struct {
+	TeamId               string
+	MessageRetentionDays *int
+	FileRetentionDays    *int
+}
+
+type DataRetentionSettings struct {
+	EnableMessageDeletion *bool
+	EnableFileDeletion    *bool
+	MessageRetentionDays  *int
+	FileRetentionDays     *int
+	TeamPolicies          []DataRetentionTeamPolicy
+}
+
+type BleveSettings struct {
+	IndexDir              *string
+	EnableIndexing        *bool
+	EnableSearching       *bool
+	BulkIndexingBatchSize *int
+}
+
+type PluginState struct {
+	Enable bool
+}
+
+type PluginSettings struct {
+	Enable       *bool
+	Directory    *string
+	Plugins      map[string]interface{}
+	PluginStates map[string]*PluginState
+}
+
+type GuestAccountsSettings struct {
+	Enable         *bool
+	AllowedDomains *string
+}
+
+type JobSettings struct {
+	RunJobs      *bool
+	RunScheduler *bool
+}
+
 type SSOSettings struct {
-	Enable          bool
-	Secret          string
-	Id              string
-	Scope           string
-	AuthEndpoint    string
-	TokenEndpoint   string
-	UserApiEndpoint string
+	Enable            bool
+	Secret            string
+	Id                string
+	Scope             string
+	AuthEndpoint      string
+	TokenEndpoint     string
+	UserApiEndpoint   string
+	DiscoveryEndpoint string
+	ButtonText        string
 }
 
 type SqlSettings struct {
@@ -396,28 +458,34 @@ type WebrtcSettings struct {
 }
 
 type Config struct {
-	ServiceSettings      ServiceSettings
-	TeamSettings         TeamSettings
-	SqlSettings          SqlSettings
-	LogSettings          LogSettings
-	PasswordSettings     PasswordSettings
-	FileSettings         FileSettings
-	EmailSettings        EmailSettings
-	RateLimitSettings    RateLimitSettings
-	PrivacySettings      PrivacySettings
-	SupportSettings      SupportSettings
-	GitLabSettings       SSOSettings
-	GoogleSettings       SSOSettings
-	Office365Settings    SSOSettings
-	LdapSettings         LdapSettings
-	ComplianceSettings   ComplianceSettings
-	LocalizationSettings LocalizationSettings
-	SamlSettings         SamlSettings
-	NativeAppSettings    NativeAppSettings
-	ClusterSettings      ClusterSettings
-	MetricsSettings      MetricsSettings
-	AnalyticsSettings    AnalyticsSettings
-	WebrtcSettings       WebrtcSettings
+	ServiceSettings       ServiceSettings
+	TeamSettings          TeamSettings
+	SqlSettings           SqlSettings
+	LogSettings           LogSettings
+	PasswordSettings      PasswordSettings
+	FileSettings          FileSettings
+	EmailSettings         EmailSettings
+	RateLimitSettings     RateLimitSettings
+	PrivacySettings       PrivacySettings
+	SupportSettings       SupportSettings
+	GitLabSettings        SSOSettings
+	GoogleSettings        SSOSettings
+	Office365Settings     SSOSettings
+	OpenIdSettings        SSOSettings
+	LdapSettings          LdapSettings
+	ComplianceSettings    ComplianceSettings
+	LocalizationSettings  LocalizationSettings
+	SamlSettings          SamlSettings
+	NativeAppSettings     NativeAppSettings
+	ClusterSettings       ClusterSettings
+	MetricsSettings       MetricsSettings
+	AnalyticsSettings     AnalyticsSettings
+	WebrtcSettings        WebrtcSettings
+	JobSettings           JobSettings
+	DataRetentionSettings DataRetentionSettings
+	BleveSettings         BleveSettings
+	PluginSettings        PluginSettings
+	GuestAccountsSettings GuestAccountsSettings
 }
 
 func (o *Config) ToJson() string {
@@ -437,6 +505,8 @@ func (o *Config) GetSSOService(service string) *SSOSettings {
 		return &o.GoogleSettings
 	case SERVICE_OFFICE365:
 		return &o.Office365Settings
+	case SERVICE_OPENID:
+		return &o.OpenIdSettings
 	}
 
 	return nil
@@ -531,6 +601,11 @@ func (o *Config) SetDefaults() {
 		*o.ServiceSettings.EnableInsecureOutgoingConnections = false
 	}
 
+	if o.ServiceSettings.AllowedUntrustedInternalConnections == nil {
+		o.ServiceSettings.AllowedUntrustedInternalConnections = new(string)
+		*o.ServiceSettings.AllowedUntrustedInternalConnections = ""
+	}
+
 	if o.ServiceSettings.EnableMultifactorAuthentication == nil {
 		o.ServiceSettings.EnableMultifactorAuthentication = new(bool)
 		*o.ServiceSettings.EnableMultifactorAuthentication = false
@@ -954,6 +1029,21 @@ func (o *Config) SetDefaults() {
 		o.ClusterSettings.InterNodeUrls = []string{}
 	}
 
+	if o.ClusterSettings.Secret == nil {
+		o.ClusterSettings.Secret = new(string)
+		*o.ClusterSettings.Secret = ""
+	}
+
+	if o.ClusterSettings.TLSCertFile == nil {
+		o.ClusterSettings.TLSCertFile = new(string)
+		*o.ClusterSettings.TLSCertFile = ""
+	}
+
+	if o.ClusterSettings.TLSKeyFile == nil {
+		o.ClusterSettings.TLSKeyFile = new(string)
+		*o.ClusterSettings.TLSKeyFile = ""
+	}
+
 	if o.MetricsSettings.ListenAddress == nil {
 		o.MetricsSettings.ListenAddress = new(string)
 		*o.MetricsSettings.ListenAddress = ":8067"
@@ -969,6 +1059,88 @@ func (o *Config) SetDefaults() {
 		*o.AnalyticsSettings.MaxUsersForStatistics = ANALYTICS_SETTINGS_DEFAULT_MAX_USERS_FOR_STATISTICS
 	}
 
+	if o.DataRetentionSettings.EnableMessageDeletion == nil {
+		o.DataRetentionSettings.EnableMessageDeletion = new(bool)
+		*o.DataRetentionSettings.EnableMessageDeletion = false
+	}
+
+	if o.DataRetentionSettings.EnableFileDeletion == nil {
+		o.DataRetentionSettings.EnableFileDeletion = new(bool)
+		*o.DataRetentionSettings.EnableFileDeletion = false
+	}
+
+	if o.DataRetentionSettings.MessageRetentionDays == nil {
+		o.DataRetentionSettings.MessageRetentionDays = new(int)
+		*o.DataRetentionSettings.MessageRetentionDays = DATA_RETENTION_SETTINGS_DEFAULT_MESSAGE_RETENTION_DAYS
+	}
+
+	if o.DataRetentionSettings.FileRetentionDays == nil {
+		o.DataRetentionSettings.FileRetentionDays = new(int)
+		*o.DataRetentionSettings.FileRetentionDays = DATA_RETENTION_SETTINGS_DEFAULT_FILE_RETENTION_DAYS
+	}
+
+	if o.DataRetentionSettings.TeamPolicies == nil {
+		o.DataRetentionSettings.TeamPolicies = []DataRetentionTeamPolicy{}
+	}
+
+	if o.JobSettings.RunJobs == nil {
+		o.JobSettings.RunJobs = new(bool)
+		*o.JobSettings.RunJobs = true
+	}
+
+	if o.JobSettings.RunScheduler == nil {
+		o.JobSettings.RunScheduler = new(bool)
+		*o.JobSettings.RunScheduler = true
+	}
+
+	if o.BleveSettings.IndexDir == nil {
+		o.BleveSettings.IndexDir = new(string)
+		*o.BleveSettings.IndexDir = BLEVE_SETTINGS_DEFAULT_INDEX_DIR
+	}
+
+	if o.BleveSettings.EnableIndexing == nil {
+		o.BleveSettings.EnableIndexing = new(bool)
+		*o.BleveSettings.EnableIndexing = false
+	}
+
+	if o.BleveSettings.EnableSearching == nil {
+		o.BleveSettings.EnableSearching = new(bool)
+		*o.BleveSettings.EnableSearching = false
+	}
+
+	if o.BleveSettings.BulkIndexingBatchSize == nil {
+		o.BleveSettings.BulkIndexingBatchSize = new(int)
+		*o.BleveSettings.BulkIndexingBatchSize = BLEVE_SETTINGS_DEFAULT_BULK_INDEXING_BATCH_SIZE
+	}
+
+	if o.PluginSettings.Enable == nil {
+		o.PluginSettings.Enable = new(bool)
+		*o.PluginSettings.Enable = false
+	}
+
+	if o.PluginSettings.Directory == nil {
+		o.PluginSettings.Directory = new(string)
+		*o.PluginSettings.Directory = PLUGIN_SETTINGS_DEFAULT_DIRECTORY
+	}
+
+	if o.PluginSettings.Plugins == nil {
+		o.PluginSettings.Plugins = make(map[string]interface{})
+	}
+
+	if o.PluginSettings.PluginStates == nil {
+		o.PluginSettings.PluginStates = make(map[string]*PluginState)
+	}
+
+	if o.GuestAccountsSettings.Enable == nil {
+		o.GuestAccountsSettings.Enable = new(bool)
+		*o.GuestAccountsSettings.Enable = false
+	}
+
+	if o.GuestAccountsSettings.AllowedDomains == nil {
+		o.GuestAccountsSettings.AllowedDomains = new(string)
+		*o.GuestAccountsSettings.AllowedDomains = ""
+	}
+
 	if o.ComplianceSettings.Enable == nil {
 		o.ComplianceSettings.Enable = new(bool)
 		*o.ComplianceSettings.Enable = false
@@ -1089,6 +1261,14 @@ func (o *Config) SetDefaults() {
 		*o.SamlSettings.LocaleAttribute = SAML_SETTINGS_DEFAULT_LOCALE_ATTRIBUTE
 	}
 
+	if o.OpenIdSettings.Scope == "" {
+		o.OpenIdSettings.Scope = OPENID_SETTINGS_DEFAULT_SCOPE
+	}
+
+	if o.OpenIdSettings.ButtonText == "" {
+		o.OpenIdSettings.ButtonText = USER_AUTH_SERVICE_OPENID_TEXT
+	}
+
 	if o.NativeAppSettings.AppDownloadLink == nil {
 		o.NativeAppSettings.AppDownloadLink = new(string)
 		*o.NativeAppSettings.AppDownloadLink = NATIVEAPP_SETTINGS_DEFAULT_APP_DOWNLOAD_LINK
@@ -1184,6 +1364,16 @@ func (o *Config) SetDefaults() {
 		*o.ServiceSettings.ClusterLogTimeoutMilliseconds = 2000
 	}
 
+	if o.ServiceSettings.EnableUserAccessTokens == nil {
+		o.ServiceSettings.EnableUserAccessTokens = new(bool)
+		*o.ServiceSettings.EnableUserAccessTokens = false
+	}
+
+	if o.ServiceSettings.EnableBotAccountCreation == nil {
+		o.ServiceSettings.EnableBotAccountCreation = new(bool)
+		*o.ServiceSettings.EnableBotAccountCreation = false
+	}
+
 	o.defaultWebrtcSettings()
 }
 
@@ -1399,6 +1589,26 @@ func (o *Config) IsValid() *AppError {
 		return err
 	}
 
+	if err := o.isValidDataRetentionSettings(); err != nil {
+		return err
+	}
+
+	if err := o.isValidBleveSettings(); err != nil {
+		return err
+	}
+
+	if err := o.isValidOpenIdSettings(); err != nil {
+		return err
+	}
+
+	if err := o.isValidClusterSettings(); err != nil {
+		return err
+	}
+
+	if *o.PluginSettings.Enable && len(*o.PluginSettings.Directory) == 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.plugin_directory.app_error", nil, "")
+	}
+
 	if !(*o.ServiceSettings.ConnectionSecurity == CONN_SECURITY_NONE || *o.ServiceSettings.ConnectionSecurity == CONN_SECURITY_TLS) {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.webserver_security.app_error", nil, "")
 	}
@@ -1445,9 +1655,17 @@ func (o *Config) Sanitize() {
 		o.GitLabSettings.Secret = FAKE_SETTING
 	}
 
+	if len(o.OpenIdSettings.Secret) > 0 {
+		o.OpenIdSettings.Secret = FAKE_SETTING
+	}
+
 	o.SqlSettings.DataSource = FAKE_SETTING
 	o.SqlSettings.AtRestEncryptKey = FAKE_SETTING
 
+	if o.ClusterSettings.Secret != nil && len(*o.ClusterSettings.Secret) > 0 {
+		*o.ClusterSettings.Secret = FAKE_SETTING
+	}
+
 	for i := range o.SqlSettings.DataSourceReplicas {
 		o.SqlSettings.DataSourceReplicas[i] = FAKE_SETTING
 	}
@@ -1499,6 +1717,80 @@ func (o *Config) defaultWebrtcSettings() {
 	}
 }
 
+func (o *Config) isValidDataRetentionSettings() *AppError {
+	if *o.DataRetentionSettings.MessageRetentionDays <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.data_retention.message_retention_days.app_error", nil, "")
+	}
+
+	if *o.DataRetentionSettings.FileRetentionDays <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.data_retention.file_retention_days.app_error", nil, "")
+	}
+
+	for _, policy := range o.DataRetentionSettings.TeamPolicies {
+		if len(policy.TeamId) != 26 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.data_retention.team_id.app_error", nil, "team_id="+policy.TeamId)
+		}
+
+		if policy.MessageRetentionDays != nil && *policy.MessageRetentionDays <= 0 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.data_retention.message_retention_days.app_error", nil, "team_id="+policy.TeamId)
+		}
+
+		if policy.FileRetentionDays != nil && *policy.FileRetentionDays <= 0 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.data_retention.file_retention_days.app_error", nil, "team_id="+policy.TeamId)
+		}
+	}
+
+	return nil
+}
+
+func (o *Config) isValidBleveSettings() *AppError {
+	if *o.BleveSettings.EnableIndexing && len(*o.BleveSettings.IndexDir) == 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.bleve_search.index_dir.app_error", nil, "")
+	}
+
+	if *o.BleveSettings.EnableSearching && !*o.BleveSettings.EnableIndexing {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.bleve_search.enable_searching.app_error", nil, "")
+	}
+
+	if *o.BleveSettings.BulkIndexingBatchSize <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.bleve_search.bulk_indexing_batch_size.app_error", nil, "")
+	}
+
+	return nil
+}
+
+func (o *Config) isValidOpenIdSettings() *AppError {
+	if o.OpenIdSettings.Enable {
+		if len(o.OpenIdSettings.DiscoveryEndpoint) == 0 || !IsValidHttpUrl(o.OpenIdSettings.DiscoveryEndpoint) {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.openid_discovery_endpoint.app_error", nil, "")
+		} else if len(o.OpenIdSettings.Id) == 0 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.openid_id.app_error", nil, "")
+		}
+	}
+
+	return nil
+}
+
+// isValidClusterSettings checks that the servers of a cluster can only talk to each other over TLS and sign their
+// requests with a secret of their own.
+func (o *Config) isValidClusterSettings() *AppError {
+	if *o.ClusterSettings.Enable {
+		if len(*o.ClusterSettings.Secret) < CLUSTER_SETTINGS_SECRET_MINIMUM_LENGTH {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.cluster_secret.app_error", map[string]interface{}{"MinLength": CLUSTER_SETTINGS_SECRET_MINIMUM_LENGTH}, "")
+		} else if len(*o.ClusterSettings.TLSCertFile) == 0 || len(*o.ClusterSettings.TLSKeyFile) == 0 {
+			return NewLocAppError("Config.IsValid", "model.config.is_valid.cluster_tls.app_error", nil, "")
+		}
+
+		for _, interNodeUrl := range o.ClusterSettings.InterNodeUrls {
+			if interNodeUrl = strings.TrimSpace(interNodeUrl); len(interNodeUrl) > 0 && !strings.HasPrefix(interNodeUrl, "https://") {
+				return NewLocAppError("Config.IsValid", "model.config.is_valid.cluster_inter_node_url.app_error", nil, "url="+interNodeUrl)
+			}
+		}
+	}
+
+	return nil
+}
+
 func (o *Config) isValidWebrtcSettings() *AppError {
 	if *o.WebrtcSettings.Enable {
 		if len(*o.WebrtcSettings.GatewayWebsocketUrl) == 0 || !IsValidWebsocketUrl(*o.WebrtcSettings.GatewayWebsocketUrl) {
@@ -15,3 +15,138 @@ func TestConfigDefaultFileSettingsDirectory(t *testing.T) {
 		t.Fatal("FileSettings.Directory should default to './data/'")
 	}
 }
+
+func TestConfigDataRetentionSettings(t *testing.T) {
+	c1 := Config{}
+	c1.SetDefaults()
+
+	if *c1.DataRetentionSettings.EnableMessageDeletion || *c1.DataRetentionSettings.EnableFileDeletion {
+		t.Fatal("data retention should be disabled by default")
+	}
+
+	if err := c1.isValidDataRetentionSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	days := 0
+	c1.DataRetentionSettings.TeamPolicies = []DataRetentionTeamPolicy{{TeamId: NewId(), MessageRetentionDays: &days}}
+	if err := c1.isValidDataRetentionSettings(); err == nil {
+		t.Fatal("team policy should need a positive number of days")
+	}
+
+	days = 30
+	if err := c1.isValidDataRetentionSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	c1.DataRetentionSettings.TeamPolicies[0].TeamId = "junk"
+	if err := c1.isValidDataRetentionSettings(); err == nil {
+		t.Fatal("team policy should need a valid team id")
+	}
+
+	c1.DataRetentionSettings.TeamPolicies = nil
+	*c1.DataRetentionSettings.FileRetentionDays = 0
+	if err := c1.isValidDataRetentionSettings(); err == nil {
+		t.Fatal("file retention should need a positive number of days")
+	}
+}
+
+func TestConfigBleveSettings(t *testing.T) {
+	c1 := Config{}
+	c1.SetDefaults()
+
+	if *c1.BleveSettings.EnableIndexing || *c1.BleveSettings.EnableSearching {
+		t.Fatal("bleve should be disabled by default")
+	}
+
+	if err := c1.isValidBleveSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	*c1.BleveSettings.EnableIndexing = true
+	if err := c1.isValidBleveSettings(); err == nil {
+		t.Fatal("indexing should need an index directory")
+	}
+
+	*c1.BleveSettings.IndexDir = "./data/bleve"
+	if err := c1.isValidBleveSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	*c1.BleveSettings.EnableIndexing = false
+	*c1.BleveSettings.EnableSearching = true
+	if err := c1.isValidBleveSettings(); err == nil {
+		t.Fatal("searching should need indexing to be enabled")
+	}
+}
+
+func TestConfigOpenIdSettings(t *testing.T) {
+	c1 := Config{}
+	c1.SetDefaults()
+
+	if c1.OpenIdSettings.Scope != OPENID_SETTINGS_DEFAULT_SCOPE || c1.OpenIdSettings.ButtonText != USER_AUTH_SERVICE_OPENID_TEXT {
+		t.Fatal("openid should have a default scope and button text")
+	}
+
+	if c1.GetSSOService(SERVICE_OPENID) != &c1.OpenIdSettings {
+		t.Fatal("openid should be an SSO service")
+	}
+
+	if err := c1.isValidOpenIdSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	c1.OpenIdSettings.Enable = true
+	c1.OpenIdSettings.Id = "mattermost"
+	if err := c1.isValidOpenIdSettings(); err == nil {
+		t.Fatal("openid should need a discovery endpoint")
+	}
+
+	c1.OpenIdSettings.DiscoveryEndpoint = "https://keycloak.example.com/auth/realms/master"
+	if err := c1.isValidOpenIdSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	c1.OpenIdSettings.Id = ""
+	if err := c1.isValidOpenIdSettings(); err == nil {
+		t.Fatal("openid should need a client id")
+	}
+}
+
+func TestConfigClusterSettings(t *testing.T) {
+	c1 := Config{}
+	c1.SetDefaults()
+
+	if err := c1.isValidClusterSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	*c1.ClusterSettings.Enable = true
+	*c1.ClusterSettings.TLSCertFile = "cluster.crt"
+	*c1.ClusterSettings.TLSKeyFile = "cluster.key"
+	c1.ClusterSettings.InterNodeUrls = []string{"https://10.0.0.1:8075", "https://10.0.0.2:8075"}
+	if err := c1.isValidClusterSettings(); err == nil {
+		t.Fatal("cluster should need a secret")
+	}
+
+	*c1.ClusterSettings.Secret = NewRandomString(CLUSTER_SETTINGS_SECRET_MINIMUM_LENGTH)
+	if err := c1.isValidClusterSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	c1.ClusterSettings.InterNodeUrls = append(c1.ClusterSettings.InterNodeUrls, "http://10.0.0.3:8075")
+	if err := c1.isValidClusterSettings(); err == nil {
+		t.Fatal("cluster should need https between servers")
+	}
+
+	c1.ClusterSettings.InterNodeUrls = c1.ClusterSettings.InterNodeUrls[:2]
+	*c1.ClusterSettings.TLSKeyFile = ""
+	if err := c1.isValidClusterSettings(); err == nil {
+		t.Fatal("cluster should need a TLS key")
+	}
+
+	c1.Sanitize()
+	if *c1.ClusterSettings.Secret != FAKE_SETTING {
+		t.Fatal("should sanitize the cluster secret")
+	}
+}
@@ -0,0 +1,428 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	DIALOG_ELEMENT_TYPE_TEXT     = "text"
+	DIALOG_ELEMENT_TYPE_TEXTAREA = "textarea"
+	DIALOG_ELEMENT_TYPE_SELECT   = "select"
+
+	DIALOG_TEXT_SUBTYPE_TEXT     = "text"
+	DIALOG_TEXT_SUBTYPE_EMAIL    = "email"
+	DIALOG_TEXT_SUBTYPE_NUMBER   = "number"
+	DIALOG_TEXT_SUBTYPE_PASSWORD = "password"
+	DIALOG_TEXT_SUBTYPE_TEL      = "tel"
+	DIALOG_TEXT_SUBTYPE_URL      = "url"
+
+	DIALOG_SUBMISSION_TYPE = "dialog_submission"
+
+	DIALOG_MAX_ELEMENTS                   = 5
+	DIALOG_TITLE_MAX_RUNES                = 24
+	DIALOG_ELEMENT_DISPLAY_NAME_MAX_RUNES = 24
+	DIALOG_ELEMENT_NAME_MAX_RUNES         = 300
+	DIALOG_ELEMENT_HELP_TEXT_MAX_RUNES    = 150
+	DIALOG_TEXT_MAX_LENGTH                = 150
+	DIALOG_TEXTAREA_MAX_LENGTH            = 3000
+
+	DIALOG_TRIGGER_ID_EXPIRY_TIME = 3000           // 3 seconds
+	DIALOG_ID_EXPIRY_TIME         = 1000 * 60 * 60 // 1 hour
+)
+
+// Dialog is a form that an integration asks a client to show to a user. What the user enters is submitted back to
+// the integration, which can reject it with an error for each of the elements.
+type Dialog struct {
+	CallbackId     string          `json:"callback_id"`
+	Title          string          `json:"title"`
+	IconURL        string          `json:"icon_url"`
+	Elements       []DialogElement `json:"elements"`
+	SubmitLabel    string          `json:"submit_label"`
+	NotifyOnCancel bool            `json:"notify_on_cancel"`
+	State          string          `json:"state"`
+}
+
+type DialogElement struct {
+	DisplayName string               `json:"display_name"`
+	Name        string               `json:"name"`
+	Type        string               `json:"type"`
+	SubType     string               `json:"subtype"`
+	Default     string               `json:"default"`
+	Placeholder string               `json:"placeholder"`
+	HelpText    string               `json:"help_text"`
+	Optional    bool                 `json:"optional"`
+	MinLength   int                  `json:"min_length"`
+	MaxLength   int                  `json:"max_length"`
+	DataSource  string               `json:"data_source"`
+	Options     []*PostActionOptions `json:"options"`
+}
+
+type OpenDialogRequest struct {
+	TriggerId string `json:"trigger_id"`
+	URL       string `json:"url"`
+	Dialog    Dialog `json:"dialog"`
+}
+
+type SubmitDialogRequest struct {
+	Type       string                 `json:"type"`
+	DialogId   string                 `json:"dialog_id,omitempty"`
+	CallbackId string                 `json:"callback_id"`
+	State      string                 `json:"state"`
+	UserId     string                 `json:"user_id"`
+	ChannelId  string                 `json:"channel_id"`
+	TeamId     string                 `json:"team_id"`
+	Submission map[string]interface{} `json:"submission"`
+	Cancelled  bool                   `json:"cancelled"`
+}
+
+type SubmitDialogResponse struct {
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// OpenedDialog is what the server needs to know about a dialog when it's submitted. It's signed and sent to the
+// client as the dialog id, so the client can't change where the dialog is submitted or what its elements accept.
+type OpenedDialog struct {
+	UserId   string `json:"user_id"`
+	URL      string `json:"url"`
+	Dialog   Dialog `json:"dialog"`
+	CreateAt int64  `json:"create_at"`
+}
+
+// GenerateTriggerId creates a trigger id that lets an integration open a dialog for a user shortly after the user ran
+// a command or clicked an action. It returns the id that the client can use to match the dialog to what the user did
+// along with the trigger id to send to the integration.
+func GenerateTriggerId(userId string, key string) (string, string) {
+	clientTriggerId := NewId()
+	payload := clientTriggerId + ":" + userId + ":" + strconv.FormatInt(GetMillis(), 10)
+	signature := signTriggerId(payload, key)
+
+	return clientTriggerId, base64.RawURLEncoding.EncodeToString([]byte(payload + ":" + signature))
+}
+
+// DecodeAndVerifyTriggerId checks that a trigger id was created with the key and hasn't expired, and returns the
+// client trigger id and the id of the user it was created for.
+func DecodeAndVerifyTriggerId(triggerId string, key string) (string, string, *AppError) {
+	decoded, err := base64.RawURLEncoding.DecodeString(triggerId)
+	if err != nil {
+		return "", "", NewAppError("DecodeAndVerifyTriggerId", "model.dialog.trigger_id.decode.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	parts := strings.Split(string(decoded), ":")
+	if len(parts) != 4 {
+		return "", "", NewAppError("DecodeAndVerifyTriggerId", "model.dialog.trigger_id.decode.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	payload := strings.Join(parts[:3], ":")
+	if !hmac.Equal([]byte(signTriggerId(payload, key)), []byte(parts[3])) {
+		return "", "", NewAppError("DecodeAndVerifyTriggerId", "model.dialog.trigger_id.signature.app_error", nil, "", http.StatusUnauthorized)
+	}
+
+	timestamp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || GetMillis()-timestamp > DIALOG_TRIGGER_ID_EXPIRY_TIME {
+		return "", "", NewAppError("DecodeAndVerifyTriggerId", "model.dialog.trigger_id.expired.app_error", map[string]interface{}{"Duration": DIALOG_TRIGGER_ID_EXPIRY_TIME}, "", http.StatusBadRequest)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// EncodeDialogId signs a dialog that's opened for a user so that the server can trust it when it's submitted.
+func EncodeDialogId(opened *OpenedDialog, key string) string {
+	b, _ := json.Marshal(opened)
+	payload := base64.RawURLEncoding.EncodeToString(b)
+
+	return payload + "." + signTriggerId(payload, key)
+}
+
+// DecodeAndVerifyDialogId checks that a dialog id was created with the key and hasn't expired, and returns the dialog
+// that it was created for.
+func DecodeAndVerifyDialogId(dialogId string, key string) (*OpenedDialog, *AppError) {
+	parts := strings.Split(dialogId, ".")
+	if len(parts) != 2 {
+		return nil, NewAppError("DecodeAndVerifyDialogId", "model.dialog.dialog_id.decode.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if !hmac.Equal([]byte(signTriggerId(parts[0], key)), []byte(parts[1])) {
+		return nil, NewAppError("DecodeAndVerifyDialogId", "model.dialog.dialog_id.signature.app_error", nil, "", http.StatusUnauthorized)
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, NewAppError("DecodeAndVerifyDialogId", "model.dialog.dialog_id.decode.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	var opened OpenedDialog
+	if err := json.Unmarshal(b, &opened); err != nil {
+		return nil, NewAppError("DecodeAndVerifyDialogId", "model.dialog.dialog_id.decode.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	if GetMillis()-opened.CreateAt > DIALOG_ID_EXPIRY_TIME {
+		return nil, NewAppError("DecodeAndVerifyDialogId", "model.dialog.dialog_id.expired.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return &opened, nil
+}
+
+func signTriggerId(payload string, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (o *OpenDialogRequest) IsValid() *AppError {
+	if o.TriggerId == "" {
+		return NewAppError("OpenDialogRequest.IsValid", "model.dialog.is_valid.trigger_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if !IsValidHttpUrl(o.URL) {
+		return NewAppError("OpenDialogRequest.IsValid", "model.dialog.is_valid.url.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return o.Dialog.IsValid()
+}
+
+func (o *Dialog) IsValid() *AppError {
+	if o.Title == "" || utf8.RuneCountInString(o.Title) > DIALOG_TITLE_MAX_RUNES {
+		return NewAppError("Dialog.IsValid", "model.dialog.is_valid.title.app_error", map[string]interface{}{"Max": DIALOG_TITLE_MAX_RUNES}, "", http.StatusBadRequest)
+	}
+
+	if o.IconURL != "" && !IsValidHttpUrl(o.IconURL) {
+		return NewAppError("Dialog.IsValid", "model.dialog.is_valid.icon_url.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(o.Elements) > DIALOG_MAX_ELEMENTS {
+		return NewAppError("Dialog.IsValid", "model.dialog.is_valid.elements.app_error", map[string]interface{}{"Max": DIALOG_MAX_ELEMENTS}, "", http.StatusBadRequest)
+	}
+
+	names := map[string]bool{}
+	for _, element := range o.Elements {
+		if err := element.IsValid(); err != nil {
+			return err
+		}
+
+		if names[element.Name] {
+			return NewAppError("Dialog.IsValid", "model.dialog.is_valid.duplicate_name.app_error", nil, "name="+element.Name, http.StatusBadRequest)
+		}
+		names[element.Name] = true
+	}
+
+	return nil
+}
+
+func (o *DialogElement) IsValid() *AppError {
+	if o.DisplayName == "" || utf8.RuneCountInString(o.DisplayName) > DIALOG_ELEMENT_DISPLAY_NAME_MAX_RUNES {
+		return NewAppError("DialogElement.IsValid", "model.dialog.is_valid.display_name.app_error", map[string]interface{}{"Max": DIALOG_ELEMENT_DISPLAY_NAME_MAX_RUNES}, "name="+o.Name, http.StatusBadRequest)
+	}
+
+	if o.Name == "" || utf8.RuneCountInString(o.Name) > DIALOG_ELEMENT_NAME_MAX_RUNES {
+		return NewAppError("DialogElement.IsValid", "model.dialog.is_valid.name.app_error", map[string]interface{}{"Max": DIALOG_ELEMENT_NAME_MAX_RUNES}, "", http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(o.HelpText) > DIALOG_ELEMENT_HELP_TEXT_MAX_RUNES || utf8.RuneCountInString(o.Placeholder) > DIALOG_ELEMENT_HELP_TEXT_MAX_RUNES {
+		return NewAppError("DialogElement.IsValid", "model.dialog.is_valid.help_text.app_error", map[string]interface{}{"Max": DIALOG_ELEMENT_HELP_TEXT_MAX_RUNES}, "name="+o.Name, http.StatusBadRequest)
+	}
+
+	switch o.Type {
+	case DIALOG_ELEMENT_TYPE_TEXT:
+		switch o.SubType {
+		case "", DIALOG_TEXT_SUBTYPE_TEXT, DIALOG_TEXT_SUBTYPE_EMAIL, DIALOG_TEXT_SUBTYPE_NUMBER, DIALOG_TEXT_SUBTYPE_PASSWORD, DIALOG_TEXT_SUBTYPE_TEL, DIALOG_TEXT_SUBTYPE_URL:
+		default:
+			return NewAppError("DialogElement.IsValid", "model.dialog.is_valid.subtype.app_error", nil, "name="+o.Name, http.StatusBadRequest)
+		}
+
+		return o.isValidLength(DIALOG_TEXT_MAX_LENGTH)
+	case DIALOG_ELEMENT_TYPE_TEXTAREA:
+		return o.isValidLength(DIALOG_TEXTAREA_MAX_LENGTH)
+	case DIALOG_ELEMENT_TYPE_SELECT:
+		return o.isValidSelect()
+	default:
+		return NewAppError("DialogElement.IsValid", "model.dialog.is_valid.type.app_error", nil, "name="+o.Name, http.StatusBadRequest)
+	}
+}
+
+// ValidateSubmission checks what a user entered against the elements of the dialog and returns an error for each
+// element whose value isn't accepted, keyed by the name of the element.
+func (o *Dialog) ValidateSubmission(submission map[string]interface{}) map[string]*AppError {
+	errors := map[string]*AppError{}
+
+	for name := range submission {
+		if o.getElement(name) == nil {
+			errors[name] = NewAppError("Dialog.ValidateSubmission", "model.dialog.submission.unknown.app_error", nil, "name="+name, http.StatusBadRequest)
+		}
+	}
+
+	for i := range o.Elements {
+		element := &o.Elements[i]
+		if err := element.validateValue(submission[element.Name]); err != nil {
+			errors[element.Name] = err
+		}
+	}
+
+	return errors
+}
+
+func (o *Dialog) getElement(name string) *DialogElement {
+	for i := range o.Elements {
+		if o.Elements[i].Name == name {
+			return &o.Elements[i]
+		}
+	}
+
+	return nil
+}
+
+func (o *DialogElement) validateValue(value interface{}) *AppError {
+	if value == nil {
+		value = ""
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return NewAppError("DialogElement.validateValue", "model.dialog.submission.type.app_error", nil, "name="+o.Name, http.StatusBadRequest)
+	}
+
+	if text == "" {
+		if !o.Optional {
+			return NewAppError("DialogElement.validateValue", "model.dialog.submission.required.app_error", nil, "name="+o.Name, http.StatusBadRequest)
+		}
+
+		return nil
+	}
+
+	switch o.Type {
+	case DIALOG_ELEMENT_TYPE_TEXT:
+		return o.validateLength(text, DIALOG_TEXT_MAX_LENGTH)
+	case DIALOG_ELEMENT_TYPE_TEXTAREA:
+		return o.validateLength(text, DIALOG_TEXTAREA_MAX_LENGTH)
+	case DIALOG_ELEMENT_TYPE_SELECT:
+		if o.DataSource == "" {
+			action := &PostAction{Options: o.Options}
+			if !action.IsValidOption(text) {
+				return NewAppError("DialogElement.validateValue", "model.dialog.submission.option.app_error", nil, "name="+o.Name, http.StatusBadRequest)
+			}
+		} else if len(text) != 26 {
+			return NewAppError("DialogElement.validateValue", "model.dialog.submission.option.app_error", nil, "name="+o.Name, http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func (o *DialogElement) validateLength(text string, max int) *AppError {
+	if o.MaxLength != 0 && o.MaxLength < max {
+		max = o.MaxLength
+	}
+
+	if length := utf8.RuneCountInString(text); length < o.MinLength {
+		return NewAppError("DialogElement.validateValue", "model.dialog.submission.min_length.app_error", map[string]interface{}{"Min": o.MinLength}, "name="+o.Name, http.StatusBadRequest)
+	} else if length > max {
+		return NewAppError("DialogElement.validateValue", "model.dialog.submission.max_length.app_error", map[string]interface{}{"Max": max}, "name="+o.Name, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (o *DialogElement) isValidLength(max int) *AppError {
+	if o.MinLength < 0 || o.MaxLength < 0 || o.MinLength > max || o.MaxLength > max || (o.MaxLength != 0 && o.MinLength > o.MaxLength) {
+		return NewAppError("DialogElement.IsValid", "model.dialog.is_valid.length.app_error", map[string]interface{}{"Max": max}, "name="+o.Name, http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(o.Default) > max {
+		return NewAppError("DialogElement.IsValid", "model.dialog.is_valid.default.app_error", nil, "name="+o.Name, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (o *DialogElement) isValidSelect() *AppError {
+	switch o.DataSource {
+	case "":
+		if len(o.Options) == 0 {
+			return NewAppError("DialogElement.IsValid", "model.dialog.is_valid.options.app_error", nil, "name="+o.Name, http.StatusBadRequest)
+		}
+
+		action := &PostAction{Options: o.Options}
+		if o.Default != "" && !action.IsValidOption(o.Default) {
+			return NewAppError("DialogElement.IsValid", "model.dialog.is_valid.default.app_error", nil, "name="+o.Name, http.StatusBadRequest)
+		}
+	case POST_ACTION_DATA_SOURCE_USERS, POST_ACTION_DATA_SOURCE_CHANNELS:
+		if len(o.Options) != 0 {
+			return NewAppError("DialogElement.IsValid", "model.dialog.is_valid.options.app_error", nil, "name="+o.Name, http.StatusBadRequest)
+		}
+	default:
+		return NewAppError("DialogElement.IsValid", "model.dialog.is_valid.data_source.app_error", nil, "name="+o.Name, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (o *OpenDialogRequest) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func OpenDialogRequestFromJson(data io.Reader) *OpenDialogRequest {
+	decoder := json.NewDecoder(data)
+	var o OpenDialogRequest
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func (o *SubmitDialogRequest) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func SubmitDialogRequestFromJson(data io.Reader) *SubmitDialogRequest {
+	decoder := json.NewDecoder(data)
+	var o SubmitDialogRequest
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func (o *SubmitDialogResponse) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func SubmitDialogResponseFromJson(data io.Reader) *SubmitDialogResponse {
+	decoder := json.NewDecoder(data)
+	var o SubmitDialogResponse
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
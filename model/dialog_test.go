@@ -0,0 +1,175 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTriggerId(t *testing.T) {
+	key := NewId()
+	userId := NewId()
+
+	clientTriggerId, triggerId := GenerateTriggerId(userId, key)
+
+	if decodedClientTriggerId, decodedUserId, err := DecodeAndVerifyTriggerId(triggerId, key); err != nil {
+		t.Fatal(err)
+	} else if decodedClientTriggerId != clientTriggerId || decodedUserId != userId {
+		t.Fatal("should have decoded the trigger id")
+	}
+
+	if _, _, err := DecodeAndVerifyTriggerId(triggerId, NewId()); err == nil {
+		t.Fatal("should have failed with another key")
+	}
+
+	if _, _, err := DecodeAndVerifyTriggerId("junk!", key); err == nil {
+		t.Fatal("should have failed to decode junk")
+	}
+
+	payload := NewId() + ":" + userId + ":" + strconv.FormatInt(GetMillis()-DIALOG_TRIGGER_ID_EXPIRY_TIME-1000, 10)
+	expired := base64.RawURLEncoding.EncodeToString([]byte(payload + ":" + signTriggerId(payload, key)))
+
+	if _, _, err := DecodeAndVerifyTriggerId(expired, key); err == nil || err.Id != "model.dialog.trigger_id.expired.app_error" {
+		t.Fatal("should have failed with an expired trigger id")
+	}
+}
+
+func TestDialogId(t *testing.T) {
+	key := NewId()
+	opened := &OpenedDialog{UserId: NewId(), URL: "http://localhost:8065/dialog", Dialog: Dialog{CallbackId: "callback"}, CreateAt: GetMillis()}
+
+	dialogId := EncodeDialogId(opened, key)
+
+	if decoded, err := DecodeAndVerifyDialogId(dialogId, key); err != nil {
+		t.Fatal(err)
+	} else if decoded.UserId != opened.UserId || decoded.URL != opened.URL || decoded.Dialog.CallbackId != "callback" {
+		t.Fatal("should have decoded the dialog id")
+	}
+
+	if _, err := DecodeAndVerifyDialogId(dialogId, NewId()); err == nil {
+		t.Fatal("should have failed with another key")
+	}
+
+	parts := strings.Split(dialogId, ".")
+	forged := *opened
+	forged.URL = "http://169.254.169.254/"
+	if _, err := DecodeAndVerifyDialogId(strings.Split(EncodeDialogId(&forged, key), ".")[0]+"."+parts[1], key); err == nil {
+		t.Fatal("should have failed with a changed dialog")
+	}
+
+	if _, err := DecodeAndVerifyDialogId("junk", key); err == nil {
+		t.Fatal("should have failed to decode junk")
+	}
+
+	opened.CreateAt = GetMillis() - DIALOG_ID_EXPIRY_TIME - 1000
+	if _, err := DecodeAndVerifyDialogId(EncodeDialogId(opened, key), key); err == nil || err.Id != "model.dialog.dialog_id.expired.app_error" {
+		t.Fatal("should have failed with an expired dialog id")
+	}
+}
+
+func TestDialogValidateSubmission(t *testing.T) {
+	dialog := &Dialog{
+		Elements: []DialogElement{
+			{Name: "name", Type: DIALOG_ELEMENT_TYPE_TEXT, MinLength: 2, MaxLength: 5},
+			{Name: "about", Type: DIALOG_ELEMENT_TYPE_TEXTAREA, Optional: true},
+			{Name: "color", Type: DIALOG_ELEMENT_TYPE_SELECT, Options: []*PostActionOptions{{Text: "Red", Value: "red"}}},
+			{Name: "user", Type: DIALOG_ELEMENT_TYPE_SELECT, DataSource: POST_ACTION_DATA_SOURCE_USERS, Optional: true},
+		},
+	}
+
+	if errors := dialog.ValidateSubmission(map[string]interface{}{"name": "abc", "color": "red", "user": NewId()}); len(errors) != 0 {
+		t.Fatal("should have accepted the submission", errors)
+	}
+
+	for name, test := range map[string]struct {
+		Submission map[string]interface{}
+		Element    string
+		ErrorId    string
+	}{
+		"missing":      {map[string]interface{}{"color": "red"}, "name", "model.dialog.submission.required.app_error"},
+		"too short":    {map[string]interface{}{"name": "a", "color": "red"}, "name", "model.dialog.submission.min_length.app_error"},
+		"too long":     {map[string]interface{}{"name": "abcdef", "color": "red"}, "name", "model.dialog.submission.max_length.app_error"},
+		"textarea":     {map[string]interface{}{"name": "abc", "color": "red", "about": strings.Repeat("a", DIALOG_TEXTAREA_MAX_LENGTH+1)}, "about", "model.dialog.submission.max_length.app_error"},
+		"not text":     {map[string]interface{}{"name": 12, "color": "red"}, "name", "model.dialog.submission.type.app_error"},
+		"bad option":   {map[string]interface{}{"name": "abc", "color": "blue"}, "color", "model.dialog.submission.option.app_error"},
+		"bad user":     {map[string]interface{}{"name": "abc", "color": "red", "user": "junk"}, "user", "model.dialog.submission.option.app_error"},
+		"unknown name": {map[string]interface{}{"name": "abc", "color": "red", "other": "value"}, "other", "model.dialog.submission.unknown.app_error"},
+	} {
+		if errors := dialog.ValidateSubmission(test.Submission); len(errors) != 1 || errors[test.Element] == nil || errors[test.Element].Id != test.ErrorId {
+			t.Fatal("should have rejected the submission with "+name, errors)
+		}
+	}
+}
+
+func TestOpenDialogRequestIsValid(t *testing.T) {
+	newRequest := func() *OpenDialogRequest {
+		return &OpenDialogRequest{
+			TriggerId: NewId(),
+			URL:       "http://localhost:8065/dialog",
+			Dialog: Dialog{
+				CallbackId: "callback",
+				Title:      "Title",
+				Elements: []DialogElement{
+					{DisplayName: "Name", Name: "name", Type: DIALOG_ELEMENT_TYPE_TEXT, MaxLength: 20},
+					{DisplayName: "Notes", Name: "notes", Type: DIALOG_ELEMENT_TYPE_TEXTAREA, Optional: true},
+					{DisplayName: "Size", Name: "size", Type: DIALOG_ELEMENT_TYPE_SELECT, Default: "s", Options: []*PostActionOptions{{Text: "Small", Value: "s"}}},
+					{DisplayName: "Owner", Name: "owner", Type: DIALOG_ELEMENT_TYPE_SELECT, DataSource: POST_ACTION_DATA_SOURCE_USERS},
+				},
+			},
+		}
+	}
+
+	if err := newRequest().IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := map[string]func(r *OpenDialogRequest){
+		"no trigger id":      func(r *OpenDialogRequest) { r.TriggerId = "" },
+		"bad url":            func(r *OpenDialogRequest) { r.URL = "junk" },
+		"no title":           func(r *OpenDialogRequest) { r.Dialog.Title = "" },
+		"long title":         func(r *OpenDialogRequest) { r.Dialog.Title = strings.Repeat("a", DIALOG_TITLE_MAX_RUNES+1) },
+		"too many elements":  func(r *OpenDialogRequest) { r.Dialog.Elements = append(r.Dialog.Elements, r.Dialog.Elements...) },
+		"duplicate name":     func(r *OpenDialogRequest) { r.Dialog.Elements[1].Name = "name" },
+		"no display name":    func(r *OpenDialogRequest) { r.Dialog.Elements[0].DisplayName = "" },
+		"bad type":           func(r *OpenDialogRequest) { r.Dialog.Elements[0].Type = "junk" },
+		"bad subtype":        func(r *OpenDialogRequest) { r.Dialog.Elements[0].SubType = "junk" },
+		"long max length":    func(r *OpenDialogRequest) { r.Dialog.Elements[0].MaxLength = DIALOG_TEXT_MAX_LENGTH + 1 },
+		"min over max":       func(r *OpenDialogRequest) { r.Dialog.Elements[0].MinLength = 30 },
+		"no options":         func(r *OpenDialogRequest) { r.Dialog.Elements[2].Options = nil },
+		"bad default option": func(r *OpenDialogRequest) { r.Dialog.Elements[2].Default = "xl" },
+		"bad data source":    func(r *OpenDialogRequest) { r.Dialog.Elements[3].DataSource = "junk" },
+	}
+
+	for name, change := range invalid {
+		request := newRequest()
+		change(request)
+
+		if err := request.IsValid(); err == nil {
+			t.Fatal("should be invalid with " + name)
+		}
+	}
+}
+
+func TestDialogJson(t *testing.T) {
+	request := &OpenDialogRequest{TriggerId: NewId(), URL: "http://localhost", Dialog: Dialog{Title: "Title", Elements: []DialogElement{{Name: "name"}}}}
+
+	if result := OpenDialogRequestFromJson(strings.NewReader(request.ToJson())); result == nil || result.TriggerId != request.TriggerId || result.Dialog.Elements[0].Name != "name" {
+		t.Fatal("open requests do not match")
+	}
+
+	submit := &SubmitDialogRequest{DialogId: "dialogid", CallbackId: "callback", Submission: map[string]interface{}{"name": "value"}}
+
+	if result := SubmitDialogRequestFromJson(strings.NewReader(submit.ToJson())); result == nil || result.DialogId != "dialogid" || result.CallbackId != "callback" || result.Submission["name"] != "value" {
+		t.Fatal("submit requests do not match")
+	}
+
+	response := &SubmitDialogResponse{Errors: map[string]string{"name": "required"}}
+
+	if result := SubmitDialogResponseFromJson(strings.NewReader(response.ToJson())); result == nil || result.Errors["name"] != "required" {
+		t.Fatal("responses do not match")
+	}
+}
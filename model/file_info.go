@@ -117,9 +117,15 @@ func (o *FileInfo) IsImage() bool {
 }
 
 func GetInfoForBytes(name string, data []byte) (*FileInfo, *AppError) {
+	return GetInfoForReader(name, int64(len(data)), bytes.NewReader(data))
+}
+
+// GetInfoForReader works like GetInfoForBytes, but only reads as much of data as it needs to identify images.
+// The reader is left positioned at the start of the file.
+func GetInfoForReader(name string, size int64, data io.ReadSeeker) (*FileInfo, *AppError) {
 	info := &FileInfo{
 		Name: name,
-		Size: int64(len(data)),
+		Size: size,
 	}
 	var err *AppError
 
@@ -135,13 +141,19 @@ func GetInfoForBytes(name string, data []byte) (*FileInfo, *AppError) {
 
 	if info.IsImage() {
 		// Only set the width and height if it's actually an image that we can understand
-		if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		config, _, decodeErr := image.DecodeConfig(data)
+		data.Seek(0, io.SeekStart)
+
+		if decodeErr == nil {
 			info.Width = config.Width
 			info.Height = config.Height
 
 			if info.MimeType == "image/gif" {
 				// Just show the gif itself instead of a preview image for animated gifs
-				if gifConfig, err := gif.DecodeAll(bytes.NewReader(data)); err != nil {
+				gifConfig, err := gif.DecodeAll(data)
+				data.Seek(0, io.SeekStart)
+
+				if err != nil {
 					// Still return the rest of the info even though it doesn't appear to be an actual gif
 					info.HasPreviewImage = true
 					err = NewLocAppError("GetInfoForBytes", "model.file_info.get.gif.app_error", nil, "name="+name)
@@ -4,9 +4,11 @@
 package model
 
 import (
+	"bytes"
 	"encoding/base64"
+	"image"
 	_ "image/gif"
-	_ "image/png"
+	"image/png"
 	"io/ioutil"
 	"strings"
 	"testing"
@@ -198,3 +200,27 @@ func TestGetInfoForFile(t *testing.T) {
 		t.Fatalf("Got incorrect mime type: %v", info.MimeType)
 	}
 }
+
+func TestGetInfoForReader(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	buf := bytes.NewBuffer(nil)
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	reader := bytes.NewReader(data)
+	if info, err := GetInfoForReader("image.png", int64(len(data)), reader); err != nil {
+		t.Fatal(err)
+	} else if info.Size != int64(len(data)) {
+		t.Fatalf("Got incorrect size: %v", info.Size)
+	} else if info.Width != 40 || info.Height != 30 {
+		t.Fatalf("Got incorrect dimensions: %vx%v", info.Width, info.Height)
+	} else if !info.HasPreviewImage {
+		t.Fatal("Should have a preview image")
+	}
+
+	if remaining := reader.Len(); remaining != len(data) {
+		t.Fatalf("Reader should have been left at the start of the file, %v bytes remaining", remaining)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// GuestsInvite lists the email addresses of the guests invited to a team and the channels they're added to when they
+// sign up.
+type GuestsInvite struct {
+	Emails   []string `json:"emails"`
+	Channels []string `json:"channels"`
+}
+
+func (i *GuestsInvite) ToJson() string {
+	if b, err := json.Marshal(i); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func GuestsInviteFromJson(data io.Reader) *GuestsInvite {
+	var i GuestsInvite
+
+	if err := json.NewDecoder(data).Decode(&i); err != nil {
+		return nil
+	} else {
+		return &i
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGuestsInviteJson(t *testing.T) {
+	invite := GuestsInvite{
+		Emails:   []string{"guest@example.com"},
+		Channels: []string{NewId(), NewId()},
+	}
+
+	rinvite := GuestsInviteFromJson(strings.NewReader(invite.ToJson()))
+	if len(rinvite.Emails) != 1 || rinvite.Emails[0] != invite.Emails[0] || len(rinvite.Channels) != 2 || rinvite.Channels[1] != invite.Channels[1] {
+		t.Fatal("invites should match")
+	}
+
+	if GuestsInviteFromJson(strings.NewReader("junk")) != nil {
+		t.Fatal("should have failed to parse")
+	}
+}
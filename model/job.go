@@ -1,110 +1,134 @@
-// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
 // See License.txt for license information.
 
 package model
 
 import (
-	"fmt"
-	"sync"
-	"time"
+	"encoding/json"
+	"io"
+	"net/http"
 )
 
-type TaskFunc func()
+const (
+	JOB_TYPE_BULK_IMPORT       = "bulk_import"
+	JOB_TYPE_LDAP_SYNC         = "ldap_sync"
+	JOB_TYPE_COMPLIANCE_EXPORT = "compliance_export"
+	JOB_TYPE_DATA_RETENTION    = "data_retention"
+	JOB_TYPE_SEARCH_INDEXING   = "search_indexing"
 
-type ScheduledTask struct {
-	Name      string        `json:"name"`
-	Interval  time.Duration `json:"interval"`
-	Recurring bool          `json:"recurring"`
-	function  TaskFunc
-	timer     *time.Timer
-}
+	JOB_STATUS_PENDING          = "pending"
+	JOB_STATUS_IN_PROGRESS      = "in_progress"
+	JOB_STATUS_SUCCESS          = "success"
+	JOB_STATUS_ERROR            = "error"
+	JOB_STATUS_CANCEL_REQUESTED = "cancel_requested"
+	JOB_STATUS_CANCELED         = "canceled"
 
-var taskMutex = sync.Mutex{}
-var tasks = make(map[string]*ScheduledTask)
+	JOB_PRIORITY_DEFAULT = 0
 
-func addTask(task *ScheduledTask) {
-	taskMutex.Lock()
-	defer taskMutex.Unlock()
-	tasks[task.Name] = task
-}
+	JOB_DATA_MAX_LENGTH = 1024
+)
 
-func removeTaskByName(name string) {
-	taskMutex.Lock()
-	defer taskMutex.Unlock()
-	delete(tasks, name)
+// Job is a unit of background work that's stored in the database so that its status survives a restart and can be
+// checked through the API. Pending jobs are picked up by the worker registered for their type.
+type Job struct {
+	Id             string    `json:"id"`
+	Type           string    `json:"type"`
+	Priority       int64     `json:"priority"`
+	CreateAt       int64     `json:"create_at"`
+	StartAt        int64     `json:"start_at"`
+	LastActivityAt int64     `json:"last_activity_at"`
+	Status         string    `json:"status"`
+	Progress       int64     `json:"progress"`
+	Data           StringMap `json:"data"`
 }
 
-func GetTaskByName(name string) *ScheduledTask {
-	taskMutex.Lock()
-	defer taskMutex.Unlock()
-	if task, ok := tasks[name]; ok {
-		return task
+func (j *Job) IsValid() *AppError {
+	if len(j.Id) != 26 {
+		return NewAppError("Job.IsValid", "model.job.is_valid.id.app_error", nil, "id="+j.Id, http.StatusBadRequest)
 	}
-	return nil
-}
 
-func GetAllTasks() *map[string]*ScheduledTask {
-	taskMutex.Lock()
-	defer taskMutex.Unlock()
-	return &tasks
-}
-
-func CreateTask(name string, function TaskFunc, timeToExecution time.Duration) *ScheduledTask {
-	task := &ScheduledTask{
-		Name:      name,
-		Interval:  timeToExecution,
-		Recurring: false,
-		function:  function,
+	if j.CreateAt == 0 {
+		return NewAppError("Job.IsValid", "model.job.is_valid.create_at.app_error", nil, "id="+j.Id, http.StatusBadRequest)
 	}
 
-	taskRunner := func() {
-		go task.function()
-		removeTaskByName(task.Name)
+	switch j.Type {
+	case JOB_TYPE_BULK_IMPORT:
+	case JOB_TYPE_LDAP_SYNC:
+	case JOB_TYPE_COMPLIANCE_EXPORT:
+	case JOB_TYPE_DATA_RETENTION:
+	case JOB_TYPE_SEARCH_INDEXING:
+	default:
+		return NewAppError("Job.IsValid", "model.job.is_valid.type.app_error", nil, "id="+j.Id, http.StatusBadRequest)
 	}
 
-	task.timer = time.AfterFunc(timeToExecution, taskRunner)
+	switch j.Status {
+	case JOB_STATUS_PENDING:
+	case JOB_STATUS_IN_PROGRESS:
+	case JOB_STATUS_SUCCESS:
+	case JOB_STATUS_ERROR:
+	case JOB_STATUS_CANCEL_REQUESTED:
+	case JOB_STATUS_CANCELED:
+	default:
+		return NewAppError("Job.IsValid", "model.job.is_valid.status.app_error", nil, "id="+j.Id, http.StatusBadRequest)
+	}
 
-	addTask(task)
+	if j.Progress < 0 || j.Progress > 100 {
+		return NewAppError("Job.IsValid", "model.job.is_valid.progress.app_error", nil, "id="+j.Id, http.StatusBadRequest)
+	}
 
-	return task
+	return nil
 }
 
-func CreateRecurringTask(name string, function TaskFunc, interval time.Duration) *ScheduledTask {
-	task := &ScheduledTask{
-		Name:      name,
-		Interval:  interval,
-		Recurring: true,
-		function:  function,
-	}
+// IsFinished returns true if the job has stopped running and its status will no longer change.
+func (j *Job) IsFinished() bool {
+	return j.Status == JOB_STATUS_SUCCESS || j.Status == JOB_STATUS_ERROR || j.Status == JOB_STATUS_CANCELED
+}
 
-	taskRecurer := func() {
-		go task.function()
-		task.timer.Reset(task.Interval)
+func (j *Job) ToJson() string {
+	if b, err := json.Marshal(j); err != nil {
+		return ""
+	} else {
+		return string(b)
 	}
+}
 
-	task.timer = time.AfterFunc(interval, taskRecurer)
+func JobFromJson(data io.Reader) *Job {
+	var job Job
 
-	addTask(task)
+	if err := json.NewDecoder(data).Decode(&job); err != nil {
+		return nil
+	} else {
+		return &job
+	}
+}
 
-	return task
+func JobsToJson(jobs []*Job) string {
+	if b, err := json.Marshal(jobs); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
 }
 
-func (task *ScheduledTask) Cancel() {
-	task.timer.Stop()
-	removeTaskByName(task.Name)
+func JobsFromJson(data io.Reader) []*Job {
+	var jobs []*Job
+
+	if err := json.NewDecoder(data).Decode(&jobs); err != nil {
+		return nil
+	} else {
+		return jobs
+	}
 }
 
-// Executes the task immediatly. A recurring task will be run regularally after interval.
-func (task *ScheduledTask) Execute() {
-	task.function()
-	task.timer.Reset(task.Interval)
+// Worker runs the jobs of a single type that are sent to it on JobChannel.
+type Worker interface {
+	Run()
+	Stop()
+	JobChannel() chan<- Job
 }
 
-func (task *ScheduledTask) String() string {
-	return fmt.Sprintf(
-		"%s\nInterval: %s\nRecurring: %t\n",
-		task.Name,
-		task.Interval.String(),
-		task.Recurring,
-	)
+// Scheduler creates jobs of a single type at regular intervals.
+type Scheduler interface {
+	Run()
+	Stop()
 }
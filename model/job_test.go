@@ -1,188 +1,85 @@
-// Copyright (c) 2016-present Mattermost, Inc. All Rights Reserved.
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
 // See License.txt for license information.
 
 package model
 
 import (
+	"strings"
 	"testing"
-	"time"
 )
 
-func TestCreateTask(t *testing.T) {
-	TASK_NAME := "Test Task"
-	TASK_TIME := time.Second * 3
-
-	testValue := 0
-	testFunc := func() {
-		testValue = 1
-	}
-
-	task := CreateTask(TASK_NAME, testFunc, TASK_TIME)
-	if testValue != 0 {
-		t.Fatal("Unexpected execuition of task")
+func TestJobJson(t *testing.T) {
+	job := Job{
+		Id:       NewId(),
+		Type:     JOB_TYPE_BULK_IMPORT,
+		CreateAt: GetMillis(),
+		Status:   JOB_STATUS_PENDING,
+		Data:     StringMap{"file_id": NewId()},
 	}
 
-	time.Sleep(TASK_TIME + time.Second)
-
-	if testValue != 1 {
-		t.Fatal("Task did not execute")
+	rjob := JobFromJson(strings.NewReader(job.ToJson()))
+	if rjob.Id != job.Id || rjob.Type != job.Type || rjob.Data["file_id"] != job.Data["file_id"] {
+		t.Fatal("job should have survived the round trip")
 	}
 
-	if task.Name != TASK_NAME {
-		t.Fatal("Bad name")
-	}
-
-	if task.Interval != TASK_TIME {
-		t.Fatal("Bad interval")
-	}
-
-	if task.Recurring != false {
-		t.Fatal("should not reccur")
+	rjobs := JobsFromJson(strings.NewReader(JobsToJson([]*Job{&job})))
+	if len(rjobs) != 1 || rjobs[0].Id != job.Id {
+		t.Fatal("jobs should have survived the round trip")
 	}
 }
 
-func TestCreateRecurringTask(t *testing.T) {
-	TASK_NAME := "Test Recurring Task"
-	TASK_TIME := time.Second * 3
-
-	testValue := 0
-	testFunc := func() {
-		testValue += 1
+func TestJobIsValid(t *testing.T) {
+	job := Job{
+		Id:       NewId(),
+		Type:     JOB_TYPE_LDAP_SYNC,
+		CreateAt: GetMillis(),
+		Status:   JOB_STATUS_PENDING,
 	}
 
-	task := CreateRecurringTask(TASK_NAME, testFunc, TASK_TIME)
-	if testValue != 0 {
-		t.Fatal("Unexpected execuition of task")
+	if err := job.IsValid(); err != nil {
+		t.Fatal(err)
 	}
 
-	time.Sleep(TASK_TIME + time.Second)
-
-	if testValue != 1 {
-		t.Fatal("Task did not execute")
+	job.Id = "junk"
+	if err := job.IsValid(); err == nil {
+		t.Fatal("should be invalid")
 	}
 
-	time.Sleep(TASK_TIME)
-
-	if testValue != 2 {
-		t.Fatal("Task did not re-execute")
+	job.Id = NewId()
+	job.CreateAt = 0
+	if err := job.IsValid(); err == nil {
+		t.Fatal("should be invalid")
 	}
 
-	if task.Name != TASK_NAME {
-		t.Fatal("Bad name")
+	job.CreateAt = GetMillis()
+	job.Type = "junk"
+	if err := job.IsValid(); err == nil {
+		t.Fatal("should be invalid")
 	}
 
-	if task.Interval != TASK_TIME {
-		t.Fatal("Bad interval")
+	job.Type = JOB_TYPE_COMPLIANCE_EXPORT
+	job.Status = "junk"
+	if err := job.IsValid(); err == nil {
+		t.Fatal("should be invalid")
 	}
 
-	if task.Recurring != true {
-		t.Fatal("should reccur")
+	job.Status = JOB_STATUS_IN_PROGRESS
+	job.Progress = 101
+	if err := job.IsValid(); err == nil {
+		t.Fatal("should be invalid")
 	}
 
-	task.Cancel()
-}
-
-func TestCancelTask(t *testing.T) {
-	TASK_NAME := "Test Task"
-	TASK_TIME := time.Second * 3
-
-	testValue := 0
-	testFunc := func() {
-		testValue = 1
-	}
-
-	task := CreateTask(TASK_NAME, testFunc, TASK_TIME)
-	if testValue != 0 {
-		t.Fatal("Unexpected execuition of task")
-	}
-	task.Cancel()
-
-	time.Sleep(TASK_TIME + time.Second)
-
-	if testValue != 0 {
-		t.Fatal("Unexpected execuition of task")
+	job.Progress = 50
+	if err := job.IsValid(); err != nil {
+		t.Fatal(err)
 	}
-}
-
-func TestGetAllTasks(t *testing.T) {
-	doNothing := func() {}
-
-	CreateTask("Task1", doNothing, time.Hour)
-	CreateTask("Task2", doNothing, time.Second)
-	CreateRecurringTask("Task3", doNothing, time.Second)
-	task4 := CreateRecurringTask("Task4", doNothing, time.Second)
 
-	task4.Cancel()
-
-	time.Sleep(time.Second * 3)
-
-	tasks := *GetAllTasks()
-	if len(tasks) != 2 {
-		t.Fatal("Wrong number of tasks got: ", len(tasks))
-	}
-	for _, task := range tasks {
-		if task.Name != "Task1" && task.Name != "Task3" {
-			t.Fatal("Wrong tasks")
-		}
+	if job.IsFinished() {
+		t.Fatal("job in progress shouldn't be finished")
 	}
-}
-
-func TestExecuteTask(t *testing.T) {
-	TASK_NAME := "Test Task"
-	TASK_TIME := time.Second * 5
-
-	testValue := 0
-	testFunc := func() {
-		testValue += 1
-	}
-
-	task := CreateTask(TASK_NAME, testFunc, TASK_TIME)
-	if testValue != 0 {
-		t.Fatal("Unexpected execuition of task")
-	}
-
-	task.Execute()
-
-	if testValue != 1 {
-		t.Fatal("Task did not execute")
-	}
-
-	time.Sleep(TASK_TIME + time.Second)
-
-	if testValue != 2 {
-		t.Fatal("Task re-executed")
-	}
-}
-
-func TestExecuteTaskRecurring(t *testing.T) {
-	TASK_NAME := "Test Recurring Task"
-	TASK_TIME := time.Second * 5
-
-	testValue := 0
-	testFunc := func() {
-		testValue += 1
-	}
-
-	task := CreateRecurringTask(TASK_NAME, testFunc, TASK_TIME)
-	if testValue != 0 {
-		t.Fatal("Unexpected execuition of task")
-	}
-
-	time.Sleep(time.Second * 3)
-
-	task.Execute()
-	if testValue != 1 {
-		t.Fatal("Task did not execute")
-	}
-
-	time.Sleep(time.Second * 3)
-	if testValue != 1 {
-		t.Fatal("Task should not have executed before 5 seconds")
-	}
-
-	time.Sleep(time.Second * 3)
 
-	if testValue != 2 {
-		t.Fatal("Task did not re-execute after forced execution")
+	job.Status = JOB_STATUS_CANCELED
+	if !job.IsFinished() {
+		t.Fatal("canceled job should be finished")
 	}
 }
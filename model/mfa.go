@@ -0,0 +1,74 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	MFA_RECOVERY_CODE_COUNT  = 10
+	MFA_RECOVERY_CODE_LENGTH = 10
+)
+
+// MfaRecoveryCode is a one-time code that a user can log in with instead of a token from their authenticator app. Only
+// a hash of the code is stored.
+type MfaRecoveryCode struct {
+	UserId   string `json:"user_id"`
+	CodeHash string `json:"-"`
+	CreateAt int64  `json:"create_at"`
+}
+
+// MfaUsedTimeStep records the latest TOTP time step that a user has logged in with so that neither its token nor the
+// token for any earlier time step can be used again.
+type MfaUsedTimeStep struct {
+	UserId   string `json:"user_id"`
+	TimeStep int64  `json:"time_step"`
+}
+
+// NewMfaRecoveryCode generates a random recovery code, formatted so that it's easy to read and type.
+func NewMfaRecoveryCode() string {
+	code := NewRandomString(MFA_RECOVERY_CODE_LENGTH)
+	return code[:MFA_RECOVERY_CODE_LENGTH/2] + "-" + code[MFA_RECOVERY_CODE_LENGTH/2:]
+}
+
+// HashMfaRecoveryCode returns the hash that's stored for a recovery code. Codes are compared case-insensitively and
+// without the separator or any whitespace.
+func HashMfaRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, code)
+
+	hash := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(hash[:])
+}
+
+func (c *MfaRecoveryCode) PreSave() {
+	if c.CreateAt == 0 {
+		c.CreateAt = GetMillis()
+	}
+}
+
+func (c *MfaRecoveryCode) IsValid() *AppError {
+	if len(c.UserId) != 26 {
+		return NewAppError("MfaRecoveryCode.IsValid", "model.mfa_recovery_code.is_valid.user_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(c.CodeHash) != 64 {
+		return NewAppError("MfaRecoveryCode.IsValid", "model.mfa_recovery_code.is_valid.code_hash.app_error", nil, "user_id="+c.UserId, http.StatusBadRequest)
+	}
+
+	if c.CreateAt == 0 {
+		return NewAppError("MfaRecoveryCode.IsValid", "model.mfa_recovery_code.is_valid.create_at.app_error", nil, "user_id="+c.UserId, http.StatusBadRequest)
+	}
+
+	return nil
+}
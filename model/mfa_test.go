@@ -0,0 +1,67 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewMfaRecoveryCode(t *testing.T) {
+	code := NewMfaRecoveryCode()
+
+	if len(code) != MFA_RECOVERY_CODE_LENGTH+1 || code[MFA_RECOVERY_CODE_LENGTH/2] != '-' {
+		t.Fatal("recovery code has the wrong format", code)
+	}
+
+	if NewMfaRecoveryCode() == code {
+		t.Fatal("recovery codes should be random")
+	}
+}
+
+func TestHashMfaRecoveryCode(t *testing.T) {
+	code := NewMfaRecoveryCode()
+	hash := HashMfaRecoveryCode(code)
+
+	if len(hash) != 64 {
+		t.Fatal("hash has the wrong length")
+	}
+
+	for _, variant := range []string{
+		strings.ToUpper(code),
+		strings.Replace(code, "-", "", -1),
+		" " + strings.Replace(code, "-", " ", -1) + " ",
+	} {
+		if HashMfaRecoveryCode(variant) != hash {
+			t.Fatal("hash should ignore case and separators", variant)
+		}
+	}
+
+	if HashMfaRecoveryCode(NewMfaRecoveryCode()) == hash {
+		t.Fatal("different codes should have different hashes")
+	}
+}
+
+func TestMfaRecoveryCodeIsValid(t *testing.T) {
+	code := MfaRecoveryCode{
+		UserId:   NewId(),
+		CodeHash: HashMfaRecoveryCode(NewMfaRecoveryCode()),
+	}
+	code.PreSave()
+
+	if err := code.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	code.UserId = "junk"
+	if err := code.IsValid(); err == nil {
+		t.Fatal("user id should be invalid")
+	}
+
+	code.UserId = NewId()
+	code.CodeHash = "junk"
+	if err := code.IsValid(); err == nil {
+		t.Fatal("code hash should be invalid")
+	}
+}
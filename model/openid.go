@@ -0,0 +1,11 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+const (
+	USER_AUTH_SERVICE_OPENID      = "openid"
+	USER_AUTH_SERVICE_OPENID_TEXT = "With OpenID Connect"
+
+	OPENID_SETTINGS_DEFAULT_SCOPE = "openid profile email"
+)
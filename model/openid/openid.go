@@ -0,0 +1,410 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package oauthopenid is a generic OpenID Connect provider. Its endpoints are read from the identity provider's
+// discovery document and users are identified by the id token, which is verified against the identity provider's
+// published keys.
+package oauthopenid
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/primefour/servers/einterfaces"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+	"gopkg.in/square/go-jose.v1"
+)
+
+const (
+	OPENID_WELL_KNOWN_PATH = "/.well-known/openid-configuration"
+
+	// How long discovery documents and keys are cached before they are fetched again
+	OPENID_CACHE_TIME = time.Hour
+
+	// How far the identity provider's clock may drift from ours when checking token expiry
+	OPENID_CLOCK_SKEW = 2 * time.Minute
+
+	OPENID_REQUEST_TIMEOUT = 30 * time.Second
+)
+
+// The signature algorithms accepted on id tokens. Symmetric algorithms are left out since the client secret would be
+// enough to forge a token.
+var openIdSignatureAlgorithms = map[string]bool{
+	string(jose.RS256): true,
+	string(jose.RS384): true,
+	string(jose.RS512): true,
+	string(jose.PS256): true,
+	string(jose.PS384): true,
+	string(jose.PS512): true,
+	string(jose.ES256): true,
+	string(jose.ES384): true,
+	string(jose.ES512): true,
+}
+
+type OpenIdProvider struct {
+	mutex     sync.Mutex
+	documents map[string]*discoveryDocument
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+
+	expiresAt     time.Time
+	keys          *jose.JsonWebKeySet
+	keysExpiresAt time.Time
+}
+
+type OpenIdUser struct {
+	Sub               string `json:"sub"`
+	Email             string `json:"email"`
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+	GivenName         string `json:"given_name"`
+	FamilyName        string `json:"family_name"`
+	Nickname          string `json:"nickname"`
+}
+
+func init() {
+	provider := &OpenIdProvider{
+		documents: make(map[string]*discoveryDocument),
+	}
+	einterfaces.RegisterOauthProvider(model.USER_AUTH_SERVICE_OPENID, provider)
+}
+
+func userFromOpenIdUser(oiu *OpenIdUser) *model.User {
+	user := &model.User{}
+
+	username := oiu.PreferredUsername
+	if username == "" {
+		username = strings.Split(oiu.Email, "@")[0]
+	}
+	user.Username = model.CleanUsername(username)
+
+	user.FirstName = oiu.GivenName
+	user.LastName = oiu.FamilyName
+	if user.FirstName == "" && user.LastName == "" {
+		splitName := strings.SplitN(strings.TrimSpace(oiu.Name), " ", 2)
+		user.FirstName = splitName[0]
+		if len(splitName) == 2 {
+			user.LastName = splitName[1]
+		}
+	}
+
+	user.Nickname = oiu.Nickname
+	user.Email = strings.ToLower(strings.TrimSpace(oiu.Email))
+	authData := oiu.Sub
+	user.AuthData = &authData
+	user.AuthService = model.USER_AUTH_SERVICE_OPENID
+
+	return user
+}
+
+func openIdUserFromJson(data io.Reader) *OpenIdUser {
+	decoder := json.NewDecoder(data)
+	var oiu OpenIdUser
+	err := decoder.Decode(&oiu)
+	if err == nil {
+		return &oiu
+	} else {
+		return nil
+	}
+}
+
+func (oiu *OpenIdUser) IsValid() bool {
+	if len(oiu.Sub) == 0 {
+		return false
+	}
+
+	if len(oiu.Email) == 0 {
+		return false
+	}
+
+	return true
+}
+
+func (m *OpenIdProvider) GetIdentifier() string {
+	return model.USER_AUTH_SERVICE_OPENID
+}
+
+func (m *OpenIdProvider) GetUserFromJson(data io.Reader) *model.User {
+	oiu := openIdUserFromJson(data)
+	if oiu != nil && oiu.IsValid() {
+		return userFromOpenIdUser(oiu)
+	}
+
+	return &model.User{}
+}
+
+func (m *OpenIdProvider) GetAuthDataFromJson(data io.Reader) string {
+	oiu := openIdUserFromJson(data)
+	if oiu != nil && oiu.IsValid() {
+		return oiu.Sub
+	}
+
+	return ""
+}
+
+// GetEndpoints returns a copy of the settings with the endpoints from the discovery document filled in. Endpoints
+// that are set explicitly take precedence.
+func (m *OpenIdProvider) GetEndpoints(settings *model.SSOSettings) (*model.SSOSettings, *model.AppError) {
+	doc, err := m.getDiscoveryDocument(settings.DiscoveryEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := *settings
+	if endpoints.AuthEndpoint == "" {
+		endpoints.AuthEndpoint = doc.AuthorizationEndpoint
+	}
+	if endpoints.TokenEndpoint == "" {
+		endpoints.TokenEndpoint = doc.TokenEndpoint
+	}
+	if endpoints.UserApiEndpoint == "" {
+		endpoints.UserApiEndpoint = doc.UserinfoEndpoint
+	}
+
+	return &endpoints, nil
+}
+
+// GetUserData verifies the id token returned with an access token and returns its claims as JSON, along with any
+// extra claims returned by the user info endpoint. The id token must have been issued for the given nonce.
+func (m *OpenIdProvider) GetUserData(settings *model.SSOSettings, accessResponse *model.AccessResponse, nonce string) ([]byte, *model.AppError) {
+	doc, err := m.getDiscoveryDocument(settings.DiscoveryEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(accessResponse.IdToken) == 0 {
+		return nil, model.NewAppError("GetUserData", "oauth.openid.missing_id_token.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	claims, err := m.verifyIdToken(doc, settings.Id, accessResponse.IdToken, nonce, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settings.UserApiEndpoint) > 0 {
+		var userInfo map[string]interface{}
+		if err := getJson(settings.UserApiEndpoint, accessResponse.AccessToken, &userInfo); err != nil {
+			return nil, model.NewAppError("GetUserData", "oauth.openid.user_info.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+
+		// The user info response must be about the user the id token was issued for
+		if sub, _ := userInfo["sub"].(string); sub != claims["sub"] {
+			return nil, model.NewAppError("GetUserData", "oauth.openid.user_info.app_error", nil, "sub="+sub, http.StatusBadRequest)
+		}
+
+		for name, value := range userInfo {
+			claims[name] = value
+		}
+	}
+
+	if data, jsonErr := json.Marshal(claims); jsonErr != nil {
+		return nil, model.NewAppError("GetUserData", "oauth.openid.user_info.app_error", nil, jsonErr.Error(), http.StatusInternalServerError)
+	} else {
+		return data, nil
+	}
+}
+
+// verifyIdToken checks the signature, issuer, audience, nonce and validity period of an id token and returns its claims.
+func (m *OpenIdProvider) verifyIdToken(doc *discoveryDocument, clientId string, idToken string, nonce string, now time.Time) (map[string]interface{}, *model.AppError) {
+	token, err := jose.ParseSigned(idToken)
+	if err != nil || len(token.Signatures) != 1 {
+		details := ""
+		if err != nil {
+			details = err.Error()
+		}
+		return nil, model.NewAppError("verifyIdToken", "oauth.openid.invalid_id_token.app_error", nil, details, http.StatusBadRequest)
+	}
+
+	header := token.Signatures[0].Header
+	if !openIdSignatureAlgorithms[header.Algorithm] {
+		return nil, model.NewAppError("verifyIdToken", "oauth.openid.invalid_id_token.app_error", nil, "alg="+header.Algorithm, http.StatusBadRequest)
+	}
+
+	payload, appErr := m.verifySignature(doc, token, header.KeyID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, model.NewAppError("verifyIdToken", "oauth.openid.invalid_id_token.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	if issuer, _ := claims["iss"].(string); issuer != doc.Issuer {
+		return nil, model.NewAppError("verifyIdToken", "oauth.openid.invalid_id_token.app_error", nil, "iss="+issuer, http.StatusBadRequest)
+	}
+
+	audiences := []string{}
+	switch aud := claims["aud"].(type) {
+	case string:
+		audiences = append(audiences, aud)
+	case []interface{}:
+		for _, value := range aud {
+			if audience, ok := value.(string); ok {
+				audiences = append(audiences, audience)
+			}
+		}
+	}
+
+	if !containsString(audiences, clientId) {
+		return nil, model.NewAppError("verifyIdToken", "oauth.openid.invalid_id_token.app_error", nil, "aud="+strings.Join(audiences, ","), http.StatusBadRequest)
+	}
+
+	if azp, ok := claims["azp"].(string); ok && azp != clientId {
+		return nil, model.NewAppError("verifyIdToken", "oauth.openid.invalid_id_token.app_error", nil, "azp="+azp, http.StatusBadRequest)
+	}
+
+	if exp, ok := claims["exp"].(float64); !ok || !now.Add(-OPENID_CLOCK_SKEW).Before(time.Unix(int64(exp), 0)) {
+		return nil, model.NewAppError("verifyIdToken", "oauth.openid.expired_id_token.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(OPENID_CLOCK_SKEW).Before(time.Unix(int64(nbf), 0)) {
+		return nil, model.NewAppError("verifyIdToken", "oauth.openid.expired_id_token.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if sub, _ := claims["sub"].(string); len(sub) == 0 {
+		return nil, model.NewAppError("verifyIdToken", "oauth.openid.invalid_id_token.app_error", nil, "missing sub", http.StatusBadRequest)
+	}
+
+	if claimedNonce, _ := claims["nonce"].(string); len(nonce) == 0 || claimedNonce != nonce {
+		return nil, model.NewAppError("verifyIdToken", "oauth.openid.invalid_id_token.app_error", nil, "nonce="+claimedNonce, http.StatusBadRequest)
+	}
+
+	return claims, nil
+}
+
+// verifySignature checks the token against the identity provider's keys. When no key matches, the keys are fetched
+// again in case the identity provider has rotated them.
+func (m *OpenIdProvider) verifySignature(doc *discoveryDocument, token *jose.JsonWebSignature, keyId string) ([]byte, *model.AppError) {
+	for _, refresh := range []bool{false, true} {
+		keys, err := m.getKeys(doc, refresh)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := keys.Keys
+		if len(keyId) > 0 {
+			candidates = keys.Key(keyId)
+		}
+
+		for _, key := range candidates {
+			if key.Use != "" && key.Use != "sig" {
+				continue
+			}
+
+			if payload, err := token.Verify(key.Key); err == nil {
+				return payload, nil
+			}
+		}
+	}
+
+	return nil, model.NewAppError("verifySignature", "oauth.openid.invalid_signature.app_error", nil, "kid="+keyId, http.StatusBadRequest)
+}
+
+func (m *OpenIdProvider) getDiscoveryDocument(endpoint string) (*discoveryDocument, *model.AppError) {
+	if len(endpoint) == 0 {
+		return nil, model.NewAppError("getDiscoveryDocument", "oauth.openid.discovery.app_error", nil, "no discovery endpoint is configured", http.StatusNotImplemented)
+	}
+
+	if !strings.Contains(endpoint, "/.well-known/") {
+		endpoint = strings.TrimRight(endpoint, "/") + OPENID_WELL_KNOWN_PATH
+	}
+
+	m.mutex.Lock()
+	doc, ok := m.documents[endpoint]
+	m.mutex.Unlock()
+
+	if ok && time.Now().Before(doc.expiresAt) {
+		return doc, nil
+	}
+
+	doc = &discoveryDocument{}
+	if err := getJson(endpoint, "", doc); err != nil {
+		return nil, model.NewAppError("getDiscoveryDocument", "oauth.openid.discovery.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if len(doc.Issuer) == 0 || len(doc.AuthorizationEndpoint) == 0 || len(doc.TokenEndpoint) == 0 || len(doc.JwksUri) == 0 {
+		return nil, model.NewAppError("getDiscoveryDocument", "oauth.openid.discovery.app_error", nil, "the discovery document is incomplete", http.StatusInternalServerError)
+	}
+
+	doc.expiresAt = time.Now().Add(OPENID_CACHE_TIME)
+
+	m.mutex.Lock()
+	m.documents[endpoint] = doc
+	m.mutex.Unlock()
+
+	return doc, nil
+}
+
+func (m *OpenIdProvider) getKeys(doc *discoveryDocument, refresh bool) (*jose.JsonWebKeySet, *model.AppError) {
+	m.mutex.Lock()
+	keys := doc.keys
+	expired := time.Now().After(doc.keysExpiresAt)
+	m.mutex.Unlock()
+
+	if keys != nil && !expired && !refresh {
+		return keys, nil
+	}
+
+	keys = &jose.JsonWebKeySet{}
+	if err := getJson(doc.JwksUri, "", keys); err != nil {
+		return nil, model.NewAppError("getKeys", "oauth.openid.keys.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	m.mutex.Lock()
+	doc.keys = keys
+	doc.keysExpiresAt = time.Now().Add(OPENID_CACHE_TIME)
+	m.mutex.Unlock()
+
+	return keys, nil
+}
+
+func getJson(url string, accessToken string, v interface{}) error {
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections},
+		},
+		Timeout: OPENID_REQUEST_TIMEOUT,
+	}
+
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Accept", "application/json")
+	if len(accessToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(url + " returned " + resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
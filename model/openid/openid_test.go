@@ -0,0 +1,377 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package oauthopenid
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+	"gopkg.in/square/go-jose.v1"
+)
+
+const (
+	testClientId    = "mattermost"
+	testAccessToken = "access-token"
+	testNonce       = "nonce"
+)
+
+// testIdentityProvider serves a discovery document, keys and user info like Keycloak or Dex would.
+type testIdentityProvider struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	keyId    string
+	userInfo map[string]interface{}
+
+	keyRequests int
+}
+
+func newTestIdentityProvider(t *testing.T) *testIdentityProvider {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	idp := &testIdentityProvider{}
+	idp.rotateKey(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(OPENID_WELL_KNOWN_PATH, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/auth",
+			"token_endpoint":         idp.server.URL + "/token",
+			"userinfo_endpoint":      idp.server.URL + "/userinfo",
+			"jwks_uri":               idp.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		idp.keyRequests++
+		json.NewEncoder(w).Encode(jose.JsonWebKeySet{
+			Keys: []jose.JsonWebKey{{Key: &idp.key.PublicKey, KeyID: idp.keyId, Algorithm: string(jose.RS256), Use: "sig"}},
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testAccessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(idp.userInfo)
+	})
+
+	idp.server = httptest.NewServer(mux)
+
+	return idp
+}
+
+func (idp *testIdentityProvider) Close() {
+	idp.server.Close()
+}
+
+func (idp *testIdentityProvider) rotateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp.key = key
+	idp.keyId = model.NewId()
+}
+
+func (idp *testIdentityProvider) settings() *model.SSOSettings {
+	return &model.SSOSettings{
+		Enable:            true,
+		Id:                testClientId,
+		Secret:            "secret",
+		DiscoveryEndpoint: idp.server.URL,
+	}
+}
+
+func (idp *testIdentityProvider) claims(sub string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":                idp.server.URL,
+		"aud":                testClientId,
+		"sub":                sub,
+		"exp":                time.Now().Add(5 * time.Minute).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              testNonce,
+		"email":              sub + "@example.com",
+		"preferred_username": sub,
+	}
+}
+
+func (idp *testIdentityProvider) sign(t *testing.T, claims map[string]interface{}, alg jose.SignatureAlgorithm, key interface{}) string {
+	signer, err := jose.NewSigner(alg, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.SetEmbedJwk(false)
+
+	payload, _ := json.Marshal(claims)
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := signed.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func (idp *testIdentityProvider) idToken(t *testing.T, claims map[string]interface{}) string {
+	return idp.sign(t, claims, jose.RS256, &jose.JsonWebKey{Key: idp.key, KeyID: idp.keyId})
+}
+
+func newTestProvider() *OpenIdProvider {
+	return &OpenIdProvider{
+		documents: make(map[string]*discoveryDocument),
+	}
+}
+
+func TestGetEndpoints(t *testing.T) {
+	idp := newTestIdentityProvider(t)
+	defer idp.Close()
+
+	provider := newTestProvider()
+
+	settings := idp.settings()
+	endpoints, err := provider.GetEndpoints(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if endpoints.AuthEndpoint != idp.server.URL+"/auth" || endpoints.TokenEndpoint != idp.server.URL+"/token" || endpoints.UserApiEndpoint != idp.server.URL+"/userinfo" {
+		t.Fatal("should read the endpoints from the discovery document", endpoints)
+	}
+
+	if settings.AuthEndpoint != "" {
+		t.Fatal("should not modify the settings")
+	}
+
+	settings.DiscoveryEndpoint = idp.server.URL + OPENID_WELL_KNOWN_PATH
+	settings.TokenEndpoint = "http://internal.example.com/token"
+	if endpoints, err := provider.GetEndpoints(settings); err != nil {
+		t.Fatal(err)
+	} else if endpoints.TokenEndpoint != "http://internal.example.com/token" || endpoints.AuthEndpoint != idp.server.URL+"/auth" {
+		t.Fatal("should prefer endpoints that are set explicitly", endpoints)
+	}
+
+	settings.DiscoveryEndpoint = idp.server.URL + "/missing"
+	if _, err := provider.GetEndpoints(settings); err == nil {
+		t.Fatal("should fail without a discovery document")
+	}
+
+	settings.DiscoveryEndpoint = ""
+	if _, err := provider.GetEndpoints(settings); err == nil {
+		t.Fatal("should fail without a discovery endpoint")
+	}
+}
+
+func TestGetUserData(t *testing.T) {
+	idp := newTestIdentityProvider(t)
+	defer idp.Close()
+
+	provider := newTestProvider()
+	settings := idp.settings()
+
+	getUserData := func(claims map[string]interface{}) (*model.User, *model.AppError) {
+		return getUserDataWithToken(provider, settings, idp.idToken(t, claims))
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		user, err := getUserData(idp.claims("alice"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if *user.AuthData != "alice" || user.Email != "alice@example.com" || user.Username != "alice" || user.AuthService != model.USER_AUTH_SERVICE_OPENID {
+			t.Fatal("should map the claims to a user", user)
+		}
+	})
+
+	t.Run("UserInfo", func(t *testing.T) {
+		resolved, err := provider.GetEndpoints(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		idp.userInfo = map[string]interface{}{"sub": "alice", "given_name": "Alice", "family_name": "Liddell"}
+		defer func() { idp.userInfo = nil }()
+
+		if user, err := getUserDataWithToken(provider, resolved, idp.idToken(t, idp.claims("alice"))); err != nil {
+			t.Fatal(err)
+		} else if user.FirstName != "Alice" || user.LastName != "Liddell" || user.Email != "alice@example.com" {
+			t.Fatal("should merge the user info claims", user)
+		}
+
+		idp.userInfo = map[string]interface{}{"sub": "bob", "email": "bob@example.com"}
+		if _, err := getUserDataWithToken(provider, resolved, idp.idToken(t, idp.claims("alice"))); err == nil {
+			t.Fatal("should reject user info about another user")
+		}
+	})
+
+	t.Run("MissingIdToken", func(t *testing.T) {
+		if _, err := provider.GetUserData(settings, &model.AccessResponse{AccessToken: testAccessToken}, testNonce); err == nil {
+			t.Fatal("should require an id token")
+		}
+	})
+
+	t.Run("WrongAudience", func(t *testing.T) {
+		claims := idp.claims("alice")
+		claims["aud"] = []string{"other", testClientId}
+		if _, err := getUserData(claims); err != nil {
+			t.Fatal("should accept a token for several audiences", err)
+		}
+
+		claims["aud"] = "other"
+		if _, err := getUserData(claims); err == nil {
+			t.Fatal("should reject a token for another client")
+		}
+
+		claims["aud"] = []string{"other", testClientId}
+		claims["azp"] = "other"
+		if _, err := getUserData(claims); err == nil {
+			t.Fatal("should reject a token authorized for another client")
+		}
+	})
+
+	t.Run("WrongIssuer", func(t *testing.T) {
+		claims := idp.claims("alice")
+		claims["iss"] = "http://other.example.com"
+		if _, err := getUserData(claims); err == nil {
+			t.Fatal("should reject a token from another issuer")
+		}
+	})
+
+	t.Run("WrongNonce", func(t *testing.T) {
+		claims := idp.claims("alice")
+		claims["nonce"] = "other"
+		if _, err := getUserData(claims); err == nil {
+			t.Fatal("should reject a token issued for another login")
+		}
+
+		delete(claims, "nonce")
+		if _, err := getUserData(claims); err == nil {
+			t.Fatal("should require a nonce")
+		}
+
+		if _, err := provider.GetUserData(settings, &model.AccessResponse{AccessToken: testAccessToken, IdToken: idp.idToken(t, idp.claims("alice"))}, ""); err == nil {
+			t.Fatal("should reject a token when no nonce was sent")
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		claims := idp.claims("alice")
+		claims["exp"] = time.Now().Add(-time.Hour).Unix()
+		if _, err := getUserData(claims); err == nil {
+			t.Fatal("should reject an expired token")
+		}
+
+		claims["exp"] = time.Now().Add(-time.Minute).Unix()
+		if _, err := getUserData(claims); err != nil {
+			t.Fatal("should allow for clock skew", err)
+		}
+
+		delete(claims, "exp")
+		if _, err := getUserData(claims); err == nil {
+			t.Fatal("should require an expiry")
+		}
+
+		claims = idp.claims("alice")
+		claims["nbf"] = time.Now().Add(time.Hour).Unix()
+		if _, err := getUserData(claims); err == nil {
+			t.Fatal("should reject a token that isn't valid yet")
+		}
+	})
+
+	t.Run("BadSignature", func(t *testing.T) {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token := idp.sign(t, idp.claims("alice"), jose.RS256, &jose.JsonWebKey{Key: otherKey, KeyID: idp.keyId})
+		if _, err := getUserDataWithToken(provider, settings, token); err == nil {
+			t.Fatal("should reject a token signed with another key")
+		}
+
+		token = idp.idToken(t, idp.claims("alice"))
+		parts := strings.Split(token, ".")
+		tampered, _ := json.Marshal(idp.claims("mallory"))
+		parts[1] = base64.RawURLEncoding.EncodeToString(tampered)
+		if _, err := getUserDataWithToken(provider, settings, strings.Join(parts, ".")); err == nil {
+			t.Fatal("should reject a tampered token")
+		}
+
+		token = idp.sign(t, idp.claims("alice"), jose.HS256, []byte(settings.Secret))
+		if _, err := getUserDataWithToken(provider, settings, token); err == nil {
+			t.Fatal("should reject a token signed with the client secret")
+		}
+	})
+
+	t.Run("KeyRotation", func(t *testing.T) {
+		requests := idp.keyRequests
+		idp.rotateKey(t)
+
+		if _, err := getUserData(idp.claims("alice")); err != nil {
+			t.Fatal("should fetch the new keys", err)
+		}
+
+		if idp.keyRequests != requests+1 {
+			t.Fatal("should fetch the keys once", idp.keyRequests-requests)
+		}
+
+		if _, err := getUserData(idp.claims("alice")); err != nil {
+			t.Fatal(err)
+		}
+
+		if idp.keyRequests != requests+1 {
+			t.Fatal("should cache the keys")
+		}
+	})
+}
+
+func getUserDataWithToken(provider *OpenIdProvider, settings *model.SSOSettings, idToken string) (*model.User, *model.AppError) {
+	data, err := provider.GetUserData(settings, &model.AccessResponse{AccessToken: testAccessToken, IdToken: idToken}, testNonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.GetUserFromJson(strings.NewReader(string(data))), nil
+}
+
+func TestGetUserFromJson(t *testing.T) {
+	provider := newTestProvider()
+
+	user := provider.GetUserFromJson(strings.NewReader(`{"sub": "1234", "email": "Alice@Example.com", "name": "Alice Pleasance Liddell"}`))
+	if *user.AuthData != "1234" || user.Email != "alice@example.com" || user.Username != "alice" {
+		t.Fatal("should fall back to the email for the username", user)
+	}
+
+	if user.FirstName != "Alice" || user.LastName != "Pleasance Liddell" {
+		t.Fatal("should split the name when there are no name claims", user)
+	}
+
+	if provider.GetAuthDataFromJson(strings.NewReader(`{"sub": "1234", "email": "alice@example.com"}`)) != "1234" {
+		t.Fatal("should use the subject as the auth data")
+	}
+
+	if provider.GetAuthDataFromJson(strings.NewReader(`{"sub": "1234"}`)) != "" {
+		t.Fatal("should require an email")
+	}
+
+	if user := provider.GetUserFromJson(strings.NewReader(`not json`)); user.AuthData != nil {
+		t.Fatal("should return an empty user for invalid data")
+	}
+}
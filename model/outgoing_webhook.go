@@ -4,31 +4,43 @@
 package model
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 )
 
 type OutgoingWebhook struct {
-	Id           string      `json:"id"`
-	Token        string      `json:"token"`
-	CreateAt     int64       `json:"create_at"`
-	UpdateAt     int64       `json:"update_at"`
-	DeleteAt     int64       `json:"delete_at"`
-	CreatorId    string      `json:"creator_id"`
-	ChannelId    string      `json:"channel_id"`
-	TeamId       string      `json:"team_id"`
-	TriggerWords StringArray `json:"trigger_words"`
-	TriggerWhen  int         `json:"trigger_when"`
-	CallbackURLs StringArray `json:"callback_urls"`
-	DisplayName  string      `json:"display_name"`
-	Description  string      `json:"description"`
-	ContentType  string      `json:"content_type"`
+	Id            string      `json:"id"`
+	Token         string      `json:"token"`
+	SigningSecret string      `json:"signing_secret"`
+	CreateAt      int64       `json:"create_at"`
+	UpdateAt      int64       `json:"update_at"`
+	DeleteAt      int64       `json:"delete_at"`
+	CreatorId     string      `json:"creator_id"`
+	ChannelId     string      `json:"channel_id"`
+	TeamId        string      `json:"team_id"`
+	TriggerWords  StringArray `json:"trigger_words"`
+	TriggerWhen   int         `json:"trigger_when"`
+	CallbackURLs  StringArray `json:"callback_urls"`
+	DisplayName   string      `json:"display_name"`
+	Description   string      `json:"description"`
+	ContentType   string      `json:"content_type"`
 }
 
+const (
+	HEADER_OUTGOING_WEBHOOK_SIGNATURE = "X-Mattermost-Signature"
+	HEADER_OUTGOING_WEBHOOK_TIMESTAMP = "X-Mattermost-Timestamp"
+
+	OUTGOING_WEBHOOK_SIGNING_SECRET_LENGTH = 32
+)
+
 type OutgoingWebhookPayload struct {
 	Token       string `json:"token"`
 	TeamId      string `json:"team_id"`
@@ -119,6 +131,10 @@ func (o *OutgoingWebhook) IsValid() *AppError {
 		return NewLocAppError("OutgoingWebhook.IsValid", "model.outgoing_hook.is_valid.token.app_error", nil, "")
 	}
 
+	if len(o.SigningSecret) != 0 && len(o.SigningSecret) != OUTGOING_WEBHOOK_SIGNING_SECRET_LENGTH {
+		return NewAppError("OutgoingWebhook.IsValid", "model.outgoing_hook.is_valid.signing_secret.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
 	if o.CreateAt == 0 {
 		return NewLocAppError("OutgoingWebhook.IsValid", "model.outgoing_hook.is_valid.create_at.app_error", nil, "id="+o.Id)
 	}
@@ -189,6 +205,8 @@ func (o *OutgoingWebhook) PreSave() {
 		o.Token = NewId()
 	}
 
+	o.SigningSecret = NewRandomString(OUTGOING_WEBHOOK_SIGNING_SECRET_LENGTH)
+
 	o.CreateAt = GetMillis()
 	o.UpdateAt = o.CreateAt
 }
@@ -224,3 +242,14 @@ func (o *OutgoingWebhook) TriggerWordStartsWith(word string) bool {
 
 	return false
 }
+
+// Sign returns the value of the signature header sent with a request to the callback URLs of the webhook. It's an
+// HMAC-SHA256 of the timestamp header, a period and the request body keyed with the signing secret of the webhook. Unlike
+// the token, the signing secret is never sent in a request, so integrations can use the signature to check that a request
+// came from this server and wasn't changed on the way, and the timestamp to turn away requests that are replayed later.
+func (o *OutgoingWebhook) Sign(timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(o.SigningSecret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
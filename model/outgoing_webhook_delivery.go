@@ -0,0 +1,144 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"unicode/utf8"
+)
+
+const (
+	OUTGOING_WEBHOOK_DELIVERY_MAX_ATTEMPTS     = 4
+	OUTGOING_WEBHOOK_DELIVERY_EXCERPT_MAX_LEN  = 1024
+	OUTGOING_WEBHOOK_DELIVERY_ERROR_MAX_LEN    = 1024
+	OUTGOING_WEBHOOK_DELIVERY_LOG_MAX_PER_HOOK = 100
+)
+
+// OutgoingWebhookDelivery records one attempt at sending a post to a callback URL of an outgoing webhook. StatusCode
+// is 0 when no response was received, in which case Error says why. NextAttemptAt is set for as long as the attempt
+// is waiting to be made, which is also when no server is allowed to make it.
+type OutgoingWebhookDelivery struct {
+	Id              string `json:"id"`
+	HookId          string `json:"hook_id"`
+	CreateAt        int64  `json:"create_at"`
+	PostId          string `json:"post_id"`
+	CallbackURL     string `json:"callback_url"`
+	Attempt         int    `json:"attempt"`
+	StatusCode      int    `json:"status_code"`
+	Latency         int64  `json:"latency"`
+	ResponseExcerpt string `json:"response_excerpt"`
+	Error           string `json:"error"`
+	NextAttemptAt   int64  `json:"next_attempt_at"`
+}
+
+func (o *OutgoingWebhookDelivery) ToJson() string {
+	if b, err := json.Marshal(o); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func OutgoingWebhookDeliveryFromJson(data io.Reader) *OutgoingWebhookDelivery {
+	var o OutgoingWebhookDelivery
+
+	if err := json.NewDecoder(data).Decode(&o); err != nil {
+		return nil
+	} else {
+		return &o
+	}
+}
+
+func OutgoingWebhookDeliveryListToJson(l []*OutgoingWebhookDelivery) string {
+	if b, err := json.Marshal(l); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func OutgoingWebhookDeliveryListFromJson(data io.Reader) []*OutgoingWebhookDelivery {
+	var o []*OutgoingWebhookDelivery
+
+	if err := json.NewDecoder(data).Decode(&o); err != nil {
+		return nil
+	} else {
+		return o
+	}
+}
+
+func (o *OutgoingWebhookDelivery) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+
+	o.ResponseExcerpt = truncateRunes(o.ResponseExcerpt, OUTGOING_WEBHOOK_DELIVERY_EXCERPT_MAX_LEN)
+	o.Error = truncateRunes(o.Error, OUTGOING_WEBHOOK_DELIVERY_ERROR_MAX_LEN)
+}
+
+func (o *OutgoingWebhookDelivery) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(o.HookId) != 26 {
+		return NewAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.hook_id.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.create_at.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if len(o.PostId) != 26 {
+		return NewAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.post_id.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if len(o.CallbackURL) == 0 || len(o.CallbackURL) > 1024 {
+		return NewAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.callback_url.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if o.Attempt < 1 || o.Attempt > OUTGOING_WEBHOOK_DELIVERY_MAX_ATTEMPTS {
+		return NewAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.attempt.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(o.ResponseExcerpt) > OUTGOING_WEBHOOK_DELIVERY_EXCERPT_MAX_LEN {
+		return NewAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.response_excerpt.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(o.Error) > OUTGOING_WEBHOOK_DELIVERY_ERROR_MAX_LEN {
+		return NewAppError("OutgoingWebhookDelivery.IsValid", "model.outgoing_hook_delivery.is_valid.error.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// IsPending returns true if the attempt hasn't been made yet.
+func (o *OutgoingWebhookDelivery) IsPending() bool {
+	return o.NextAttemptAt != 0
+}
+
+// IsSuccess returns true if the callback URL accepted the post.
+func (o *OutgoingWebhookDelivery) IsSuccess() bool {
+	return o.StatusCode >= 200 && o.StatusCode < 300
+}
+
+// ShouldRetry returns true if the attempt failed in a way that may not happen again, which is when no response was
+// received, the server had an error or it asked to be sent fewer requests.
+func (o *OutgoingWebhookDelivery) ShouldRetry() bool {
+	return o.StatusCode == 0 || o.StatusCode >= 500 || o.StatusCode == http.StatusTooManyRequests
+}
+
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOutgoingWebhookDeliveryJson(t *testing.T) {
+	o := OutgoingWebhookDelivery{Id: NewId(), StatusCode: http.StatusOK, ResponseExcerpt: "ok"}
+	ro := OutgoingWebhookDeliveryFromJson(strings.NewReader(o.ToJson()))
+
+	if ro == nil || o.Id != ro.Id || ro.StatusCode != http.StatusOK || ro.ResponseExcerpt != "ok" {
+		t.Fatal("deliveries do not match")
+	}
+
+	list := OutgoingWebhookDeliveryListFromJson(strings.NewReader(OutgoingWebhookDeliveryListToJson([]*OutgoingWebhookDelivery{&o})))
+	if len(list) != 1 || list[0].Id != o.Id {
+		t.Fatal("lists do not match")
+	}
+}
+
+func TestOutgoingWebhookDeliveryIsValid(t *testing.T) {
+	o := OutgoingWebhookDelivery{
+		HookId:      NewId(),
+		PostId:      NewId(),
+		CallbackURL: "http://nowhere.com/",
+		Attempt:     1,
+	}
+
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without an id")
+	}
+
+	o.PreSave()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Attempt = OUTGOING_WEBHOOK_DELIVERY_MAX_ATTEMPTS + 1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid with too many attempts")
+	}
+
+	o.Attempt = 1
+	o.CallbackURL = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without a callback url")
+	}
+
+	o.CallbackURL = "http://nowhere.com/"
+	o.HookId = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without a hook id")
+	}
+}
+
+func TestOutgoingWebhookDeliveryPreSave(t *testing.T) {
+	o := OutgoingWebhookDelivery{
+		ResponseExcerpt: strings.Repeat("é", OUTGOING_WEBHOOK_DELIVERY_EXCERPT_MAX_LEN+10),
+		Error:           strings.Repeat("a", OUTGOING_WEBHOOK_DELIVERY_ERROR_MAX_LEN+10),
+	}
+	o.PreSave()
+
+	if len(o.Id) != 26 || o.CreateAt == 0 {
+		t.Fatal("should have set the id and create at")
+	}
+
+	if o.ResponseExcerpt != strings.Repeat("é", OUTGOING_WEBHOOK_DELIVERY_EXCERPT_MAX_LEN) {
+		t.Fatal("should have truncated the response excerpt")
+	}
+
+	if len(o.Error) != OUTGOING_WEBHOOK_DELIVERY_ERROR_MAX_LEN {
+		t.Fatal("should have truncated the error")
+	}
+}
+
+func TestOutgoingWebhookDeliveryShouldRetry(t *testing.T) {
+	for statusCode, retry := range map[int]bool{
+		0:                              true,
+		http.StatusOK:                  false,
+		http.StatusBadRequest:          false,
+		http.StatusNotFound:            false,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusServiceUnavailable:  true,
+	} {
+		o := OutgoingWebhookDelivery{StatusCode: statusCode}
+
+		if o.ShouldRetry() != retry {
+			t.Fatal("wrong retry decision for status code", statusCode)
+		}
+
+		if o.IsSuccess() != (statusCode == http.StatusOK) {
+			t.Fatal("wrong success decision for status code", statusCode)
+		}
+	}
+}
@@ -58,6 +58,12 @@ func TestOutgoingWebhookIsValid(t *testing.T) {
 	}
 
 	o.Token = NewId()
+	o.SigningSecret = "123"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.SigningSecret = NewRandomString(OUTGOING_WEBHOOK_SIGNING_SECRET_LENGTH)
 	if err := o.IsValid(); err == nil {
 		t.Fatal("should be invalid")
 	}
@@ -157,6 +163,16 @@ func TestOutgoingWebhookPayloadToFormValues(t *testing.T) {
 func TestOutgoingWebhookPreSave(t *testing.T) {
 	o := OutgoingWebhook{}
 	o.PreSave()
+
+	if len(o.SigningSecret) != OUTGOING_WEBHOOK_SIGNING_SECRET_LENGTH {
+		t.Fatal("should have generated a signing secret")
+	}
+
+	secret := o.SigningSecret
+	o.PreSave()
+	if o.SigningSecret == secret {
+		t.Fatal("should not keep a signing secret chosen by the client")
+	}
 }
 
 func TestOutgoingWebhookPreUpdate(t *testing.T) {
@@ -174,3 +190,35 @@ func TestOutgoingWebhookTriggerWordStartsWith(t *testing.T) {
 		t.Fatal("Should return false")
 	}
 }
+
+func TestOutgoingWebhookSign(t *testing.T) {
+	o := OutgoingWebhook{Token: NewId(), SigningSecret: NewRandomString(OUTGOING_WEBHOOK_SIGNING_SECRET_LENGTH)}
+	body := []byte(`{"text":"hello"}`)
+	timestamp := GetMillis() / 1000
+
+	signature := o.Sign(timestamp, body)
+	if !strings.HasPrefix(signature, "sha256=") || len(signature) != len("sha256=")+64 {
+		t.Fatal("should be a hex encoded sha256 hmac", signature)
+	}
+
+	if o.Sign(timestamp, body) != signature {
+		t.Fatal("should sign the same body the same way")
+	}
+
+	if o.Sign(timestamp, []byte(`{"text":"hello!"}`)) == signature {
+		t.Fatal("should sign another body differently")
+	}
+
+	if o.Sign(timestamp+1, body) == signature {
+		t.Fatal("should sign another timestamp differently")
+	}
+
+	other := OutgoingWebhook{Token: o.Token, SigningSecret: NewRandomString(OUTGOING_WEBHOOK_SIGNING_SECRET_LENGTH)}
+	if other.Sign(timestamp, body) == signature {
+		t.Fatal("should sign with the signing secret of the webhook")
+	}
+
+	if strings.Contains((&OutgoingWebhookPayload{Token: o.Token}).ToJSON(), o.SigningSecret) {
+		t.Fatal("should not send the signing secret")
+	}
+}
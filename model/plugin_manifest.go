@@ -0,0 +1,108 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	PLUGIN_MANIFEST_FILENAME = "plugin.json"
+	PLUGIN_ID_MAX_LENGTH     = 190
+)
+
+// Plugin ids are used as keys in the config, which lowercases them when it's loaded, so they're lowercase to begin with.
+var validPluginId = regexp.MustCompile(`^[a-z0-9\-_.]+$`)
+
+// Manifest describes a plugin. It's read from the plugin.json file at the root of the directory of each plugin.
+type Manifest struct {
+	Id          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Version     string           `json:"version"`
+	Backend     *ManifestBackend `json:"backend,omitempty"`
+}
+
+// ManifestBackend describes the process that the server runs for a plugin. Executable is relative to the directory of
+// the plugin and can't lead out of it.
+type ManifestBackend struct {
+	Executable string `json:"executable"`
+}
+
+type PluginsResponse struct {
+	Active   []*Manifest `json:"active"`
+	Inactive []*Manifest `json:"inactive"`
+}
+
+func IsValidPluginId(id string) bool {
+	return len(id) > 0 && len(id) <= PLUGIN_ID_MAX_LENGTH && validPluginId.MatchString(id)
+}
+
+func (m *Manifest) IsValid() *AppError {
+	if !IsValidPluginId(m.Id) {
+		return NewAppError("Manifest.IsValid", "model.plugin_manifest.is_valid.id.app_error", nil, "id="+m.Id, http.StatusBadRequest)
+	}
+
+	if m.Backend != nil && !isValidPluginExecutable(m.Backend.Executable) {
+		return NewAppError("Manifest.IsValid", "model.plugin_manifest.is_valid.executable.app_error", nil, "id="+m.Id, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func isValidPluginExecutable(executable string) bool {
+	// Absolute paths and separators of every platform are checked since a manifest may be written on another one
+	if len(executable) == 0 || filepath.IsAbs(executable) || strings.HasPrefix(executable, "/") || strings.HasPrefix(executable, "\\") || (len(executable) > 1 && executable[1] == ':') {
+		return false
+	}
+
+	for _, part := range strings.FieldsFunc(executable, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if part == ".." {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *Manifest) ToJson() string {
+	if b, err := json.Marshal(m); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ManifestFromJson(data io.Reader) *Manifest {
+	var m Manifest
+
+	if err := json.NewDecoder(data).Decode(&m); err != nil {
+		return nil
+	} else {
+		return &m
+	}
+}
+
+func (r *PluginsResponse) ToJson() string {
+	if b, err := json.Marshal(r); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func PluginsResponseFromJson(data io.Reader) *PluginsResponse {
+	var r PluginsResponse
+
+	if err := json.NewDecoder(data).Decode(&r); err != nil {
+		return nil
+	} else {
+		return &r
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestManifestIsValid(t *testing.T) {
+	m := &Manifest{Id: "com.example.plugin", Backend: &ManifestBackend{Executable: "plugin"}}
+	if err := m.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"", "Com.Example.Plugin", "with space", "slash/es", strings.Repeat("a", PLUGIN_ID_MAX_LENGTH+1)} {
+		m.Id = id
+		if err := m.IsValid(); err == nil {
+			t.Fatal("should be invalid with id " + id)
+		}
+	}
+
+	m.Id = "plugin"
+	m.Backend.Executable = ""
+	if err := m.IsValid(); err == nil {
+		t.Fatal("should be invalid without an executable")
+	}
+
+	for _, executable := range []string{"/usr/bin/plugin", "../plugin", "bin/../../plugin", "..", `\\server\plugin`, `..\plugin`, `C:\plugin.exe`} {
+		m.Backend.Executable = executable
+		if err := m.IsValid(); err == nil {
+			t.Fatal("should be invalid with an executable outside of the plugin directory " + executable)
+		}
+	}
+
+	for _, executable := range []string{"plugin", "bin/plugin", "./plugin.exe", "bin/..plugin"} {
+		m.Backend.Executable = executable
+		if err := m.IsValid(); err != nil {
+			t.Fatal("should be valid with executable " + executable)
+		}
+	}
+
+	m.Backend = nil
+	if err := m.IsValid(); err != nil {
+		t.Fatal("should be valid without a backend")
+	}
+}
+
+func TestManifestJson(t *testing.T) {
+	m := &Manifest{Id: "plugin", Name: "Plugin", Version: "0.1.0", Backend: &ManifestBackend{Executable: "plugin.exe"}}
+
+	if rm := ManifestFromJson(strings.NewReader(m.ToJson())); rm == nil || rm.Id != m.Id || rm.Backend == nil || rm.Backend.Executable != "plugin.exe" {
+		t.Fatal("manifests do not match")
+	}
+
+	r := &PluginsResponse{Active: []*Manifest{m}, Inactive: []*Manifest{}}
+	if rr := PluginsResponseFromJson(strings.NewReader(r.ToJson())); rr == nil || len(rr.Active) != 1 || rr.Active[0].Id != m.Id || len(rr.Inactive) != 0 {
+		t.Fatal("responses do not match")
+	}
+
+	if ManifestFromJson(strings.NewReader("junk")) != nil {
+		t.Fatal("should fail to decode junk")
+	}
+}
@@ -6,6 +6,7 @@ package model
 import (
 	"encoding/json"
 	"io"
+	"strings"
 	"unicode/utf8"
 )
 
@@ -24,6 +25,7 @@ const (
 	POST_DISPLAYNAME_CHANGE    = "system_displayname_change"
 	POST_PURPOSE_CHANGE        = "system_purpose_change"
 	POST_CHANNEL_DELETED       = "system_channel_deleted"
+	POST_CHANNEL_RESTORED      = "system_channel_restored"
 	POST_EPHEMERAL             = "system_ephemeral"
 	POST_FILEIDS_MAX_RUNES     = 150
 	POST_FILENAMES_MAX_RUNES   = 4000
@@ -54,6 +56,20 @@ type Post struct {
 	HasReactions  bool            `json:"has_reactions,omitempty"`
 }
 
+// PostForExport is a post along with the names that identify where it was made, as needed by the bulk exporter.
+type PostForExport struct {
+	Post
+	TeamName    string
+	ChannelName string
+	Username    string
+}
+
+// PostForIndexing is a post along with the id of the team that it was made in, as needed by the search engine.
+type PostForIndexing struct {
+	Post
+	TeamId string `json:"team_id"`
+}
+
 type PostPatch struct {
 	IsPinned     *bool            `json:"is_pinned"`
 	Message      *string          `json:"message"`
@@ -137,7 +153,7 @@ func (o *Post) IsValid() *AppError {
 		o.Type == POST_JOIN_CHANNEL || o.Type == POST_LEAVE_CHANNEL ||
 		o.Type == POST_REMOVE_FROM_CHANNEL || o.Type == POST_ADD_TO_CHANNEL ||
 		o.Type == POST_SLACK_ATTACHMENT || o.Type == POST_HEADER_CHANGE || o.Type == POST_PURPOSE_CHANGE ||
-		o.Type == POST_DISPLAYNAME_CHANGE || o.Type == POST_CHANNEL_DELETED) {
+		o.Type == POST_DISPLAYNAME_CHANGE || o.Type == POST_CHANNEL_DELETED || o.Type == POST_CHANNEL_RESTORED) {
 		return NewLocAppError("Post.IsValid", "model.post.is_valid.type.app_error", nil, "id="+o.Type)
 	}
 
@@ -153,6 +169,14 @@ func (o *Post) IsValid() *AppError {
 		return NewLocAppError("Post.IsValid", "model.post.is_valid.props.app_error", nil, "id="+o.Id)
 	}
 
+	for _, attachment := range o.Attachments() {
+		for _, action := range attachment.Actions {
+			if action != nil && action.Integration != nil && !IsValidHttpUrl(action.Integration.URL) {
+				return NewLocAppError("Post.IsValid", "model.post.is_valid.action_url.app_error", nil, "id="+o.Id)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -199,6 +223,126 @@ func (o *Post) IsSystemMessage() bool {
 	return len(o.Type) >= len(POST_SYSTEM_MESSAGE_PREFIX) && o.Type[:len(POST_SYSTEM_MESSAGE_PREFIX)] == POST_SYSTEM_MESSAGE_PREFIX
 }
 
+// Attachments returns a copy of the attachments of the post, which are stored in its props either as they were
+// created or as they were decoded from JSON.
+func (o *Post) Attachments() []*SlackAttachment {
+	if o.Props == nil || o.Props["attachments"] == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(o.Props["attachments"])
+	if err != nil {
+		return nil
+	}
+
+	var attachments []*SlackAttachment
+	if err := json.Unmarshal(b, &attachments); err != nil {
+		return nil
+	}
+
+	return attachments
+}
+
+func (o *Post) setAttachments(attachments []*SlackAttachment) {
+	props := make(StringInterface, len(o.Props))
+	for key, value := range o.Props {
+		props[key] = value
+	}
+
+	props["attachments"] = attachments
+	o.Props = props
+}
+
+// GetAction returns the action of the attachments with the given id or nil if there isn't one.
+func (o *Post) GetAction(id string) *PostAction {
+	for _, attachment := range o.Attachments() {
+		for _, action := range attachment.Actions {
+			if action != nil && action.Id == id {
+				return action
+			}
+		}
+	}
+
+	return nil
+}
+
+// GenerateActionIds makes sure that every action of the attachments has an id that can be used in a URL, generating
+// one for the actions that don't.
+func (o *Post) GenerateActionIds() {
+	attachments := o.Attachments()
+
+	hasActions := false
+	for _, attachment := range attachments {
+		for _, action := range attachment.Actions {
+			if action == nil {
+				continue
+			}
+
+			hasActions = true
+			action.Id = strings.Map(func(r rune) rune {
+				if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+					return r
+				}
+				return -1
+			}, action.Id)
+
+			if action.Id == "" {
+				action.Id = NewId()
+			}
+		}
+	}
+
+	if hasActions {
+		o.setAttachments(attachments)
+	}
+}
+
+// StripActionIntegrations removes the integrations from the actions of the attachments since their URLs and contexts
+// are only meant for the server. The props are replaced rather than changed so a copy of a post can be stripped before
+// it's sent to a client without changing the original.
+func (o *Post) StripActionIntegrations() {
+	attachments := o.Attachments()
+
+	hasActions := false
+	for _, attachment := range attachments {
+		for _, action := range attachment.Actions {
+			if action != nil {
+				hasActions = true
+				action.Integration = nil
+			}
+		}
+	}
+
+	if hasActions {
+		o.setAttachments(attachments)
+	}
+}
+
+// CopyActionIntegrations gives the actions that don't have an integration the integration of the action with the same
+// id on the other post. This keeps the integrations of a post that's updated with props from a client, which never
+// has them.
+func (o *Post) CopyActionIntegrations(other *Post) {
+	attachments := o.Attachments()
+
+	changed := false
+	for _, attachment := range attachments {
+		for _, action := range attachment.Actions {
+			if action == nil || action.Integration != nil {
+				continue
+			}
+
+			if otherAction := other.GetAction(action.Id); otherAction != nil && otherAction.Integration != nil {
+				action.Integration = otherAction.Integration
+				changed = true
+			}
+		}
+	}
+
+	if changed {
+		o.setAttachments(attachments)
+	}
+}
+
 func (p *Post) Patch(patch *PostPatch) {
 	if patch.IsPinned != nil {
 		p.IsPinned = *patch.IsPinned
@@ -43,6 +43,32 @@ func (o *PostList) MakeNonNil() {
 	}
 }
 
+// WithoutActionIntegrations returns the list with the action integrations removed from its posts. The list itself is
+// copied when it has posts with actions instead of being changed since it may be shared with a cache.
+func (o *PostList) WithoutActionIntegrations() *PostList {
+	list := &PostList{
+		Order: o.Order,
+		Posts: make(map[string]*Post, len(o.Posts)),
+	}
+
+	changed := false
+	for id, post := range o.Posts {
+		clientPost := *post
+		clientPost.StripActionIntegrations()
+
+		if clientPost.Props["attachments"] != nil {
+			changed = true
+		}
+		list.Posts[id] = &clientPost
+	}
+
+	if !changed {
+		return o
+	}
+
+	return list
+}
+
 func (o *PostList) AddOrder(id string) {
 
 	if o.Order == nil {
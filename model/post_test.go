@@ -78,6 +78,20 @@ func TestPostIsValid(t *testing.T) {
 	if err := o.IsValid(); err != nil {
 		t.Fatal(err)
 	}
+
+	o.AddProp("attachments", []*SlackAttachment{
+		{Actions: []*PostAction{{Name: "button", Integration: &PostActionIntegration{URL: "http://%zz"}}}},
+	})
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.AddProp("attachments", []*SlackAttachment{
+		{Actions: []*PostAction{{Name: "button", Integration: &PostActionIntegration{URL: "http://localhost/action"}}}},
+	})
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestPostPreSave(t *testing.T) {
@@ -113,3 +127,71 @@ func TestPostIsSystemMessage(t *testing.T) {
 		t.Fatalf("TestPostIsSystemMessage failed, expected post2.IsSystemMessage() to be true")
 	}
 }
+
+func TestPostActions(t *testing.T) {
+	attachments := []*SlackAttachment{
+		{
+			Text: "attachment",
+			Actions: []*PostAction{
+				{Id: "button-1", Name: "button", Integration: &PostActionIntegration{URL: "http://localhost", Context: map[string]interface{}{"token": "secret"}}},
+				{Name: "menu", Type: POST_ACTION_TYPE_SELECT, Integration: &PostActionIntegration{URL: "http://localhost"}},
+			},
+		},
+	}
+
+	o := Post{Message: "test"}
+	o.AddProp("attachments", attachments)
+	o.GenerateActionIds()
+
+	if actions := o.Attachments()[0].Actions; actions[0].Id != "button1" || len(actions[1].Id) != 26 {
+		t.Fatal("should have generated valid ids", actions[0].Id, actions[1].Id)
+	}
+
+	// Posts read from the database have their attachments decoded from JSON
+	o = *PostFromJson(strings.NewReader(o.ToJson()))
+
+	if action := o.GetAction("button1"); action == nil || action.Integration == nil || action.Integration.Context["token"] != "secret" {
+		t.Fatal("should have found the action")
+	}
+
+	if o.GetAction("junk") != nil {
+		t.Fatal("shouldn't have found an action")
+	}
+
+	clientPost := o
+	clientPost.StripActionIntegrations()
+
+	if action := clientPost.GetAction("button1"); action == nil || action.Integration != nil {
+		t.Fatal("should have stripped the integration")
+	}
+
+	if action := o.GetAction("button1"); action == nil || action.Integration == nil {
+		t.Fatal("shouldn't have changed the original post")
+	}
+
+	clientPost.CopyActionIntegrations(&o)
+
+	if action := clientPost.GetAction("button1"); action == nil || action.Integration == nil || action.Integration.URL != "http://localhost" {
+		t.Fatal("should have copied the integration")
+	}
+
+	list := NewPostList()
+	list.AddPost(&o)
+	list.AddOrder(o.Id)
+
+	if stripped := list.WithoutActionIntegrations(); stripped.Posts[o.Id].GetAction("button1").Integration != nil {
+		t.Fatal("should have stripped the integrations from the list")
+	}
+
+	if list.Posts[o.Id].GetAction("button1").Integration == nil {
+		t.Fatal("shouldn't have changed the list")
+	}
+
+	plain := Post{Message: "test"}
+	plain.StripActionIntegrations()
+	plain.GenerateActionIds()
+
+	if plain.Props != nil {
+		t.Fatal("shouldn't have changed a post without attachments")
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"regexp"
 )
 
+var validReactionEmojiName = regexp.MustCompile(`^[a-zA-Z0-9\-\+_]+$`)
+
 type Reaction struct {
 	UserId    string `json:"user_id"`
 	PostId    string `json:"post_id"`
@@ -61,9 +63,7 @@ func (o *Reaction) IsValid() *AppError {
 		return NewLocAppError("Reaction.IsValid", "model.reaction.is_valid.post_id.app_error", nil, "post_id="+o.PostId)
 	}
 
-	validName := regexp.MustCompile(`^[a-zA-Z0-9\-\+_]+$`)
-
-	if len(o.EmojiName) == 0 || len(o.EmojiName) > 64 || !validName.MatchString(o.EmojiName) {
+	if !IsValidReactionEmojiName(o.EmojiName) {
 		return NewLocAppError("Reaction.IsValid", "model.reaction.is_valid.emoji_name.app_error", nil, "emoji_name="+o.EmojiName)
 	}
 
@@ -74,6 +74,11 @@ func (o *Reaction) IsValid() *AppError {
 	return nil
 }
 
+// IsValidReactionEmojiName returns whether name could be the name of a system or custom emoji that's used in a reaction.
+func IsValidReactionEmojiName(name string) bool {
+	return len(name) != 0 && len(name) <= 64 && validReactionEmojiName.MatchString(name)
+}
+
 func (o *Reaction) PreSave() {
 	if o.CreateAt == 0 {
 		o.CreateAt = GetMillis()
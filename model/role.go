@@ -0,0 +1,169 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"unicode/utf8"
+)
+
+const (
+	ROLE_ID_MAX_LENGTH          = 64
+	ROLE_NAME_MAX_LENGTH        = 128
+	ROLE_DESCRIPTION_MAX_LENGTH = 1024
+	ROLE_CACHE_SIZE             = 20000
+	ROLE_CACHE_SEC              = 1800 // 30 minutes
+)
+
+var validRoleId = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Role is a named set of permissions. The built-in roles are defined in code and can be overridden by a role with the
+// same id that's saved in the database. Roles that are managed by a scheme take the place of the built-in team and
+// channel roles on the teams that use that scheme.
+type Role struct {
+	Id            string      `json:"id"`
+	Name          string      `json:"name"`
+	Description   string      `json:"description"`
+	Permissions   StringArray `json:"permissions"`
+	SchemeManaged bool        `json:"scheme_managed"`
+	CreateAt      int64       `json:"create_at"`
+	UpdateAt      int64       `json:"update_at"`
+}
+
+type RolePatch struct {
+	Permissions *[]string `json:"permissions"`
+}
+
+func (r *Role) IsValid() *AppError {
+	if len(r.Id) == 0 || len(r.Id) > ROLE_ID_MAX_LENGTH || !validRoleId.MatchString(r.Id) {
+		return NewAppError("Role.IsValid", "model.role.is_valid.id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(r.Name) == 0 || utf8.RuneCountInString(r.Name) > ROLE_NAME_MAX_LENGTH {
+		return NewAppError("Role.IsValid", "model.role.is_valid.name.app_error", nil, "id="+r.Id, http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(r.Description) > ROLE_DESCRIPTION_MAX_LENGTH {
+		return NewAppError("Role.IsValid", "model.role.is_valid.description.app_error", nil, "id="+r.Id, http.StatusBadRequest)
+	}
+
+	for _, permissionId := range r.Permissions {
+		if !IsValidPermissionId(permissionId) {
+			return NewAppError("Role.IsValid", "model.role.is_valid.permission.app_error", nil, "id="+r.Id+", permission="+permissionId, http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func (r *Role) PreSave() {
+	if r.Permissions == nil {
+		r.Permissions = StringArray{}
+	}
+
+	r.CreateAt = GetMillis()
+	r.UpdateAt = r.CreateAt
+}
+
+func (r *Role) PreUpdate() {
+	r.UpdateAt = GetMillis()
+}
+
+func (r *Role) Patch(patch *RolePatch) {
+	if patch.Permissions != nil {
+		r.Permissions = StringArray(*patch.Permissions)
+	}
+}
+
+func (r *Role) HasPermission(permissionId string) bool {
+	for _, p := range r.Permissions {
+		if p == permissionId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GuestRoleFor returns the guest role that takes the place of the given team or channel role for guests, so that
+// guests who are made team or channel admins still can't do more than other guests. Any other role is returned
+// unchanged.
+func GuestRoleFor(roleId string) string {
+	switch roleId {
+	case ROLE_TEAM_USER.Id, ROLE_TEAM_ADMIN.Id:
+		return ROLE_TEAM_GUEST.Id
+	case ROLE_CHANNEL_USER.Id, ROLE_CHANNEL_ADMIN.Id:
+		return ROLE_CHANNEL_GUEST.Id
+	}
+
+	return roleId
+}
+
+// IsValidPermissionId returns true if the given id belongs to one of the permissions in ALL_PERMISSIONS.
+func IsValidPermissionId(permissionId string) bool {
+	for _, permission := range ALL_PERMISSIONS {
+		if permission.Id == permissionId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Role) ToJson() string {
+	if b, err := json.Marshal(r); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func RoleFromJson(data io.Reader) *Role {
+	var r Role
+
+	if err := json.NewDecoder(data).Decode(&r); err != nil {
+		return nil
+	} else {
+		return &r
+	}
+}
+
+func (p *RolePatch) ToJson() string {
+	if b, err := json.Marshal(p); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func RolePatchFromJson(data io.Reader) *RolePatch {
+	var p RolePatch
+
+	if err := json.NewDecoder(data).Decode(&p); err != nil {
+		return nil
+	} else {
+		return &p
+	}
+}
+
+func RoleListToJson(r []*Role) string {
+	if b, err := json.Marshal(r); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func RoleListFromJson(data io.Reader) []*Role {
+	var r []*Role
+
+	if err := json.NewDecoder(data).Decode(&r); err != nil {
+		return nil
+	} else {
+		return r
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRoleJson(t *testing.T) {
+	role := Role{
+		Id:          "some_role",
+		Name:        "Some Role",
+		Permissions: StringArray{PERMISSION_CREATE_POST.Id},
+	}
+
+	rrole := RoleFromJson(strings.NewReader(role.ToJson()))
+	if rrole.Id != role.Id || rrole.Name != role.Name || len(rrole.Permissions) != 1 || rrole.Permissions[0] != PERMISSION_CREATE_POST.Id {
+		t.Fatal("roles do not match")
+	}
+
+	rroles := RoleListFromJson(strings.NewReader(RoleListToJson([]*Role{&role})))
+	if len(rroles) != 1 || rroles[0].Id != role.Id {
+		t.Fatal("role lists do not match")
+	}
+
+	rpatch := RolePatchFromJson(strings.NewReader((&RolePatch{Permissions: &[]string{PERMISSION_EDIT_POST.Id}}).ToJson()))
+	if rpatch.Permissions == nil || len(*rpatch.Permissions) != 1 || (*rpatch.Permissions)[0] != PERMISSION_EDIT_POST.Id {
+		t.Fatal("patches do not match")
+	}
+}
+
+func TestRoleIsValid(t *testing.T) {
+	role := Role{
+		Id:          "some_role",
+		Name:        "Some Role",
+		Permissions: StringArray{PERMISSION_CREATE_POST.Id},
+	}
+	role.PreSave()
+
+	if err := role.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, role := range BuiltInRoles {
+		if err := role.IsValid(); err != nil {
+			t.Fatal(role.Id, err)
+		}
+	}
+
+	role.Id = "Some Role"
+	if err := role.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	role.Id = strings.Repeat("a", ROLE_ID_MAX_LENGTH+1)
+	if err := role.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	role.Id = NewId()
+	role.Name = ""
+	if err := role.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	role.Name = "Some Role"
+	role.Description = strings.Repeat("a", ROLE_DESCRIPTION_MAX_LENGTH+1)
+	if err := role.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	role.Description = ""
+	role.Permissions = append(role.Permissions, "junk")
+	if err := role.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestRolePatch(t *testing.T) {
+	role := Role{
+		Id:          "some_role",
+		Permissions: StringArray{PERMISSION_CREATE_POST.Id},
+	}
+
+	role.Patch(&RolePatch{})
+	if !role.HasPermission(PERMISSION_CREATE_POST.Id) {
+		t.Fatal("an empty patch shouldn't change the permissions")
+	}
+
+	role.Patch(&RolePatch{Permissions: &[]string{PERMISSION_EDIT_POST.Id}})
+	if role.HasPermission(PERMISSION_CREATE_POST.Id) || !role.HasPermission(PERMISSION_EDIT_POST.Id) {
+		t.Fatal("the permissions should have been replaced")
+	}
+}
+
+func TestGuestRoleFor(t *testing.T) {
+	for roleId, expected := range map[string]string{
+		ROLE_TEAM_USER.Id:     ROLE_TEAM_GUEST.Id,
+		ROLE_TEAM_ADMIN.Id:    ROLE_TEAM_GUEST.Id,
+		ROLE_CHANNEL_USER.Id:  ROLE_CHANNEL_GUEST.Id,
+		ROLE_CHANNEL_ADMIN.Id: ROLE_CHANNEL_GUEST.Id,
+		ROLE_SYSTEM_GUEST.Id:  ROLE_SYSTEM_GUEST.Id,
+	} {
+		if actual := GuestRoleFor(roleId); actual != expected {
+			t.Fatalf("expected %v for %v, got %v", expected, roleId, actual)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"unicode/utf8"
+)
+
+const (
+	SCHEME_NAME_MAX_RUNES        = 64
+	SCHEME_DESCRIPTION_MAX_RUNES = 1024
+	TEAM_SCHEME_CACHE_SIZE       = 20000
+	TEAM_SCHEME_CACHE_SEC        = 1800 // 30 minutes
+)
+
+// Scheme is a set of team and channel roles that a team can use instead of the built-in ones. Each scheme has its own
+// copy of the team_admin, team_user, channel_admin and channel_user roles, which are created along with it.
+type Scheme struct {
+	Id                      string `json:"id"`
+	Name                    string `json:"name"`
+	Description             string `json:"description"`
+	CreateAt                int64  `json:"create_at"`
+	UpdateAt                int64  `json:"update_at"`
+	DeleteAt                int64  `json:"delete_at"`
+	DefaultTeamAdminRole    string `json:"default_team_admin_role"`
+	DefaultTeamUserRole     string `json:"default_team_user_role"`
+	DefaultChannelAdminRole string `json:"default_channel_admin_role"`
+	DefaultChannelUserRole  string `json:"default_channel_user_role"`
+}
+
+type SchemePatch struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+func (s *Scheme) IsValid() *AppError {
+	if len(s.Id) != 26 {
+		return NewAppError("Scheme.IsValid", "model.scheme.is_valid.id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(s.Name) == 0 || utf8.RuneCountInString(s.Name) > SCHEME_NAME_MAX_RUNES {
+		return NewAppError("Scheme.IsValid", "model.scheme.is_valid.name.app_error", nil, "id="+s.Id, http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(s.Description) > SCHEME_DESCRIPTION_MAX_RUNES {
+		return NewAppError("Scheme.IsValid", "model.scheme.is_valid.description.app_error", nil, "id="+s.Id, http.StatusBadRequest)
+	}
+
+	if s.CreateAt == 0 {
+		return NewAppError("Scheme.IsValid", "model.scheme.is_valid.create_at.app_error", nil, "id="+s.Id, http.StatusBadRequest)
+	}
+
+	if s.UpdateAt == 0 {
+		return NewAppError("Scheme.IsValid", "model.scheme.is_valid.update_at.app_error", nil, "id="+s.Id, http.StatusBadRequest)
+	}
+
+	for _, roleId := range []string{s.DefaultTeamAdminRole, s.DefaultTeamUserRole, s.DefaultChannelAdminRole, s.DefaultChannelUserRole} {
+		if len(roleId) != 26 {
+			return NewAppError("Scheme.IsValid", "model.scheme.is_valid.role.app_error", nil, "id="+s.Id, http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func (s *Scheme) PreSave() {
+	if s.Id == "" {
+		s.Id = NewId()
+	}
+
+	s.CreateAt = GetMillis()
+	s.UpdateAt = s.CreateAt
+}
+
+func (s *Scheme) PreUpdate() {
+	s.UpdateAt = GetMillis()
+}
+
+func (s *Scheme) Patch(patch *SchemePatch) {
+	if patch.Name != nil {
+		s.Name = *patch.Name
+	}
+
+	if patch.Description != nil {
+		s.Description = *patch.Description
+	}
+}
+
+// RoleFor returns the role of the scheme that takes the place of the given built-in team or channel role. Any other
+// role is returned unchanged.
+func (s *Scheme) RoleFor(roleId string) string {
+	switch roleId {
+	case ROLE_TEAM_ADMIN.Id:
+		return s.DefaultTeamAdminRole
+	case ROLE_TEAM_USER.Id:
+		return s.DefaultTeamUserRole
+	case ROLE_CHANNEL_ADMIN.Id:
+		return s.DefaultChannelAdminRole
+	case ROLE_CHANNEL_USER.Id:
+		return s.DefaultChannelUserRole
+	}
+
+	return roleId
+}
+
+func (s *Scheme) ToJson() string {
+	if b, err := json.Marshal(s); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func SchemeFromJson(data io.Reader) *Scheme {
+	var s Scheme
+
+	if err := json.NewDecoder(data).Decode(&s); err != nil {
+		return nil
+	} else {
+		return &s
+	}
+}
+
+func (p *SchemePatch) ToJson() string {
+	if b, err := json.Marshal(p); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func SchemePatchFromJson(data io.Reader) *SchemePatch {
+	var p SchemePatch
+
+	if err := json.NewDecoder(data).Decode(&p); err != nil {
+		return nil
+	} else {
+		return &p
+	}
+}
+
+func SchemeListToJson(s []*Scheme) string {
+	if b, err := json.Marshal(s); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func SchemeListFromJson(data io.Reader) []*Scheme {
+	var s []*Scheme
+
+	if err := json.NewDecoder(data).Decode(&s); err != nil {
+		return nil
+	} else {
+		return s
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func newTestScheme() *Scheme {
+	return &Scheme{
+		Name:                    "Some Scheme",
+		DefaultTeamAdminRole:    NewId(),
+		DefaultTeamUserRole:     NewId(),
+		DefaultChannelAdminRole: NewId(),
+		DefaultChannelUserRole:  NewId(),
+	}
+}
+
+func TestSchemeJson(t *testing.T) {
+	scheme := newTestScheme()
+	scheme.Id = NewId()
+
+	rscheme := SchemeFromJson(strings.NewReader(scheme.ToJson()))
+	if rscheme.Id != scheme.Id || rscheme.Name != scheme.Name || rscheme.DefaultTeamUserRole != scheme.DefaultTeamUserRole {
+		t.Fatal("schemes do not match")
+	}
+
+	rschemes := SchemeListFromJson(strings.NewReader(SchemeListToJson([]*Scheme{scheme})))
+	if len(rschemes) != 1 || rschemes[0].Id != scheme.Id {
+		t.Fatal("scheme lists do not match")
+	}
+
+	name := "Other Scheme"
+	rpatch := SchemePatchFromJson(strings.NewReader((&SchemePatch{Name: &name}).ToJson()))
+	if rpatch.Name == nil || *rpatch.Name != name || rpatch.Description != nil {
+		t.Fatal("patches do not match")
+	}
+}
+
+func TestSchemeIsValid(t *testing.T) {
+	scheme := newTestScheme()
+	scheme.PreSave()
+
+	if err := scheme.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	scheme.Name = ""
+	if err := scheme.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	scheme.Name = strings.Repeat("a", SCHEME_NAME_MAX_RUNES+1)
+	if err := scheme.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	scheme.Name = "Some Scheme"
+	scheme.Description = strings.Repeat("a", SCHEME_DESCRIPTION_MAX_RUNES+1)
+	if err := scheme.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	scheme.Description = ""
+	scheme.DefaultChannelUserRole = ""
+	if err := scheme.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestSchemeRoleFor(t *testing.T) {
+	scheme := newTestScheme()
+
+	if scheme.RoleFor(ROLE_TEAM_ADMIN.Id) != scheme.DefaultTeamAdminRole {
+		t.Fatal("wrong team admin role")
+	}
+
+	if scheme.RoleFor(ROLE_TEAM_USER.Id) != scheme.DefaultTeamUserRole {
+		t.Fatal("wrong team user role")
+	}
+
+	if scheme.RoleFor(ROLE_CHANNEL_ADMIN.Id) != scheme.DefaultChannelAdminRole {
+		t.Fatal("wrong channel admin role")
+	}
+
+	if scheme.RoleFor(ROLE_CHANNEL_USER.Id) != scheme.DefaultChannelUserRole {
+		t.Fatal("wrong channel user role")
+	}
+
+	if scheme.RoleFor(ROLE_SYSTEM_USER.Id) != ROLE_SYSTEM_USER.Id {
+		t.Fatal("system roles shouldn't be replaced")
+	}
+}
@@ -7,17 +7,30 @@ import (
 	"encoding/json"
 	"regexp"
 	"strings"
+	"time"
+)
+
+const (
+	SEARCH_DATE_FORMAT = "2006-01-02"
 )
 
 var searchTermPuncStart = regexp.MustCompile(`^[^\pL\d\s#"]+`)
 var searchTermPuncEnd = regexp.MustCompile(`[^\pL\d\s*"]+$`)
 
 type SearchParams struct {
-	Terms      string
-	IsHashtag  bool
-	InChannels []string
-	FromUsers  []string
-	OrTerms    bool
+	Terms            string
+	ExcludedTerms    string
+	IsHashtag        bool
+	InChannels       []string
+	FromUsers        []string
+	OnDate           string
+	BeforeDate       string
+	AfterDate        string
+	HasFile          bool
+	HasLink          bool
+	IsPinned         bool
+	InDirectChannels bool
+	OrTerms          bool
 }
 
 func (o *SearchParams) ToJson() string {
@@ -29,7 +42,60 @@ func (o *SearchParams) ToJson() string {
 	}
 }
 
-var searchFlags = [...]string{"from", "channel", "in"}
+// IsFiltered returns true if the search is limited by anything other than its terms.
+func (o *SearchParams) IsFiltered() bool {
+	return len(o.InChannels) != 0 || len(o.FromUsers) != 0 || o.OnDate != "" || o.BeforeDate != "" || o.AfterDate != "" ||
+		o.HasFile || o.HasLink || o.IsPinned || o.InDirectChannels
+}
+
+// parseSearchDate returns the time at the start of the given day in UTC, or zero if the date isn't valid.
+func parseSearchDate(date string) int64 {
+	if t, err := time.Parse(SEARCH_DATE_FORMAT, date); err != nil {
+		return 0
+	} else {
+		return t.UnixNano() / int64(time.Millisecond)
+	}
+}
+
+// GetOnDateMillis returns the start and end of the day that posts must be made on, or zeroes if there isn't one.
+func (o *SearchParams) GetOnDateMillis() (int64, int64) {
+	if start := parseSearchDate(o.OnDate); start == 0 {
+		return 0, 0
+	} else {
+		return start, start + int64(24*time.Hour/time.Millisecond)
+	}
+}
+
+// GetBeforeDateMillis returns the start of the day that posts must be made before, or zero if there isn't one.
+func (o *SearchParams) GetBeforeDateMillis() int64 {
+	return parseSearchDate(o.BeforeDate)
+}
+
+// GetAfterDateMillis returns the end of the day that posts must be made after, or zero if there isn't one.
+func (o *SearchParams) GetAfterDateMillis() int64 {
+	if start := parseSearchDate(o.AfterDate); start == 0 {
+		return 0
+	} else {
+		return start + int64(24*time.Hour/time.Millisecond)
+	}
+}
+
+var searchFlags = [...]string{"from", "channel", "in", "on", "before", "after", "has", "is"}
+
+// isValidSearchFlagValue checks the values of flags that only accept certain values so that anything else is
+// searched for as a regular word.
+func isValidSearchFlagValue(flag string, value string) bool {
+	switch flag {
+	case "on", "before", "after":
+		return parseSearchDate(value) != 0
+	case "has":
+		return value == "file" || value == "link"
+	case "is":
+		return value == "pinned" || value == "dm"
+	}
+
+	return true
+}
 
 func splitWordsNoQuotes(text string) []string {
 	words := []string{}
@@ -88,9 +154,11 @@ func parseSearchFlags(input []string) ([]string, [][2]string) {
 				// check for case insensitive equality
 				if strings.EqualFold(flag, searchFlag) {
 					if value != "" {
-						flags = append(flags, [2]string{searchFlag, value})
-						isFlag = true
-					} else if i < len(input)-1 {
+						if isValidSearchFlagValue(searchFlag, strings.ToLower(value)) {
+							flags = append(flags, [2]string{searchFlag, value})
+							isFlag = true
+						}
+					} else if i < len(input)-1 && isValidSearchFlagValue(searchFlag, strings.ToLower(input[i+1])) {
 						flags = append(flags, [2]string{searchFlag, input[i+1]})
 						skipNextWord = true
 						isFlag = true
@@ -104,6 +172,16 @@ func parseSearchFlags(input []string) ([]string, [][2]string) {
 		}
 
 		if !isFlag {
+			// a leading dash excludes a word, or the quoted phrase that follows it, from the results
+			excluded := false
+			if word == "-" && i < len(input)-1 && strings.HasPrefix(input[i+1], "\"") {
+				word = input[i+1]
+				skipNextWord = true
+				excluded = true
+			} else if strings.HasPrefix(word, "-") {
+				excluded = true
+			}
+
 			// trim off surrounding punctuation (note that we leave trailing asterisks to allow wildcards)
 			word = searchTermPuncStart.ReplaceAllString(word, "")
 			word = searchTermPuncEnd.ReplaceAllString(word, "")
@@ -112,6 +190,10 @@ func parseSearchFlags(input []string) ([]string, [][2]string) {
 			word = hashtagStart.ReplaceAllString(word, "#")
 
 			if len(word) != 0 {
+				if excluded {
+					word = "-" + word
+				}
+
 				words = append(words, word)
 			}
 		}
@@ -120,65 +202,98 @@ func parseSearchFlags(input []string) ([]string, [][2]string) {
 	return words, flags
 }
 
+// ParseSearchParams splits a search into separate params for plain terms and hashtags. Words prefixed with a dash are
+// excluded from the results, and flags like in: and on: limit the search.
 func ParseSearchParams(text string) []*SearchParams {
 	words, flags := parseSearchFlags(splitWords(text))
 
 	hashtagTermList := []string{}
+	excludedHashtagTermList := []string{}
 	plainTermList := []string{}
+	excludedPlainTermList := []string{}
 
 	for _, word := range words {
+		excluded := strings.HasPrefix(word, "-")
+		if excluded {
+			word = word[1:]
+		}
+
 		if validHashtag.MatchString(word) {
-			hashtagTermList = append(hashtagTermList, word)
+			if excluded {
+				excludedHashtagTermList = append(excludedHashtagTermList, word)
+			} else {
+				hashtagTermList = append(hashtagTermList, word)
+			}
 		} else {
-			plainTermList = append(plainTermList, word)
+			if excluded {
+				excludedPlainTermList = append(excludedPlainTermList, word)
+			} else {
+				plainTermList = append(plainTermList, word)
+			}
 		}
 	}
 
 	hashtagTerms := strings.Join(hashtagTermList, " ")
+	excludedHashtagTerms := strings.Join(excludedHashtagTermList, " ")
 	plainTerms := strings.Join(plainTermList, " ")
+	excludedPlainTerms := strings.Join(excludedPlainTermList, " ")
 
-	inChannels := []string{}
-	fromUsers := []string{}
+	filters := SearchParams{
+		InChannels: []string{},
+		FromUsers:  []string{},
+	}
 
 	for _, flagPair := range flags {
 		flag := flagPair[0]
 		value := flagPair[1]
 
-		if flag == "in" || flag == "channel" {
-			inChannels = append(inChannels, value)
-		} else if flag == "from" {
-			fromUsers = append(fromUsers, value)
+		switch flag {
+		case "in", "channel":
+			filters.InChannels = append(filters.InChannels, value)
+		case "from":
+			filters.FromUsers = append(filters.FromUsers, value)
+		case "on":
+			filters.OnDate = value
+		case "before":
+			filters.BeforeDate = value
+		case "after":
+			filters.AfterDate = value
+		case "has":
+			if strings.EqualFold(value, "file") {
+				filters.HasFile = true
+			} else {
+				filters.HasLink = true
+			}
+		case "is":
+			if strings.EqualFold(value, "pinned") {
+				filters.IsPinned = true
+			} else {
+				filters.InDirectChannels = true
+			}
 		}
 	}
 
+	newParams := func(terms string, excludedTerms string, isHashtag bool) *SearchParams {
+		params := filters
+		params.Terms = terms
+		params.ExcludedTerms = excludedTerms
+		params.IsHashtag = isHashtag
+		return &params
+	}
+
 	paramsList := []*SearchParams{}
 
 	if len(plainTerms) > 0 {
-		paramsList = append(paramsList, &SearchParams{
-			Terms:      plainTerms,
-			IsHashtag:  false,
-			InChannels: inChannels,
-			FromUsers:  fromUsers,
-		})
+		paramsList = append(paramsList, newParams(plainTerms, excludedPlainTerms, false))
 	}
 
 	if len(hashtagTerms) > 0 {
-		paramsList = append(paramsList, &SearchParams{
-			Terms:      hashtagTerms,
-			IsHashtag:  true,
-			InChannels: inChannels,
-			FromUsers:  fromUsers,
-		})
+		paramsList = append(paramsList, newParams(hashtagTerms, excludedHashtagTerms, true))
 	}
 
 	// special case for when no terms are specified but we still have a filter
-	if len(plainTerms) == 0 && len(hashtagTerms) == 0 && (len(inChannels) != 0 || len(fromUsers) != 0) {
-		paramsList = append(paramsList, &SearchParams{
-			Terms:      "",
-			IsHashtag:  false,
-			InChannels: inChannels,
-			FromUsers:  fromUsers,
-		})
+	if len(plainTerms) == 0 && len(hashtagTerms) == 0 && filters.IsFiltered() {
+		paramsList = append(paramsList, newParams("", excludedPlainTerms, false))
 	}
 
 	return paramsList
@@ -4,6 +4,7 @@
 package model
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -221,3 +222,127 @@ func TestParseSearchParams(t *testing.T) {
 		t.Fatalf("Incorrect output from parse search params: %v", sp[0])
 	}
 }
+
+func TestParseSearchParamsModifiers(t *testing.T) {
+	for _, testCase := range []struct {
+		Input    string
+		Expected []*SearchParams
+	}{
+		{
+			Input:    "apple -banana",
+			Expected: []*SearchParams{{Terms: "apple", ExcludedTerms: "banana"}},
+		},
+		{
+			Input:    "apple -\"banana split\" -cherry",
+			Expected: []*SearchParams{{Terms: "apple", ExcludedTerms: "\"banana split\" cherry"}},
+		},
+		{
+			Input:    "apple - banana",
+			Expected: []*SearchParams{{Terms: "apple banana"}},
+		},
+		{
+			Input:    "#apple -#banana",
+			Expected: []*SearchParams{{Terms: "#apple", ExcludedTerms: "#banana", IsHashtag: true}},
+		},
+		{
+			Input:    "apple on:2017-06-15",
+			Expected: []*SearchParams{{Terms: "apple", OnDate: "2017-06-15"}},
+		},
+		{
+			Input:    "apple before: 2017-06-15 after:2017-06-01",
+			Expected: []*SearchParams{{Terms: "apple", BeforeDate: "2017-06-15", AfterDate: "2017-06-01"}},
+		},
+		{
+			Input:    "apple on:yesterday",
+			Expected: []*SearchParams{{Terms: "apple on:yesterday"}},
+		},
+		{
+			Input:    "apple has:file",
+			Expected: []*SearchParams{{Terms: "apple", HasFile: true}},
+		},
+		{
+			Input:    "apple HAS:Link",
+			Expected: []*SearchParams{{Terms: "apple", HasLink: true}},
+		},
+		{
+			Input:    "apple has:banana",
+			Expected: []*SearchParams{{Terms: "apple has:banana"}},
+		},
+		{
+			Input:    "apple is:pinned",
+			Expected: []*SearchParams{{Terms: "apple", IsPinned: true}},
+		},
+		{
+			Input:    "apple is:dm",
+			Expected: []*SearchParams{{Terms: "apple", InDirectChannels: true}},
+		},
+		{
+			Input:    "apple in:@someone",
+			Expected: []*SearchParams{{Terms: "apple", InChannels: []string{"@someone"}}},
+		},
+		{
+			Input:    "is:pinned",
+			Expected: []*SearchParams{{IsPinned: true}},
+		},
+		{
+			Input:    "on:2017-06-15 -apple",
+			Expected: []*SearchParams{{ExcludedTerms: "apple", OnDate: "2017-06-15"}},
+		},
+		{
+			Input:    "-apple",
+			Expected: []*SearchParams{},
+		},
+		{
+			Input: "apple #banana has:file",
+			Expected: []*SearchParams{
+				{Terms: "apple", HasFile: true},
+				{Terms: "#banana", IsHashtag: true, HasFile: true},
+			},
+		},
+	} {
+		for _, params := range testCase.Expected {
+			if params.InChannels == nil {
+				params.InChannels = []string{}
+			}
+			if params.FromUsers == nil {
+				params.FromUsers = []string{}
+			}
+		}
+
+		if actual := ParseSearchParams(testCase.Input); !reflect.DeepEqual(actual, testCase.Expected) {
+			t.Fatalf("Incorrect output from parse search params for %q: %v", testCase.Input, searchParamsListToJson(actual))
+		}
+	}
+}
+
+func searchParamsListToJson(paramsList []*SearchParams) string {
+	result := ""
+	for _, params := range paramsList {
+		result += params.ToJson()
+	}
+	return result
+}
+
+func TestSearchParamsDates(t *testing.T) {
+	params := &SearchParams{OnDate: "2017-06-15", BeforeDate: "2017-06-15", AfterDate: "2017-06-15"}
+
+	dayStart := int64(1497484800000)
+	dayEnd := dayStart + 24*60*60*1000
+
+	if start, end := params.GetOnDateMillis(); start != dayStart || end != dayEnd {
+		t.Fatalf("Incorrect on date %v %v", start, end)
+	}
+
+	if before := params.GetBeforeDateMillis(); before != dayStart {
+		t.Fatalf("Incorrect before date %v", before)
+	}
+
+	if after := params.GetAfterDateMillis(); after != dayEnd {
+		t.Fatalf("Incorrect after date %v", after)
+	}
+
+	params = &SearchParams{}
+	if start, end := params.GetOnDateMillis(); start != 0 || end != 0 || params.GetBeforeDateMillis() != 0 || params.GetAfterDateMillis() != 0 {
+		t.Fatal("Dates should be zero when not set")
+	}
+}
@@ -10,13 +10,17 @@ import (
 )
 
 const (
-	SESSION_COOKIE_TOKEN     = "MMAUTHTOKEN"
-	SESSION_COOKIE_USER      = "MMUSERID"
-	SESSION_CACHE_SIZE       = 35000
-	SESSION_PROP_PLATFORM    = "platform"
-	SESSION_PROP_OS          = "os"
-	SESSION_PROP_BROWSER     = "browser"
-	SESSION_ACTIVITY_TIMEOUT = 1000 * 60 * 5 // 5 minutes
+	SESSION_COOKIE_TOKEN              = "MMAUTHTOKEN"
+	SESSION_COOKIE_USER               = "MMUSERID"
+	SESSION_CACHE_SIZE                = 35000
+	SESSION_PROP_PLATFORM             = "platform"
+	SESSION_PROP_OS                   = "os"
+	SESSION_PROP_BROWSER              = "browser"
+	SESSION_PROP_TYPE                 = "type"
+	SESSION_PROP_USER_ACCESS_TOKEN_ID = "user_access_token_id"
+	SESSION_TYPE_USER_ACCESS_TOKEN    = "UserAccessToken"
+	SESSION_ACTIVITY_TIMEOUT          = 1000 * 60 * 5 // 5 minutes
+	SESSION_USER_ACCESS_TOKEN_EXPIRY  = 100 * 365     // 100 years
 )
 
 type Session struct {
@@ -120,6 +124,10 @@ func (me *Session) GetUserRoles() []string {
 	return strings.Fields(me.Roles)
 }
 
+func (me *Session) IsGuest() bool {
+	return IsInRole(me.Roles, ROLE_SYSTEM_GUEST.Id)
+}
+
 func SessionsToJson(o []*Session) string {
 	if b, err := json.Marshal(o); err != nil {
 		return "[]"
@@ -3,6 +3,19 @@
 
 package model
 
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	POST_ACTION_TYPE_BUTTON = "button"
+	POST_ACTION_TYPE_SELECT = "select"
+
+	POST_ACTION_DATA_SOURCE_USERS    = "users"
+	POST_ACTION_DATA_SOURCE_CHANNELS = "channels"
+)
+
 type SlackAttachment struct {
 	Id         int64                   `json:"id"`
 	Fallback   string                  `json:"fallback"`
@@ -20,6 +33,7 @@ type SlackAttachment struct {
 	Footer     string                  `json:"footer"`
 	FooterIcon string                  `json:"footer_icon"`
 	Timestamp  interface{}             `json:"ts"` // This is either a string or an int64
+	Actions    []*PostAction           `json:"actions,omitempty"`
 }
 
 type SlackAttachmentField struct {
@@ -27,3 +41,120 @@ type SlackAttachmentField struct {
 	Value interface{} `json:"value"`
 	Short bool        `json:"short"`
 }
+
+// PostAction is a button or a select menu on an attachment. When a user clicks the button or picks an option, the
+// server sends the context of the integration to its URL.
+type PostAction struct {
+	Id          string                 `json:"id,omitempty"`
+	Name        string                 `json:"name,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	DataSource  string                 `json:"data_source,omitempty"`
+	Options     []*PostActionOptions   `json:"options,omitempty"`
+	Integration *PostActionIntegration `json:"integration,omitempty"`
+}
+
+type PostActionOptions struct {
+	Text  string `json:"text"`
+	Value string `json:"value"`
+}
+
+type PostActionIntegration struct {
+	URL     string                 `json:"url,omitempty"`
+	Context map[string]interface{} `json:"context,omitempty"`
+}
+
+type PostActionIntegrationRequest struct {
+	UserId     string                 `json:"user_id"`
+	ChannelId  string                 `json:"channel_id"`
+	TeamId     string                 `json:"team_id"`
+	PostId     string                 `json:"post_id"`
+	TriggerId  string                 `json:"trigger_id"`
+	Type       string                 `json:"type,omitempty"`
+	DataSource string                 `json:"data_source,omitempty"`
+	Context    map[string]interface{} `json:"context,omitempty"`
+}
+
+type PostActionIntegrationResponse struct {
+	Update        *Post  `json:"update"`
+	EphemeralText string `json:"ephemeral_text"`
+}
+
+type DoPostActionRequest struct {
+	SelectedOption string `json:"selected_option"`
+}
+
+// IsValidOption reports whether an option picked from a select menu is one of the options of the menu. Menus that
+// list users or channels are filled in by the client, so any option is accepted for them.
+func (o *PostAction) IsValidOption(option string) bool {
+	if o.DataSource != "" {
+		return option != ""
+	}
+
+	for _, opt := range o.Options {
+		if opt != nil && opt.Value == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o *PostActionIntegrationRequest) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func PostActionIntegrationRequestFromJson(data io.Reader) *PostActionIntegrationRequest {
+	decoder := json.NewDecoder(data)
+	var o PostActionIntegrationRequest
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func (o *PostActionIntegrationResponse) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func PostActionIntegrationResponseFromJson(data io.Reader) *PostActionIntegrationResponse {
+	decoder := json.NewDecoder(data)
+	var o PostActionIntegrationResponse
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func (o *DoPostActionRequest) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func DoPostActionRequestFromJson(data io.Reader) *DoPostActionRequest {
+	decoder := json.NewDecoder(data)
+	var o DoPostActionRequest
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
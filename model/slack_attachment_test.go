@@ -0,0 +1,56 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostActionIsValidOption(t *testing.T) {
+	action := &PostAction{
+		Type:    POST_ACTION_TYPE_SELECT,
+		Options: []*PostActionOptions{{Text: "One", Value: "1"}, {Text: "Two", Value: "2"}},
+	}
+
+	if !action.IsValidOption("2") {
+		t.Fatal("should be a valid option")
+	}
+
+	if action.IsValidOption("3") || action.IsValidOption("") {
+		t.Fatal("shouldn't be a valid option")
+	}
+
+	action = &PostAction{Type: POST_ACTION_TYPE_SELECT, DataSource: POST_ACTION_DATA_SOURCE_USERS}
+
+	if !action.IsValidOption(NewId()) {
+		t.Fatal("should accept any option from a data source")
+	}
+
+	if action.IsValidOption("") {
+		t.Fatal("should require an option")
+	}
+}
+
+func TestPostActionIntegrationJson(t *testing.T) {
+	request := &PostActionIntegrationRequest{
+		UserId:  NewId(),
+		PostId:  NewId(),
+		Context: map[string]interface{}{"key": "value"},
+	}
+
+	if result := PostActionIntegrationRequestFromJson(strings.NewReader(request.ToJson())); result == nil || result.UserId != request.UserId || result.Context["key"] != "value" {
+		t.Fatal("requests do not match")
+	}
+
+	response := &PostActionIntegrationResponse{Update: &Post{Message: "updated"}, EphemeralText: "text"}
+
+	if result := PostActionIntegrationResponseFromJson(strings.NewReader(response.ToJson())); result == nil || result.Update.Message != "updated" || result.EphemeralText != "text" {
+		t.Fatal("responses do not match")
+	}
+
+	if DoPostActionRequestFromJson(strings.NewReader("junk")) != nil {
+		t.Fatal("should have failed to decode junk")
+	}
+}
@@ -39,6 +39,7 @@ type Team struct {
 	AllowedDomains  string `json:"allowed_domains"`
 	InviteId        string `json:"invite_id"`
 	AllowOpenInvite bool   `json:"allow_open_invite"`
+	SchemeId        string `json:"scheme_id"`
 }
 
 type TeamPatch struct {
@@ -199,6 +200,10 @@ func (o *Team) IsValid() *AppError {
 		return NewAppError("Team.IsValid", "model.team.is_valid.domains.app_error", nil, "id="+o.Id, http.StatusBadRequest)
 	}
 
+	if len(o.SchemeId) != 0 && len(o.SchemeId) != 26 {
+		return NewAppError("Team.IsValid", "model.team.is_valid.scheme_id.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
 	return nil
 }
 
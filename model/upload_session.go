@@ -0,0 +1,106 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+const (
+	UPLOAD_SESSION_EXPIRY_TIME = 1000 * 60 * 60 * 24 // 24 hours
+)
+
+// UploadSession tracks a file that's being uploaded in several chunks so that an interrupted upload can be resumed
+// from FileOffset instead of being restarted. UpdateAt is moved forward whenever a chunk is received so that a session
+// only expires once the client has stopped sending data.
+type UploadSession struct {
+	Id         string `json:"id"`
+	CreateAt   int64  `json:"create_at"`
+	UpdateAt   int64  `json:"update_at"`
+	UserId     string `json:"user_id"`
+	ChannelId  string `json:"channel_id"`
+	Filename   string `json:"filename"`
+	Path       string `json:"-"` // not sent back to the client
+	FileSize   int64  `json:"file_size"`
+	FileOffset int64  `json:"file_offset"`
+	Finishing  bool   `json:"-"` // set while the chunks are being assembled so that the upload is only finished once
+}
+
+func (us *UploadSession) ToJson() string {
+	if b, err := json.Marshal(us); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func UploadSessionFromJson(data io.Reader) *UploadSession {
+	var us UploadSession
+
+	if err := json.NewDecoder(data).Decode(&us); err != nil {
+		return nil
+	} else {
+		return &us
+	}
+}
+
+func (us *UploadSession) PreSave() {
+	if us.Id == "" {
+		us.Id = NewId()
+	}
+
+	if us.CreateAt == 0 {
+		us.CreateAt = GetMillis()
+	}
+
+	if us.UpdateAt == 0 {
+		us.UpdateAt = us.CreateAt
+	}
+}
+
+func (us *UploadSession) IsValid() *AppError {
+	if len(us.Id) != 26 {
+		return NewAppError("UploadSession.IsValid", "model.upload_session.is_valid.id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if us.CreateAt == 0 {
+		return NewAppError("UploadSession.IsValid", "model.upload_session.is_valid.create_at.app_error", nil, "id="+us.Id, http.StatusBadRequest)
+	}
+
+	if us.UpdateAt == 0 {
+		return NewAppError("UploadSession.IsValid", "model.upload_session.is_valid.update_at.app_error", nil, "id="+us.Id, http.StatusBadRequest)
+	}
+
+	if len(us.UserId) != 26 {
+		return NewAppError("UploadSession.IsValid", "model.upload_session.is_valid.user_id.app_error", nil, "id="+us.Id, http.StatusBadRequest)
+	}
+
+	if len(us.ChannelId) != 26 {
+		return NewAppError("UploadSession.IsValid", "model.upload_session.is_valid.channel_id.app_error", nil, "id="+us.Id, http.StatusBadRequest)
+	}
+
+	if len(us.Filename) == 0 || len(us.Filename) > 256 {
+		return NewAppError("UploadSession.IsValid", "model.upload_session.is_valid.filename.app_error", nil, "id="+us.Id, http.StatusBadRequest)
+	}
+
+	if len(us.Path) == 0 || len(us.Path) > 512 {
+		return NewAppError("UploadSession.IsValid", "model.upload_session.is_valid.path.app_error", nil, "id="+us.Id, http.StatusBadRequest)
+	}
+
+	if us.FileSize <= 0 {
+		return NewAppError("UploadSession.IsValid", "model.upload_session.is_valid.file_size.app_error", nil, "id="+us.Id, http.StatusBadRequest)
+	}
+
+	if us.FileOffset < 0 || us.FileOffset > us.FileSize {
+		return NewAppError("UploadSession.IsValid", "model.upload_session.is_valid.file_offset.app_error", nil, "id="+us.Id, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (us *UploadSession) IsComplete() bool {
+	return us.FileOffset == us.FileSize
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUploadSessionJson(t *testing.T) {
+	us := UploadSession{
+		Id:        NewId(),
+		UserId:    NewId(),
+		ChannelId: NewId(),
+		Filename:  "file.txt",
+		Path:      "uploads/file",
+		FileSize:  1000,
+	}
+
+	json := us.ToJson()
+	rus := UploadSessionFromJson(strings.NewReader(json))
+
+	if rus.Id != us.Id || rus.FileSize != us.FileSize {
+		t.Fatal("ids or sizes do not match")
+	}
+
+	if rus.Path != "" {
+		t.Fatal("path should not be sent to the client")
+	}
+}
+
+func TestUploadSessionIsValid(t *testing.T) {
+	us := UploadSession{
+		UserId:    NewId(),
+		ChannelId: NewId(),
+		Filename:  "file.txt",
+		Path:      "uploads/file",
+		FileSize:  1000,
+	}
+	us.PreSave()
+
+	if err := us.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if us.UpdateAt != us.CreateAt {
+		t.Fatal("update at should start out equal to create at")
+	}
+
+	us.UserId = "junk"
+	if err := us.IsValid(); err == nil {
+		t.Fatal("user id should be invalid")
+	}
+
+	us.UserId = NewId()
+	us.ChannelId = ""
+	if err := us.IsValid(); err == nil {
+		t.Fatal("channel id should be invalid")
+	}
+
+	us.ChannelId = NewId()
+	us.Filename = ""
+	if err := us.IsValid(); err == nil {
+		t.Fatal("filename should be invalid")
+	}
+
+	us.Filename = "file.txt"
+	us.FileSize = 0
+	if err := us.IsValid(); err == nil {
+		t.Fatal("file size should be invalid")
+	}
+
+	us.FileSize = 1000
+	us.FileOffset = 1001
+	if err := us.IsValid(); err == nil {
+		t.Fatal("file offset past the end of the file should be invalid")
+	}
+
+	us.FileOffset = 1000
+	if err := us.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !us.IsComplete() {
+		t.Fatal("should be complete")
+	}
+}
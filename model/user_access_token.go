@@ -0,0 +1,79 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// UserAccessToken is a personal access token that lets a user, script or bot authenticate as a user without a
+// password. The token itself is only returned when it's created.
+type UserAccessToken struct {
+	Id          string `json:"id"`
+	Token       string `json:"token,omitempty"`
+	UserId      string `json:"user_id"`
+	Description string `json:"description"`
+}
+
+func (t *UserAccessToken) IsValid() *AppError {
+	if len(t.Id) != 26 {
+		return NewAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(t.Token) != 26 {
+		return NewAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.token.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(t.UserId) != 26 {
+		return NewAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.user_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(t.Description) > 255 {
+		return NewAppError("UserAccessToken.IsValid", "model.user_access_token.is_valid.description.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (t *UserAccessToken) PreSave() {
+	t.Id = NewId()
+}
+
+func (t *UserAccessToken) ToJson() string {
+	if b, err := json.Marshal(t); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func UserAccessTokenFromJson(data io.Reader) *UserAccessToken {
+	var t UserAccessToken
+
+	if err := json.NewDecoder(data).Decode(&t); err != nil {
+		return nil
+	} else {
+		return &t
+	}
+}
+
+func UserAccessTokenListToJson(t []*UserAccessToken) string {
+	if b, err := json.Marshal(t); err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func UserAccessTokenListFromJson(data io.Reader) []*UserAccessToken {
+	var t []*UserAccessToken
+
+	if err := json.NewDecoder(data).Decode(&t); err != nil {
+		return nil
+	} else {
+		return t
+	}
+}
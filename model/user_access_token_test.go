@@ -0,0 +1,59 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUserAccessTokenJson(t *testing.T) {
+	token := UserAccessToken{
+		Id:          NewId(),
+		Token:       NewId(),
+		UserId:      NewId(),
+		Description: "test",
+	}
+
+	json := token.ToJson()
+	rtoken := UserAccessTokenFromJson(strings.NewReader(json))
+
+	if rtoken.Id != token.Id || rtoken.Token != token.Token || rtoken.Description != token.Description {
+		t.Fatal("tokens do not match")
+	}
+
+	rtokens := UserAccessTokenListFromJson(strings.NewReader(UserAccessTokenListToJson([]*UserAccessToken{&token})))
+	if len(rtokens) != 1 || rtokens[0].Id != token.Id {
+		t.Fatal("token lists do not match")
+	}
+}
+
+func TestUserAccessTokenIsValid(t *testing.T) {
+	token := UserAccessToken{
+		Token:  NewId(),
+		UserId: NewId(),
+	}
+	token.PreSave()
+
+	if err := token.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	token.Token = ""
+	if err := token.IsValid(); err == nil {
+		t.Fatal("token should be invalid")
+	}
+
+	token.Token = NewId()
+	token.UserId = "junk"
+	if err := token.IsValid(); err == nil {
+		t.Fatal("user id should be invalid")
+	}
+
+	token.UserId = NewId()
+	token.Description = strings.Repeat("a", 256)
+	if err := token.IsValid(); err == nil {
+		t.Fatal("description should be invalid")
+	}
+}
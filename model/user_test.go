@@ -270,3 +270,19 @@ func TestRoles(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestUserIsGuest(t *testing.T) {
+	user := User{Roles: ROLE_SYSTEM_USER.Id}
+	if user.IsGuest() {
+		t.Fatal("should not be a guest")
+	}
+
+	user.Roles = ROLE_SYSTEM_GUEST.Id
+	if !user.IsGuest() {
+		t.Fatal("should be a guest")
+	}
+
+	if !IsValidUserRoles(user.Roles) {
+		t.Fatal("system_guest should be a valid role")
+	}
+}
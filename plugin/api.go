@@ -0,0 +1,38 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package plugin
+
+import (
+	"github.com/primefour/servers/model"
+)
+
+// API is what a plugin can use to call back into the server.
+type API interface {
+	// LoadPluginConfiguration unmarshals the settings of the plugin from the PluginSettings of the config into dest,
+	// like json.Unmarshal.
+	LoadPluginConfiguration(dest interface{}) error
+
+	// RegisterCommand registers a slash command that's handled by the ExecuteCommand hook of the plugin. The command
+	// is available to every team if it doesn't have a TeamId.
+	RegisterCommand(command *model.Command) error
+
+	// UnregisterCommand unregisters a slash command registered with RegisterCommand.
+	UnregisterCommand(teamId, trigger string) error
+
+	GetTeam(teamId string) (*model.Team, *model.AppError)
+	GetTeamByName(name string) (*model.Team, *model.AppError)
+
+	GetUser(userId string) (*model.User, *model.AppError)
+	GetUserByUsername(name string) (*model.User, *model.AppError)
+
+	GetChannel(channelId string) (*model.Channel, *model.AppError)
+	GetChannelByName(name, teamId string) (*model.Channel, *model.AppError)
+	GetChannelMember(channelId, userId string) (*model.ChannelMember, *model.AppError)
+	AddChannelMember(channelId, userId string) (*model.ChannelMember, *model.AppError)
+
+	GetPost(postId string) (*model.Post, *model.AppError)
+	CreatePost(post *model.Post) (*model.Post, *model.AppError)
+	UpdatePost(post *model.Post) (*model.Post, *model.AppError)
+	DeletePost(postId string) *model.AppError
+}
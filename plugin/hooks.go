@@ -0,0 +1,38 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package plugin defines the hooks that the server calls on plugins and the API that plugins use to call back into
+// the server. Plugins run as separate processes, see the rpcplugin package for how they talk to the server.
+package plugin
+
+import (
+	"github.com/primefour/servers/model"
+)
+
+// Hooks are the methods that the server calls on a plugin. A plugin only needs to implement the hooks that it's
+// interested in, the others are never called.
+type Hooks interface {
+	// OnActivate is called when the plugin is started, with the API that it can use until it's deactivated. The
+	// plugin isn't activated if an error is returned.
+	OnActivate(api API) error
+
+	// OnDeactivate is called before the plugin is stopped.
+	OnDeactivate() error
+
+	// OnConfigurationChange is called when the config of the server has changed, after which the plugin should reload
+	// its settings with API.LoadPluginConfiguration.
+	OnConfigurationChange() error
+
+	// ExecuteCommand is called when a user runs a slash command that the plugin registered with API.RegisterCommand.
+	ExecuteCommand(args *model.CommandArgs) (*model.CommandResponse, *model.AppError)
+
+	// MessageWillBePosted is called before a post is saved. The post that's returned is saved in its place, so that
+	// the plugin can change it, and the post is rejected if a reason is returned instead.
+	MessageWillBePosted(post *model.Post) (*model.Post, string)
+
+	// MessageHasBeenPosted is called after a post is saved.
+	MessageHasBeenPosted(post *model.Post)
+
+	// UserHasJoinedChannel is called after a user is added to a channel.
+	UserHasJoinedChannel(channelMember *model.ChannelMember)
+}
@@ -0,0 +1,262 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package rpcplugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/rpc"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/plugin"
+)
+
+type UnregisterCommandArgs struct {
+	TeamId  string
+	Trigger string
+}
+
+type GetChannelByNameArgs struct {
+	Name   string
+	TeamId string
+}
+
+type ChannelMemberArgs struct {
+	ChannelId string
+	UserId    string
+}
+
+type TeamReply struct {
+	Team  *model.Team
+	Error *model.AppError
+}
+
+type UserReply struct {
+	User  *model.User
+	Error *model.AppError
+}
+
+type ChannelReply struct {
+	Channel *model.Channel
+	Error   *model.AppError
+}
+
+type ChannelMemberReply struct {
+	ChannelMember *model.ChannelMember
+	Error         *model.AppError
+}
+
+type PostReply struct {
+	Post  *model.Post
+	Error *model.AppError
+}
+
+type ErrorReply struct {
+	Error *model.AppError
+}
+
+// LocalAPI serves the API to a plugin. It runs in the server.
+type LocalAPI struct {
+	api plugin.API
+}
+
+func (a *LocalAPI) LoadPluginConfiguration(args struct{}, reply *json.RawMessage) error {
+	return a.api.LoadPluginConfiguration(reply)
+}
+
+func (a *LocalAPI) RegisterCommand(args *model.Command, reply *struct{}) error {
+	return a.api.RegisterCommand(args)
+}
+
+func (a *LocalAPI) UnregisterCommand(args *UnregisterCommandArgs, reply *struct{}) error {
+	return a.api.UnregisterCommand(args.TeamId, args.Trigger)
+}
+
+func (a *LocalAPI) GetTeam(args string, reply *TeamReply) error {
+	reply.Team, reply.Error = a.api.GetTeam(args)
+	return nil
+}
+
+func (a *LocalAPI) GetTeamByName(args string, reply *TeamReply) error {
+	reply.Team, reply.Error = a.api.GetTeamByName(args)
+	return nil
+}
+
+func (a *LocalAPI) GetUser(args string, reply *UserReply) error {
+	reply.User, reply.Error = a.api.GetUser(args)
+	return nil
+}
+
+func (a *LocalAPI) GetUserByUsername(args string, reply *UserReply) error {
+	reply.User, reply.Error = a.api.GetUserByUsername(args)
+	return nil
+}
+
+func (a *LocalAPI) GetChannel(args string, reply *ChannelReply) error {
+	reply.Channel, reply.Error = a.api.GetChannel(args)
+	return nil
+}
+
+func (a *LocalAPI) GetChannelByName(args *GetChannelByNameArgs, reply *ChannelReply) error {
+	reply.Channel, reply.Error = a.api.GetChannelByName(args.Name, args.TeamId)
+	return nil
+}
+
+func (a *LocalAPI) GetChannelMember(args *ChannelMemberArgs, reply *ChannelMemberReply) error {
+	reply.ChannelMember, reply.Error = a.api.GetChannelMember(args.ChannelId, args.UserId)
+	return nil
+}
+
+func (a *LocalAPI) AddChannelMember(args *ChannelMemberArgs, reply *ChannelMemberReply) error {
+	reply.ChannelMember, reply.Error = a.api.AddChannelMember(args.ChannelId, args.UserId)
+	return nil
+}
+
+func (a *LocalAPI) GetPost(args string, reply *PostReply) error {
+	reply.Post, reply.Error = a.api.GetPost(args)
+	return nil
+}
+
+func (a *LocalAPI) CreatePost(args *model.Post, reply *PostReply) error {
+	reply.Post, reply.Error = a.api.CreatePost(args)
+	return nil
+}
+
+func (a *LocalAPI) UpdatePost(args *model.Post, reply *PostReply) error {
+	reply.Post, reply.Error = a.api.UpdatePost(args)
+	return nil
+}
+
+func (a *LocalAPI) DeletePost(args string, reply *ErrorReply) error {
+	reply.Error = a.api.DeletePost(args)
+	return nil
+}
+
+// RemoteAPI calls the API of the server from a plugin.
+type RemoteAPI struct {
+	client *rpc.Client
+}
+
+var _ plugin.API = (*RemoteAPI)(nil)
+
+func rpcError(where string, err error) *model.AppError {
+	return model.NewAppError(where, "plugin.rpcplugin.api.app_error", nil, err.Error(), http.StatusInternalServerError)
+}
+
+func (a *RemoteAPI) LoadPluginConfiguration(dest interface{}) error {
+	var config json.RawMessage
+	if err := a.client.Call("API.LoadPluginConfiguration", struct{}{}, &config); err != nil {
+		return err
+	}
+
+	if len(config) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(config, dest)
+}
+
+func (a *RemoteAPI) RegisterCommand(command *model.Command) error {
+	return a.client.Call("API.RegisterCommand", command, &struct{}{})
+}
+
+func (a *RemoteAPI) UnregisterCommand(teamId, trigger string) error {
+	return a.client.Call("API.UnregisterCommand", &UnregisterCommandArgs{TeamId: teamId, Trigger: trigger}, &struct{}{})
+}
+
+func (a *RemoteAPI) GetTeam(teamId string) (*model.Team, *model.AppError) {
+	var reply TeamReply
+	if err := a.client.Call("API.GetTeam", teamId, &reply); err != nil {
+		return nil, rpcError("RemoteAPI.GetTeam", err)
+	}
+	return reply.Team, reply.Error
+}
+
+func (a *RemoteAPI) GetTeamByName(name string) (*model.Team, *model.AppError) {
+	var reply TeamReply
+	if err := a.client.Call("API.GetTeamByName", name, &reply); err != nil {
+		return nil, rpcError("RemoteAPI.GetTeamByName", err)
+	}
+	return reply.Team, reply.Error
+}
+
+func (a *RemoteAPI) GetUser(userId string) (*model.User, *model.AppError) {
+	var reply UserReply
+	if err := a.client.Call("API.GetUser", userId, &reply); err != nil {
+		return nil, rpcError("RemoteAPI.GetUser", err)
+	}
+	return reply.User, reply.Error
+}
+
+func (a *RemoteAPI) GetUserByUsername(name string) (*model.User, *model.AppError) {
+	var reply UserReply
+	if err := a.client.Call("API.GetUserByUsername", name, &reply); err != nil {
+		return nil, rpcError("RemoteAPI.GetUserByUsername", err)
+	}
+	return reply.User, reply.Error
+}
+
+func (a *RemoteAPI) GetChannel(channelId string) (*model.Channel, *model.AppError) {
+	var reply ChannelReply
+	if err := a.client.Call("API.GetChannel", channelId, &reply); err != nil {
+		return nil, rpcError("RemoteAPI.GetChannel", err)
+	}
+	return reply.Channel, reply.Error
+}
+
+func (a *RemoteAPI) GetChannelByName(name, teamId string) (*model.Channel, *model.AppError) {
+	var reply ChannelReply
+	if err := a.client.Call("API.GetChannelByName", &GetChannelByNameArgs{Name: name, TeamId: teamId}, &reply); err != nil {
+		return nil, rpcError("RemoteAPI.GetChannelByName", err)
+	}
+	return reply.Channel, reply.Error
+}
+
+func (a *RemoteAPI) GetChannelMember(channelId, userId string) (*model.ChannelMember, *model.AppError) {
+	var reply ChannelMemberReply
+	if err := a.client.Call("API.GetChannelMember", &ChannelMemberArgs{ChannelId: channelId, UserId: userId}, &reply); err != nil {
+		return nil, rpcError("RemoteAPI.GetChannelMember", err)
+	}
+	return reply.ChannelMember, reply.Error
+}
+
+func (a *RemoteAPI) AddChannelMember(channelId, userId string) (*model.ChannelMember, *model.AppError) {
+	var reply ChannelMemberReply
+	if err := a.client.Call("API.AddChannelMember", &ChannelMemberArgs{ChannelId: channelId, UserId: userId}, &reply); err != nil {
+		return nil, rpcError("RemoteAPI.AddChannelMember", err)
+	}
+	return reply.ChannelMember, reply.Error
+}
+
+func (a *RemoteAPI) GetPost(postId string) (*model.Post, *model.AppError) {
+	var reply PostReply
+	if err := a.client.Call("API.GetPost", postId, &reply); err != nil {
+		return nil, rpcError("RemoteAPI.GetPost", err)
+	}
+	return reply.Post, reply.Error
+}
+
+func (a *RemoteAPI) CreatePost(post *model.Post) (*model.Post, *model.AppError) {
+	var reply PostReply
+	if err := a.client.Call("API.CreatePost", post, &reply); err != nil {
+		return nil, rpcError("RemoteAPI.CreatePost", err)
+	}
+	return reply.Post, reply.Error
+}
+
+func (a *RemoteAPI) UpdatePost(post *model.Post) (*model.Post, *model.AppError) {
+	var reply PostReply
+	if err := a.client.Call("API.UpdatePost", post, &reply); err != nil {
+		return nil, rpcError("RemoteAPI.UpdatePost", err)
+	}
+	return reply.Post, reply.Error
+}
+
+func (a *RemoteAPI) DeletePost(postId string) *model.AppError {
+	var reply ErrorReply
+	if err := a.client.Call("API.DeletePost", postId, &reply); err != nil {
+		return rpcError("RemoteAPI.DeletePost", err)
+	}
+	return reply.Error
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package rpcplugin
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"reflect"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/plugin"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	HOOK_TIMEOUT = 30 * time.Second
+
+	// Posts wait for MessageWillBePosted, so it gets much less time to answer than the other hooks
+	MESSAGE_WILL_BE_POSTED_TIMEOUT = 5 * time.Second
+)
+
+type ExecuteCommandReply struct {
+	Response *model.CommandResponse
+	Error    *model.AppError
+}
+
+type MessageWillBePostedReply struct {
+	Post            *model.Post
+	RejectionReason string
+}
+
+// LocalHooks serves the hooks of a plugin to the server. It runs in the process of the plugin.
+type LocalHooks struct {
+	hooks interface{}
+	api   plugin.API
+}
+
+// Implemented returns the names of the methods of plugin.Hooks that the plugin has.
+func (h *LocalHooks) Implemented(args struct{}, reply *[]string) error {
+	hooksType := reflect.TypeOf((*plugin.Hooks)(nil)).Elem()
+	value := reflect.ValueOf(h.hooks)
+
+	methods := []string{}
+	for i := 0; i < hooksType.NumMethod(); i++ {
+		method := hooksType.Method(i)
+		if m := value.MethodByName(method.Name); m.IsValid() && m.Type() == method.Type {
+			methods = append(methods, method.Name)
+		}
+	}
+
+	*reply = methods
+	return nil
+}
+
+func (h *LocalHooks) OnActivate(args struct{}, reply *struct{}) error {
+	if hook, ok := h.hooks.(interface {
+		OnActivate(plugin.API) error
+	}); ok {
+		return hook.OnActivate(h.api)
+	}
+	return nil
+}
+
+func (h *LocalHooks) OnDeactivate(args struct{}, reply *struct{}) error {
+	if hook, ok := h.hooks.(interface {
+		OnDeactivate() error
+	}); ok {
+		return hook.OnDeactivate()
+	}
+	return nil
+}
+
+func (h *LocalHooks) OnConfigurationChange(args struct{}, reply *struct{}) error {
+	if hook, ok := h.hooks.(interface {
+		OnConfigurationChange() error
+	}); ok {
+		return hook.OnConfigurationChange()
+	}
+	return nil
+}
+
+func (h *LocalHooks) ExecuteCommand(args *model.CommandArgs, reply *ExecuteCommandReply) error {
+	if hook, ok := h.hooks.(interface {
+		ExecuteCommand(*model.CommandArgs) (*model.CommandResponse, *model.AppError)
+	}); ok {
+		reply.Response, reply.Error = hook.ExecuteCommand(args)
+	}
+	return nil
+}
+
+func (h *LocalHooks) MessageWillBePosted(args *model.Post, reply *MessageWillBePostedReply) error {
+	if hook, ok := h.hooks.(interface {
+		MessageWillBePosted(*model.Post) (*model.Post, string)
+	}); ok {
+		reply.Post, reply.RejectionReason = hook.MessageWillBePosted(args)
+	}
+	return nil
+}
+
+func (h *LocalHooks) MessageHasBeenPosted(args *model.Post, reply *struct{}) error {
+	if hook, ok := h.hooks.(interface {
+		MessageHasBeenPosted(*model.Post)
+	}); ok {
+		hook.MessageHasBeenPosted(args)
+	}
+	return nil
+}
+
+func (h *LocalHooks) UserHasJoinedChannel(args *model.ChannelMember, reply *struct{}) error {
+	if hook, ok := h.hooks.(interface {
+		UserHasJoinedChannel(*model.ChannelMember)
+	}); ok {
+		hook.UserHasJoinedChannel(args)
+	}
+	return nil
+}
+
+// RemoteHooks calls the hooks of a plugin from the server. Hooks that the plugin doesn't implement aren't called.
+// onTimeout is called whenever the plugin fails to answer a hook in time.
+type RemoteHooks struct {
+	client      *rpc.Client
+	implemented map[string]bool
+	onTimeout   func()
+}
+
+var _ plugin.Hooks = (*RemoteHooks)(nil)
+
+func connectHooks(conn io.ReadWriteCloser, onTimeout func()) (*RemoteHooks, error) {
+	h := &RemoteHooks{
+		client:      rpc.NewClientWithCodec(jsonrpc.NewClientCodec(conn)),
+		implemented: make(map[string]bool),
+		onTimeout:   onTimeout,
+	}
+
+	var implemented []string
+	if err := h.call("Implemented", struct{}{}, &implemented, HOOK_TIMEOUT); err != nil {
+		h.client.Close()
+		return nil, err
+	}
+
+	for _, hook := range implemented {
+		h.implemented[hook] = true
+	}
+
+	return h, nil
+}
+
+// Implements returns true if the plugin has the hook with the given name.
+func (h *RemoteHooks) Implements(hook string) bool {
+	return h.implemented[hook]
+}
+
+func (h *RemoteHooks) call(hook string, args interface{}, reply interface{}, timeout time.Duration) error {
+	call := h.client.Go("Hooks."+hook, args, reply, make(chan *rpc.Call, 1))
+
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-time.After(timeout):
+		if h.onTimeout != nil {
+			h.onTimeout()
+		}
+		return errors.New("rpcplugin: timed out calling " + hook)
+	}
+}
+
+// OnActivate activates the plugin. The API passed to it isn't used since the plugin is given the API that the
+// supervisor serves instead.
+func (h *RemoteHooks) OnActivate(api plugin.API) error {
+	if !h.implemented["OnActivate"] {
+		return nil
+	}
+
+	return h.call("OnActivate", struct{}{}, &struct{}{}, HOOK_TIMEOUT)
+}
+
+func (h *RemoteHooks) OnDeactivate() error {
+	if !h.implemented["OnDeactivate"] {
+		return nil
+	}
+
+	return h.call("OnDeactivate", struct{}{}, &struct{}{}, HOOK_TIMEOUT)
+}
+
+func (h *RemoteHooks) OnConfigurationChange() error {
+	if !h.implemented["OnConfigurationChange"] {
+		return nil
+	}
+
+	return h.call("OnConfigurationChange", struct{}{}, &struct{}{}, HOOK_TIMEOUT)
+}
+
+func (h *RemoteHooks) ExecuteCommand(args *model.CommandArgs) (*model.CommandResponse, *model.AppError) {
+	if !h.implemented["ExecuteCommand"] {
+		return nil, model.NewAppError("RemoteHooks.ExecuteCommand", "plugin.rpcplugin.execute_command.app_error", nil, "not implemented", http.StatusNotImplemented)
+	}
+
+	var reply ExecuteCommandReply
+	if err := h.call("ExecuteCommand", args, &reply, HOOK_TIMEOUT); err != nil {
+		return nil, model.NewAppError("RemoteHooks.ExecuteCommand", "plugin.rpcplugin.execute_command.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return reply.Response, reply.Error
+}
+
+// MessageWillBePosted returns the post unchanged if the plugin fails to answer, so that a broken plugin doesn't stop
+// users from posting.
+func (h *RemoteHooks) MessageWillBePosted(post *model.Post) (*model.Post, string) {
+	if !h.implemented["MessageWillBePosted"] {
+		return post, ""
+	}
+
+	var reply MessageWillBePostedReply
+	if err := h.call("MessageWillBePosted", post, &reply, MESSAGE_WILL_BE_POSTED_TIMEOUT); err != nil {
+		l4g.Error(utils.T("plugin.rpcplugin.hook.error"), "MessageWillBePosted", err.Error())
+		return post, ""
+	}
+
+	if reply.Post == nil {
+		reply.Post = post
+	}
+
+	return reply.Post, reply.RejectionReason
+}
+
+func (h *RemoteHooks) MessageHasBeenPosted(post *model.Post) {
+	if !h.implemented["MessageHasBeenPosted"] {
+		return
+	}
+
+	if err := h.call("MessageHasBeenPosted", post, &struct{}{}, HOOK_TIMEOUT); err != nil {
+		l4g.Error(utils.T("plugin.rpcplugin.hook.error"), "MessageHasBeenPosted", err.Error())
+	}
+}
+
+func (h *RemoteHooks) UserHasJoinedChannel(channelMember *model.ChannelMember) {
+	if !h.implemented["UserHasJoinedChannel"] {
+		return
+	}
+
+	if err := h.call("UserHasJoinedChannel", channelMember, &struct{}{}, HOOK_TIMEOUT); err != nil {
+		l4g.Error(utils.T("plugin.rpcplugin.hook.error"), "UserHasJoinedChannel", err.Error())
+	}
+}
+
+func (h *RemoteHooks) Close() error {
+	return h.client.Close()
+}
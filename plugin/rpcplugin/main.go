@@ -0,0 +1,43 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package rpcplugin
+
+import (
+	"errors"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+)
+
+// Main connects a plugin to the server that started it and serves hooks, which implements any of the methods of
+// plugin.Hooks, until the server disconnects. It's meant to be called from the main function of the plugin.
+func Main(hooks interface{}) error {
+	address := os.Getenv(ENV_ADDRESS)
+	token := os.Getenv(ENV_TOKEN)
+	if address == "" || token == "" {
+		return errors.New("rpcplugin: the plugin wasn't started by the server")
+	}
+
+	apiConn, err := dial(address, token, CONN_API)
+	if err != nil {
+		return err
+	}
+
+	api := &RemoteAPI{client: rpc.NewClientWithCodec(jsonrpc.NewClientCodec(apiConn))}
+	defer api.client.Close()
+
+	hooksConn, err := dial(address, token, CONN_HOOKS)
+	if err != nil {
+		return err
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("Hooks", &LocalHooks{hooks: hooks, api: api}); err != nil {
+		hooksConn.Close()
+		return err
+	}
+	server.ServeCodec(jsonrpc.NewServerCodec(hooksConn))
+
+	return nil
+}
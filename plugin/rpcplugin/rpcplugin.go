@@ -0,0 +1,56 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package rpcplugin runs plugins as separate processes that talk to the server with JSON-RPC. The server listens on
+// a loopback port for each plugin that it starts and tells the plugin where to connect through its environment. The
+// plugin then makes two connections: one over which the server calls its hooks and one over which it calls the API.
+package rpcplugin
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	ENV_ADDRESS = "MM_PLUGIN_ADDRESS"
+	ENV_TOKEN   = "MM_PLUGIN_TOKEN"
+
+	CONN_HOOKS = 'h'
+	CONN_API   = 'a'
+)
+
+// dial connects to the server that started the plugin. The connection starts with the token that the server gave the
+// plugin, so that the server knows the connection comes from it, followed by the kind of connection.
+func dial(address, token string, kind byte) (net.Conn, error) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write(append([]byte(token), kind)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// readHandshake reads the start of a connection made by dial and returns its kind.
+func readHandshake(conn net.Conn, token string, deadline time.Time) (byte, error) {
+	conn.SetReadDeadline(deadline)
+	defer conn.SetReadDeadline(time.Time{})
+
+	buf := make([]byte, len(token)+1)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return 0, err
+	}
+
+	if subtle.ConstantTimeCompare(buf[:len(token)], []byte(token)) != 1 {
+		return 0, errors.New("rpcplugin: invalid token")
+	}
+
+	return buf[len(token)], nil
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package rpcplugin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/plugin"
+	"github.com/primefour/servers/utils"
+)
+
+const ENV_TEST_EXIT = "MM_PLUGIN_TEST_EXIT"
+
+// The test binary doubles as the plugin that the tests start
+func TestMain(m *testing.M) {
+	if os.Getenv(ENV_TEST_EXIT) != "" {
+		os.Exit(1)
+	}
+
+	if os.Getenv(ENV_ADDRESS) != "" {
+		if err := Main(&testHooks{}); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	utils.TranslationsPreInit()
+
+	os.Exit(m.Run())
+}
+
+type testConfig struct {
+	Greeting string
+}
+
+// testHooks implements some of the hooks, leaving out MessageHasBeenPosted and UserHasJoinedChannel
+type testHooks struct {
+	api    plugin.API
+	config testConfig
+}
+
+func (h *testHooks) OnActivate(api plugin.API) error {
+	h.api = api
+
+	if err := api.LoadPluginConfiguration(&h.config); err != nil {
+		return err
+	}
+
+	if h.config.Greeting == "fail" {
+		return errors.New("failed to activate")
+	}
+
+	return api.RegisterCommand(&model.Command{Trigger: "greet"})
+}
+
+func (h *testHooks) OnConfigurationChange() error {
+	return h.api.LoadPluginConfiguration(&h.config)
+}
+
+func (h *testHooks) ExecuteCommand(args *model.CommandArgs) (*model.CommandResponse, *model.AppError) {
+	user, err := h.api.GetUser(args.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CommandResponse{Text: h.config.Greeting + " " + user.Username}, nil
+}
+
+func (h *testHooks) MessageWillBePosted(post *model.Post) (*model.Post, string) {
+	if strings.Contains(post.Message, "reject") {
+		return nil, "rejected"
+	} else if post.Message == "hang" {
+		select {}
+	}
+
+	post.Message = strings.ToUpper(post.Message)
+	return post, ""
+}
+
+// testAPI is the API that the server gives the plugin
+type testAPI struct {
+	plugin.API
+
+	config   interface{}
+	commands []*model.Command
+	users    map[string]*model.User
+}
+
+func (a *testAPI) LoadPluginConfiguration(dest interface{}) error {
+	b, err := json.Marshal(a.config)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dest)
+}
+
+func (a *testAPI) RegisterCommand(command *model.Command) error {
+	a.commands = append(a.commands, command)
+	return nil
+}
+
+func (a *testAPI) GetUser(userId string) (*model.User, *model.AppError) {
+	if user, ok := a.users[userId]; ok {
+		return user, nil
+	}
+	return nil, model.NewAppError("GetUser", "store.sql_user.missing_account.const", nil, "", http.StatusNotFound)
+}
+
+func startTestPlugin(t *testing.T, api plugin.API, env ...string) *Supervisor {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), env...)
+
+	s, err := StartSupervisor(cmd, api)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestSupervisor(t *testing.T) {
+	user := &model.User{Id: model.NewId(), Username: "someone"}
+	api := &testAPI{
+		config: map[string]interface{}{"Greeting": "hello"},
+		users:  map[string]*model.User{user.Id: user},
+	}
+
+	s := startTestPlugin(t, api)
+	hooks := s.Hooks()
+
+	for hook, implemented := range map[string]bool{
+		"OnActivate":            true,
+		"OnDeactivate":          false,
+		"OnConfigurationChange": true,
+		"ExecuteCommand":        true,
+		"MessageWillBePosted":   true,
+		"MessageHasBeenPosted":  false,
+		"UserHasJoinedChannel":  false,
+	} {
+		if hooks.Implements(hook) != implemented {
+			t.Fatal("should know which hooks the plugin implements", hook)
+		}
+	}
+
+	if err := hooks.OnActivate(api); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(api.commands) != 1 || api.commands[0].Trigger != "greet" {
+		t.Fatal("should have registered the command through the api")
+	}
+
+	if response, err := hooks.ExecuteCommand(&model.CommandArgs{UserId: user.Id, Command: "/greet"}); err != nil {
+		t.Fatal(err)
+	} else if response.Text != "hello someone" {
+		t.Fatal("should have run the command with the config and the api", response.Text)
+	}
+
+	if _, err := hooks.ExecuteCommand(&model.CommandArgs{UserId: model.NewId(), Command: "/greet"}); err == nil || err.StatusCode != http.StatusNotFound {
+		t.Fatal("should have returned the error from the api")
+	}
+
+	api.config = map[string]interface{}{"Greeting": "hi"}
+	if err := hooks.OnConfigurationChange(); err != nil {
+		t.Fatal(err)
+	}
+
+	if response, err := hooks.ExecuteCommand(&model.CommandArgs{UserId: user.Id, Command: "/greet"}); err != nil {
+		t.Fatal(err)
+	} else if response.Text != "hi someone" {
+		t.Fatal("should have reloaded the config", response.Text)
+	}
+
+	if post, reason := hooks.MessageWillBePosted(&model.Post{Message: "hello"}); reason != "" || post.Message != "HELLO" {
+		t.Fatal("should have changed the post")
+	}
+
+	if _, reason := hooks.MessageWillBePosted(&model.Post{Message: "reject this"}); reason != "rejected" {
+		t.Fatal("should have rejected the post")
+	}
+
+	// Hooks that the plugin doesn't implement are skipped
+	hooks.MessageHasBeenPosted(&model.Post{})
+	hooks.UserHasJoinedChannel(&model.ChannelMember{})
+	if err := hooks.OnDeactivate(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Stop()
+
+	select {
+	case <-s.Exited():
+	default:
+		t.Fatal("should have stopped the plugin")
+	}
+
+	if post, reason := hooks.MessageWillBePosted(&model.Post{Message: "hello"}); reason != "" || post.Message != "hello" {
+		t.Fatal("should leave the post alone once the plugin is gone")
+	}
+}
+
+func TestSupervisorActivateError(t *testing.T) {
+	api := &testAPI{config: map[string]interface{}{"Greeting": "fail"}}
+
+	s := startTestPlugin(t, api)
+	defer s.Stop()
+
+	if err := s.Hooks().OnActivate(api); err == nil || !strings.Contains(err.Error(), "failed to activate") {
+		t.Fatal("should have returned the error from the plugin", err)
+	}
+}
+
+func TestSupervisorHookTimeout(t *testing.T) {
+	s := startTestPlugin(t, &testAPI{})
+	defer s.Stop()
+
+	if post, reason := s.Hooks().MessageWillBePosted(&model.Post{Message: "hang"}); reason != "" || post.Message != "hang" {
+		t.Fatal("should leave the post alone when the plugin doesn't answer")
+	}
+
+	select {
+	case <-s.Exited():
+	case <-time.After(STOP_TIMEOUT):
+		t.Fatal("should have killed the plugin for not answering")
+	}
+}
+
+func TestSupervisorPluginExits(t *testing.T) {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), ENV_TEST_EXIT+"=1")
+
+	if _, err := StartSupervisor(cmd, &testAPI{}); err == nil {
+		t.Fatal("should fail when the plugin exits without connecting")
+	}
+}
+
+func TestMainWithoutServer(t *testing.T) {
+	if err := Main(&testHooks{}); err == nil {
+		t.Fatal("should fail when not started by the server")
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package rpcplugin
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/plugin"
+)
+
+const (
+	START_TIMEOUT = 10 * time.Second
+	STOP_TIMEOUT  = 10 * time.Second
+)
+
+// Supervisor runs the process of a plugin and connects it to the server.
+type Supervisor struct {
+	cmd     *exec.Cmd
+	hooks   *RemoteHooks
+	apiConn net.Conn
+	done    chan struct{}
+}
+
+// StartSupervisor starts cmd as a plugin that's given api and waits for it to connect. The path, arguments, working
+// directory and output of cmd are left to the caller. A plugin that fails to answer a hook in time is killed, since it
+// would hold up every later call to it, which the caller finds out about through Exited.
+func StartSupervisor(cmd *exec.Cmd, api plugin.API) (*Supervisor, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	token := model.NewId()
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, ENV_ADDRESS+"="+listener.Addr().String(), ENV_TOKEN+"="+token)
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	s := &Supervisor{
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		cmd.Wait()
+		close(s.done)
+	}()
+
+	// Stop waiting for the plugin to connect as soon as it exits
+	go func() {
+		select {
+		case <-s.done:
+			listener.Close()
+		case <-time.After(START_TIMEOUT):
+		}
+	}()
+
+	deadline := time.Now().Add(START_TIMEOUT)
+	listener.(*net.TCPListener).SetDeadline(deadline)
+
+	var hooksConn net.Conn
+	for hooksConn == nil || s.apiConn == nil {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.abort(hooksConn)
+			return nil, errors.New("rpcplugin: plugin didn't connect, err=" + err.Error())
+		}
+
+		kind, err := readHandshake(conn, token, deadline)
+		if err != nil {
+			conn.Close()
+			continue
+		}
+
+		if kind == CONN_HOOKS && hooksConn == nil {
+			hooksConn = conn
+		} else if kind == CONN_API && s.apiConn == nil {
+			s.apiConn = conn
+		} else {
+			conn.Close()
+		}
+	}
+
+	server := rpc.NewServer()
+	server.RegisterName("API", &LocalAPI{api: api})
+	go server.ServeCodec(jsonrpc.NewServerCodec(s.apiConn))
+
+	if s.hooks, err = connectHooks(hooksConn, s.kill); err != nil {
+		s.abort(nil)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Supervisor) abort(hooksConn net.Conn) {
+	if hooksConn != nil {
+		hooksConn.Close()
+	}
+
+	if s.apiConn != nil {
+		s.apiConn.Close()
+	}
+
+	s.cmd.Process.Kill()
+	<-s.done
+}
+
+func (s *Supervisor) kill() {
+	s.cmd.Process.Kill()
+}
+
+// Hooks returns the hooks of the plugin.
+func (s *Supervisor) Hooks() *RemoteHooks {
+	return s.hooks
+}
+
+// Stop disconnects from the plugin, which should make it exit, and kills it if it doesn't.
+func (s *Supervisor) Stop() {
+	s.hooks.Close()
+	s.apiConn.Close()
+
+	select {
+	case <-s.done:
+	case <-time.After(STOP_TIMEOUT):
+		s.cmd.Process.Kill()
+		<-s.done
+	}
+}
+
+// Exited returns a channel that's closed when the process of the plugin exits.
+func (s *Supervisor) Exited() <-chan struct{} {
+	return s.done
+}
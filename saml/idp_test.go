@@ -0,0 +1,448 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/primefour/servers/model"
+	"github.com/russellhaering/goxmldsig"
+)
+
+// testKeyPair is a key and a self-signed certificate written to PEM files so that they can be named in the SAML
+// settings.
+type testKeyPair struct {
+	Key      *rsa.PrivateKey
+	Cert     *x509.Certificate
+	CertFile string
+	KeyFile  string
+}
+
+func newTestKeyPair(dir, name string) (*testKeyPair, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-24 * time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	pair := &testKeyPair{
+		Key:      key,
+		Cert:     cert,
+		CertFile: filepath.Join(dir, name+".crt"),
+		KeyFile:  filepath.Join(dir, name+".key"),
+	}
+
+	if err := ioutil.WriteFile(pair.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(pair.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600); err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// testXmlencRsa15 is the RSA 1.5 key transport algorithm, which the service provider refuses.
+const testXmlencRsa15 = "http://www.w3.org/2001/04/xmlenc#rsa-1_5"
+
+// testResponseOptions control how testIdp builds a Response so that tests can produce both valid and broken ones.
+type testResponseOptions struct {
+	Email      string
+	Attributes map[string]string
+
+	SignResponse  bool
+	SignAssertion bool
+	Encrypt       bool
+	EncryptionGCM bool
+	KeyTransport  string
+
+	Now      time.Time
+	Audience string
+	Issuer   string
+	Status   string
+}
+
+// testIdp is a minimal identity provider. It accepts AuthnRequests over the HTTP-Redirect binding, checks their
+// signature against the service provider certificate and answers with an auto-submitting form like real identity
+// providers do for the HTTP-POST binding.
+type testIdp struct {
+	Server   *httptest.Server
+	Dir      string
+	EntityId string
+	Idp      *testKeyPair
+	SP       *testKeyPair
+
+	// The options used to answer AuthnRequests received by Server
+	Options testResponseOptions
+
+	// The last AuthnRequest received by Server
+	LastRequest *etree.Element
+}
+
+func newTestIdp() (*testIdp, error) {
+	dir, err := ioutil.TempDir("", "saml")
+	if err != nil {
+		return nil, err
+	}
+
+	idp := &testIdp{Dir: dir}
+
+	if idp.Idp, err = newTestKeyPair(dir, "idp"); err != nil {
+		idp.Close()
+		return nil, err
+	}
+
+	if idp.SP, err = newTestKeyPair(dir, "sp"); err != nil {
+		idp.Close()
+		return nil, err
+	}
+
+	idp.Server = httptest.NewServer(http.HandlerFunc(idp.handleSSO))
+	idp.EntityId = idp.Server.URL + "/metadata"
+
+	return idp, nil
+}
+
+func (idp *testIdp) Close() {
+	if idp.Server != nil {
+		idp.Server.Close()
+	}
+
+	os.RemoveAll(idp.Dir)
+}
+
+func (idp *testIdp) handleSSO(w http.ResponseWriter, r *http.Request) {
+	request, err := idp.parseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	idp.LastRequest = request
+
+	response, err := idp.BuildResponse(request.SelectAttrValue("AssertionConsumerServiceURL", ""), request.SelectAttrValue("ID", ""), idp.Options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprintf(w, `<html><body onload="document.forms[0].submit()"><form method="post" action="%s">`+
+		`<input type="hidden" name="SAMLResponse" value="%s"/><input type="hidden" name="RelayState" value="%s"/>`+
+		`</form></body></html>`,
+		html.EscapeString(request.SelectAttrValue("AssertionConsumerServiceURL", "")),
+		html.EscapeString(response),
+		html.EscapeString(r.URL.Query().Get("RelayState")))
+}
+
+// parseRequest checks the signature on a redirected AuthnRequest and inflates it.
+func (idp *testIdp) parseRequest(r *http.Request) (*etree.Element, error) {
+	query := r.URL.Query()
+
+	if query.Get("SigAlg") != SAML_SIGNATURE_RSA_SHA256 {
+		return nil, errors.New("the request is not signed with rsa-sha256")
+	}
+
+	// The signature covers the query exactly as it was sent
+	signed := r.URL.RawQuery[:bytes.LastIndex([]byte(r.URL.RawQuery), []byte("&Signature="))]
+
+	signature, err := base64.StdEncoding.DecodeString(query.Get("Signature"))
+	if err != nil {
+		return nil, err
+	}
+
+	hashed := sha256.Sum256([]byte(signed))
+	if err := rsa.VerifyPKCS1v15(&idp.SP.Key.PublicKey, crypto.SHA256, hashed[:], signature); err != nil {
+		return nil, err
+	}
+
+	deflated, err := base64.StdEncoding.DecodeString(query.Get("SAMLRequest"))
+	if err != nil {
+		return nil, err
+	}
+
+	inflated, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	if err != nil {
+		return nil, err
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(inflated); err != nil {
+		return nil, err
+	}
+
+	if doc.Root() == nil || doc.Root().Tag != "AuthnRequest" {
+		return nil, errors.New("missing AuthnRequest element")
+	}
+
+	return doc.Root(), nil
+}
+
+var testFormValuePattern = regexp.MustCompile(`name="(SAMLResponse|RelayState)" value="([^"]*)"`)
+
+// Login follows a login URL built by the service provider and returns the values the identity provider would post
+// back to the assertion consumer service.
+func (idp *testIdp) Login(loginUrl string) (url.Values, error) {
+	resp, err := http.Get(loginUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(string(body))
+	}
+
+	values := url.Values{}
+	for _, match := range testFormValuePattern.FindAllStringSubmatch(string(body), -1) {
+		values.Set(match[1], html.UnescapeString(match[2]))
+	}
+
+	return values, nil
+}
+
+// BuildResponse returns a base64 encoded Response for the given service provider.
+func (idp *testIdp) BuildResponse(acsURL string, inResponseTo string, options testResponseOptions) (string, error) {
+	now := options.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	audience := options.Audience
+	if audience == "" {
+		audience = acsURL
+	}
+
+	issuer := options.Issuer
+	if issuer == "" {
+		issuer = idp.EntityId
+	}
+
+	status := options.Status
+	if status == "" {
+		status = SAML_STATUS_SUCCESS
+	}
+
+	doc := etree.NewDocument()
+
+	response := doc.CreateElement("samlp:Response")
+	response.CreateAttr("xmlns:samlp", SAML_NAMESPACE_PROTOCOL)
+	response.CreateAttr("xmlns:saml", SAML_NAMESPACE_ASSERTION)
+	response.CreateAttr("ID", "_"+model.NewId())
+	response.CreateAttr("Version", "2.0")
+	response.CreateAttr("IssueInstant", now.UTC().Format(SAML_TIME_FORMAT))
+	response.CreateAttr("Destination", acsURL)
+	if inResponseTo != "" {
+		response.CreateAttr("InResponseTo", inResponseTo)
+	}
+
+	response.CreateElement("saml:Issuer").SetText(issuer)
+	response.CreateElement("samlp:Status").CreateElement("samlp:StatusCode").CreateAttr("Value", status)
+
+	assertion := etree.NewElement("saml:Assertion")
+	assertion.CreateAttr("xmlns:saml", SAML_NAMESPACE_ASSERTION)
+	assertion.CreateAttr("ID", "_"+model.NewId())
+	assertion.CreateAttr("Version", "2.0")
+	assertion.CreateAttr("IssueInstant", now.UTC().Format(SAML_TIME_FORMAT))
+
+	assertion.CreateElement("saml:Issuer").SetText(issuer)
+
+	subject := assertion.CreateElement("saml:Subject")
+	subject.CreateElement("saml:NameID").SetText(options.Email)
+	confirmation := subject.CreateElement("saml:SubjectConfirmation")
+	confirmation.CreateAttr("Method", "urn:oasis:names:tc:SAML:2.0:cm:bearer")
+	confirmationData := confirmation.CreateElement("saml:SubjectConfirmationData")
+	confirmationData.CreateAttr("NotOnOrAfter", now.Add(5*time.Minute).UTC().Format(SAML_TIME_FORMAT))
+	confirmationData.CreateAttr("Recipient", acsURL)
+	if inResponseTo != "" {
+		confirmationData.CreateAttr("InResponseTo", inResponseTo)
+	}
+
+	conditions := assertion.CreateElement("saml:Conditions")
+	conditions.CreateAttr("NotBefore", now.Add(-time.Minute).UTC().Format(SAML_TIME_FORMAT))
+	conditions.CreateAttr("NotOnOrAfter", now.Add(5*time.Minute).UTC().Format(SAML_TIME_FORMAT))
+	conditions.CreateElement("saml:AudienceRestriction").CreateElement("saml:Audience").SetText(audience)
+
+	statement := assertion.CreateElement("saml:AttributeStatement")
+	for name, value := range options.Attributes {
+		attribute := statement.CreateElement("saml:Attribute")
+		attribute.CreateAttr("Name", name)
+		attribute.CreateElement("saml:AttributeValue").SetText(value)
+	}
+
+	signingContext := dsig.NewDefaultSigningContext(dsig.TLSCertKeyStore(tls.Certificate{
+		Certificate: [][]byte{idp.Idp.Cert.Raw},
+		PrivateKey:  idp.Idp.Key,
+	}))
+	signingContext.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+
+	if options.SignAssertion {
+		signed, err := signingContext.SignEnveloped(assertion)
+		if err != nil {
+			return "", err
+		}
+		assertion = signed
+	}
+
+	if options.Encrypt {
+		encrypted, err := idp.encryptAssertion(assertion, options.EncryptionGCM, options.KeyTransport)
+		if err != nil {
+			return "", err
+		}
+		response.AddChild(encrypted)
+	} else {
+		response.AddChild(assertion)
+	}
+
+	if options.SignResponse {
+		signed, err := signingContext.SignEnveloped(response)
+		if err != nil {
+			return "", err
+		}
+		doc.SetRoot(signed)
+	}
+
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// encryptAssertion encrypts an assertion for the service provider with a random AES key that is itself encrypted
+// with RSA-OAEP, which is what most identity providers do by default, unless another key transport is given.
+func (idp *testIdp) encryptAssertion(assertion *etree.Element, useGCM bool, keyTransport string) (*etree.Element, error) {
+	doc := etree.NewDocument()
+	doc.SetRoot(assertion.Copy())
+
+	plaintext, err := doc.WriteToBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var algorithm string
+	var ciphertext []byte
+
+	if useGCM {
+		algorithm = XMLENC_AES256_GCM
+
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+
+		ciphertext = gcm.Seal(nonce, nonce, plaintext, nil)
+	} else {
+		algorithm = XMLENC_AES256_CBC
+
+		padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+		plaintext = append(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+		ciphertext = make([]byte, aes.BlockSize+len(plaintext))
+		if _, err := rand.Read(ciphertext[:aes.BlockSize]); err != nil {
+			return nil, err
+		}
+
+		cipher.NewCBCEncrypter(block, ciphertext[:aes.BlockSize]).CryptBlocks(ciphertext[aes.BlockSize:], plaintext)
+	}
+
+	if keyTransport == "" {
+		keyTransport = XMLENC_RSA_OAEP_MGF1P
+	}
+
+	var encryptedKey []byte
+	if keyTransport == testXmlencRsa15 {
+		encryptedKey, err = rsa.EncryptPKCS1v15(rand.Reader, &idp.SP.Key.PublicKey, key)
+	} else {
+		encryptedKey, err = rsa.EncryptOAEP(sha1.New(), rand.Reader, &idp.SP.Key.PublicKey, key, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedAssertion := etree.NewElement("saml:EncryptedAssertion")
+
+	data := encryptedAssertion.CreateElement("xenc:EncryptedData")
+	data.CreateAttr("xmlns:xenc", SAML_NAMESPACE_XMLENC)
+	data.CreateAttr("Type", "http://www.w3.org/2001/04/xmlenc#Element")
+	data.CreateElement("xenc:EncryptionMethod").CreateAttr("Algorithm", algorithm)
+
+	keyInfo := data.CreateElement("ds:KeyInfo")
+	keyInfo.CreateAttr("xmlns:ds", SAML_NAMESPACE_DSIG)
+
+	keyElement := keyInfo.CreateElement("xenc:EncryptedKey")
+	keyMethod := keyElement.CreateElement("xenc:EncryptionMethod")
+	keyMethod.CreateAttr("Algorithm", keyTransport)
+	keyMethod.CreateElement("ds:DigestMethod").CreateAttr("Algorithm", "http://www.w3.org/2000/09/xmldsig#sha1")
+	keyElement.CreateElement("xenc:CipherData").CreateElement("xenc:CipherValue").SetText(base64.StdEncoding.EncodeToString(encryptedKey))
+
+	data.CreateElement("xenc:CipherData").CreateElement("xenc:CipherValue").SetText(base64.StdEncoding.EncodeToString(ciphertext))
+
+	return encryptedAssertion, nil
+}
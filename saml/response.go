@@ -0,0 +1,483 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package saml
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/primefour/servers/model"
+	"github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+const (
+	XMLENC_AES128_CBC = "http://www.w3.org/2001/04/xmlenc#aes128-cbc"
+	XMLENC_AES192_CBC = "http://www.w3.org/2001/04/xmlenc#aes192-cbc"
+	XMLENC_AES256_CBC = "http://www.w3.org/2001/04/xmlenc#aes256-cbc"
+	XMLENC_AES128_GCM = "http://www.w3.org/2009/xmlenc11#aes128-gcm"
+	XMLENC_AES192_GCM = "http://www.w3.org/2009/xmlenc11#aes192-gcm"
+	XMLENC_AES256_GCM = "http://www.w3.org/2009/xmlenc11#aes256-gcm"
+
+	XMLENC_RSA_OAEP_MGF1P = "http://www.w3.org/2001/04/xmlenc#rsa-oaep-mgf1p"
+	XMLENC_RSA_OAEP       = "http://www.w3.org/2009/xmlenc11#rsa-oaep"
+
+	XMLDSIG_SHA256 = "http://www.w3.org/2001/04/xmlenc#sha256"
+)
+
+// samlAssertion is the part of a verified assertion that is used to log a user in. Id and InResponseTo are used to make
+// sure that the assertion answers a request that we sent and that it's only used once.
+type samlAssertion struct {
+	Id           string
+	InResponseTo string
+	NotOnOrAfter time.Time
+	NameId       string
+	Attributes   map[string][]string
+}
+
+func (a *samlAssertion) GetAttribute(name string) string {
+	if name == "" {
+		return ""
+	}
+
+	if values := a.Attributes[name]; len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+
+	return ""
+}
+
+func newResponseError(id string, details string) *model.AppError {
+	return model.NewAppError("parseResponse", id, nil, details, http.StatusBadRequest)
+}
+
+// parseResponse decodes a Response, checks its status, issuer, signatures and validity period, and returns the
+// assertion it contains.
+func (sp *serviceProvider) parseResponse(encodedXML string, now time.Time) (*samlAssertion, *model.AppError) {
+	data, err := base64.StdEncoding.DecodeString(encodedXML)
+	if err != nil {
+		return nil, newResponseError("ent.saml.do_login.parse.app_error", err.Error())
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, newResponseError("ent.saml.do_login.parse.app_error", err.Error())
+	}
+
+	response := doc.Root()
+	if response == nil || response.Tag != "Response" || response.NamespaceURI() != SAML_NAMESPACE_PROTOCOL {
+		return nil, newResponseError("ent.saml.do_login.parse.app_error", "missing Response element")
+	}
+
+	validationContext := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{
+		Roots: []*x509.Certificate{sp.IdpCert},
+	})
+	validationContext.Clock = dsig.NewFakeClockAt(now)
+
+	signed := false
+
+	if sp.Verify && findChild(response, SAML_NAMESPACE_DSIG, "Signature") != nil {
+		if response, err = validationContext.Validate(response); err != nil {
+			return nil, newResponseError("ent.saml.do_login.validate.app_error", err.Error())
+		}
+		signed = true
+	}
+
+	if destination := response.SelectAttrValue("Destination", ""); destination != "" && destination != sp.AcsURL {
+		return nil, newResponseError("ent.saml.do_login.invalid_destination.app_error", "destination="+destination)
+	}
+
+	if status := findPath(response, SAML_NAMESPACE_PROTOCOL, "Status", "StatusCode"); status == nil || status.SelectAttrValue("Value", "") != SAML_STATUS_SUCCESS {
+		details := ""
+		if status != nil {
+			details = "status=" + status.SelectAttrValue("Value", "")
+		}
+		return nil, newResponseError("ent.saml.do_login.status.app_error", details)
+	}
+
+	if err := sp.checkIssuer(response); err != nil {
+		return nil, err
+	}
+
+	inResponseTo := response.SelectAttrValue("InResponseTo", "")
+
+	assertion := findChild(response, SAML_NAMESPACE_ASSERTION, "Assertion")
+
+	if encryptedAssertion := findChild(response, SAML_NAMESPACE_ASSERTION, "EncryptedAssertion"); encryptedAssertion != nil {
+		if sp.KeyPair == nil {
+			return nil, newResponseError("ent.saml.do_login.decrypt.app_error", "no private key is configured")
+		}
+
+		if assertion, err = decryptAssertion(encryptedAssertion, sp.KeyPair.PrivateKey.(*rsa.PrivateKey)); err != nil {
+			return nil, newResponseError("ent.saml.do_login.decrypt.app_error", err.Error())
+		}
+	} else if sp.Encrypt {
+		return nil, newResponseError("ent.saml.configure.not_encrypted_response.app_error", "")
+	}
+
+	if assertion == nil {
+		return nil, newResponseError("ent.saml.do_login.parse.app_error", "missing Assertion element")
+	}
+
+	if sp.Verify && findChild(assertion, SAML_NAMESPACE_DSIG, "Signature") != nil {
+		// Namespaces declared on the Response have to be carried over before the assertion can be canonicalized alone
+		if ctx, err := etreeutils.NSBuildParentContext(assertion); err != nil {
+			return nil, newResponseError("ent.saml.do_login.validate.app_error", err.Error())
+		} else if assertion, err = etreeutils.NSDetatch(ctx, assertion); err != nil {
+			return nil, newResponseError("ent.saml.do_login.validate.app_error", err.Error())
+		}
+
+		if assertion, err = validationContext.Validate(assertion); err != nil {
+			return nil, newResponseError("ent.saml.do_login.validate.app_error", err.Error())
+		}
+		signed = true
+	}
+
+	if sp.Verify && !signed {
+		return nil, newResponseError("ent.saml.do_login.validate.app_error", "neither the response nor the assertion is signed")
+	}
+
+	if err := sp.checkIssuer(assertion); err != nil {
+		return nil, err
+	}
+
+	if err := sp.checkConditions(assertion, now); err != nil {
+		return nil, err
+	}
+
+	if err := sp.checkSubjectConfirmation(assertion, inResponseTo, now); err != nil {
+		return nil, err
+	}
+
+	result := &samlAssertion{
+		Id:           assertion.SelectAttrValue("ID", ""),
+		InResponseTo: inResponseTo,
+		Attributes:   make(map[string][]string),
+	}
+
+	if result.Id == "" {
+		return nil, newResponseError("ent.saml.do_login.parse.app_error", "missing assertion ID")
+	}
+
+	// The assertion is remembered until it expires so that it can't be replayed, which requires it to expire soon
+	notOnOrAfter, appErr := getNotOnOrAfter(assertion)
+	if appErr != nil {
+		return nil, appErr
+	} else if notOnOrAfter.IsZero() || notOnOrAfter.After(now.Add(SAML_MAX_ASSERTION_LIFETIME)) {
+		return nil, newResponseError("ent.saml.do_login.invalid_time.app_error", "the assertion must expire within "+SAML_MAX_ASSERTION_LIFETIME.String())
+	}
+	result.NotOnOrAfter = notOnOrAfter
+
+	if nameId := findPath(assertion, SAML_NAMESPACE_ASSERTION, "Subject", "NameID"); nameId != nil {
+		result.NameId = strings.TrimSpace(nameId.Text())
+	}
+
+	for _, statement := range findChildren(assertion, SAML_NAMESPACE_ASSERTION, "AttributeStatement") {
+		for _, attribute := range findChildren(statement, SAML_NAMESPACE_ASSERTION, "Attribute") {
+			name := attribute.SelectAttrValue("Name", "")
+			for _, value := range findChildren(attribute, SAML_NAMESPACE_ASSERTION, "AttributeValue") {
+				result.Attributes[name] = append(result.Attributes[name], value.Text())
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (sp *serviceProvider) checkIssuer(el *etree.Element) *model.AppError {
+	if sp.IdpIssuer == "" {
+		return nil
+	}
+
+	if issuer := findChild(el, SAML_NAMESPACE_ASSERTION, "Issuer"); issuer != nil && strings.TrimSpace(issuer.Text()) != sp.IdpIssuer {
+		return newResponseError("ent.saml.do_login.invalid_issuer.app_error", "issuer="+issuer.Text())
+	}
+
+	return nil
+}
+
+func (sp *serviceProvider) checkConditions(assertion *etree.Element, now time.Time) *model.AppError {
+	conditions := findChild(assertion, SAML_NAMESPACE_ASSERTION, "Conditions")
+	if conditions == nil {
+		return nil
+	}
+
+	if err := checkValidityPeriod(conditions, now); err != nil {
+		return err
+	}
+
+	for _, restriction := range findChildren(conditions, SAML_NAMESPACE_ASSERTION, "AudienceRestriction") {
+		found := false
+		for _, audience := range findChildren(restriction, SAML_NAMESPACE_ASSERTION, "Audience") {
+			if strings.TrimSpace(audience.Text()) == sp.EntityId {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return newResponseError("ent.saml.do_login.invalid_audience.app_error", "")
+		}
+	}
+
+	return nil
+}
+
+// checkSubjectConfirmation checks that the assertion is meant for us and, if its subject confirmation names the request
+// it answers, that it's the same request as the one named by the response.
+func (sp *serviceProvider) checkSubjectConfirmation(assertion *etree.Element, inResponseTo string, now time.Time) *model.AppError {
+	subject := findChild(assertion, SAML_NAMESPACE_ASSERTION, "Subject")
+	if subject == nil {
+		return nil
+	}
+
+	for _, confirmation := range findChildren(subject, SAML_NAMESPACE_ASSERTION, "SubjectConfirmation") {
+		data := findChild(confirmation, SAML_NAMESPACE_ASSERTION, "SubjectConfirmationData")
+		if data == nil {
+			continue
+		}
+
+		if recipient := data.SelectAttrValue("Recipient", ""); recipient != "" && recipient != sp.AcsURL {
+			return newResponseError("ent.saml.do_login.invalid_destination.app_error", "recipient="+recipient)
+		}
+
+		if dataInResponseTo := data.SelectAttrValue("InResponseTo", ""); dataInResponseTo != "" && dataInResponseTo != inResponseTo {
+			return newResponseError("ent.saml.do_login.invalid_request.app_error", "in_response_to="+dataInResponseTo)
+		}
+
+		if err := checkValidityPeriod(data, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getNotOnOrAfter returns the earliest time at which the assertion's conditions or subject confirmations expire, or the
+// zero time if none of them do.
+func getNotOnOrAfter(assertion *etree.Element) (time.Time, *model.AppError) {
+	elements := []*etree.Element{}
+	if conditions := findChild(assertion, SAML_NAMESPACE_ASSERTION, "Conditions"); conditions != nil {
+		elements = append(elements, conditions)
+	}
+	if subject := findChild(assertion, SAML_NAMESPACE_ASSERTION, "Subject"); subject != nil {
+		for _, confirmation := range findChildren(subject, SAML_NAMESPACE_ASSERTION, "SubjectConfirmation") {
+			if data := findChild(confirmation, SAML_NAMESPACE_ASSERTION, "SubjectConfirmationData"); data != nil {
+				elements = append(elements, data)
+			}
+		}
+	}
+
+	var earliest time.Time
+	for _, el := range elements {
+		if notOnOrAfter := el.SelectAttrValue("NotOnOrAfter", ""); notOnOrAfter != "" {
+			if t, err := time.Parse(time.RFC3339, notOnOrAfter); err != nil {
+				return time.Time{}, newResponseError("ent.saml.do_login.invalid_time.app_error", err.Error())
+			} else if earliest.IsZero() || t.Before(earliest) {
+				earliest = t
+			}
+		}
+	}
+
+	return earliest, nil
+}
+
+func checkValidityPeriod(el *etree.Element, now time.Time) *model.AppError {
+	if notBefore := el.SelectAttrValue("NotBefore", ""); notBefore != "" {
+		if t, err := time.Parse(time.RFC3339, notBefore); err != nil {
+			return newResponseError("ent.saml.do_login.invalid_time.app_error", err.Error())
+		} else if now.Add(SAML_CLOCK_SKEW).Before(t) {
+			return newResponseError("ent.saml.do_login.invalid_time.app_error", "not_before="+notBefore)
+		}
+	}
+
+	if notOnOrAfter := el.SelectAttrValue("NotOnOrAfter", ""); notOnOrAfter != "" {
+		if t, err := time.Parse(time.RFC3339, notOnOrAfter); err != nil {
+			return newResponseError("ent.saml.do_login.invalid_time.app_error", err.Error())
+		} else if !now.Add(-SAML_CLOCK_SKEW).Before(t) {
+			return newResponseError("ent.saml.do_login.invalid_time.app_error", "not_on_or_after="+notOnOrAfter)
+		}
+	}
+
+	return nil
+}
+
+// decryptAssertion decrypts an EncryptedAssertion as described in the XML Encryption specification. The key used to
+// encrypt the assertion is itself encrypted with the service provider's public key.
+func decryptAssertion(encryptedAssertion *etree.Element, privateKey *rsa.PrivateKey) (*etree.Element, error) {
+	encryptedData := findChild(encryptedAssertion, SAML_NAMESPACE_XMLENC, "EncryptedData")
+	if encryptedData == nil {
+		return nil, errors.New("missing EncryptedData element")
+	}
+
+	// The EncryptedKey is either in the KeyInfo of the EncryptedData or alongside it
+	var encryptedKey *etree.Element
+	if keyInfo := findChild(encryptedData, SAML_NAMESPACE_DSIG, "KeyInfo"); keyInfo != nil {
+		encryptedKey = findChild(keyInfo, SAML_NAMESPACE_XMLENC, "EncryptedKey")
+	}
+	if encryptedKey == nil {
+		encryptedKey = findChild(encryptedAssertion, SAML_NAMESPACE_XMLENC, "EncryptedKey")
+	}
+	if encryptedKey == nil {
+		return nil, errors.New("missing EncryptedKey element")
+	}
+
+	key, err := decryptKey(encryptedKey, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := decryptData(encryptedData, key)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(plaintext); err != nil {
+		return nil, err
+	}
+
+	assertion := doc.Root()
+	if assertion == nil {
+		return nil, errors.New("the encrypted data is not an assertion")
+	}
+
+	// Keep the decrypted assertion in the response so it inherits the namespaces declared there
+	encryptedAssertion.Parent().AddChild(assertion)
+
+	if assertion.Tag != "Assertion" || assertion.NamespaceURI() != SAML_NAMESPACE_ASSERTION {
+		encryptedAssertion.Parent().RemoveChild(assertion)
+		return nil, errors.New("the encrypted data is not an assertion")
+	}
+
+	return assertion, nil
+}
+
+func getEncryptionMethod(el *etree.Element) (algorithm string, method *etree.Element) {
+	if method = findChild(el, SAML_NAMESPACE_XMLENC, "EncryptionMethod"); method != nil {
+		algorithm = method.SelectAttrValue("Algorithm", "")
+	}
+
+	return
+}
+
+func getCipherValue(el *etree.Element) ([]byte, error) {
+	value := findPath(el, SAML_NAMESPACE_XMLENC, "CipherData", "CipherValue")
+	if value == nil {
+		return nil, errors.New("missing CipherValue element")
+	}
+
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value.Text()), ""))
+}
+
+// decryptKey decrypts the key that the assertion was encrypted with. Only RSA-OAEP key transport is supported since RSA
+// 1.5 is open to padding oracle attacks.
+func decryptKey(encryptedKey *etree.Element, privateKey *rsa.PrivateKey) ([]byte, error) {
+	ciphertext, err := getCipherValue(encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, method := getEncryptionMethod(encryptedKey)
+
+	switch algorithm {
+	case XMLENC_RSA_OAEP_MGF1P, XMLENC_RSA_OAEP:
+		var digest hash.Hash = sha1.New()
+		if digestMethod := findChild(method, SAML_NAMESPACE_DSIG, "DigestMethod"); digestMethod != nil && digestMethod.SelectAttrValue("Algorithm", "") == XMLDSIG_SHA256 {
+			digest = sha256.New()
+		}
+
+		return rsa.DecryptOAEP(digest, rand.Reader, privateKey, ciphertext, nil)
+	}
+
+	return nil, errors.New("unsupported key transport algorithm " + algorithm)
+}
+
+func decryptData(encryptedData *etree.Element, key []byte) ([]byte, error) {
+	ciphertext, err := getCipherValue(encryptedData)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, _ := getEncryptionMethod(encryptedData)
+
+	switch algorithm {
+	case XMLENC_AES128_CBC, XMLENC_AES192_CBC, XMLENC_AES256_CBC:
+		if len(ciphertext) < 2*aes.BlockSize || len(ciphertext)%aes.BlockSize != 0 {
+			return nil, errors.New("invalid ciphertext length")
+		}
+
+		iv := ciphertext[:aes.BlockSize]
+		plaintext := make([]byte, len(ciphertext)-aes.BlockSize)
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext[aes.BlockSize:])
+
+		// XML Encryption only requires the last byte of the padding to hold its length
+		padding := int(plaintext[len(plaintext)-1])
+		if padding < 1 || padding > aes.BlockSize {
+			return nil, errors.New("invalid padding")
+		}
+
+		return plaintext[:len(plaintext)-padding], nil
+	case XMLENC_AES128_GCM, XMLENC_AES192_GCM, XMLENC_AES256_GCM:
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(ciphertext) < gcm.NonceSize() {
+			return nil, errors.New("invalid ciphertext length")
+		}
+
+		return gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+	}
+
+	return nil, errors.New("unsupported block encryption algorithm " + algorithm)
+}
+
+func findChildren(el *etree.Element, namespace, tag string) []*etree.Element {
+	var children []*etree.Element
+	for _, child := range el.ChildElements() {
+		if child.Tag == tag && child.NamespaceURI() == namespace {
+			children = append(children, child)
+		}
+	}
+
+	return children
+}
+
+func findChild(el *etree.Element, namespace, tag string) *etree.Element {
+	if children := findChildren(el, namespace, tag); len(children) > 0 {
+		return children[0]
+	}
+
+	return nil
+}
+
+// findPath follows a path of child elements that are all in the same namespace.
+func findPath(el *etree.Element, namespace string, tags ...string) *etree.Element {
+	for _, tag := range tags {
+		if el = findChild(el, namespace, tag); el == nil {
+			return nil
+		}
+	}
+
+	return el
+}
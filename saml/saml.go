@@ -0,0 +1,367 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package saml is a SAML 2.0 service provider. Users are sent to the identity provider with a signed AuthnRequest over
+// the HTTP-Redirect binding and come back with a Response over the HTTP-POST binding, whose assertion is decrypted and
+// verified against the identity provider's certificate before it is used to log the user in.
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/beevik/etree"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/einterfaces"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const (
+	SAML_NAMESPACE_PROTOCOL  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	SAML_NAMESPACE_ASSERTION = "urn:oasis:names:tc:SAML:2.0:assertion"
+	SAML_NAMESPACE_METADATA  = "urn:oasis:names:tc:SAML:2.0:metadata"
+	SAML_NAMESPACE_DSIG      = "http://www.w3.org/2000/09/xmldsig#"
+	SAML_NAMESPACE_XMLENC    = "http://www.w3.org/2001/04/xmlenc#"
+
+	SAML_BINDING_HTTP_POST     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	SAML_BINDING_HTTP_REDIRECT = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+
+	SAML_NAMEID_FORMAT_UNSPECIFIED = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+	SAML_STATUS_SUCCESS            = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	SAML_SIGNATURE_RSA_SHA256      = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+
+	SAML_TIME_FORMAT = "2006-01-02T15:04:05Z"
+
+	// How far the identity provider's clock may drift from ours when checking assertion validity periods
+	SAML_CLOCK_SKEW = 3 * time.Minute
+
+	// Ids of the requests that we've sent and the assertions that we've accepted are kept as tokens, which are cleaned
+	// up after model.MAX_TOKEN_EXIPRY_TIME, so assertions can't be valid for longer than that
+	SAML_MAX_ASSERTION_LIFETIME = time.Duration(model.MAX_TOKEN_EXIPRY_TIME) * time.Millisecond
+
+	TOKEN_TYPE_SAML_REQUEST   = "saml_request"
+	TOKEN_TYPE_SAML_ASSERTION = "saml_assertion"
+)
+
+// serviceProvider holds the settings and key material loaded by ConfigureSP.
+type serviceProvider struct {
+	EntityId  string
+	AcsURL    string
+	IdpURL    string
+	IdpIssuer string
+	IdpCert   *x509.Certificate
+	KeyPair   *tls.Certificate
+	Verify    bool
+	Encrypt   bool
+}
+
+type SamlProvider struct {
+	mutex sync.RWMutex
+	sp    *serviceProvider
+}
+
+func init() {
+	einterfaces.RegisterSamlInterface(&SamlProvider{})
+}
+
+// ConfigureSP loads the identity provider certificate and the service provider key pair named in the SAML settings.
+// It runs every time the config is loaded.
+func (me *SamlProvider) ConfigureSP() *model.AppError {
+	sp, err := newServiceProvider()
+
+	me.mutex.Lock()
+	me.sp = sp
+	me.mutex.Unlock()
+
+	if err != nil {
+		l4g.Error(err.Error())
+	}
+
+	return err
+}
+
+func (me *SamlProvider) getServiceProvider() (*serviceProvider, *model.AppError) {
+	if !*utils.Cfg.SamlSettings.Enable {
+		return nil, model.NewAppError("getServiceProvider", "ent.saml.service_disable.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	me.mutex.RLock()
+	sp := me.sp
+	me.mutex.RUnlock()
+
+	if sp == nil {
+		if err := me.ConfigureSP(); err != nil {
+			return nil, err
+		}
+
+		me.mutex.RLock()
+		sp = me.sp
+		me.mutex.RUnlock()
+	}
+
+	return sp, nil
+}
+
+func newServiceProvider() (*serviceProvider, *model.AppError) {
+	settings := utils.Cfg.SamlSettings
+
+	if !*settings.Enable {
+		return nil, nil
+	}
+
+	sp := &serviceProvider{
+		EntityId:  *settings.AssertionConsumerServiceURL,
+		AcsURL:    *settings.AssertionConsumerServiceURL,
+		IdpURL:    *settings.IdpUrl,
+		IdpIssuer: *settings.IdpDescriptorUrl,
+		Verify:    *settings.Verify,
+		Encrypt:   *settings.Encrypt,
+	}
+
+	if *settings.IdpCertificateFile != "" {
+		if cert, err := loadCertificate(*settings.IdpCertificateFile); err != nil {
+			return nil, model.NewAppError("ConfigureSP", "ent.saml.configure.load_idp_cert.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			sp.IdpCert = cert
+		}
+	} else if sp.Verify {
+		return nil, model.NewAppError("ConfigureSP", "ent.saml.configure.load_idp_cert.app_error", nil, "", http.StatusInternalServerError)
+	}
+
+	if *settings.PublicCertificateFile != "" && *settings.PrivateKeyFile != "" {
+		keyPair, err := tls.LoadX509KeyPair(utils.FindConfigFile(*settings.PublicCertificateFile), utils.FindConfigFile(*settings.PrivateKeyFile))
+		if err != nil {
+			return nil, model.NewAppError("ConfigureSP", "ent.saml.configure.load_private_key.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+
+		if _, ok := keyPair.PrivateKey.(*rsa.PrivateKey); !ok {
+			return nil, model.NewAppError("ConfigureSP", "ent.saml.configure.load_private_key.app_error", nil, "the private key must be an RSA key", http.StatusInternalServerError)
+		}
+
+		sp.KeyPair = &keyPair
+	} else if sp.Encrypt {
+		return nil, model.NewAppError("ConfigureSP", "ent.saml.configure.encryption_not_enabled.app_error", nil, "", http.StatusInternalServerError)
+	}
+
+	return sp, nil
+}
+
+func loadCertificate(fileName string) (*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(utils.FindConfigFile(fileName))
+	if err != nil {
+		return nil, err
+	}
+
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	return x509.ParseCertificate(data)
+}
+
+// GetMetadata returns the service provider metadata to be registered with the identity provider.
+func (me *SamlProvider) GetMetadata() (string, *model.AppError) {
+	sp, err := me.getServiceProvider()
+	if err != nil {
+		return "", err
+	}
+
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+
+	descriptor := doc.CreateElement("md:EntityDescriptor")
+	descriptor.CreateAttr("xmlns:md", SAML_NAMESPACE_METADATA)
+	descriptor.CreateAttr("xmlns:ds", SAML_NAMESPACE_DSIG)
+	descriptor.CreateAttr("entityID", sp.EntityId)
+
+	spDescriptor := descriptor.CreateElement("md:SPSSODescriptor")
+	spDescriptor.CreateAttr("protocolSupportEnumeration", SAML_NAMESPACE_PROTOCOL)
+	spDescriptor.CreateAttr("AuthnRequestsSigned", boolToXml(sp.KeyPair != nil))
+	spDescriptor.CreateAttr("WantAssertionsSigned", boolToXml(sp.Verify))
+
+	if sp.KeyPair != nil {
+		for _, use := range []string{"signing", "encryption"} {
+			keyDescriptor := spDescriptor.CreateElement("md:KeyDescriptor")
+			keyDescriptor.CreateAttr("use", use)
+			keyInfo := keyDescriptor.CreateElement("ds:KeyInfo")
+			keyInfo.CreateElement("ds:X509Data").CreateElement("ds:X509Certificate").SetText(base64.StdEncoding.EncodeToString(sp.KeyPair.Certificate[0]))
+		}
+	}
+
+	spDescriptor.CreateElement("md:NameIDFormat").SetText(SAML_NAMEID_FORMAT_UNSPECIFIED)
+
+	acs := spDescriptor.CreateElement("md:AssertionConsumerService")
+	acs.CreateAttr("Binding", SAML_BINDING_HTTP_POST)
+	acs.CreateAttr("Location", sp.AcsURL)
+	acs.CreateAttr("index", "0")
+
+	doc.Indent(2)
+
+	if metadata, err := doc.WriteToString(); err != nil {
+		return "", model.NewAppError("GetMetadata", "ent.saml.metadata.app_error", nil, err.Error(), http.StatusInternalServerError)
+	} else {
+		return metadata, nil
+	}
+}
+
+// BuildRequest creates an AuthnRequest for the HTTP-Redirect binding. When the service provider has a private key the
+// request is signed as described in section 3.4.4.1 of the SAML bindings specification.
+func (me *SamlProvider) BuildRequest(relayState string) (*model.SamlAuthRequest, *model.AppError) {
+	sp, err := me.getServiceProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	// The id is remembered so that the response can be checked against it
+	token := model.NewToken(TOKEN_TYPE_SAML_REQUEST, "")
+	if result := <-app.Srv.Store.Token().Save(token); result.Err != nil {
+		return nil, result.Err
+	}
+
+	doc := etree.NewDocument()
+
+	request := doc.CreateElement("samlp:AuthnRequest")
+	request.CreateAttr("xmlns:samlp", SAML_NAMESPACE_PROTOCOL)
+	request.CreateAttr("xmlns:saml", SAML_NAMESPACE_ASSERTION)
+	request.CreateAttr("ID", "_"+token.Token)
+	request.CreateAttr("Version", "2.0")
+	request.CreateAttr("IssueInstant", time.Now().UTC().Format(SAML_TIME_FORMAT))
+	request.CreateAttr("Destination", sp.IdpURL)
+	request.CreateAttr("ProtocolBinding", SAML_BINDING_HTTP_POST)
+	request.CreateAttr("AssertionConsumerServiceURL", sp.AcsURL)
+
+	request.CreateElement("saml:Issuer").SetText(sp.EntityId)
+
+	nameIdPolicy := request.CreateElement("samlp:NameIDPolicy")
+	nameIdPolicy.CreateAttr("Format", SAML_NAMEID_FORMAT_UNSPECIFIED)
+	nameIdPolicy.CreateAttr("AllowCreate", "true")
+
+	xml, xmlErr := doc.WriteToBytes()
+	if xmlErr != nil {
+		return nil, model.NewAppError("BuildRequest", "ent.saml.build_request.encoding.app_error", nil, xmlErr.Error(), http.StatusInternalServerError)
+	}
+
+	var buf bytes.Buffer
+	writer, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	writer.Write(xml)
+	writer.Close()
+
+	encodedRequest := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	// The signature covers the parameters in this order with their URL encoded values
+	query := "SAMLRequest=" + url.QueryEscape(encodedRequest)
+	if relayState != "" {
+		query += "&RelayState=" + url.QueryEscape(relayState)
+	}
+
+	if sp.KeyPair != nil {
+		query += "&SigAlg=" + url.QueryEscape(SAML_SIGNATURE_RSA_SHA256)
+
+		hashed := sha256.Sum256([]byte(query))
+		signature, signErr := rsa.SignPKCS1v15(rand.Reader, sp.KeyPair.PrivateKey.(*rsa.PrivateKey), crypto.SHA256, hashed[:])
+		if signErr != nil {
+			return nil, model.NewAppError("BuildRequest", "ent.saml.build_request.encoding_signed.app_error", nil, signErr.Error(), http.StatusInternalServerError)
+		}
+
+		query += "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+	}
+
+	separator := "?"
+	if strings.Contains(sp.IdpURL, "?") {
+		separator = "&"
+	}
+
+	return &model.SamlAuthRequest{
+		Base64AuthRequest: encodedRequest,
+		URL:               sp.IdpURL + separator + query,
+		RelayState:        relayState,
+	}, nil
+}
+
+// DoLogin verifies a base64 encoded Response from the identity provider and returns the user it identifies, creating
+// or updating the user as needed.
+func (me *SamlProvider) DoLogin(encodedXML string, relayState map[string]string) (*model.User, *model.AppError) {
+	sp, err := me.getServiceProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	assertion, err := sp.parseResponse(encodedXML, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := useRequestId(assertion.InResponseTo); err != nil {
+		return nil, err
+	}
+
+	if err := useAssertionId(assertion.Id); err != nil {
+		return nil, err
+	}
+
+	samlUser, err := assertionToUser(assertion)
+	if err != nil {
+		return nil, err
+	}
+
+	return completeLogin(samlUser, relayState)
+}
+
+// useRequestId checks that a response answers an AuthnRequest that we sent and forgets the request so that it can't be
+// answered again.
+func useRequestId(requestId string) *model.AppError {
+	if !strings.HasPrefix(requestId, "_") {
+		return newResponseError("ent.saml.do_login.invalid_request.app_error", "in_response_to="+requestId)
+	}
+
+	result := <-app.Srv.Store.Token().GetByToken(strings.TrimPrefix(requestId, "_"))
+	if result.Err != nil || result.Data.(*model.Token).Type != TOKEN_TYPE_SAML_REQUEST {
+		return newResponseError("ent.saml.do_login.invalid_request.app_error", "in_response_to="+requestId)
+	}
+
+	if result := <-app.Srv.Store.Token().Delete(result.Data.(*model.Token).Token); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// useAssertionId records that an assertion has been used to log in. It fails if the assertion has already been used,
+// which is detected by the token for its id already existing.
+func useAssertionId(assertionId string) *model.AppError {
+	hash := sha256.Sum256([]byte(assertionId))
+
+	token := model.NewToken(TOKEN_TYPE_SAML_ASSERTION, "")
+	token.Token = hex.EncodeToString(hash[:])
+
+	if result := <-app.Srv.Store.Token().Save(token); result.Err != nil {
+		return newResponseError("ent.saml.do_login.replayed.app_error", "assertion_id="+assertionId+", "+result.Err.Error())
+	}
+
+	return nil
+}
+
+func boolToXml(value bool) string {
+	if value {
+		return "true"
+	}
+
+	return "false"
+}
@@ -0,0 +1,573 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package saml
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+const testAcsURL = "http://localhost:8065/login/sso/saml"
+
+func newString(s string) *string {
+	return &s
+}
+
+func newBool(b bool) *bool {
+	return &b
+}
+
+// setupTestSaml starts a test identity provider and points the SAML settings at it. The returned function stops the
+// identity provider and restores the settings.
+func setupTestSaml(t *testing.T) (*SamlProvider, *testIdp, func()) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	idp, err := newTestIdp()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldSettings := utils.Cfg.SamlSettings
+
+	utils.Cfg.SamlSettings = model.SamlSettings{
+		Enable:                      newBool(true),
+		Verify:                      newBool(true),
+		Encrypt:                     newBool(false),
+		IdpUrl:                      newString(idp.Server.URL + "/sso"),
+		IdpDescriptorUrl:            newString(idp.EntityId),
+		AssertionConsumerServiceURL: newString(testAcsURL),
+		IdpCertificateFile:          newString(idp.Idp.CertFile),
+		PublicCertificateFile:       newString(idp.SP.CertFile),
+		PrivateKeyFile:              newString(idp.SP.KeyFile),
+		FirstNameAttribute:          newString("FirstName"),
+		LastNameAttribute:           newString("LastName"),
+		EmailAttribute:              newString("Email"),
+		UsernameAttribute:           newString("Username"),
+		NicknameAttribute:           newString(""),
+		LocaleAttribute:             newString("Locale"),
+		PositionAttribute:           newString("Position"),
+		LoginButtonText:             newString(model.USER_AUTH_SERVICE_SAML_TEXT),
+	}
+
+	provider := &SamlProvider{}
+	if err := provider.ConfigureSP(); err != nil {
+		idp.Close()
+		utils.Cfg.SamlSettings = oldSettings
+		t.Fatal(err)
+	}
+
+	return provider, idp, func() {
+		idp.Close()
+		utils.Cfg.SamlSettings = oldSettings
+	}
+}
+
+func newTestResponseOptions(email string) testResponseOptions {
+	return testResponseOptions{
+		Email: email,
+		Attributes: map[string]string{
+			"Email":     email,
+			"Username":  strings.Split(email, "@")[0],
+			"FirstName": "First",
+			"LastName":  "Last",
+			"Locale":    "fr",
+			"Position":  "Engineer",
+		},
+		SignAssertion: true,
+	}
+}
+
+func TestGetMetadata(t *testing.T) {
+	provider, idp, teardown := setupTestSaml(t)
+	defer teardown()
+
+	metadata, err := provider.GetMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString(metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	descriptor := doc.Root()
+	if descriptor.Tag != "EntityDescriptor" || descriptor.SelectAttrValue("entityID", "") != testAcsURL {
+		t.Fatal("should have an entity descriptor for the service provider")
+	}
+
+	spDescriptor := findChild(descriptor, SAML_NAMESPACE_METADATA, "SPSSODescriptor")
+	if spDescriptor == nil {
+		t.Fatal("should have an SP descriptor")
+	}
+
+	if spDescriptor.SelectAttrValue("AuthnRequestsSigned", "") != "true" || spDescriptor.SelectAttrValue("WantAssertionsSigned", "") != "true" {
+		t.Fatal("should advertise signed requests and assertions")
+	}
+
+	keyDescriptors := findChildren(spDescriptor, SAML_NAMESPACE_METADATA, "KeyDescriptor")
+	if len(keyDescriptors) != 2 {
+		t.Fatal("should have signing and encryption keys")
+	}
+
+	cert := findPath(keyDescriptors[0], SAML_NAMESPACE_DSIG, "KeyInfo", "X509Data", "X509Certificate")
+	if cert == nil || cert.Text() != base64.StdEncoding.EncodeToString(idp.SP.Cert.Raw) {
+		t.Fatal("should include the service provider certificate")
+	}
+
+	acs := findChild(spDescriptor, SAML_NAMESPACE_METADATA, "AssertionConsumerService")
+	if acs == nil || acs.SelectAttrValue("Location", "") != testAcsURL || acs.SelectAttrValue("Binding", "") != SAML_BINDING_HTTP_POST {
+		t.Fatal("should have an HTTP-POST assertion consumer service")
+	}
+
+	*utils.Cfg.SamlSettings.Enable = false
+	if _, err := provider.GetMetadata(); err == nil {
+		t.Fatal("should fail when SAML is disabled")
+	}
+}
+
+func TestBuildRequest(t *testing.T) {
+	app.Setup()
+
+	provider, idp, teardown := setupTestSaml(t)
+	defer teardown()
+
+	request, err := provider.BuildRequest("relay")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(request.URL, idp.Server.URL+"/sso?") {
+		t.Fatal("should send the user to the identity provider", request.URL)
+	}
+
+	if request.RelayState != "relay" {
+		t.Fatal("should keep the relay state")
+	}
+
+	values, loginErr := idp.Login(request.URL)
+	if loginErr != nil {
+		t.Fatal("identity provider should accept the signed request", loginErr)
+	}
+
+	if values.Get("RelayState") != "relay" {
+		t.Fatal("identity provider should return the relay state")
+	}
+
+	if idp.LastRequest.SelectAttrValue("AssertionConsumerServiceURL", "") != testAcsURL {
+		t.Fatal("request should name the assertion consumer service")
+	}
+
+	if issuer := findChild(idp.LastRequest, SAML_NAMESPACE_ASSERTION, "Issuer"); issuer == nil || issuer.Text() != testAcsURL {
+		t.Fatal("request should be issued by the service provider")
+	}
+
+	// Tampering with the query should break the signature
+	tampered := strings.Replace(request.URL, "RelayState=relay", "RelayState=other", 1)
+	if _, err := idp.Login(tampered); err == nil {
+		t.Fatal("identity provider should reject a tampered request")
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	provider, idp, teardown := setupTestSaml(t)
+	defer teardown()
+
+	sp := provider.sp
+	email := "saml-" + model.NewId() + "@example.com"
+
+	t.Run("SignedAssertion", func(t *testing.T) {
+		response, err := idp.BuildResponse(testAcsURL, "", newTestResponseOptions(email))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertion, appErr := sp.parseResponse(response, time.Now())
+		if appErr != nil {
+			t.Fatal(appErr)
+		}
+
+		if assertion.NameId != email || assertion.GetAttribute("Email") != email || assertion.GetAttribute("Position") != "Engineer" {
+			t.Fatal("should return the subject and attributes", assertion)
+		}
+	})
+
+	t.Run("SignedResponse", func(t *testing.T) {
+		options := newTestResponseOptions(email)
+		options.SignAssertion = false
+		options.SignResponse = true
+
+		response, err := idp.BuildResponse(testAcsURL, "", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if assertion, appErr := sp.parseResponse(response, time.Now()); appErr != nil {
+			t.Fatal(appErr)
+		} else if assertion.GetAttribute("Email") != email {
+			t.Fatal("should return the attributes")
+		}
+	})
+
+	t.Run("Encrypted", func(t *testing.T) {
+		for _, useGCM := range []bool{false, true} {
+			options := newTestResponseOptions(email)
+			options.Encrypt = true
+			options.EncryptionGCM = useGCM
+
+			response, err := idp.BuildResponse(testAcsURL, "", options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if assertion, appErr := sp.parseResponse(response, time.Now()); appErr != nil {
+				t.Fatal(appErr)
+			} else if assertion.GetAttribute("Email") != email {
+				t.Fatal("should return the attributes")
+			}
+		}
+	})
+
+	t.Run("EncryptedWithRSA15", func(t *testing.T) {
+		options := newTestResponseOptions(email)
+		options.Encrypt = true
+		options.KeyTransport = testXmlencRsa15
+
+		response, err := idp.BuildResponse(testAcsURL, "", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, appErr := sp.parseResponse(response, time.Now()); appErr == nil {
+			t.Fatal("should reject a key encrypted with RSA 1.5")
+		}
+	})
+
+	t.Run("RequestAndAssertionIds", func(t *testing.T) {
+		response, err := idp.BuildResponse(testAcsURL, "_request", newTestResponseOptions(email))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if assertion, appErr := sp.parseResponse(response, time.Now()); appErr != nil {
+			t.Fatal(appErr)
+		} else if assertion.InResponseTo != "_request" || assertion.Id == "" {
+			t.Fatal("should return the request and assertion ids", assertion)
+		} else if assertion.NotOnOrAfter.IsZero() {
+			t.Fatal("should return when the assertion expires")
+		}
+	})
+
+	t.Run("EncryptionRequired", func(t *testing.T) {
+		response, err := idp.BuildResponse(testAcsURL, "", newTestResponseOptions(email))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		encryptSP := *sp
+		encryptSP.Encrypt = true
+		if _, appErr := encryptSP.parseResponse(response, time.Now()); appErr == nil {
+			t.Fatal("should reject an unencrypted assertion")
+		}
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		response, err := idp.BuildResponse(testAcsURL, "", newTestResponseOptions(email))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, _ := base64.StdEncoding.DecodeString(response)
+		tampered := strings.Replace(string(data), email, "admin@example.com", -1)
+
+		if _, appErr := sp.parseResponse(base64.StdEncoding.EncodeToString([]byte(tampered)), time.Now()); appErr == nil {
+			t.Fatal("should reject a tampered assertion")
+		}
+	})
+
+	t.Run("WrongCertificate", func(t *testing.T) {
+		response, err := idp.BuildResponse(testAcsURL, "", newTestResponseOptions(email))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		otherSP := *sp
+		otherSP.IdpCert = idp.SP.Cert
+		if _, appErr := otherSP.parseResponse(response, time.Now()); appErr == nil {
+			t.Fatal("should reject an assertion signed by another certificate")
+		}
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		options := newTestResponseOptions(email)
+		options.SignAssertion = false
+
+		response, err := idp.BuildResponse(testAcsURL, "", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, appErr := sp.parseResponse(response, time.Now()); appErr == nil {
+			t.Fatal("should reject an unsigned assertion")
+		}
+
+		unverifiedSP := *sp
+		unverifiedSP.Verify = false
+		if _, appErr := unverifiedSP.parseResponse(response, time.Now()); appErr != nil {
+			t.Fatal("should accept an unsigned assertion when verification is disabled", appErr)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		options := newTestResponseOptions(email)
+		options.Now = time.Now().Add(-time.Hour)
+
+		response, err := idp.BuildResponse(testAcsURL, "", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, appErr := sp.parseResponse(response, time.Now()); appErr == nil {
+			t.Fatal("should reject an expired assertion")
+		}
+
+		// Small differences between the clocks are allowed
+		if _, appErr := sp.parseResponse(response, options.Now.Add(-time.Minute)); appErr != nil {
+			t.Fatal("should allow for clock skew", appErr)
+		}
+	})
+
+	t.Run("WrongAudience", func(t *testing.T) {
+		options := newTestResponseOptions(email)
+		options.Audience = "http://other.example.com"
+
+		response, err := idp.BuildResponse(testAcsURL, "", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, appErr := sp.parseResponse(response, time.Now()); appErr == nil {
+			t.Fatal("should reject an assertion for another service provider")
+		}
+	})
+
+	t.Run("WrongIssuer", func(t *testing.T) {
+		options := newTestResponseOptions(email)
+		options.Issuer = "http://other.example.com"
+
+		response, err := idp.BuildResponse(testAcsURL, "", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, appErr := sp.parseResponse(response, time.Now()); appErr == nil {
+			t.Fatal("should reject an assertion from another identity provider")
+		}
+	})
+
+	t.Run("WrongDestination", func(t *testing.T) {
+		response, err := idp.BuildResponse("http://other.example.com/login/sso/saml", "", newTestResponseOptions(email))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, appErr := sp.parseResponse(response, time.Now()); appErr == nil {
+			t.Fatal("should reject a response for another service provider")
+		}
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		options := newTestResponseOptions(email)
+		options.Status = "urn:oasis:names:tc:SAML:2.0:status:Requester"
+
+		response, err := idp.BuildResponse(testAcsURL, "", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, appErr := sp.parseResponse(response, time.Now()); appErr == nil {
+			t.Fatal("should reject an unsuccessful response")
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, appErr := sp.parseResponse("not base64!", time.Now()); appErr == nil {
+			t.Fatal("should reject invalid base64")
+		}
+
+		if _, appErr := sp.parseResponse(base64.StdEncoding.EncodeToString([]byte("<foo/>")), time.Now()); appErr == nil {
+			t.Fatal("should reject a document that isn't a response")
+		}
+	})
+}
+
+func TestAssertionToUser(t *testing.T) {
+	_, _, teardown := setupTestSaml(t)
+	defer teardown()
+
+	assertion := &samlAssertion{
+		Attributes: map[string][]string{
+			"Email":     {"Test.User@Example.com"},
+			"Username":  {"Test.User"},
+			"FirstName": {" First "},
+			"LastName":  {"Last"},
+			"Locale":    {"fr"},
+			"Position":  {"Engineer"},
+		},
+	}
+
+	user, err := assertionToUser(assertion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.AuthService != model.USER_AUTH_SERVICE_SAML || user.AuthData == nil || *user.AuthData != "test.user@example.com" {
+		t.Fatal("should identify the user by email")
+	}
+
+	if user.Email != "test.user@example.com" || user.Username != "test.user" || user.FirstName != "First" ||
+		user.LastName != "Last" || user.Locale != "fr" || user.Position != "Engineer" || !user.EmailVerified {
+		t.Fatal("should map the attributes", user)
+	}
+
+	*utils.Cfg.SamlSettings.UsernameAttribute = ""
+	if user, err := assertionToUser(assertion); err != nil {
+		t.Fatal(err)
+	} else if user.Username != "test.user" {
+		t.Fatal("should use the email to make up a username", user.Username)
+	}
+
+	delete(assertion.Attributes, "Email")
+	if _, err := assertionToUser(assertion); err == nil {
+		t.Fatal("should require an email")
+	}
+}
+
+func TestUpdateUserAttributes(t *testing.T) {
+	_, _, teardown := setupTestSaml(t)
+	defer teardown()
+
+	user := &model.User{Username: "old", FirstName: "Old", Nickname: "nick", Locale: "en"}
+	samlUser := &model.User{Username: "new", FirstName: "New", Locale: "fr"}
+
+	if !updateUserAttributes(user, samlUser) {
+		t.Fatal("should report a change")
+	}
+
+	if user.Username != "new" || user.FirstName != "New" || user.Locale != "fr" {
+		t.Fatal("should copy configured attributes", user)
+	}
+
+	if user.Nickname != "nick" {
+		t.Fatal("should keep attributes that aren't configured")
+	}
+
+	if updateUserAttributes(user, samlUser) {
+		t.Fatal("should not report a change when nothing changed")
+	}
+}
+
+func TestDoLogin(t *testing.T) {
+	app.Setup()
+
+	provider, idp, teardown := setupTestSaml(t)
+	defer teardown()
+
+	// login sends a new request to the identity provider and returns the response that it posts back
+	login := func(options testResponseOptions) string {
+		request, err := provider.BuildRequest("")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		idp.Options = options
+
+		values, loginErr := idp.Login(request.URL)
+		if loginErr != nil {
+			t.Fatal(loginErr)
+		}
+
+		return values.Get("SAMLResponse")
+	}
+
+	email := "saml-" + model.NewId() + "@example.com"
+	options := newTestResponseOptions(email)
+
+	response := login(options)
+
+	user, err := provider.DoLogin(response, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user.Email != email || user.AuthService != model.USER_AUTH_SERVICE_SAML || user.Position != "Engineer" {
+		t.Fatal("should create the user", user)
+	}
+
+	if _, err := provider.DoLogin(response, map[string]string{}); err == nil {
+		t.Fatal("should not accept the same response twice")
+	}
+
+	options.Attributes["Position"] = "Manager"
+
+	if updatedUser, err := provider.DoLogin(login(options), map[string]string{}); err != nil {
+		t.Fatal(err)
+	} else if updatedUser.Id != user.Id || updatedUser.Position != "Manager" {
+		t.Fatal("should update the existing user", updatedUser)
+	}
+
+	// Responses have to answer a request that was sent by the service provider
+	if unsolicited, err := idp.BuildResponse(testAcsURL, "", options); err != nil {
+		t.Fatal(err)
+	} else if _, err := provider.DoLogin(unsolicited, map[string]string{}); err == nil {
+		t.Fatal("should not accept a response that doesn't answer a request")
+	}
+
+	if unknown, err := idp.BuildResponse(testAcsURL, "_"+model.NewRandomString(model.TOKEN_SIZE), options); err != nil {
+		t.Fatal(err)
+	} else if _, err := provider.DoLogin(unknown, map[string]string{}); err == nil {
+		t.Fatal("should not accept a response to a request that wasn't sent")
+	}
+
+	// An email user can only switch to SAML with an assertion for their own email
+	emailUser, err := app.CreateUser(&model.User{
+		Email:    "saml-" + model.NewId() + "@example.com",
+		Username: "saml" + model.NewId()[:20],
+		Password: "password",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options = newTestResponseOptions(emailUser.Email)
+
+	if _, err := provider.DoLogin(login(options), map[string]string{}); err == nil {
+		t.Fatal("should not log in to an email account without switching it")
+	}
+
+	if _, err := provider.DoLogin(login(options), map[string]string{"action": model.OAUTH_ACTION_EMAIL_TO_SSO, "email": "other@example.com"}); err == nil {
+		t.Fatal("should not switch an account with another email")
+	}
+
+	if switchedUser, err := provider.DoLogin(login(options), map[string]string{"action": model.OAUTH_ACTION_EMAIL_TO_SSO, "email": emailUser.Email}); err != nil {
+		t.Fatal(err)
+	} else if switchedUser.Id != emailUser.Id {
+		t.Fatal("should switch the email account")
+	}
+
+	if loggedInUser, err := provider.DoLogin(login(options), map[string]string{}); err != nil {
+		t.Fatal(err)
+	} else if loggedInUser.Id != emailUser.Id || loggedInUser.AuthService != model.USER_AUTH_SERVICE_SAML {
+		t.Fatal("should log in to the switched account")
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package saml
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/primefour/servers/app"
+	"github.com/primefour/servers/model"
+	"github.com/primefour/servers/utils"
+)
+
+// assertionToUser maps the attributes of an assertion to a user using the attribute settings. The email attribute is
+// required since it identifies the user, and attributes that aren't configured are left empty.
+func assertionToUser(assertion *samlAssertion) (*model.User, *model.AppError) {
+	settings := utils.Cfg.SamlSettings
+
+	email := strings.ToLower(assertion.GetAttribute(*settings.EmailAttribute))
+	if email == "" {
+		return nil, model.NewAppError("assertionToUser", "ent.saml.attribute.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	authData := email
+
+	user := &model.User{
+		AuthService:   model.USER_AUTH_SERVICE_SAML,
+		AuthData:      &authData,
+		Email:         email,
+		Username:      model.CleanUsername(assertion.GetAttribute(*settings.UsernameAttribute)),
+		FirstName:     assertion.GetAttribute(*settings.FirstNameAttribute),
+		LastName:      assertion.GetAttribute(*settings.LastNameAttribute),
+		Nickname:      assertion.GetAttribute(*settings.NicknameAttribute),
+		Position:      assertion.GetAttribute(*settings.PositionAttribute),
+		EmailVerified: true,
+	}
+
+	if locale := assertion.GetAttribute(*settings.LocaleAttribute); locale != "" {
+		user.Locale = locale
+	}
+
+	if *settings.UsernameAttribute == "" {
+		user.Username = model.CleanUsername(strings.Split(email, "@")[0])
+	}
+
+	return user, nil
+}
+
+// updateUserAttributes copies the attributes mapped from the assertion onto an existing user and reports whether
+// anything changed. Optional attributes are only copied when they are configured.
+func updateUserAttributes(user *model.User, samlUser *model.User) bool {
+	settings := utils.Cfg.SamlSettings
+	changed := false
+
+	update := func(field *string, value string, attribute string) {
+		if attribute != "" && *field != value {
+			*field = value
+			changed = true
+		}
+	}
+
+	update(&user.Username, samlUser.Username, *settings.UsernameAttribute)
+	update(&user.FirstName, samlUser.FirstName, *settings.FirstNameAttribute)
+	update(&user.LastName, samlUser.LastName, *settings.LastNameAttribute)
+	update(&user.Nickname, samlUser.Nickname, *settings.NicknameAttribute)
+	update(&user.Position, samlUser.Position, *settings.PositionAttribute)
+
+	if *settings.LocaleAttribute != "" && samlUser.Locale != "" && user.Locale != samlUser.Locale {
+		user.Locale = samlUser.Locale
+		changed = true
+	}
+
+	return changed
+}
+
+// completeLogin returns the existing user for a SAML user, creating it when it doesn't exist yet or, when the user is
+// switching from email sign in, attaching the SAML account to the user that started the switch.
+func completeLogin(samlUser *model.User, relayState map[string]string) (*model.User, *model.AppError) {
+	if result := <-app.Srv.Store.User().GetByAuth(samlUser.AuthData, model.USER_AUTH_SERVICE_SAML); result.Err == nil {
+		user := result.Data.(*model.User)
+
+		if updateUserAttributes(user, samlUser) {
+			if updateResult := <-app.Srv.Store.User().Update(user, true); updateResult.Err != nil {
+				l4g.Error(utils.T("ent.saml.update_saml_user.unable_error"), updateResult.Err.Error())
+			} else {
+				app.InvalidateCacheForUser(user.Id)
+				user = updateResult.Data.([2]*model.User)[0]
+			}
+		}
+
+		return user, nil
+	}
+
+	if relayState["action"] == model.OAUTH_ACTION_EMAIL_TO_SSO {
+		return switchToSaml(samlUser, relayState["email"])
+	}
+
+	if result := <-app.Srv.Store.User().GetByEmail(samlUser.Email); result.Err == nil {
+		authService := result.Data.(*model.User).AuthService
+		if authService == "" {
+			authService = model.USER_AUTH_SERVICE_EMAIL
+		}
+
+		return nil, model.NewAppError("completeLogin", "api.user.create_oauth_user.already_attached.app_error",
+			map[string]interface{}{"Service": model.USER_AUTH_SERVICE_SAML, "Auth": authService}, "email="+samlUser.Email, http.StatusBadRequest)
+	}
+
+	if !utils.Cfg.TeamSettings.EnableUserCreation {
+		return nil, model.NewAppError("completeLogin", "api.user.create_user.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	if samlUser.Username == "" {
+		samlUser.Username = "saml-user"
+	}
+
+	found := true
+	count := 0
+	for found {
+		if found = app.IsUsernameTaken(samlUser.Username); found {
+			samlUser.Username = samlUser.Username + strconv.Itoa(count)
+			count += 1
+		}
+	}
+
+	if user, err := app.CreateUser(samlUser); err != nil {
+		return nil, model.NewAppError("completeLogin", "ent.saml.do_login.unable_to_create_user.app_error", nil, err.Error(), http.StatusInternalServerError)
+	} else {
+		return user, nil
+	}
+}
+
+// switchToSaml attaches a SAML account to the email user that started the switch. The identity provider has to
+// vouch for the same email address the switch was started with.
+func switchToSaml(samlUser *model.User, email string) (*model.User, *model.AppError) {
+	if email == "" {
+		return nil, model.NewAppError("switchToSaml", "api.user.complete_switch_with_oauth.blank_email.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if strings.ToLower(email) != samlUser.Email {
+		return nil, model.NewAppError("switchToSaml", "ent.saml.do_login.email_mismatch.app_error", nil, "email="+email, http.StatusBadRequest)
+	}
+
+	var user *model.User
+	if result := <-app.Srv.Store.User().GetByEmail(email); result.Err != nil {
+		return nil, result.Err
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	if result := <-app.Srv.Store.User().UpdateAuthData(user.Id, model.USER_AUTH_SERVICE_SAML, samlUser.AuthData, "", true); result.Err != nil {
+		return nil, result.Err
+	}
+
+	app.InvalidateCacheForUser(user.Id)
+
+	return user, nil
+}
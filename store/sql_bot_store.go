@@ -0,0 +1,169 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/primefour/servers/model"
+)
+
+type SqlBotStore struct {
+	*SqlStore
+}
+
+func NewSqlBotStore(sqlStore *SqlStore) BotStore {
+	s := &SqlBotStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Bot{}, "Bots").SetKeys(false, "UserId")
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("Description").SetMaxSize(1024)
+		table.ColMap("OwnerId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlBotStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_bots_owner_id", "Bots", "OwnerId")
+	s.CreateIndexIfNotExists("idx_bots_delete_at", "Bots", "DeleteAt")
+}
+
+func (s SqlBotStore) Save(bot *model.Bot) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		bot.PreSave()
+
+		if result.Err = bot.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(bot); err != nil {
+			result.Err = model.NewAppError("SqlBotStore.Save", "store.sql_bot.save.app_error", nil, "user_id="+bot.UserId+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = bot
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlBotStore) Update(bot *model.Bot) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		bot.PreUpdate()
+
+		if result.Err = bot.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := s.GetMaster().Update(bot); err != nil {
+			result.Err = model.NewAppError("SqlBotStore.Update", "store.sql_bot.update.app_error", nil, "user_id="+bot.UserId+", "+err.Error(), http.StatusInternalServerError)
+		} else if count != 1 {
+			result.Err = model.NewAppError("SqlBotStore.Update", "store.sql_bot.update.app_error", nil, fmt.Sprintf("user_id=%v, count=%v", bot.UserId, count), http.StatusInternalServerError)
+		} else {
+			result.Data = bot
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlBotStore) Get(userId string, includeDeleted bool) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		query := "SELECT * FROM Bots WHERE UserId = :UserId"
+		if !includeDeleted {
+			query += " AND DeleteAt = 0"
+		}
+
+		bot := model.Bot{}
+
+		if err := s.GetReplica().SelectOne(&bot, query, map[string]interface{}{"UserId": userId}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlBotStore.Get", "store.sql_bot.get.missing.app_error", nil, "user_id="+userId, http.StatusNotFound)
+			} else {
+				result.Err = model.NewAppError("SqlBotStore.Get", "store.sql_bot.get.app_error", nil, "user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+			}
+		} else {
+			result.Data = &bot
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetAll returns a page of bots ordered by when they were created. If ownerId is set, only the bots owned by that user
+// are returned.
+func (s SqlBotStore) GetAll(ownerId string, includeDeleted bool, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		query := "SELECT * FROM Bots WHERE 1 = 1"
+		if ownerId != "" {
+			query += " AND OwnerId = :OwnerId"
+		}
+		if !includeDeleted {
+			query += " AND DeleteAt = 0"
+		}
+		query += " ORDER BY CreateAt, UserId LIMIT :Limit OFFSET :Offset"
+
+		bots := []*model.Bot{}
+
+		if _, err := s.GetReplica().Select(&bots, query, map[string]interface{}{"OwnerId": ownerId, "Offset": offset, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlBotStore.GetAll", "store.sql_bot.get_all.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = bots
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlBotStore) PermanentDelete(userId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM Bots WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewAppError("SqlBotStore.PermanentDelete", "store.sql_bot.permanent_delete.app_error", nil, "user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/primefour/servers/model"
+)
+
+func TestBotStoreSaveGetUpdate(t *testing.T) {
+	Setup()
+
+	bot := &model.Bot{
+		UserId:      model.NewId(),
+		Description: "a bot",
+		OwnerId:     model.NewId(),
+	}
+
+	if result := <-store.Bot().Save(bot); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Bot().Save(&model.Bot{UserId: model.NewId()}); result.Err == nil {
+		t.Fatal("shouldn't have saved a bot without an owner")
+	}
+
+	if result := <-store.Bot().Get(bot.UserId, false); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.Bot); received.Description != bot.Description || received.OwnerId != bot.OwnerId {
+		t.Fatal("received incorrect bot after save")
+	}
+
+	if result := <-store.Bot().Get(model.NewId(), true); result.Err == nil {
+		t.Fatal("shouldn't have found a missing bot")
+	}
+
+	bot.DeleteAt = model.GetMillis()
+	if result := <-store.Bot().Update(bot); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Bot().Get(bot.UserId, false); result.Err == nil {
+		t.Fatal("shouldn't have found a disabled bot")
+	}
+
+	if result := <-store.Bot().Get(bot.UserId, true); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Bot().PermanentDelete(bot.UserId); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Bot().Get(bot.UserId, true); result.Err == nil {
+		t.Fatal("should have deleted the bot")
+	}
+}
+
+func TestBotStoreGetAll(t *testing.T) {
+	Setup()
+
+	ownerId := model.NewId()
+
+	b1 := Must(store.Bot().Save(&model.Bot{UserId: model.NewId(), OwnerId: ownerId})).(*model.Bot)
+	Must(store.Bot().Save(&model.Bot{UserId: model.NewId(), OwnerId: ownerId, DeleteAt: 1}))
+	Must(store.Bot().Save(&model.Bot{UserId: model.NewId(), OwnerId: model.NewId()}))
+
+	if result := <-store.Bot().GetAll(ownerId, false, 0, 100); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if bots := result.Data.([]*model.Bot); len(bots) != 1 || bots[0].UserId != b1.UserId {
+		t.Fatal("should only have returned the active bot of the owner")
+	}
+
+	if result := <-store.Bot().GetAll(ownerId, true, 0, 100); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if bots := result.Data.([]*model.Bot); len(bots) != 2 {
+		t.Fatal("should have returned every bot of the owner")
+	}
+
+	if result := <-store.Bot().GetAll("", true, 0, 100); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if bots := result.Data.([]*model.Bot); len(bots) < 3 {
+		t.Fatal("should have returned the bots of every owner")
+	}
+}
@@ -440,6 +440,10 @@ func (s SqlChannelStore) Delete(channelId string, time int64) StoreChannel {
 	return s.SetDeleteAt(channelId, time, time)
 }
 
+func (s SqlChannelStore) Restore(channelId string, time int64) StoreChannel {
+	return s.SetDeleteAt(channelId, 0, time)
+}
+
 func (s SqlChannelStore) SetDeleteAt(channelId string, deleteAt int64, updateAt int64) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -541,6 +545,30 @@ func (s SqlChannelStore) GetChannels(teamId string, userId string) StoreChannel
 	return storeChannel
 }
 
+// GetDeletedForUser returns the deleted channels on a team that a user is still a member of. Deleted channels are
+// archived, so their members can still read them.
+func (s SqlChannelStore) GetDeletedForUser(teamId string, userId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		data := &model.ChannelList{}
+		_, err := s.GetReplica().Select(data, "SELECT Channels.* FROM Channels, ChannelMembers WHERE Id = ChannelId AND UserId = :UserId AND DeleteAt != 0 AND TeamId = :TeamId ORDER BY DisplayName", map[string]interface{}{"TeamId": teamId, "UserId": userId})
+
+		if err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.GetDeletedForUser", "store.sql_channel.get_deleted_for_user.app_error", nil, "teamId="+teamId+", userId="+userId+", err="+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = data
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlChannelStore) GetMoreChannels(teamId string, userId string, offset int, limit int) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -1043,7 +1071,7 @@ func (s SqlChannelStore) GetAllChannelMembersForUser(userId string, allowFromCac
 		}
 
 		var data []allChannelMember
-		_, err := s.GetReplica().Select(&data, "SELECT ChannelId, Roles FROM Channels, ChannelMembers WHERE Channels.Id = ChannelMembers.ChannelId AND ChannelMembers.UserId = :UserId AND Channels.DeleteAt = 0", map[string]interface{}{"UserId": userId})
+		_, err := s.GetReplica().Select(&data, "SELECT ChannelId, Roles FROM Channels, ChannelMembers WHERE Channels.Id = ChannelMembers.ChannelId AND ChannelMembers.UserId = :UserId", map[string]interface{}{"UserId": userId})
 
 		if err != nil {
 			result.Err = model.NewLocAppError("SqlChannelStore.GetAllChannelMembersForUser", "store.sql_channel.get_channels.get.app_error", nil, "userId="+userId+", err="+err.Error())
@@ -1210,6 +1238,67 @@ func (s SqlChannelStore) GetMemberCount(channelId string, allowFromCache bool) S
 	return storeChannel
 }
 
+// UsersShareChannel returns whether two users are both members of a public or private channel that hasn't been
+// deleted.
+func (s SqlChannelStore) UsersShareChannel(userId string, otherUserId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		count, err := s.GetReplica().SelectInt(`
+			SELECT
+				count(*)
+			FROM
+				ChannelMembers cm1
+			INNER JOIN ChannelMembers cm2 ON cm2.ChannelId = cm1.ChannelId
+			INNER JOIN Channels ON Channels.Id = cm1.ChannelId
+			WHERE
+				cm1.UserId = :UserId
+				AND cm2.UserId = :OtherUserId
+				AND Channels.Type IN ('O', 'P')
+				AND Channels.DeleteAt = 0`, map[string]interface{}{"UserId": userId, "OtherUserId": otherUserId})
+		if err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.UsersShareChannel", "store.sql_channel.users_share_channel.app_error", nil, "user_id="+userId+", other_user_id="+otherUserId+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = count > 0
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// RenameMemberRole replaces a role in the roles of every channel member that has it. The roles are padded with spaces
+// so that only whole role ids match.
+func (s SqlChannelStore) RenameMemberRole(oldRoleId string, newRoleId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(`
+			UPDATE
+				ChannelMembers
+			SET
+				Roles = TRIM(REPLACE(CONCAT(' ', Roles, ' '), :OldRole, :NewRole))
+			WHERE
+				CONCAT(' ', Roles, ' ') LIKE :Pattern`,
+			map[string]interface{}{"OldRole": " " + oldRoleId + " ", "NewRole": " " + newRoleId + " ", "Pattern": "% " + oldRoleId + " %"}); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.RenameMemberRole", "store.sql_channel.rename_member_role.app_error", nil, "old_role_id="+oldRoleId+", new_role_id="+newRoleId+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data, _ = sqlResult.RowsAffected()
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlChannelStore) RemoveMember(channelId string, userId string) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
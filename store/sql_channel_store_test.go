@@ -1443,6 +1443,109 @@ func TestGetMemberCount(t *testing.T) {
 	}
 }
 
+func TestChannelStoreGetDeletedForUser(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+
+	c1 := model.Channel{
+		TeamId:      teamId,
+		DisplayName: "Channel1",
+		Name:        "a" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	}
+	Must(store.Channel().Save(&c1))
+
+	c2 := model.Channel{
+		TeamId:      teamId,
+		DisplayName: "Channel2",
+		Name:        "a" + model.NewId() + "b",
+		Type:        model.CHANNEL_PRIVATE,
+	}
+	Must(store.Channel().Save(&c2))
+
+	userId := model.NewId()
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	Must(store.Channel().Delete(c1.Id, model.GetMillis()))
+	Must(store.Channel().Delete(c2.Id, model.GetMillis()))
+
+	if result := <-store.Channel().GetDeletedForUser(teamId, userId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if list := *result.Data.(*model.ChannelList); len(list) != 1 || list[0].Id != c1.Id {
+		t.Fatal("should only have returned the deleted channel that the user is a member of")
+	}
+
+	Must(store.Channel().Restore(c1.Id, model.GetMillis()))
+
+	if result := <-store.Channel().GetDeletedForUser(teamId, userId); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if list := *result.Data.(*model.ChannelList); len(list) != 0 {
+		t.Fatal("shouldn't have returned the restored channel")
+	}
+
+	if result := <-store.Channel().Get(c1.Id, false); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.Channel).DeleteAt != 0 {
+		t.Fatal("channel should have been restored")
+	}
+}
+
+func TestChannelStoreUsersShareChannel(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+
+	c1 := model.Channel{
+		TeamId:      teamId,
+		DisplayName: "Channel1",
+		Name:        "a" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	}
+	Must(store.Channel().Save(&c1))
+
+	u1 := &model.User{Email: model.NewId()}
+	Must(store.User().Save(u1))
+
+	u2 := &model.User{Email: model.NewId()}
+	Must(store.User().Save(u2))
+
+	u3 := &model.User{Email: model.NewId()}
+	Must(store.User().Save(u3))
+
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: u1.Id, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: u2.Id, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	if result := <-store.Channel().UsersShareChannel(u1.Id, u2.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if !result.Data.(bool) {
+		t.Fatal("users should share a channel")
+	}
+
+	if result := <-store.Channel().UsersShareChannel(u1.Id, u3.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(bool) {
+		t.Fatal("users shouldn't share a channel")
+	}
+
+	// Direct channels don't count
+	Must(store.Channel().CreateDirectChannel(u1.Id, u3.Id))
+
+	if result := <-store.Channel().UsersShareChannel(u1.Id, u3.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(bool) {
+		t.Fatal("users shouldn't share a channel")
+	}
+
+	Must(store.Channel().Delete(c1.Id, model.GetMillis()))
+
+	if result := <-store.Channel().UsersShareChannel(u1.Id, u2.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(bool) {
+		t.Fatal("users shouldn't share a deleted channel")
+	}
+}
+
 func TestUpdateExtrasByUser(t *testing.T) {
 	Setup()
 
@@ -1715,6 +1818,45 @@ func TestChannelStoreGetMembersByIds(t *testing.T) {
 	}
 }
 
+func TestChannelStoreRenameMemberRole(t *testing.T) {
+	Setup()
+
+	o1 := model.Channel{}
+	o1.TeamId = model.NewId()
+	o1.DisplayName = "ChannelA"
+	o1.Name = "a" + model.NewId() + "b"
+	o1.Type = model.CHANNEL_OPEN
+	Must(store.Channel().Save(&o1))
+
+	m1 := &model.ChannelMember{ChannelId: o1.Id, UserId: model.NewId(), Roles: "guest", NotifyProps: model.GetDefaultChannelNotifyProps()}
+	Must(store.Channel().SaveMember(m1))
+
+	m2 := &model.ChannelMember{ChannelId: o1.Id, UserId: model.NewId(), Roles: "channel_user guest custom_guest", NotifyProps: model.GetDefaultChannelNotifyProps()}
+	Must(store.Channel().SaveMember(m2))
+
+	m3 := &model.ChannelMember{ChannelId: o1.Id, UserId: model.NewId(), Roles: "channel_user custom_guest", NotifyProps: model.GetDefaultChannelNotifyProps()}
+	Must(store.Channel().SaveMember(m3))
+
+	if r := <-store.Channel().RenameMemberRole("guest", "channel_guest"); r.Err != nil {
+		t.Fatal(r.Err)
+	}
+
+	for _, expected := range []struct {
+		member *model.ChannelMember
+		roles  string
+	}{
+		{m1, "channel_guest"},
+		{m2, "channel_user channel_guest custom_guest"},
+		{m3, "channel_user custom_guest"},
+	} {
+		if r := <-store.Channel().GetMember(o1.Id, expected.member.UserId); r.Err != nil {
+			t.Fatal(r.Err)
+		} else if roles := r.Data.(*model.ChannelMember).Roles; roles != expected.roles {
+			t.Fatal("should have renamed only the whole role", roles)
+		}
+	}
+}
+
 func TestChannelStoreAnalyticsDeletedTypeCount(t *testing.T) {
 	Setup()
 
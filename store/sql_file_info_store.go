@@ -257,3 +257,97 @@ func (fs SqlFileInfoStore) DeleteForPost(postId string) StoreChannel {
 
 	return storeChannel
 }
+
+// GetForPostIds returns the file infos attached to the given posts, including ones that have been deleted.
+func (fs SqlFileInfoStore) GetForPostIds(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		infos := []*model.FileInfo{}
+
+		if len(postIds) > 0 {
+			props := make(map[string]interface{})
+			idQuery := buildIdsQuery("postId", postIds, props)
+
+			if _, err := fs.GetMaster().Select(&infos, "SELECT * FROM FileInfo WHERE PostId IN ("+idQuery+")", props); err != nil {
+				result.Err = model.NewAppError("SqlFileInfoStore.GetForPostIds", "store.sql_file_info.get_for_post_ids.app_error", nil, err.Error(), http.StatusInternalServerError)
+			}
+		}
+
+		if result.Err == nil {
+			result.Data = infos
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetForDataRetention returns up to limit file infos that were created before endTime, oldest first. teamId and
+// excludedTeamIds select channels in the same way as they do for PostStore.GetIdsForDataRetention. Files that were
+// never attached to a post are only returned when no team is specified.
+func (fs SqlFileInfoStore) GetForDataRetention(endTime int64, teamId string, excludedTeamIds []string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"EndTime": endTime, "Limit": limit}
+		join := dataRetentionChannelJoin(teamId)
+		query := `SELECT
+				FileInfo.*
+			FROM
+				FileInfo
+				` + join + ` JOIN Posts ON FileInfo.PostId = Posts.Id
+				` + join + ` JOIN Channels ON Posts.ChannelId = Channels.Id
+			WHERE
+				FileInfo.CreateAt < :EndTime
+				` + dataRetentionTeamFilter(teamId, excludedTeamIds, props) + `
+			ORDER BY
+				FileInfo.CreateAt
+			LIMIT :Limit`
+
+		var infos []*model.FileInfo
+
+		if _, err := fs.GetMaster().Select(&infos, query, props); err != nil {
+			result.Err = model.NewAppError("SqlFileInfoStore.GetForDataRetention", "store.sql_file_info.get_for_data_retention.app_error", nil, "team_id="+teamId+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = infos
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (fs SqlFileInfoStore) PermanentDeleteByIds(fileIds []string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(fileIds) > 0 {
+			props := make(map[string]interface{})
+			idQuery := buildIdsQuery("fileId", fileIds, props)
+
+			if sqlResult, err := fs.GetMaster().Exec("DELETE FROM FileInfo WHERE Id IN ("+idQuery+")", props); err != nil {
+				result.Err = model.NewAppError("SqlFileInfoStore.PermanentDeleteByIds", "store.sql_file_info.permanent_delete_by_ids.app_error", nil, err.Error(), http.StatusInternalServerError)
+			} else {
+				result.Data, _ = sqlResult.RowsAffected()
+			}
+		} else {
+			result.Data = int64(0)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -218,3 +218,57 @@ func TestFileInfoDeleteForPost(t *testing.T) {
 		t.Fatal("shouldn't have returned any file infos")
 	}
 }
+
+func TestFileInfoDataRetention(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	channel := Must(store.Channel().Save(&model.Channel{
+		TeamId:      teamId,
+		DisplayName: "DisplayName",
+		Name:        "zz" + model.NewId(),
+		Type:        model.CHANNEL_OPEN,
+	})).(*model.Channel)
+
+	post := Must(store.Post().Save(&model.Post{
+		ChannelId: channel.Id,
+		UserId:    model.NewId(),
+		Message:   "message",
+	})).(*model.Post)
+
+	oldInfo := Must(store.FileInfo().Save(&model.FileInfo{
+		CreatorId: post.UserId,
+		PostId:    post.Id,
+		Path:      "file.txt",
+		CreateAt:  1000,
+	})).(*model.FileInfo)
+
+	newInfo := Must(store.FileInfo().Save(&model.FileInfo{
+		CreatorId: post.UserId,
+		PostId:    post.Id,
+		Path:      "file.txt",
+		CreateAt:  3000,
+	})).(*model.FileInfo)
+
+	if result := <-store.FileInfo().GetForDataRetention(2000, teamId, nil, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if infos := result.Data.([]*model.FileInfo); len(infos) != 1 || infos[0].Id != oldInfo.Id {
+		t.Fatal("should've only returned the old file")
+	}
+
+	if result := <-store.FileInfo().GetForPostIds([]string{post.Id}); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if infos := result.Data.([]*model.FileInfo); len(infos) != 2 {
+		t.Fatal("should've returned both files")
+	}
+
+	if result := <-store.FileInfo().PermanentDeleteByIds([]string{oldInfo.Id, newInfo.Id}); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if deleted := result.Data.(int64); deleted != 2 {
+		t.Fatal("should've deleted both files")
+	}
+
+	if result := <-store.FileInfo().Get(oldInfo.Id); result.Err == nil {
+		t.Fatal("file should be gone")
+	}
+}
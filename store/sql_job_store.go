@@ -0,0 +1,316 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/primefour/servers/model"
+)
+
+type SqlJobStore struct {
+	*SqlStore
+}
+
+func NewSqlJobStore(sqlStore *SqlStore) JobStore {
+	s := &SqlJobStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Job{}, "Jobs").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("Type").SetMaxSize(32)
+		table.ColMap("Status").SetMaxSize(32)
+		table.ColMap("Data").SetMaxSize(model.JOB_DATA_MAX_LENGTH)
+	}
+
+	return s
+}
+
+func (jss SqlJobStore) CreateIndexesIfNotExists() {
+	jss.CreateIndexIfNotExists("idx_jobs_type", "Jobs", "Type")
+	jss.CreateIndexIfNotExists("idx_jobs_status", "Jobs", "Status")
+}
+
+func (jss SqlJobStore) Save(job *model.Job) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if result.Err = job.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := jss.GetMaster().Insert(job); err != nil {
+			result.Err = model.NewAppError("SqlJobStore.Save", "store.sql_job.save.app_error", nil, "id="+job.Id+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = job
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdateOptimistically saves the status, progress and data of a job, but only if its status in the database is still
+// currentStatus. The result's Data is true if the job was updated.
+func (jss SqlJobStore) UpdateOptimistically(job *model.Job, currentStatus string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := jss.GetMaster().Exec(
+			`UPDATE
+				Jobs
+			SET
+				LastActivityAt = :LastActivityAt,
+				Status = :Status,
+				Progress = :Progress,
+				Data = :Data
+			WHERE
+				Id = :Id
+				AND Status = :OldStatus`,
+			map[string]interface{}{
+				"Id":             job.Id,
+				"OldStatus":      currentStatus,
+				"LastActivityAt": model.GetMillis(),
+				"Status":         job.Status,
+				"Progress":       job.Progress,
+				"Data":           model.MapToJson(job.Data),
+			}); err != nil {
+			result.Err = model.NewAppError("SqlJobStore.UpdateOptimistically", "store.sql_job.update.app_error", nil, "id="+job.Id+", "+err.Error(), http.StatusInternalServerError)
+		} else if rows, err := sqlResult.RowsAffected(); err != nil {
+			result.Err = model.NewAppError("SqlJobStore.UpdateOptimistically", "store.sql_job.update.app_error", nil, "id="+job.Id+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = rows == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (jss SqlJobStore) UpdateStatus(id string, status string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := jss.GetMaster().Exec(
+			"UPDATE Jobs SET Status = :Status, LastActivityAt = :LastActivityAt WHERE Id = :Id",
+			map[string]interface{}{"Id": id, "Status": status, "LastActivityAt": model.GetMillis()}); err != nil {
+			result.Err = model.NewAppError("SqlJobStore.UpdateStatus", "store.sql_job.update.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = true
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdateStatusOptimistically changes the status of a job, but only if its status in the database is still
+// currentStatus. The result's Data is true if the status was changed. Moving a job to in progress also sets its StartAt,
+// while leaving the status as it is only updates its LastActivityAt.
+func (jss SqlJobStore) UpdateStatusOptimistically(id string, currentStatus string, newStatus string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		now := model.GetMillis()
+
+		query := "UPDATE Jobs SET Status = :NewStatus, LastActivityAt = :LastActivityAt WHERE Id = :Id AND Status = :OldStatus"
+		if newStatus == model.JOB_STATUS_IN_PROGRESS && currentStatus != model.JOB_STATUS_IN_PROGRESS {
+			query = "UPDATE Jobs SET Status = :NewStatus, StartAt = :LastActivityAt, LastActivityAt = :LastActivityAt WHERE Id = :Id AND Status = :OldStatus"
+		}
+
+		if sqlResult, err := jss.GetMaster().Exec(query, map[string]interface{}{"Id": id, "OldStatus": currentStatus, "NewStatus": newStatus, "LastActivityAt": now}); err != nil {
+			result.Err = model.NewAppError("SqlJobStore.UpdateStatusOptimistically", "store.sql_job.update.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+		} else if rows, err := sqlResult.RowsAffected(); err != nil {
+			result.Err = model.NewAppError("SqlJobStore.UpdateStatusOptimistically", "store.sql_job.update.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = rows == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (jss SqlJobStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var job model.Job
+
+		if err := jss.GetMaster().SelectOne(&job, "SELECT * FROM Jobs WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlJobStore.Get", "store.sql_job.get.app_error", nil, "id="+id+", "+err.Error(), http.StatusNotFound)
+			} else {
+				result.Err = model.NewAppError("SqlJobStore.Get", "store.sql_job.get.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+			}
+		} else {
+			result.Data = &job
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (jss SqlJobStore) GetAllPage(offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var jobs []*model.Job
+
+		if _, err := jss.GetReplica().Select(&jobs,
+			"SELECT * FROM Jobs ORDER BY CreateAt DESC LIMIT :Limit OFFSET :Offset",
+			map[string]interface{}{"Limit": limit, "Offset": offset}); err != nil {
+			result.Err = model.NewAppError("SqlJobStore.GetAllPage", "store.sql_job.get_all.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = jobs
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (jss SqlJobStore) GetAllByTypePage(jobType string, offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var jobs []*model.Job
+
+		if _, err := jss.GetReplica().Select(&jobs,
+			"SELECT * FROM Jobs WHERE Type = :Type ORDER BY CreateAt DESC LIMIT :Limit OFFSET :Offset",
+			map[string]interface{}{"Type": jobType, "Limit": limit, "Offset": offset}); err != nil {
+			result.Err = model.NewAppError("SqlJobStore.GetAllByTypePage", "store.sql_job.get_all.app_error", nil, "type="+jobType+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = jobs
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetAllByStatus returns the jobs with the given status, oldest first.
+func (jss SqlJobStore) GetAllByStatus(status string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var jobs []*model.Job
+
+		if _, err := jss.GetMaster().Select(&jobs,
+			"SELECT * FROM Jobs WHERE Status = :Status ORDER BY Priority DESC, CreateAt ASC",
+			map[string]interface{}{"Status": status}); err != nil {
+			result.Err = model.NewAppError("SqlJobStore.GetAllByStatus", "store.sql_job.get_all.app_error", nil, "status="+status+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = jobs
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetNewestJobByStatusAndType returns the most recently created job with the given status and type. The result's Data
+// is nil if there isn't one.
+func (jss SqlJobStore) GetNewestJobByStatusAndType(status string, jobType string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var job model.Job
+
+		if err := jss.GetMaster().SelectOne(&job,
+			"SELECT * FROM Jobs WHERE Status = :Status AND Type = :Type ORDER BY CreateAt DESC LIMIT 1",
+			map[string]interface{}{"Status": status, "Type": jobType}); err != nil {
+			if err != sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlJobStore.GetNewestJobByStatusAndType", "store.sql_job.get_newest_job_by_status_and_type.app_error", nil, "status="+status+", type="+jobType+", "+err.Error(), http.StatusInternalServerError)
+			} else {
+				result.Data = (*model.Job)(nil)
+			}
+		} else {
+			result.Data = &job
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (jss SqlJobStore) GetCountByStatusAndType(status string, jobType string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if count, err := jss.GetMaster().SelectInt(
+			"SELECT COUNT(*) FROM Jobs WHERE Status = :Status AND Type = :Type",
+			map[string]interface{}{"Status": status, "Type": jobType}); err != nil {
+			result.Err = model.NewAppError("SqlJobStore.GetCountByStatusAndType", "store.sql_job.get_count_by_status_and_type.app_error", nil, "status="+status+", type="+jobType+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = count
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (jss SqlJobStore) Delete(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := jss.GetMaster().Exec("DELETE FROM Jobs WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewAppError("SqlJobStore.Delete", "store.sql_job.delete.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = id
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/primefour/servers/model"
+)
+
+func TestJobSaveGet(t *testing.T) {
+	Setup()
+
+	job := &model.Job{
+		Id:       model.NewId(),
+		Type:     model.JOB_TYPE_BULK_IMPORT,
+		CreateAt: model.GetMillis(),
+		Status:   model.JOB_STATUS_PENDING,
+		Data: model.StringMap{
+			"file_id": model.NewId(),
+		},
+	}
+
+	if result := <-store.Job().Save(job); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	defer func() {
+		<-store.Job().Delete(job.Id)
+	}()
+
+	if result := <-store.Job().Get(job.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.Job); received.Id != job.Id || received.Data["file_id"] != job.Data["file_id"] {
+		t.Fatal("should have received the saved job")
+	}
+
+	if result := <-store.Job().Get(model.NewId()); result.Err == nil {
+		t.Fatal("shouldn't have found a missing job")
+	}
+}
+
+func TestJobGetAllByType(t *testing.T) {
+	Setup()
+
+	jobType := model.JOB_TYPE_COMPLIANCE_EXPORT
+
+	jobs := []*model.Job{
+		{
+			Id:       model.NewId(),
+			Type:     jobType,
+			CreateAt: model.GetMillis() + 1000,
+			Status:   model.JOB_STATUS_PENDING,
+		},
+		{
+			Id:       model.NewId(),
+			Type:     jobType,
+			CreateAt: model.GetMillis() + 2000,
+			Status:   model.JOB_STATUS_PENDING,
+		},
+		{
+			Id:       model.NewId(),
+			Type:     model.JOB_TYPE_LDAP_SYNC,
+			CreateAt: model.GetMillis() + 3000,
+			Status:   model.JOB_STATUS_PENDING,
+		},
+	}
+
+	for _, job := range jobs {
+		Must(store.Job().Save(job))
+		defer store.Job().Delete(job.Id)
+	}
+
+	if result := <-store.Job().GetAllByTypePage(jobType, 0, 2); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.([]*model.Job); len(received) != 2 {
+		t.Fatal("received wrong number of jobs")
+	} else if received[0].Id != jobs[1].Id || received[1].Id != jobs[0].Id {
+		t.Fatal("should've received newest job first")
+	}
+
+	if result := <-store.Job().GetAllPage(0, 1); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.([]*model.Job); len(received) != 1 || received[0].Id != jobs[2].Id {
+		t.Fatal("should've received newest job of any type")
+	}
+}
+
+func TestJobGetNewestAndCountByStatusAndType(t *testing.T) {
+	Setup()
+
+	jobType := model.JOB_TYPE_LDAP_SYNC
+	status := model.JOB_STATUS_CANCEL_REQUESTED
+
+	jobs := []*model.Job{
+		{
+			Id:       model.NewId(),
+			Type:     jobType,
+			CreateAt: 1001,
+			Status:   status,
+		},
+		{
+			Id:       model.NewId(),
+			Type:     jobType,
+			CreateAt: 1002,
+			Status:   status,
+		},
+	}
+
+	for _, job := range jobs {
+		Must(store.Job().Save(job))
+		defer store.Job().Delete(job.Id)
+	}
+
+	if result := <-store.Job().GetNewestJobByStatusAndType(status, jobType); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.Job); received == nil || received.Id != jobs[1].Id {
+		t.Fatal("should've received the newest job")
+	}
+
+	if result := <-store.Job().GetNewestJobByStatusAndType(model.JOB_STATUS_CANCELED, model.JOB_TYPE_BULK_IMPORT); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.Job); received != nil && received.Id == jobs[0].Id {
+		t.Fatal("shouldn't have received a job with another status and type")
+	}
+
+	if result := <-store.Job().GetCountByStatusAndType(status, jobType); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if count := result.Data.(int64); count < 2 {
+		t.Fatal("should've counted both jobs")
+	}
+}
+
+func TestJobUpdateOptimistically(t *testing.T) {
+	Setup()
+
+	job := &model.Job{
+		Id:       model.NewId(),
+		Type:     model.JOB_TYPE_BULK_IMPORT,
+		CreateAt: model.GetMillis(),
+		Status:   model.JOB_STATUS_PENDING,
+	}
+
+	Must(store.Job().Save(job))
+	defer store.Job().Delete(job.Id)
+
+	if result := <-store.Job().UpdateStatusOptimistically(job.Id, model.JOB_STATUS_IN_PROGRESS, model.JOB_STATUS_SUCCESS); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(bool) {
+		t.Fatal("shouldn't have updated a job with a different status")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	if result := <-store.Job().UpdateStatusOptimistically(job.Id, model.JOB_STATUS_PENDING, model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if !result.Data.(bool) {
+		t.Fatal("should have claimed the job")
+	}
+
+	received := Must(store.Job().Get(job.Id)).(*model.Job)
+	if received.Status != model.JOB_STATUS_IN_PROGRESS || received.StartAt == 0 {
+		t.Fatal("should have started the job")
+	}
+
+	received.Progress = 50
+	received.Data = model.StringMap{"key": "value"}
+
+	if result := <-store.Job().UpdateOptimistically(received, model.JOB_STATUS_PENDING); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(bool) {
+		t.Fatal("shouldn't have updated a job with a different status")
+	}
+
+	if result := <-store.Job().UpdateOptimistically(received, model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if !result.Data.(bool) {
+		t.Fatal("should have updated the job")
+	}
+
+	received = Must(store.Job().Get(job.Id)).(*model.Job)
+	if received.Progress != 50 || received.Data["key"] != "value" {
+		t.Fatal("should have saved the progress and data")
+	}
+
+	if result := <-store.Job().UpdateStatus(job.Id, model.JOB_STATUS_SUCCESS); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Job().GetAllByStatus(model.JOB_STATUS_SUCCESS); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		found := false
+		for _, j := range result.Data.([]*model.Job) {
+			if j.Id == job.Id {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("should have found the finished job")
+		}
+	}
+}
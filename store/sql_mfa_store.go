@@ -0,0 +1,152 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+
+	"github.com/primefour/servers/model"
+)
+
+type SqlMfaStore struct {
+	*SqlStore
+}
+
+func NewSqlMfaStore(sqlStore *SqlStore) MfaStore {
+	s := &SqlMfaStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.MfaRecoveryCode{}, "MfaRecoveryCodes").SetKeys(false, "UserId", "CodeHash")
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("CodeHash").SetMaxSize(64)
+
+		table = db.AddTableWithName(model.MfaUsedTimeStep{}, "MfaUsedTimeSteps").SetKeys(false, "UserId")
+		table.ColMap("UserId").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlMfaStore) CreateIndexesIfNotExists() {
+}
+
+// SaveRecoveryCodes replaces all of a user's recovery codes with the given ones.
+func (s SqlMfaStore) SaveRecoveryCodes(userId string, codes []*model.MfaRecoveryCode) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		for _, code := range codes {
+			code.UserId = userId
+			code.PreSave()
+			if result.Err = code.IsValid(); result.Err != nil {
+				storeChannel <- result
+				close(storeChannel)
+				return
+			}
+		}
+
+		if transaction, err := s.GetMaster().Begin(); err != nil {
+			result.Err = model.NewAppError("SqlMfaStore.SaveRecoveryCodes", "store.sql_mfa.save_recovery_codes.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			if _, err := transaction.Exec("DELETE FROM MfaRecoveryCodes WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+				transaction.Rollback()
+				result.Err = model.NewAppError("SqlMfaStore.SaveRecoveryCodes", "store.sql_mfa.save_recovery_codes.app_error", nil, "user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+			} else {
+				for _, code := range codes {
+					if err := transaction.Insert(code); err != nil {
+						transaction.Rollback()
+						result.Err = model.NewAppError("SqlMfaStore.SaveRecoveryCodes", "store.sql_mfa.save_recovery_codes.app_error", nil, "user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+						break
+					}
+				}
+
+				if result.Err == nil {
+					if err := transaction.Commit(); err != nil {
+						result.Err = model.NewAppError("SqlMfaStore.SaveRecoveryCodes", "store.sql_mfa.save_recovery_codes.app_error", nil, "user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+					} else {
+						result.Data = codes
+					}
+				}
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UseRecoveryCode deletes the recovery code with the given hash. The result's data is true if the code existed, and
+// since the code is deleted by the same statement, it can only ever be used once.
+func (s SqlMfaStore) UseRecoveryCode(userId string, codeHash string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("DELETE FROM MfaRecoveryCodes WHERE UserId = :UserId AND CodeHash = :CodeHash", map[string]interface{}{"UserId": userId, "CodeHash": codeHash}); err != nil {
+			result.Err = model.NewAppError("SqlMfaStore.UseRecoveryCode", "store.sql_mfa.use_recovery_code.app_error", nil, "user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+		} else if rows, err := sqlResult.RowsAffected(); err != nil {
+			result.Err = model.NewAppError("SqlMfaStore.UseRecoveryCode", "store.sql_mfa.use_recovery_code.app_error", nil, "user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = rows == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// SaveUsedTimeStep records that a user has logged in with the token for a time step. It fails with a conflict if the
+// user has already logged in with the token for that time step or a later one.
+func (s SqlMfaStore) SaveUsedTimeStep(userId string, timeStep int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("UPDATE MfaUsedTimeSteps SET TimeStep = :TimeStep WHERE UserId = :UserId AND TimeStep < :TimeStep", map[string]interface{}{"UserId": userId, "TimeStep": timeStep}); err != nil {
+			result.Err = model.NewAppError("SqlMfaStore.SaveUsedTimeStep", "store.sql_mfa.save_used_time_step.app_error", nil, "user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+		} else if rows, _ := sqlResult.RowsAffected(); rows == 0 {
+			// Either this is the first time that the user has logged in or the time step isn't after the last one used
+			if err := s.GetMaster().Insert(&model.MfaUsedTimeStep{UserId: userId, TimeStep: timeStep}); err != nil {
+				if IsUniqueConstraintError(err.Error(), []string{"PRIMARY", "mfausedtimesteps_pkey"}) {
+					result.Err = model.NewAppError("SqlMfaStore.SaveUsedTimeStep", "store.sql_mfa.save_used_time_step.exists.app_error", nil, "user_id="+userId, http.StatusConflict)
+				} else {
+					result.Err = model.NewAppError("SqlMfaStore.SaveUsedTimeStep", "store.sql_mfa.save_used_time_step.app_error", nil, "user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+				}
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DeleteAllForUser removes a user's recovery codes and used time steps.
+func (s SqlMfaStore) DeleteAllForUser(userId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM MfaRecoveryCodes WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewAppError("SqlMfaStore.DeleteAllForUser", "store.sql_mfa.delete_all_for_user.app_error", nil, "user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+		} else if _, err := s.GetMaster().Exec("DELETE FROM MfaUsedTimeSteps WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewAppError("SqlMfaStore.DeleteAllForUser", "store.sql_mfa.delete_all_for_user.app_error", nil, "user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/primefour/servers/model"
+)
+
+func TestMfaStoreRecoveryCodes(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+	defer func() {
+		<-store.Mfa().DeleteAllForUser(userId)
+	}()
+
+	code1 := model.HashMfaRecoveryCode(model.NewMfaRecoveryCode())
+	code2 := model.HashMfaRecoveryCode(model.NewMfaRecoveryCode())
+
+	Must(store.Mfa().SaveRecoveryCodes(userId, []*model.MfaRecoveryCode{{CodeHash: code1}, {CodeHash: code2}}))
+
+	if used := Must(store.Mfa().UseRecoveryCode(userId, code1)).(bool); !used {
+		t.Fatal("should've been able to use the code")
+	}
+
+	if used := Must(store.Mfa().UseRecoveryCode(userId, code1)).(bool); used {
+		t.Fatal("shouldn't have been able to use the code twice")
+	}
+
+	if used := Must(store.Mfa().UseRecoveryCode(model.NewId(), code2)).(bool); used {
+		t.Fatal("shouldn't have been able to use another user's code")
+	}
+
+	code3 := model.HashMfaRecoveryCode(model.NewMfaRecoveryCode())
+	Must(store.Mfa().SaveRecoveryCodes(userId, []*model.MfaRecoveryCode{{CodeHash: code3}}))
+
+	if used := Must(store.Mfa().UseRecoveryCode(userId, code2)).(bool); used {
+		t.Fatal("saving new codes should've replaced the old ones")
+	}
+
+	if result := <-store.Mfa().SaveRecoveryCodes(userId, []*model.MfaRecoveryCode{{CodeHash: "junk"}}); result.Err == nil {
+		t.Fatal("shouldn't have saved an invalid code")
+	}
+
+	Must(store.Mfa().DeleteAllForUser(userId))
+
+	if used := Must(store.Mfa().UseRecoveryCode(userId, code3)).(bool); used {
+		t.Fatal("codes should've been deleted")
+	}
+}
+
+func TestMfaStoreUsedTimeSteps(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+	defer func() {
+		<-store.Mfa().DeleteAllForUser(userId)
+	}()
+
+	Must(store.Mfa().SaveUsedTimeStep(userId, 100))
+
+	if result := <-store.Mfa().SaveUsedTimeStep(userId, 100); result.Err == nil {
+		t.Fatal("shouldn't have been able to use a time step twice")
+	} else if result.Err.StatusCode != http.StatusConflict {
+		t.Fatal("should've returned a conflict", result.Err)
+	}
+
+	otherUserId := model.NewId()
+	defer func() {
+		<-store.Mfa().DeleteAllForUser(otherUserId)
+	}()
+
+	Must(store.Mfa().SaveUsedTimeStep(otherUserId, 100))
+	Must(store.Mfa().SaveUsedTimeStep(userId, 102))
+
+	// An older time step can't be used once a newer one has been
+	if result := <-store.Mfa().SaveUsedTimeStep(userId, 101); result.Err == nil {
+		t.Fatal("shouldn't have been able to use an older time step")
+	} else if result.Err.StatusCode != http.StatusConflict {
+		t.Fatal("should've returned a conflict", result.Err)
+	}
+
+	Must(store.Mfa().SaveUsedTimeStep(userId, 103))
+}
@@ -902,6 +902,9 @@ var specialSearchChar = []string{
 	":",
 }
 
+// searchExclusionTerm matches the quoted phrases and words in the excluded terms of a search
+var searchExclusionTerm = regexp.MustCompile(`"[^"]*"|\S+`)
+
 func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchParams) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -927,7 +930,7 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 		termMap := map[string]bool{}
 		terms := params.Terms
 
-		if terms == "" && len(params.InChannels) == 0 && len(params.FromUsers) == 0 {
+		if terms == "" && !params.IsFiltered() {
 			result.Data = []*model.Post{}
 			storeChannel <- result
 			close(storeChannel)
@@ -958,6 +961,7 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 				DeleteAt = 0
 				AND Type NOT LIKE '` + model.POST_SYSTEM_MESSAGE_PREFIX + `%'
 				POST_FILTER
+				POST_MODIFIERS
 				AND ChannelId IN (
 					SELECT
 						Id
@@ -968,9 +972,10 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 						Id = ChannelId
 							AND (TeamId = :TeamId OR TeamId = '')
 							AND UserId = :UserId
-							AND DeleteAt = 0
-							CHANNEL_FILTER)
+							CHANNEL_FILTER
+							CHANNEL_TYPE_FILTER)
 				SEARCH_CLAUSE
+				EXCLUSION_CLAUSE
 				ORDER BY CreateAt DESC
 			LIMIT 100`
 
@@ -1030,6 +1035,15 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 			searchQuery = strings.Replace(searchQuery, "POST_FILTER", "", 1)
 		}
 
+		if params.InDirectChannels {
+			searchQuery = strings.Replace(searchQuery, "CHANNEL_TYPE_FILTER", "AND Type IN ('"+model.CHANNEL_DIRECT+"', '"+model.CHANNEL_GROUP+"')", 1)
+		} else {
+			searchQuery = strings.Replace(searchQuery, "CHANNEL_TYPE_FILTER", "", 1)
+		}
+
+		searchQuery = strings.Replace(searchQuery, "POST_MODIFIERS", buildSearchModifiers(params, queryParams), 1)
+		searchQuery = strings.Replace(searchQuery, "EXCLUSION_CLAUSE", buildSearchExclusionClause(searchType, params.ExcludedTerms, queryParams), 1)
+
 		if terms == "" {
 			// we've already confirmed that we have a channel or user to search for
 			searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", "", 1)
@@ -1098,6 +1112,93 @@ func (s SqlPostStore) Search(teamId string, userId string, params *model.SearchP
 	return storeChannel
 }
 
+// buildSearchModifiers returns the conditions on posts for the date, has: and is: modifiers of a search and adds
+// their parameters to queryParams.
+func buildSearchModifiers(params *model.SearchParams, queryParams map[string]interface{}) string {
+	modifiers := ""
+
+	if start, end := params.GetOnDateMillis(); start != 0 {
+		modifiers += " AND CreateAt >= :OnDateStart AND CreateAt < :OnDateEnd"
+		queryParams["OnDateStart"] = start
+		queryParams["OnDateEnd"] = end
+	}
+
+	if before := params.GetBeforeDateMillis(); before != 0 {
+		modifiers += " AND CreateAt < :BeforeDate"
+		queryParams["BeforeDate"] = before
+	}
+
+	if after := params.GetAfterDateMillis(); after != 0 {
+		modifiers += " AND CreateAt >= :AfterDate"
+		queryParams["AfterDate"] = after
+	}
+
+	if params.HasFile {
+		modifiers += " AND (FileIds != '[]' OR Filenames != '[]')"
+	}
+
+	if params.HasLink {
+		modifiers += " AND (Message LIKE :HttpLink OR Message LIKE :HttpsLink)"
+		queryParams["HttpLink"] = "%http://%"
+		queryParams["HttpsLink"] = "%https://%"
+	}
+
+	if params.IsPinned {
+		modifiers += " AND IsPinned = :IsPinned"
+		queryParams["IsPinned"] = true
+	}
+
+	return modifiers
+}
+
+// buildSearchExclusionClause returns a condition that filters out posts matching any of the excluded terms and adds
+// them to queryParams.
+func buildSearchExclusionClause(searchType string, excludedTerms string, queryParams map[string]interface{}) string {
+	terms := []string{}
+
+	for _, term := range searchExclusionTerm.FindAllString(excludedTerms, -1) {
+		isPhrase := strings.HasPrefix(term, "\"")
+
+		// these chars have special meaning and can be treated as spaces
+		for _, c := range specialSearchChar {
+			term = strings.Replace(term, c, " ", -1)
+		}
+		term = strings.Trim(term, "\" ")
+
+		if term == "" {
+			continue
+		}
+
+		if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+			if strings.HasSuffix(term, "*") {
+				term = strings.TrimSuffix(term, "*") + ":*"
+			}
+
+			if isPhrase {
+				term = "(" + strings.Join(strings.Fields(term), " & ") + ")"
+			}
+		} else if isPhrase {
+			term = "\"" + term + "\""
+		}
+
+		terms = append(terms, term)
+	}
+
+	if len(terms) == 0 {
+		return ""
+	}
+
+	if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+		queryParams["ExcludedTerms"] = strings.Join(terms, " | ")
+		return fmt.Sprintf("AND NOT %s @@ to_tsquery(:ExcludedTerms)", searchType)
+	} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_MYSQL {
+		queryParams["ExcludedTerms"] = strings.Join(terms, " ")
+		return fmt.Sprintf("AND NOT MATCH (%s) AGAINST (:ExcludedTerms IN BOOLEAN MODE)", searchType)
+	}
+
+	return ""
+}
+
 func (s SqlPostStore) AnalyticsUserCountsWithPostsByDay(teamId string) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -1287,3 +1388,256 @@ func (s SqlPostStore) GetPostsCreatedAt(channelId string, time int64) StoreChann
 
 	return storeChannel
 }
+
+// GetPostsForExport returns up to limit regular root posts with an id greater than afterId, ordered by id, along with
+// the names of the team, channel and user that they belong to. Deleted posts and posts in deleted or direct channels
+// are skipped.
+func (s SqlPostStore) GetPostsForExport(afterId string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		query := `
+			SELECT
+				p.*,
+				Teams.Name AS TeamName,
+				Channels.Name AS ChannelName,
+				Users.Username AS Username
+			FROM
+				Posts p
+			INNER JOIN Channels ON p.ChannelId = Channels.Id
+			INNER JOIN Teams ON Channels.TeamId = Teams.Id
+			INNER JOIN Users ON p.UserId = Users.Id
+			WHERE
+				p.Id > :AfterId
+				AND p.RootId = ''
+				AND p.DeleteAt = 0
+				AND p.Type = ''
+				AND Channels.DeleteAt = 0
+				AND Channels.Type IN ('O', 'P')
+				AND Teams.DeleteAt = 0
+			ORDER BY p.Id
+			LIMIT :Limit`
+
+		var posts []*model.PostForExport
+		if _, err := s.GetReplica().Select(&posts, query, map[string]interface{}{"AfterId": afterId, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetPostsForExport", "store.sql_post.get_posts_for_export.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = posts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetRepliesForExport returns the regular replies to a post, ordered by the time that they were made, along with the
+// usernames of the users that made them.
+func (s SqlPostStore) GetRepliesForExport(rootId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		query := `
+			SELECT
+				p.*,
+				Users.Username AS Username
+			FROM
+				Posts p
+			INNER JOIN Users ON p.UserId = Users.Id
+			WHERE
+				p.RootId = :RootId
+				AND p.DeleteAt = 0
+				AND p.Type = ''
+			ORDER BY p.CreateAt`
+
+		var posts []*model.PostForExport
+		if _, err := s.GetReplica().Select(&posts, query, map[string]interface{}{"RootId": rootId}); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetRepliesForExport", "store.sql_post.get_replies_for_export.app_error", nil, "rootId="+rootId+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = posts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetIdsForDataRetention returns the ids of up to limit posts that were created before endTime, oldest first. If teamId
+// is set, only posts in that team's channels are returned. Otherwise, posts in every channel are returned, including
+// direct and group channels, except for those in the channels of excludedTeamIds.
+func (s SqlPostStore) GetIdsForDataRetention(endTime int64, teamId string, excludedTeamIds []string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"EndTime": endTime, "Limit": limit}
+		query := `SELECT
+				Posts.Id
+			FROM
+				Posts
+				` + dataRetentionChannelJoin(teamId) + ` JOIN Channels ON Posts.ChannelId = Channels.Id
+			WHERE
+				Posts.CreateAt < :EndTime
+				` + dataRetentionTeamFilter(teamId, excludedTeamIds, props) + `
+			ORDER BY
+				Posts.CreateAt
+			LIMIT :Limit`
+
+		var postIds []string
+
+		// This reads from the master so that posts that were just deleted aren't returned again
+		if _, err := s.GetMaster().Select(&postIds, query, props); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetIdsForDataRetention", "store.sql_post.get_ids_for_data_retention.app_error", nil, "team_id="+teamId+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = postIds
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// PermanentDeleteByIds deletes the given posts. Posts are deleted by primary key so that deleting a batch of them
+// doesn't hold locks on the rest of the table.
+func (s SqlPostStore) PermanentDeleteByIds(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(postIds) > 0 {
+			props := make(map[string]interface{})
+			idQuery := buildIdsQuery("postId", postIds, props)
+
+			if sqlResult, err := s.GetMaster().Exec("DELETE FROM Posts WHERE Id IN ("+idQuery+")", props); err != nil {
+				result.Err = model.NewAppError("SqlPostStore.PermanentDeleteByIds", "store.sql_post.permanent_delete_by_ids.app_error", nil, err.Error(), http.StatusInternalServerError)
+			} else {
+				result.Data, _ = sqlResult.RowsAffected()
+			}
+		} else {
+			result.Data = int64(0)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// dataRetentionChannelJoin returns the type of join to Channels that's needed by dataRetentionTeamFilter. Posts in
+// channels that no longer exist are only included when no team is specified.
+func dataRetentionChannelJoin(teamId string) string {
+	if teamId != "" {
+		return "INNER"
+	}
+
+	return "LEFT"
+}
+
+// dataRetentionTeamFilter returns the conditions on Channels.TeamId for a data retention query and adds their
+// parameters to props.
+func dataRetentionTeamFilter(teamId string, excludedTeamIds []string, props map[string]interface{}) string {
+	if teamId != "" {
+		props["TeamId"] = teamId
+		return "AND Channels.TeamId = :TeamId"
+	}
+
+	if len(excludedTeamIds) == 0 {
+		return ""
+	}
+
+	return "AND (Channels.TeamId IS NULL OR Channels.TeamId NOT IN (" + buildIdsQuery("excludedTeamId", excludedTeamIds, props) + "))"
+}
+
+// buildIdsQuery returns a comma separated list of named parameters for use in an IN clause and adds the ids to props.
+func buildIdsQuery(prefix string, ids []string, props map[string]interface{}) string {
+	idQuery := ""
+
+	for index, id := range ids {
+		if len(idQuery) > 0 {
+			idQuery += ", "
+		}
+
+		props[prefix+strconv.Itoa(index)] = id
+		idQuery += ":" + prefix + strconv.Itoa(index)
+	}
+
+	return idQuery
+}
+
+func (s SqlPostStore) GetPostsByIds(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(postIds) == 0 {
+			result.Data = []*model.Post{}
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		props := make(map[string]interface{})
+		query := "SELECT * FROM Posts WHERE Id IN (" + buildIdsQuery("postId", postIds, props) + ") ORDER BY CreateAt DESC"
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts, query, props); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetPostsByIds", "store.sql_post.get_posts_by_ids.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = posts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetPostsForIndexing returns the posts that belong in the search index, ordered by id, along with the ids of the teams
+// that they were made in. Posts in direct and group channels have an empty team id.
+func (s SqlPostStore) GetPostsForIndexing(afterId string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		query := `
+			SELECT
+				p.*,
+				Channels.TeamId AS TeamId
+			FROM
+				Posts p
+			INNER JOIN Channels ON p.ChannelId = Channels.Id
+			WHERE
+				p.Id > :AfterId
+				AND p.DeleteAt = 0
+				AND p.Type NOT LIKE '` + model.POST_SYSTEM_MESSAGE_PREFIX + `%'
+			ORDER BY p.Id
+			LIMIT :Limit`
+
+		var posts []*model.PostForIndexing
+		if _, err := s.GetReplica().Select(&posts, query, map[string]interface{}{"AfterId": afterId, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetPostsForIndexing", "store.sql_post.get_posts_for_indexing.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = posts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -1550,3 +1550,306 @@ func TestPostStoreOverwrite(t *testing.T) {
 		t.Fatal("Failed to set FileIds")
 	}
 }
+
+func TestPostStoreGetPostsForExport(t *testing.T) {
+	Setup()
+
+	t1 := Must(store.Team().Save(&model.Team{
+		DisplayName: "DisplayName",
+		Name:        "a" + model.NewId() + "b",
+		Email:       model.NewId() + "@nowhere.com",
+		Type:        model.TEAM_OPEN,
+	})).(*model.Team)
+
+	c1 := Must(store.Channel().Save(&model.Channel{
+		TeamId:      t1.Id,
+		DisplayName: "DisplayName",
+		Name:        "a" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	})).(*model.Channel)
+
+	u1 := Must(store.User().Save(&model.User{
+		Email:    model.NewId() + "@nowhere.com",
+		Username: "u" + model.NewId(),
+	})).(*model.User)
+
+	p1 := Must(store.Post().Save(&model.Post{
+		ChannelId: c1.Id,
+		UserId:    u1.Id,
+		Message:   "a" + model.NewId() + "b",
+	})).(*model.Post)
+
+	p2 := Must(store.Post().Save(&model.Post{
+		ChannelId: c1.Id,
+		UserId:    u1.Id,
+		Message:   "a" + model.NewId() + "b",
+		Type:      model.POST_JOIN_CHANNEL,
+	})).(*model.Post)
+
+	p3 := Must(store.Post().Save(&model.Post{
+		ChannelId: c1.Id,
+		UserId:    u1.Id,
+		Message:   "a" + model.NewId() + "b",
+		RootId:    p1.Id,
+		ParentId:  p1.Id,
+	})).(*model.Post)
+
+	var found *model.PostForExport
+	afterId := ""
+	for {
+		posts := Must(store.Post().GetPostsForExport(afterId, 100)).([]*model.PostForExport)
+		if len(posts) == 0 {
+			break
+		}
+
+		for _, post := range posts {
+			if post.Id <= afterId {
+				t.Fatal("posts should be returned in order of id")
+			} else if post.Id == p2.Id {
+				t.Fatal("shouldn't have returned a system message")
+			} else if post.Id == p3.Id {
+				t.Fatal("shouldn't have returned a reply")
+			} else if post.Id == p1.Id {
+				found = post
+			}
+
+			afterId = post.Id
+		}
+	}
+
+	if found == nil {
+		t.Fatal("should have returned the post")
+	} else if found.Message != p1.Message || found.TeamName != t1.Name || found.ChannelName != c1.Name || found.Username != u1.Username {
+		t.Fatal("should have returned the post with the names of its team, channel and user")
+	}
+
+	replies := Must(store.Post().GetRepliesForExport(p1.Id)).([]*model.PostForExport)
+	if len(replies) != 1 || replies[0].Id != p3.Id || replies[0].Username != u1.Username {
+		t.Fatal("should have returned the reply with the name of its user")
+	}
+}
+
+func TestPostStoreDataRetention(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	channel := Must(store.Channel().Save(&model.Channel{
+		TeamId:      teamId,
+		DisplayName: "DisplayName",
+		Name:        "zz" + model.NewId(),
+		Type:        model.CHANNEL_OPEN,
+	})).(*model.Channel)
+
+	oldPost := Must(store.Post().Save(&model.Post{
+		ChannelId: channel.Id,
+		UserId:    model.NewId(),
+		Message:   "old",
+		CreateAt:  1000,
+	})).(*model.Post)
+
+	newPost := Must(store.Post().Save(&model.Post{
+		ChannelId: channel.Id,
+		UserId:    model.NewId(),
+		Message:   "new",
+		CreateAt:  3000,
+	})).(*model.Post)
+
+	if result := <-store.Post().GetIdsForDataRetention(2000, teamId, nil, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if postIds := result.Data.([]string); len(postIds) != 1 || postIds[0] != oldPost.Id {
+		t.Fatal("should've only returned the old post", postIds)
+	}
+
+	if result := <-store.Post().GetIdsForDataRetention(2000, model.NewId(), nil, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if postIds := result.Data.([]string); len(postIds) != 0 {
+		t.Fatal("shouldn't have returned posts from another team")
+	}
+
+	if result := <-store.Post().GetIdsForDataRetention(2000, "", []string{teamId}, 1000); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		for _, postId := range result.Data.([]string) {
+			if postId == oldPost.Id {
+				t.Fatal("shouldn't have returned posts from an excluded team")
+			}
+		}
+	}
+
+	if result := <-store.Post().PermanentDeleteByIds([]string{oldPost.Id}); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if deleted := result.Data.(int64); deleted != 1 {
+		t.Fatal("should've deleted the old post")
+	}
+
+	if result := <-store.Post().Get(oldPost.Id); result.Err == nil {
+		t.Fatal("old post should be gone")
+	}
+
+	if result := <-store.Post().Get(newPost.Id); result.Err != nil {
+		t.Fatal("new post should still exist")
+	}
+}
+
+func TestPostStoreGetPostsByIds(t *testing.T) {
+	Setup()
+
+	o1 := Must(store.Post().Save(&model.Post{
+		ChannelId: model.NewId(),
+		UserId:    model.NewId(),
+		Message:   "a" + model.NewId() + "b",
+	})).(*model.Post)
+
+	o2 := Must(store.Post().Save(&model.Post{
+		ChannelId: model.NewId(),
+		UserId:    model.NewId(),
+		Message:   "a" + model.NewId() + "b",
+	})).(*model.Post)
+
+	if posts := Must(store.Post().GetPostsByIds([]string{o1.Id, o2.Id, model.NewId()})).([]*model.Post); len(posts) != 2 {
+		t.Fatal("should've returned both posts")
+	} else if posts[0].Id != o2.Id || posts[1].Id != o1.Id {
+		t.Fatal("should've returned the newest post first")
+	}
+
+	if posts := Must(store.Post().GetPostsByIds([]string{})).([]*model.Post); len(posts) != 0 {
+		t.Fatal("shouldn't have returned any posts")
+	}
+}
+
+func TestPostStoreGetPostsForIndexing(t *testing.T) {
+	Setup()
+
+	channel := Must(store.Channel().Save(&model.Channel{
+		TeamId:      model.NewId(),
+		DisplayName: "DisplayName",
+		Name:        "zz" + model.NewId(),
+		Type:        model.CHANNEL_OPEN,
+	})).(*model.Channel)
+
+	post := Must(store.Post().Save(&model.Post{
+		ChannelId: channel.Id,
+		UserId:    model.NewId(),
+		Message:   "a" + model.NewId() + "b",
+	})).(*model.Post)
+
+	systemPost := Must(store.Post().Save(&model.Post{
+		ChannelId: channel.Id,
+		UserId:    model.NewId(),
+		Message:   "joined",
+		Type:      model.POST_JOIN_CHANNEL,
+	})).(*model.Post)
+
+	var found *model.PostForIndexing
+	afterId := ""
+	for {
+		posts := Must(store.Post().GetPostsForIndexing(afterId, 100)).([]*model.PostForIndexing)
+		if len(posts) == 0 {
+			break
+		}
+
+		for _, p := range posts {
+			if p.Id == post.Id {
+				found = p
+			} else if p.Id == systemPost.Id {
+				t.Fatal("shouldn't have returned a system message")
+			}
+		}
+
+		afterId = posts[len(posts)-1].Id
+	}
+
+	if found == nil {
+		t.Fatal("should've returned the post")
+	} else if found.TeamId != channel.TeamId || found.Message != post.Message {
+		t.Fatal("returned the wrong post data")
+	}
+}
+
+func TestPostStoreSearchModifiers(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	userId := model.NewId()
+
+	c1 := Must(store.Channel().Save(&model.Channel{
+		TeamId:      teamId,
+		DisplayName: "Channel1",
+		Name:        "a" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	})).(*model.Channel)
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	otherUserId := model.NewId()
+	dm := Must(store.Channel().Save(&model.Channel{
+		Name: model.GetDMNameFromIds(userId, otherUserId),
+		Type: model.CHANNEL_DIRECT,
+	})).(*model.Channel)
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: dm.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	june14 := int64(1497441600000) // 2017-06-14 12:00 UTC
+	june15 := june14 + 24*60*60*1000
+	june16 := june15 + 24*60*60*1000
+
+	p1 := Must(store.Post().Save(&model.Post{
+		ChannelId: c1.Id,
+		UserId:    model.NewId(),
+		Message:   "zebra apple",
+		CreateAt:  june14,
+	})).(*model.Post)
+
+	p2 := Must(store.Post().Save(&model.Post{
+		ChannelId: c1.Id,
+		UserId:    model.NewId(),
+		Message:   "zebra banana https://example.com",
+		CreateAt:  june15,
+		IsPinned:  true,
+	})).(*model.Post)
+
+	p3 := Must(store.Post().Save(&model.Post{
+		ChannelId: c1.Id,
+		UserId:    model.NewId(),
+		Message:   "zebra cherry",
+		CreateAt:  june16,
+		FileIds:   []string{model.NewId()},
+	})).(*model.Post)
+
+	p4 := Must(store.Post().Save(&model.Post{
+		ChannelId: dm.Id,
+		UserId:    otherUserId,
+		Message:   "zebra direct",
+		CreateAt:  june16 + 1,
+	})).(*model.Post)
+
+	for _, testCase := range []struct {
+		Name     string
+		Params   *model.SearchParams
+		Expected []*model.Post
+	}{
+		{"no modifiers", &model.SearchParams{Terms: "zebra"}, []*model.Post{p4, p3, p2, p1}},
+		{"excluded word", &model.SearchParams{Terms: "zebra", ExcludedTerms: "banana"}, []*model.Post{p4, p3, p1}},
+		{"excluded words", &model.SearchParams{Terms: "zebra", ExcludedTerms: "banana cherry"}, []*model.Post{p4, p1}},
+		{"on", &model.SearchParams{Terms: "zebra", OnDate: "2017-06-15"}, []*model.Post{p2}},
+		{"before", &model.SearchParams{Terms: "zebra", BeforeDate: "2017-06-15"}, []*model.Post{p1}},
+		{"after", &model.SearchParams{Terms: "zebra", AfterDate: "2017-06-15"}, []*model.Post{p4, p3}},
+		{"before and after", &model.SearchParams{Terms: "zebra", AfterDate: "2017-06-14", BeforeDate: "2017-06-16"}, []*model.Post{p2}},
+		{"has file", &model.SearchParams{Terms: "zebra", HasFile: true}, []*model.Post{p3}},
+		{"has link", &model.SearchParams{Terms: "zebra", HasLink: true}, []*model.Post{p2}},
+		{"is pinned", &model.SearchParams{Terms: "zebra", IsPinned: true}, []*model.Post{p2}},
+		{"is pinned without terms", &model.SearchParams{IsPinned: true}, []*model.Post{p2}},
+		{"in direct channels", &model.SearchParams{Terms: "zebra", InDirectChannels: true}, []*model.Post{p4}},
+		{"in a direct channel", &model.SearchParams{Terms: "zebra", InChannels: []string{dm.Name}}, []*model.Post{p4}},
+	} {
+		result := Must(store.Post().Search(teamId, userId, testCase.Params)).(*model.PostList)
+
+		if len(result.Order) != len(testCase.Expected) {
+			t.Fatalf("%v: returned %v posts, expected %v", testCase.Name, len(result.Order), len(testCase.Expected))
+		}
+
+		for i, post := range testCase.Expected {
+			if result.Order[i] != post.Id {
+				t.Fatalf("%v: returned the wrong post at position %v", testCase.Name, i)
+			}
+		}
+	}
+}
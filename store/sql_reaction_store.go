@@ -4,6 +4,8 @@
 package store
 
 import (
+	"net/http"
+
 	"github.com/primefour/servers/einterfaces"
 	"github.com/primefour/servers/model"
 	"github.com/primefour/servers/utils"
@@ -269,3 +271,35 @@ func (s SqlReactionStore) DeleteAllWithEmojiName(emojiName string) StoreChannel
 
 	return storeChannel
 }
+
+// PermanentDeleteByPostIds deletes every reaction to the given posts. It's meant to be used when the posts themselves
+// are being deleted, so it doesn't update their HasReactions flags.
+func (s SqlReactionStore) PermanentDeleteByPostIds(postIds []string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(postIds) > 0 {
+			props := make(map[string]interface{})
+			idQuery := buildIdsQuery("postId", postIds, props)
+
+			if sqlResult, err := s.GetMaster().Exec("DELETE FROM Reactions WHERE PostId IN ("+idQuery+")", props); err != nil {
+				result.Err = model.NewAppError("SqlReactionStore.PermanentDeleteByPostIds", "store.sql_reaction.permanent_delete_by_post_ids.app_error", nil, err.Error(), http.StatusInternalServerError)
+			} else {
+				result.Data, _ = sqlResult.RowsAffected()
+			}
+		} else {
+			result.Data = int64(0)
+		}
+
+		for _, postId := range postIds {
+			s.InvalidateCacheForPost(postId)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -294,3 +294,29 @@ func TestReactionDeleteAllWithEmojiName(t *testing.T) {
 		t.Fatal("post shouldn't have reactions any more")
 	}
 }
+
+func TestReactionPermanentDeleteByPostIds(t *testing.T) {
+	Setup()
+
+	post := Must(store.Post().Save(&model.Post{
+		ChannelId: model.NewId(),
+		UserId:    model.NewId(),
+		Message:   "message",
+	})).(*model.Post)
+
+	Must(store.Reaction().Save(&model.Reaction{
+		PostId:    post.Id,
+		UserId:    model.NewId(),
+		EmojiName: "smile",
+	}))
+
+	if result := <-store.Reaction().PermanentDeleteByPostIds([]string{post.Id}); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if deleted := result.Data.(int64); deleted != 1 {
+		t.Fatal("should've deleted the reaction")
+	}
+
+	if reactions := Must(store.Reaction().GetForPost(post.Id, false)).([]*model.Reaction); len(reactions) != 0 {
+		t.Fatal("reaction should be gone")
+	}
+}
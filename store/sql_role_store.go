@@ -0,0 +1,155 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/primefour/servers/model"
+)
+
+type SqlRoleStore struct {
+	*SqlStore
+}
+
+func NewSqlRoleStore(sqlStore *SqlStore) RoleStore {
+	s := &SqlRoleStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Role{}, "Roles").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(64)
+		table.ColMap("Name").SetMaxSize(128)
+		table.ColMap("Description").SetMaxSize(1024)
+		table.ColMap("Permissions").SetMaxSize(4096)
+	}
+
+	return s
+}
+
+func (s SqlRoleStore) Save(role *model.Role) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		role.PreSave()
+
+		if result.Err = role.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(role); err != nil {
+			if IsUniqueConstraintError(err.Error(), []string{"PRIMARY", "roles_pkey"}) {
+				result.Err = model.NewAppError("SqlRoleStore.Save", "store.sql_role.save.exists.app_error", nil, "id="+role.Id+", "+err.Error(), http.StatusBadRequest)
+			} else {
+				result.Err = model.NewAppError("SqlRoleStore.Save", "store.sql_role.save.app_error", nil, "id="+role.Id+", "+err.Error(), http.StatusInternalServerError)
+			}
+		} else {
+			result.Data = role
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlRoleStore) Update(role *model.Role) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		role.PreUpdate()
+
+		if result.Err = role.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := s.GetMaster().Update(role); err != nil {
+			result.Err = model.NewAppError("SqlRoleStore.Update", "store.sql_role.update.app_error", nil, "id="+role.Id+", "+err.Error(), http.StatusInternalServerError)
+		} else if count != 1 {
+			result.Err = model.NewAppError("SqlRoleStore.Update", "store.sql_role.update.app_error", nil, fmt.Sprintf("id=%v, count=%v", role.Id, count), http.StatusInternalServerError)
+		} else {
+			result.Data = role
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlRoleStore) Get(roleId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		role := model.Role{}
+
+		if err := s.GetReplica().SelectOne(&role, "SELECT * FROM Roles WHERE Id = :Id", map[string]interface{}{"Id": roleId}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlRoleStore.Get", "store.sql_role.get.missing.app_error", nil, "id="+roleId, http.StatusNotFound)
+			} else {
+				result.Err = model.NewAppError("SqlRoleStore.Get", "store.sql_role.get.app_error", nil, "id="+roleId+", "+err.Error(), http.StatusInternalServerError)
+			}
+		} else {
+			result.Data = &role
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetAll returns every role that's saved in the database, which doesn't include the built-in roles that haven't been
+// changed.
+func (s SqlRoleStore) GetAll() StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		roles := []*model.Role{}
+
+		if _, err := s.GetReplica().Select(&roles, "SELECT * FROM Roles ORDER BY Id"); err != nil {
+			result.Err = model.NewAppError("SqlRoleStore.GetAll", "store.sql_role.get_all.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = roles
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlRoleStore) PermanentDelete(roleId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM Roles WHERE Id = :Id", map[string]interface{}{"Id": roleId}); err != nil {
+			result.Err = model.NewAppError("SqlRoleStore.PermanentDelete", "store.sql_role.permanent_delete.app_error", nil, "id="+roleId+", "+err.Error(), http.StatusInternalServerError)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
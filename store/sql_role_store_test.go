@@ -0,0 +1,76 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/primefour/servers/model"
+)
+
+func TestRoleStoreSaveGetUpdate(t *testing.T) {
+	Setup()
+
+	role := &model.Role{
+		Id:          model.NewId(),
+		Name:        "Some Role",
+		Permissions: model.StringArray{model.PERMISSION_CREATE_POST.Id},
+	}
+
+	if result := <-store.Role().Save(role); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Role().Save(&model.Role{Id: role.Id, Name: "Some Role"}); result.Err == nil {
+		t.Fatal("shouldn't have saved a role with the same id")
+	}
+
+	if result := <-store.Role().Save(&model.Role{Id: model.NewId(), Name: "Some Role", Permissions: model.StringArray{"junk"}}); result.Err == nil {
+		t.Fatal("shouldn't have saved a role with an unknown permission")
+	}
+
+	if result := <-store.Role().Get(role.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.Role); received.Name != role.Name || len(received.Permissions) != 1 || received.Permissions[0] != model.PERMISSION_CREATE_POST.Id {
+		t.Fatal("received incorrect role after save")
+	}
+
+	if result := <-store.Role().Get(model.NewId()); result.Err == nil {
+		t.Fatal("shouldn't have found a missing role")
+	}
+
+	role.Permissions = model.StringArray{model.PERMISSION_EDIT_POST.Id, model.PERMISSION_DELETE_POST.Id}
+	if result := <-store.Role().Update(role); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Role().Get(role.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.Role); len(received.Permissions) != 2 || received.HasPermission(model.PERMISSION_CREATE_POST.Id) {
+		t.Fatal("received incorrect role after update")
+	}
+
+	if result := <-store.Role().GetAll(); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		found := false
+		for _, r := range result.Data.([]*model.Role) {
+			if r.Id == role.Id {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("should have returned the saved role")
+		}
+	}
+
+	if result := <-store.Role().PermanentDelete(role.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Role().Get(role.Id); result.Err == nil {
+		t.Fatal("shouldn't have found a deleted role")
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/primefour/servers/model"
+)
+
+type SqlSchemeStore struct {
+	*SqlStore
+}
+
+func NewSqlSchemeStore(sqlStore *SqlStore) SchemeStore {
+	s := &SqlSchemeStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.Scheme{}, "Schemes").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("Name").SetMaxSize(64)
+		table.ColMap("Description").SetMaxSize(1024)
+		table.ColMap("DefaultTeamAdminRole").SetMaxSize(64)
+		table.ColMap("DefaultTeamUserRole").SetMaxSize(64)
+		table.ColMap("DefaultChannelAdminRole").SetMaxSize(64)
+		table.ColMap("DefaultChannelUserRole").SetMaxSize(64)
+	}
+
+	return s
+}
+
+func (s SqlSchemeStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_schemes_delete_at", "Schemes", "DeleteAt")
+}
+
+func (s SqlSchemeStore) Save(scheme *model.Scheme) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if len(scheme.Id) > 0 {
+			result.Err = model.NewAppError("SqlSchemeStore.Save", "store.sql_scheme.save.existing.app_error", nil, "id="+scheme.Id, http.StatusBadRequest)
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		scheme.PreSave()
+
+		if result.Err = scheme.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(scheme); err != nil {
+			result.Err = model.NewAppError("SqlSchemeStore.Save", "store.sql_scheme.save.app_error", nil, "id="+scheme.Id+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = scheme
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlSchemeStore) Update(scheme *model.Scheme) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		scheme.PreUpdate()
+
+		if result.Err = scheme.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := s.GetMaster().Update(scheme); err != nil {
+			result.Err = model.NewAppError("SqlSchemeStore.Update", "store.sql_scheme.update.app_error", nil, "id="+scheme.Id+", "+err.Error(), http.StatusInternalServerError)
+		} else if count != 1 {
+			result.Err = model.NewAppError("SqlSchemeStore.Update", "store.sql_scheme.update.app_error", nil, fmt.Sprintf("id=%v, count=%v", scheme.Id, count), http.StatusInternalServerError)
+		} else {
+			result.Data = scheme
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlSchemeStore) Get(schemeId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		scheme := model.Scheme{}
+
+		if err := s.GetReplica().SelectOne(&scheme, "SELECT * FROM Schemes WHERE Id = :Id AND DeleteAt = 0", map[string]interface{}{"Id": schemeId}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlSchemeStore.Get", "store.sql_scheme.get.missing.app_error", nil, "id="+schemeId, http.StatusNotFound)
+			} else {
+				result.Err = model.NewAppError("SqlSchemeStore.Get", "store.sql_scheme.get.app_error", nil, "id="+schemeId+", "+err.Error(), http.StatusInternalServerError)
+			}
+		} else {
+			result.Data = &scheme
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlSchemeStore) GetAllPage(offset int, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		schemes := []*model.Scheme{}
+
+		if _, err := s.GetReplica().Select(&schemes, "SELECT * FROM Schemes WHERE DeleteAt = 0 ORDER BY CreateAt, Id LIMIT :Limit OFFSET :Offset", map[string]interface{}{"Offset": offset, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlSchemeStore.GetAllPage", "store.sql_scheme.get_all_page.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = schemes
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Delete marks a scheme as deleted and moves the teams that used it back to the built-in roles.
+func (s SqlSchemeStore) Delete(schemeId string, time int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if transaction, err := s.GetMaster().Begin(); err != nil {
+			result.Err = model.NewAppError("SqlSchemeStore.Delete", "store.sql_scheme.delete.open_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else if _, err := transaction.Exec("UPDATE Teams SET SchemeId = '', UpdateAt = :UpdateAt WHERE SchemeId = :SchemeId", map[string]interface{}{"UpdateAt": time, "SchemeId": schemeId}); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewAppError("SqlSchemeStore.Delete", "store.sql_scheme.delete.teams.app_error", nil, "id="+schemeId+", "+err.Error(), http.StatusInternalServerError)
+		} else if _, err := transaction.Exec("UPDATE Schemes SET DeleteAt = :DeleteAt, UpdateAt = :UpdateAt WHERE Id = :Id", map[string]interface{}{"DeleteAt": time, "UpdateAt": time, "Id": schemeId}); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewAppError("SqlSchemeStore.Delete", "store.sql_scheme.delete.app_error", nil, "id="+schemeId+", "+err.Error(), http.StatusInternalServerError)
+		} else if err := transaction.Commit(); err != nil {
+			result.Err = model.NewAppError("SqlSchemeStore.Delete", "store.sql_scheme.delete.commit_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/primefour/servers/model"
+)
+
+func TestSchemeStoreSaveGetUpdate(t *testing.T) {
+	Setup()
+
+	scheme := &model.Scheme{
+		Name:                    "Some Scheme",
+		DefaultTeamAdminRole:    model.NewId(),
+		DefaultTeamUserRole:     model.NewId(),
+		DefaultChannelAdminRole: model.NewId(),
+		DefaultChannelUserRole:  model.NewId(),
+	}
+
+	if result := <-store.Scheme().Save(scheme); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Scheme().Save(scheme); result.Err == nil {
+		t.Fatal("shouldn't have saved an existing scheme")
+	}
+
+	if result := <-store.Scheme().Get(scheme.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.Scheme); received.Name != scheme.Name || received.DefaultTeamUserRole != scheme.DefaultTeamUserRole {
+		t.Fatal("received incorrect scheme after save")
+	}
+
+	scheme.Description = "a scheme"
+	if result := <-store.Scheme().Update(scheme); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Scheme().Get(scheme.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.Scheme); received.Description != scheme.Description {
+		t.Fatal("received incorrect scheme after update")
+	}
+
+	if result := <-store.Scheme().GetAllPage(0, 1000); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		found := false
+		for _, s := range result.Data.([]*model.Scheme) {
+			if s.Id == scheme.Id {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("should have returned the saved scheme")
+		}
+	}
+}
+
+func TestSchemeStoreDelete(t *testing.T) {
+	Setup()
+
+	scheme := &model.Scheme{
+		Name:                    "Some Scheme",
+		DefaultTeamAdminRole:    model.NewId(),
+		DefaultTeamUserRole:     model.NewId(),
+		DefaultChannelAdminRole: model.NewId(),
+		DefaultChannelUserRole:  model.NewId(),
+	}
+	Must(store.Scheme().Save(scheme))
+
+	team := &model.Team{
+		DisplayName: "DisplayName",
+		Name:        "z-z-z" + model.NewId() + "b",
+		Email:       model.NewId() + "@nowhere.com",
+		Type:        model.TEAM_OPEN,
+	}
+	Must(store.Team().Save(team))
+
+	if result := <-store.Team().UpdateSchemeId(team.Id, scheme.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if rteam := Must(store.Team().Get(team.Id)).(*model.Team); rteam.SchemeId != scheme.Id {
+		t.Fatal("the team should use the scheme")
+	}
+
+	// Regular updates leave the scheme alone
+	Must(store.Team().Update(team))
+	if rteam := Must(store.Team().Get(team.Id)).(*model.Team); rteam.SchemeId != scheme.Id {
+		t.Fatal("the team should still use the scheme")
+	}
+
+	if result := <-store.Scheme().Delete(scheme.Id, model.GetMillis()); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Scheme().Get(scheme.Id); result.Err == nil {
+		t.Fatal("shouldn't have found a deleted scheme")
+	}
+
+	if rteam := Must(store.Team().Get(team.Id)).(*model.Team); rteam.SchemeId != "" {
+		t.Fatal("the team shouldn't use the deleted scheme")
+	}
+}
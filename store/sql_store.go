@@ -66,30 +66,37 @@ const (
 )
 
 type SqlStore struct {
-	master         *gorp.DbMap
-	replicas       []*gorp.DbMap
-	searchReplicas []*gorp.DbMap
-	team           TeamStore
-	channel        ChannelStore
-	post           PostStore
-	user           UserStore
-	audit          AuditStore
-	compliance     ComplianceStore
-	session        SessionStore
-	oauth          OAuthStore
-	system         SystemStore
-	webhook        WebhookStore
-	command        CommandStore
-	preference     PreferenceStore
-	license        LicenseStore
-	token          TokenStore
-	emoji          EmojiStore
-	status         StatusStore
-	fileInfo       FileInfoStore
-	reaction       ReactionStore
-	SchemaVersion  string
-	rrCounter      int64
-	srCounter      int64
+	master          *gorp.DbMap
+	replicas        []*gorp.DbMap
+	searchReplicas  []*gorp.DbMap
+	team            TeamStore
+	channel         ChannelStore
+	post            PostStore
+	user            UserStore
+	audit           AuditStore
+	compliance      ComplianceStore
+	session         SessionStore
+	oauth           OAuthStore
+	system          SystemStore
+	webhook         WebhookStore
+	command         CommandStore
+	preference      PreferenceStore
+	license         LicenseStore
+	token           TokenStore
+	emoji           EmojiStore
+	status          StatusStore
+	fileInfo        FileInfoStore
+	reaction        ReactionStore
+	uploadSession   UploadSessionStore
+	job             JobStore
+	userAccessToken UserAccessTokenStore
+	mfa             MfaStore
+	bot             BotStore
+	role            RoleStore
+	scheme          SchemeStore
+	SchemaVersion   string
+	rrCounter       int64
+	srCounter       int64
 }
 
 func initConnection() *SqlStore {
@@ -151,6 +158,13 @@ func NewSqlStore() Store {
 	sqlStore.status = NewSqlStatusStore(sqlStore)
 	sqlStore.fileInfo = NewSqlFileInfoStore(sqlStore)
 	sqlStore.reaction = NewSqlReactionStore(sqlStore)
+	sqlStore.uploadSession = NewSqlUploadSessionStore(sqlStore)
+	sqlStore.job = NewSqlJobStore(sqlStore)
+	sqlStore.userAccessToken = NewSqlUserAccessTokenStore(sqlStore)
+	sqlStore.mfa = NewSqlMfaStore(sqlStore)
+	sqlStore.bot = NewSqlBotStore(sqlStore)
+	sqlStore.role = NewSqlRoleStore(sqlStore)
+	sqlStore.scheme = NewSqlSchemeStore(sqlStore)
 
 	err := sqlStore.master.CreateTablesIfNotExists()
 	if err != nil {
@@ -179,6 +193,12 @@ func NewSqlStore() Store {
 	sqlStore.status.(*SqlStatusStore).CreateIndexesIfNotExists()
 	sqlStore.fileInfo.(*SqlFileInfoStore).CreateIndexesIfNotExists()
 	sqlStore.reaction.(*SqlReactionStore).CreateIndexesIfNotExists()
+	sqlStore.uploadSession.(*SqlUploadSessionStore).CreateIndexesIfNotExists()
+	sqlStore.job.(*SqlJobStore).CreateIndexesIfNotExists()
+	sqlStore.userAccessToken.(*SqlUserAccessTokenStore).CreateIndexesIfNotExists()
+	sqlStore.mfa.(*SqlMfaStore).CreateIndexesIfNotExists()
+	sqlStore.bot.(*SqlBotStore).CreateIndexesIfNotExists()
+	sqlStore.scheme.(*SqlSchemeStore).CreateIndexesIfNotExists()
 
 	sqlStore.preference.(*SqlPreferenceStore).DeleteUnusedFeatures()
 
@@ -735,6 +755,34 @@ func (ss *SqlStore) Reaction() ReactionStore {
 	return ss.reaction
 }
 
+func (ss *SqlStore) UploadSession() UploadSessionStore {
+	return ss.uploadSession
+}
+
+func (ss *SqlStore) Job() JobStore {
+	return ss.job
+}
+
+func (ss *SqlStore) UserAccessToken() UserAccessTokenStore {
+	return ss.userAccessToken
+}
+
+func (ss *SqlStore) Mfa() MfaStore {
+	return ss.mfa
+}
+
+func (ss *SqlStore) Bot() BotStore {
+	return ss.bot
+}
+
+func (ss *SqlStore) Role() RoleStore {
+	return ss.role
+}
+
+func (ss *SqlStore) Scheme() SchemeStore {
+	return ss.scheme
+}
+
 func (ss *SqlStore) DropAllTables() {
 	ss.master.TruncateTables()
 }
@@ -33,6 +33,7 @@ func NewSqlTeamStore(sqlStore *SqlStore) TeamStore {
 		table.ColMap("CompanyName").SetMaxSize(64)
 		table.ColMap("AllowedDomains").SetMaxSize(500)
 		table.ColMap("InviteId").SetMaxSize(32)
+		table.ColMap("SchemeId").SetMaxSize(26)
 
 		tablem := db.AddTableWithName(model.TeamMember{}, "TeamMembers").SetKeys(false, "TeamId", "UserId")
 		tablem.ColMap("TeamId").SetMaxSize(26)
@@ -50,6 +51,7 @@ func (s SqlTeamStore) CreateIndexesIfNotExists() {
 	s.CreateIndexIfNotExists("idx_teams_update_at", "Teams", "UpdateAt")
 	s.CreateIndexIfNotExists("idx_teams_create_at", "Teams", "CreateAt")
 	s.CreateIndexIfNotExists("idx_teams_delete_at", "Teams", "DeleteAt")
+	s.CreateIndexIfNotExists("idx_teams_scheme_id", "Teams", "SchemeId")
 
 	s.CreateIndexIfNotExists("idx_teammembers_team_id", "TeamMembers", "TeamId")
 	s.CreateIndexIfNotExists("idx_teammembers_user_id", "TeamMembers", "UserId")
@@ -119,6 +121,7 @@ func (s SqlTeamStore) Update(team *model.Team) StoreChannel {
 			team.CreateAt = oldTeam.CreateAt
 			team.UpdateAt = model.GetMillis()
 			team.Name = oldTeam.Name
+			team.SchemeId = oldTeam.SchemeId
 
 			if count, err := s.GetMaster().Update(team); err != nil {
 				result.Err = model.NewLocAppError("SqlTeamStore.Update", "store.sql_team.update.updating.app_error", nil, "id="+team.Id+", "+err.Error())
@@ -156,6 +159,27 @@ func (s SqlTeamStore) UpdateDisplayName(name string, teamId string) StoreChannel
 	return storeChannel
 }
 
+// UpdateSchemeId sets the scheme that a team uses, which Update leaves alone. An empty schemeId moves the team back to
+// the built-in roles.
+func (s SqlTeamStore) UpdateSchemeId(teamId string, schemeId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("UPDATE Teams SET SchemeId = :SchemeId, UpdateAt = :UpdateAt WHERE Id = :Id", map[string]interface{}{"SchemeId": schemeId, "UpdateAt": model.GetMillis(), "Id": teamId}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.UpdateSchemeId", "store.sql_team.update_scheme_id.app_error", nil, "team_id="+teamId+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = teamId
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlTeamStore) Get(id string) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -15,16 +15,17 @@ import (
 )
 
 const (
-	VERSION_3_9_0 = "3.9.0"
-	VERSION_3_8_0 = "3.8.0"
-	VERSION_3_7_0 = "3.7.0"
-	VERSION_3_6_0 = "3.6.0"
-	VERSION_3_5_0 = "3.5.0"
-	VERSION_3_4_0 = "3.4.0"
-	VERSION_3_3_0 = "3.3.0"
-	VERSION_3_2_0 = "3.2.0"
-	VERSION_3_1_0 = "3.1.0"
-	VERSION_3_0_0 = "3.0.0"
+	VERSION_3_10_0 = "3.10.0"
+	VERSION_3_9_0  = "3.9.0"
+	VERSION_3_8_0  = "3.8.0"
+	VERSION_3_7_0  = "3.7.0"
+	VERSION_3_6_0  = "3.6.0"
+	VERSION_3_5_0  = "3.5.0"
+	VERSION_3_4_0  = "3.4.0"
+	VERSION_3_3_0  = "3.3.0"
+	VERSION_3_2_0  = "3.2.0"
+	VERSION_3_1_0  = "3.1.0"
+	VERSION_3_0_0  = "3.0.0"
 )
 
 const (
@@ -45,6 +46,7 @@ func UpgradeDatabase(sqlStore *SqlStore) {
 	UpgradeDatabaseToVersion37(sqlStore)
 	UpgradeDatabaseToVersion38(sqlStore)
 	UpgradeDatabaseToVersion39(sqlStore)
+	UpgradeDatabaseToVersion310(sqlStore)
 
 	// If the SchemaVersion is empty this this is the first time it has ran
 	// so lets set it to the current version.
@@ -262,3 +264,19 @@ func UpgradeDatabaseToVersion39(sqlStore *SqlStore) {
 		saveSchemaVersion(sqlStore, VERSION_3_9_0)
 	}
 }
+
+func UpgradeDatabaseToVersion310(sqlStore *SqlStore) {
+	if shouldPerformUpgrade(sqlStore, VERSION_3_9_0, VERSION_3_10_0) {
+		// Add the IsBot column to users so that bots can be told apart from people.
+		sqlStore.CreateColumnIfNotExists("Users", "IsBot", "boolean", "boolean", "0")
+
+		// Add the SchemeId column to teams so that a team can use a permission scheme instead of the built-in roles.
+		sqlStore.CreateColumnIfNotExists("Teams", "SchemeId", "varchar(26)", "varchar(26)", "")
+
+		// Add the SigningSecret column to outgoing webhooks so that requests can be signed with a key that is never
+		// sent. Existing webhooks are given a secret the first time they are used.
+		sqlStore.CreateColumnIfNotExists("OutgoingWebhooks", "SigningSecret", "varchar(32)", "varchar(32)", "")
+
+		saveSchemaVersion(sqlStore, VERSION_3_10_0)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/primefour/servers/model"
+)
+
+type SqlUploadSessionStore struct {
+	*SqlStore
+}
+
+func NewSqlUploadSessionStore(sqlStore *SqlStore) UploadSessionStore {
+	s := &SqlUploadSessionStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.UploadSession{}, "UploadSessions").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("ChannelId").SetMaxSize(26)
+		table.ColMap("Filename").SetMaxSize(256)
+		table.ColMap("Path").SetMaxSize(512)
+	}
+
+	return s
+}
+
+func (s SqlUploadSessionStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_uploadsessions_user_id", "UploadSessions", "UserId")
+	s.CreateIndexIfNotExists("idx_uploadsessions_update_at", "UploadSessions", "UpdateAt")
+}
+
+func (s SqlUploadSessionStore) Save(session *model.UploadSession) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		session.PreSave()
+		if result.Err = session.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(session); err != nil {
+			result.Err = model.NewAppError("SqlUploadSessionStore.Save", "store.sql_upload_session.save.app_error", nil, "id="+session.Id+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = session
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlUploadSessionStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		session := &model.UploadSession{}
+
+		if err := s.GetMaster().SelectOne(session, "SELECT * FROM UploadSessions WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlUploadSessionStore.Get", "store.sql_upload_session.get.app_error", nil, "id="+id+", "+err.Error(), http.StatusNotFound)
+			} else {
+				result.Err = model.NewAppError("SqlUploadSessionStore.Get", "store.sql_upload_session.get.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+			}
+		} else {
+			result.Data = session
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdateOffset moves the offset of a session forward, but only if nothing else has moved it since oldOffset was read
+// and the session isn't being finished.
+func (s SqlUploadSessionStore) UpdateOffset(id string, oldOffset int64, newOffset int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				UploadSessions
+			SET
+				FileOffset = :NewOffset,
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :Id
+				AND FileOffset = :OldOffset
+				AND Finishing = :Finishing`, map[string]interface{}{"Id": id, "OldOffset": oldOffset, "NewOffset": newOffset, "UpdateAt": model.GetMillis(), "Finishing": false}); err != nil {
+			result.Err = model.NewAppError("SqlUploadSessionStore.UpdateOffset", "store.sql_upload_session.update_offset.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+		} else if rows, _ := sqlResult.RowsAffected(); rows == 0 {
+			result.Err = model.NewAppError("SqlUploadSessionStore.UpdateOffset", "store.sql_upload_session.update_offset.conflict.app_error", nil, "id="+id, http.StatusConflict)
+		} else {
+			result.Data = newOffset
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// SetFinishing marks a session as being finished or not. It fails with a conflict if the session is already in that
+// state, so only one request at a time can finish a given session.
+func (s SqlUploadSessionStore) SetFinishing(id string, finishing bool) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				UploadSessions
+			SET
+				Finishing = :Finishing,
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :Id
+				AND Finishing = :OldFinishing`, map[string]interface{}{"Id": id, "Finishing": finishing, "OldFinishing": !finishing, "UpdateAt": model.GetMillis()}); err != nil {
+			result.Err = model.NewAppError("SqlUploadSessionStore.SetFinishing", "store.sql_upload_session.set_finishing.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+		} else if rows, _ := sqlResult.RowsAffected(); rows == 0 {
+			result.Err = model.NewAppError("SqlUploadSessionStore.SetFinishing", "store.sql_upload_session.set_finishing.conflict.app_error", nil, "id="+id, http.StatusConflict)
+		} else {
+			result.Data = finishing
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetStale returns the sessions that haven't received any data since the given time.
+func (s SqlUploadSessionStore) GetStale(updatedBefore int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var sessions []*model.UploadSession
+
+		if _, err := s.GetMaster().Select(&sessions, "SELECT * FROM UploadSessions WHERE UpdateAt < :UpdatedBefore", map[string]interface{}{"UpdatedBefore": updatedBefore}); err != nil {
+			result.Err = model.NewAppError("SqlUploadSessionStore.GetStale", "store.sql_upload_session.get_stale.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = sessions
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlUploadSessionStore) Delete(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM UploadSessions WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewAppError("SqlUploadSessionStore.Delete", "store.sql_upload_session.delete.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/primefour/servers/model"
+)
+
+func TestUploadSessionSaveGet(t *testing.T) {
+	Setup()
+
+	session := &model.UploadSession{
+		UserId:    model.NewId(),
+		ChannelId: model.NewId(),
+		Filename:  "file.txt",
+		Path:      "uploads/" + model.NewId(),
+		FileSize:  1000,
+	}
+
+	if result := <-store.UploadSession().Save(session); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if saved := result.Data.(*model.UploadSession); len(saved.Id) != 26 {
+		t.Fatal("should have assigned an id")
+	}
+	defer func() {
+		<-store.UploadSession().Delete(session.Id)
+	}()
+
+	if result := <-store.UploadSession().Get(session.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.UploadSession); received.Path != session.Path || received.FileSize != session.FileSize {
+		t.Fatal("should have received the saved session")
+	}
+
+	if result := <-store.UploadSession().Get(model.NewId()); result.Err == nil {
+		t.Fatal("shouldn't have found a missing session")
+	}
+}
+
+func TestUploadSessionUpdateOffset(t *testing.T) {
+	Setup()
+
+	session := Must(store.UploadSession().Save(&model.UploadSession{
+		UserId:    model.NewId(),
+		ChannelId: model.NewId(),
+		Filename:  "file.txt",
+		Path:      "uploads/" + model.NewId(),
+		FileSize:  1000,
+	})).(*model.UploadSession)
+	defer func() {
+		<-store.UploadSession().Delete(session.Id)
+	}()
+
+	if result := <-store.UploadSession().UpdateOffset(session.Id, 0, 500); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.UploadSession().UpdateOffset(session.Id, 0, 600); result.Err == nil {
+		t.Fatal("should have failed to update from a stale offset")
+	}
+
+	if received := Must(store.UploadSession().Get(session.Id)).(*model.UploadSession); received.FileOffset != 500 {
+		t.Fatal("should have updated the offset")
+	} else if received.UpdateAt < session.UpdateAt {
+		t.Fatal("should have updated the update at time")
+	}
+}
+
+func TestUploadSessionSetFinishing(t *testing.T) {
+	Setup()
+
+	session := Must(store.UploadSession().Save(&model.UploadSession{
+		UserId:     model.NewId(),
+		ChannelId:  model.NewId(),
+		Filename:   "file.txt",
+		Path:       "uploads/" + model.NewId(),
+		FileSize:   1000,
+		FileOffset: 500,
+	})).(*model.UploadSession)
+	defer func() {
+		<-store.UploadSession().Delete(session.Id)
+	}()
+
+	if result := <-store.UploadSession().SetFinishing(session.Id, true); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.UploadSession().SetFinishing(session.Id, true); result.Err == nil {
+		t.Fatal("shouldn't be able to finish a session twice at the same time")
+	}
+
+	if result := <-store.UploadSession().UpdateOffset(session.Id, 500, 1000); result.Err == nil {
+		t.Fatal("shouldn't be able to upload data while the session is being finished")
+	}
+
+	if result := <-store.UploadSession().SetFinishing(session.Id, false); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.UploadSession().UpdateOffset(session.Id, 500, 1000); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+}
+
+func TestUploadSessionGetStaleDelete(t *testing.T) {
+	Setup()
+
+	stale := Must(store.UploadSession().Save(&model.UploadSession{
+		CreateAt:  500,
+		UpdateAt:  1000,
+		UserId:    model.NewId(),
+		ChannelId: model.NewId(),
+		Filename:  "file.txt",
+		Path:      "uploads/" + model.NewId(),
+		FileSize:  1000,
+	})).(*model.UploadSession)
+
+	fresh := Must(store.UploadSession().Save(&model.UploadSession{
+		CreateAt:  500,
+		UpdateAt:  model.GetMillis(),
+		UserId:    model.NewId(),
+		ChannelId: model.NewId(),
+		Filename:  "file.txt",
+		Path:      "uploads/" + model.NewId(),
+		FileSize:  1000,
+	})).(*model.UploadSession)
+	defer func() {
+		<-store.UploadSession().Delete(fresh.Id)
+	}()
+
+	sessions := Must(store.UploadSession().GetStale(2000)).([]*model.UploadSession)
+
+	found := false
+	for _, session := range sessions {
+		if session.Id == fresh.Id {
+			t.Fatal("shouldn't have returned a fresh session")
+		} else if session.Id == stale.Id {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("should have returned the stale session")
+	}
+
+	if result := <-store.UploadSession().Delete(stale.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.UploadSession().Get(stale.Id); result.Err == nil {
+		t.Fatal("should have deleted the session")
+	}
+}
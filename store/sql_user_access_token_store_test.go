@@ -0,0 +1,101 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/primefour/servers/model"
+)
+
+func TestUserAccessTokenSaveGetDelete(t *testing.T) {
+	Setup()
+
+	uat := &model.UserAccessToken{
+		Token:       model.NewId(),
+		UserId:      model.NewId(),
+		Description: "testtoken",
+	}
+
+	s1 := model.Session{}
+	s1.UserId = uat.UserId
+	s1.Token = uat.Token
+
+	s1 = *Must(store.Session().Save(&s1)).(*model.Session)
+
+	if result := <-store.UserAccessToken().Save(uat); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.UserAccessToken().Get(uat.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.UserAccessToken); received.Token != uat.Token {
+		t.Fatal("received incorrect token after save")
+	}
+
+	if result := <-store.UserAccessToken().GetByToken(uat.Token); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.(*model.UserAccessToken); received.Id != uat.Id {
+		t.Fatal("received incorrect token after save")
+	}
+
+	if result := <-store.UserAccessToken().GetByToken(model.NewId()); result.Err == nil {
+		t.Fatal("shouldn't have found a missing token")
+	}
+
+	if result := <-store.UserAccessToken().GetByUser(uat.UserId, 0, 100); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.([]*model.UserAccessToken); len(received) != 1 {
+		t.Fatal("length should be 1")
+	}
+
+	if result := <-store.UserAccessToken().Delete(uat.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if err := (<-store.Session().Get(s1.Token)).Err; err == nil {
+		t.Fatal("should error - session should be deleted")
+	}
+
+	if err := (<-store.UserAccessToken().GetByToken(s1.Token)).Err; err == nil {
+		t.Fatal("should error - access token should be deleted")
+	}
+}
+
+func TestUserAccessTokenDeleteAllForUser(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+
+	uat1 := &model.UserAccessToken{Token: model.NewId(), UserId: userId}
+	uat2 := &model.UserAccessToken{Token: model.NewId(), UserId: userId}
+	other := &model.UserAccessToken{Token: model.NewId(), UserId: model.NewId()}
+
+	Must(store.UserAccessToken().Save(uat1))
+	Must(store.UserAccessToken().Save(uat2))
+	Must(store.UserAccessToken().Save(other))
+	defer func() {
+		<-store.UserAccessToken().Delete(other.Id)
+	}()
+
+	s1 := Must(store.Session().Save(&model.Session{UserId: userId, Token: uat1.Token})).(*model.Session)
+
+	if result := <-store.UserAccessToken().DeleteAllForUser(userId); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.UserAccessToken().GetByUser(userId, 0, 100); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if received := result.Data.([]*model.UserAccessToken); len(received) != 0 {
+		t.Fatal("all of the user's tokens should be deleted")
+	}
+
+	if err := (<-store.Session().Get(s1.Id)).Err; err == nil {
+		t.Fatal("the token's session should be deleted")
+	}
+
+	if err := (<-store.UserAccessToken().Get(other.Id)).Err; err != nil {
+		t.Fatal("other users' tokens shouldn't be deleted")
+	}
+}
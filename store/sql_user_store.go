@@ -155,6 +155,7 @@ func (us SqlUserStore) Update(user *model.User, trustedUpdateData bool) StoreCha
 			user.FailedAttempts = oldUser.FailedAttempts
 			user.MfaSecret = oldUser.MfaSecret
 			user.MfaActive = oldUser.MfaActive
+			user.IsBot = oldUser.IsBot
 
 			if !trustedUpdateData {
 				user.Roles = oldUser.Roles
@@ -1068,7 +1069,7 @@ func (us SqlUserStore) GetTotalUsersCount() StoreChannel {
 	go func() {
 		result := StoreResult{}
 
-		if count, err := us.GetReplica().SelectInt("SELECT COUNT(Id) FROM Users"); err != nil {
+		if count, err := us.GetReplica().SelectInt("SELECT COUNT(Id) FROM Users WHERE IsBot = false"); err != nil {
 			result.Err = model.NewLocAppError("SqlUserStore.GetTotalUsersCount", "store.sql_user.get_total_users_count.app_error", nil, err.Error())
 		} else {
 			result.Data = count
@@ -1108,9 +1109,9 @@ func (us SqlUserStore) AnalyticsUniqueUserCount(teamId string) StoreChannel {
 
 		query := ""
 		if len(teamId) > 0 {
-			query = "SELECT COUNT(DISTINCT Users.Email) From Users, TeamMembers WHERE TeamMembers.TeamId = :TeamId AND Users.Id = TeamMembers.UserId AND TeamMembers.DeleteAt = 0 AND Users.DeleteAt = 0"
+			query = "SELECT COUNT(DISTINCT Users.Email) From Users, TeamMembers WHERE TeamMembers.TeamId = :TeamId AND Users.Id = TeamMembers.UserId AND TeamMembers.DeleteAt = 0 AND Users.DeleteAt = 0 AND Users.IsBot = false"
 		} else {
-			query = "SELECT COUNT(DISTINCT Email) FROM Users WHERE DeleteAt = 0"
+			query = "SELECT COUNT(DISTINCT Email) FROM Users WHERE DeleteAt = 0 AND IsBot = false"
 		}
 
 		v, err := us.GetReplica().SelectInt(query, map[string]interface{}{"TeamId": teamId})
@@ -1485,7 +1486,7 @@ func (us SqlUserStore) AnalyticsGetInactiveUsersCount() StoreChannel {
 	go func() {
 		result := StoreResult{}
 
-		if count, err := us.GetReplica().SelectInt("SELECT COUNT(Id) FROM Users WHERE DeleteAt > 0"); err != nil {
+		if count, err := us.GetReplica().SelectInt("SELECT COUNT(Id) FROM Users WHERE DeleteAt > 0 AND IsBot = false"); err != nil {
 			result.Err = model.NewLocAppError("SqlUserStore.AnalyticsGetInactiveUsersCount", "store.sql_user.analytics_get_inactive_users_count.app_error", nil, err.Error())
 		} else {
 			result.Data = count
@@ -1588,3 +1589,26 @@ func (us SqlUserStore) GetEtagForProfilesNotInTeam(teamId string) StoreChannel {
 
 	return storeChannel
 }
+
+// GetAllAfter returns up to limit users with an id greater than afterId, ordered by id. Unlike GetAllProfiles, the
+// users aren't sanitized.
+func (us SqlUserStore) GetAllAfter(limit int, afterId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var users []*model.User
+
+		if _, err := us.GetReplica().Select(&users, "SELECT * FROM Users WHERE Id > :AfterId ORDER BY Id LIMIT :Limit", map[string]interface{}{"AfterId": afterId, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlUserStore.GetAllAfter", "store.sql_user.get_all_after.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = users
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -221,6 +221,31 @@ func TestUserCount(t *testing.T) {
 	}
 }
 
+func TestUserCountExcludesBots(t *testing.T) {
+	Setup()
+
+	var count int64
+	if result := <-store.User().GetTotalUsersCount(); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		count = result.Data.(int64)
+	}
+
+	Must(store.User().Save(&model.User{Email: model.NewId(), Username: "b" + model.NewId(), IsBot: true}))
+
+	if result := <-store.User().GetTotalUsersCount(); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(int64) != count {
+		t.Fatal("shouldn't have counted the bot")
+	}
+
+	if result := <-store.User().AnalyticsUniqueUserCount(""); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(int64) > count {
+		t.Fatal("shouldn't have counted the bot")
+	}
+}
+
 func TestGetAllUsingAuthService(t *testing.T) {
 	Setup()
 
@@ -2002,3 +2027,42 @@ func TestUserStoreGetProfilesNotInTeam(t *testing.T) {
 		}
 	}
 }
+
+func TestUserStoreGetAllAfter(t *testing.T) {
+	Setup()
+
+	authData := model.NewId()
+	u1 := Must(store.User().Save(&model.User{
+		Email:       model.NewId(),
+		AuthService: "someservice",
+		AuthData:    &authData,
+	})).(*model.User)
+
+	if r1 := <-store.User().GetAllAfter(1, ""); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else if users := r1.Data.([]*model.User); len(users) != 1 {
+		t.Fatal("invalid returned users, limit did not work")
+	}
+
+	id := u1.Id[:len(u1.Id)-1]
+	if r2 := <-store.User().GetAllAfter(100, id); r2.Err != nil {
+		t.Fatal(r2.Err)
+	} else {
+		found := false
+		for _, user := range r2.Data.([]*model.User) {
+			if user.Id <= id {
+				t.Fatal("should only have returned users after the given id")
+			} else if user.Id == u1.Id {
+				found = true
+
+				if user.AuthData == nil || *user.AuthData != authData {
+					t.Fatal("shouldn't have sanitized the user")
+				}
+			}
+		}
+
+		if !found {
+			t.Fatal("should have returned the user")
+		}
+	}
+}
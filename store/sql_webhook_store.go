@@ -52,6 +52,15 @@ func NewSqlWebhookStore(sqlStore *SqlStore) WebhookStore {
 		tableo.ColMap("Description").SetMaxSize(128)
 		tableo.ColMap("ContentType").SetMaxSize(128)
 		tableo.ColMap("TriggerWhen").SetMaxSize(1)
+		tableo.ColMap("SigningSecret").SetMaxSize(32)
+
+		tabled := db.AddTableWithName(model.OutgoingWebhookDelivery{}, "OutgoingWebhookDeliveries").SetKeys(false, "Id")
+		tabled.ColMap("Id").SetMaxSize(26)
+		tabled.ColMap("HookId").SetMaxSize(26)
+		tabled.ColMap("PostId").SetMaxSize(26)
+		tabled.ColMap("CallbackURL").SetMaxSize(1024)
+		tabled.ColMap("ResponseExcerpt").SetMaxSize(4096)
+		tabled.ColMap("Error").SetMaxSize(4096)
 	}
 
 	return s
@@ -69,6 +78,10 @@ func (s SqlWebhookStore) CreateIndexesIfNotExists() {
 	s.CreateIndexIfNotExists("idx_outgoing_webhook_update_at", "OutgoingWebhooks", "UpdateAt")
 	s.CreateIndexIfNotExists("idx_outgoing_webhook_create_at", "OutgoingWebhooks", "CreateAt")
 	s.CreateIndexIfNotExists("idx_outgoing_webhook_delete_at", "OutgoingWebhooks", "DeleteAt")
+
+	s.CreateIndexIfNotExists("idx_outgoing_webhook_deliveries_hook_id", "OutgoingWebhookDeliveries", "HookId")
+	s.CreateIndexIfNotExists("idx_outgoing_webhook_deliveries_create_at", "OutgoingWebhookDeliveries", "CreateAt")
+	s.CreateIndexIfNotExists("idx_outgoing_webhook_deliveries_next_attempt_at", "OutgoingWebhookDeliveries", "NextAttemptAt")
 }
 
 func (s SqlWebhookStore) InvalidateWebhookCache(webhookId string) {
@@ -430,6 +443,13 @@ func (s SqlWebhookStore) PermanentDeleteOutgoingByUser(userId string) StoreChann
 	go func() {
 		result := StoreResult{}
 
+		if _, err := s.GetMaster().Exec("DELETE FROM OutgoingWebhookDeliveries WHERE HookId IN (SELECT Id FROM OutgoingWebhooks WHERE CreatorId = :UserId)", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlWebhookStore.DeleteOutgoingByUser", "store.sql_webhooks.permanent_delete_outgoing_by_user.app_error", nil, "id="+userId+", err="+err.Error())
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
 		_, err := s.GetMaster().Exec("DELETE FROM OutgoingWebhooks WHERE CreatorId = :UserId", map[string]interface{}{"UserId": userId})
 		if err != nil {
 			result.Err = model.NewLocAppError("SqlWebhookStore.DeleteOutgoingByUser", "store.sql_webhooks.permanent_delete_outgoing_by_user.app_error", nil, "id="+userId+", err="+err.Error())
@@ -463,6 +483,168 @@ func (s SqlWebhookStore) UpdateOutgoing(hook *model.OutgoingWebhook) StoreChanne
 	return storeChannel
 }
 
+func (s SqlWebhookStore) SaveOutgoingDelivery(delivery *model.OutgoingWebhookDelivery) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		delivery.PreSave()
+		if result.Err = delivery.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(delivery); err != nil {
+			result.Err = model.NewAppError("SqlWebhookStore.SaveOutgoingDelivery", "store.sql_webhooks.save_outgoing_delivery.app_error", nil, "id="+delivery.Id+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = delivery
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlWebhookStore) UpdateOutgoingDelivery(delivery *model.OutgoingWebhookDelivery) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		delivery.PreSave()
+		if result.Err = delivery.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := s.GetMaster().Update(delivery); err != nil {
+			result.Err = model.NewAppError("SqlWebhookStore.UpdateOutgoingDelivery", "store.sql_webhooks.update_outgoing_delivery.app_error", nil, "id="+delivery.Id+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = delivery
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// ClaimOutgoingDelivery moves the next attempt of a pending delivery to until, but only if no other server has done so
+// since the delivery was read. The result is true if the delivery was claimed.
+func (s SqlWebhookStore) ClaimOutgoingDelivery(delivery *model.OutgoingWebhookDelivery, until int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			"UPDATE OutgoingWebhookDeliveries SET NextAttemptAt = :Until WHERE Id = :Id AND NextAttemptAt = :NextAttemptAt",
+			map[string]interface{}{"Id": delivery.Id, "NextAttemptAt": delivery.NextAttemptAt, "Until": until}); err != nil {
+			result.Err = model.NewAppError("SqlWebhookStore.ClaimOutgoingDelivery", "store.sql_webhooks.claim_outgoing_delivery.app_error", nil, "id="+delivery.Id+", "+err.Error(), http.StatusInternalServerError)
+		} else if rows, err := sqlResult.RowsAffected(); err != nil {
+			result.Err = model.NewAppError("SqlWebhookStore.ClaimOutgoingDelivery", "store.sql_webhooks.claim_outgoing_delivery.app_error", nil, "id="+delivery.Id+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			if rows == 1 {
+				delivery.NextAttemptAt = until
+			}
+			result.Data = rows == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlWebhookStore) GetOutgoingDeliveries(hookId string, offset, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var deliveries []*model.OutgoingWebhookDelivery
+
+		if _, err := s.GetReplica().Select(&deliveries, "SELECT * FROM OutgoingWebhookDeliveries WHERE HookId = :HookId ORDER BY CreateAt DESC, Attempt DESC LIMIT :Limit OFFSET :Offset", map[string]interface{}{"HookId": hookId, "Offset": offset, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlWebhookStore.GetOutgoingDeliveries", "store.sql_webhooks.get_outgoing_deliveries.app_error", nil, "hookId="+hookId+", err="+err.Error(), http.StatusInternalServerError)
+		}
+
+		result.Data = deliveries
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlWebhookStore) GetPendingOutgoingDeliveries(before int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var deliveries []*model.OutgoingWebhookDelivery
+
+		if _, err := s.GetMaster().Select(&deliveries, "SELECT * FROM OutgoingWebhookDeliveries WHERE NextAttemptAt > 0 AND NextAttemptAt <= :Before ORDER BY NextAttemptAt LIMIT :Limit", map[string]interface{}{"Before": before, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlWebhookStore.GetPendingOutgoingDeliveries", "store.sql_webhooks.get_pending_outgoing_deliveries.app_error", nil, "err="+err.Error(), http.StatusInternalServerError)
+		}
+
+		result.Data = deliveries
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// PruneOutgoingDeliveries deletes all but the newest keep attempts that have been made for a hook.
+func (s SqlWebhookStore) PruneOutgoingDeliveries(hookId string, keep int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var createAts []int64
+		if _, err := s.GetMaster().Select(&createAts, "SELECT CreateAt FROM OutgoingWebhookDeliveries WHERE HookId = :HookId AND NextAttemptAt = 0 ORDER BY CreateAt DESC LIMIT 1 OFFSET :Keep", map[string]interface{}{"HookId": hookId, "Keep": keep}); err != nil {
+			result.Err = model.NewAppError("SqlWebhookStore.PruneOutgoingDeliveries", "store.sql_webhooks.prune_outgoing_deliveries.app_error", nil, "hookId="+hookId+", err="+err.Error(), http.StatusInternalServerError)
+		} else if len(createAts) == 1 {
+			if _, err := s.GetMaster().Exec("DELETE FROM OutgoingWebhookDeliveries WHERE HookId = :HookId AND NextAttemptAt = 0 AND CreateAt <= :CreateAt", map[string]interface{}{"HookId": hookId, "CreateAt": createAts[0]}); err != nil {
+				result.Err = model.NewAppError("SqlWebhookStore.PruneOutgoingDeliveries", "store.sql_webhooks.prune_outgoing_deliveries.app_error", nil, "hookId="+hookId+", err="+err.Error(), http.StatusInternalServerError)
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlWebhookStore) PermanentDeleteOutgoingDeliveriesByHook(hookId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM OutgoingWebhookDeliveries WHERE HookId = :HookId", map[string]interface{}{"HookId": hookId}); err != nil {
+			result.Err = model.NewAppError("SqlWebhookStore.PermanentDeleteOutgoingDeliveriesByHook", "store.sql_webhooks.permanent_delete_outgoing_deliveries_by_hook.app_error", nil, "hookId="+hookId+", err="+err.Error(), http.StatusInternalServerError)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlWebhookStore) AnalyticsIncomingCount(teamId string) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -465,3 +465,153 @@ func TestWebhookStoreCountOutgoing(t *testing.T) {
 		}
 	}
 }
+
+func TestWebhookStoreOutgoingDeliveries(t *testing.T) {
+	Setup()
+
+	hookId := model.NewId()
+	postId := model.NewId()
+
+	d1 := &model.OutgoingWebhookDelivery{HookId: hookId, PostId: postId, CallbackURL: "http://nowhere.com/", Attempt: 1, StatusCode: 500}
+	if result := <-store.Webhook().SaveOutgoingDelivery(d1); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	d2 := &model.OutgoingWebhookDelivery{HookId: hookId, PostId: postId, CallbackURL: "http://nowhere.com/", Attempt: 2, StatusCode: 200, CreateAt: d1.CreateAt + 1}
+	if result := <-store.Webhook().SaveOutgoingDelivery(d2); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	other := &model.OutgoingWebhookDelivery{HookId: model.NewId(), PostId: postId, CallbackURL: "http://nowhere.com/", Attempt: 1}
+	if result := <-store.Webhook().SaveOutgoingDelivery(other); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Webhook().SaveOutgoingDelivery(&model.OutgoingWebhookDelivery{HookId: hookId}); result.Err == nil {
+		t.Fatal("shouldn't save an invalid delivery")
+	}
+
+	if result := <-store.Webhook().GetOutgoingDeliveries(hookId, 0, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if deliveries := result.Data.([]*model.OutgoingWebhookDelivery); len(deliveries) != 2 || deliveries[0].Id != d2.Id || deliveries[1].Id != d1.Id {
+		t.Fatal("should return the deliveries of the hook starting with the most recent")
+	}
+
+	if result := <-store.Webhook().GetOutgoingDeliveries(hookId, 1, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if deliveries := result.Data.([]*model.OutgoingWebhookDelivery); len(deliveries) != 1 || deliveries[0].Id != d1.Id {
+		t.Fatal("should page the deliveries")
+	}
+
+	if result := <-store.Webhook().PermanentDeleteOutgoingDeliveriesByHook(hookId); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Webhook().GetOutgoingDeliveries(hookId, 0, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]*model.OutgoingWebhookDelivery)) != 0 {
+		t.Fatal("should have deleted the deliveries of the hook")
+	}
+
+	if result := <-store.Webhook().GetOutgoingDeliveries(other.HookId, 0, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]*model.OutgoingWebhookDelivery)) != 1 {
+		t.Fatal("shouldn't have deleted the deliveries of other hooks")
+	}
+}
+
+func TestWebhookStorePendingOutgoingDeliveries(t *testing.T) {
+	Setup()
+
+	hookId := model.NewId()
+	now := model.GetMillis()
+
+	due := &model.OutgoingWebhookDelivery{HookId: hookId, PostId: model.NewId(), CallbackURL: "http://nowhere.com/", Attempt: 1, NextAttemptAt: now - 1}
+	if result := <-store.Webhook().SaveOutgoingDelivery(due); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	later := &model.OutgoingWebhookDelivery{HookId: hookId, PostId: model.NewId(), CallbackURL: "http://nowhere.com/", Attempt: 2, NextAttemptAt: now + 60000}
+	if result := <-store.Webhook().SaveOutgoingDelivery(later); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	var pending []*model.OutgoingWebhookDelivery
+	if result := <-store.Webhook().GetPendingOutgoingDeliveries(now, 1000); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		pending = result.Data.([]*model.OutgoingWebhookDelivery)
+	}
+
+	found := false
+	for _, delivery := range pending {
+		if delivery.Id == later.Id {
+			t.Fatal("shouldn't return deliveries that aren't due yet")
+		} else if delivery.Id == due.Id {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("should return the deliveries that are due")
+	}
+
+	stale := *due
+	if result := <-store.Webhook().ClaimOutgoingDelivery(due, now+60000); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if !result.Data.(bool) || due.NextAttemptAt != now+60000 {
+		t.Fatal("should have claimed the delivery")
+	}
+
+	if result := <-store.Webhook().ClaimOutgoingDelivery(&stale, now+60000); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(bool) {
+		t.Fatal("shouldn't claim a delivery twice")
+	}
+
+	due.NextAttemptAt = 0
+	due.StatusCode = 200
+	if result := <-store.Webhook().UpdateOutgoingDelivery(due); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Webhook().GetOutgoingDeliveries(hookId, 0, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if deliveries := result.Data.([]*model.OutgoingWebhookDelivery); len(deliveries) != 2 || deliveries[1].StatusCode != 200 || deliveries[1].IsPending() {
+		t.Fatal("should have updated the delivery")
+	}
+}
+
+func TestWebhookStorePruneOutgoingDeliveries(t *testing.T) {
+	Setup()
+
+	hookId := model.NewId()
+	createAt := model.GetMillis()
+
+	var deliveries []*model.OutgoingWebhookDelivery
+	for i := 0; i < 5; i++ {
+		delivery := &model.OutgoingWebhookDelivery{HookId: hookId, PostId: model.NewId(), CallbackURL: "http://nowhere.com/", Attempt: 1, CreateAt: createAt + int64(i)}
+		if result := <-store.Webhook().SaveOutgoingDelivery(delivery); result.Err != nil {
+			t.Fatal(result.Err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	pending := &model.OutgoingWebhookDelivery{HookId: hookId, PostId: model.NewId(), CallbackURL: "http://nowhere.com/", Attempt: 2, CreateAt: createAt - 1, NextAttemptAt: createAt}
+	if result := <-store.Webhook().SaveOutgoingDelivery(pending); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Webhook().PruneOutgoingDeliveries(hookId, 2); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.Webhook().GetOutgoingDeliveries(hookId, 0, 10); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if remaining := result.Data.([]*model.OutgoingWebhookDelivery); len(remaining) != 3 || remaining[0].Id != deliveries[4].Id || remaining[1].Id != deliveries[3].Id || remaining[2].Id != pending.Id {
+		t.Fatal("should have kept the newest attempts and the pending ones")
+	}
+
+	if result := <-store.Webhook().PruneOutgoingDeliveries(hookId, 2); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+}
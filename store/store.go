@@ -46,6 +46,13 @@ type Store interface {
 	Status() StatusStore
 	FileInfo() FileInfoStore
 	Reaction() ReactionStore
+	UploadSession() UploadSessionStore
+	Job() JobStore
+	UserAccessToken() UserAccessTokenStore
+	Mfa() MfaStore
+	Bot() BotStore
+	Role() RoleStore
+	Scheme() SchemeStore
 	MarkSystemRanUnitTests()
 	Close()
 	DropAllTables()
@@ -58,6 +65,7 @@ type TeamStore interface {
 	Save(team *model.Team) StoreChannel
 	Update(team *model.Team) StoreChannel
 	UpdateDisplayName(name string, teamId string) StoreChannel
+	UpdateSchemeId(teamId string, schemeId string) StoreChannel
 	Get(id string) StoreChannel
 	GetByName(name string) StoreChannel
 	SearchByName(name string) StoreChannel
@@ -96,6 +104,7 @@ type ChannelStore interface {
 	InvalidateChannelByName(teamId, name string)
 	GetFromMaster(id string) StoreChannel
 	Delete(channelId string, time int64) StoreChannel
+	Restore(channelId string, time int64) StoreChannel
 	SetDeleteAt(channelId string, deleteAt int64, updateAt int64) StoreChannel
 	PermanentDeleteByTeam(teamId string) StoreChannel
 	PermanentDelete(channelId string) StoreChannel
@@ -103,6 +112,7 @@ type ChannelStore interface {
 	GetByNameIncludeDeleted(team_id string, name string, allowFromCache bool) StoreChannel
 	GetDeletedByName(team_id string, name string) StoreChannel
 	GetChannels(teamId string, userId string) StoreChannel
+	GetDeletedForUser(teamId string, userId string) StoreChannel
 	GetMoreChannels(teamId string, userId string, offset int, limit int) StoreChannel
 	GetPublicChannelsForTeam(teamId string, offset int, limit int) StoreChannel
 	GetPublicChannelsByIdsForTeam(teamId string, channelIds []string) StoreChannel
@@ -123,6 +133,8 @@ type ChannelStore interface {
 	InvalidateMemberCount(channelId string)
 	GetMemberCountFromCache(channelId string) int64
 	GetMemberCount(channelId string, allowFromCache bool) StoreChannel
+	UsersShareChannel(userId string, otherUserId string) StoreChannel
+	RenameMemberRole(oldRoleId string, newRoleId string) StoreChannel
 	GetPinnedPosts(channelId string) StoreChannel
 	RemoveMember(channelId string, userId string) StoreChannel
 	PermanentDeleteMembersByUser(userId string) StoreChannel
@@ -162,6 +174,12 @@ type PostStore interface {
 	InvalidateLastPostTimeCache(channelId string)
 	GetPostsCreatedAt(channelId string, time int64) StoreChannel
 	Overwrite(post *model.Post) StoreChannel
+	GetPostsForExport(afterId string, limit int) StoreChannel
+	GetRepliesForExport(rootId string) StoreChannel
+	GetIdsForDataRetention(endTime int64, teamId string, excludedTeamIds []string, limit int) StoreChannel
+	PermanentDeleteByIds(postIds []string) StoreChannel
+	GetPostsByIds(postIds []string) StoreChannel
+	GetPostsForIndexing(afterId string, limit int) StoreChannel
 }
 
 type UserStore interface {
@@ -212,6 +230,7 @@ type UserStore interface {
 	AnalyticsGetSystemAdminCount() StoreChannel
 	GetProfilesNotInTeam(teamId string, offset int, limit int) StoreChannel
 	GetEtagForProfilesNotInTeam(teamId string) StoreChannel
+	GetAllAfter(limit int, afterId string) StoreChannel
 }
 
 type SessionStore interface {
@@ -290,6 +309,14 @@ type WebhookStore interface {
 	PermanentDeleteOutgoingByUser(userId string) StoreChannel
 	UpdateOutgoing(hook *model.OutgoingWebhook) StoreChannel
 
+	SaveOutgoingDelivery(delivery *model.OutgoingWebhookDelivery) StoreChannel
+	UpdateOutgoingDelivery(delivery *model.OutgoingWebhookDelivery) StoreChannel
+	ClaimOutgoingDelivery(delivery *model.OutgoingWebhookDelivery, until int64) StoreChannel
+	GetOutgoingDeliveries(hookId string, offset, limit int) StoreChannel
+	GetPendingOutgoingDeliveries(before int64, limit int) StoreChannel
+	PruneOutgoingDeliveries(hookId string, keep int) StoreChannel
+	PermanentDeleteOutgoingDeliveriesByHook(hookId string) StoreChannel
+
 	AnalyticsIncomingCount(teamId string) StoreChannel
 	AnalyticsOutgoingCount(teamId string) StoreChannel
 	InvalidateWebhookCache(webhook string)
@@ -357,6 +384,9 @@ type FileInfoStore interface {
 	InvalidateFileInfosForPostCache(postId string)
 	AttachToPost(fileId string, postId string) StoreChannel
 	DeleteForPost(postId string) StoreChannel
+	GetForPostIds(postIds []string) StoreChannel
+	GetForDataRetention(endTime int64, teamId string, excludedTeamIds []string, limit int) StoreChannel
+	PermanentDeleteByIds(fileIds []string) StoreChannel
 }
 
 type ReactionStore interface {
@@ -366,4 +396,68 @@ type ReactionStore interface {
 	InvalidateCache()
 	GetForPost(postId string, allowFromCache bool) StoreChannel
 	DeleteAllWithEmojiName(emojiName string) StoreChannel
+	PermanentDeleteByPostIds(postIds []string) StoreChannel
+}
+
+type UploadSessionStore interface {
+	Save(session *model.UploadSession) StoreChannel
+	Get(id string) StoreChannel
+	UpdateOffset(id string, oldOffset int64, newOffset int64) StoreChannel
+	SetFinishing(id string, finishing bool) StoreChannel
+	GetStale(updatedBefore int64) StoreChannel
+	Delete(id string) StoreChannel
+}
+
+type JobStore interface {
+	Save(job *model.Job) StoreChannel
+	UpdateOptimistically(job *model.Job, currentStatus string) StoreChannel
+	UpdateStatus(id string, status string) StoreChannel
+	UpdateStatusOptimistically(id string, currentStatus string, newStatus string) StoreChannel
+	Get(id string) StoreChannel
+	GetAllPage(offset int, limit int) StoreChannel
+	GetAllByTypePage(jobType string, offset int, limit int) StoreChannel
+	GetAllByStatus(status string) StoreChannel
+	GetNewestJobByStatusAndType(status string, jobType string) StoreChannel
+	GetCountByStatusAndType(status string, jobType string) StoreChannel
+	Delete(id string) StoreChannel
+}
+
+type UserAccessTokenStore interface {
+	Save(token *model.UserAccessToken) StoreChannel
+	Delete(tokenId string) StoreChannel
+	DeleteAllForUser(userId string) StoreChannel
+	Get(tokenId string) StoreChannel
+	GetByToken(tokenString string) StoreChannel
+	GetByUser(userId string, offset int, limit int) StoreChannel
+}
+
+type MfaStore interface {
+	SaveRecoveryCodes(userId string, codes []*model.MfaRecoveryCode) StoreChannel
+	UseRecoveryCode(userId string, codeHash string) StoreChannel
+	SaveUsedTimeStep(userId string, timeStep int64) StoreChannel
+	DeleteAllForUser(userId string) StoreChannel
+}
+
+type BotStore interface {
+	Save(bot *model.Bot) StoreChannel
+	Update(bot *model.Bot) StoreChannel
+	Get(userId string, includeDeleted bool) StoreChannel
+	GetAll(ownerId string, includeDeleted bool, offset int, limit int) StoreChannel
+	PermanentDelete(userId string) StoreChannel
+}
+
+type RoleStore interface {
+	Save(role *model.Role) StoreChannel
+	Update(role *model.Role) StoreChannel
+	Get(roleId string) StoreChannel
+	GetAll() StoreChannel
+	PermanentDelete(roleId string) StoreChannel
+}
+
+type SchemeStore interface {
+	Save(scheme *model.Scheme) StoreChannel
+	Update(scheme *model.Scheme) StoreChannel
+	Get(schemeId string) StoreChannel
+	GetAllPage(offset int, limit int) StoreChannel
+	Delete(schemeId string, time int64) StoreChannel
 }
@@ -0,0 +1,40 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/primefour/servers/model"
+)
+
+type ReadCloseSeeker interface {
+	io.ReadCloser
+	io.Seeker
+}
+
+type FileBackend interface {
+	TestConnection() *model.AppError
+
+	Reader(path string) (ReadCloseSeeker, *model.AppError)
+	ReadFile(path string) ([]byte, *model.AppError)
+	WriteFile(fr io.Reader, path string) (int64, *model.AppError)
+	MoveFile(oldPath, newPath string) *model.AppError
+	RemoveFile(path string) *model.AppError
+
+	ListDirectory(path string) ([]string, *model.AppError)
+	RemoveDirectory(path string) *model.AppError
+}
+
+func NewFileBackend(settings *model.FileSettings) (FileBackend, *model.AppError) {
+	switch settings.DriverName {
+	case model.IMAGE_DRIVER_S3:
+		return NewS3FileBackend(settings)
+	case model.IMAGE_DRIVER_LOCAL:
+		return NewLocalFileBackend(settings.Directory), nil
+	}
+
+	return nil, model.NewAppError("NewFileBackend", "utils.file.no_driver.app_error", nil, "", http.StatusNotImplemented)
+}
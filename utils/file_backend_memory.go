@@ -0,0 +1,153 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/primefour/servers/model"
+)
+
+// MemoryFileBackend keeps every file in a map. It is meant for unit tests that exercise file handling
+// without a configured storage directory or S3 bucket.
+type MemoryFileBackend struct {
+	mutex sync.RWMutex
+	files map[string][]byte
+}
+
+func NewMemoryFileBackend() *MemoryFileBackend {
+	return &MemoryFileBackend{
+		files: make(map[string][]byte),
+	}
+}
+
+type memoryFileReader struct {
+	*bytes.Reader
+}
+
+func (r *memoryFileReader) Close() error {
+	return nil
+}
+
+func cleanMemoryFilePath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+func (b *MemoryFileBackend) TestConnection() *model.AppError {
+	return nil
+}
+
+func (b *MemoryFileBackend) Reader(path string) (ReadCloseSeeker, *model.AppError) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if f, ok := b.files[cleanMemoryFilePath(path)]; !ok {
+		return nil, model.NewAppError("Reader", "utils.file.read_file.memory.app_error", nil, "path="+path, http.StatusNotFound)
+	} else {
+		// Stored data is never modified in place, so it's safe to hand out a reader over it
+		return &memoryFileReader{bytes.NewReader(f)}, nil
+	}
+}
+
+func (b *MemoryFileBackend) ReadFile(path string) ([]byte, *model.AppError) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if f, ok := b.files[cleanMemoryFilePath(path)]; !ok {
+		return nil, model.NewAppError("ReadFile", "utils.file.read_file.memory.app_error", nil, "path="+path, http.StatusNotFound)
+	} else {
+		data := make([]byte, len(f))
+		copy(data, f)
+		return data, nil
+	}
+}
+
+func (b *MemoryFileBackend) WriteFile(fr io.Reader, path string) (int64, *model.AppError) {
+	data, err := ioutil.ReadAll(fr)
+	if err != nil {
+		return int64(len(data)), model.NewAppError("WriteFile", "utils.file.write_file.memory.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.files[cleanMemoryFilePath(path)] = data
+	return int64(len(data)), nil
+}
+
+func (b *MemoryFileBackend) MoveFile(oldPath, newPath string) *model.AppError {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	oldPath = cleanMemoryFilePath(oldPath)
+	if f, ok := b.files[oldPath]; !ok {
+		return model.NewAppError("moveFile", "utils.file.read_file.memory.app_error", nil, "path="+oldPath, http.StatusNotFound)
+	} else {
+		delete(b.files, oldPath)
+		b.files[cleanMemoryFilePath(newPath)] = f
+	}
+
+	return nil
+}
+
+func (b *MemoryFileBackend) RemoveFile(path string) *model.AppError {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	path = cleanMemoryFilePath(path)
+	if _, ok := b.files[path]; !ok {
+		return model.NewAppError("RemoveFile", "utils.file.read_file.memory.app_error", nil, "path="+path, http.StatusNotFound)
+	}
+
+	delete(b.files, path)
+	return nil
+}
+
+func (b *MemoryFileBackend) ListDirectory(dir string) ([]string, *model.AppError) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	prefix := cleanMemoryFilePath(dir)
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	seen := make(map[string]bool)
+	paths := []string{}
+	for p := range b.files {
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+
+		entry := prefix + strings.SplitN(p[len(prefix):], "/", 2)[0]
+		if !seen[entry] {
+			seen[entry] = true
+			paths = append(paths, entry)
+		}
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (b *MemoryFileBackend) RemoveDirectory(dir string) *model.AppError {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	prefix := cleanMemoryFilePath(dir) + "/"
+	for p := range b.files {
+		if strings.HasPrefix(p, prefix) {
+			delete(b.files, p)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	s3 "github.com/minio/minio-go"
+	"github.com/primefour/servers/model"
+)
+
+type S3FileBackend struct {
+	client *s3.Client
+	bucket string
+	region string
+}
+
+func NewS3FileBackend(settings *model.FileSettings) (*S3FileBackend, *model.AppError) {
+	secure := true
+	if settings.AmazonS3SSL != nil {
+		secure = *settings.AmazonS3SSL
+	}
+
+	client, err := s3.New(settings.AmazonS3Endpoint, settings.AmazonS3AccessKeyId, settings.AmazonS3SecretAccessKey, secure)
+	if err != nil {
+		return nil, model.NewAppError("NewS3FileBackend", "api.file.write_file.s3.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return &S3FileBackend{
+		client: client,
+		bucket: settings.AmazonS3Bucket,
+		region: settings.AmazonS3Region,
+	}, nil
+}
+
+func (b *S3FileBackend) TestConnection() *model.AppError {
+	exists, err := b.client.BucketExists(b.bucket)
+	if err != nil {
+		return model.NewAppError("TestFileConnection", "utils.file.test_connection.s3.connection.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if !exists {
+		if err := b.client.MakeBucket(b.bucket, b.region); err != nil {
+			return model.NewAppError("TestFileConnection", "utils.file.test_connection.s3.bucket_create.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
+
+func (b *S3FileBackend) Reader(path string) (ReadCloseSeeker, *model.AppError) {
+	minioObject, err := b.client.GetObject(b.bucket, path)
+	if err != nil {
+		return nil, model.NewAppError("Reader", "api.file.reader.s3.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return minioObject, nil
+}
+
+func (b *S3FileBackend) ReadFile(path string) ([]byte, *model.AppError) {
+	minioObject, err := b.client.GetObject(b.bucket, path)
+	if err != nil {
+		return nil, model.NewAppError("ReadFile", "api.file.read_file.s3.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	defer minioObject.Close()
+
+	if f, err := ioutil.ReadAll(minioObject); err != nil {
+		return nil, model.NewAppError("ReadFile", "api.file.read_file.s3.app_error", nil, err.Error(), http.StatusInternalServerError)
+	} else {
+		return f, nil
+	}
+}
+
+func (b *S3FileBackend) WriteFile(fr io.Reader, path string) (int64, *model.AppError) {
+	var contentType string
+	if ext := filepath.Ext(path); model.IsFileExtImage(ext) {
+		contentType = model.GetImageMimeType(ext)
+	} else {
+		contentType = "binary/octet-stream"
+	}
+
+	written, err := b.client.PutObject(b.bucket, path, fr, contentType)
+	if err != nil {
+		return written, model.NewAppError("WriteFile", "api.file.write_file.s3.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return written, nil
+}
+
+func (b *S3FileBackend) MoveFile(oldPath, newPath string) *model.AppError {
+	copyConds := s3.NewCopyConditions()
+	if err := b.client.CopyObject(b.bucket, newPath, "/"+path.Join(b.bucket, oldPath), copyConds); err != nil {
+		return model.NewAppError("moveFile", "api.file.move_file.copy_within_s3.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if err := b.client.RemoveObject(b.bucket, oldPath); err != nil {
+		return model.NewAppError("moveFile", "api.file.move_file.delete_from_s3.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func (b *S3FileBackend) RemoveFile(path string) *model.AppError {
+	if err := b.client.RemoveObject(b.bucket, path); err != nil {
+		return model.NewAppError("RemoveFile", "utils.file.remove_file.s3.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// ListDirectory returns the keys and common prefixes directly under path. S3 has no real directories, so
+// sub-directories are reported as the prefix shared by the objects inside them.
+func (b *S3FileBackend) ListDirectory(path string) ([]string, *model.AppError) {
+	prefix := strings.TrimPrefix(path, "/")
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	paths := []string{}
+	for object := range b.client.ListObjects(b.bucket, prefix, false, doneCh) {
+		if object.Err != nil {
+			return nil, model.NewAppError("ListDirectory", "utils.file.list_directory.s3.app_error", nil, object.Err.Error(), http.StatusInternalServerError)
+		}
+		paths = append(paths, strings.TrimSuffix(object.Key, "/"))
+	}
+
+	return paths, nil
+}
+
+func (b *S3FileBackend) RemoveDirectory(path string) *model.AppError {
+	prefix := strings.TrimPrefix(path, "/")
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	for object := range b.client.ListObjects(b.bucket, prefix, true, doneCh) {
+		if object.Err != nil {
+			return model.NewAppError("RemoveDirectory", "utils.file.remove_directory.s3.app_error", nil, object.Err.Error(), http.StatusInternalServerError)
+		}
+
+		if err := b.client.RemoveObject(b.bucket, object.Key); err != nil {
+			return model.NewAppError("RemoveDirectory", "utils.file.remove_directory.s3.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return nil
+}
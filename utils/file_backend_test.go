@@ -0,0 +1,134 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/primefour/servers/model"
+)
+
+func TestNewFileBackend(t *testing.T) {
+	if _, err := NewFileBackend(&model.FileSettings{DriverName: ""}); err == nil {
+		t.Fatal("should have failed without a driver")
+	}
+
+	if backend, err := NewFileBackend(&model.FileSettings{DriverName: model.IMAGE_DRIVER_LOCAL, Directory: "./data/"}); err != nil {
+		t.Fatal(err)
+	} else if _, ok := backend.(*LocalFileBackend); !ok {
+		t.Fatal("should have created a local backend")
+	}
+
+	if backend, err := NewFileBackend(&model.FileSettings{DriverName: model.IMAGE_DRIVER_S3, AmazonS3Endpoint: "localhost:9000"}); err != nil {
+		t.Fatal(err)
+	} else if _, ok := backend.(*S3FileBackend); !ok {
+		t.Fatal("should have created an S3 backend")
+	}
+}
+
+func TestLocalFileBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filebackend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	testFileBackend(t, NewLocalFileBackend(dir))
+}
+
+func TestMemoryFileBackend(t *testing.T) {
+	testFileBackend(t, NewMemoryFileBackend())
+}
+
+func testFileBackend(t *testing.T, backend FileBackend) {
+	if err := backend.TestConnection(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("some test data")
+	path := "tests/" + model.NewId() + "/file.txt"
+
+	if written, err := backend.WriteFile(bytes.NewReader(data), path); err != nil {
+		t.Fatal(err)
+	} else if written != int64(len(data)) {
+		t.Fatal("should have written all of the data")
+	}
+
+	if read, err := backend.ReadFile(path); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(read, data) {
+		t.Fatal("read data should match written data")
+	}
+
+	if reader, err := backend.Reader(path); err != nil {
+		t.Fatal(err)
+	} else {
+		if _, err := reader.Seek(5, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+
+		if read, err := ioutil.ReadAll(reader); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(read, data[5:]) {
+			t.Fatal("should have read from the seeked offset")
+		}
+
+		reader.Close()
+	}
+
+	if paths, err := backend.ListDirectory("tests"); err != nil {
+		t.Fatal(err)
+	} else if len(paths) != 1 {
+		t.Fatal("should have listed a single directory", paths)
+	}
+
+	newPath := "tests/moved/file.txt"
+	if err := backend.MoveFile(path, newPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := backend.ReadFile(path); err == nil {
+		t.Fatal("old path should no longer exist")
+	}
+
+	if read, err := backend.ReadFile(newPath); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(read, data) {
+		t.Fatal("moved data should match written data")
+	}
+
+	if paths, err := backend.ListDirectory("tests/moved"); err != nil {
+		t.Fatal(err)
+	} else if len(paths) != 1 || paths[0] != newPath {
+		t.Fatal("should have listed the moved file", paths)
+	}
+
+	if err := backend.RemoveFile(newPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := backend.ReadFile(newPath); err == nil {
+		t.Fatal("removed file should no longer exist")
+	}
+
+	if err := backend.RemoveDirectory("tests"); err != nil {
+		t.Fatal(err)
+	}
+
+	if paths, err := backend.ListDirectory("tests"); err != nil {
+		t.Fatal(err)
+	} else if len(paths) != 0 {
+		t.Fatal("directory should have been removed", paths)
+	}
+
+	if paths, err := backend.ListDirectory("missing"); err != nil {
+		t.Fatal(err)
+	} else if len(paths) != 0 {
+		t.Fatal("missing directory should be empty")
+	}
+}
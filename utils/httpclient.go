@@ -0,0 +1,126 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	UNTRUSTED_HTTP_CLIENT_TIMEOUT = 30 * time.Second
+)
+
+// reservedIPRanges are the networks that a request to a URL from a user or an integration must not reach, such as
+// the loopback and private networks, since they usually hold services that are only meant for the server itself.
+var reservedIPRanges []*net.IPNet
+
+func init() {
+	for _, cidr := range []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.0.0.0/24",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"224.0.0.0/4",
+		"240.0.0.0/4",
+		"::/128",
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+		"ff00::/8",
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		reservedIPRanges = append(reservedIPRanges, network)
+	}
+}
+
+func IsReservedIP(ip net.IP) bool {
+	for _, network := range reservedIPRanges {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var errUntrustedInternalConnection = errors.New("address forbidden, you may need to set AllowedUntrustedInternalConnections to allow an integration access to your internal network")
+
+// isAllowedInternalConnection checks the host name or the address that a request is made to against the hosts,
+// addresses and networks in AllowedUntrustedInternalConnections.
+func isAllowedInternalConnection(host string, ip net.IP) bool {
+	for _, allowed := range strings.Fields(*Cfg.ServiceSettings.AllowedUntrustedInternalConnections) {
+		if strings.EqualFold(allowed, host) {
+			return true
+		}
+
+		if _, network, err := net.ParseCIDR(allowed); err == nil && ip != nil && network.Contains(ip) {
+			return true
+		} else if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewUntrustedHTTPClient returns a client for requests to URLs that come from users or integrations, such as the
+// URLs of interactive message actions. It refuses to connect to reserved addresses unless they are allowed by
+// AllowedUntrustedInternalConnections, and doesn't follow redirects so a redirect can't get around that.
+func NewUntrustedHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   UNTRUSTED_HTTP_CLIENT_TIMEOUT,
+		KeepAlive: UNTRUSTED_HTTP_CLIENT_TIMEOUT,
+	}
+
+	dialContext := func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		// Connect to the address that was checked rather than looking the host up again
+		lastErr := errUntrustedInternalConnection
+		for _, ip := range ips {
+			if IsReservedIP(ip.IP) && !isAllowedInternalConnection(host, ip.IP) {
+				continue
+			}
+
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+
+		return nil, lastErr
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:         dialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: *Cfg.ServiceSettings.EnableInsecureOutgoingConnections},
+		},
+		Timeout: UNTRUSTED_HTTP_CLIENT_TIMEOUT,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
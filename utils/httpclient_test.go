@@ -0,0 +1,68 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsReservedIP(t *testing.T) {
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "::1", "fd00::1"} {
+		if !IsReservedIP(net.ParseIP(ip)) {
+			t.Fatal("should be reserved", ip)
+		}
+	}
+
+	for _, ip := range []string{"8.8.8.8", "172.32.0.1", "2001:4860:4860::8888"} {
+		if IsReservedIP(net.ParseIP(ip)) {
+			t.Fatal("shouldn't be reserved", ip)
+		}
+	}
+}
+
+func TestUntrustedHTTPClient(t *testing.T) {
+	TranslationsPreInit()
+	LoadConfig("config.json")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	allowed := *Cfg.ServiceSettings.AllowedUntrustedInternalConnections
+	defer func() {
+		*Cfg.ServiceSettings.AllowedUntrustedInternalConnections = allowed
+	}()
+
+	*Cfg.ServiceSettings.AllowedUntrustedInternalConnections = ""
+	if _, err := NewUntrustedHTTPClient().Get(ts.URL); err == nil {
+		t.Fatal("should refuse to connect to the loopback address")
+	}
+
+	for _, allowed := range []string{"127.0.0.1", "127.0.0.0/8", "10.0.0.0/8 127.0.0.1"} {
+		*Cfg.ServiceSettings.AllowedUntrustedInternalConnections = allowed
+		if resp, err := NewUntrustedHTTPClient().Get(ts.URL); err != nil {
+			t.Fatal("should connect to an allowed address", allowed, err)
+		} else {
+			resp.Body.Close()
+		}
+	}
+
+	if resp, err := NewUntrustedHTTPClient().Get(ts.URL + "/redirect"); err != nil {
+		t.Fatal(err)
+	} else {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			t.Fatal("shouldn't follow redirects", resp.StatusCode)
+		}
+	}
+}